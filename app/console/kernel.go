@@ -7,5 +7,8 @@ import (
 func Commands() []*cobra.Command {
 	return []*cobra.Command{
 		helloCMD(),
+		schedulerCMD(),
+		importProductsCMD(),
+		exportProductsCMD(),
 	}
 }
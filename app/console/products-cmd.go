@@ -0,0 +1,80 @@
+package console
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"phakram/app/modules"
+	"phakram/app/modules/products"
+
+	"github.com/spf13/cobra"
+)
+
+func importProductsCMD() *cobra.Command {
+	var apply bool
+
+	cmd := &cobra.Command{
+		Use:   "import-products <file.csv|file.xlsx>",
+		Short: "Imports products from a CSV or XLSX file, as a dry run unless --apply is set",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := products.ProductBulkFormatFromFileName(args[0])
+			if err != nil {
+				return err
+			}
+			content, err := os.ReadFile(args[0])
+			if err != nil {
+				return err
+			}
+
+			report, err := modules.Get().Products.Svc.ImportProductsService(cmd.Context(), &products.ImportProductsServiceRequest{
+				Format:  format,
+				Content: content,
+				DryRun:  !apply,
+			})
+			if err != nil {
+				return err
+			}
+
+			out, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				return err
+			}
+			cmd.Println(string(out))
+			if report.Failed > 0 {
+				return fmt.Errorf("%d of %d rows failed validation", report.Failed, report.TotalRows)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&apply, "apply", false, "write the changes instead of only validating them")
+	return cmd
+}
+
+func exportProductsCMD() *cobra.Command {
+	var format string
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "export-products",
+		Short: "Exports products in the import file layout",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			content, fileName, err := modules.Get().Products.Svc.ExportProductsService(cmd.Context(), format)
+			if err != nil {
+				return err
+			}
+			if output == "" {
+				output = fileName
+			}
+			if err := os.WriteFile(output, content, 0o644); err != nil {
+				return err
+			}
+			cmd.Printf("Exported products to %s\n", output)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&format, "format", products.ProductBulkFormatCSV, "file format: csv or xlsx")
+	cmd.Flags().StringVarP(&output, "out", "o", "", "output file, defaults to a timestamped name")
+	return cmd
+}
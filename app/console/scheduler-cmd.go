@@ -0,0 +1,112 @@
+package console
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"phakram/app/modules"
+	"phakram/internal/log"
+
+	"github.com/spf13/cobra"
+)
+
+// scheduledJob is one unit of background work. Jobs must be idempotent:
+// the scheduler runs all of them on every tick.
+type scheduledJob struct {
+	name string
+	run  func(ctx context.Context, now time.Time) (any, error)
+}
+
+func scheduledJobs(mod *modules.Modules) []scheduledJob {
+	return []scheduledJob{
+		{
+			name: "promotions",
+			run: func(ctx context.Context, now time.Time) (any, error) {
+				return mod.Promotions.Svc.RunSchedule(ctx, now)
+			},
+		},
+		{
+			name: "product_publishing",
+			run: func(ctx context.Context, now time.Time) (any, error) {
+				return mod.Products.Svc.RunPublishSchedule(ctx, now)
+			},
+		},
+		{
+			name: "product_prices",
+			run: func(ctx context.Context, now time.Time) (any, error) {
+				return mod.Products.Svc.RunPriceSchedule(ctx, now)
+			},
+		},
+		{
+			name: "product_recommendations",
+			run: func(ctx context.Context, now time.Time) (any, error) {
+				return mod.Products.Svc.RunRecommendations(ctx, now)
+			},
+		},
+		{
+			name: "low_stock_alerts",
+			run: func(ctx context.Context, now time.Time) (any, error) {
+				return mod.ProductStocks.Svc.RunLowStockAlerts(ctx, now)
+			},
+		},
+		{
+			name: "wishlist_alerts",
+			run: func(ctx context.Context, now time.Time) (any, error) {
+				return mod.Members.Svc.RunWishlistAlerts(ctx, now)
+			},
+		},
+	}
+}
+
+func schedulerCMD() *cobra.Command {
+	var interval time.Duration
+	var once bool
+
+	cmd := &cobra.Command{
+		Use:   "scheduler",
+		Short: "Runs scheduled background jobs",
+		Run: func(cmd *cobra.Command, _ []string) {
+			ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer cancel()
+
+			jobs := scheduledJobs(modules.Get())
+			runScheduledJobs(ctx, jobs)
+			if once {
+				return
+			}
+
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					runScheduledJobs(ctx, jobs)
+				}
+			}
+		},
+	}
+	cmd.Flags().DurationVar(&interval, "interval", time.Minute, "time between runs")
+	cmd.Flags().BoolVar(&once, "once", false, "run every job once and exit, for use with cron")
+	return cmd
+}
+
+func runScheduledJobs(ctx context.Context, jobs []scheduledJob) {
+	now := time.Now()
+	for _, job := range jobs {
+		if ctx.Err() != nil {
+			return
+		}
+		logger := log.With(log.String("job", job.name))
+		result, err := job.run(ctx, now)
+		if err != nil {
+			logger.With(log.Error(err)).Errf("scheduled job failed")
+			continue
+		}
+		logger.With(log.Any("result", result)).Infof("scheduled job finished")
+	}
+}
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"errors"
 	"strings"
 
 	"phakram/app/utils"
@@ -16,85 +17,100 @@ func (c *Controller) AuthMiddleware() gin.HandlerFunc {
 		span, log := utils.LogSpanFromGin(ctx)
 		span.AddEvent(`auth.middleware.start`)
 
-		authHeader := ctx.GetHeader("Authorization")
-		if authHeader == "" {
+		if err := c.authenticate(ctx); err != nil {
+			log.Errf(`internal: %s`, err)
 			base.Unauthorized(ctx, i18n.Unauthorized, nil)
 			ctx.Abort()
 			return
 		}
 
-		parts := strings.SplitN(authHeader, " ", 2)
-		if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
-			base.Unauthorized(ctx, i18n.Unauthorized, nil)
-			ctx.Abort()
-			return
-		}
+		span.AddEvent(`auth.middleware.success`)
+		ctx.Next()
+	}
+}
 
-		claims, err := c.svc.parseToken(parts[1], "access")
-		if err != nil {
-			log.Errf(`internal: %s`, err)
-			base.Unauthorized(ctx, i18n.Unauthorized, nil)
-			ctx.Abort()
-			return
-		}
+// OptionalAuthMiddleware identifies the caller on public routes that tailor
+// their response to a member, such as member pricing. Requests without a
+// valid token carry on anonymously.
+func (c *Controller) OptionalAuthMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		span, log := utils.LogSpanFromGin(ctx)
+		span.AddEvent(`auth.middleware.optional.start`)
 
-		memberID, err := uuid.Parse(claims.Sub)
-		if err != nil {
-			base.Unauthorized(ctx, i18n.Unauthorized, nil)
-			ctx.Abort()
-			return
+		if ctx.GetHeader("Authorization") != "" {
+			if err := c.authenticate(ctx); err != nil {
+				log.Errf(`internal: %s`, err)
+			}
 		}
 
-		sessionID, err := uuid.Parse(claims.SessionID)
-		if err != nil {
-			base.Unauthorized(ctx, i18n.Unauthorized, nil)
-			ctx.Abort()
-			return
-		}
+		span.AddEvent(`auth.middleware.optional.success`)
+		ctx.Next()
+	}
+}
 
-		if _, err := c.svc.validateAccessSession(ctx.Request.Context(), sessionID, memberID); err != nil {
-			log.Errf(`internal: %s`, err)
-			base.Unauthorized(ctx, i18n.Unauthorized, nil)
-			ctx.Abort()
-			return
-		}
+// authenticate validates the bearer token and its session and stores the
+// caller in the gin context.
+func (c *Controller) authenticate(ctx *gin.Context) error {
+	authHeader := ctx.GetHeader("Authorization")
+	if authHeader == "" {
+		return errors.New("missing authorization header")
+	}
 
-		if err := c.svc.touchAuthSessionActivity(ctx.Request.Context(), sessionID); err != nil {
-			log.Errf(`internal: %s`, err)
-			base.Unauthorized(ctx, i18n.Unauthorized, nil)
-			ctx.Abort()
-			return
-		}
+	parts := strings.SplitN(authHeader, " ", 2)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
+		return errors.New("invalid authorization header")
+	}
 
-		ctx.Set(ContextMemberIDKey, memberID)
-		ctx.Set(ContextRoleKey, claims.Role)
-		ctx.Set(ContextIsAdminKey, claims.IsAdmin)
-		ctx.Set(ContextSessionIDKey, sessionID)
+	claims, err := c.svc.parseToken(parts[1], "access")
+	if err != nil {
+		return err
+	}
 
-		actorSub := claims.Sub
-		actorIsAdmin := claims.IsAdmin
-		if claims.ActorSub != "" {
-			actorSub = claims.ActorSub
-			actorIsAdmin = claims.ActorIsAdmin
-		}
+	memberID, err := uuid.Parse(claims.Sub)
+	if err != nil {
+		return err
+	}
 
-		ctx.Set(ContextActorMemberIDKey, actorSub)
-		ctx.Set(ContextActorIsAdminKey, actorIsAdmin)
-		ctx.Set(ContextActingAsKey, claims.ActingAs)
-
-		targetID := memberID
-		actorID, actorErr := uuid.Parse(actorSub)
-		if actorErr == nil {
-			ctx.Request = ctx.Request.WithContext(WithRequestMeta(
-				ctx.Request.Context(),
-				ctx.FullPath(),
-				actorID,
-				targetID,
-				claims.ActingAs,
-			))
-		}
+	sessionID, err := uuid.Parse(claims.SessionID)
+	if err != nil {
+		return err
+	}
 
-		span.AddEvent(`auth.middleware.success`)
-		ctx.Next()
+	if _, err := c.svc.validateAccessSession(ctx.Request.Context(), sessionID, memberID); err != nil {
+		return err
+	}
+
+	if err := c.svc.touchAuthSessionActivity(ctx.Request.Context(), sessionID); err != nil {
+		return err
 	}
+
+	ctx.Set(ContextMemberIDKey, memberID)
+	ctx.Set(ContextRoleKey, claims.Role)
+	ctx.Set(ContextIsAdminKey, claims.IsAdmin)
+	ctx.Set(ContextSessionIDKey, sessionID)
+
+	actorSub := claims.Sub
+	actorIsAdmin := claims.IsAdmin
+	if claims.ActorSub != "" {
+		actorSub = claims.ActorSub
+		actorIsAdmin = claims.ActorIsAdmin
+	}
+
+	ctx.Set(ContextActorMemberIDKey, actorSub)
+	ctx.Set(ContextActorIsAdminKey, actorIsAdmin)
+	ctx.Set(ContextActingAsKey, claims.ActingAs)
+
+	targetID := memberID
+	actorID, actorErr := uuid.Parse(actorSub)
+	if actorErr == nil {
+		ctx.Request = ctx.Request.WithContext(WithRequestMeta(
+			ctx.Request.Context(),
+			ctx.FullPath(),
+			actorID,
+			targetID,
+			claims.ActingAs,
+		))
+	}
+
+	return nil
 }
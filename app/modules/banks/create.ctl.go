@@ -13,6 +13,7 @@ type CreateBankController struct {
 	NameAbbTh string `json:"name_abb_th"`
 	NameEn    string `json:"name_en"`
 	NameAbbEn string `json:"name_abb_en"`
+	Code      string `json:"code"`
 	IsActive  bool   `json:"is_active"`
 }
 
@@ -32,6 +33,7 @@ func (c *Controller) CreateBankController(ctx *gin.Context) {
 		NameAbbTh: req.NameAbbTh,
 		NameEn:    req.NameEn,
 		NameAbbEn: req.NameAbbEn,
+		Code:      req.Code,
 		IsActive:  req.IsActive,
 	}); err != nil {
 		base.HandleError(ctx, err)
@@ -16,6 +16,7 @@ type CreateBankService struct {
 	NameAbbTh string `json:"name_abb_th"`
 	NameEn    string `json:"name_en"`
 	NameAbbEn string `json:"name_abb_en"`
+	Code      string `json:"code"`
 	IsActive  bool   `json:"is_active"`
 }
 
@@ -30,6 +31,7 @@ func (s *Service) CreateBankService(ctx context.Context, req *CreateBankService)
 		NameAbbTh: req.NameAbbTh,
 		NameEn:    req.NameEn,
 		NameAbbEn: req.NameAbbEn,
+		Code:      req.Code,
 		IsActive:  req.IsActive,
 	}
 	err := s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
@@ -14,6 +14,7 @@ type InfoBankServiceResponses struct {
 	NameAbbTh string    `json:"name_abb_th"`
 	NameEn    string    `json:"name_en"`
 	NameAbbEn string    `json:"name_abb_en"`
+	Code      string    `json:"code"`
 	IsActive  bool      `json:"is_active"`
 	CreatedAt string    `json:"created_at"`
 	UpdatedAt string    `json:"updated_at"`
@@ -35,6 +36,7 @@ func (s *Service) InfoService(ctx context.Context, id uuid.UUID) (*InfoBankServi
 		NameAbbTh: data.NameAbbTh,
 		NameEn:    data.NameEn,
 		NameAbbEn: data.NameAbbEn,
+		Code:      data.Code,
 		IsActive:  data.IsActive,
 		CreatedAt: data.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 		UpdatedAt: data.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
@@ -20,6 +20,7 @@ type ListBankServiceResponses struct {
 	NameAbbTh string    `json:"name_abb_th"`
 	NameEn    string    `json:"name_en"`
 	NameAbbEn string    `json:"name_abb_en"`
+	Code      string    `json:"code"`
 	IsActive  bool      `json:"is_active"`
 	CreatedAt string    `json:"created_at"`
 	UpdatedAt string    `json:"updated_at"`
@@ -44,6 +45,7 @@ func (s *Service) ListService(ctx context.Context, req *ListBankServiceRequest)
 			NameAbbTh: item.NameAbbTh,
 			NameEn:    item.NameEn,
 			NameAbbEn: item.NameAbbEn,
+			Code:      item.Code,
 			IsActive:  item.IsActive,
 			CreatedAt: item.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 			UpdatedAt: item.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
@@ -19,6 +19,7 @@ type UpdateBankController struct {
 	NameAbbTh string `json:"name_abb_th"`
 	NameEn    string `json:"name_en"`
 	NameAbbEn string `json:"name_abb_en"`
+	Code      string `json:"code"`
 	IsActive  bool   `json:"is_active"`
 }
 
@@ -53,6 +54,7 @@ func (c *Controller) UpdateController(ctx *gin.Context) {
 		NameAbbTh: req.NameAbbTh,
 		NameEn:    req.NameEn,
 		NameAbbEn: req.NameAbbEn,
+		Code:      req.Code,
 		IsActive:  req.IsActive,
 	}); err != nil {
 		base.HandleError(ctx, err)
@@ -17,6 +17,7 @@ type UpdateBankService struct {
 	NameAbbTh string `json:"name_abb_th"`
 	NameEn    string `json:"name_en"`
 	NameAbbEn string `json:"name_abb_en"`
+	Code      string `json:"code"`
 	IsActive  bool   `json:"is_active"`
 }
 
@@ -43,6 +44,9 @@ func (s *Service) UpdateService(ctx context.Context, id uuid.UUID, req *UpdateBa
 		if req.NameAbbEn != "" {
 			data.NameAbbEn = req.NameAbbEn
 		}
+		if req.Code != "" {
+			data.Code = req.Code
+		}
 		data.IsActive = req.IsActive
 
 		if _, err := tx.NewUpdate().Model(data).Where("id = ?", data.ID).Exec(ctx); err != nil {
@@ -20,10 +20,8 @@ type ListCartItemControllerRequest struct {
 }
 
 type CreateCartItemControllerRequest struct {
-	ProductID       string `json:"product_id"`
-	Quantity        int    `json:"quantity"`
-	PricePerUnit    string `json:"price_per_unit"`
-	TotalItemAmount string `json:"total_item_amount"`
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
 }
 
 type UpdateCartItemControllerRequest = CreateCartItemControllerRequest
@@ -115,10 +113,8 @@ func (c *Controller) CreateCartItemController(ctx *gin.Context) {
 	}
 
 	if err := c.svc.CreateCartItemService(ctx.Request.Context(), cartID, &CreateCartItemServiceRequest{
-		ProductID:       productID,
-		Quantity:        req.Quantity,
-		PricePerUnit:    req.PricePerUnit,
-		TotalItemAmount: req.TotalItemAmount,
+		ProductID: productID,
+		Quantity:  req.Quantity,
 	}, requesterID, isAdmin); err != nil {
 		base.HandleError(ctx, err)
 		return
@@ -157,10 +153,8 @@ func (c *Controller) UpdateCartItemController(ctx *gin.Context) {
 	}
 
 	if err := c.svc.UpdateCartItemService(ctx.Request.Context(), cartID, itemID, &UpdateCartItemServiceRequest{
-		ProductID:       productID,
-		Quantity:        req.Quantity,
-		PricePerUnit:    req.PricePerUnit,
-		TotalItemAmount: req.TotalItemAmount,
+		ProductID: productID,
+		Quantity:  req.Quantity,
 	}, requesterID, isAdmin); err != nil {
 		base.HandleError(ctx, err)
 		return
@@ -7,6 +7,8 @@ import (
 	"phakram/app/modules/entities/ent"
 	"phakram/app/utils"
 	"phakram/app/utils/base"
+	"phakram/app/utils/flashsale"
+	"phakram/app/utils/pricelist"
 	"time"
 
 	"github.com/google/uuid"
@@ -20,10 +22,8 @@ type ListCartItemServiceRequest struct {
 }
 
 type CreateCartItemServiceRequest struct {
-	ProductID       uuid.UUID
-	Quantity        int
-	PricePerUnit    string
-	TotalItemAmount string
+	ProductID uuid.UUID
+	Quantity  int
 }
 
 type UpdateCartItemServiceRequest = CreateCartItemServiceRequest
@@ -83,7 +83,8 @@ func (s *Service) CreateCartItemService(ctx context.Context, cartID uuid.UUID, r
 	span, _ := utils.LogSpanFromContext(ctx)
 	span.AddEvent(`carts.svc.items.create.start`)
 
-	if _, err := s.ensureCartAccess(ctx, cartID, requesterID, isAdmin); err != nil {
+	cart, err := s.ensureCartAccess(ctx, cartID, requesterID, isAdmin)
+	if err != nil {
 		return err
 	}
 
@@ -91,14 +92,11 @@ func (s *Service) CreateCartItemService(ctx context.Context, cartID uuid.UUID, r
 		return errors.New("quantity must be greater than zero")
 	}
 
-	pricePerUnit, err := decimal.NewFromString(req.PricePerUnit)
-	if err != nil {
-		return err
-	}
-	totalItemAmount, err := parseTotalAmount(req.TotalItemAmount, pricePerUnit, req.Quantity)
+	pricePerUnit, err := s.resolveCartItemPrice(ctx, cart.MemberID, req.ProductID, req.Quantity)
 	if err != nil {
 		return err
 	}
+	totalItemAmount := pricePerUnit.Mul(decimal.NewFromInt(int64(req.Quantity))).Round(2)
 
 	existing := new(ent.CartItemEntity)
 	err = s.bunDB.DB().NewSelect().
@@ -151,7 +149,8 @@ func (s *Service) UpdateCartItemService(ctx context.Context, cartID uuid.UUID, i
 	span, _ := utils.LogSpanFromContext(ctx)
 	span.AddEvent(`carts.svc.items.update.start`)
 
-	if _, err := s.ensureCartAccess(ctx, cartID, requesterID, isAdmin); err != nil {
+	cart, err := s.ensureCartAccess(ctx, cartID, requesterID, isAdmin)
+	if err != nil {
 		return err
 	}
 
@@ -163,14 +162,15 @@ func (s *Service) UpdateCartItemService(ctx context.Context, cartID uuid.UUID, i
 		return errors.New("cart items not found")
 	}
 
-	pricePerUnit, err := decimal.NewFromString(req.PricePerUnit)
-	if err != nil {
-		return err
+	if req.Quantity <= 0 {
+		return errors.New("quantity must be greater than zero")
 	}
-	totalItemAmount, err := parseTotalAmount(req.TotalItemAmount, pricePerUnit, req.Quantity)
+
+	pricePerUnit, err := s.resolveCartItemPrice(ctx, cart.MemberID, req.ProductID, req.Quantity)
 	if err != nil {
 		return err
 	}
+	totalItemAmount := pricePerUnit.Mul(decimal.NewFromInt(int64(req.Quantity))).Round(2)
 
 	item.ProductID = req.ProductID
 	item.Quantity = req.Quantity
@@ -210,9 +210,38 @@ func (s *Service) DeleteCartItemService(ctx context.Context, cartID uuid.UUID, i
 	return nil
 }
 
-func parseTotalAmount(input string, pricePerUnit decimal.Decimal, quantity int) (decimal.Decimal, error) {
-	if input == "" {
-		return pricePerUnit.Mul(decimal.NewFromInt(int64(quantity))), nil
+// resolveCartItemPrice prices a cart line the way checkout will: a running
+// flash sale first, then the cart owner's price list for the quantity.
+func (s *Service) resolveCartItemPrice(ctx context.Context, memberID uuid.UUID, productID uuid.UUID, quantity int) (decimal.Decimal, error) {
+	product := new(ent.ProductEntity)
+	if err := s.bunDB.DB().NewSelect().
+		Model(product).
+		Column("id", "price").
+		Where("id = ?", productID).
+		Limit(1).
+		Scan(ctx); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return decimal.Zero, errors.New("product not found")
+		}
+		return decimal.Zero, err
+	}
+
+	productIDs := []uuid.UUID{productID}
+	offers, err := flashsale.LoadActive(ctx, s.bunDB.DB(), productIDs)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	if offer, ok := offers[productID]; ok {
+		return offer.SalePrice, nil
+	}
+
+	tier, err := pricelist.MemberTier(ctx, s.bunDB.DB(), memberID)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	priceLists, err := pricelist.Load(ctx, s.bunDB.DB(), tier.ID, productIDs)
+	if err != nil {
+		return decimal.Zero, err
 	}
-	return decimal.NewFromString(input)
+	return priceLists[productID].Resolve(product.Price, quantity).UnitPrice, nil
 }
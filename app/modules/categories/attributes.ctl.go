@@ -0,0 +1,192 @@
+package categories
+
+import (
+	"phakram/app/modules/auth"
+	"phakram/app/modules/entities/ent"
+	"phakram/app/utils"
+	"phakram/app/utils/base"
+	"phakram/config/i18n"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type categoryAttributeControllerURI struct {
+	ID          string `uri:"id"`
+	AttributeID string `uri:"attribute_id"`
+}
+
+type CreateCategoryAttributeControllerRequest struct {
+	Code         string                        `json:"code"`
+	LabelTh      string                        `json:"label_th"`
+	LabelEn      string                        `json:"label_en"`
+	DataType     string                        `json:"data_type"`
+	Unit         *string                       `json:"unit"`
+	Options      []ent.CategoryAttributeOption `json:"options"`
+	IsRequired   bool                          `json:"is_required"`
+	IsFilterable bool                          `json:"is_filterable"`
+	SortOrder    int                           `json:"sort_order"`
+}
+
+type UpdateCategoryAttributeControllerRequest struct {
+	LabelTh      *string                        `json:"label_th"`
+	LabelEn      *string                        `json:"label_en"`
+	Unit         *string                        `json:"unit"`
+	Options      *[]ent.CategoryAttributeOption `json:"options"`
+	IsRequired   *bool                          `json:"is_required"`
+	IsFilterable *bool                          `json:"is_filterable"`
+	SortOrder    *int                           `json:"sort_order"`
+}
+
+// parseCategoryAttributeURI reads the category id and, when the route has
+// one, the attribute id.
+func parseCategoryAttributeURI(ctx *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	var uri categoryAttributeControllerURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	categoryID, err := uuid.Parse(uri.ID)
+	if err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	if uri.AttributeID == "" {
+		return categoryID, uuid.Nil, true
+	}
+	attributeID, err := uuid.Parse(uri.AttributeID)
+	if err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return categoryID, attributeID, true
+}
+
+// requireAdmin returns the requesting admin, or answers 403.
+func requireAdmin(ctx *gin.Context) (*uuid.UUID, bool) {
+	memberID, ok := auth.GetMemberID(ctx)
+	if !ok || !auth.GetIsAdmin(ctx) {
+		base.Forbidden(ctx, i18n.Forbidden, nil)
+		return nil, false
+	}
+	return &memberID, true
+}
+
+func (c *Controller) ListCategoryAttributesController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`categories.ctl.attributes.list.start`)
+
+	categoryID, _, ok := parseCategoryAttributeURI(ctx)
+	if !ok {
+		return
+	}
+
+	data, err := c.svc.ListCategoryAttributesService(ctx.Request.Context(), categoryID)
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`categories.ctl.attributes.list.success`)
+	base.Success(ctx, data)
+}
+
+func (c *Controller) CreateCategoryAttributeController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`categories.ctl.attributes.create.start`)
+
+	actionBy, ok := requireAdmin(ctx)
+	if !ok {
+		return
+	}
+	categoryID, _, ok := parseCategoryAttributeURI(ctx)
+	if !ok {
+		return
+	}
+
+	var req CreateCategoryAttributeControllerRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	data, err := c.svc.CreateCategoryAttributeService(ctx.Request.Context(), categoryID, &CreateCategoryAttributeServiceRequest{
+		Code:         req.Code,
+		LabelTh:      req.LabelTh,
+		LabelEn:      req.LabelEn,
+		DataType:     req.DataType,
+		Unit:         req.Unit,
+		Options:      req.Options,
+		IsRequired:   req.IsRequired,
+		IsFilterable: req.IsFilterable,
+		SortOrder:    req.SortOrder,
+		ActionBy:     actionBy,
+	})
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`categories.ctl.attributes.create.success`)
+	base.Success(ctx, data)
+}
+
+func (c *Controller) UpdateCategoryAttributeController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`categories.ctl.attributes.update.start`)
+
+	actionBy, ok := requireAdmin(ctx)
+	if !ok {
+		return
+	}
+	categoryID, attributeID, ok := parseCategoryAttributeURI(ctx)
+	if !ok {
+		return
+	}
+
+	var req UpdateCategoryAttributeControllerRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	data, err := c.svc.UpdateCategoryAttributeService(ctx.Request.Context(), categoryID, attributeID, &UpdateCategoryAttributeServiceRequest{
+		LabelTh:      req.LabelTh,
+		LabelEn:      req.LabelEn,
+		Unit:         req.Unit,
+		Options:      req.Options,
+		IsRequired:   req.IsRequired,
+		IsFilterable: req.IsFilterable,
+		SortOrder:    req.SortOrder,
+		ActionBy:     actionBy,
+	})
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`categories.ctl.attributes.update.success`)
+	base.Success(ctx, data)
+}
+
+func (c *Controller) DeleteCategoryAttributeController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`categories.ctl.attributes.delete.start`)
+
+	actionBy, ok := requireAdmin(ctx)
+	if !ok {
+		return
+	}
+	categoryID, attributeID, ok := parseCategoryAttributeURI(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.svc.DeleteCategoryAttributeService(ctx.Request.Context(), categoryID, attributeID, actionBy); err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`categories.ctl.attributes.delete.success`)
+	base.Success(ctx, nil)
+}
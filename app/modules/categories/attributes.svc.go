@@ -0,0 +1,251 @@
+package categories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"phakram/app/modules/entities/ent"
+	"phakram/app/utils"
+	"phakram/app/utils/attributes"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+type CreateCategoryAttributeServiceRequest struct {
+	Code         string
+	LabelTh      string
+	LabelEn      string
+	DataType     string
+	Unit         *string
+	Options      []ent.CategoryAttributeOption
+	IsRequired   bool
+	IsFilterable bool
+	SortOrder    int
+	ActionBy     *uuid.UUID
+}
+
+// UpdateCategoryAttributeServiceRequest changes an attribute definition. The
+// code and data type are fixed once created since product values depend on
+// them; a nil field keeps the current value.
+type UpdateCategoryAttributeServiceRequest struct {
+	LabelTh      *string
+	LabelEn      *string
+	Unit         *string
+	Options      *[]ent.CategoryAttributeOption
+	IsRequired   *bool
+	IsFilterable *bool
+	SortOrder    *int
+	ActionBy     *uuid.UUID
+}
+
+// ListCategoryAttributesService returns the attribute schema of a category,
+// including the attributes it inherits from its ancestors.
+func (s *Service) ListCategoryAttributesService(ctx context.Context, categoryID uuid.UUID) ([]*ent.CategoryAttributeEntity, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`categories.svc.attributes.list.start`)
+
+	if err := s.ensureCategoryExists(ctx, s.bunDB.DB(), categoryID); err != nil {
+		return nil, err
+	}
+	data, err := attributes.LoadSchema(ctx, s.bunDB.DB(), categoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	span.AddEvent(`categories.svc.attributes.list.success`)
+	return data, nil
+}
+
+func (s *Service) CreateCategoryAttributeService(ctx context.Context, categoryID uuid.UUID, req *CreateCategoryAttributeServiceRequest) (*ent.CategoryAttributeEntity, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`categories.svc.attributes.create.start`)
+
+	now := time.Now()
+	data := &ent.CategoryAttributeEntity{
+		ID:           uuid.New(),
+		CategoryID:   categoryID,
+		Code:         req.Code,
+		LabelTh:      req.LabelTh,
+		LabelEn:      req.LabelEn,
+		DataType:     ent.AttributeDataTypeEnum(strings.ToLower(strings.TrimSpace(req.DataType))),
+		Unit:         req.Unit,
+		Options:      req.Options,
+		IsRequired:   req.IsRequired,
+		IsFilterable: req.IsFilterable,
+		SortOrder:    req.SortOrder,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if err := attributes.NormalizeDefinition(data); err != nil {
+		return nil, err
+	}
+
+	err := s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if err := s.ensureCategoryExists(ctx, tx, categoryID); err != nil {
+			return err
+		}
+		exists, err := tx.NewSelect().
+			Model((*ent.CategoryAttributeEntity)(nil)).
+			Where("category_id = ?", categoryID).
+			Where("code = ?", data.Code).
+			Exists(ctx)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return errors.New("attribute code already exists")
+		}
+
+		if _, err := tx.NewInsert().Model(data).Exec(ctx); err != nil {
+			return err
+		}
+		return insertCategoryAttributeAuditLog(ctx, tx, ent.AuditActionCreated, "create_category_attribute", data, req.ActionBy, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	span.AddEvent(`categories.svc.attributes.create.success`)
+	return data, nil
+}
+
+func (s *Service) UpdateCategoryAttributeService(ctx context.Context, categoryID uuid.UUID, attributeID uuid.UUID, req *UpdateCategoryAttributeServiceRequest) (*ent.CategoryAttributeEntity, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`categories.svc.attributes.update.start`)
+
+	now := time.Now()
+	data := new(ent.CategoryAttributeEntity)
+	err := s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if err := tx.NewSelect().
+			Model(data).
+			Where("id = ?", attributeID).
+			Where("category_id = ?", categoryID).
+			For("UPDATE").
+			Scan(ctx); err != nil {
+			return err
+		}
+
+		if req.LabelTh != nil {
+			data.LabelTh = *req.LabelTh
+		}
+		if req.LabelEn != nil {
+			data.LabelEn = *req.LabelEn
+		}
+		if req.Unit != nil {
+			data.Unit = req.Unit
+		}
+		if req.Options != nil {
+			data.Options = *req.Options
+		}
+		if req.IsRequired != nil {
+			data.IsRequired = *req.IsRequired
+		}
+		if req.IsFilterable != nil {
+			data.IsFilterable = *req.IsFilterable
+		}
+		if req.SortOrder != nil {
+			data.SortOrder = *req.SortOrder
+		}
+		if err := attributes.NormalizeDefinition(data); err != nil {
+			return err
+		}
+
+		// An enum option can only go away once no product uses it.
+		if req.Options != nil && data.DataType == ent.AttributeEnum {
+			values := make([]string, 0, len(data.Options))
+			for _, option := range data.Options {
+				values = append(values, option.Value)
+			}
+			inUse, err := tx.NewSelect().
+				Model((*ent.ProductAttributeValueEntity)(nil)).
+				Where("attribute_id = ?", data.ID).
+				Where("value_text NOT IN (?)", bun.In(values)).
+				Exists(ctx)
+			if err != nil {
+				return err
+			}
+			if inUse {
+				return errors.New("attribute option is in use")
+			}
+		}
+
+		data.UpdatedAt = now
+		if _, err := tx.NewUpdate().
+			Model(data).
+			Column("label_th", "label_en", "unit", "options", "is_required", "is_filterable", "sort_order", "updated_at").
+			WherePK().
+			Exec(ctx); err != nil {
+			return err
+		}
+		return insertCategoryAttributeAuditLog(ctx, tx, ent.AuditActionUpdated, "update_category_attribute", data, req.ActionBy, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	span.AddEvent(`categories.svc.attributes.update.success`)
+	return data, nil
+}
+
+// DeleteCategoryAttributeService removes an attribute definition together
+// with the product values stored for it.
+func (s *Service) DeleteCategoryAttributeService(ctx context.Context, categoryID uuid.UUID, attributeID uuid.UUID, actionBy *uuid.UUID) error {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`categories.svc.attributes.delete.start`)
+
+	now := time.Now()
+	err := s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		data := new(ent.CategoryAttributeEntity)
+		if _, err := tx.NewDelete().
+			Model(data).
+			Where("id = ?", attributeID).
+			Where("category_id = ?", categoryID).
+			Returning("*").
+			Exec(ctx, data); err != nil {
+			return err
+		}
+		if data.ID == uuid.Nil {
+			return sql.ErrNoRows
+		}
+		return insertCategoryAttributeAuditLog(ctx, tx, ent.AuditActionDeleted, "delete_category_attribute", data, actionBy, now)
+	})
+	if err != nil {
+		return err
+	}
+
+	span.AddEvent(`categories.svc.attributes.delete.success`)
+	return nil
+}
+
+func (s *Service) ensureCategoryExists(ctx context.Context, db bun.IDB, categoryID uuid.UUID) error {
+	exists, err := db.NewSelect().
+		Model((*ent.CategoryEntity)(nil)).
+		Where("id = ?", categoryID).
+		Exists(ctx)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func insertCategoryAttributeAuditLog(ctx context.Context, tx bun.Tx, action ent.AuditActionEnum, actionType string, data *ent.CategoryAttributeEntity, actionBy *uuid.UUID, now time.Time) error {
+	auditLog := &ent.AuditLogEntity{
+		ID:           uuid.New(),
+		Action:       action,
+		ActionType:   actionType,
+		ActionID:     data.ID,
+		ActionBy:     actionBy,
+		Status:       ent.StatusAuditSuccesses,
+		ActionDetail: "Category " + data.CategoryID.String() + " attribute " + data.Code,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	_, err := tx.NewInsert().Model(auditLog).Exec(ctx)
+	return err
+}
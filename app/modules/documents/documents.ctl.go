@@ -0,0 +1,168 @@
+package documents
+
+import (
+	"phakram/app/modules/auth"
+	"phakram/app/utils"
+	"phakram/app/utils/base"
+	"phakram/config/i18n"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type OrderDocumentURIRequest struct {
+	OrderID    string `uri:"id"`
+	DocumentID string `uri:"document_id"`
+}
+
+type IssueTaxInvoiceControllerRequest struct {
+	BuyerName    string `json:"buyer_name"`
+	BuyerTaxID   string `json:"buyer_tax_id"`
+	BuyerBranch  string `json:"buyer_branch"`
+	BuyerAddress string `json:"buyer_address"`
+}
+
+func (c *Controller) ListOrderDocumentsController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`documents.ctl.list.start`)
+
+	orderID, _, ok := parseOrderDocumentURI(ctx, false)
+	if !ok {
+		return
+	}
+
+	requesterID, hasRequester := auth.GetMemberID(ctx)
+	isAdmin := auth.GetIsAdmin(ctx)
+	if !isAdmin && !hasRequester {
+		base.Forbidden(ctx, i18n.Forbidden, nil)
+		return
+	}
+
+	data, err := c.svc.ListOrderDocumentsService(ctx.Request.Context(), orderID, requesterID, isAdmin)
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`documents.ctl.list.success`)
+	base.Success(ctx, data)
+}
+
+func (c *Controller) InfoOrderDocumentController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`documents.ctl.info.start`)
+
+	orderID, documentID, ok := parseOrderDocumentURI(ctx, true)
+	if !ok {
+		return
+	}
+
+	requesterID, hasRequester := auth.GetMemberID(ctx)
+	isAdmin := auth.GetIsAdmin(ctx)
+	if !isAdmin && !hasRequester {
+		base.Forbidden(ctx, i18n.Forbidden, nil)
+		return
+	}
+
+	data, err := c.svc.InfoOrderDocumentService(ctx.Request.Context(), orderID, documentID, requesterID, isAdmin)
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`documents.ctl.info.success`)
+	base.Success(ctx, data)
+}
+
+func (c *Controller) IssueReceiptController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`documents.ctl.receipt.start`)
+
+	orderID, _, ok := parseOrderDocumentURI(ctx, false)
+	if !ok {
+		return
+	}
+
+	requesterID, hasRequester := auth.GetMemberID(ctx)
+	isAdmin := auth.GetIsAdmin(ctx)
+	if !isAdmin && !hasRequester {
+		base.Forbidden(ctx, i18n.Forbidden, nil)
+		return
+	}
+
+	data, err := c.svc.IssueOrderDocumentService(ctx.Request.Context(), &IssueOrderDocumentServiceRequest{
+		OrderID:      orderID,
+		DocumentType: documentTypeReceipt,
+	}, requesterID, isAdmin)
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`documents.ctl.receipt.success`)
+	base.Success(ctx, data)
+}
+
+func (c *Controller) IssueTaxInvoiceController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`documents.ctl.tax_invoice.start`)
+
+	orderID, _, ok := parseOrderDocumentURI(ctx, false)
+	if !ok {
+		return
+	}
+
+	var req IssueTaxInvoiceControllerRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	requesterID, hasRequester := auth.GetMemberID(ctx)
+	isAdmin := auth.GetIsAdmin(ctx)
+	if !isAdmin && !hasRequester {
+		base.Forbidden(ctx, i18n.Forbidden, nil)
+		return
+	}
+
+	data, err := c.svc.IssueOrderDocumentService(ctx.Request.Context(), &IssueOrderDocumentServiceRequest{
+		OrderID:      orderID,
+		DocumentType: documentTypeTaxInvoice,
+		BuyerName:    req.BuyerName,
+		BuyerTaxID:   req.BuyerTaxID,
+		BuyerBranch:  req.BuyerBranch,
+		BuyerAddress: req.BuyerAddress,
+	}, requesterID, isAdmin)
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`documents.ctl.tax_invoice.success`)
+	base.Success(ctx, data)
+}
+
+func parseOrderDocumentURI(ctx *gin.Context, withDocument bool) (uuid.UUID, uuid.UUID, bool) {
+	var uri OrderDocumentURIRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	orderID, err := uuid.Parse(uri.OrderID)
+	if err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	if !withDocument {
+		return orderID, uuid.Nil, true
+	}
+
+	documentID, err := uuid.Parse(uri.DocumentID)
+	if err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return orderID, documentID, true
+}
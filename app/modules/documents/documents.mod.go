@@ -0,0 +1,81 @@
+package documents
+
+import (
+	"phakram/internal/database"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type Config struct {
+	SellerName    string
+	SellerTaxNo   string
+	SellerBranch  string
+	SellerAddress string
+	SellerPhone   string
+	FontDir       string
+}
+
+type RailwayConfig struct {
+	URL            string
+	ServiceRoleKey string
+	PublicBucket   string
+	PrivateBucket  string
+}
+
+type Module struct {
+	Svc *Service
+	Ctl *Controller
+}
+
+type (
+	Service struct {
+		tracer         trace.Tracer
+		bunDB          *database.DatabaseService
+		conf           *Config
+		railwayStorage *railwayStorageClient
+	}
+
+	Controller struct {
+		tracer trace.Tracer
+		svc    *Service
+	}
+)
+
+type Options struct {
+	tracer      trace.Tracer
+	bunDB       *database.DatabaseService
+	conf        *Config
+	railwayConf RailwayConfig
+}
+
+func New(bunDB *database.DatabaseService, conf *Config, railwayConf RailwayConfig) *Module {
+	tracer := otel.Tracer("documents_module")
+	svc := newService(&Options{
+		tracer:      tracer,
+		bunDB:       bunDB,
+		conf:        conf,
+		railwayConf: railwayConf,
+	})
+
+	return &Module{
+		Svc: svc,
+		Ctl: newController(tracer, svc),
+	}
+}
+
+func newService(opt *Options) *Service {
+	return &Service{
+		tracer:         opt.tracer,
+		bunDB:          opt.bunDB,
+		conf:           opt.conf,
+		railwayStorage: newRailwayStorageClient(opt.railwayConf),
+	}
+}
+
+func newController(trace trace.Tracer, svc *Service) *Controller {
+	return &Controller{
+		tracer: trace,
+		svc:    svc,
+	}
+}
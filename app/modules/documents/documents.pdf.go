@@ -0,0 +1,217 @@
+package documents
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	thaidate "phakram/app/utils/thai-date"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	documentFontFamily      = "Sarabun"
+	documentFontRegularFile = "Sarabun-Regular.ttf"
+	documentFontBoldFile    = "Sarabun-Bold.ttf"
+)
+
+//go:embed fonts
+var embeddedFonts embed.FS
+
+// bundledFonts is where fonts shipped with the binary are read from.
+var bundledFonts fs.FS = embeddedFonts
+
+type documentFonts struct {
+	Regular []byte
+	Bold    []byte
+}
+
+// loadDocumentFonts prefers the configured font directory and falls back to
+// fonts embedded at build time. Thai text cannot be rendered without one.
+func (s *Service) loadDocumentFonts() (*documentFonts, error) {
+	readFont := func(name string) []byte {
+		if s.conf != nil && strings.TrimSpace(s.conf.FontDir) != "" {
+			if data, err := os.ReadFile(filepath.Join(s.conf.FontDir, name)); err == nil {
+				return data
+			}
+		}
+		if data, err := fs.ReadFile(bundledFonts, "fonts/"+name); err == nil {
+			return data
+		}
+		return nil
+	}
+
+	fonts := &documentFonts{
+		Regular: readFont(documentFontRegularFile),
+		Bold:    readFont(documentFontBoldFile),
+	}
+	if len(fonts.Regular) == 0 {
+		return nil, errors.New("document font is not configured")
+	}
+	if len(fonts.Bold) == 0 {
+		fonts.Bold = fonts.Regular
+	}
+
+	return fonts, nil
+}
+
+// CheckFonts reports whether receipts and tax invoices can be rendered, so a
+// missing font shows up when the server starts rather than on the first
+// document.
+func (s *Service) CheckFonts() error {
+	_, err := s.loadDocumentFonts()
+	return err
+}
+
+func formatDocumentAmount(amount decimal.Decimal) string {
+	value := amount.StringFixed(2)
+	sign := ""
+	if strings.HasPrefix(value, "-") {
+		sign = "-"
+		value = value[1:]
+	}
+
+	parts := strings.SplitN(value, ".", 2)
+	integer := parts[0]
+	var grouped strings.Builder
+	for i, r := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			grouped.WriteByte(',')
+		}
+		grouped.WriteRune(r)
+	}
+
+	return sign + grouped.String() + "." + parts[1]
+}
+
+func formatBranch(branch string) string {
+	if branch == "" {
+		return ""
+	}
+	if branch == headOfficeBranch {
+		return "สำนักงานใหญ่"
+	}
+	return "สาขาที่ " + branch
+}
+
+func renderDocumentPDF(w io.Writer, fonts *documentFonts, content *documentContent) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.AddUTF8FontFromBytes(documentFontFamily, "", fonts.Regular)
+	pdf.AddUTF8FontFromBytes(documentFontFamily, "B", fonts.Bold)
+	pdf.AddPage()
+
+	const pageWidth = 180.0
+
+	// Seller
+	pdf.SetFont(documentFontFamily, "B", 16)
+	pdf.CellFormat(110, 8, content.Seller.SellerName, "", 0, "L", false, 0, "")
+	pdf.CellFormat(70, 8, content.Title, "", 1, "R", false, 0, "")
+	pdf.SetFont(documentFontFamily, "", 11)
+	pdf.CellFormat(110, 6, content.Seller.SellerAddress, "", 0, "L", false, 0, "")
+	pdf.CellFormat(70, 6, content.TitleEn, "", 1, "R", false, 0, "")
+	if content.Seller.SellerTaxNo != "" {
+		sellerTax := "เลขประจำตัวผู้เสียภาษี " + content.Seller.SellerTaxNo
+		if branch := formatBranch(content.Seller.SellerBranch); branch != "" {
+			sellerTax += " (" + branch + ")"
+		}
+		pdf.CellFormat(pageWidth, 6, sellerTax, "", 1, "L", false, 0, "")
+	}
+	if content.Seller.SellerPhone != "" {
+		pdf.CellFormat(pageWidth, 6, "โทร "+content.Seller.SellerPhone, "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	// Document and buyer
+	pdf.CellFormat(110, 6, "ลูกค้า: "+content.BuyerName, "", 0, "L", false, 0, "")
+	pdf.CellFormat(70, 6, "เลขที่: "+content.DocumentNo, "", 1, "R", false, 0, "")
+	buyerTax := ""
+	if content.BuyerTaxID != "" {
+		buyerTax = "เลขประจำตัวผู้เสียภาษี " + content.BuyerTaxID
+		if branch := formatBranch(content.BuyerBranch); branch != "" {
+			buyerTax += " (" + branch + ")"
+		}
+	}
+	pdf.CellFormat(110, 6, buyerTax, "", 0, "L", false, 0, "")
+	pdf.CellFormat(70, 6, "วันที่: "+thaidate.GetThaiDateFromTime(content.IssuedAt), "", 1, "R", false, 0, "")
+	pdf.CellFormat(110, 6, "", "", 0, "L", false, 0, "")
+	pdf.CellFormat(70, 6, "อ้างอิงคำสั่งซื้อ: "+content.OrderNo, "", 1, "R", false, 0, "")
+	if content.BuyerAddress != "" {
+		pdf.MultiCell(110, 6, "ที่อยู่: "+content.BuyerAddress, "", "L", false)
+	}
+	pdf.Ln(4)
+
+	// Items
+	columns := []struct {
+		title string
+		width float64
+		align string
+	}{
+		{"ลำดับ", 15, "C"},
+		{"รายการ", 85, "L"},
+		{"จำนวน", 20, "R"},
+		{"ราคาต่อหน่วย", 30, "R"},
+		{"จำนวนเงิน", 30, "R"},
+	}
+	pdf.SetFont(documentFontFamily, "B", 11)
+	for _, column := range columns {
+		pdf.CellFormat(column.width, 8, column.title, "1", 0, "C", false, 0, "")
+	}
+	pdf.Ln(-1)
+	pdf.SetFont(documentFontFamily, "", 11)
+	for i, line := range content.Lines {
+		values := []string{
+			fmt.Sprintf("%d", i+1),
+			line.Name,
+			fmt.Sprintf("%d", line.Quantity),
+			formatDocumentAmount(line.PricePerUnit),
+			formatDocumentAmount(line.Amount),
+		}
+		for j, column := range columns {
+			pdf.CellFormat(column.width, 7, values[j], "LR", 0, column.align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+	pdf.CellFormat(pageWidth, 0, "", "T", 1, "", false, 0, "")
+	pdf.Ln(2)
+
+	// Totals
+	totals := [][2]string{
+		{"รวมเป็นเงิน", formatDocumentAmount(content.Subtotal)},
+	}
+	if content.Discount.GreaterThan(decimal.Zero) {
+		totals = append(totals, [2]string{"ส่วนลด", formatDocumentAmount(content.Discount)})
+	}
+	if content.CODFee.GreaterThan(decimal.Zero) {
+		totals = append(totals, [2]string{"ค่าธรรมเนียมเก็บเงินปลายทาง", formatDocumentAmount(content.CODFee)})
+	}
+	if content.ZeroRated.GreaterThan(decimal.Zero) {
+		totals = append(totals, [2]string{"มูลค่าสินค้าภาษีอัตราศูนย์", formatDocumentAmount(content.ZeroRated)})
+	}
+	if content.Exempt.GreaterThan(decimal.Zero) {
+		totals = append(totals, [2]string{"มูลค่าสินค้าที่ได้รับยกเว้นภาษี", formatDocumentAmount(content.Exempt)})
+	}
+	totals = append(totals,
+		[2]string{"มูลค่าก่อนภาษีมูลค่าเพิ่ม", formatDocumentAmount(content.VATBase)},
+		[2]string{fmt.Sprintf("ภาษีมูลค่าเพิ่ม %s%%", vatRatePercent.String()), formatDocumentAmount(content.VAT)},
+	)
+	for _, total := range totals {
+		pdf.CellFormat(150, 7, total[0], "", 0, "R", false, 0, "")
+		pdf.CellFormat(30, 7, total[1], "", 1, "R", false, 0, "")
+	}
+	pdf.SetFont(documentFontFamily, "B", 12)
+	pdf.CellFormat(150, 8, "จำนวนเงินรวมทั้งสิ้น", "", 0, "R", false, 0, "")
+	pdf.CellFormat(30, 8, formatDocumentAmount(content.Total), "TB", 1, "R", false, 0, "")
+
+	if err := pdf.Error(); err != nil {
+		return err
+	}
+	return pdf.Output(w)
+}
@@ -0,0 +1,100 @@
+package documents
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func writeFonts(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func Test_loadDocumentFonts(t *testing.T) {
+	tests := []struct {
+		name        string
+		dir         map[string]string
+		bundled     fstest.MapFS
+		wantRegular string
+		wantBold    string
+		wantErr     bool
+	}{
+		{
+			name:        "font directory",
+			dir:         map[string]string{documentFontRegularFile: "dir-regular", documentFontBoldFile: "dir-bold"},
+			wantRegular: "dir-regular",
+			wantBold:    "dir-bold",
+		},
+		{
+			name:        "bold falls back to regular",
+			dir:         map[string]string{documentFontRegularFile: "dir-regular"},
+			wantRegular: "dir-regular",
+			wantBold:    "dir-regular",
+		},
+		{
+			name:        "font directory wins over bundled fonts",
+			dir:         map[string]string{documentFontRegularFile: "dir-regular"},
+			bundled:     fstest.MapFS{"fonts/" + documentFontRegularFile: {Data: []byte("bundled-regular")}, "fonts/" + documentFontBoldFile: {Data: []byte("bundled-bold")}},
+			wantRegular: "dir-regular",
+			wantBold:    "bundled-bold",
+		},
+		{
+			name:        "bundled fonts without a font directory",
+			bundled:     fstest.MapFS{"fonts/" + documentFontRegularFile: {Data: []byte("bundled-regular")}},
+			wantRegular: "bundled-regular",
+			wantBold:    "bundled-regular",
+		},
+		{
+			name:    "bold alone is not enough",
+			dir:     map[string]string{documentFontBoldFile: "dir-bold"},
+			wantErr: true,
+		},
+		{
+			name:    "no font anywhere",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundled := tt.bundled
+			if bundled == nil {
+				bundled = fstest.MapFS{"fonts/README.md": {Data: []byte("# Document fonts")}}
+			}
+			previous := bundledFonts
+			bundledFonts = bundled
+			t.Cleanup(func() { bundledFonts = previous })
+
+			conf := &Config{}
+			if tt.dir != nil {
+				conf.FontDir = writeFonts(t, tt.dir)
+			}
+			s := &Service{conf: conf}
+
+			got, err := s.loadDocumentFonts()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadDocumentFonts() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if err := s.CheckFonts(); err == nil {
+					t.Errorf("CheckFonts() error = nil, want an error")
+				}
+				return
+			}
+			if string(got.Regular) != tt.wantRegular {
+				t.Errorf("loadDocumentFonts() regular = %q, want %q", got.Regular, tt.wantRegular)
+			}
+			if string(got.Bold) != tt.wantBold {
+				t.Errorf("loadDocumentFonts() bold = %q, want %q", got.Bold, tt.wantBold)
+			}
+		})
+	}
+}
@@ -0,0 +1,573 @@
+package documents
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"phakram/app/modules/entities/ent"
+	"phakram/app/utils"
+	"phakram/app/utils/sales"
+	"phakram/app/utils/sequence"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/uptrace/bun"
+)
+
+const (
+	documentTypeReceipt    = "receipt"
+	documentTypeTaxInvoice = "tax_invoice"
+
+	headOfficeBranch = "00000"
+)
+
+var vatRatePercent = decimal.NewFromInt(7)
+
+type orderDocumentRecord struct {
+	bun.BaseModel `bun:"table:order_documents"`
+
+	ID             uuid.UUID       `bun:"id,pk,type:uuid"`
+	OrderID        uuid.UUID       `bun:"order_id,type:uuid,notnull"`
+	DocumentType   string          `bun:"document_type,notnull"`
+	DocumentNo     string          `bun:"document_no,notnull"`
+	BuyerName      string          `bun:"buyer_name"`
+	BuyerTaxID     string          `bun:"buyer_tax_id"`
+	BuyerBranch    string          `bun:"buyer_branch"`
+	BuyerAddress   string          `bun:"buyer_address"`
+	SubtotalAmount decimal.Decimal `bun:"subtotal_amount"`
+	DiscountAmount decimal.Decimal `bun:"discount_amount"`
+	VATBaseAmount  decimal.Decimal `bun:"vat_base_amount"`
+	VATAmount      decimal.Decimal `bun:"vat_amount"`
+	TotalAmount    decimal.Decimal `bun:"total_amount"`
+	FileID         *uuid.UUID      `bun:"file_id,type:uuid"`
+	IssuedBy       *uuid.UUID      `bun:"issued_by,type:uuid"`
+	IssuedAt       time.Time       `bun:"issued_at"`
+	CreatedAt      time.Time       `bun:"created_at"`
+	UpdatedAt      time.Time       `bun:"updated_at"`
+}
+
+type IssueOrderDocumentServiceRequest struct {
+	OrderID      uuid.UUID
+	DocumentType string
+	BuyerName    string
+	BuyerTaxID   string
+	BuyerBranch  string
+	BuyerAddress string
+}
+
+type OrderDocumentItem struct {
+	ID             uuid.UUID       `json:"id"`
+	OrderID        uuid.UUID       `json:"order_id"`
+	DocumentType   string          `json:"document_type"`
+	DocumentNo     string          `json:"document_no"`
+	BuyerName      string          `json:"buyer_name"`
+	BuyerTaxID     string          `json:"buyer_tax_id,omitempty"`
+	BuyerBranch    string          `json:"buyer_branch,omitempty"`
+	BuyerAddress   string          `json:"buyer_address,omitempty"`
+	SubtotalAmount decimal.Decimal `json:"subtotal_amount"`
+	DiscountAmount decimal.Decimal `json:"discount_amount"`
+	VATBaseAmount  decimal.Decimal `json:"vat_base_amount"`
+	VATAmount      decimal.Decimal `json:"vat_amount"`
+	TotalAmount    decimal.Decimal `json:"total_amount"`
+	FileURL        string          `json:"file_url"`
+	IssuedAt       time.Time       `json:"issued_at"`
+}
+
+type documentLine struct {
+	Name         string
+	Quantity     int
+	PricePerUnit decimal.Decimal
+	Amount       decimal.Decimal
+}
+
+type documentLineRow struct {
+	ProductNameTh   string          `bun:"name_th"`
+	ProductNameEn   string          `bun:"name_en"`
+	ProductNo       string          `bun:"product_no"`
+	Quantity        int             `bun:"quantity"`
+	PricePerUnit    decimal.Decimal `bun:"price_per_unit"`
+	TotalItemAmount decimal.Decimal `bun:"total_item_amount"`
+}
+
+type documentContent struct {
+	Title        string
+	TitleEn      string
+	DocumentNo   string
+	IssuedAt     time.Time
+	OrderNo      string
+	Seller       Config
+	BuyerName    string
+	BuyerTaxID   string
+	BuyerBranch  string
+	BuyerAddress string
+	Lines        []*documentLine
+	Subtotal     decimal.Decimal
+	Discount     decimal.Decimal
+	CODFee       decimal.Decimal
+	ZeroRated    decimal.Decimal
+	Exempt       decimal.Decimal
+	VATBase      decimal.Decimal
+	VAT          decimal.Decimal
+	Total        decimal.Decimal
+}
+
+func normalizeDocumentType(documentType string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(documentType)) {
+	case documentTypeReceipt:
+		return documentTypeReceipt, nil
+	case documentTypeTaxInvoice, "tax-invoice":
+		return documentTypeTaxInvoice, nil
+	default:
+		return "", errors.New("invalid document type")
+	}
+}
+
+func documentNoPrefix(documentType string) string {
+	if documentType == documentTypeTaxInvoice {
+		return "INV"
+	}
+	return "RC"
+}
+
+// isValidThaiTaxID checks the 13-digit Revenue Department tax ID checksum.
+func isValidThaiTaxID(taxID string) bool {
+	if len(taxID) != 13 {
+		return false
+	}
+
+	sum := 0
+	for i := 0; i < 13; i++ {
+		if taxID[i] < '0' || taxID[i] > '9' {
+			return false
+		}
+		if i < 12 {
+			sum += int(taxID[i]-'0') * (13 - i)
+		}
+	}
+
+	return (11-sum%11)%10 == int(taxID[12]-'0')
+}
+
+func normalizeBuyerBranch(branch string) (string, error) {
+	trimmed := strings.TrimSpace(branch)
+	if trimmed == "" || trimmed == "สำนักงานใหญ่" || strings.EqualFold(trimmed, "head office") {
+		return headOfficeBranch, nil
+	}
+	if len(trimmed) > 5 {
+		return "", errors.New("invalid buyer branch")
+	}
+	for _, r := range trimmed {
+		if r < '0' || r > '9' {
+			return "", errors.New("invalid buyer branch")
+		}
+	}
+
+	return fmt.Sprintf("%05s", trimmed), nil
+}
+
+// calculateVATFromInclusive splits a VAT-inclusive amount into base and VAT.
+func calculateVATFromInclusive(total decimal.Decimal) (decimal.Decimal, decimal.Decimal) {
+	hundred := decimal.NewFromInt(100)
+	base := total.Mul(hundred).Div(hundred.Add(vatRatePercent)).Round(2)
+	return base, total.Sub(base).Round(2)
+}
+
+func (s *Service) getOrderForDocument(ctx context.Context, orderID uuid.UUID, requesterID uuid.UUID, isAdmin bool) (*ent.OrderEntity, error) {
+	order := new(ent.OrderEntity)
+	if err := s.bunDB.DB().NewSelect().Model(order).Where("id = ?", orderID).Limit(1).Scan(ctx); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("order not found")
+		}
+		return nil, err
+	}
+	if !isAdmin && order.MemberID != requesterID {
+		return nil, errors.New("forbidden")
+	}
+
+	return order, nil
+}
+
+func (s *Service) listDocumentLines(ctx context.Context, orderID uuid.UUID) ([]*documentLine, error) {
+	rows := make([]*documentLineRow, 0)
+	if err := s.bunDB.DB().NewSelect().
+		TableExpr("order_items AS oi").
+		Join("LEFT JOIN products AS p ON p.id = oi.product_id").
+		ColumnExpr("COALESCE(p.name_th, '') AS name_th").
+		ColumnExpr("COALESCE(p.name_en, '') AS name_en").
+		ColumnExpr("COALESCE(p.product_no, '') AS product_no").
+		ColumnExpr("oi.quantity AS quantity").
+		ColumnExpr("oi.price_per_unit AS price_per_unit").
+		ColumnExpr("oi.total_item_amount AS total_item_amount").
+		Where("oi.order_id = ?", orderID).
+		OrderExpr("oi.created_at ASC").
+		Scan(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	lines := make([]*documentLine, 0, len(rows))
+	for _, row := range rows {
+		name := strings.TrimSpace(row.ProductNameTh)
+		if name == "" {
+			name = strings.TrimSpace(row.ProductNameEn)
+		}
+		if row.ProductNo != "" {
+			name = fmt.Sprintf("%s (%s)", name, row.ProductNo)
+		}
+		lines = append(lines, &documentLine{
+			Name:         name,
+			Quantity:     row.Quantity,
+			PricePerUnit: row.PricePerUnit,
+			Amount:       row.TotalItemAmount,
+		})
+	}
+
+	return lines, nil
+}
+
+func (s *Service) getDefaultBuyerName(ctx context.Context, order *ent.OrderEntity) (string, error) {
+	address := new(ent.MemberAddressEntity)
+	err := s.bunDB.DB().NewSelect().Model(address).Where("id = ?", order.AddressID).Limit(1).Scan(ctx)
+	if err == nil {
+		name := strings.TrimSpace(address.FirstName + " " + address.LastName)
+		if name != "" {
+			return name, nil
+		}
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return "", err
+	}
+
+	member := new(ent.MemberEntity)
+	if err := s.bunDB.DB().NewSelect().Model(member).Where("id = ?", order.MemberID).Limit(1).Scan(ctx); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	return strings.TrimSpace(member.FirstnameTh + " " + member.LastnameTh), nil
+}
+
+func (s *Service) nextDocumentNoInTx(ctx context.Context, tx bun.Tx, documentType string, issuedAt time.Time) (string, error) {
+	// Running numbers reset monthly; the period is written in the Buddhist era
+	// as printed on Thai tax documents.
+	period := fmt.Sprintf("%04d%02d", issuedAt.Year()+543, int(issuedAt.Month()))
+	lastNo, err := sequence.NextInTx(ctx, tx, documentType, period, issuedAt)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s-%s-%05d", documentNoPrefix(documentType), period, lastNo), nil
+}
+
+func (s *Service) IssueOrderDocumentService(ctx context.Context, req *IssueOrderDocumentServiceRequest, requesterID uuid.UUID, isAdmin bool) (*OrderDocumentItem, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`documents.svc.issue.start`)
+
+	documentType, err := normalizeDocumentType(req.DocumentType)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := s.getOrderForDocument(ctx, req.OrderID, requesterID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+	// Receipts and tax invoices follow the money, not the order status: a
+	// cash-on-delivery order ships before anything is collected.
+	paid, err := sales.IsOrderPaid(ctx, s.bunDB.DB(), order.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !paid {
+		return nil, errors.New("document is available only for paid orders")
+	}
+
+	existing := new(orderDocumentRecord)
+	err = s.bunDB.DB().NewSelect().
+		Model(existing).
+		Where("order_id = ?", order.ID).
+		Where("document_type = ?", documentType).
+		Limit(1).
+		Scan(ctx)
+	if err == nil {
+		item, itemErr := s.toOrderDocumentItem(ctx, existing)
+		if itemErr != nil {
+			return nil, itemErr
+		}
+		span.AddEvent(`documents.svc.issue.success`)
+		return item, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	buyerName := strings.TrimSpace(req.BuyerName)
+	buyerTaxID := strings.ReplaceAll(strings.TrimSpace(req.BuyerTaxID), "-", "")
+	buyerAddress := strings.TrimSpace(req.BuyerAddress)
+	buyerBranch := ""
+	if documentType == documentTypeTaxInvoice {
+		if buyerName == "" {
+			return nil, errors.New("buyer name is required")
+		}
+		if !isValidThaiTaxID(buyerTaxID) {
+			return nil, errors.New("invalid buyer tax id")
+		}
+		if buyerAddress == "" {
+			return nil, errors.New("buyer address is required")
+		}
+		buyerBranch, err = normalizeBuyerBranch(req.BuyerBranch)
+		if err != nil {
+			return nil, err
+		}
+	} else if buyerName == "" {
+		buyerName, err = s.getDefaultBuyerName(ctx, order)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	lines, err := s.listDocumentLines(ctx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	vatBase, vatAmount := order.VATBaseAmount, order.VATAmount
+	if vatBase.IsZero() && vatAmount.IsZero() && order.ZeroRatedAmount.IsZero() && order.ExemptAmount.IsZero() {
+		// Orders placed before VAT was stored on checkout.
+		vatBase, vatAmount = calculateVATFromInclusive(order.NetAmount)
+	}
+	record := &orderDocumentRecord{
+		ID:             uuid.New(),
+		OrderID:        order.ID,
+		DocumentType:   documentType,
+		BuyerName:      buyerName,
+		BuyerTaxID:     buyerTaxID,
+		BuyerBranch:    buyerBranch,
+		BuyerAddress:   buyerAddress,
+		SubtotalAmount: order.TotalAmount,
+		DiscountAmount: order.DiscountAmount,
+		VATBaseAmount:  vatBase,
+		VATAmount:      vatAmount,
+		TotalAmount:    order.NetAmount,
+	}
+
+	fonts, err := s.loadDocumentFonts()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		now := time.Now()
+		issuedAt := now.In(utils.DatabaseTimeLocation())
+
+		documentNo, err := s.nextDocumentNoInTx(ctx, tx, documentType, issuedAt)
+		if err != nil {
+			return err
+		}
+
+		content := &documentContent{
+			DocumentNo:   documentNo,
+			IssuedAt:     issuedAt,
+			OrderNo:      order.OrderNo,
+			BuyerName:    buyerName,
+			BuyerTaxID:   buyerTaxID,
+			BuyerBranch:  buyerBranch,
+			BuyerAddress: buyerAddress,
+			Lines:        lines,
+			Subtotal:     order.TotalAmount,
+			Discount:     order.DiscountAmount,
+			CODFee:       order.CODFee,
+			ZeroRated:    order.ZeroRatedAmount,
+			Exempt:       order.ExemptAmount,
+			VATBase:      vatBase,
+			VAT:          vatAmount,
+			Total:        order.NetAmount,
+		}
+		if s.conf != nil {
+			content.Seller = *s.conf
+		}
+		if documentType == documentTypeTaxInvoice {
+			content.Title = "ใบกำกับภาษี/ใบเสร็จรับเงิน"
+			content.TitleEn = "TAX INVOICE / RECEIPT"
+		} else {
+			content.Title = "ใบเสร็จรับเงิน"
+			content.TitleEn = "RECEIPT"
+		}
+
+		var pdf bytes.Buffer
+		if err := renderDocumentPDF(&pdf, fonts, content); err != nil {
+			return err
+		}
+
+		filePath := ""
+		fileSize := int64(pdf.Len())
+		if s.railwayStorage != nil && s.railwayStorage.enabledForPrivate() {
+			uploaded, uploadErr := s.railwayStorage.UploadDocument(ctx, order.ID, documentNo, pdf.Bytes())
+			if uploadErr == nil {
+				filePath = uploaded.Path
+				fileSize = uploaded.Size
+			}
+		}
+		fileSource := "STORAGE"
+		if filePath == "" {
+			filePath = "data:application/pdf;base64," + base64.StdEncoding.EncodeToString(pdf.Bytes())
+			fileSource = "INLINE"
+		}
+
+		var uploadedBy *uuid.UUID
+		if requesterID != uuid.Nil {
+			uploadedBy = &requesterID
+		}
+
+		storage := &ent.StorageEntity{
+			ID:            uuid.New(),
+			RefID:         order.ID,
+			FileName:      documentNo + ".pdf",
+			FilePath:      filePath,
+			FileSource:    fileSource,
+			FileSize:      fileSize,
+			FileType:      "application/pdf",
+			IsActive:      true,
+			RelatedEntity: ent.RelatedEntityOrderFile,
+			UploadedBy:    uploadedBy,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+		if _, err := tx.NewInsert().Model(storage).Exec(ctx); err != nil {
+			return err
+		}
+
+		record.DocumentNo = documentNo
+		record.FileID = &storage.ID
+		record.IssuedBy = uploadedBy
+		record.IssuedAt = now
+		record.CreatedAt = now
+		record.UpdatedAt = now
+		if _, err := tx.NewInsert().Model(record).Exec(ctx); err != nil {
+			return err
+		}
+
+		auditLog := &ent.AuditLogEntity{
+			ID:           uuid.New(),
+			Action:       ent.AuditActionCreated,
+			ActionType:   "order_document_issued",
+			ActionID:     order.ID,
+			ActionBy:     uploadedBy,
+			Status:       ent.StatusAuditSuccesses,
+			ActionDetail: fmt.Sprintf("Issued %s %s", documentType, documentNo),
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		}
+		_, err = tx.NewInsert().Model(auditLog).Exec(ctx)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	item, err := s.toOrderDocumentItem(ctx, record)
+	if err != nil {
+		return nil, err
+	}
+
+	span.AddEvent(`documents.svc.issue.success`)
+	return item, nil
+}
+
+func (s *Service) ListOrderDocumentsService(ctx context.Context, orderID uuid.UUID, requesterID uuid.UUID, isAdmin bool) ([]*OrderDocumentItem, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`documents.svc.list.start`)
+
+	order, err := s.getOrderForDocument(ctx, orderID, requesterID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]*orderDocumentRecord, 0)
+	if err := s.bunDB.DB().NewSelect().
+		Model(&records).
+		Where("order_id = ?", order.ID).
+		OrderExpr("issued_at ASC").
+		Scan(ctx); err != nil {
+		return nil, err
+	}
+
+	data := make([]*OrderDocumentItem, 0, len(records))
+	for _, record := range records {
+		item, err := s.toOrderDocumentItem(ctx, record)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, item)
+	}
+
+	span.AddEvent(`documents.svc.list.success`)
+	return data, nil
+}
+
+func (s *Service) InfoOrderDocumentService(ctx context.Context, orderID uuid.UUID, documentID uuid.UUID, requesterID uuid.UUID, isAdmin bool) (*OrderDocumentItem, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`documents.svc.info.start`)
+
+	order, err := s.getOrderForDocument(ctx, orderID, requesterID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	record := new(orderDocumentRecord)
+	if err := s.bunDB.DB().NewSelect().
+		Model(record).
+		Where("id = ?", documentID).
+		Where("order_id = ?", order.ID).
+		Limit(1).
+		Scan(ctx); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("document not found")
+		}
+		return nil, err
+	}
+
+	item, err := s.toOrderDocumentItem(ctx, record)
+	if err != nil {
+		return nil, err
+	}
+
+	span.AddEvent(`documents.svc.info.success`)
+	return item, nil
+}
+
+func (s *Service) toOrderDocumentItem(ctx context.Context, record *orderDocumentRecord) (*OrderDocumentItem, error) {
+	fileURL := ""
+	if record.FileID != nil {
+		storage := new(ent.StorageEntity)
+		err := s.bunDB.DB().NewSelect().Model(storage).Where("id = ?", *record.FileID).Limit(1).Scan(ctx)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		if err == nil {
+			fileURL = s.railwayStorage.ResolveObjectURL(storage.FilePath)
+		}
+	}
+
+	return &OrderDocumentItem{
+		ID:             record.ID,
+		OrderID:        record.OrderID,
+		DocumentType:   record.DocumentType,
+		DocumentNo:     record.DocumentNo,
+		BuyerName:      record.BuyerName,
+		BuyerTaxID:     record.BuyerTaxID,
+		BuyerBranch:    record.BuyerBranch,
+		BuyerAddress:   record.BuyerAddress,
+		SubtotalAmount: record.SubtotalAmount,
+		DiscountAmount: record.DiscountAmount,
+		VATBaseAmount:  record.VATBaseAmount,
+		VATAmount:      record.VATAmount,
+		TotalAmount:    record.TotalAmount,
+		FileURL:        fileURL,
+		IssuedAt:       record.IssuedAt,
+	}, nil
+}
@@ -0,0 +1,111 @@
+package documents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"phakram/app/utils/s3compat"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const signedURLExpiresInSeconds = 60 * 60
+
+type railwayStorageClient struct {
+	s3            *s3compat.Client
+	publicBucket  string
+	privateBucket string
+}
+
+type uploadedDocumentObject struct {
+	Path string
+	Size int64
+}
+
+func newRailwayStorageClient(conf RailwayConfig) *railwayStorageClient {
+	endpointURL := strings.TrimRight(strings.TrimSpace(conf.URL), "/")
+	if endpointURL == "" {
+		endpointURL = strings.TrimRight(firstNonEmptyEnv("OBJECT_ENDPOINT_URL"), "/")
+	}
+
+	secretAccessKey := strings.TrimSpace(conf.ServiceRoleKey)
+	if secretAccessKey == "" {
+		secretAccessKey = firstNonEmptyEnv("OBJECT_SECRET_ACCESS_KEY", "AWS_SECRET_ACCESS_KEY", "S3_SECRET_ACCESS_KEY", "RAILWAY_STORAGE_SECRET_ACCESS_KEY", "RAILWAY_SECRET_ACCESS_KEY", "SECRET_ACCESS_KEY")
+	}
+
+	accessKeyID := firstNonEmptyEnv("OBJECT_ACCESS_KEY_ID", "AWS_ACCESS_KEY_ID", "S3_ACCESS_KEY_ID", "RAILWAY_STORAGE_ACCESS_KEY_ID", "RAILWAY_ACCESS_KEY_ID", "ACCESS_KEY_ID")
+	region := firstNonEmptyEnv("OBJECT_REGION", "AWS_REGION", "AWS_DEFAULT_REGION")
+
+	publicBucket := strings.TrimSpace(conf.PublicBucket)
+	if publicBucket == "" {
+		publicBucket = firstNonEmptyEnv("OBJECT_PUBLIC_BUCKET")
+	}
+
+	privateBucket := strings.TrimSpace(conf.PrivateBucket)
+	if privateBucket == "" {
+		privateBucket = firstNonEmptyEnv("OBJECT_PRIVATE_BUCKET")
+	}
+
+	return &railwayStorageClient{
+		s3:            s3compat.NewClient(endpointURL, accessKeyID, secretAccessKey, region, 20*time.Second),
+		publicBucket:  publicBucket,
+		privateBucket: privateBucket,
+	}
+}
+
+func firstNonEmptyEnv(names ...string) string {
+	for _, name := range names {
+		if value := strings.TrimSpace(os.Getenv(name)); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func (c *railwayStorageClient) enabledForPrivate() bool {
+	return c != nil && c.s3 != nil && c.s3.Enabled() && c.privateBucket != ""
+}
+
+func (c *railwayStorageClient) UploadDocument(ctx context.Context, orderID uuid.UUID, documentNo string, data []byte) (*uploadedDocumentObject, error) {
+	if !c.enabledForPrivate() {
+		return nil, errors.New("railway storage is not configured")
+	}
+	if len(data) == 0 {
+		return nil, errors.New("document is empty")
+	}
+
+	objectPath := fmt.Sprintf("documents/%s/%s-%d.pdf", orderID.String(), documentNo, time.Now().UnixMilli())
+	if err := c.s3.PutObject(ctx, c.privateBucket, objectPath, "application/pdf", data); err != nil {
+		return nil, err
+	}
+
+	return &uploadedDocumentObject{
+		Path: fmt.Sprintf("%s/%s", c.privateBucket, objectPath),
+		Size: int64(len(data)),
+	}, nil
+}
+
+func (c *railwayStorageClient) ResolveObjectURL(storedPath string) string {
+	trimmed := strings.TrimSpace(storedPath)
+	if trimmed == "" || strings.HasPrefix(trimmed, "data:") {
+		return trimmed
+	}
+	if c == nil || c.s3 == nil || !c.s3.Enabled() {
+		return trimmed
+	}
+
+	parts := strings.SplitN(strings.Trim(trimmed, "/"), "/", 2)
+	if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "" {
+		return trimmed
+	}
+
+	signedURL, err := c.s3.PresignGetObject(parts[0], parts[1], time.Duration(signedURLExpiresInSeconds)*time.Second)
+	if err != nil || strings.TrimSpace(signedURL) == "" {
+		return trimmed
+	}
+
+	return signedURL
+}
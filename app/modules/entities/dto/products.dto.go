@@ -1,7 +1,12 @@
 package entitiesdto
 
-import "phakram/app/utils/base"
+import (
+	"phakram/app/utils/attributes"
+	"phakram/app/utils/base"
+)
 
 type ListProductsRequest struct {
 	base.RequestPaginate
+	Status     string
+	Attributes []*attributes.Filter
 }
@@ -0,0 +1,61 @@
+package ent
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/uptrace/bun"
+)
+
+// AttributeDataTypeEnum is the kind of value a category attribute holds.
+type AttributeDataTypeEnum string
+
+const (
+	AttributeText    AttributeDataTypeEnum = "text"
+	AttributeNumber  AttributeDataTypeEnum = "number"
+	AttributeEnum    AttributeDataTypeEnum = "enum"
+	AttributeBoolean AttributeDataTypeEnum = "boolean"
+)
+
+// CategoryAttributeOption is one allowed value of an enum attribute.
+type CategoryAttributeOption struct {
+	Value   string `json:"value"`
+	LabelTh string `json:"label_th"`
+	LabelEn string `json:"label_en"`
+}
+
+// CategoryAttributeEntity defines one product attribute of a category. A
+// category also inherits the attributes of its ancestors.
+type CategoryAttributeEntity struct {
+	bun.BaseModel `bun:"table:category_attributes"`
+
+	ID           uuid.UUID                 `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	CategoryID   uuid.UUID                 `bun:"category_id,type:uuid" json:"category_id"`
+	Code         string                    `bun:"code" json:"code"`
+	LabelTh      string                    `bun:"label_th" json:"label_th"`
+	LabelEn      string                    `bun:"label_en" json:"label_en"`
+	DataType     AttributeDataTypeEnum     `bun:"data_type" json:"data_type"`
+	Unit         *string                   `bun:"unit" json:"unit"`
+	Options      []CategoryAttributeOption `bun:"options,type:jsonb" json:"options"`
+	IsRequired   bool                      `bun:"is_required" json:"is_required"`
+	IsFilterable bool                      `bun:"is_filterable" json:"is_filterable"`
+	SortOrder    int                       `bun:"sort_order" json:"sort_order"`
+	CreatedAt    time.Time                 `bun:"created_at,default:current_timestamp" json:"created_at"`
+	UpdatedAt    time.Time                 `bun:"updated_at,default:current_timestamp" json:"updated_at"`
+}
+
+// ProductAttributeValueEntity is a product's value for one category
+// attribute. Only the column matching the attribute's data type is set;
+// enum values are stored in ValueText.
+type ProductAttributeValueEntity struct {
+	bun.BaseModel `bun:"table:product_attribute_values"`
+
+	ProductID    uuid.UUID        `bun:"product_id,pk,type:uuid" json:"product_id"`
+	AttributeID  uuid.UUID        `bun:"attribute_id,pk,type:uuid" json:"attribute_id"`
+	ValueText    *string          `bun:"value_text" json:"value_text"`
+	ValueNumber  *decimal.Decimal `bun:"value_number" json:"value_number"`
+	ValueBoolean *bool            `bun:"value_boolean" json:"value_boolean"`
+	CreatedAt    time.Time        `bun:"created_at,default:current_timestamp" json:"created_at"`
+	UpdatedAt    time.Time        `bun:"updated_at,default:current_timestamp" json:"updated_at"`
+}
@@ -0,0 +1,25 @@
+package ent
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/uptrace/bun"
+)
+
+type CODSettingEntity struct {
+	bun.BaseModel `bun:"table:cod_settings"`
+
+	ID              uuid.UUID        `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	IsEnabled       bool             `bun:"is_enabled" json:"is_enabled"`
+	FeeType         string           `bun:"fee_type" json:"fee_type"`
+	FeeValue        decimal.Decimal  `bun:"fee_value" json:"fee_value"`
+	MaxFee          *decimal.Decimal `bun:"max_fee" json:"max_fee"`
+	MinOrderAmount  decimal.Decimal  `bun:"min_order_amount" json:"min_order_amount"`
+	MaxOrderAmount  *decimal.Decimal `bun:"max_order_amount" json:"max_order_amount"`
+	MaxRefusedCount int              `bun:"max_refused_count" json:"max_refused_count"`
+	UpdatedBy       *uuid.UUID       `bun:"updated_by,type:uuid" json:"updated_by"`
+	CreatedAt       time.Time        `bun:"created_at,default:current_timestamp" json:"created_at"`
+	UpdatedAt       time.Time        `bun:"updated_at,default:current_timestamp" json:"updated_at"`
+}
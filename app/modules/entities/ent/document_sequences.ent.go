@@ -0,0 +1,19 @@
+package ent
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+type DocumentSequenceEntity struct {
+	bun.BaseModel `bun:"table:document_sequences,alias:ds"`
+
+	ID           uuid.UUID `bun:"id,pk,type:uuid"`
+	DocumentType string    `bun:"document_type,notnull"`
+	Period       string    `bun:"period,notnull"`
+	LastNo       int       `bun:"last_no,notnull"`
+	CreatedAt    time.Time `bun:"created_at"`
+	UpdatedAt    time.Time `bun:"updated_at"`
+}
@@ -17,6 +17,15 @@ type MemberWishlistEntity struct {
 	Quantity        int             `bun:"quantity" json:"quantity"`
 	PricePerUnit    decimal.Decimal `bun:"price_per_unit" json:"price_per_unit"`
 	TotalItemAmount decimal.Decimal `bun:"total_item_amount" json:"total_item_amount"`
-	CreatedAt       time.Time       `bun:"created_at,default:current_timestamp" json:"created_at"`
-	UpdatedAt       time.Time       `bun:"updated_at,default:current_timestamp" json:"updated_at"`
+	// Alert opt-ins. AlertInStock and AlertNotifiedPrice are what the alert
+	// job saw last, so it only notifies on a change.
+	AlertBackInStock     bool             `bun:"alert_back_in_stock" json:"alert_back_in_stock"`
+	AlertPriceDrop       bool             `bun:"alert_price_drop" json:"alert_price_drop"`
+	AlertToken           uuid.UUID        `bun:"alert_token,type:uuid,nullzero,default:uuid_generate_v4()" json:"-"`
+	AlertInStock         *bool            `bun:"alert_in_stock" json:"-"`
+	AlertNotifiedPrice   *decimal.Decimal `bun:"alert_notified_price" json:"-"`
+	BackInStockAlertedAt *time.Time       `bun:"back_in_stock_alerted_at" json:"back_in_stock_alerted_at"`
+	PriceDropAlertedAt   *time.Time       `bun:"price_drop_alerted_at" json:"price_drop_alerted_at"`
+	CreatedAt            time.Time        `bun:"created_at,default:current_timestamp" json:"created_at"`
+	UpdatedAt            time.Time        `bun:"updated_at,default:current_timestamp" json:"updated_at"`
 }
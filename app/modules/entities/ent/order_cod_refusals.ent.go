@@ -0,0 +1,20 @@
+package ent
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+type OrderCODRefusalEntity struct {
+	bun.BaseModel `bun:"table:order_cod_refusals"`
+
+	ID         uuid.UUID  `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	OrderID    uuid.UUID  `bun:"order_id,type:uuid" json:"order_id"`
+	MemberID   uuid.UUID  `bun:"member_id,type:uuid" json:"member_id"`
+	Reason     string     `bun:"reason" json:"reason"`
+	RecordedBy *uuid.UUID `bun:"recorded_by,type:uuid" json:"recorded_by"`
+	CreatedAt  time.Time  `bun:"created_at,default:current_timestamp" json:"created_at"`
+	UpdatedAt  time.Time  `bun:"updated_at,default:current_timestamp" json:"updated_at"`
+}
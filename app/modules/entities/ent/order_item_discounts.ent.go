@@ -0,0 +1,21 @@
+package ent
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/uptrace/bun"
+)
+
+type OrderItemDiscountEntity struct {
+	bun.BaseModel `bun:"table:order_item_discounts"`
+
+	ID             uuid.UUID       `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	OrderID        uuid.UUID       `bun:"order_id,type:uuid" json:"order_id"`
+	OrderItemID    uuid.UUID       `bun:"order_item_id,type:uuid" json:"order_item_id"`
+	PromotionID    uuid.UUID       `bun:"promotion_id,type:uuid" json:"promotion_id"`
+	DiscountAmount decimal.Decimal `bun:"discount_amount" json:"discount_amount"`
+	CreatedAt      time.Time       `bun:"created_at,default:current_timestamp" json:"created_at"`
+	UpdatedAt      time.Time       `bun:"updated_at,default:current_timestamp" json:"updated_at"`
+}
@@ -11,12 +11,21 @@ import (
 type OrderItemEntity struct {
 	bun.BaseModel `bun:"table:order_items"`
 
-	ID              uuid.UUID       `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
-	OrderID         uuid.UUID       `bun:"order_id,type:uuid" json:"order_id"`
-	ProductID       uuid.UUID       `bun:"product_id,type:uuid" json:"product_id"`
-	Quantity        int             `bun:"quantity" json:"quantity"`
-	PricePerUnit    decimal.Decimal `bun:"price_per_unit" json:"price_per_unit"`
-	TotalItemAmount decimal.Decimal `bun:"total_item_amount" json:"total_item_amount"`
-	CreatedAt       time.Time       `bun:"created_at,default:current_timestamp" json:"created_at"`
-	UpdatedAt       time.Time       `bun:"updated_at,default:current_timestamp" json:"updated_at"`
+	ID               uuid.UUID       `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	OrderID          uuid.UUID       `bun:"order_id,type:uuid" json:"order_id"`
+	ProductID        uuid.UUID       `bun:"product_id,type:uuid" json:"product_id"`
+	Quantity         int             `bun:"quantity" json:"quantity"`
+	PricePerUnit     decimal.Decimal `bun:"price_per_unit" json:"price_per_unit"`
+	TotalItemAmount  decimal.Decimal `bun:"total_item_amount" json:"total_item_amount"`
+	TaxClass         TaxClassEnum    `bun:"tax_class,nullzero,default:'vat'" json:"tax_class"`
+	VATRate          decimal.Decimal `bun:"vat_rate" json:"vat_rate"`
+	PriceIncludesVAT bool            `bun:"price_includes_vat" json:"price_includes_vat"`
+	DiscountAmount   decimal.Decimal `bun:"discount_amount" json:"discount_amount"`
+	VATBaseAmount    decimal.Decimal `bun:"vat_base_amount" json:"vat_base_amount"`
+	VATAmount        decimal.Decimal `bun:"vat_amount" json:"vat_amount"`
+	PromotionID      *uuid.UUID      `bun:"promotion_id,type:uuid" json:"promotion_id"`
+	FlashSaleItemID  *uuid.UUID      `bun:"flash_sale_item_id,type:uuid" json:"flash_sale_item_id"`
+	ProductPriceID   *uuid.UUID      `bun:"product_price_id,type:uuid" json:"product_price_id"`
+	CreatedAt        time.Time       `bun:"created_at,default:current_timestamp" json:"created_at"`
+	UpdatedAt        time.Time       `bun:"updated_at,default:current_timestamp" json:"updated_at"`
 }
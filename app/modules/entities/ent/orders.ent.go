@@ -31,10 +31,18 @@ type OrderEntity struct {
 	TotalAmount            decimal.Decimal `bun:"total_amount" json:"total_amount"`
 	DiscountAmount         decimal.Decimal `bun:"discount_amount" json:"discount_amount"`
 	NetAmount              decimal.Decimal `bun:"net_amount" json:"net_amount"`
+	CODFee                 decimal.Decimal `bun:"cod_fee" json:"cod_fee"`
+	VATBaseAmount          decimal.Decimal `bun:"vat_base_amount" json:"vat_base_amount"`
+	VATAmount              decimal.Decimal `bun:"vat_amount" json:"vat_amount"`
+	VATAddedAmount         decimal.Decimal `bun:"vat_added_amount" json:"vat_added_amount"`
+	ZeroRatedAmount        decimal.Decimal `bun:"zero_rated_amount" json:"zero_rated_amount"`
+	ExemptAmount           decimal.Decimal `bun:"exempt_amount" json:"exempt_amount"`
 	CreatedAt              time.Time       `bun:"created_at,default:current_timestamp" json:"created_at"`
 	UpdatedAt              time.Time       `bun:"updated_at,default:current_timestamp" json:"updated_at"`
+	PaymentMethod          string          `bun:"-" json:"payment_method,omitempty"`
 	PaymentSubmitted       bool            `bun:"-" json:"payment_submitted"`
 	PaymentRejected        bool            `bun:"-" json:"payment_rejected"`
+	PaymentSlipFlagged     bool            `bun:"-" json:"payment_slip_flagged"`
 	PaymentRejectionReason string          `bun:"-" json:"payment_rejection_reason,omitempty"`
 	PaymentAppealReason    string          `bun:"-" json:"payment_appeal_reason,omitempty"`
 	RefundRejectionReason  string          `bun:"-" json:"refund_rejection_reason,omitempty"`
@@ -0,0 +1,23 @@
+package ent
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+type PaymentSlipHashEntity struct {
+	bun.BaseModel `bun:"table:payment_slip_hashes"`
+
+	ID             uuid.UUID  `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	OrderID        uuid.UUID  `bun:"order_id,type:uuid" json:"order_id"`
+	PaymentID      uuid.UUID  `bun:"payment_id,type:uuid" json:"payment_id"`
+	FileID         *uuid.UUID `bun:"file_id,type:uuid" json:"file_id"`
+	SHA256         string     `bun:"sha256,nullzero" json:"sha256"`
+	PerceptualHash string     `bun:"perceptual_hash,nullzero" json:"perceptual_hash"`
+	IsFlagged      bool       `bun:"is_flagged" json:"is_flagged"`
+	IsUnverified   bool       `bun:"is_unverified" json:"is_unverified"`
+	CreatedAt      time.Time  `bun:"created_at,default:current_timestamp" json:"created_at"`
+	UpdatedAt      time.Time  `bun:"updated_at,default:current_timestamp" json:"updated_at"`
+}
@@ -17,12 +17,20 @@ const (
 	PaymentTypeRefunded PaymentTypeEnum = "refunded"
 )
 
+type PaymentMethodEnum string
+
+const (
+	PaymentMethodTransfer PaymentMethodEnum = "transfer"
+	PaymentMethodCOD      PaymentMethodEnum = "cod"
+)
+
 type PaymentEntity struct {
 	bun.BaseModel `bun:"table:payments"`
 
-	ID         uuid.UUID       `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
-	Amount     decimal.Decimal `bun:"amount" json:"amount"`
-	Status     PaymentTypeEnum `bun:"status" json:"status"`
-	ApprovedBy *uuid.UUID      `bun:"approved_by,type:uuid" json:"approved_by"`
-	ApprovedAt *time.Time      `bun:"approved_at" json:"approved_at"`
+	ID         uuid.UUID         `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	Amount     decimal.Decimal   `bun:"amount" json:"amount"`
+	Status     PaymentTypeEnum   `bun:"status" json:"status"`
+	Method     PaymentMethodEnum `bun:"method,nullzero,default:'transfer'" json:"method"`
+	ApprovedBy *uuid.UUID        `bun:"approved_by,type:uuid" json:"approved_by"`
+	ApprovedAt *time.Time        `bun:"approved_at" json:"approved_at"`
 }
@@ -0,0 +1,45 @@
+package ent
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/uptrace/bun"
+)
+
+type PayoutBatchStatusEnum string
+
+const (
+	PayoutBatchStatusDraft     PayoutBatchStatusEnum = "draft"
+	PayoutBatchStatusApproved  PayoutBatchStatusEnum = "approved"
+	PayoutBatchStatusExported  PayoutBatchStatusEnum = "exported"
+	PayoutBatchStatusCompleted PayoutBatchStatusEnum = "completed"
+	PayoutBatchStatusCancelled PayoutBatchStatusEnum = "cancelled"
+)
+
+type PayoutFileFormatEnum string
+
+const (
+	PayoutFileFormatKBank PayoutFileFormatEnum = "kbank"
+	PayoutFileFormatSCB   PayoutFileFormatEnum = "scb"
+)
+
+type PayoutBatchEntity struct {
+	bun.BaseModel `bun:"table:payout_batches"`
+
+	ID                uuid.UUID             `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	BatchNo           string                `bun:"batch_no" json:"batch_no"`
+	FileFormat        PayoutFileFormatEnum  `bun:"file_format" json:"file_format"`
+	Status            PayoutBatchStatusEnum `bun:"status" json:"status"`
+	ItemCount         int                   `bun:"item_count" json:"item_count"`
+	TotalAmount       decimal.Decimal       `bun:"total_amount" json:"total_amount"`
+	TransferReference string                `bun:"transfer_reference" json:"transfer_reference"`
+	CreatedBy         *uuid.UUID            `bun:"created_by,type:uuid" json:"created_by"`
+	ApprovedBy        *uuid.UUID            `bun:"approved_by,type:uuid" json:"approved_by"`
+	ApprovedAt        *time.Time            `bun:"approved_at" json:"approved_at"`
+	ExportedAt        *time.Time            `bun:"exported_at" json:"exported_at"`
+	CompletedAt       *time.Time            `bun:"completed_at" json:"completed_at"`
+	CreatedAt         time.Time             `bun:"created_at,default:current_timestamp" json:"created_at"`
+	UpdatedAt         time.Time             `bun:"updated_at,default:current_timestamp" json:"updated_at"`
+}
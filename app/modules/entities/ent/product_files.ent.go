@@ -10,10 +10,15 @@ import (
 type ProductFileEntity struct {
 	bun.BaseModel `bun:"table:product_files"`
 
-	ID        uuid.UUID  `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
-	ProductID uuid.UUID  `bun:"product_id,type:uuid" json:"product_id"`
-	FileID    uuid.UUID  `bun:"file_id,type:uuid" json:"file_id"`
-	CreatedAt time.Time  `bun:"created_at,default:current_timestamp" json:"created_at"`
-	UpdatedAt time.Time  `bun:"updated_at,default:current_timestamp" json:"updated_at"`
-	DeletedAt *time.Time `bun:"deleted_at,soft_delete" json:"deleted_at"`
+	ID         uuid.UUID  `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	ProductID  uuid.UUID  `bun:"product_id,type:uuid" json:"product_id"`
+	FileID     uuid.UUID  `bun:"file_id,type:uuid" json:"file_id"`
+	SortOrder  int        `bun:"sort_order" json:"sort_order"`
+	IsPrimary  bool       `bun:"is_primary" json:"is_primary"`
+	AltTextTh  string     `bun:"alt_text_th" json:"alt_text_th"`
+	AltTextEn  string     `bun:"alt_text_en" json:"alt_text_en"`
+	VariantKey *string    `bun:"variant_key" json:"variant_key"`
+	CreatedAt  time.Time  `bun:"created_at,default:current_timestamp" json:"created_at"`
+	UpdatedAt  time.Time  `bun:"updated_at,default:current_timestamp" json:"updated_at"`
+	DeletedAt  *time.Time `bun:"deleted_at,soft_delete" json:"deleted_at"`
 }
@@ -8,15 +8,21 @@ import (
 	"github.com/uptrace/bun"
 )
 
+// ProductStockEntity is the stock of a product. Admins are alerted once
+// Remaining drops to ReorderPoint; TargetStock is the level a reorder should
+// bring it back to.
 type ProductStockEntity struct {
 	bun.BaseModel `bun:"table:product_stocks"`
 
-	ID          uuid.UUID       `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
-	ProductID   uuid.UUID       `bun:"product_id,type:uuid" json:"product_id"`
-	UnitPrice   decimal.Decimal `bun:"unit_price" json:"unit_price"`
-	StockAmount int             `bun:"stock_amount" json:"stock_amount"`
-	Remaining   int             `bun:"remaining" json:"remaining"`
-	CreatedAt   time.Time       `bun:"created_at,default:current_timestamp" json:"created_at"`
-	UpdatedAt   time.Time       `bun:"updated_at,default:current_timestamp" json:"updated_at"`
-	DeletedAt   *time.Time      `bun:"deleted_at,soft_delete" json:"deleted_at"`
+	ID                uuid.UUID       `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	ProductID         uuid.UUID       `bun:"product_id,type:uuid" json:"product_id"`
+	UnitPrice         decimal.Decimal `bun:"unit_price" json:"unit_price"`
+	StockAmount       int             `bun:"stock_amount" json:"stock_amount"`
+	Remaining         int             `bun:"remaining" json:"remaining"`
+	ReorderPoint      *int            `bun:"reorder_point" json:"reorder_point"`
+	TargetStock       *int            `bun:"target_stock" json:"target_stock"`
+	LowStockAlertedAt *time.Time      `bun:"low_stock_alerted_at" json:"low_stock_alerted_at"`
+	CreatedAt         time.Time       `bun:"created_at,default:current_timestamp" json:"created_at"`
+	UpdatedAt         time.Time       `bun:"updated_at,default:current_timestamp" json:"updated_at"`
+	DeletedAt         *time.Time      `bun:"deleted_at,soft_delete" json:"deleted_at"`
 }
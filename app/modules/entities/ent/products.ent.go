@@ -8,17 +8,41 @@ import (
 	"github.com/uptrace/bun"
 )
 
+type TaxClassEnum string
+
+const (
+	TaxClassVAT       TaxClassEnum = "vat"
+	TaxClassZeroRated TaxClassEnum = "zero_rated"
+	TaxClassExempt    TaxClassEnum = "exempt"
+)
+
+// ProductStatusEnum is where a product is in its lifecycle. Only published
+// products are visible to shoppers; IsActive mirrors that state.
+type ProductStatusEnum string
+
+const (
+	ProductStatusDraft     ProductStatusEnum = "draft"
+	ProductStatusScheduled ProductStatusEnum = "scheduled"
+	ProductStatusPublished ProductStatusEnum = "published"
+	ProductStatusArchived  ProductStatusEnum = "archived"
+)
+
 type ProductEntity struct {
 	bun.BaseModel `bun:"table:products"`
 
-	ID         uuid.UUID       `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
-	CategoryID uuid.UUID       `bun:"category_id,type:uuid" json:"category_id"`
-	NameTh     string          `bun:"name_th" json:"name_th"`
-	NameEn     string          `bun:"name_en" json:"name_en"`
-	ProductNo  string          `bun:"product_no" json:"product_no"`
-	Price      decimal.Decimal `bun:"price" json:"price"`
-	IsActive   bool            `bun:"is_active" json:"is_active"`
-	CreatedAt  time.Time       `bun:"created_at,default:current_timestamp" json:"created_at"`
-	UpdatedAt  time.Time       `bun:"updated_at,default:current_timestamp" json:"updated_at"`
-	DeletedAt  *time.Time      `bun:"deleted_at,soft_delete" json:"deleted_at"`
+	ID               uuid.UUID         `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	CategoryID       uuid.UUID         `bun:"category_id,type:uuid" json:"category_id"`
+	NameTh           string            `bun:"name_th" json:"name_th"`
+	NameEn           string            `bun:"name_en" json:"name_en"`
+	ProductNo        string            `bun:"product_no" json:"product_no"`
+	Price            decimal.Decimal   `bun:"price" json:"price"`
+	IsActive         bool              `bun:"is_active" json:"is_active"`
+	TaxClass         TaxClassEnum      `bun:"tax_class,nullzero,default:'vat'" json:"tax_class"`
+	PriceIncludesVAT bool              `bun:"price_includes_vat" json:"price_includes_vat"`
+	Status           ProductStatusEnum `bun:"status,nullzero,default:'published'" json:"status"`
+	PublishAt        *time.Time        `bun:"publish_at" json:"publish_at"`
+	UnpublishAt      *time.Time        `bun:"unpublish_at" json:"unpublish_at"`
+	CreatedAt        time.Time         `bun:"created_at,default:current_timestamp" json:"created_at"`
+	UpdatedAt        time.Time         `bun:"updated_at,default:current_timestamp" json:"updated_at"`
+	DeletedAt        *time.Time        `bun:"deleted_at,soft_delete" json:"deleted_at"`
 }
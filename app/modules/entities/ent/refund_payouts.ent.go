@@ -0,0 +1,40 @@
+package ent
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/uptrace/bun"
+)
+
+type RefundPayoutStatusEnum string
+
+const (
+	RefundPayoutStatusPending RefundPayoutStatusEnum = "pending"
+	RefundPayoutStatusBatched RefundPayoutStatusEnum = "batched"
+	RefundPayoutStatusPaid    RefundPayoutStatusEnum = "paid"
+	RefundPayoutStatusFailed  RefundPayoutStatusEnum = "failed"
+)
+
+type RefundPayoutEntity struct {
+	bun.BaseModel `bun:"table:refund_payouts"`
+
+	ID                uuid.UUID              `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	OrderID           uuid.UUID              `bun:"order_id,type:uuid" json:"order_id"`
+	MemberID          uuid.UUID              `bun:"member_id,type:uuid" json:"member_id"`
+	MemberBankID      *uuid.UUID             `bun:"member_bank_id,type:uuid" json:"member_bank_id"`
+	BankCode          string                 `bun:"bank_code" json:"bank_code"`
+	BankName          string                 `bun:"bank_name" json:"bank_name"`
+	AccountNo         string                 `bun:"account_no" json:"account_no"`
+	AccountName       string                 `bun:"account_name" json:"account_name"`
+	Amount            decimal.Decimal        `bun:"amount" json:"amount"`
+	Status            RefundPayoutStatusEnum `bun:"status" json:"status"`
+	BatchID           *uuid.UUID             `bun:"batch_id,type:uuid" json:"batch_id"`
+	TransferReference string                 `bun:"transfer_reference" json:"transfer_reference"`
+	TransferredAt     *time.Time             `bun:"transferred_at" json:"transferred_at"`
+	FailureReason     string                 `bun:"failure_reason" json:"failure_reason"`
+	ConfirmedBy       *uuid.UUID             `bun:"confirmed_by,type:uuid" json:"confirmed_by"`
+	CreatedAt         time.Time              `bun:"created_at,default:current_timestamp" json:"created_at"`
+	UpdatedAt         time.Time              `bun:"updated_at,default:current_timestamp" json:"updated_at"`
+}
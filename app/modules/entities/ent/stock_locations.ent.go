@@ -0,0 +1,68 @@
+package ent
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// StockMovementKindEnum says why stock moved. Receive and adjust change the
+// total on hand; transfer moves it between locations; fulfil and return
+// follow orders out of and back into a location.
+type StockMovementKindEnum string
+
+const (
+	StockMovementInitial  StockMovementKindEnum = "initial"
+	StockMovementReceive  StockMovementKindEnum = "receive"
+	StockMovementAdjust   StockMovementKindEnum = "adjust"
+	StockMovementTransfer StockMovementKindEnum = "transfer"
+	StockMovementFulfil   StockMovementKindEnum = "fulfil"
+	StockMovementReturn   StockMovementKindEnum = "return"
+)
+
+// StockLocationEntity is a place stock is held, such as the shop front or a
+// warehouse. Orders are fulfilled from locations in the customer's province
+// first, then by ascending Priority.
+type StockLocationEntity struct {
+	bun.BaseModel `bun:"table:stock_locations"`
+
+	ID         uuid.UUID  `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	Code       string     `bun:"code" json:"code"`
+	NameTh     string     `bun:"name_th" json:"name_th"`
+	NameEn     string     `bun:"name_en" json:"name_en"`
+	ProvinceID *uuid.UUID `bun:"province_id,type:uuid" json:"province_id"`
+	Priority   int        `bun:"priority" json:"priority"`
+	IsDefault  bool       `bun:"is_default" json:"is_default"`
+	IsActive   bool       `bun:"is_active" json:"is_active"`
+	CreatedAt  time.Time  `bun:"created_at,default:current_timestamp" json:"created_at"`
+	UpdatedAt  time.Time  `bun:"updated_at,default:current_timestamp" json:"updated_at"`
+	DeletedAt  *time.Time `bun:"deleted_at,soft_delete" json:"deleted_at"`
+}
+
+// LocationStockEntity is the quantity of a product held at one location.
+// product_stocks.remaining is kept equal to the sum over all locations.
+type LocationStockEntity struct {
+	bun.BaseModel `bun:"table:location_stocks"`
+
+	LocationID uuid.UUID `bun:"location_id,pk,type:uuid" json:"location_id"`
+	ProductID  uuid.UUID `bun:"product_id,pk,type:uuid" json:"product_id"`
+	Quantity   int       `bun:"quantity" json:"quantity"`
+	CreatedAt  time.Time `bun:"created_at,default:current_timestamp" json:"created_at"`
+	UpdatedAt  time.Time `bun:"updated_at,default:current_timestamp" json:"updated_at"`
+}
+
+type StockMovementEntity struct {
+	bun.BaseModel `bun:"table:stock_movements"`
+
+	ID             uuid.UUID             `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	ProductID      uuid.UUID             `bun:"product_id,type:uuid" json:"product_id"`
+	FromLocationID *uuid.UUID            `bun:"from_location_id,type:uuid" json:"from_location_id"`
+	ToLocationID   *uuid.UUID            `bun:"to_location_id,type:uuid" json:"to_location_id"`
+	Quantity       int                   `bun:"quantity" json:"quantity"`
+	Kind           StockMovementKindEnum `bun:"kind" json:"kind"`
+	OrderID        *uuid.UUID            `bun:"order_id,type:uuid" json:"order_id"`
+	Note           *string               `bun:"note" json:"note"`
+	CreatedBy      *uuid.UUID            `bun:"created_by,type:uuid" json:"created_by"`
+	CreatedAt      time.Time             `bun:"created_at,default:current_timestamp" json:"created_at"`
+}
@@ -14,6 +14,8 @@ const (
 	RelatedEntityOrderFile   RelatedEntityEnum = "ORDER_FILE"
 	RelatedEntityProductFile RelatedEntityEnum = "PRODUCT_FILE"
 	RelatedEntityPaymentFile RelatedEntityEnum = "PAYMENT_FILE"
+	RelatedEntityReviewFile  RelatedEntityEnum = "REVIEW_FILE"
+	RelatedEntityBankFile    RelatedEntityEnum = "SYSTEM_BANK_FILE"
 	RelatedEntityOther       RelatedEntityEnum = "OTHER"
 )
 
@@ -30,7 +32,33 @@ type StorageEntity struct {
 	IsActive      bool              `bun:"is_active" json:"is_active"`
 	RelatedEntity RelatedEntityEnum `bun:"related_entity" json:"related_entity"`
 	UploadedBy    *uuid.UUID        `bun:"uploaded_by,type:uuid" json:"uploaded_by"`
+	Renditions    Renditions        `bun:"renditions,type:jsonb" json:"renditions"`
 	CreatedAt     time.Time         `bun:"created_at,default:current_timestamp" json:"created_at"`
 	UpdatedAt     time.Time         `bun:"updated_at,default:current_timestamp" json:"updated_at"`
 	DeletedAt     *time.Time        `bun:"deleted_at,soft_delete" json:"deleted_at"`
 }
+
+// Rendition is where one rendition size is stored, as "bucket/object"
+// paths. Renditions is saved on storages.renditions, keyed by size name.
+type Rendition struct {
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	WebP   string `json:"webp"`
+	JPEG   string `json:"jpeg"`
+}
+
+type Renditions map[string]Rendition
+
+// Paths lists every stored rendition object, for cleanup.
+func (r Renditions) Paths() []string {
+	paths := make([]string, 0, len(r)*2)
+	for _, rendition := range r {
+		if rendition.WebP != "" {
+			paths = append(paths, rendition.WebP)
+		}
+		if rendition.JPEG != "" {
+			paths = append(paths, rendition.JPEG)
+		}
+	}
+	return paths
+}
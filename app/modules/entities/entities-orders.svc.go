@@ -2,10 +2,10 @@ package entities
 
 import (
 	"context"
-	"os"
 	entitiesdto "phakram/app/modules/entities/dto"
 	"phakram/app/modules/entities/ent"
 	entitiesinf "phakram/app/modules/entities/inf"
+	"phakram/app/utils"
 	"phakram/app/utils/base"
 	"time"
 
@@ -17,7 +17,7 @@ var _ entitiesinf.OrderEntity = (*Service)(nil)
 
 func (s *Service) ListOrders(ctx context.Context, req *entitiesdto.ListOrdersRequest) ([]*ent.OrderEntity, *base.ResponsePaginate, error) {
 	data := make([]*ent.OrderEntity, 0)
-	loc := orderTimeLocation()
+	loc := utils.DatabaseTimeLocation()
 
 	_, page, err := base.NewInstant(s.db).GetList(
 		ctx,
@@ -96,17 +96,3 @@ func (s *Service) DeleteOrder(ctx context.Context, orderID uuid.UUID) error {
 		return nil
 	})
 }
-
-func orderTimeLocation() *time.Location {
-	keys := []string{"DATABASE_SQL__TIME_ZONE", "DATABASE_SQL__TIMEZONE", "DB_TIMEZONE"}
-	for _, key := range keys {
-		if tz := os.Getenv(key); tz != "" {
-			loc, err := time.LoadLocation(tz)
-			if err == nil {
-				return loc
-			}
-			break
-		}
-	}
-	return time.Local
-}
@@ -5,10 +5,12 @@ import (
 	entitiesdto "phakram/app/modules/entities/dto"
 	"phakram/app/modules/entities/ent"
 	entitiesinf "phakram/app/modules/entities/inf"
+	"phakram/app/utils/attributes"
 	"phakram/app/utils/base"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/uptrace/bun"
 )
 
 var _ entitiesinf.ProductEntity = (*Service)(nil)
@@ -21,8 +23,13 @@ func (s *Service) ListProducts(ctx context.Context, req *entitiesdto.ListProduct
 		&data,
 		&req.RequestPaginate,
 		[]string{"name_th", "name_en", "product_no", "is_active"},
-		[]string{"created_at", "name_th", "name_en", "product_no", "price"},
-		nil,
+		[]string{"created_at", "name_th", "name_en", "product_no", "price", "publish_at"},
+		func(selQ *bun.SelectQuery) *bun.SelectQuery {
+			if req.Status != "" {
+				selQ.Where("status = ?", req.Status)
+			}
+			return attributes.ApplyFilters(selQ, req.Attributes)
+		},
 	)
 	if err != nil {
 		return nil, nil, err
@@ -0,0 +1,202 @@
+package flashsales
+
+import (
+	"strings"
+
+	"phakram/app/modules/auth"
+	"phakram/app/utils/base"
+	"phakram/config/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FlashSaleItemControllerRequest struct {
+	ProductID      string `json:"product_id"`
+	SalePrice      string `json:"sale_price"`
+	QuantityCap    int    `json:"quantity_cap"`
+	PerMemberLimit *int   `json:"per_member_limit"`
+}
+
+type ListFlashSalesControllerRequest struct {
+	base.RequestPaginate
+	Status string `form:"status"`
+}
+
+type CreateFlashSaleControllerRequest struct {
+	Name     string                           `json:"name"`
+	StartsAt string                           `json:"starts_at"`
+	EndsAt   string                           `json:"ends_at"`
+	IsActive *bool                            `json:"is_active"`
+	Items    []FlashSaleItemControllerRequest `json:"items"`
+}
+
+type UpdateFlashSaleControllerRequest struct {
+	Name     string                           `json:"name"`
+	StartsAt string                           `json:"starts_at"`
+	EndsAt   string                           `json:"ends_at"`
+	IsActive *bool                            `json:"is_active"`
+	Items    []FlashSaleItemControllerRequest `json:"items"`
+}
+
+func requireFlashSaleAdmin(ctx *gin.Context) bool {
+	_, hasRequester := auth.GetMemberID(ctx)
+	if !auth.GetIsAdmin(ctx) || !hasRequester {
+		base.Forbidden(ctx, i18n.Forbidden, nil)
+		return false
+	}
+	return true
+}
+
+func toFlashSaleItemRequests(items []FlashSaleItemControllerRequest) []FlashSaleItemServiceRequest {
+	result := make([]FlashSaleItemServiceRequest, 0, len(items))
+	for _, item := range items {
+		result = append(result, FlashSaleItemServiceRequest{
+			ProductID:      item.ProductID,
+			SalePrice:      item.SalePrice,
+			QuantityCap:    item.QuantityCap,
+			PerMemberLimit: item.PerMemberLimit,
+		})
+	}
+	return result
+}
+
+func (c *Controller) ListController(ctx *gin.Context) {
+	if !requireFlashSaleAdmin(ctx) {
+		return
+	}
+
+	var req ListFlashSalesControllerRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	data, page, err := c.svc.List(ctx.Request.Context(), &ListFlashSalesServiceRequest{
+		RequestPaginate: req.RequestPaginate,
+		Status:          req.Status,
+	})
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	base.Paginate(ctx, data, page)
+}
+
+func (c *Controller) InfoController(ctx *gin.Context) {
+	if !requireFlashSaleAdmin(ctx) {
+		return
+	}
+
+	id := strings.TrimSpace(ctx.Param("id"))
+	if id == "" {
+		base.BadRequest(ctx, "ไม่พบรหัสแฟลชเซล", nil)
+		return
+	}
+
+	data, err := c.svc.Info(ctx.Request.Context(), id)
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	base.Success(ctx, data)
+}
+
+func (c *Controller) CreateController(ctx *gin.Context) {
+	if !requireFlashSaleAdmin(ctx) {
+		return
+	}
+	memberID, _ := auth.GetMemberID(ctx)
+
+	var req CreateFlashSaleControllerRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		base.BadRequest(ctx, "กรุณาระบุข้อมูลให้ครบถ้วน", nil)
+		return
+	}
+
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	data, err := c.svc.Create(ctx.Request.Context(), &CreateFlashSaleServiceRequest{
+		Name:      req.Name,
+		StartsAt:  req.StartsAt,
+		EndsAt:    req.EndsAt,
+		IsActive:  isActive,
+		Items:     toFlashSaleItemRequests(req.Items),
+		CreatedBy: memberID,
+	})
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	base.Success(ctx, data)
+}
+
+func (c *Controller) UpdateController(ctx *gin.Context) {
+	if !requireFlashSaleAdmin(ctx) {
+		return
+	}
+
+	id := strings.TrimSpace(ctx.Param("id"))
+	if id == "" {
+		base.BadRequest(ctx, "ไม่พบรหัสแฟลชเซล", nil)
+		return
+	}
+
+	var req UpdateFlashSaleControllerRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		base.BadRequest(ctx, "กรุณาระบุข้อมูลให้ครบถ้วน", nil)
+		return
+	}
+
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	data, err := c.svc.Update(ctx.Request.Context(), &UpdateFlashSaleServiceRequest{
+		ID:       id,
+		Name:     req.Name,
+		StartsAt: req.StartsAt,
+		EndsAt:   req.EndsAt,
+		IsActive: isActive,
+		Items:    toFlashSaleItemRequests(req.Items),
+	})
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	base.Success(ctx, data)
+}
+
+func (c *Controller) DeleteController(ctx *gin.Context) {
+	if !requireFlashSaleAdmin(ctx) {
+		return
+	}
+
+	id := strings.TrimSpace(ctx.Param("id"))
+	if id == "" {
+		base.BadRequest(ctx, "ไม่พบรหัสแฟลชเซล", nil)
+		return
+	}
+
+	if err := c.svc.Delete(ctx.Request.Context(), id); err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	base.Success(ctx, nil)
+}
@@ -0,0 +1,573 @@
+package flashsales
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"phakram/app/utils/base"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/uptrace/bun"
+)
+
+type flashSaleRecord struct {
+	bun.BaseModel `bun:"table:flash_sales"`
+
+	ID        uuid.UUID  `bun:"id,pk,type:uuid"`
+	Name      string     `bun:"name,notnull"`
+	StartsAt  time.Time  `bun:"starts_at,notnull"`
+	EndsAt    time.Time  `bun:"ends_at,notnull"`
+	IsActive  bool       `bun:"is_active,notnull"`
+	CreatedBy *uuid.UUID `bun:"created_by,type:uuid"`
+	CreatedAt time.Time  `bun:"created_at,notnull"`
+	UpdatedAt time.Time  `bun:"updated_at,notnull"`
+}
+
+type flashSaleItemRecord struct {
+	bun.BaseModel `bun:"table:flash_sale_items"`
+
+	ID             uuid.UUID       `bun:"id,pk,type:uuid"`
+	FlashSaleID    uuid.UUID       `bun:"flash_sale_id,type:uuid,notnull"`
+	ProductID      uuid.UUID       `bun:"product_id,type:uuid,notnull"`
+	SalePrice      decimal.Decimal `bun:"sale_price,notnull"`
+	QuantityCap    int             `bun:"quantity_cap,notnull"`
+	SoldQuantity   int             `bun:"sold_quantity,notnull"`
+	PerMemberLimit *int            `bun:"per_member_limit"`
+	CreatedAt      time.Time       `bun:"created_at,notnull"`
+	UpdatedAt      time.Time       `bun:"updated_at,notnull"`
+}
+
+type FlashSaleItemServiceRequest struct {
+	ProductID      string
+	SalePrice      string
+	QuantityCap    int
+	PerMemberLimit *int
+}
+
+type CreateFlashSaleServiceRequest struct {
+	Name      string
+	StartsAt  string
+	EndsAt    string
+	IsActive  bool
+	Items     []FlashSaleItemServiceRequest
+	CreatedBy uuid.UUID
+}
+
+type UpdateFlashSaleServiceRequest struct {
+	ID       string
+	Name     string
+	StartsAt string
+	EndsAt   string
+	IsActive bool
+	Items    []FlashSaleItemServiceRequest
+}
+
+type ListFlashSalesServiceRequest struct {
+	base.RequestPaginate
+	Status string
+}
+
+type FlashSaleItem struct {
+	ID             string          `json:"id"`
+	ProductID      string          `json:"product_id"`
+	ProductNo      string          `json:"product_no"`
+	ProductName    string          `json:"product_name"`
+	RegularPrice   decimal.Decimal `json:"regular_price"`
+	SalePrice      decimal.Decimal `json:"sale_price"`
+	QuantityCap    int             `json:"quantity_cap"`
+	SoldQuantity   int             `json:"sold_quantity"`
+	Remaining      int             `json:"remaining"`
+	PerMemberLimit *int            `json:"per_member_limit"`
+}
+
+type FlashSale struct {
+	ID        string           `json:"id"`
+	Name      string           `json:"name"`
+	StartsAt  string           `json:"starts_at"`
+	EndsAt    string           `json:"ends_at"`
+	IsActive  bool             `json:"is_active"`
+	Status    string           `json:"status"`
+	Items     []*FlashSaleItem `json:"items"`
+	CreatedAt string           `json:"created_at"`
+	UpdatedAt string           `json:"updated_at"`
+}
+
+type parsedFlashSaleItem struct {
+	ProductID      uuid.UUID
+	SalePrice      decimal.Decimal
+	QuantityCap    int
+	PerMemberLimit *int
+}
+
+const (
+	statusScheduled = "scheduled"
+	statusRunning   = "running"
+	statusEnded     = "ended"
+	statusInactive  = "inactive"
+)
+
+func saleStatus(record *flashSaleRecord, now time.Time) string {
+	switch {
+	case !record.IsActive:
+		return statusInactive
+	case now.Before(record.StartsAt):
+		return statusScheduled
+	case now.Before(record.EndsAt):
+		return statusRunning
+	default:
+		return statusEnded
+	}
+}
+
+func parseSaleTime(value string) (time.Time, error) {
+	trimmed := strings.TrimSpace(value)
+	if parsed, err := time.Parse(time.RFC3339, trimmed); err == nil {
+		return parsed.UTC(), nil
+	}
+	if parsed, err := time.Parse("2006-01-02T15:04", trimmed); err == nil {
+		return parsed.UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid datetime format")
+}
+
+func parseSalePeriod(startsAt string, endsAt string) (time.Time, time.Time, error) {
+	start, err := parseSaleTime(startsAt)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	end, err := parseSaleTime(endsAt)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if !end.After(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("end date must be after start date")
+	}
+	return start, end, nil
+}
+
+func parseFlashSaleItems(items []FlashSaleItemServiceRequest) ([]*parsedFlashSaleItem, error) {
+	if len(items) == 0 {
+		return nil, fmt.Errorf("flash sale requires at least one product")
+	}
+
+	parsed := make([]*parsedFlashSaleItem, 0, len(items))
+	seen := make(map[uuid.UUID]struct{}, len(items))
+	for _, item := range items {
+		productID, err := uuid.Parse(strings.TrimSpace(item.ProductID))
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := seen[productID]; ok {
+			return nil, fmt.Errorf("duplicate flash sale product")
+		}
+		seen[productID] = struct{}{}
+
+		salePrice, err := decimal.NewFromString(strings.TrimSpace(item.SalePrice))
+		if err != nil || salePrice.IsNegative() {
+			return nil, fmt.Errorf("invalid flash sale price")
+		}
+		if item.QuantityCap <= 0 {
+			return nil, fmt.Errorf("flash sale quantity must be greater than 0")
+		}
+		if item.PerMemberLimit != nil && *item.PerMemberLimit <= 0 {
+			return nil, fmt.Errorf("flash sale quantity must be greater than 0")
+		}
+
+		parsed = append(parsed, &parsedFlashSaleItem{
+			ProductID:      productID,
+			SalePrice:      salePrice.Round(2),
+			QuantityCap:    item.QuantityCap,
+			PerMemberLimit: item.PerMemberLimit,
+		})
+	}
+	return parsed, nil
+}
+
+// ensureNoOverlap rejects products that already take part in another active
+// sale during the same period, so a shopper never sees two sale prices.
+func (s *Service) ensureNoOverlap(ctx context.Context, saleID uuid.UUID, startsAt time.Time, endsAt time.Time, items []*parsedFlashSaleItem) error {
+	productIDs := make([]uuid.UUID, 0, len(items))
+	for _, item := range items {
+		productIDs = append(productIDs, item.ProductID)
+	}
+
+	exists, err := s.bunDB.DB().NewSelect().
+		TableExpr("flash_sale_items AS fsi").
+		Join("JOIN flash_sales AS fs ON fs.id = fsi.flash_sale_id").
+		Where("fs.id <> ?", saleID).
+		Where("fs.is_active = true").
+		Where("fs.starts_at < ?", endsAt).
+		Where("fs.ends_at > ?", startsAt).
+		Where("fsi.product_id IN (?)", bun.In(productIDs)).
+		Exists(ctx)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("product is already in another flash sale")
+	}
+	return nil
+}
+
+func (s *Service) ensureProductsExist(ctx context.Context, items []*parsedFlashSaleItem) error {
+	productIDs := make([]uuid.UUID, 0, len(items))
+	for _, item := range items {
+		productIDs = append(productIDs, item.ProductID)
+	}
+
+	count, err := s.bunDB.DB().NewSelect().
+		TableExpr("products").
+		Where("id IN (?)", bun.In(productIDs)).
+		Where("deleted_at IS NULL").
+		Count(ctx)
+	if err != nil {
+		return err
+	}
+	if count != len(productIDs) {
+		return fmt.Errorf("product not found")
+	}
+	return nil
+}
+
+func (s *Service) loadItems(ctx context.Context, saleIDs []uuid.UUID) (map[uuid.UUID][]*FlashSaleItem, error) {
+	items := make(map[uuid.UUID][]*FlashSaleItem, len(saleIDs))
+	if len(saleIDs) == 0 {
+		return items, nil
+	}
+
+	type itemRow struct {
+		ID             uuid.UUID       `bun:"id"`
+		FlashSaleID    uuid.UUID       `bun:"flash_sale_id"`
+		ProductID      uuid.UUID       `bun:"product_id"`
+		ProductNo      string          `bun:"product_no"`
+		NameTh         string          `bun:"name_th"`
+		Price          decimal.Decimal `bun:"price"`
+		SalePrice      decimal.Decimal `bun:"sale_price"`
+		QuantityCap    int             `bun:"quantity_cap"`
+		SoldQuantity   int             `bun:"sold_quantity"`
+		PerMemberLimit *int            `bun:"per_member_limit"`
+	}
+	rows := make([]*itemRow, 0)
+	if err := s.bunDB.DB().NewSelect().
+		TableExpr("flash_sale_items AS fsi").
+		Join("JOIN products AS p ON p.id = fsi.product_id").
+		ColumnExpr("fsi.id, fsi.flash_sale_id, fsi.product_id").
+		ColumnExpr("p.product_no, p.name_th, p.price").
+		ColumnExpr("fsi.sale_price, fsi.quantity_cap, fsi.sold_quantity, fsi.per_member_limit").
+		Where("fsi.flash_sale_id IN (?)", bun.In(saleIDs)).
+		OrderExpr("fsi.created_at ASC, p.product_no ASC").
+		Scan(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		items[row.FlashSaleID] = append(items[row.FlashSaleID], &FlashSaleItem{
+			ID:             row.ID.String(),
+			ProductID:      row.ProductID.String(),
+			ProductNo:      row.ProductNo,
+			ProductName:    row.NameTh,
+			RegularPrice:   row.Price,
+			SalePrice:      row.SalePrice,
+			QuantityCap:    row.QuantityCap,
+			SoldQuantity:   row.SoldQuantity,
+			Remaining:      max(row.QuantityCap-row.SoldQuantity, 0),
+			PerMemberLimit: row.PerMemberLimit,
+		})
+	}
+	return items, nil
+}
+
+func toFlashSale(record *flashSaleRecord, items []*FlashSaleItem, now time.Time) *FlashSale {
+	if items == nil {
+		items = make([]*FlashSaleItem, 0)
+	}
+	return &FlashSale{
+		ID:        record.ID.String(),
+		Name:      record.Name,
+		StartsAt:  record.StartsAt.Format(time.RFC3339),
+		EndsAt:    record.EndsAt.Format(time.RFC3339),
+		IsActive:  record.IsActive,
+		Status:    saleStatus(record, now),
+		Items:     items,
+		CreatedAt: record.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: record.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+func (s *Service) List(ctx context.Context, req *ListFlashSalesServiceRequest) ([]*FlashSale, *base.ResponsePaginate, error) {
+	now := time.Now().UTC()
+	records := make([]*flashSaleRecord, 0)
+	query := s.bunDB.DB().NewSelect().Model(&records)
+
+	switch strings.TrimSpace(req.Status) {
+	case "":
+	case statusInactive:
+		query.Where("is_active = false")
+	case statusScheduled:
+		query.Where("is_active = true").Where("starts_at > ?", now)
+	case statusRunning:
+		query.Where("is_active = true").Where("starts_at <= ?", now).Where("ends_at > ?", now)
+	case statusEnded:
+		query.Where("is_active = true").Where("ends_at <= ?", now)
+	default:
+		return nil, nil, fmt.Errorf("invalid flash sale status")
+	}
+	if search := strings.TrimSpace(req.Search); search != "" {
+		query.Where("name ILIKE ?", "%"+search+"%")
+	}
+
+	total, err := query.Count(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	query.OrderExpr("starts_at DESC")
+	req.SetOffsetLimit(query)
+	if err := query.Scan(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	saleIDs := make([]uuid.UUID, 0, len(records))
+	for _, record := range records {
+		saleIDs = append(saleIDs, record.ID)
+	}
+	items, err := s.loadItems(ctx, saleIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	response := make([]*FlashSale, 0, len(records))
+	for _, record := range records {
+		response = append(response, toFlashSale(record, items[record.ID], now))
+	}
+	return response, &base.ResponsePaginate{Page: req.GetPage(), Size: req.GetSize(), Total: int64(total)}, nil
+}
+
+func (s *Service) Info(ctx context.Context, id string) (*FlashSale, error) {
+	saleID, err := uuid.Parse(strings.TrimSpace(id))
+	if err != nil {
+		return nil, err
+	}
+
+	record := new(flashSaleRecord)
+	if err := s.bunDB.DB().NewSelect().
+		Model(record).
+		Where("id = ?", saleID).
+		Limit(1).
+		Scan(ctx); err != nil {
+		return nil, err
+	}
+
+	items, err := s.loadItems(ctx, []uuid.UUID{saleID})
+	if err != nil {
+		return nil, err
+	}
+	return toFlashSale(record, items[saleID], time.Now().UTC()), nil
+}
+
+func (s *Service) Create(ctx context.Context, req *CreateFlashSaleServiceRequest) (*FlashSale, error) {
+	startsAt, endsAt, err := parseSalePeriod(req.StartsAt, req.EndsAt)
+	if err != nil {
+		return nil, err
+	}
+	items, err := parseFlashSaleItems(req.Items)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ensureProductsExist(ctx, items); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	record := &flashSaleRecord{
+		ID:        uuid.New(),
+		Name:      strings.TrimSpace(req.Name),
+		StartsAt:  startsAt,
+		EndsAt:    endsAt,
+		IsActive:  req.IsActive,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if req.CreatedBy != uuid.Nil {
+		record.CreatedBy = &req.CreatedBy
+	}
+	if record.IsActive {
+		if err := s.ensureNoOverlap(ctx, record.ID, startsAt, endsAt, items); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewInsert().Model(record).Exec(ctx); err != nil {
+			return err
+		}
+		return replaceItemsInTx(ctx, tx, record.ID, items)
+	}); err != nil {
+		return nil, err
+	}
+
+	return s.Info(ctx, record.ID.String())
+}
+
+func (s *Service) Update(ctx context.Context, req *UpdateFlashSaleServiceRequest) (*FlashSale, error) {
+	saleID, err := uuid.Parse(strings.TrimSpace(req.ID))
+	if err != nil {
+		return nil, err
+	}
+	startsAt, endsAt, err := parseSalePeriod(req.StartsAt, req.EndsAt)
+	if err != nil {
+		return nil, err
+	}
+	items, err := parseFlashSaleItems(req.Items)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ensureProductsExist(ctx, items); err != nil {
+		return nil, err
+	}
+	if req.IsActive {
+		if err := s.ensureNoOverlap(ctx, saleID, startsAt, endsAt, items); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		result, err := tx.NewUpdate().
+			Model((*flashSaleRecord)(nil)).
+			Set("name = ?", strings.TrimSpace(req.Name)).
+			Set("starts_at = ?", startsAt).
+			Set("ends_at = ?", endsAt).
+			Set("is_active = ?", req.IsActive).
+			Set("updated_at = ?", time.Now().UTC()).
+			Where("id = ?", saleID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+			return sql.ErrNoRows
+		}
+		return replaceItemsInTx(ctx, tx, saleID, items)
+	}); err != nil {
+		return nil, err
+	}
+
+	return s.Info(ctx, saleID.String())
+}
+
+// replaceItemsInTx syncs the sale's products with the request. Items keep
+// their sold count; an item can't be capped below what was sold or removed
+// once someone bought it.
+func replaceItemsInTx(ctx context.Context, tx bun.Tx, saleID uuid.UUID, items []*parsedFlashSaleItem) error {
+	existing := make([]*flashSaleItemRecord, 0)
+	if err := tx.NewSelect().
+		Model(&existing).
+		Where("flash_sale_id = ?", saleID).
+		For("UPDATE").
+		Scan(ctx); err != nil {
+		return err
+	}
+	existingByProduct := make(map[uuid.UUID]*flashSaleItemRecord, len(existing))
+	for _, item := range existing {
+		existingByProduct[item.ProductID] = item
+	}
+
+	now := time.Now().UTC()
+	kept := make(map[uuid.UUID]struct{}, len(items))
+	for _, item := range items {
+		kept[item.ProductID] = struct{}{}
+		current, ok := existingByProduct[item.ProductID]
+		if !ok {
+			if _, err := tx.NewInsert().Model(&flashSaleItemRecord{
+				ID:             uuid.New(),
+				FlashSaleID:    saleID,
+				ProductID:      item.ProductID,
+				SalePrice:      item.SalePrice,
+				QuantityCap:    item.QuantityCap,
+				SoldQuantity:   0,
+				PerMemberLimit: item.PerMemberLimit,
+				CreatedAt:      now,
+				UpdatedAt:      now,
+			}).Exec(ctx); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if item.QuantityCap < current.SoldQuantity {
+			return fmt.Errorf("flash sale quantity is below sold quantity")
+		}
+		if _, err := tx.NewUpdate().
+			Model((*flashSaleItemRecord)(nil)).
+			Set("sale_price = ?", item.SalePrice).
+			Set("quantity_cap = ?", item.QuantityCap).
+			Set("per_member_limit = ?", item.PerMemberLimit).
+			Set("updated_at = ?", now).
+			Where("id = ?", current.ID).
+			Exec(ctx); err != nil {
+			return err
+		}
+	}
+
+	for _, current := range existing {
+		if _, ok := kept[current.ProductID]; ok {
+			continue
+		}
+		purchased, err := tx.NewSelect().
+			TableExpr("flash_sale_purchases").
+			Where("flash_sale_item_id = ?", current.ID).
+			Exists(ctx)
+		if err != nil {
+			return err
+		}
+		if purchased {
+			return fmt.Errorf("flash sale item already has purchases")
+		}
+		if _, err := tx.NewDelete().
+			Model((*flashSaleItemRecord)(nil)).
+			Where("id = ?", current.ID).
+			Exec(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete removes a sale that nobody bought from. Sales with purchases keep
+// their history and should be deactivated instead.
+func (s *Service) Delete(ctx context.Context, id string) error {
+	saleID, err := uuid.Parse(strings.TrimSpace(id))
+	if err != nil {
+		return err
+	}
+
+	return s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		purchased, err := tx.NewSelect().
+			TableExpr("flash_sale_purchases AS fsp").
+			Join("JOIN flash_sale_items AS fsi ON fsi.id = fsp.flash_sale_item_id").
+			Where("fsi.flash_sale_id = ?", saleID).
+			Exists(ctx)
+		if err != nil {
+			return err
+		}
+		if purchased {
+			return errors.New("flash sale already has purchases")
+		}
+
+		result, err := tx.NewDelete().
+			Model((*flashSaleRecord)(nil)).
+			Where("id = ?", saleID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+			return sql.ErrNoRows
+		}
+		return nil
+	})
+}
@@ -0,0 +1,109 @@
+package members
+
+import (
+	"phakram/app/utils"
+	"phakram/app/utils/base"
+	"phakram/config/i18n"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type UpdateMemberWishlistAlertsControllerRequest struct {
+	BackInStock *bool `json:"back_in_stock"`
+	PriceDrop   *bool `json:"price_drop"`
+}
+
+type UnsubscribeWishlistAlertsControllerRequest struct {
+	Token string `form:"token" json:"token"`
+}
+
+func (c *Controller) UpdateMemberWishlistAlertsController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`members.ctl.wishlist.alerts.update.start`)
+
+	memberID, wishlistID, ok := c.parseMemberWishlistURI(ctx)
+	if !ok {
+		return
+	}
+
+	if !c.ensureAdminOrSelf(ctx, memberID) {
+		return
+	}
+
+	var req UpdateMemberWishlistAlertsControllerRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	data, err := c.svc.UpdateMemberWishlistAlertsService(ctx.Request.Context(), memberID, wishlistID, &UpdateMemberWishlistAlertsServiceRequest{
+		BackInStock: req.BackInStock,
+		PriceDrop:   req.PriceDrop,
+		ActionBy:    getActionBy(ctx),
+	})
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`members.ctl.wishlist.alerts.update.success`)
+	base.Success(ctx, data)
+}
+
+// WishlistAlertSubscriptionController backs the unsubscribe link sent with
+// wishlist alerts. It only reports what the token is subscribed to; the page
+// asks the member to confirm and then posts to UnsubscribeWishlistAlerts.
+func (c *Controller) WishlistAlertSubscriptionController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`members.ctl.wishlist.alerts.subscription.start`)
+
+	var req UnsubscribeWishlistAlertsControllerRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+	token, err := uuid.Parse(req.Token)
+	if err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	data, err := c.svc.WishlistAlertSubscriptionService(ctx.Request.Context(), token)
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`members.ctl.wishlist.alerts.subscription.success`)
+	base.Success(ctx, data)
+}
+
+// UnsubscribeWishlistAlertsController turns the alerts off. It takes the
+// token from the body or, for RFC 8058 one-click posts, the query string.
+func (c *Controller) UnsubscribeWishlistAlertsController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`members.ctl.wishlist.alerts.unsubscribe.start`)
+
+	var req UnsubscribeWishlistAlertsControllerRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+	if req.Token == "" {
+		req.Token = ctx.Query("token")
+	}
+	token, err := uuid.Parse(req.Token)
+	if err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	if err := c.svc.UnsubscribeWishlistAlertsService(ctx.Request.Context(), token); err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`members.ctl.wishlist.alerts.unsubscribe.success`)
+	base.Success(ctx, nil)
+}
@@ -0,0 +1,356 @@
+package members
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"phakram/app/modules/entities/ent"
+	"phakram/app/utils"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/uptrace/bun"
+)
+
+const (
+	wishlistAlertBatchSize = 500
+	// wishlistAlertThrottle is the least time between two alerts of the same
+	// kind for one wishlist item. A change seen inside it is sent once the
+	// window has passed, if it still holds.
+	wishlistAlertThrottle = 24 * time.Hour
+
+	WishlistAlertBackInStock = "wishlist_back_in_stock"
+	WishlistAlertPriceDrop   = "wishlist_price_drop"
+)
+
+type UpdateMemberWishlistAlertsServiceRequest struct {
+	BackInStock *bool
+	PriceDrop   *bool
+	ActionBy    *uuid.UUID
+}
+
+type MemberWishlistAlerts struct {
+	WishlistID           uuid.UUID  `json:"wishlist_id"`
+	BackInStock          bool       `json:"back_in_stock"`
+	PriceDrop            bool       `json:"price_drop"`
+	BackInStockAlertedAt *time.Time `json:"back_in_stock_alerted_at"`
+	PriceDropAlertedAt   *time.Time `json:"price_drop_alerted_at"`
+}
+
+// WishlistAlertSubscription is what the unsubscribe page shows before the
+// member confirms.
+type WishlistAlertSubscription struct {
+	Subscribed    bool   `bun:"-" json:"subscribed"`
+	ProductNameTh string `bun:"product_name_th" json:"product_name_th"`
+	BackInStock   bool   `bun:"back_in_stock" json:"back_in_stock"`
+	PriceDrop     bool   `bun:"price_drop" json:"price_drop"`
+}
+
+type WishlistAlertResult struct {
+	BackInStock int `json:"back_in_stock"`
+	PriceDrop   int `json:"price_drop"`
+}
+
+type wishlistAlertRow struct {
+	ID                   uuid.UUID        `bun:"id"`
+	MemberID             uuid.UUID        `bun:"member_id"`
+	ProductID            uuid.UUID        `bun:"product_id"`
+	PricePerUnit         decimal.Decimal  `bun:"price_per_unit"`
+	AlertBackInStock     bool             `bun:"alert_back_in_stock"`
+	AlertPriceDrop       bool             `bun:"alert_price_drop"`
+	AlertInStock         *bool            `bun:"alert_in_stock"`
+	AlertNotifiedPrice   *decimal.Decimal `bun:"alert_notified_price"`
+	BackInStockAlertedAt *time.Time       `bun:"back_in_stock_alerted_at"`
+	PriceDropAlertedAt   *time.Time       `bun:"price_drop_alerted_at"`
+	ProductNameTh        string           `bun:"product_name_th"`
+	Price                decimal.Decimal  `bun:"price"`
+	Remaining            int              `bun:"remaining"`
+}
+
+func toMemberWishlistAlerts(data *ent.MemberWishlistEntity) *MemberWishlistAlerts {
+	return &MemberWishlistAlerts{
+		WishlistID:           data.ID,
+		BackInStock:          data.AlertBackInStock,
+		PriceDrop:            data.AlertPriceDrop,
+		BackInStockAlertedAt: data.BackInStockAlertedAt,
+		PriceDropAlertedAt:   data.PriceDropAlertedAt,
+	}
+}
+
+func productRemainingQuery(db bun.IDB, productID uuid.UUID) *bun.SelectQuery {
+	return db.NewSelect().
+		TableExpr("product_stocks").
+		ColumnExpr("COALESCE(SUM(remaining), 0)").
+		Where("product_id = ?", productID).
+		Where("deleted_at IS NULL")
+}
+
+// UpdateMemberWishlistAlertsService switches the back-in-stock and price-drop
+// alerts of a wishlist item on or off. Turning an alert on records the
+// current stock and price, so only later changes are notified.
+func (s *Service) UpdateMemberWishlistAlertsService(ctx context.Context, memberID uuid.UUID, wishlistID uuid.UUID, req *UpdateMemberWishlistAlertsServiceRequest) (*MemberWishlistAlerts, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`members.svc.wishlist.alerts.update.start`)
+
+	now := time.Now()
+	data := new(ent.MemberWishlistEntity)
+	err := s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if err := tx.NewSelect().Model(data).Where("id = ?", wishlistID).For("UPDATE").Scan(ctx); err != nil {
+			return err
+		}
+		if data.MemberID != memberID {
+			return errors.New("member wishlist not found")
+		}
+
+		if req.BackInStock != nil {
+			if *req.BackInStock && !data.AlertBackInStock {
+				var remaining int
+				if err := productRemainingQuery(tx, data.ProductID).Scan(ctx, &remaining); err != nil {
+					return err
+				}
+				inStock := remaining > 0
+				data.AlertInStock = &inStock
+			}
+			data.AlertBackInStock = *req.BackInStock
+		}
+		if req.PriceDrop != nil {
+			if *req.PriceDrop && !data.AlertPriceDrop {
+				data.AlertNotifiedPrice = nil
+			}
+			data.AlertPriceDrop = *req.PriceDrop
+		}
+		data.UpdatedAt = now
+
+		if _, err := tx.NewUpdate().
+			Model(data).
+			Column("alert_back_in_stock", "alert_price_drop", "alert_in_stock", "alert_notified_price", "updated_at").
+			WherePK().
+			Exec(ctx); err != nil {
+			return err
+		}
+		detail := fmt.Sprintf("Updated wishlist alerts with ID %s: back_in_stock=%t, price_drop=%t", data.ID, data.AlertBackInStock, data.AlertPriceDrop)
+		return s.logMemberActionTx(ctx, tx, memberID, ent.MemberActionUpdated, ent.AuditActionUpdated, "update_member_wishlist_alerts", data.ID, req.ActionBy, detail, now)
+	})
+	if err != nil {
+		s.logMemberActionFailed(ctx, ent.AuditActionUpdated, "update_member_wishlist_alerts", wishlistID, req.ActionBy, now, err)
+		return nil, err
+	}
+
+	span.AddEvent(`members.svc.wishlist.alerts.update.success`)
+	return toMemberWishlistAlerts(data), nil
+}
+
+// WishlistAlertSubscriptionService looks up the wishlist item behind an
+// unsubscribe token without changing it, so opening the link, or a mail
+// scanner prefetching it, unsubscribes no one. An unknown token reads as not
+// subscribed.
+func (s *Service) WishlistAlertSubscriptionService(ctx context.Context, token uuid.UUID) (*WishlistAlertSubscription, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`members.svc.wishlist.alerts.subscription.start`)
+
+	data := new(WishlistAlertSubscription)
+	err := s.bunDB.DB().NewSelect().
+		TableExpr("member_wishlist AS mw").
+		Join("LEFT JOIN products AS p ON p.id = mw.product_id").
+		ColumnExpr("COALESCE(p.name_th, '') AS product_name_th").
+		ColumnExpr("mw.alert_back_in_stock AS back_in_stock").
+		ColumnExpr("mw.alert_price_drop AS price_drop").
+		Where("mw.alert_token = ?", token).
+		Limit(1).
+		Scan(ctx, data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &WishlistAlertSubscription{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	data.Subscribed = data.BackInStock || data.PriceDrop
+
+	span.AddEvent(`members.svc.wishlist.alerts.subscription.success`)
+	return data, nil
+}
+
+// UnsubscribeWishlistAlertsService turns off every alert of the wishlist item
+// behind an unsubscribe token. It needs no login so it can back a one-click
+// link; an unknown or already used token is not an error.
+func (s *Service) UnsubscribeWishlistAlertsService(ctx context.Context, token uuid.UUID) error {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`members.svc.wishlist.alerts.unsubscribe.start`)
+
+	now := time.Now()
+	err := s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		data := new(ent.MemberWishlistEntity)
+		err := tx.NewSelect().Model(data).Where("alert_token = ?", token).For("UPDATE").Scan(ctx)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !data.AlertBackInStock && !data.AlertPriceDrop {
+			return nil
+		}
+
+		data.AlertBackInStock = false
+		data.AlertPriceDrop = false
+		data.UpdatedAt = now
+		if _, err := tx.NewUpdate().
+			Model(data).
+			Column("alert_back_in_stock", "alert_price_drop", "updated_at").
+			WherePK().
+			Exec(ctx); err != nil {
+			return err
+		}
+		return s.logMemberActionTx(ctx, tx, data.MemberID, ent.MemberActionUpdated, ent.AuditActionUpdated, "unsubscribe_member_wishlist_alerts", data.ID, nil, "Unsubscribed wishlist alerts with ID "+data.ID.String(), now)
+	})
+	if err != nil {
+		return err
+	}
+
+	span.AddEvent(`members.svc.wishlist.alerts.unsubscribe.success`)
+	return nil
+}
+
+// RunWishlistAlerts notifies members whose wishlist items came back in stock
+// or dropped below the price they were saved at. The notification is an
+// audit log entry, which is what the member notification feed reads. It is
+// safe to run repeatedly: each item remembers what it was last alerted on.
+func (s *Service) RunWishlistAlerts(ctx context.Context, now time.Time) (*WishlistAlertResult, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`members.svc.wishlist.alerts.run.start`)
+
+	now = now.UTC()
+	result := &WishlistAlertResult{}
+	lastID := uuid.Nil
+	for {
+		rows := make([]*wishlistAlertRow, 0)
+		if err := s.bunDB.DB().NewSelect().
+			TableExpr("member_wishlist AS mw").
+			Join("JOIN products AS p ON p.id = mw.product_id AND p.deleted_at IS NULL").
+			Join("LEFT JOIN (SELECT product_id, SUM(remaining) AS remaining FROM product_stocks WHERE deleted_at IS NULL GROUP BY product_id) AS ps ON ps.product_id = mw.product_id").
+			ColumnExpr("mw.id, mw.member_id, mw.product_id, mw.price_per_unit").
+			ColumnExpr("mw.alert_back_in_stock, mw.alert_price_drop, mw.alert_in_stock, mw.alert_notified_price").
+			ColumnExpr("mw.back_in_stock_alerted_at, mw.price_drop_alerted_at").
+			ColumnExpr("p.name_th AS product_name_th, p.price").
+			ColumnExpr("COALESCE(ps.remaining, 0) AS remaining").
+			Where("(mw.alert_back_in_stock OR mw.alert_price_drop)").
+			Where("p.status = ?", ent.ProductStatusPublished).
+			Where("mw.id > ?", lastID).
+			OrderExpr("mw.id ASC").
+			Limit(wishlistAlertBatchSize).
+			Scan(ctx, &rows); err != nil {
+			return nil, err
+		}
+		if len(rows) == 0 {
+			break
+		}
+		lastID = rows[len(rows)-1].ID
+
+		if err := s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			logs := make([]*ent.AuditLogEntity, 0)
+			for _, row := range rows {
+				kinds, changed := evaluateWishlistAlert(row, now)
+				if !changed {
+					continue
+				}
+				if _, err := tx.NewUpdate().
+					TableExpr("member_wishlist").
+					Set("alert_in_stock = ?", row.AlertInStock).
+					Set("alert_notified_price = ?", row.AlertNotifiedPrice).
+					Set("back_in_stock_alerted_at = ?", row.BackInStockAlertedAt).
+					Set("price_drop_alerted_at = ?", row.PriceDropAlertedAt).
+					Where("id = ?", row.ID).
+					Exec(ctx); err != nil {
+					return err
+				}
+				for _, kind := range kinds {
+					logs = append(logs, &ent.AuditLogEntity{
+						ID:           uuid.New(),
+						Action:       ent.AuditActionCreated,
+						ActionType:   kind,
+						ActionID:     row.ID,
+						Status:       ent.StatusAuditSuccesses,
+						ActionDetail: fmt.Sprintf("product=%s; price=%s; saved_price=%s", row.ProductNameTh, row.Price.StringFixed(2), row.PricePerUnit.StringFixed(2)),
+						CreatedAt:    now,
+						UpdatedAt:    now,
+					})
+					if kind == WishlistAlertBackInStock {
+						result.BackInStock++
+					} else {
+						result.PriceDrop++
+					}
+				}
+			}
+			if len(logs) == 0 {
+				return nil
+			}
+			_, err := tx.NewInsert().Model(&logs).Exec(ctx)
+			return err
+		}); err != nil {
+			return nil, err
+		}
+
+		if len(rows) < wishlistAlertBatchSize {
+			break
+		}
+	}
+
+	span.AddEvent(`members.svc.wishlist.alerts.run.success`)
+	return result, nil
+}
+
+// evaluateWishlistAlert compares an item with what it was last alerted on,
+// updates that state in place and returns the alerts to send. changed is
+// false when nothing needs saving.
+func evaluateWishlistAlert(row *wishlistAlertRow, now time.Time) ([]string, bool) {
+	kinds := make([]string, 0, 2)
+	changed := false
+	throttled := func(last *time.Time) bool {
+		return last != nil && now.Sub(*last) < wishlistAlertThrottle
+	}
+
+	if row.AlertBackInStock {
+		inStock := row.Remaining > 0
+		switch {
+		case row.AlertInStock == nil || *row.AlertInStock == inStock:
+			if row.AlertInStock == nil {
+				row.AlertInStock = &inStock
+				changed = true
+			}
+		case inStock && throttled(row.BackInStockAlertedAt):
+			// Keep the out-of-stock state so the alert goes out later.
+		case inStock:
+			row.AlertInStock = &inStock
+			row.BackInStockAlertedAt = &now
+			kinds = append(kinds, WishlistAlertBackInStock)
+			changed = true
+		default:
+			row.AlertInStock = &inStock
+			changed = true
+		}
+	}
+
+	if row.AlertPriceDrop {
+		dropped := row.Price.LessThan(row.PricePerUnit)
+		switch {
+		case !dropped:
+			if row.AlertNotifiedPrice != nil {
+				row.AlertNotifiedPrice = nil
+				changed = true
+			}
+		case row.AlertNotifiedPrice != nil && !row.Price.LessThan(*row.AlertNotifiedPrice):
+		case throttled(row.PriceDropAlertedAt):
+		default:
+			price := row.Price
+			row.AlertNotifiedPrice = &price
+			row.PriceDropAlertedAt = &now
+			kinds = append(kinds, WishlistAlertPriceDrop)
+			changed = true
+		}
+	}
+
+	return kinds, changed
+}
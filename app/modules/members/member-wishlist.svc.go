@@ -117,6 +117,12 @@ func (s *Service) UpdateMemberWishlistService(ctx context.Context, memberID uuid
 			return errors.New("member wishlist not found")
 		}
 
+		if data.ProductID != req.ProductID {
+			data.AlertInStock = nil
+			data.AlertNotifiedPrice = nil
+		} else if !data.PricePerUnit.Equal(pricePerUnit) {
+			data.AlertNotifiedPrice = nil
+		}
 		data.ProductID = req.ProductID
 		data.Quantity = req.Quantity
 		data.PricePerUnit = pricePerUnit
@@ -8,13 +8,16 @@ import (
 	"phakram/app/modules/categories"
 	"phakram/app/modules/contact"
 	"phakram/app/modules/districts"
+	"phakram/app/modules/documents"
 	"phakram/app/modules/entities"
 	"phakram/app/modules/example"
 	exampletwo "phakram/app/modules/example-two"
+	"phakram/app/modules/flashsales"
 	"phakram/app/modules/genders"
 	"phakram/app/modules/members"
 	"phakram/app/modules/orders"
 	"phakram/app/modules/payments"
+	"phakram/app/modules/payouts"
 	"phakram/app/modules/prefixes"
 	productdetails "phakram/app/modules/product_details"
 	productstocks "phakram/app/modules/product_stocks"
@@ -30,6 +33,7 @@ import (
 	systembankaccounts "phakram/app/modules/system_bank_accounts"
 	"phakram/app/modules/tiers"
 	"phakram/app/modules/zipcodes"
+	"phakram/app/utils/notify"
 	appConf "phakram/config"
 	"phakram/internal/config"
 	"phakram/internal/database"
@@ -74,6 +78,9 @@ type Modules struct {
 	Carts              *carts.Module
 	Promotions         *promotions.Module
 	Reviews            *reviews.Module
+	Documents          *documents.Module
+	Payouts            *payouts.Module
+	FlashSales         *flashsales.Module
 }
 
 func modulesInit() {
@@ -121,7 +128,13 @@ func modulesInit() {
 		PrivateBucket:  conf.RailwayStorage.PrivateBucket,
 	})
 	productDetailsMod := productdetails.New(db.Svc)
-	productStocksMod := productstocks.New(db.Svc)
+	productStocksConf := conf.ProductStocks
+	if !productStocksConf.Mail.Enabled() {
+		// Low-stock emails go out through the contact form's SMTP server
+		// unless one is set for them.
+		productStocksConf.Mail = notify.MailConfig(conf.Contact.Mail)
+	}
+	productStocksMod := productstocks.New(db.Svc, &productStocksConf)
 	storagesMod := storages.New(db.Svc, entitiesMod.Svc, storages.RailwayConfig{
 		URL:            conf.RailwayStorage.URL,
 		ServiceRoleKey: conf.RailwayStorage.ServiceRoleKey,
@@ -151,7 +164,7 @@ func modulesInit() {
 	contactMod := contact.New(db.Svc, &conf.Contact)
 	paymentsMod := payments.New(db.Svc, entitiesMod.Svc)
 	cartsMod := carts.New(db.Svc, entitiesMod.Svc, entitiesMod.Svc)
-	promotionsMod := promotions.New(db.Svc)
+	promotionsMod := promotions.New(db.Svc, &conf.Promotions)
 	reviewsMod := reviews.New(db.Svc, reviews.RailwayConfig{
 		URL:            conf.RailwayStorage.URL,
 		ServiceRoleKey: conf.RailwayStorage.ServiceRoleKey,
@@ -159,6 +172,19 @@ func modulesInit() {
 		ReviewBucket:   conf.RailwayStorage.ReviewBucket,
 		PrivateBucket:  conf.RailwayStorage.PrivateBucket,
 	})
+	documentsMod := documents.New(db.Svc, &conf.Documents, documents.RailwayConfig{
+		URL:            conf.RailwayStorage.URL,
+		ServiceRoleKey: conf.RailwayStorage.ServiceRoleKey,
+		PublicBucket:   conf.RailwayStorage.PublicBucket,
+		PrivateBucket:  conf.RailwayStorage.PrivateBucket,
+	})
+	if err := documentsMod.Svc.CheckFonts(); err != nil {
+		log.With(
+			slog.String("documents_font_dir", strings.TrimSpace(conf.Documents.FontDir)),
+		).Warnf("receipts and tax invoices cannot be rendered: %s", err)
+	}
+	payoutsMod := payouts.New(db.Svc)
+	flashSalesMod := flashsales.New(db.Svc)
 	mod = &Modules{
 		Conf:               confMod,
 		Specs:              specsMod,
@@ -192,6 +218,9 @@ func modulesInit() {
 		Carts:              cartsMod,
 		Promotions:         promotionsMod,
 		Reviews:            reviewsMod,
+		Documents:          documentsMod,
+		Payouts:            payoutsMod,
+		FlashSales:         flashSalesMod,
 	}
 
 	log.Infof("all modules initialized")
@@ -0,0 +1,191 @@
+package orders
+
+import (
+	"phakram/app/modules/auth"
+	"phakram/app/utils"
+	"phakram/app/utils/base"
+	"phakram/config/i18n"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type UpdateCODSettingControllerRequest struct {
+	IsEnabled       bool   `json:"is_enabled"`
+	FeeType         string `json:"fee_type"`
+	FeeValue        string `json:"fee_value"`
+	MaxFee          string `json:"max_fee"`
+	MinOrderAmount  string `json:"min_order_amount"`
+	MaxOrderAmount  string `json:"max_order_amount"`
+	MaxRefusedCount int    `json:"max_refused_count"`
+}
+
+type CODEligibilityControllerRequest struct {
+	MemberID    string `form:"member_id"`
+	OrderAmount string `form:"order_amount"`
+}
+
+type CollectCODPaymentControllerRequest struct {
+	CollectedAmount string `json:"collected_amount"`
+}
+
+type RefuseCODOrderControllerRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (c *Controller) GetCODSettingController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`orders.ctl.cod.setting.info.start`)
+
+	_, hasRequester := auth.GetMemberID(ctx)
+	isAdmin := auth.GetIsAdmin(ctx)
+	if !isAdmin || !hasRequester {
+		base.Forbidden(ctx, i18n.Forbidden, nil)
+		return
+	}
+
+	data, err := c.svc.GetCODSettingService(ctx.Request.Context())
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`orders.ctl.cod.setting.info.success`)
+	base.Success(ctx, data)
+}
+
+func (c *Controller) UpdateCODSettingController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`orders.ctl.cod.setting.update.start`)
+
+	var req UpdateCODSettingControllerRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	requesterID, hasRequester := auth.GetMemberID(ctx)
+	isAdmin := auth.GetIsAdmin(ctx)
+	if !isAdmin || !hasRequester {
+		base.Forbidden(ctx, i18n.Forbidden, nil)
+		return
+	}
+
+	data, err := c.svc.UpdateCODSettingService(ctx.Request.Context(), &UpdateCODSettingServiceRequest{
+		IsEnabled:       req.IsEnabled,
+		FeeType:         req.FeeType,
+		FeeValue:        req.FeeValue,
+		MaxFee:          req.MaxFee,
+		MinOrderAmount:  req.MinOrderAmount,
+		MaxOrderAmount:  req.MaxOrderAmount,
+		MaxRefusedCount: req.MaxRefusedCount,
+	}, requesterID)
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`orders.ctl.cod.setting.update.success`)
+	base.Success(ctx, data)
+}
+
+func (c *Controller) CODEligibilityController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`orders.ctl.cod.eligibility.start`)
+
+	var req CODEligibilityControllerRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	requesterID, hasRequester := auth.GetMemberID(ctx)
+	isAdmin := auth.GetIsAdmin(ctx)
+	if !isAdmin && !hasRequester {
+		base.Forbidden(ctx, i18n.Forbidden, nil)
+		return
+	}
+
+	memberID := requesterID
+	if isAdmin && req.MemberID != "" {
+		parsedMemberID, err := uuid.Parse(req.MemberID)
+		if err != nil {
+			base.BadRequest(ctx, i18n.BadRequest, nil)
+			return
+		}
+		memberID = parsedMemberID
+	}
+
+	data, err := c.svc.CODEligibilityService(ctx.Request.Context(), memberID, req.OrderAmount)
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`orders.ctl.cod.eligibility.success`)
+	base.Success(ctx, data)
+}
+
+func (c *Controller) CollectCODPaymentController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`orders.ctl.cod.collect.start`)
+
+	orderID, ok := c.parseOrderID(ctx)
+	if !ok {
+		return
+	}
+
+	var req CollectCODPaymentControllerRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	requesterID, hasRequester := auth.GetMemberID(ctx)
+	isAdmin := auth.GetIsAdmin(ctx)
+	if !isAdmin || !hasRequester {
+		base.Forbidden(ctx, i18n.Forbidden, nil)
+		return
+	}
+
+	data, err := c.svc.CollectCODPaymentService(ctx.Request.Context(), orderID, &CollectCODPaymentServiceRequest{CollectedAmount: req.CollectedAmount}, requesterID)
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`orders.ctl.cod.collect.success`)
+	base.Success(ctx, data)
+}
+
+func (c *Controller) RefuseCODOrderController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`orders.ctl.cod.refuse.start`)
+
+	orderID, ok := c.parseOrderID(ctx)
+	if !ok {
+		return
+	}
+
+	var req RefuseCODOrderControllerRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	requesterID, hasRequester := auth.GetMemberID(ctx)
+	isAdmin := auth.GetIsAdmin(ctx)
+	if !isAdmin || !hasRequester {
+		base.Forbidden(ctx, i18n.Forbidden, nil)
+		return
+	}
+
+	data, err := c.svc.RefuseCODOrderService(ctx.Request.Context(), orderID, &RefuseCODOrderServiceRequest{Reason: req.Reason}, requesterID)
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`orders.ctl.cod.refuse.success`)
+	base.Success(ctx, data)
+}
@@ -0,0 +1,601 @@
+package orders
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"phakram/app/modules/entities/ent"
+	"phakram/app/utils"
+	"phakram/app/utils/flashsale"
+	"phakram/app/utils/inventory"
+	promotionscope "phakram/app/utils/promotion"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/uptrace/bun"
+)
+
+const (
+	codFeeTypeAmount  = "amount"
+	codFeeTypePercent = "percent"
+)
+
+type UpdateCODSettingServiceRequest struct {
+	IsEnabled       bool
+	FeeType         string
+	FeeValue        string
+	MaxFee          string
+	MinOrderAmount  string
+	MaxOrderAmount  string
+	MaxRefusedCount int
+}
+
+type CODEligibilityServiceResponse struct {
+	Eligible     bool            `json:"eligible"`
+	Reason       string          `json:"reason,omitempty"`
+	OrderAmount  decimal.Decimal `json:"order_amount"`
+	CODFee       decimal.Decimal `json:"cod_fee"`
+	NetAmount    decimal.Decimal `json:"net_amount"`
+	RefusedCount int             `json:"refused_count"`
+}
+
+type CollectCODPaymentServiceRequest struct {
+	CollectedAmount string
+}
+
+type RefuseCODOrderServiceRequest struct {
+	Reason string
+}
+
+func parsePaymentMethod(method string) (ent.PaymentMethodEnum, error) {
+	switch strings.ToLower(strings.TrimSpace(method)) {
+	case "", string(ent.PaymentMethodTransfer):
+		return ent.PaymentMethodTransfer, nil
+	case string(ent.PaymentMethodCOD):
+		return ent.PaymentMethodCOD, nil
+	default:
+		return "", errors.New("invalid payment method")
+	}
+}
+
+func (s *Service) getPaymentMethod(ctx context.Context, db bun.IDB, paymentID uuid.UUID) (ent.PaymentMethodEnum, error) {
+	if paymentID == uuid.Nil {
+		return ent.PaymentMethodTransfer, nil
+	}
+
+	payment := new(ent.PaymentEntity)
+	if err := db.NewSelect().Model(payment).Column("method").Where("id = ?", paymentID).Limit(1).Scan(ctx); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ent.PaymentMethodTransfer, nil
+		}
+		return "", err
+	}
+	if payment.Method == "" {
+		return ent.PaymentMethodTransfer, nil
+	}
+
+	return payment.Method, nil
+}
+
+func (s *Service) getCODSetting(ctx context.Context, db bun.IDB) (*ent.CODSettingEntity, error) {
+	setting := new(ent.CODSettingEntity)
+	err := db.NewSelect().
+		Model(setting).
+		OrderExpr("updated_at DESC").
+		Limit(1).
+		Scan(ctx)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &ent.CODSettingEntity{
+				IsEnabled:       false,
+				FeeType:         codFeeTypeAmount,
+				FeeValue:        decimal.Zero,
+				MinOrderAmount:  decimal.Zero,
+				MaxRefusedCount: 1,
+			}, nil
+		}
+		return nil, err
+	}
+
+	return setting, nil
+}
+
+func (s *Service) GetCODSettingService(ctx context.Context) (*ent.CODSettingEntity, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`orders.svc.cod.setting.info.start`)
+
+	setting, err := s.getCODSetting(ctx, s.bunDB.DB())
+	if err != nil {
+		return nil, err
+	}
+
+	span.AddEvent(`orders.svc.cod.setting.info.success`)
+	return setting, nil
+}
+
+func (s *Service) UpdateCODSettingService(ctx context.Context, req *UpdateCODSettingServiceRequest, updatedBy uuid.UUID) (*ent.CODSettingEntity, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`orders.svc.cod.setting.update.start`)
+
+	feeType := strings.ToLower(strings.TrimSpace(req.FeeType))
+	if feeType == "" {
+		feeType = codFeeTypeAmount
+	}
+	if feeType != codFeeTypeAmount && feeType != codFeeTypePercent {
+		return nil, errors.New("invalid cod fee type")
+	}
+
+	feeValue, err := parseOptionalDecimal(req.FeeValue)
+	if err != nil {
+		return nil, err
+	}
+	minOrderAmount, err := parseOptionalDecimal(req.MinOrderAmount)
+	if err != nil {
+		return nil, err
+	}
+	if feeValue.IsNegative() || minOrderAmount.IsNegative() {
+		return nil, errors.New("invalid cod setting amount")
+	}
+	if feeType == codFeeTypePercent && feeValue.GreaterThan(decimal.NewFromInt(100)) {
+		return nil, errors.New("invalid cod setting amount")
+	}
+
+	var maxFee *decimal.Decimal
+	if strings.TrimSpace(req.MaxFee) != "" {
+		parsed, err := decimal.NewFromString(strings.TrimSpace(req.MaxFee))
+		if err != nil {
+			return nil, err
+		}
+		if parsed.IsNegative() {
+			return nil, errors.New("invalid cod setting amount")
+		}
+		maxFee = &parsed
+	}
+
+	var maxOrderAmount *decimal.Decimal
+	if strings.TrimSpace(req.MaxOrderAmount) != "" {
+		parsed, err := decimal.NewFromString(strings.TrimSpace(req.MaxOrderAmount))
+		if err != nil {
+			return nil, err
+		}
+		if parsed.LessThan(minOrderAmount) {
+			return nil, errors.New("invalid cod setting amount")
+		}
+		maxOrderAmount = &parsed
+	}
+
+	maxRefusedCount := req.MaxRefusedCount
+	if maxRefusedCount < 0 {
+		maxRefusedCount = 0
+	}
+
+	setting := new(ent.CODSettingEntity)
+	if err := s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		now := time.Now()
+		current, err := s.getCODSetting(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		isNew := current.ID == uuid.Nil
+		setting = current
+		if isNew {
+			setting.ID = uuid.New()
+			setting.CreatedAt = now
+		}
+		setting.IsEnabled = req.IsEnabled
+		setting.FeeType = feeType
+		setting.FeeValue = feeValue
+		setting.MaxFee = maxFee
+		setting.MinOrderAmount = minOrderAmount
+		setting.MaxOrderAmount = maxOrderAmount
+		setting.MaxRefusedCount = maxRefusedCount
+		setting.UpdatedBy = &updatedBy
+		setting.UpdatedAt = now
+
+		if isNew {
+			_, err = tx.NewInsert().Model(setting).Exec(ctx)
+		} else {
+			_, err = tx.NewUpdate().Model(setting).Where("id = ?", setting.ID).Exec(ctx)
+		}
+		if err != nil {
+			return err
+		}
+
+		auditLog := &ent.AuditLogEntity{
+			ID:           uuid.New(),
+			Action:       ent.AuditActionUpdated,
+			ActionType:   "cod_setting_updated",
+			ActionID:     setting.ID,
+			ActionBy:     &updatedBy,
+			Status:       ent.StatusAuditSuccesses,
+			ActionDetail: fmt.Sprintf("COD setting updated: enabled=%t fee_type=%s fee_value=%s", setting.IsEnabled, setting.FeeType, setting.FeeValue.StringFixed(2)),
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		}
+		_, err = tx.NewInsert().Model(auditLog).Exec(ctx)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	span.AddEvent(`orders.svc.cod.setting.update.success`)
+	return setting, nil
+}
+
+func parseOptionalDecimal(value string) (decimal.Decimal, error) {
+	trimmed := strings.TrimSpace(value)
+	if trimmed == "" {
+		return decimal.Zero, nil
+	}
+	return decimal.NewFromString(trimmed)
+}
+
+func calculateCODFee(setting *ent.CODSettingEntity, orderAmount decimal.Decimal) decimal.Decimal {
+	if setting == nil {
+		return decimal.Zero
+	}
+
+	fee := setting.FeeValue
+	if setting.FeeType == codFeeTypePercent {
+		fee = orderAmount.Mul(setting.FeeValue).Div(decimal.NewFromInt(100))
+	}
+	if setting.MaxFee != nil && setting.MaxFee.GreaterThan(decimal.Zero) && fee.GreaterThan(*setting.MaxFee) {
+		fee = *setting.MaxFee
+	}
+	if fee.IsNegative() {
+		fee = decimal.Zero
+	}
+
+	return fee.Round(2)
+}
+
+func (s *Service) countMemberCODRefusals(ctx context.Context, db bun.IDB, memberID uuid.UUID) (int, error) {
+	return db.NewSelect().
+		Model((*ent.OrderCODRefusalEntity)(nil)).
+		Where("member_id = ?", memberID).
+		Count(ctx)
+}
+
+// evaluateCODEligibility checks the store COD setting and the member's refusal
+// history against the order amount before COD fee is added.
+func (s *Service) evaluateCODEligibility(ctx context.Context, memberID uuid.UUID, orderAmount decimal.Decimal) (*CODEligibilityServiceResponse, error) {
+	setting, err := s.getCODSetting(ctx, s.bunDB.DB())
+	if err != nil {
+		return nil, err
+	}
+
+	refusedCount, err := s.countMemberCODRefusals(ctx, s.bunDB.DB(), memberID)
+	if err != nil {
+		return nil, err
+	}
+
+	return codEligibility(setting, refusedCount, orderAmount), nil
+}
+
+// codEligibility decides COD for an order amount, which is what the member
+// pays for the goods including VAT charged on top, before the COD fee.
+func codEligibility(setting *ent.CODSettingEntity, refusedCount int, orderAmount decimal.Decimal) *CODEligibilityServiceResponse {
+	fee := calculateCODFee(setting, orderAmount)
+	result := &CODEligibilityServiceResponse{
+		Eligible:     true,
+		OrderAmount:  orderAmount.Round(2),
+		CODFee:       fee,
+		NetAmount:    orderAmount.Add(fee).Round(2),
+		RefusedCount: refusedCount,
+	}
+
+	switch {
+	case !setting.IsEnabled:
+		result.Eligible = false
+		result.Reason = "ร้านค้ายังไม่เปิดให้บริการเก็บเงินปลายทาง"
+	case orderAmount.LessThan(setting.MinOrderAmount):
+		result.Eligible = false
+		result.Reason = fmt.Sprintf("ยอดสั่งซื้อขั้นต่ำสำหรับเก็บเงินปลายทางคือ %s บาท", setting.MinOrderAmount.StringFixed(2))
+	case setting.MaxOrderAmount != nil && orderAmount.GreaterThan(*setting.MaxOrderAmount):
+		result.Eligible = false
+		result.Reason = fmt.Sprintf("ยอดสั่งซื้อเกิน %s บาท ไม่สามารถเก็บเงินปลายทางได้", setting.MaxOrderAmount.StringFixed(2))
+	case setting.MaxRefusedCount > 0 && refusedCount >= setting.MaxRefusedCount:
+		result.Eligible = false
+		result.Reason = "บัญชีนี้มีประวัติปฏิเสธรับพัสดุเก็บเงินปลายทาง"
+	}
+
+	if !result.Eligible {
+		result.CODFee = decimal.Zero
+		result.NetAmount = orderAmount.Round(2)
+	}
+
+	return result
+}
+
+func (s *Service) CODEligibilityService(ctx context.Context, memberID uuid.UUID, orderAmount string) (*CODEligibilityServiceResponse, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`orders.svc.cod.eligibility.start`)
+
+	amount, err := parseOptionalDecimal(orderAmount)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.evaluateCODEligibility(ctx, memberID, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	span.AddEvent(`orders.svc.cod.eligibility.success`)
+	return result, nil
+}
+
+func (s *Service) CollectCODPaymentService(ctx context.Context, orderID uuid.UUID, req *CollectCODPaymentServiceRequest, collectorID uuid.UUID) (*OrderPaymentServiceResponse, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`orders.svc.cod.collect.start`)
+
+	order, err := s.ensureOrderAccess(ctx, orderID, collectorID, true)
+	if err != nil {
+		return nil, err
+	}
+	if order.PaymentID == uuid.Nil {
+		return nil, errors.New("payment not found")
+	}
+
+	paymentMethod, err := s.getPaymentMethod(ctx, s.bunDB.DB(), order.PaymentID)
+	if err != nil {
+		return nil, err
+	}
+	if paymentMethod != ent.PaymentMethodCOD {
+		return nil, errors.New("order is not cash on delivery")
+	}
+	if order.Status != ent.StatusTypePending && order.Status != ent.StatusTypeShipping {
+		return nil, errors.New("cash on delivery can be collected only before completion")
+	}
+
+	collectedAmount := order.NetAmount
+	if strings.TrimSpace(req.CollectedAmount) != "" {
+		collectedAmount, err = decimal.NewFromString(strings.TrimSpace(req.CollectedAmount))
+		if err != nil {
+			return nil, err
+		}
+	}
+	if collectedAmount.LessThan(order.NetAmount) {
+		return nil, errors.New("collected amount is less than order net amount")
+	}
+
+	// Cash taken before dispatch (e.g. counter pickup) settles the order as paid,
+	// cash taken by the carrier on delivery completes it.
+	resolvedOrderStatus := ent.StatusTypePaid
+	if order.Status == ent.StatusTypeShipping {
+		resolvedOrderStatus = ent.StatusTypeCompleted
+	}
+
+	if err := s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		now := time.Now()
+
+		payment := new(ent.PaymentEntity)
+		if err := tx.NewSelect().Model(payment).Where("id = ?", order.PaymentID).For("UPDATE").Scan(ctx); err != nil {
+			return err
+		}
+		if payment.Status == ent.PaymentTypeSuccess {
+			return errors.New("cash on delivery already collected")
+		}
+
+		payment.Amount = collectedAmount
+		payment.Status = ent.PaymentTypeSuccess
+		payment.ApprovedBy = &collectorID
+		payment.ApprovedAt = &now
+		if _, err := tx.NewUpdate().Model(payment).Where("id = ?", payment.ID).Exec(ctx); err != nil {
+			return err
+		}
+
+		previousStatus := order.Status
+		order.Status = resolvedOrderStatus
+		order.UpdatedAt = now
+
+		if previousStatus == ent.StatusTypeShipping {
+			if err := s.createMemberPaymentFromOrder(ctx, tx, order); err != nil {
+				return err
+			}
+		}
+		if err := s.applyOrderStatusSideEffects(ctx, tx, order, previousStatus, collectorID); err != nil {
+			return err
+		}
+
+		if _, err := tx.NewUpdate().Model(order).Where("id = ?", order.ID).Exec(ctx); err != nil {
+			return err
+		}
+
+		collectedLog := &ent.AuditLogEntity{
+			ID:           uuid.New(),
+			Action:       ent.AuditActionUpdated,
+			ActionType:   "order_cod_collected",
+			ActionID:     order.ID,
+			ActionBy:     &collectorID,
+			Status:       ent.StatusAuditSuccesses,
+			ActionDetail: "COD collected amount: " + collectedAmount.StringFixed(2),
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		}
+		if _, err := tx.NewInsert().Model(collectedLog).Exec(ctx); err != nil {
+			return err
+		}
+
+		statusLog := &ent.AuditLogEntity{
+			ID:           uuid.New(),
+			Action:       ent.AuditActionUpdated,
+			ActionType:   "order_status_transition",
+			ActionID:     order.ID,
+			ActionBy:     &collectorID,
+			Status:       ent.StatusAuditSuccesses,
+			ActionDetail: fmt.Sprintf("Order status changed from %s to %s", previousStatus, order.Status),
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		}
+		_, err := tx.NewInsert().Model(statusLog).Exec(ctx)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	span.AddEvent(`orders.svc.cod.collect.success`)
+	return &OrderPaymentServiceResponse{
+		OrderID:       order.ID,
+		PaymentID:     order.PaymentID,
+		OrderStatus:   string(resolvedOrderStatus),
+		PaymentStatus: string(ent.PaymentTypeSuccess),
+		SlipAttached:  false,
+	}, nil
+}
+
+func (s *Service) RefuseCODOrderService(ctx context.Context, orderID uuid.UUID, req *RefuseCODOrderServiceRequest, recorderID uuid.UUID) (*OrderPaymentServiceResponse, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`orders.svc.cod.refuse.start`)
+
+	reason := normalizeOrderCancellationReason(req.Reason)
+	if reason == "" {
+		return nil, errors.New("cod refusal reason is required")
+	}
+
+	order, err := s.ensureOrderAccess(ctx, orderID, recorderID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	paymentMethod, err := s.getPaymentMethod(ctx, s.bunDB.DB(), order.PaymentID)
+	if err != nil {
+		return nil, err
+	}
+	if paymentMethod != ent.PaymentMethodCOD {
+		return nil, errors.New("order is not cash on delivery")
+	}
+	if order.Status != ent.StatusTypeShipping {
+		return nil, errors.New("cod refusal is allowed only while shipping")
+	}
+
+	if err := s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		now := time.Now()
+
+		payment := new(ent.PaymentEntity)
+		if err := tx.NewSelect().Model(payment).Where("id = ?", order.PaymentID).For("UPDATE").Scan(ctx); err != nil {
+			return err
+		}
+		if payment.Status == ent.PaymentTypeSuccess {
+			return errors.New("cash on delivery already collected")
+		}
+
+		payment.Status = ent.PaymentTypeFailed
+		payment.ApprovedBy = &recorderID
+		payment.ApprovedAt = &now
+		if _, err := tx.NewUpdate().Model(payment).Where("id = ?", payment.ID).Exec(ctx); err != nil {
+			return err
+		}
+
+		// The parcel comes back to the warehouse, so the stock taken at dispatch is returned.
+		if err := s.increaseStockFromOrderItems(ctx, tx, order.ID, recorderID); err != nil {
+			return err
+		}
+
+		if err := flashsale.ReleaseOrderInTx(ctx, tx, order.ID); err != nil {
+			return err
+		}
+		if err := promotionscope.ReleaseOrderCodeInTx(ctx, tx, order.ID); err != nil {
+			return err
+		}
+
+		previousStatus := order.Status
+		order.Status = ent.StatusTypeCancelled
+		order.UpdatedAt = now
+		if _, err := tx.NewUpdate().Model(order).Where("id = ?", order.ID).Exec(ctx); err != nil {
+			return err
+		}
+
+		refusal := &ent.OrderCODRefusalEntity{
+			ID:         uuid.New(),
+			OrderID:    order.ID,
+			MemberID:   order.MemberID,
+			Reason:     reason,
+			RecordedBy: &recorderID,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		if _, err := tx.NewInsert().
+			Model(refusal).
+			On("CONFLICT (order_id) DO UPDATE").
+			Set("reason = EXCLUDED.reason").
+			Set("recorded_by = EXCLUDED.recorded_by").
+			Set("updated_at = EXCLUDED.updated_at").
+			Exec(ctx); err != nil {
+			return err
+		}
+
+		if err := s.upsertOrderCancellationInTx(ctx, tx, order.ID, recorderID, true, reason); err != nil {
+			return err
+		}
+
+		refusedLog := &ent.AuditLogEntity{
+			ID:           uuid.New(),
+			Action:       ent.AuditActionUpdated,
+			ActionType:   "order_cod_refused",
+			ActionID:     order.ID,
+			ActionBy:     &recorderID,
+			Status:       ent.StatusAuditSuccesses,
+			ActionDetail: "COD refused reason: " + reason,
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		}
+		if _, err := tx.NewInsert().Model(refusedLog).Exec(ctx); err != nil {
+			return err
+		}
+
+		statusLog := &ent.AuditLogEntity{
+			ID:           uuid.New(),
+			Action:       ent.AuditActionUpdated,
+			ActionType:   "order_status_transition",
+			ActionID:     order.ID,
+			ActionBy:     &recorderID,
+			Status:       ent.StatusAuditSuccesses,
+			ActionDetail: fmt.Sprintf("Order status changed from %s to %s", previousStatus, order.Status),
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		}
+		_, err := tx.NewInsert().Model(statusLog).Exec(ctx)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	span.AddEvent(`orders.svc.cod.refuse.success`)
+	return &OrderPaymentServiceResponse{
+		OrderID:       order.ID,
+		PaymentID:     order.PaymentID,
+		OrderStatus:   string(ent.StatusTypeCancelled),
+		PaymentStatus: string(ent.PaymentTypeFailed),
+		SlipAttached:  false,
+	}, nil
+}
+
+// increaseStockFromOrderItems puts the order's items back into the
+// locations they were picked from.
+func (s *Service) increaseStockFromOrderItems(ctx context.Context, tx bun.Tx, orderID uuid.UUID, recorderID uuid.UUID) error {
+	return inventory.ReturnOrderInTx(ctx, tx, orderID, &recorderID)
+}
+
+func parseCODRefusedReason(detail string) string {
+	const prefix = "COD refused reason: "
+	if strings.HasPrefix(detail, prefix) {
+		return strings.TrimSpace(strings.TrimPrefix(detail, prefix))
+	}
+	return strings.TrimSpace(detail)
+}
+
+func mapCODOrderStatusSummary(status ent.StatusTypeEnum) (string, string, bool) {
+	switch status {
+	case ent.StatusTypePending:
+		return "รอจัดส่ง (เก็บเงินปลายทาง)", "รอแอดมินเตรียมพัสดุ ชำระเงินกับพนักงานเมื่อได้รับสินค้า", true
+	case ent.StatusTypeShipping:
+		return "กำลังจัดส่ง (เก็บเงินปลายทาง)", "เตรียมเงินสดให้พนักงานจัดส่งเมื่อได้รับพัสดุ", true
+	default:
+		return "", "", false
+	}
+}
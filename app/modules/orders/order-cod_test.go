@@ -0,0 +1,42 @@
+package orders
+
+import (
+	"testing"
+
+	"phakram/app/modules/entities/ent"
+)
+
+func Test_calculateCODFee(t *testing.T) {
+	type args struct {
+		setting     *ent.CODSettingEntity
+		orderAmount string
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{"no setting", args{nil, "1000"}, "0"},
+		{"fixed amount", args{&ent.CODSettingEntity{FeeType: codFeeTypeAmount, FeeValue: dec("30")}, "1000"}, "30"},
+		{"fixed amount ignores the order amount", args{&ent.CODSettingEntity{FeeType: codFeeTypeAmount, FeeValue: dec("30")}, "0"}, "30"},
+		{"percent of the order amount", args{&ent.CODSettingEntity{FeeType: codFeeTypePercent, FeeValue: dec("3")}, "1000"}, "30"},
+		{"percent is rounded", args{&ent.CODSettingEntity{FeeType: codFeeTypePercent, FeeValue: dec("2.5")}, "99.99"}, "2.5"},
+		{"percent rounds half up", args{&ent.CODSettingEntity{FeeType: codFeeTypePercent, FeeValue: dec("1")}, "100.50"}, "1.01"},
+		{"percent under the max fee", args{&ent.CODSettingEntity{FeeType: codFeeTypePercent, FeeValue: dec("3"), MaxFee: decPtr("50")}, "1000"}, "30"},
+		{"percent capped at the max fee", args{&ent.CODSettingEntity{FeeType: codFeeTypePercent, FeeValue: dec("3"), MaxFee: decPtr("50")}, "5000"}, "50"},
+		{"fixed amount capped at the max fee", args{&ent.CODSettingEntity{FeeType: codFeeTypeAmount, FeeValue: dec("80"), MaxFee: decPtr("50")}, "1000"}, "50"},
+		{"zero max fee means no cap", args{&ent.CODSettingEntity{FeeType: codFeeTypePercent, FeeValue: dec("3"), MaxFee: decPtr("0")}, "5000"}, "150"},
+		{"negative max fee means no cap", args{&ent.CODSettingEntity{FeeType: codFeeTypePercent, FeeValue: dec("3"), MaxFee: decPtr("-10")}, "5000"}, "150"},
+		{"negative fixed amount is clamped", args{&ent.CODSettingEntity{FeeType: codFeeTypeAmount, FeeValue: dec("-30")}, "1000"}, "0"},
+		{"negative percent is clamped", args{&ent.CODSettingEntity{FeeType: codFeeTypePercent, FeeValue: dec("-3")}, "1000"}, "0"},
+		{"negative order amount is clamped", args{&ent.CODSettingEntity{FeeType: codFeeTypePercent, FeeValue: dec("3")}, "-1000"}, "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := calculateCODFee(tt.args.setting, dec(tt.args.orderAmount)); !got.Equal(dec(tt.want)) {
+				t.Errorf("calculateCODFee() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package orders
+
+import (
+	"phakram/app/modules/auth"
+	"phakram/app/utils"
+	"phakram/app/utils/base"
+	"phakram/config/i18n"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type CalculateOrderDiscountControllerRequest struct {
+	MemberID       string                             `json:"member_id"`
+	PromotionCodes []string                           `json:"promotion_codes"`
+	TotalAmount    string                             `json:"total_amount"`
+	Items          []CreateOrderLineControllerRequest `json:"items"`
+}
+
+func (c *Controller) CalculateOrderDiscountController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`orders.ctl.discounts.calculate.start`)
+
+	var req CalculateOrderDiscountControllerRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+	items, err := toCreateOrderLines(req.Items)
+	if err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	requesterID, hasRequester := auth.GetMemberID(ctx)
+	isAdmin := auth.GetIsAdmin(ctx)
+	if !isAdmin && !hasRequester {
+		base.Forbidden(ctx, i18n.Forbidden, nil)
+		return
+	}
+
+	memberID := requesterID
+	if isAdmin && req.MemberID != "" {
+		parsedMemberID, err := uuid.Parse(req.MemberID)
+		if err != nil {
+			base.BadRequest(ctx, i18n.BadRequest, nil)
+			return
+		}
+		memberID = parsedMemberID
+	}
+
+	data, err := c.svc.CalculateOrderDiscountService(ctx.Request.Context(), &CalculateOrderDiscountServiceRequest{
+		MemberID:       memberID,
+		PromotionCodes: req.PromotionCodes,
+		TotalAmount:    req.TotalAmount,
+		Items:          items,
+	})
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`orders.ctl.discounts.calculate.success`)
+	base.Success(ctx, data)
+}
+
+type SuggestOrderPromotionsControllerRequest struct {
+	MemberID    string                             `json:"member_id"`
+	TotalAmount string                             `json:"total_amount"`
+	Items       []CreateOrderLineControllerRequest `json:"items"`
+}
+
+func (c *Controller) SuggestOrderPromotionsController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`orders.ctl.promotions.suggest.start`)
+
+	var req SuggestOrderPromotionsControllerRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+	items, err := toCreateOrderLines(req.Items)
+	if err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	requesterID, hasRequester := auth.GetMemberID(ctx)
+	isAdmin := auth.GetIsAdmin(ctx)
+	if !isAdmin && !hasRequester {
+		base.Forbidden(ctx, i18n.Forbidden, nil)
+		return
+	}
+
+	memberID := requesterID
+	if isAdmin && req.MemberID != "" {
+		parsedMemberID, err := uuid.Parse(req.MemberID)
+		if err != nil {
+			base.BadRequest(ctx, i18n.BadRequest, nil)
+			return
+		}
+		memberID = parsedMemberID
+	}
+
+	data, err := c.svc.SuggestOrderPromotionsService(ctx.Request.Context(), &SuggestOrderPromotionsServiceRequest{
+		MemberID:    memberID,
+		TotalAmount: req.TotalAmount,
+		Items:       items,
+	})
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`orders.ctl.promotions.suggest.success`)
+	base.Success(ctx, data)
+}
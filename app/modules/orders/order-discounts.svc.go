@@ -0,0 +1,166 @@
+package orders
+
+import (
+	"context"
+	"strings"
+
+	"phakram/app/utils"
+	promotionscope "phakram/app/utils/promotion"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+type CalculateOrderDiscountServiceRequest struct {
+	MemberID       uuid.UUID
+	PromotionCodes []string
+	TotalAmount    string
+	Items          []CreateOrderLineServiceRequest
+}
+
+type CalculateOrderDiscountServiceResponse struct {
+	TotalAmount    decimal.Decimal           `json:"total_amount"`
+	DiscountAmount decimal.Decimal           `json:"discount_amount"`
+	NetAmount      decimal.Decimal           `json:"net_amount"`
+	Breakdown      *promotionscope.Breakdown `json:"breakdown"`
+	Rewards        []promotionscope.Reward   `json:"rewards"`
+}
+
+type orderDiscountResult struct {
+	Lines       []*pricedOrderLine
+	TotalAmount decimal.Decimal
+	Applied     []*promotionscope.Candidate
+	Breakdown   *promotionscope.Breakdown
+}
+
+// normalizePromotionCodes merges the single code field with the code list,
+// upper-cased and without duplicates.
+func normalizePromotionCodes(code string, codes []string) []string {
+	all := append([]string{code}, codes...)
+	result := make([]string, 0, len(all))
+	seen := make(map[string]struct{}, len(all))
+	for _, value := range all {
+		normalized := strings.ToUpper(strings.TrimSpace(value))
+		if normalized == "" {
+			continue
+		}
+		if _, ok := seen[normalized]; ok {
+			continue
+		}
+		seen[normalized] = struct{}{}
+		result = append(result, normalized)
+	}
+	return result
+}
+
+// priceOrderRequest prices the requested items, or falls back to the
+// client-supplied total for orders placed without items.
+func (s *Service) priceOrderRequest(ctx context.Context, memberID uuid.UUID, items []CreateOrderLineServiceRequest, totalAmount string) ([]*pricedOrderLine, decimal.Decimal, error) {
+	if len(items) == 0 {
+		total, err := decimal.NewFromString(totalAmount)
+		if err != nil {
+			return nil, decimal.Zero, err
+		}
+		return nil, total, nil
+	}
+
+	lines, err := s.priceOrderLines(ctx, memberID, items)
+	if err != nil {
+		return nil, decimal.Zero, err
+	}
+	total := decimal.Zero
+	for _, line := range lines {
+		total = total.Add(line.Amount)
+	}
+	return lines, total, nil
+}
+
+// calculateOrderDiscounts validates every promotion code, stacks them with
+// the member tier discount according to each promotion's policy and applies
+// the order-level discount cap. Reward lines of the chosen promotions are
+// appended to the returned lines and total.
+func (s *Service) calculateOrderDiscounts(ctx context.Context, memberID uuid.UUID, codes []string, lines []*pricedOrderLine, totalAmount decimal.Decimal) (*orderDiscountResult, error) {
+	promotionLines := toPromotionLines(lines)
+	candidates := make([]*promotionscope.Candidate, 0, len(codes))
+	for _, code := range codes {
+		candidate, err := s.calculatePromotionDiscount(ctx, memberID, code, totalAmount, promotionLines)
+		if err != nil {
+			return nil, err
+		}
+		if candidate != nil {
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	stack := promotionscope.Select(candidates)
+	for _, candidate := range stack.Applied {
+		rewardLines, err := s.priceRewardLines(ctx, candidate.PromotionID, candidate.Rewards)
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range rewardLines {
+			lines = append(lines, line)
+			totalAmount = totalAmount.Add(line.Amount)
+		}
+	}
+
+	// Lines priced from the member's tier list already carry the tier
+	// benefit, so the tier discount rate only covers the rest.
+	tierDiscountBase := totalAmount
+	for _, line := range lines {
+		if line.TierPriced {
+			tierDiscountBase = tierDiscountBase.Sub(line.Amount)
+		}
+	}
+	tierDiscount, _, err := s.calculateOrderAmountsByMemberTier(ctx, memberID, tierDiscountBase)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := promotionscope.LoadSettings(ctx, s.bunDB.DB())
+	if err != nil {
+		return nil, err
+	}
+
+	return &orderDiscountResult{
+		Lines:       lines,
+		TotalAmount: totalAmount,
+		Applied:     stack.Applied,
+		Breakdown:   stack.Apply(totalAmount, toPromotionLines(lines), tierDiscount, settings),
+	}, nil
+}
+
+func (s *Service) CalculateOrderDiscountService(ctx context.Context, req *CalculateOrderDiscountServiceRequest) (*CalculateOrderDiscountServiceResponse, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`orders.svc.discounts.calculate.start`)
+
+	if len(req.Items) == 0 && strings.TrimSpace(req.TotalAmount) == "" {
+		return nil, errPromotionRequiresItems
+	}
+
+	lines, totalAmount, err := s.priceOrderRequest(ctx, req.MemberID, req.Items, req.TotalAmount)
+	if err != nil {
+		return nil, err
+	}
+	discounts, err := s.calculateOrderDiscounts(ctx, req.MemberID, normalizePromotionCodes("", req.PromotionCodes), lines, totalAmount)
+	if err != nil {
+		return nil, err
+	}
+
+	discountAmount := discounts.Breakdown.TotalDiscount
+	if discountAmount.GreaterThan(discounts.TotalAmount) {
+		discountAmount = discounts.TotalAmount
+	}
+	rewards := make([]promotionscope.Reward, 0)
+	for _, applied := range discounts.Applied {
+		rewards = append(rewards, applied.Rewards...)
+	}
+
+	span.AddEvent(`orders.svc.discounts.calculate.success`)
+	return &CalculateOrderDiscountServiceResponse{
+		TotalAmount:    discounts.TotalAmount.Round(2),
+		DiscountAmount: discountAmount,
+		NetAmount:      discounts.TotalAmount.Sub(discountAmount).Round(2),
+		Breakdown:      discounts.Breakdown,
+		Rewards:        rewards,
+	}, nil
+}
@@ -114,20 +114,26 @@ func (s *Service) CreateOrderItemService(ctx context.Context, orderID uuid.UUID,
 
 	now := time.Now()
 	item := &ent.OrderItemEntity{
-		ID:              uuid.New(),
-		OrderID:         orderID,
-		ProductID:       req.ProductID,
-		Quantity:        req.Quantity,
-		PricePerUnit:    pricePerUnit,
-		TotalItemAmount: totalItemAmount,
-		CreatedAt:       now,
-		UpdatedAt:       now,
+		ID:               uuid.New(),
+		OrderID:          orderID,
+		ProductID:        req.ProductID,
+		Quantity:         req.Quantity,
+		PricePerUnit:     pricePerUnit,
+		TotalItemAmount:  totalItemAmount,
+		TaxClass:         product.TaxClass,
+		PriceIncludesVAT: product.PriceIncludesVAT,
+		CreatedAt:        now,
+		UpdatedAt:        now,
 	}
 
 	if err := s.item.CreateOrderItem(ctx, item); err != nil {
 		return err
 	}
 
+	if err := s.recalculateOrderTax(ctx, orderID); err != nil {
+		return err
+	}
+
 	span.AddEvent(`orders.svc.items.create.success`)
 	return nil
 }
@@ -157,6 +163,15 @@ func (s *Service) UpdateOrderItemService(ctx context.Context, orderID uuid.UUID,
 		return err
 	}
 
+	if item.ProductID != req.ProductID || item.TaxClass == "" {
+		product := new(ent.ProductEntity)
+		if err := s.bunDB.DB().NewSelect().Model(product).Where("id = ?", req.ProductID).Scan(ctx); err != nil {
+			return err
+		}
+		item.TaxClass = product.TaxClass
+		item.PriceIncludesVAT = product.PriceIncludesVAT
+	}
+
 	item.ProductID = req.ProductID
 	item.Quantity = req.Quantity
 	item.PricePerUnit = pricePerUnit
@@ -167,6 +182,10 @@ func (s *Service) UpdateOrderItemService(ctx context.Context, orderID uuid.UUID,
 		return err
 	}
 
+	if err := s.recalculateOrderTax(ctx, orderID); err != nil {
+		return err
+	}
+
 	span.AddEvent(`orders.svc.items.update.success`)
 	return nil
 }
@@ -190,6 +209,16 @@ func (s *Service) DeleteOrderItemService(ctx context.Context, orderID uuid.UUID,
 	if err := s.item.DeleteOrderItem(ctx, itemID); err != nil {
 		return err
 	}
+	if _, err := s.bunDB.DB().NewDelete().
+		Model((*ent.OrderItemDiscountEntity)(nil)).
+		Where("order_item_id = ?", itemID).
+		Exec(ctx); err != nil {
+		return err
+	}
+
+	if err := s.recalculateOrderTax(ctx, orderID); err != nil {
+		return err
+	}
 
 	span.AddEvent(`orders.svc.items.delete.success`)
 	return nil
@@ -0,0 +1,229 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"phakram/app/modules/entities/ent"
+	"phakram/app/utils/flashsale"
+	"phakram/app/utils/pricelist"
+	promotionscope "phakram/app/utils/promotion"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/uptrace/bun"
+)
+
+type CreateOrderLineServiceRequest struct {
+	ProductID uuid.UUID
+	Quantity  int
+}
+
+type pricedOrderLine struct {
+	Product      *ent.ProductEntity
+	Quantity     int
+	PricePerUnit decimal.Decimal
+	Amount       decimal.Decimal
+	PromotionID  *uuid.UUID
+	// FlashSaleItemID is set when the unit price comes from a running flash
+	// sale, so checkout can reserve the units against its caps.
+	FlashSaleItemID *uuid.UUID
+	// ProductPriceID is the price list row the unit price came from.
+	// TierPriced lines carry the member's tier price and are left out of
+	// the tier discount rate.
+	ProductPriceID *uuid.UUID
+	TierPriced     bool
+}
+
+// priceOrderLines prices the requested lines from the current product
+// catalogue so promotions are evaluated against server-side amounts.
+// Products in a running flash sale are priced at the sale price; otherwise
+// the member's price list resolves the unit price for the quantity.
+func (s *Service) priceOrderLines(ctx context.Context, memberID uuid.UUID, lines []CreateOrderLineServiceRequest) ([]*pricedOrderLine, error) {
+	productIDs := make([]uuid.UUID, 0, len(lines))
+	for _, line := range lines {
+		if line.Quantity <= 0 {
+			return nil, errors.New("quantity must be greater than zero")
+		}
+		productIDs = append(productIDs, line.ProductID)
+	}
+
+	products := make([]*ent.ProductEntity, 0)
+	if err := s.bunDB.DB().NewSelect().
+		Model(&products).
+		Where("id IN (?)", bun.In(productIDs)).
+		Scan(ctx); err != nil {
+		return nil, err
+	}
+	productByID := make(map[uuid.UUID]*ent.ProductEntity, len(products))
+	for _, product := range products {
+		productByID[product.ID] = product
+	}
+	offers, err := flashsale.LoadActive(ctx, s.bunDB.DB(), productIDs)
+	if err != nil {
+		return nil, err
+	}
+	tier, err := pricelist.MemberTier(ctx, s.bunDB.DB(), memberID)
+	if err != nil {
+		return nil, err
+	}
+	priceLists, err := pricelist.Load(ctx, s.bunDB.DB(), tier.ID, productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	priced := make([]*pricedOrderLine, 0, len(lines))
+	for _, line := range lines {
+		product, ok := productByID[line.ProductID]
+		if !ok {
+			return nil, errors.New("product not found")
+		}
+		if !product.IsActive {
+			return nil, errors.New("product is inactive")
+		}
+
+		stock := new(ent.ProductStockEntity)
+		if err := s.bunDB.DB().NewSelect().Model(stock).Where("product_id = ?", line.ProductID).OrderExpr("updated_at DESC").Limit(1).Scan(ctx); err != nil {
+			return nil, err
+		}
+		if stock.Remaining < line.Quantity {
+			return nil, errors.New("insufficient product stock")
+		}
+
+		pricedLine := &pricedOrderLine{
+			Product:      product,
+			Quantity:     line.Quantity,
+			PricePerUnit: product.Price,
+		}
+		if offer, ok := offers[product.ID]; ok {
+			pricedLine.PricePerUnit = offer.SalePrice
+			pricedLine.FlashSaleItemID = &offer.FlashSaleItemID
+		} else {
+			price := priceLists[product.ID].Resolve(product.Price, line.Quantity)
+			pricedLine.PricePerUnit = price.UnitPrice
+			pricedLine.ProductPriceID = price.PriceID
+			pricedLine.TierPriced = price.TierPriced
+		}
+		pricedLine.Amount = pricedLine.PricePerUnit.Mul(decimal.NewFromInt(int64(line.Quantity))).Round(2)
+		priced = append(priced, pricedLine)
+	}
+
+	return priced, nil
+}
+
+// priceRewardLines turns buy X get Y rewards into extra order lines at the
+// promotional price, so free units are recorded as zero-priced items.
+func (s *Service) priceRewardLines(ctx context.Context, promotionID uuid.UUID, rewards []promotionscope.Reward) ([]*pricedOrderLine, error) {
+	if len(rewards) == 0 {
+		return nil, nil
+	}
+
+	requests := make([]CreateOrderLineServiceRequest, 0, len(rewards))
+	for _, reward := range rewards {
+		requests = append(requests, CreateOrderLineServiceRequest{ProductID: reward.ProductID, Quantity: reward.Quantity})
+	}
+	lines, err := s.priceOrderLines(ctx, uuid.Nil, requests)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, line := range lines {
+		payable := hundred.Sub(rewards[i].DiscountPercent)
+		if payable.IsNegative() {
+			payable = decimal.Zero
+		}
+		line.PricePerUnit = line.Product.Price.Mul(payable).Div(hundred).Round(2)
+		line.Amount = line.PricePerUnit.Mul(decimal.NewFromInt(int64(line.Quantity))).Round(2)
+		line.PromotionID = &promotionID
+		line.FlashSaleItemID = nil
+		line.ProductPriceID = nil
+	}
+	return lines, nil
+}
+
+// flashSaleReservations collects the units each flash sale line takes from
+// its sale.
+func flashSaleReservations(lines []*pricedOrderLine) []flashsale.Reservation {
+	reservations := make([]flashsale.Reservation, 0)
+	for _, line := range lines {
+		if line.FlashSaleItemID == nil {
+			continue
+		}
+		reservations = append(reservations, flashsale.Reservation{ItemID: *line.FlashSaleItemID, Quantity: line.Quantity})
+	}
+	return reservations
+}
+
+func toPromotionLines(lines []*pricedOrderLine) []promotionscope.Line {
+	result := make([]promotionscope.Line, 0, len(lines))
+	for _, line := range lines {
+		result = append(result, promotionscope.Line{
+			ProductID: line.Product.ID,
+			Quantity:  line.Quantity,
+			Amount:    line.Amount,
+		})
+	}
+	return result
+}
+
+// insertOrderLinesInTx stores the priced lines as order items together with
+// the discount each applied promotion attributed to them.
+func insertOrderLinesInTx(ctx context.Context, tx bun.Tx, order *ent.OrderEntity, lines []*pricedOrderLine, applied []*promotionscope.Candidate) error {
+	now := time.Now()
+	for i, line := range lines {
+		item := &ent.OrderItemEntity{
+			ID:               uuid.New(),
+			OrderID:          order.ID,
+			ProductID:        line.Product.ID,
+			Quantity:         line.Quantity,
+			PricePerUnit:     line.PricePerUnit,
+			TotalItemAmount:  line.Amount,
+			TaxClass:         line.Product.TaxClass,
+			PriceIncludesVAT: line.Product.PriceIncludesVAT,
+			PromotionID:      line.PromotionID,
+			FlashSaleItemID:  line.FlashSaleItemID,
+			ProductPriceID:   line.ProductPriceID,
+			CreatedAt:        now,
+			UpdatedAt:        now,
+		}
+		if _, err := tx.NewInsert().Model(item).Exec(ctx); err != nil {
+			return err
+		}
+
+		for _, candidate := range applied {
+			if i >= len(candidate.LineDiscounts) || !candidate.LineDiscounts[i].IsPositive() {
+				continue
+			}
+			discount := &ent.OrderItemDiscountEntity{
+				ID:             uuid.New(),
+				OrderID:        order.ID,
+				OrderItemID:    item.ID,
+				PromotionID:    candidate.PromotionID,
+				DiscountAmount: candidate.LineDiscounts[i],
+				CreatedAt:      now,
+				UpdatedAt:      now,
+			}
+			if _, err := tx.NewInsert().Model(discount).Exec(ctx); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func getOrderItemPromotionDiscountsInTx(ctx context.Context, tx bun.IDB, orderID uuid.UUID) (map[uuid.UUID]decimal.Decimal, error) {
+	rows := make([]*ent.OrderItemDiscountEntity, 0)
+	if err := tx.NewSelect().
+		Model(&rows).
+		Where("order_id = ?", orderID).
+		Scan(ctx); err != nil {
+		return nil, err
+	}
+
+	discounts := make(map[uuid.UUID]decimal.Decimal, len(rows))
+	for _, row := range rows {
+		discounts[row.OrderItemID] = discounts[row.OrderItemID].Add(row.DiscountAmount)
+	}
+	return discounts, nil
+}
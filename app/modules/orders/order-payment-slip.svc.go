@@ -0,0 +1,373 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"phakram/app/modules/entities/ent"
+	"phakram/app/utils"
+	"phakram/app/utils/hashing"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// slipPerceptualHashMaxDistance is the largest dHash Hamming distance still
+// treated as the same slip (screenshots re-saved, cropped borders, recompression).
+const slipPerceptualHashMaxDistance = 6
+
+const (
+	slipMatchTypeExact      = "exact"
+	slipMatchTypePerceptual = "perceptual"
+)
+
+type PaymentSlipMatchItem struct {
+	OrderID     uuid.UUID `json:"order_id"`
+	OrderNo     string    `json:"order_no"`
+	MemberID    uuid.UUID `json:"member_id"`
+	PaymentID   uuid.UUID `json:"payment_id"`
+	FileID      uuid.UUID `json:"file_id"`
+	MatchType   string    `json:"match_type"`
+	Distance    int       `json:"distance"`
+	SubmittedAt time.Time `json:"submitted_at"`
+}
+
+type paymentSlipMatchRow struct {
+	ID             uuid.UUID  `bun:"id"`
+	OrderID        uuid.UUID  `bun:"order_id"`
+	OrderNo        string     `bun:"order_no"`
+	MemberID       uuid.UUID  `bun:"member_id"`
+	PaymentID      uuid.UUID  `bun:"payment_id"`
+	FileID         *uuid.UUID `bun:"file_id"`
+	SHA256         string     `bun:"sha256"`
+	PerceptualHash string     `bun:"perceptual_hash"`
+	CreatedAt      time.Time  `bun:"created_at"`
+}
+
+// errSlipCannotBeVerified marks slips whose bytes cannot be read for the
+// duplicate check, such as external URLs or paths outside slip storage.
+// Such slips are accepted but held for review instead of being hashed.
+var errSlipCannotBeVerified = errors.New("slip image cannot be verified")
+
+// decodeSlipForHashing returns raw slip bytes when the slip was sent inline.
+func decodeSlipForHashing(encoded string) []byte {
+	trimmed := strings.TrimSpace(encoded)
+	if trimmed == "" {
+		return nil
+	}
+
+	data, _, err := decodeBase64Image(trimmed)
+	if err != nil {
+		return nil
+	}
+
+	return data
+}
+
+// loadSlipForHashing returns the bytes of a slip sent by path: inline data
+// URLs are decoded and storage paths are downloaded. A slip that cannot be
+// read returns errSlipCannotBeVerified so it never skips the duplicate check
+// unnoticed.
+func (s *Service) loadSlipForHashing(ctx context.Context, slipPath string) ([]byte, error) {
+	trimmed := strings.TrimSpace(slipPath)
+	if strings.HasPrefix(strings.ToLower(trimmed), "data:") {
+		if data := decodeSlipForHashing(trimmed); len(data) > 0 {
+			return data, nil
+		}
+		return nil, errSlipCannotBeVerified
+	}
+	if s.railwayStorage == nil || !s.railwayStorage.enabledForPrivate() {
+		return nil, errSlipCannotBeVerified
+	}
+
+	data, err := s.railwayStorage.DownloadPaymentSlip(ctx, trimmed)
+	if err != nil {
+		if errors.Is(err, errSlipCannotBeVerified) {
+			return nil, err
+		}
+		span, log := utils.LogSpanFromContext(ctx)
+		span.AddEvent(`orders.svc.payment.slip_download.failed`)
+		log.Errf("orders.svc.payment.slip_download.error: %s", err)
+		return nil, errSlipCannotBeVerified
+	}
+	return data, nil
+}
+
+func findPaymentSlipMatches(ctx context.Context, db bun.IDB, orderID uuid.UUID, sha string, perceptualHash string) ([]*paymentSlipMatchRow, error) {
+	rows := make([]*paymentSlipMatchRow, 0)
+	query := db.NewSelect().
+		TableExpr("payment_slip_hashes AS psh").
+		Join("JOIN orders AS o ON o.id = psh.order_id").
+		ColumnExpr("psh.id AS id").
+		ColumnExpr("psh.order_id AS order_id").
+		ColumnExpr("o.order_no AS order_no").
+		ColumnExpr("o.member_id AS member_id").
+		ColumnExpr("psh.payment_id AS payment_id").
+		ColumnExpr("psh.file_id AS file_id").
+		ColumnExpr("psh.sha256 AS sha256").
+		ColumnExpr("COALESCE(psh.perceptual_hash, '') AS perceptual_hash").
+		ColumnExpr("psh.created_at AS created_at").
+		Where("psh.order_id <> ?", orderID).
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			q = q.Where("psh.sha256 = ?", sha)
+			if perceptualHash != "" {
+				q = q.WhereOr(
+					"psh.perceptual_hash IS NOT NULL AND length(replace((('x' || psh.perceptual_hash)::bit(64) # ('x' || ?)::bit(64))::text, '0', '')) <= ?",
+					perceptualHash,
+					slipPerceptualHashMaxDistance,
+				)
+			}
+			return q
+		}).
+		OrderExpr("psh.created_at ASC")
+
+	if err := query.Scan(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+func (s *Service) recordPaymentSlipHashInTx(ctx context.Context, tx bun.Tx, order *ent.OrderEntity, fileID uuid.UUID, slip []byte, actionBy uuid.UUID) error {
+	if len(slip) == 0 {
+		return nil
+	}
+
+	tableExists, err := relationExistsInTx(ctx, tx, "public.payment_slip_hashes")
+	if err != nil {
+		return err
+	}
+	if !tableExists {
+		return nil
+	}
+
+	sha := hashing.ImageSHA256(slip)
+	perceptualHash, err := hashing.ImageDifferenceHash(slip)
+	if err != nil {
+		// Formats the standard decoders cannot read (e.g. HEIC) still get exact matching.
+		perceptualHash = ""
+	}
+
+	matches, err := findPaymentSlipMatches(ctx, tx, order.ID, sha, perceptualHash)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	record := &ent.PaymentSlipHashEntity{
+		ID:             uuid.New(),
+		OrderID:        order.ID,
+		PaymentID:      order.PaymentID,
+		FileID:         &fileID,
+		SHA256:         sha,
+		PerceptualHash: perceptualHash,
+		IsFlagged:      len(matches) > 0,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if _, err := tx.NewInsert().Model(record).Exec(ctx); err != nil {
+		return err
+	}
+
+	if len(matches) == 0 {
+		return nil
+	}
+
+	matchedIDs := make([]uuid.UUID, 0, len(matches))
+	matchedOrderNos := make([]string, 0, len(matches))
+	for _, match := range matches {
+		matchedIDs = append(matchedIDs, match.ID)
+		matchedOrderNos = append(matchedOrderNos, match.OrderNo)
+	}
+	if _, err := tx.NewUpdate().
+		Model((*ent.PaymentSlipHashEntity)(nil)).
+		Set("is_flagged = ?", true).
+		Set("updated_at = ?", now).
+		Where("id IN (?)", bun.In(matchedIDs)).
+		Exec(ctx); err != nil {
+		return err
+	}
+
+	flaggedLog := &ent.AuditLogEntity{
+		ID:           uuid.New(),
+		Action:       ent.AuditActionUpdated,
+		ActionType:   "order_payment_slip_flagged",
+		ActionID:     order.ID,
+		ActionBy:     &actionBy,
+		Status:       ent.StatusAuditSuccesses,
+		ActionDetail: "Payment slip matches orders: " + strings.Join(matchedOrderNos, ", "),
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if _, err := tx.NewInsert().Model(flaggedLog).Exec(ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// recordUnverifiedPaymentSlipInTx keeps a slip that could not be hashed as
+// flagged, so approving the payment needs an admin to look at it first.
+func (s *Service) recordUnverifiedPaymentSlipInTx(ctx context.Context, tx bun.Tx, order *ent.OrderEntity, fileID uuid.UUID, actionBy uuid.UUID) error {
+	tableExists, err := relationExistsInTx(ctx, tx, "public.payment_slip_hashes")
+	if err != nil {
+		return err
+	}
+	if !tableExists {
+		return nil
+	}
+
+	now := time.Now()
+	record := &ent.PaymentSlipHashEntity{
+		ID:           uuid.New(),
+		OrderID:      order.ID,
+		PaymentID:    order.PaymentID,
+		FileID:       &fileID,
+		IsFlagged:    true,
+		IsUnverified: true,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if _, err := tx.NewInsert().Model(record).Exec(ctx); err != nil {
+		return err
+	}
+
+	unverifiedLog := &ent.AuditLogEntity{
+		ID:           uuid.New(),
+		Action:       ent.AuditActionUpdated,
+		ActionType:   "order_payment_slip_unverified",
+		ActionID:     order.ID,
+		ActionBy:     &actionBy,
+		Status:       ent.StatusAuditSuccesses,
+		ActionDetail: "Payment slip could not be checked for duplicates and needs review",
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	_, err = tx.NewInsert().Model(unverifiedLog).Exec(ctx)
+	return err
+}
+
+func (s *Service) isOrderPaymentSlipFlagged(ctx context.Context, orderID uuid.UUID) (bool, error) {
+	exists, err := s.bunDB.DB().NewSelect().
+		Model((*ent.PaymentSlipHashEntity)(nil)).
+		Where("order_id = ?", orderID).
+		Where("is_flagged = ?", true).
+		Exists(ctx)
+	if err != nil {
+		if isPaymentSlipHashesRelationMissing(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return exists, nil
+}
+
+func isPaymentSlipHashesRelationMissing(err error) bool {
+	if err == nil {
+		return false
+	}
+	message := strings.ToLower(strings.TrimSpace(err.Error()))
+	if message == "" {
+		return false
+	}
+	return strings.Contains(message, `relation "payment_slip_hashes" does not exist`) || strings.Contains(message, "sqlstate 42p01")
+}
+
+func (s *Service) ListOrderPaymentSlipMatchesService(ctx context.Context, orderID uuid.UUID, requesterID uuid.UUID) ([]*PaymentSlipMatchItem, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`orders.svc.payment.slip_matches.start`)
+
+	order, err := s.ensureOrderAccess(ctx, orderID, requesterID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := make([]*ent.PaymentSlipHashEntity, 0)
+	if err := s.bunDB.DB().NewSelect().
+		Model(&hashes).
+		Where("order_id = ?", order.ID).
+		OrderExpr("created_at ASC").
+		Scan(ctx); err != nil {
+		if isPaymentSlipHashesRelationMissing(err) {
+			return []*PaymentSlipMatchItem{}, nil
+		}
+		return nil, err
+	}
+
+	data := make([]*PaymentSlipMatchItem, 0)
+	seen := make(map[string]struct{})
+	for _, hash := range hashes {
+		if hash.IsUnverified {
+			continue
+		}
+		matches, err := findPaymentSlipMatches(ctx, s.bunDB.DB(), order.ID, hash.SHA256, hash.PerceptualHash)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, match := range matches {
+			if _, ok := seen[match.ID.String()]; ok {
+				continue
+			}
+			seen[match.ID.String()] = struct{}{}
+
+			item := &PaymentSlipMatchItem{
+				OrderID:     match.OrderID,
+				OrderNo:     match.OrderNo,
+				MemberID:    match.MemberID,
+				PaymentID:   match.PaymentID,
+				MatchType:   slipMatchTypeExact,
+				SubmittedAt: match.CreatedAt,
+			}
+			if match.FileID != nil {
+				item.FileID = *match.FileID
+			}
+			if match.SHA256 != hash.SHA256 {
+				item.MatchType = slipMatchTypePerceptual
+				distance, err := hashing.ImageHashDistance(hash.PerceptualHash, match.PerceptualHash)
+				if err != nil {
+					return nil, fmt.Errorf("invalid slip hash: %w", err)
+				}
+				item.Distance = distance
+			}
+			data = append(data, item)
+		}
+	}
+
+	span.AddEvent(`orders.svc.payment.slip_matches.success`)
+	return data, nil
+}
+
+// ensurePaymentSlipApprovable blocks approving a reused or unverified slip
+// unless the admin has looked at it and explicitly acknowledged it.
+func (s *Service) ensurePaymentSlipApprovable(ctx context.Context, orderID uuid.UUID, acknowledgeDuplicateSlip bool) error {
+	if acknowledgeDuplicateSlip {
+		return nil
+	}
+
+	flagged := make([]*ent.PaymentSlipHashEntity, 0)
+	if err := s.bunDB.DB().NewSelect().
+		Model(&flagged).
+		Column("is_unverified").
+		Where("order_id = ?", orderID).
+		Where("is_flagged = ?", true).
+		Scan(ctx); err != nil {
+		if isPaymentSlipHashesRelationMissing(err) {
+			return nil
+		}
+		return err
+	}
+	for _, hash := range flagged {
+		if hash.IsUnverified {
+			return errors.New("payment slip has not been verified")
+		}
+	}
+	if len(flagged) > 0 {
+		return errors.New("payment slip matches another order")
+	}
+
+	return nil
+}
@@ -18,6 +18,10 @@ type ConfirmOrderPaymentControllerRequest struct {
 	SlipFileSize      int64  `json:"slip_file_size"`
 }
 
+type ApproveOrderPaymentControllerRequest struct {
+	AcknowledgeDuplicateSlip bool `form:"acknowledge_duplicate_slip"`
+}
+
 type RejectOrderPaymentControllerRequest struct {
 	Reason string `json:"reason"`
 }
@@ -74,6 +78,12 @@ func (c *Controller) ApproveOrderPaymentController(ctx *gin.Context) {
 		return
 	}
 
+	var req ApproveOrderPaymentControllerRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
 	requesterID, hasRequester := auth.GetMemberID(ctx)
 	isAdmin := auth.GetIsAdmin(ctx)
 	if !isAdmin || !hasRequester {
@@ -81,7 +91,7 @@ func (c *Controller) ApproveOrderPaymentController(ctx *gin.Context) {
 		return
 	}
 
-	data, err := c.svc.ApproveOrderPaymentService(ctx.Request.Context(), orderID, requesterID)
+	data, err := c.svc.ApproveOrderPaymentService(ctx.Request.Context(), orderID, requesterID, req.AcknowledgeDuplicateSlip)
 	if err != nil {
 		base.HandleError(ctx, err)
 		return
@@ -154,3 +164,29 @@ func (c *Controller) AppealOrderPaymentController(ctx *gin.Context) {
 	span.AddEvent(`orders.ctl.payment.appeal.success`)
 	base.Success(ctx, data)
 }
+
+func (c *Controller) ListOrderPaymentSlipMatchesController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`orders.ctl.payment.slip_matches.start`)
+
+	orderID, ok := c.parseOrderID(ctx)
+	if !ok {
+		return
+	}
+
+	requesterID, hasRequester := auth.GetMemberID(ctx)
+	isAdmin := auth.GetIsAdmin(ctx)
+	if !isAdmin || !hasRequester {
+		base.Forbidden(ctx, i18n.Forbidden, nil)
+		return
+	}
+
+	data, err := c.svc.ListOrderPaymentSlipMatchesService(ctx.Request.Context(), orderID, requesterID)
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`orders.ctl.payment.slip_matches.success`)
+	base.Success(ctx, data)
+}
@@ -0,0 +1,284 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"phakram/app/utils"
+	promotionscope "phakram/app/utils/promotion"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	promotionSuggestionCollected = "collected"
+	promotionSuggestionPublic    = "public"
+)
+
+type SuggestOrderPromotionsServiceRequest struct {
+	MemberID    uuid.UUID
+	TotalAmount string
+	Items       []CreateOrderLineServiceRequest
+}
+
+type OrderPromotionSuggestion struct {
+	Rank           int                       `json:"rank"`
+	PromotionID    string                    `json:"promotion_id"`
+	Code           string                    `json:"code"`
+	Name           string                    `json:"name"`
+	Source         string                    `json:"source"`
+	Eligible       bool                      `json:"eligible"`
+	Reason         string                    `json:"reason"`
+	DiscountAmount decimal.Decimal           `json:"discount_amount"`
+	Savings        decimal.Decimal           `json:"savings"`
+	NetAmount      decimal.Decimal           `json:"net_amount"`
+	Breakdown      *promotionscope.Breakdown `json:"breakdown,omitempty"`
+	Rewards        []promotionscope.Reward   `json:"rewards,omitempty"`
+	priority       int
+	lowersTotal    bool
+}
+
+type SuggestOrderPromotionsServiceResponse struct {
+	TotalAmount decimal.Decimal             `json:"total_amount"`
+	Best        *OrderPromotionSuggestion   `json:"best"`
+	Suggestions []*OrderPromotionSuggestion `json:"suggestions"`
+}
+
+type suggestablePromotionRow struct {
+	ID        uuid.UUID `bun:"id"`
+	Code      string    `bun:"code"`
+	Name      string    `bun:"name"`
+	Priority  int       `bun:"priority"`
+	Collected bool      `bun:"collected"`
+}
+
+// Errors calculatePromotionDiscount uses to reject a code. They are matched
+// with errors.Is, so callers may wrap them.
+var (
+	errPromotionInactive         = errors.New("promotion is inactive")
+	errPromotionNotStarted       = errors.New("promotion is not active yet")
+	errPromotionExpired          = errors.New("promotion has expired")
+	errPromotionMinimumNotMet    = errors.New("order amount is below promotion minimum")
+	errPromotionUsageLimit       = errors.New("promotion usage limit reached")
+	errPromotionMemberUsageLimit = errors.New("promotion usage per member limit reached")
+	errPromotionNoEligibleItems  = errors.New("no items eligible for promotion")
+	errPromotionRequiresItems    = errors.New("promotion requires order items")
+)
+
+// promotionNotApplicableReason is shown when a promotion could not be
+// evaluated against the cart, such as a reward product that is out of stock.
+const promotionNotApplicableReason = "ไม่ตรงตามเงื่อนไขโปรโมชั่น"
+
+// promotionIneligibleReasons maps the errors that reject a code onto the
+// reason shown next to a suggestion.
+var promotionIneligibleReasons = []struct {
+	err    error
+	reason string
+}{
+	{errPromotionInactive, "โปรโมชั่นปิดใช้งานอยู่"},
+	{errPromotionNotStarted, "โปรโมชั่นยังไม่เริ่มใช้งาน"},
+	{errPromotionExpired, "โปรโมชั่นหมดอายุแล้ว"},
+	{errPromotionMinimumNotMet, "ยอดสั่งซื้อไม่ถึงขั้นต่ำของโปรโมชั่น"},
+	{errPromotionUsageLimit, "สิทธิ์โปรโมชั่นเต็มแล้ว"},
+	{errPromotionMemberUsageLimit, "คุณใช้โปรโมชั่นนี้ครบสิทธิ์แล้ว"},
+	{errPromotionNoEligibleItems, "ไม่มีสินค้าในตะกร้าที่ร่วมรายการโปรโมชั่นนี้"},
+	{errPromotionRequiresItems, "โปรโมชั่นนี้ใช้ได้กับสินค้าบางรายการ กรุณาระบุสินค้าในตะกร้า"},
+	{promotionscope.ErrMemberNotInSegment, "โปรโมชั่นนี้ไม่ได้จัดให้สำหรับบัญชีของคุณ"},
+	{promotionscope.ErrCodeNotFound, "ไม่พบโค้ดโปรโมชั่น"},
+	{promotionscope.ErrCodeRedeemed, "โค้ดนี้ถูกใช้งานแล้ว"},
+	{promotionscope.ErrBuyQuantityNotMet, "จำนวนสินค้าที่ร่วมรายการยังไม่ครบตามเงื่อนไขโปรโมชั่น"},
+	{promotionscope.ErrBundleIncomplete, "สินค้าในตะกร้ายังไม่ครบชุดตามโปรโมชั่น"},
+}
+
+// promotionIneligibleReason returns the reason for a known rejection, or
+// false for any other error.
+func promotionIneligibleReason(err error) (string, bool) {
+	for _, known := range promotionIneligibleReasons {
+		if errors.Is(err, known.err) {
+			return known.reason, true
+		}
+	}
+	return "", false
+}
+
+// listSuggestablePromotions returns the promotions the member may try: the
+// ones they collected, and public ones that are running for their segment.
+// Promotions handed out as single-use codes are not public.
+func (s *Service) listSuggestablePromotions(ctx context.Context, memberID uuid.UUID) ([]*suggestablePromotionRow, error) {
+	now := time.Now().UTC()
+	public := s.bunDB.DB().NewSelect().
+		TableExpr("promotions AS p").
+		Column("p.id").
+		Where("p.is_active = true").
+		Where("(p.starts_at IS NULL OR p.starts_at <= ?)", now).
+		Where("(p.ends_at IS NULL OR p.ends_at >= ?)", now).
+		Where("NOT EXISTS (SELECT 1 FROM promotion_code_batches AS pcb WHERE pcb.promotion_id = p.id)")
+	public = promotionscope.WhereMemberInSegment(public, "p", memberID)
+
+	rows := make([]*suggestablePromotionRow, 0)
+	if err := s.bunDB.DB().NewSelect().
+		TableExpr("promotions AS sp").
+		ColumnExpr("sp.id, sp.code, sp.name, sp.priority").
+		ColumnExpr("EXISTS (SELECT 1 FROM member_promotion_collections AS mpc WHERE mpc.promotion_id = sp.id AND mpc.member_id = ?) AS collected", memberID).
+		Where("(sp.id IN (?) OR EXISTS (SELECT 1 FROM member_promotion_collections AS mpc WHERE mpc.promotion_id = sp.id AND mpc.member_id = ?))", public, memberID).
+		Where("sp.code <> ''").
+		OrderExpr("sp.priority DESC, sp.created_at DESC").
+		Scan(ctx, &rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// suggestPromotions evaluates every suggestable promotion on its own against
+// the priced cart, including the tier discount it may stack with and the
+// order-level cap, and ranks the eligible ones by what the member saves
+// compared with checking out without a code.
+func (s *Service) suggestPromotions(ctx context.Context, memberID uuid.UUID, lines []*pricedOrderLine, totalAmount decimal.Decimal) ([]*OrderPromotionSuggestion, error) {
+	span, log := utils.LogSpanFromContext(ctx)
+
+	promotions, err := s.listSuggestablePromotions(ctx, memberID)
+	if err != nil {
+		return nil, err
+	}
+	if len(promotions) == 0 {
+		return []*OrderPromotionSuggestion{}, nil
+	}
+
+	// The tier discount applies without any code, so it is not a saving
+	// the code brings.
+	baseline, err := s.calculateOrderDiscounts(ctx, memberID, nil, append([]*pricedOrderLine(nil), lines...), totalAmount)
+	if err != nil {
+		return nil, err
+	}
+	baselineNet := discountedNetAmount(baseline)
+
+	suggestions := make([]*OrderPromotionSuggestion, 0, len(promotions))
+	for _, promotion := range promotions {
+		suggestion := &OrderPromotionSuggestion{
+			PromotionID:    promotion.ID.String(),
+			Code:           promotion.Code,
+			Name:           promotion.Name,
+			Source:         promotionSuggestionPublic,
+			DiscountAmount: decimal.Zero,
+			Savings:        decimal.Zero,
+			NetAmount:      totalAmount,
+			priority:       promotion.Priority,
+		}
+		if promotion.Collected {
+			suggestion.Source = promotionSuggestionCollected
+		}
+		suggestions = append(suggestions, suggestion)
+
+		cartLines := append([]*pricedOrderLine(nil), lines...)
+		discounts, err := s.calculateOrderDiscounts(ctx, memberID, []string{promotion.Code}, cartLines, totalAmount)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			// One misconfigured promotion must not break suggestions or
+			// auto-apply for the whole cart, so any other failure only
+			// rules this promotion out.
+			reason, ok := promotionIneligibleReason(err)
+			if !ok {
+				span.AddEvent(`orders.svc.promotions.suggest.evaluate_failed`)
+				log.Errf("promotion %s could not be evaluated: %s", promotion.ID, err)
+				reason = promotionNotApplicableReason
+			}
+			suggestion.Reason = reason
+			continue
+		}
+		if len(discounts.Applied) == 0 {
+			suggestion.Reason = promotionNotApplicableReason
+			continue
+		}
+
+		// Reward lines are goods the member gets on top of the cart, so
+		// their regular price counts as saved and what they cost does not.
+		netAmount := discountedNetAmount(discounts)
+		savings := baselineNet.Sub(netAmount)
+		for _, line := range discounts.Lines[len(lines):] {
+			savings = savings.Add(line.Product.Price.Mul(decimal.NewFromInt(int64(line.Quantity))))
+		}
+
+		suggestion.Eligible = true
+		suggestion.Reason = "ใช้โปรโมชั่นได้"
+		suggestion.DiscountAmount = decimal.Min(discounts.Breakdown.TotalDiscount, discounts.TotalAmount)
+		suggestion.Savings = savings.Round(2)
+		suggestion.NetAmount = netAmount
+		suggestion.lowersTotal = netAmount.LessThan(baselineNet)
+		suggestion.Breakdown = discounts.Breakdown
+		suggestion.Rewards = discounts.Applied[0].Rewards
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		a, b := suggestions[i], suggestions[j]
+		if a.Eligible != b.Eligible {
+			return a.Eligible
+		}
+		if !a.Savings.Equal(b.Savings) {
+			return a.Savings.GreaterThan(b.Savings)
+		}
+		return a.priority > b.priority
+	})
+	for i, suggestion := range suggestions {
+		suggestion.Rank = i + 1
+	}
+	return suggestions, nil
+}
+
+// discountedNetAmount is what the member pays for an evaluated cart, with
+// the discount capped at the cart total.
+func discountedNetAmount(discounts *orderDiscountResult) decimal.Decimal {
+	discountAmount := decimal.Min(discounts.Breakdown.TotalDiscount, discounts.TotalAmount)
+	return discounts.TotalAmount.Sub(discountAmount).Round(2)
+}
+
+// bestPromotionCode picks the highest ranked code that makes the order
+// strictly cheaper than checking out without one, or an empty string when
+// none does. A code whose only benefit is extra goods is suggested but
+// never applied on the member's behalf.
+func (s *Service) bestPromotionCode(ctx context.Context, memberID uuid.UUID, lines []*pricedOrderLine, totalAmount decimal.Decimal) (string, error) {
+	suggestions, err := s.suggestPromotions(ctx, memberID, lines, totalAmount)
+	if err != nil {
+		return "", err
+	}
+	for _, suggestion := range suggestions {
+		if suggestion.Eligible && suggestion.lowersTotal {
+			return suggestion.Code, nil
+		}
+	}
+	return "", nil
+}
+
+func (s *Service) SuggestOrderPromotionsService(ctx context.Context, req *SuggestOrderPromotionsServiceRequest) (*SuggestOrderPromotionsServiceResponse, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`orders.svc.promotions.suggest.start`)
+
+	if len(req.Items) == 0 && strings.TrimSpace(req.TotalAmount) == "" {
+		return nil, errPromotionRequiresItems
+	}
+
+	lines, totalAmount, err := s.priceOrderRequest(ctx, req.MemberID, req.Items, req.TotalAmount)
+	if err != nil {
+		return nil, err
+	}
+	suggestions, err := s.suggestPromotions(ctx, req.MemberID, lines, totalAmount)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &SuggestOrderPromotionsServiceResponse{
+		TotalAmount: totalAmount.Round(2),
+		Suggestions: suggestions,
+	}
+	if len(suggestions) > 0 && suggestions[0].Eligible && suggestions[0].Savings.IsPositive() {
+		response.Best = suggestions[0]
+	}
+
+	span.AddEvent(`orders.svc.promotions.suggest.success`)
+	return response, nil
+}
@@ -0,0 +1,115 @@
+package orders
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"phakram/app/modules/entities/ent"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+type refundPayoutBankRow struct {
+	MemberBankID uuid.UUID `bun:"member_bank_id"`
+	BankCode     string    `bun:"bank_code"`
+	BankName     string    `bun:"bank_name"`
+	AccountNo    string    `bun:"account_no"`
+	FirstnameTh  string    `bun:"firstname_th"`
+	LastnameTh   string    `bun:"lastname_th"`
+	FirstnameEn  string    `bun:"firstname_en"`
+	LastnameEn   string    `bun:"lastname_en"`
+}
+
+// queueRefundPayoutInTx records an approved refund for finance to transfer.
+// The member's default bank account is captured now. Refunds for members
+// without a default account stay pending with no account, so they are left
+// out of batches until the member sets one or an admin pays them by hand.
+func (s *Service) queueRefundPayoutInTx(ctx context.Context, tx bun.Tx, order *ent.OrderEntity, requesterID uuid.UUID) error {
+	tableExists, err := relationExistsInTx(ctx, tx, "public.refund_payouts")
+	if err != nil {
+		return err
+	}
+	if !tableExists {
+		return nil
+	}
+
+	amount, err := s.getActualPaidAmountInTx(ctx, tx, order)
+	if err != nil {
+		return err
+	}
+	if !amount.IsPositive() {
+		return nil
+	}
+
+	now := time.Now()
+	payout := &ent.RefundPayoutEntity{
+		ID:        uuid.New(),
+		OrderID:   order.ID,
+		MemberID:  order.MemberID,
+		Amount:    amount,
+		Status:    ent.RefundPayoutStatusPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	bank := new(refundPayoutBankRow)
+	err = tx.NewSelect().
+		TableExpr("member_banks AS mb").
+		Join("LEFT JOIN banks AS b ON b.id = mb.bank_id").
+		ColumnExpr("mb.id AS member_bank_id").
+		ColumnExpr("COALESCE(b.code, '') AS bank_code").
+		ColumnExpr("COALESCE(b.name_th, '') AS bank_name").
+		ColumnExpr("mb.bank_no AS account_no").
+		ColumnExpr("mb.firstname_th, mb.lastname_th, mb.firstname_en, mb.lastname_en").
+		Where("mb.member_id = ?", order.MemberID).
+		Where("mb.is_default").
+		OrderExpr("mb.updated_at DESC").
+		Limit(1).
+		Scan(ctx, bank)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if err == nil {
+		payout.MemberBankID = &bank.MemberBankID
+		payout.BankCode = bank.BankCode
+		payout.BankName = bank.BankName
+		payout.AccountNo = bank.AccountNo
+		payout.AccountName = strings.TrimSpace(bank.FirstnameTh + " " + bank.LastnameTh)
+		if payout.AccountName == "" {
+			payout.AccountName = strings.TrimSpace(bank.FirstnameEn + " " + bank.LastnameEn)
+		}
+	}
+
+	result, err := tx.NewInsert().
+		Model(payout).
+		On("CONFLICT (order_id) DO NOTHING").
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return nil
+	}
+
+	var actionBy *uuid.UUID
+	if requesterID != uuid.Nil {
+		actionBy = &requesterID
+	}
+	queuedLog := &ent.AuditLogEntity{
+		ID:           uuid.New(),
+		Action:       ent.AuditActionCreated,
+		ActionType:   "order_refund_payout_queued",
+		ActionID:     order.ID,
+		ActionBy:     actionBy,
+		Status:       ent.StatusAuditSuccesses,
+		ActionDetail: fmt.Sprintf("Refund payout queued: %s", amount.StringFixed(2)),
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	_, err = tx.NewInsert().Model(queuedLog).Exec(ctx)
+	return err
+}
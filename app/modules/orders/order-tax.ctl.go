@@ -0,0 +1,41 @@
+package orders
+
+import (
+	"phakram/app/modules/auth"
+	"phakram/app/utils"
+	"phakram/app/utils/base"
+	"phakram/config/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SalesTaxReportControllerRequest struct {
+	Month string `form:"month"`
+}
+
+func (c *Controller) SalesTaxReportController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`orders.ctl.report.sales_tax.start`)
+
+	var req SalesTaxReportControllerRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	_, hasRequester := auth.GetMemberID(ctx)
+	isAdmin := auth.GetIsAdmin(ctx)
+	if !isAdmin || !hasRequester {
+		base.Forbidden(ctx, i18n.Forbidden, nil)
+		return
+	}
+
+	data, err := c.svc.SalesTaxReportService(ctx.Request.Context(), req.Month)
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`orders.ctl.report.sales_tax.success`)
+	base.Success(ctx, data)
+}
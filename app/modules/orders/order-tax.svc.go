@@ -0,0 +1,329 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"phakram/app/modules/entities/ent"
+	"phakram/app/utils"
+	promotionscope "phakram/app/utils/promotion"
+	"phakram/app/utils/sales"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/uptrace/bun"
+)
+
+var (
+	standardVATRate = decimal.NewFromInt(7)
+	hundred         = decimal.NewFromInt(100)
+)
+
+type orderLineTax struct {
+	DiscountAmount decimal.Decimal
+	VATBaseAmount  decimal.Decimal
+	VATAmount      decimal.Decimal
+	VATAdded       decimal.Decimal
+}
+
+type orderTaxTotals struct {
+	VATBaseAmount   decimal.Decimal
+	VATAmount       decimal.Decimal
+	VATAddedAmount  decimal.Decimal
+	ZeroRatedAmount decimal.Decimal
+	ExemptAmount    decimal.Decimal
+}
+
+type SalesTaxReportDay struct {
+	Date            string          `json:"date"`
+	OrderCount      int             `json:"order_count"`
+	TotalSales      decimal.Decimal `json:"total_sales"`
+	VATBaseAmount   decimal.Decimal `json:"vat_base_amount"`
+	VATAmount       decimal.Decimal `json:"vat_amount"`
+	ZeroRatedAmount decimal.Decimal `json:"zero_rated_amount"`
+	ExemptAmount    decimal.Decimal `json:"exempt_amount"`
+}
+
+// SalesTaxReportServiceResponse mirrors the sales section of the monthly VAT
+// return (ภ.พ.30).
+type SalesTaxReportServiceResponse struct {
+	Period          string               `json:"period"`
+	OrderCount      int                  `json:"order_count"`
+	TotalSales      decimal.Decimal      `json:"total_sales"`
+	ZeroRatedAmount decimal.Decimal      `json:"zero_rated_amount"`
+	ExemptAmount    decimal.Decimal      `json:"exempt_amount"`
+	VATBaseAmount   decimal.Decimal      `json:"vat_base_amount"`
+	VATAmount       decimal.Decimal      `json:"vat_amount"`
+	Days            []*SalesTaxReportDay `json:"days"`
+}
+
+type salesTaxReportRow struct {
+	Date            time.Time       `bun:"date"`
+	OrderCount      int             `bun:"order_count"`
+	TotalSales      decimal.Decimal `bun:"total_sales"`
+	VATBaseAmount   decimal.Decimal `bun:"vat_base_amount"`
+	VATAmount       decimal.Decimal `bun:"vat_amount"`
+	ZeroRatedAmount decimal.Decimal `bun:"zero_rated_amount"`
+	ExemptAmount    decimal.Decimal `bun:"exempt_amount"`
+}
+
+func vatRateForTaxClass(taxClass ent.TaxClassEnum) decimal.Decimal {
+	if taxClass == ent.TaxClassZeroRated || taxClass == ent.TaxClassExempt {
+		return decimal.Zero
+	}
+	return standardVATRate
+}
+
+// splitVATInclusive splits a VAT-inclusive amount into its base and VAT parts.
+func splitVATInclusive(amount decimal.Decimal, rate decimal.Decimal) (decimal.Decimal, decimal.Decimal) {
+	if rate.IsZero() {
+		return amount.Round(2), decimal.Zero
+	}
+	base := amount.Mul(hundred).Div(hundred.Add(rate)).Round(2)
+	return base, amount.Sub(base).Round(2)
+}
+
+func calculateOrderLineTax(item *ent.OrderItemEntity, discount decimal.Decimal) *orderLineTax {
+	net := item.TotalItemAmount.Sub(discount)
+	if net.IsNegative() {
+		net = decimal.Zero
+	}
+
+	rate := vatRateForTaxClass(item.TaxClass)
+	result := &orderLineTax{DiscountAmount: discount}
+	if item.PriceIncludesVAT || rate.IsZero() {
+		result.VATBaseAmount, result.VATAmount = splitVATInclusive(net, rate)
+		return result
+	}
+
+	result.VATBaseAmount = net.Round(2)
+	result.VATAmount = net.Mul(rate).Div(hundred).Round(2)
+	result.VATAdded = result.VATAmount
+	return result
+}
+
+// calculateOrderTax derives the VAT of every item and the order totals.
+// promotionDiscounts holds what promotions attributed to each item; the rest
+// of the order discount is prorated over what remains. Items without a tax
+// class are treated as standard-rated.
+func calculateOrderTax(order *ent.OrderEntity, items []*ent.OrderItemEntity, promotionDiscounts map[uuid.UUID]decimal.Decimal) ([]*orderLineTax, *orderTaxTotals) {
+	attributed := decimal.Zero
+	lineDiscounts := make([]decimal.Decimal, 0, len(items))
+	lines := make([]promotionscope.Line, 0, len(items))
+	eligible := make([]bool, 0, len(items))
+	for _, item := range items {
+		lineDiscount := promotionDiscounts[item.ID]
+		if lineDiscount.GreaterThan(item.TotalItemAmount) {
+			lineDiscount = item.TotalItemAmount
+		}
+		lineDiscounts = append(lineDiscounts, lineDiscount)
+		attributed = attributed.Add(lineDiscount)
+		lines = append(lines, promotionscope.Line{ProductID: item.ProductID, Quantity: item.Quantity, Amount: item.TotalItemAmount.Sub(lineDiscount)})
+		eligible = append(eligible, true)
+	}
+	unattributed := order.DiscountAmount.Sub(attributed)
+	if unattributed.IsNegative() {
+		unattributed = decimal.Zero
+	}
+	// Prorate with the same rounding the promotion discounts were split with.
+	discounts := promotionscope.AllocateDiscount(lines, eligible, unattributed)
+
+	lineTaxes := make([]*orderLineTax, 0, len(items))
+	totals := &orderTaxTotals{}
+	for i, item := range items {
+		if item.TaxClass == "" {
+			item.TaxClass = ent.TaxClassVAT
+		}
+		lineTax := calculateOrderLineTax(item, discounts[i].Add(lineDiscounts[i]))
+		lineTaxes = append(lineTaxes, lineTax)
+
+		switch item.TaxClass {
+		case ent.TaxClassZeroRated:
+			totals.ZeroRatedAmount = totals.ZeroRatedAmount.Add(lineTax.VATBaseAmount)
+		case ent.TaxClassExempt:
+			totals.ExemptAmount = totals.ExemptAmount.Add(lineTax.VATBaseAmount)
+		default:
+			totals.VATBaseAmount = totals.VATBaseAmount.Add(lineTax.VATBaseAmount)
+			totals.VATAmount = totals.VATAmount.Add(lineTax.VATAmount)
+			totals.VATAddedAmount = totals.VATAddedAmount.Add(lineTax.VATAdded)
+		}
+	}
+
+	if len(items) == 0 {
+		// Orders created from an amount only are treated as standard-rated
+		// VAT-inclusive sales until their items are recorded.
+		goodsAmount := order.TotalAmount.Sub(order.DiscountAmount)
+		if goodsAmount.IsPositive() {
+			totals.VATBaseAmount, totals.VATAmount = splitVATInclusive(goodsAmount, standardVATRate)
+		}
+	}
+
+	if order.CODFee.IsPositive() {
+		feeBase, feeVAT := splitVATInclusive(order.CODFee, standardVATRate)
+		totals.VATBaseAmount = totals.VATBaseAmount.Add(feeBase)
+		totals.VATAmount = totals.VATAmount.Add(feeVAT)
+	}
+
+	return lineTaxes, totals
+}
+
+// estimateOrderVATAdded is the VAT that will be charged on top of
+// VAT-exclusive prices once the priced lines are stored, so checkout can
+// include it in what the member pays before the order exists.
+func estimateOrderVATAdded(discountAmount decimal.Decimal, lines []*pricedOrderLine, applied []*promotionscope.Candidate) decimal.Decimal {
+	items := make([]*ent.OrderItemEntity, 0, len(lines))
+	promotionDiscounts := make(map[uuid.UUID]decimal.Decimal, len(lines))
+	for i, line := range lines {
+		item := &ent.OrderItemEntity{
+			ID:               uuid.New(),
+			ProductID:        line.Product.ID,
+			Quantity:         line.Quantity,
+			TotalItemAmount:  line.Amount,
+			TaxClass:         line.Product.TaxClass,
+			PriceIncludesVAT: line.Product.PriceIncludesVAT,
+		}
+		for _, candidate := range applied {
+			if i < len(candidate.LineDiscounts) && candidate.LineDiscounts[i].IsPositive() {
+				promotionDiscounts[item.ID] = promotionDiscounts[item.ID].Add(candidate.LineDiscounts[i])
+			}
+		}
+		items = append(items, item)
+	}
+
+	_, totals := calculateOrderTax(&ent.OrderEntity{DiscountAmount: discountAmount}, items, promotionDiscounts)
+	return totals.VATAddedAmount
+}
+
+// recalculateOrderTaxInTx derives per-line and per-order VAT from the stored
+// items and the order discount, and keeps the payable amount in step with VAT
+// charged on top of VAT-exclusive prices while the order is still pending.
+func (s *Service) recalculateOrderTaxInTx(ctx context.Context, tx bun.Tx, order *ent.OrderEntity) error {
+	items, err := s.listOrderItemsByOrderID(ctx, tx, order.ID)
+	if err != nil {
+		return err
+	}
+	promotionDiscounts, err := getOrderItemPromotionDiscountsInTx(ctx, tx, order.ID)
+	if err != nil {
+		return err
+	}
+
+	lineTaxes, totals := calculateOrderTax(order, items, promotionDiscounts)
+	now := time.Now()
+	for i, item := range items {
+		lineTax := lineTaxes[i]
+		item.VATRate = vatRateForTaxClass(item.TaxClass)
+		item.DiscountAmount = lineTax.DiscountAmount
+		item.VATBaseAmount = lineTax.VATBaseAmount
+		item.VATAmount = lineTax.VATAmount
+		item.UpdatedAt = now
+		if _, err := tx.NewUpdate().
+			Model(item).
+			Column("tax_class", "vat_rate", "discount_amount", "vat_base_amount", "vat_amount", "updated_at").
+			Where("id = ?", item.ID).
+			Exec(ctx); err != nil {
+			return err
+		}
+	}
+
+	columns := []string{"vat_base_amount", "vat_amount", "zero_rated_amount", "exempt_amount", "updated_at"}
+	if order.Status == ent.StatusTypePending && !totals.VATAddedAmount.Equal(order.VATAddedAmount) {
+		order.NetAmount = order.NetAmount.Sub(order.VATAddedAmount).Add(totals.VATAddedAmount).Round(2)
+		order.VATAddedAmount = totals.VATAddedAmount
+		columns = append(columns, "net_amount", "vat_added_amount")
+
+		if _, err := tx.NewUpdate().
+			Model((*ent.PaymentEntity)(nil)).
+			Set("amount = ?", order.NetAmount).
+			Where("id = ?", order.PaymentID).
+			Where("status = ?", ent.PaymentTypePending).
+			Exec(ctx); err != nil {
+			return err
+		}
+	}
+
+	order.VATBaseAmount = totals.VATBaseAmount
+	order.VATAmount = totals.VATAmount
+	order.ZeroRatedAmount = totals.ZeroRatedAmount
+	order.ExemptAmount = totals.ExemptAmount
+	order.UpdatedAt = now
+	_, err = tx.NewUpdate().Model(order).Column(columns...).Where("id = ?", order.ID).Exec(ctx)
+	return err
+}
+
+func (s *Service) recalculateOrderTax(ctx context.Context, orderID uuid.UUID) error {
+	return s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		order := new(ent.OrderEntity)
+		if err := tx.NewSelect().Model(order).Where("id = ?", orderID).For("UPDATE").Scan(ctx); err != nil {
+			return err
+		}
+		return s.recalculateOrderTaxInTx(ctx, tx, order)
+	})
+}
+
+func (s *Service) SalesTaxReportService(ctx context.Context, month string) (*SalesTaxReportServiceResponse, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`orders.svc.report.sales_tax.start`)
+
+	loc := utils.DatabaseTimeLocation()
+	month = strings.TrimSpace(month)
+	var start time.Time
+	if month == "" {
+		now := time.Now().In(loc)
+		start = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc)
+	} else {
+		parsed, err := time.ParseInLocation("2006-01", month, loc)
+		if err != nil {
+			return nil, errors.New("invalid report month")
+		}
+		start = parsed
+	}
+	end := start.AddDate(0, 1, 0)
+
+	// Sales are recognised on the order date once payment has been taken,
+	// so unpaid and refused cash-on-delivery orders are left out; refunds are
+	// excluded only after the order is cancelled.
+	rows := make([]*salesTaxReportRow, 0)
+	if err := s.bunDB.DB().NewSelect().
+		TableExpr("orders AS o").
+		ColumnExpr("date_trunc('day', o.created_at) AS date").
+		ColumnExpr("COUNT(*) AS order_count").
+		ColumnExpr("COALESCE(SUM(o.net_amount), 0) AS total_sales").
+		ColumnExpr("COALESCE(SUM(o.vat_base_amount), 0) AS vat_base_amount").
+		ColumnExpr("COALESCE(SUM(o.vat_amount), 0) AS vat_amount").
+		ColumnExpr("COALESCE(SUM(o.zero_rated_amount), 0) AS zero_rated_amount").
+		ColumnExpr("COALESCE(SUM(o.exempt_amount), 0) AS exempt_amount").
+		Where("?", sales.Paid("o")).
+		Where("o.created_at >= ?", start).
+		Where("o.created_at < ?", end).
+		GroupExpr("date_trunc('day', o.created_at)").
+		OrderExpr("date ASC").
+		Scan(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	data := &SalesTaxReportServiceResponse{
+		Period: start.Format("2006-01"),
+		Days:   make([]*SalesTaxReportDay, 0, len(rows)),
+	}
+	for _, row := range rows {
+		data.OrderCount += row.OrderCount
+		data.TotalSales = data.TotalSales.Add(row.TotalSales)
+		data.VATBaseAmount = data.VATBaseAmount.Add(row.VATBaseAmount)
+		data.VATAmount = data.VATAmount.Add(row.VATAmount)
+		data.ZeroRatedAmount = data.ZeroRatedAmount.Add(row.ZeroRatedAmount)
+		data.ExemptAmount = data.ExemptAmount.Add(row.ExemptAmount)
+		data.Days = append(data.Days, &SalesTaxReportDay{
+			Date:            row.Date.Format("2006-01-02"),
+			OrderCount:      row.OrderCount,
+			TotalSales:      row.TotalSales,
+			VATBaseAmount:   row.VATBaseAmount,
+			VATAmount:       row.VATAmount,
+			ZeroRatedAmount: row.ZeroRatedAmount,
+			ExemptAmount:    row.ExemptAmount,
+		})
+	}
+
+	span.AddEvent(`orders.svc.report.sales_tax.success`)
+	return data, nil
+}
@@ -0,0 +1,332 @@
+package orders
+
+import (
+	"testing"
+
+	"phakram/app/modules/entities/ent"
+	promotionscope "phakram/app/utils/promotion"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+func dec(value string) decimal.Decimal {
+	return decimal.RequireFromString(value)
+}
+
+func decPtr(value string) *decimal.Decimal {
+	d := dec(value)
+	return &d
+}
+
+func orderItem(amount string, taxClass ent.TaxClassEnum, includesVAT bool) *ent.OrderItemEntity {
+	return &ent.OrderItemEntity{
+		ID:               uuid.New(),
+		ProductID:        uuid.New(),
+		Quantity:         1,
+		TotalItemAmount:  dec(amount),
+		TaxClass:         taxClass,
+		PriceIncludesVAT: includesVAT,
+	}
+}
+
+func Test_splitVATInclusive(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		rate     string
+		wantBase string
+		wantVAT  string
+	}{
+		{"standard rate", "107", "7", "100", "7"},
+		{"base is rounded and vat takes the rest", "100", "7", "93.46", "6.54"},
+		{"one satang", "0.01", "7", "0.01", "0"},
+		{"zero", "0", "7", "0", "0"},
+		{"zero rate keeps the amount", "50", "0", "50", "0"},
+		{"zero rate rounds the amount", "10.005", "0", "10.01", "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, vat := splitVATInclusive(dec(tt.amount), dec(tt.rate))
+			if !base.Equal(dec(tt.wantBase)) || !vat.Equal(dec(tt.wantVAT)) {
+				t.Errorf("splitVATInclusive() = %v, %v, want %v, %v", base, vat, tt.wantBase, tt.wantVAT)
+			}
+		})
+	}
+}
+
+func Test_calculateOrderLineTax(t *testing.T) {
+	tests := []struct {
+		name         string
+		item         *ent.OrderItemEntity
+		discount     string
+		wantBase     string
+		wantVAT      string
+		wantVATAdded string
+	}{
+		{"vat inclusive", orderItem("107", ent.TaxClassVAT, true), "0", "100", "7", "0"},
+		{"vat inclusive after discount", orderItem("107", ent.TaxClassVAT, true), "53.50", "50", "3.50", "0"},
+		{"vat exclusive adds vat", orderItem("100", ent.TaxClassVAT, false), "0", "100", "7", "7"},
+		{"vat exclusive after discount", orderItem("100", ent.TaxClassVAT, false), "10", "90", "6.30", "6.30"},
+		{"vat exclusive rounds vat", orderItem("33.33", ent.TaxClassVAT, false), "0", "33.33", "2.33", "2.33"},
+		{"zero rated", orderItem("100", ent.TaxClassZeroRated, false), "0", "100", "0", "0"},
+		{"zero rated inclusive", orderItem("100", ent.TaxClassZeroRated, true), "20", "80", "0", "0"},
+		{"exempt", orderItem("50", ent.TaxClassExempt, true), "0", "50", "0", "0"},
+		{"discount above the line total", orderItem("100", ent.TaxClassVAT, false), "150", "0", "0", "0"},
+		{"discount above an inclusive line total", orderItem("107", ent.TaxClassVAT, true), "200", "0", "0", "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := calculateOrderLineTax(tt.item, dec(tt.discount))
+			if !got.DiscountAmount.Equal(dec(tt.discount)) {
+				t.Errorf("calculateOrderLineTax() discount = %v, want %v", got.DiscountAmount, tt.discount)
+			}
+			if !got.VATBaseAmount.Equal(dec(tt.wantBase)) {
+				t.Errorf("calculateOrderLineTax() base = %v, want %v", got.VATBaseAmount, tt.wantBase)
+			}
+			if !got.VATAmount.Equal(dec(tt.wantVAT)) {
+				t.Errorf("calculateOrderLineTax() vat = %v, want %v", got.VATAmount, tt.wantVAT)
+			}
+			if !got.VATAdded.Equal(dec(tt.wantVATAdded)) {
+				t.Errorf("calculateOrderLineTax() vat added = %v, want %v", got.VATAdded, tt.wantVATAdded)
+			}
+		})
+	}
+}
+
+func Test_calculateOrderTax(t *testing.T) {
+	inclusive := orderItem("107", ent.TaxClassVAT, true)
+	exclusive := orderItem("100", ent.TaxClassVAT, false)
+	zeroRated := orderItem("50", ent.TaxClassZeroRated, false)
+	exempt := orderItem("30", ent.TaxClassExempt, true)
+	unclassified := orderItem("107", "", true)
+
+	type want struct {
+		lineDiscounts []string
+		vatBase       string
+		vat           string
+		vatAdded      string
+		zeroRated     string
+		exempt        string
+	}
+	tests := []struct {
+		name               string
+		order              *ent.OrderEntity
+		items              []*ent.OrderItemEntity
+		promotionDiscounts map[uuid.UUID]decimal.Decimal
+		want               want
+	}{
+		{
+			name:  "every tax class",
+			order: &ent.OrderEntity{TotalAmount: dec("287")},
+			items: []*ent.OrderItemEntity{inclusive, exclusive, zeroRated, exempt},
+			want:  want{[]string{"0", "0", "0", "0"}, "200", "14", "7", "50", "30"},
+		},
+		{
+			name:  "missing tax class is standard rated",
+			order: &ent.OrderEntity{TotalAmount: dec("107")},
+			items: []*ent.OrderItemEntity{unclassified},
+			want:  want{[]string{"0"}, "100", "7", "0", "0", "0"},
+		},
+		{
+			name:  "order discount is prorated",
+			order: &ent.OrderEntity{TotalAmount: dec("207"), DiscountAmount: dec("20.70")},
+			items: []*ent.OrderItemEntity{inclusive, exclusive},
+			want:  want{[]string{"10.70", "10"}, "180", "12.60", "6.30", "0", "0"},
+		},
+		{
+			name:               "promotion discount stays on its line",
+			order:              &ent.OrderEntity{TotalAmount: dec("207"), DiscountAmount: dec("10")},
+			items:              []*ent.OrderItemEntity{inclusive, exclusive},
+			promotionDiscounts: map[uuid.UUID]decimal.Decimal{exclusive.ID: dec("10")},
+			want:               want{[]string{"0", "10"}, "190", "13.30", "6.30", "0", "0"},
+		},
+		{
+			name:               "promotion discount above the line total is capped and the rest prorated",
+			order:              &ent.OrderEntity{TotalAmount: dec("207"), DiscountAmount: dec("153.50")},
+			items:              []*ent.OrderItemEntity{exclusive, inclusive},
+			promotionDiscounts: map[uuid.UUID]decimal.Decimal{exclusive.ID: dec("150")},
+			want:               want{[]string{"100", "53.50"}, "50", "3.50", "0", "0", "0"},
+		},
+		{
+			name:  "cod fee is split as vat inclusive",
+			order: &ent.OrderEntity{TotalAmount: dec("107"), CODFee: dec("32.10")},
+			items: []*ent.OrderItemEntity{inclusive},
+			want:  want{[]string{"0"}, "130", "9.10", "0", "0", "0"},
+		},
+		{
+			name:  "cod fee is standard rated on a zero rated order",
+			order: &ent.OrderEntity{TotalAmount: dec("50"), CODFee: dec("10.70")},
+			items: []*ent.OrderItemEntity{zeroRated},
+			want:  want{[]string{"0"}, "10", "0.70", "0", "50", "0"},
+		},
+		{
+			name:  "cod fee does not add vat",
+			order: &ent.OrderEntity{TotalAmount: dec("100"), CODFee: dec("21.40")},
+			items: []*ent.OrderItemEntity{exclusive},
+			want:  want{[]string{"0"}, "120", "8.40", "7", "0", "0"},
+		},
+		{
+			name:  "amount only order is vat inclusive",
+			order: &ent.OrderEntity{TotalAmount: dec("224"), DiscountAmount: dec("10")},
+			want:  want{[]string{}, "200", "14", "0", "0", "0"},
+		},
+		{
+			name:  "amount only order with cod fee",
+			order: &ent.OrderEntity{TotalAmount: dec("107"), CODFee: dec("10.70")},
+			want:  want{[]string{}, "110", "7.70", "0", "0", "0"},
+		},
+		{
+			name:  "fully discounted amount only order",
+			order: &ent.OrderEntity{TotalAmount: dec("100"), DiscountAmount: dec("120")},
+			want:  want{[]string{}, "0", "0", "0", "0", "0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lineTaxes, totals := calculateOrderTax(tt.order, tt.items, tt.promotionDiscounts)
+			if len(lineTaxes) != len(tt.want.lineDiscounts) {
+				t.Fatalf("calculateOrderTax() lines = %d, want %d", len(lineTaxes), len(tt.want.lineDiscounts))
+			}
+			for i, lineTax := range lineTaxes {
+				if !lineTax.DiscountAmount.Equal(dec(tt.want.lineDiscounts[i])) {
+					t.Errorf("calculateOrderTax() line %d discount = %v, want %v", i, lineTax.DiscountAmount, tt.want.lineDiscounts[i])
+				}
+			}
+			if !totals.VATBaseAmount.Equal(dec(tt.want.vatBase)) {
+				t.Errorf("calculateOrderTax() vat base = %v, want %v", totals.VATBaseAmount, tt.want.vatBase)
+			}
+			if !totals.VATAmount.Equal(dec(tt.want.vat)) {
+				t.Errorf("calculateOrderTax() vat = %v, want %v", totals.VATAmount, tt.want.vat)
+			}
+			if !totals.VATAddedAmount.Equal(dec(tt.want.vatAdded)) {
+				t.Errorf("calculateOrderTax() vat added = %v, want %v", totals.VATAddedAmount, tt.want.vatAdded)
+			}
+			if !totals.ZeroRatedAmount.Equal(dec(tt.want.zeroRated)) {
+				t.Errorf("calculateOrderTax() zero rated = %v, want %v", totals.ZeroRatedAmount, tt.want.zeroRated)
+			}
+			if !totals.ExemptAmount.Equal(dec(tt.want.exempt)) {
+				t.Errorf("calculateOrderTax() exempt = %v, want %v", totals.ExemptAmount, tt.want.exempt)
+			}
+		})
+	}
+
+	if unclassified.TaxClass != ent.TaxClassVAT {
+		t.Errorf("calculateOrderTax() tax class = %q, want %q", unclassified.TaxClass, ent.TaxClassVAT)
+	}
+}
+
+func pricedLine(amount string, taxClass ent.TaxClassEnum, includesVAT bool) *pricedOrderLine {
+	return &pricedOrderLine{
+		Product:  &ent.ProductEntity{ID: uuid.New(), TaxClass: taxClass, PriceIncludesVAT: includesVAT},
+		Quantity: 1,
+		Amount:   dec(amount),
+	}
+}
+
+func Test_estimateOrderVATAdded(t *testing.T) {
+	tests := []struct {
+		name           string
+		discountAmount string
+		lines          []*pricedOrderLine
+		applied        []*promotionscope.Candidate
+		want           string
+	}{
+		{
+			name:  "vat inclusive lines add nothing",
+			lines: []*pricedOrderLine{pricedLine("107", ent.TaxClassVAT, true)},
+			want:  "0",
+		},
+		{
+			name:  "vat exclusive lines",
+			lines: []*pricedOrderLine{pricedLine("100", ent.TaxClassVAT, false), pricedLine("50", ent.TaxClassVAT, false)},
+			want:  "10.50",
+		},
+		{
+			name:  "zero rated and exempt lines add nothing",
+			lines: []*pricedOrderLine{pricedLine("100", ent.TaxClassZeroRated, false), pricedLine("100", ent.TaxClassExempt, false)},
+			want:  "0",
+		},
+		{
+			name:           "line discount of an applied promotion",
+			discountAmount: "10",
+			lines:          []*pricedOrderLine{pricedLine("100", ent.TaxClassVAT, false), pricedLine("107", ent.TaxClassVAT, true)},
+			applied:        []*promotionscope.Candidate{{LineDiscounts: []decimal.Decimal{dec("10"), decimal.Zero}}},
+			want:           "6.30",
+		},
+		{
+			name:           "reward lines beyond the promotion's lines",
+			discountAmount: "10",
+			lines:          []*pricedOrderLine{pricedLine("100", ent.TaxClassVAT, false), pricedLine("20", ent.TaxClassVAT, false)},
+			applied:        []*promotionscope.Candidate{{LineDiscounts: []decimal.Decimal{dec("10")}}},
+			want:           "7.70",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			discountAmount := decimal.Zero
+			if tt.discountAmount != "" {
+				discountAmount = dec(tt.discountAmount)
+			}
+			if got := estimateOrderVATAdded(discountAmount, tt.lines, tt.applied); !got.Equal(dec(tt.want)) {
+				t.Errorf("estimateOrderVATAdded() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Test_codEligibility_includesVATAdded pins that the COD limits and the
+// percent fee see the amount the courier collects, VAT added included.
+func Test_codEligibility_includesVATAdded(t *testing.T) {
+	lines := []*pricedOrderLine{pricedLine("1000", ent.TaxClassVAT, false)}
+	orderAmount := dec("1000").Add(estimateOrderVATAdded(decimal.Zero, lines, nil))
+
+	tests := []struct {
+		name         string
+		setting      *ent.CODSettingEntity
+		wantEligible bool
+		wantFee      string
+		wantNet      string
+	}{
+		{
+			name:         "max order amount between the pre-vat and collected amount",
+			setting:      &ent.CODSettingEntity{IsEnabled: true, FeeType: codFeeTypeAmount, FeeValue: dec("30"), MaxOrderAmount: decPtr("1050")},
+			wantEligible: false,
+			wantFee:      "0",
+			wantNet:      "1070",
+		},
+		{
+			name:         "percent fee on the collected amount",
+			setting:      &ent.CODSettingEntity{IsEnabled: true, FeeType: codFeeTypePercent, FeeValue: dec("3")},
+			wantEligible: true,
+			wantFee:      "32.10",
+			wantNet:      "1102.10",
+		},
+		{
+			name:         "min order amount met only with vat",
+			setting:      &ent.CODSettingEntity{IsEnabled: true, FeeType: codFeeTypeAmount, FeeValue: dec("30"), MinOrderAmount: dec("1050")},
+			wantEligible: true,
+			wantFee:      "30",
+			wantNet:      "1100",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := codEligibility(tt.setting, 0, orderAmount)
+			if got.Eligible != tt.wantEligible {
+				t.Errorf("codEligibility() eligible = %v, want %v (%s)", got.Eligible, tt.wantEligible, got.Reason)
+			}
+			if !got.CODFee.Equal(dec(tt.wantFee)) {
+				t.Errorf("codEligibility() fee = %v, want %v", got.CODFee, tt.wantFee)
+			}
+			if !got.NetAmount.Equal(dec(tt.wantNet)) {
+				t.Errorf("codEligibility() net = %v, want %v", got.NetAmount, tt.wantNet)
+			}
+		})
+	}
+}
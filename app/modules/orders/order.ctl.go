@@ -24,15 +24,24 @@ type ListMemberNotificationControllerRequest struct {
 }
 
 type CreateOrderControllerRequest struct {
-	MemberID           string `json:"member_id"`
-	PaymentID          string `json:"payment_id"`
-	AddressID          string `json:"address_id"`
-	PromotionCode      string `json:"promotion_code"`
-	Status             string `json:"status"`
-	ShippingTrackingNo string `json:"shipping_tracking_no"`
-	TotalAmount        string `json:"total_amount"`
-	DiscountAmount     string `json:"discount_amount"`
-	NetAmount          string `json:"net_amount"`
+	MemberID           string                             `json:"member_id"`
+	PaymentID          string                             `json:"payment_id"`
+	AddressID          string                             `json:"address_id"`
+	PromotionCode      string                             `json:"promotion_code"`
+	PromotionCodes     []string                           `json:"promotion_codes"`
+	AutoApplyPromotion bool                               `json:"auto_apply_promotion"`
+	PaymentMethod      string                             `json:"payment_method"`
+	Status             string                             `json:"status"`
+	ShippingTrackingNo string                             `json:"shipping_tracking_no"`
+	TotalAmount        string                             `json:"total_amount"`
+	DiscountAmount     string                             `json:"discount_amount"`
+	NetAmount          string                             `json:"net_amount"`
+	Items              []CreateOrderLineControllerRequest `json:"items"`
+}
+
+type CreateOrderLineControllerRequest struct {
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
 }
 
 type UpdateOrderControllerRequest struct {
@@ -227,6 +236,11 @@ func (c *Controller) CreateOrderController(ctx *gin.Context) {
 		base.BadRequest(ctx, i18n.BadRequest, nil)
 		return
 	}
+	items, err := toCreateOrderLines(req.Items)
+	if err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
 
 	requesterID, hasRequester := auth.GetMemberID(ctx)
 	isAdmin := auth.GetIsAdmin(ctx)
@@ -254,11 +268,15 @@ func (c *Controller) CreateOrderController(ctx *gin.Context) {
 		PaymentID:          paymentID,
 		AddressID:          addressID,
 		PromotionCode:      req.PromotionCode,
+		PromotionCodes:     req.PromotionCodes,
+		AutoApplyPromotion: req.AutoApplyPromotion,
+		PaymentMethod:      req.PaymentMethod,
 		Status:             req.Status,
 		ShippingTrackingNo: req.ShippingTrackingNo,
 		TotalAmount:        req.TotalAmount,
 		DiscountAmount:     req.DiscountAmount,
 		NetAmount:          req.NetAmount,
+		Items:              items,
 	})
 	if err != nil {
 		base.HandleError(ctx, err)
@@ -269,6 +287,21 @@ func (c *Controller) CreateOrderController(ctx *gin.Context) {
 	base.Success(ctx, data)
 }
 
+func toCreateOrderLines(lines []CreateOrderLineControllerRequest) ([]CreateOrderLineServiceRequest, error) {
+	items := make([]CreateOrderLineServiceRequest, 0, len(lines))
+	for _, line := range lines {
+		productID, err := uuid.Parse(line.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, CreateOrderLineServiceRequest{
+			ProductID: productID,
+			Quantity:  line.Quantity,
+		})
+	}
+	return items, nil
+}
+
 func (c *Controller) UpdateOrderController(ctx *gin.Context) {
 	span, _ := utils.LogSpanFromGin(ctx)
 	span.AddEvent(`orders.ctl.update.start`)
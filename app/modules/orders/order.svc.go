@@ -8,8 +8,13 @@ import (
 	"fmt"
 	entitiesdto "phakram/app/modules/entities/dto"
 	"phakram/app/modules/entities/ent"
+	"phakram/app/modules/members"
 	"phakram/app/utils"
 	"phakram/app/utils/base"
+	"phakram/app/utils/flashsale"
+	"phakram/app/utils/inventory"
+	promotionscope "phakram/app/utils/promotion"
+	thaidate "phakram/app/utils/thai-date"
 	"strings"
 	"time"
 
@@ -36,28 +41,41 @@ type CreateOrderServiceRequest struct {
 	PaymentID          uuid.UUID
 	AddressID          uuid.UUID
 	PromotionCode      string
+	PromotionCodes     []string
+	AutoApplyPromotion bool
+	PaymentMethod      string
 	Status             string
 	ShippingTrackingNo string
 	TotalAmount        string
 	DiscountAmount     string
 	NetAmount          string
+	Items              []CreateOrderLineServiceRequest
 }
 
 type promotionEntity struct {
 	bun.BaseModel `bun:"table:promotions"`
 
-	ID             uuid.UUID  `bun:"id,pk,type:uuid"`
-	Code           string     `bun:"code,notnull"`
-	DiscountType   string     `bun:"discount_type,notnull"`
-	DiscountValue  float64    `bun:"discount_value,notnull"`
-	MaxDiscount    *float64   `bun:"max_discount"`
-	MinOrderAmount float64    `bun:"min_order_amount,notnull"`
-	UsageLimit     *int       `bun:"usage_limit"`
-	UsagePerMember *int       `bun:"usage_per_member"`
-	UsedCount      int        `bun:"used_count,notnull"`
-	StartsAt       *time.Time `bun:"starts_at"`
-	EndsAt         *time.Time `bun:"ends_at"`
-	IsActive       bool       `bun:"is_active,notnull"`
+	ID                  uuid.UUID  `bun:"id,pk,type:uuid"`
+	Code                string     `bun:"code,notnull"`
+	DiscountType        string     `bun:"discount_type,notnull"`
+	DiscountValue       float64    `bun:"discount_value,notnull"`
+	MaxDiscount         *float64   `bun:"max_discount"`
+	MinOrderAmount      float64    `bun:"min_order_amount,notnull"`
+	UsageLimit          *int       `bun:"usage_limit"`
+	UsagePerMember      *int       `bun:"usage_per_member"`
+	UsedCount           int        `bun:"used_count,notnull"`
+	StartsAt            *time.Time `bun:"starts_at"`
+	EndsAt              *time.Time `bun:"ends_at"`
+	IsActive            bool       `bun:"is_active,notnull"`
+	BuyQuantity         int        `bun:"buy_quantity,notnull"`
+	GetQuantity         int        `bun:"get_quantity,notnull"`
+	GetDiscountPercent  float64    `bun:"get_discount_percent,notnull"`
+	GetProductID        *uuid.UUID `bun:"get_product_id,type:uuid"`
+	BundlePrice         float64    `bun:"bundle_price,notnull"`
+	IsExclusive         bool       `bun:"is_exclusive,notnull"`
+	StackWithTier       bool       `bun:"stack_with_tier,notnull"`
+	StackWithPromotions bool       `bun:"stack_with_promotions,notnull"`
+	Priority            int        `bun:"priority,notnull"`
 }
 
 type promotionUsageEntity struct {
@@ -67,14 +85,6 @@ type promotionUsageEntity struct {
 	MemberID    uuid.UUID `bun:"member_id,type:uuid,notnull"`
 }
 
-type promotionDiscountResult struct {
-	PromotionID     uuid.UUID
-	DiscountAmount  decimal.Decimal
-	OriginalCode    string
-	NormalizedCode  string
-	ValidatedAmount decimal.Decimal
-}
-
 type UpdateOrderServiceRequest struct {
 	PaymentID          uuid.UUID
 	AddressID          uuid.UUID
@@ -125,15 +135,18 @@ type OrderTimelineItem struct {
 }
 
 type MemberNotificationItem struct {
-	ID          uuid.UUID `json:"id"`
-	EventType   string    `json:"event_type"`
-	Title       string    `json:"title"`
-	Message     string    `json:"message"`
-	OrderID     uuid.UUID `json:"order_id"`
-	OrderNo     string    `json:"order_no"`
-	OrderStatus string    `json:"order_status"`
-	IsRead      bool      `json:"is_read"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID               uuid.UUID  `json:"id"`
+	EventType        string     `json:"event_type"`
+	Title            string     `json:"title"`
+	Message          string     `json:"message"`
+	OrderID          uuid.UUID  `json:"order_id"`
+	OrderNo          string     `json:"order_no"`
+	OrderStatus      string     `json:"order_status"`
+	PromotionID      *uuid.UUID `json:"promotion_id,omitempty"`
+	ProductID        *uuid.UUID `json:"product_id,omitempty"`
+	UnsubscribeToken string     `json:"unsubscribe_token,omitempty"`
+	IsRead           bool       `json:"is_read"`
+	CreatedAt        time.Time  `json:"created_at"`
 }
 
 type MemberNotificationMarkAllReadResponse struct {
@@ -170,6 +183,9 @@ type memberNotificationRow struct {
 	OrderID      uuid.UUID          `bun:"order_id"`
 	OrderNo      string             `bun:"order_no"`
 	OrderStatus  ent.StatusTypeEnum `bun:"order_status"`
+	PromotionID  *uuid.UUID         `bun:"promotion_id"`
+	ProductID    *uuid.UUID         `bun:"product_id"`
+	AlertToken   *uuid.UUID         `bun:"alert_token"`
 	IsRead       bool               `bun:"is_read"`
 }
 
@@ -205,12 +221,29 @@ func (s *Service) ListOrderService(ctx context.Context, req *ListOrderServiceReq
 		item.PaymentRejected = reviewState.Rejected
 		item.PaymentRejectionReason = normalizePaymentRejectionReason(reviewState.Reason)
 
+		slipFlagged, flaggedErr := s.isOrderPaymentSlipFlagged(ctx, item.ID)
+		if flaggedErr != nil {
+			return nil, nil, flaggedErr
+		}
+		item.PaymentSlipFlagged = slipFlagged
+
 		trackingNo, trackingErr := s.getOrderShippingTrackingNo(ctx, item.ID)
 		if trackingErr != nil {
 			return nil, nil, trackingErr
 		}
 		item.ShippingTrackingNo = trackingNo
 		item.StatusSummary, item.StatusNextStep = mapOrderStatusSummary(item.Status, reviewState.Submitted, reviewState.Rejected)
+
+		paymentMethod, methodErr := s.getPaymentMethod(ctx, s.bunDB.DB(), item.PaymentID)
+		if methodErr != nil {
+			return nil, nil, methodErr
+		}
+		item.PaymentMethod = string(paymentMethod)
+		if paymentMethod == ent.PaymentMethodCOD {
+			if summary, nextStep, ok := mapCODOrderStatusSummary(item.Status); ok {
+				item.StatusSummary, item.StatusNextStep = summary, nextStep
+			}
+		}
 	}
 
 	span.AddEvent(`orders.svc.list.success`)
@@ -229,13 +262,15 @@ func (s *Service) ListMemberNotificationService(ctx context.Context, req *ListMe
 
 	query := s.bunDB.DB().NewSelect().
 		TableExpr("audit_log AS al").
-		Join("JOIN orders AS o ON o.id = al.action_id").
+		Join("LEFT JOIN orders AS o ON o.id = al.action_id").
+		Join("LEFT JOIN member_promotion_collections AS mpc ON mpc.id = al.action_id").
+		Join("LEFT JOIN member_wishlist AS mw ON mw.id = al.action_id").
 		Join("LEFT JOIN member_notification_reads AS mnr ON mnr.notification_id = al.id AND mnr.member_id = ?", requesterID).
 		Where("al.status = ?", ent.StatusAuditSuccesses).
 		Where("al.action_type IN (?)", bun.In(allowedEventTypes))
 
 	_ = isAdmin
-	query = query.Where("o.member_id = ?", requesterID)
+	query = query.Where("(o.member_id = ? OR mpc.member_id = ? OR mw.member_id = ?)", requesterID, requesterID, requesterID)
 
 	total, err := query.Clone().Count(ctx)
 	if err != nil {
@@ -250,8 +285,11 @@ func (s *Service) ListMemberNotificationService(ctx context.Context, req *ListMe
 		ColumnExpr("al.action_detail AS action_detail").
 		ColumnExpr("al.created_at AS created_at").
 		ColumnExpr("o.id AS order_id").
-		ColumnExpr("o.order_no AS order_no").
-		ColumnExpr("o.status AS order_status").
+		ColumnExpr("COALESCE(o.order_no, '') AS order_no").
+		ColumnExpr("COALESCE(o.status, '') AS order_status").
+		ColumnExpr("mpc.promotion_id AS promotion_id").
+		ColumnExpr("mw.product_id AS product_id").
+		ColumnExpr("mw.alert_token AS alert_token").
 		ColumnExpr("CASE WHEN mnr.member_id IS NULL THEN FALSE ELSE TRUE END AS is_read").
 		OrderExpr("al.created_at DESC").
 		Offset(int(offset)).
@@ -263,7 +301,7 @@ func (s *Service) ListMemberNotificationService(ctx context.Context, req *ListMe
 	items := make([]*MemberNotificationItem, 0, len(rows))
 	for _, row := range rows {
 		title, message := mapNotificationTitleMessage(row.ActionType, row.ActionDetail, row.OrderNo)
-		items = append(items, &MemberNotificationItem{
+		item := &MemberNotificationItem{
 			ID:          row.ID,
 			EventType:   row.ActionType,
 			Title:       title,
@@ -271,9 +309,15 @@ func (s *Service) ListMemberNotificationService(ctx context.Context, req *ListMe
 			OrderID:     row.OrderID,
 			OrderNo:     row.OrderNo,
 			OrderStatus: string(row.OrderStatus),
+			PromotionID: row.PromotionID,
+			ProductID:   row.ProductID,
 			IsRead:      row.IsRead,
 			CreatedAt:   row.CreatedAt,
-		})
+		}
+		if (row.ActionType == members.WishlistAlertBackInStock || row.ActionType == members.WishlistAlertPriceDrop) && row.AlertToken != nil {
+			item.UnsubscribeToken = row.AlertToken.String()
+		}
+		items = append(items, item)
 	}
 
 	page := &base.ResponsePaginate{Page: req.GetPage(), Size: req.GetSize(), Total: int64(total)}
@@ -330,12 +374,14 @@ func (s *Service) MarkAllMemberNotificationsReadService(ctx context.Context, req
 	notificationIDs := make([]uuid.UUID, 0)
 	query := s.bunDB.DB().NewSelect().
 		TableExpr("audit_log AS al").
-		Join("JOIN orders AS o ON o.id = al.action_id").
+		Join("LEFT JOIN orders AS o ON o.id = al.action_id").
+		Join("LEFT JOIN member_promotion_collections AS mpc ON mpc.id = al.action_id").
+		Join("LEFT JOIN member_wishlist AS mw ON mw.id = al.action_id").
 		Where("al.status = ?", ent.StatusAuditSuccesses).
 		Where("al.action_type IN (?)", bun.In(allowedEventTypes))
 
 	_ = isAdmin
-	query = query.Where("o.member_id = ?", requesterID)
+	query = query.Where("(o.member_id = ? OR mpc.member_id = ? OR mw.member_id = ?)", requesterID, requesterID, requesterID)
 
 	if err := query.
 		ColumnExpr("al.id").
@@ -381,11 +427,13 @@ func (s *Service) CountMemberUnreadNotificationsService(ctx context.Context, req
 
 	count, err := s.bunDB.DB().NewSelect().
 		TableExpr("audit_log AS al").
-		Join("JOIN orders AS o ON o.id = al.action_id").
+		Join("LEFT JOIN orders AS o ON o.id = al.action_id").
+		Join("LEFT JOIN member_promotion_collections AS mpc ON mpc.id = al.action_id").
+		Join("LEFT JOIN member_wishlist AS mw ON mw.id = al.action_id").
 		Join("LEFT JOIN member_notification_reads AS mnr ON mnr.notification_id = al.id AND mnr.member_id = ?", requesterID).
 		Where("al.status = ?", ent.StatusAuditSuccesses).
 		Where("al.action_type IN (?)", bun.In(allowedEventTypes)).
-		Where("o.member_id = ?", requesterID).
+		Where("(o.member_id = ? OR mpc.member_id = ? OR mw.member_id = ?)", requesterID, requesterID, requesterID).
 		Where("mnr.notification_id IS NULL").
 		Count(ctx)
 	if err != nil {
@@ -407,13 +455,15 @@ func (s *Service) ensureMemberNotificationAccess(ctx context.Context, notificati
 
 	query := s.bunDB.DB().NewSelect().
 		TableExpr("audit_log AS al").
-		Join("JOIN orders AS o ON o.id = al.action_id").
+		Join("LEFT JOIN orders AS o ON o.id = al.action_id").
+		Join("LEFT JOIN member_promotion_collections AS mpc ON mpc.id = al.action_id").
+		Join("LEFT JOIN member_wishlist AS mw ON mw.id = al.action_id").
 		Where("al.id = ?", notificationID).
 		Where("al.status = ?", ent.StatusAuditSuccesses).
 		Where("al.action_type IN (?)", bun.In(allowedEventTypes))
 
 	_ = isAdmin
-	query = query.Where("o.member_id = ?", requesterID)
+	query = query.Where("(o.member_id = ? OR mpc.member_id = ? OR mw.member_id = ?)", requesterID, requesterID, requesterID)
 
 	count, err := query.Count(ctx)
 	if err != nil {
@@ -432,8 +482,15 @@ func memberNotificationEventTypes() []string {
 		"order_payment_approved",
 		"order_payment_rejected",
 		"order_refund_rejected",
+		"order_cod_collected",
+		"order_cod_refused",
+		"order_refund_paid",
+		"order_refund_payout_failed",
 		"order_status_transition",
 		"order_shipping_tracking_updated",
+		"promotion_expiry_reminder",
+		members.WishlistAlertBackInStock,
+		members.WishlistAlertPriceDrop,
 	}
 }
 
@@ -454,6 +511,12 @@ func (s *Service) InfoOrderService(ctx context.Context, orderID uuid.UUID, reque
 	data.PaymentRejected = paymentReviewState.Rejected
 	data.PaymentRejectionReason = normalizePaymentRejectionReason(paymentReviewState.Reason)
 
+	slipFlagged, err := s.isOrderPaymentSlipFlagged(ctx, data.ID)
+	if err != nil {
+		return nil, err
+	}
+	data.PaymentSlipFlagged = slipFlagged
+
 	trackingNo, err := s.getOrderShippingTrackingNo(ctx, data.ID)
 	if err != nil {
 		return nil, err
@@ -461,6 +524,17 @@ func (s *Service) InfoOrderService(ctx context.Context, orderID uuid.UUID, reque
 	data.ShippingTrackingNo = trackingNo
 	data.StatusSummary, data.StatusNextStep = mapOrderStatusSummary(data.Status, paymentReviewState.Submitted, paymentReviewState.Rejected)
 
+	paymentMethod, err := s.getPaymentMethod(ctx, s.bunDB.DB(), data.PaymentID)
+	if err != nil {
+		return nil, err
+	}
+	data.PaymentMethod = string(paymentMethod)
+	if paymentMethod == ent.PaymentMethodCOD {
+		if summary, nextStep, ok := mapCODOrderStatusSummary(data.Status); ok {
+			data.StatusSummary, data.StatusNextStep = summary, nextStep
+		}
+	}
+
 	cancellationReason, err := s.getOrderCancellationReason(ctx, data.ID)
 	if err != nil {
 		return nil, err
@@ -583,32 +657,26 @@ func (s *Service) CreateOrderService(ctx context.Context, req *CreateOrderServic
 	span, _ := utils.LogSpanFromContext(ctx)
 	span.AddEvent(`orders.svc.create.start`)
 
-	totalAmount, err := decimal.NewFromString(req.TotalAmount)
+	lines, totalAmount, err := s.priceOrderRequest(ctx, req.MemberID, req.Items, req.TotalAmount)
 	if err != nil {
 		return nil, err
 	}
-	tierDiscountAmount, tierNetAmount, err := s.calculateOrderAmountsByMemberTier(ctx, req.MemberID, totalAmount)
+	promotionCodes := normalizePromotionCodes(req.PromotionCode, req.PromotionCodes)
+	if len(promotionCodes) == 0 && req.AutoApplyPromotion {
+		bestCode, err := s.bestPromotionCode(ctx, req.MemberID, lines, totalAmount)
+		if err != nil {
+			return nil, err
+		}
+		promotionCodes = normalizePromotionCodes(bestCode, nil)
+	}
+	discounts, err := s.calculateOrderDiscounts(ctx, req.MemberID, promotionCodes, lines, totalAmount)
 	if err != nil {
 		return nil, err
 	}
+	lines = discounts.Lines
+	totalAmount = discounts.TotalAmount
 
-	promotionDiscountAmount := decimal.Zero
-	promotionCode := strings.ToUpper(strings.TrimSpace(req.PromotionCode))
-	if promotionCode != "" {
-		promotionDiscount, promoErr := s.calculatePromotionDiscount(ctx, req.MemberID, promotionCode, totalAmount)
-		if promoErr != nil {
-			return nil, promoErr
-		}
-
-		if promotionDiscount != nil {
-			promotionDiscountAmount = promotionDiscount.DiscountAmount
-			if promotionDiscountAmount.GreaterThan(tierNetAmount) {
-				promotionDiscountAmount = tierNetAmount
-			}
-		}
-	}
-
-	discountAmount := tierDiscountAmount.Add(promotionDiscountAmount).Round(2)
+	discountAmount := discounts.Breakdown.TotalDiscount
 	if discountAmount.GreaterThan(totalAmount) {
 		discountAmount = totalAmount
 	}
@@ -617,6 +685,33 @@ func (s *Service) CreateOrderService(ctx context.Context, req *CreateOrderServic
 	if netAmount.IsNegative() {
 		netAmount = decimal.Zero
 	}
+	// VAT charged on top of VAT-exclusive prices is collected with the
+	// order, so the COD limits and percent fee are measured including it.
+	vatAddedAmount := estimateOrderVATAdded(discountAmount, lines, discounts.Applied)
+	netAmount = netAmount.Add(vatAddedAmount)
+
+	paymentMethod, err := parsePaymentMethod(req.PaymentMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	codFee := decimal.Zero
+	if paymentMethod == ent.PaymentMethodCOD {
+		if req.PaymentID != uuid.Nil {
+			return nil, errors.New("cash on delivery order cannot use existing payment")
+		}
+
+		eligibility, err := s.evaluateCODEligibility(ctx, req.MemberID, netAmount)
+		if err != nil {
+			return nil, err
+		}
+		if !eligibility.Eligible {
+			return nil, errors.New("member is not eligible for cash on delivery")
+		}
+
+		codFee = eligibility.CODFee
+		netAmount = eligibility.NetAmount
+	}
 
 	requireMemberPayment := req.PaymentID != uuid.Nil
 	if req.PaymentID == uuid.Nil {
@@ -624,6 +719,7 @@ func (s *Service) CreateOrderService(ctx context.Context, req *CreateOrderServic
 			ID:     uuid.New(),
 			Amount: netAmount,
 			Status: ent.PaymentTypePending,
+			Method: paymentMethod,
 		}
 		if _, err := s.bunDB.DB().NewInsert().Model(payment).Exec(ctx); err != nil {
 			return nil, err
@@ -656,11 +752,32 @@ func (s *Service) CreateOrderService(ctx context.Context, req *CreateOrderServic
 		TotalAmount:    totalAmount,
 		DiscountAmount: discountAmount,
 		NetAmount:      netAmount,
+		VATAddedAmount: vatAddedAmount,
+		CODFee:         codFee,
 		CreatedAt:      now,
 		UpdatedAt:      now,
 	}
 
-	if err := s.order.CreateOrder(ctx, data); err != nil {
+	if err := s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewInsert().Model(data).Exec(ctx); err != nil {
+			return err
+		}
+		if err := flashsale.ReserveInTx(ctx, tx, req.MemberID, data.ID, flashSaleReservations(lines)); err != nil {
+			return err
+		}
+		for _, applied := range discounts.Applied {
+			if applied.CodeID == nil {
+				continue
+			}
+			if err := promotionscope.RedeemCodeInTx(ctx, tx, *applied.CodeID, req.MemberID, data.ID); err != nil {
+				return err
+			}
+		}
+		if err := insertOrderLinesInTx(ctx, tx, data, lines, discounts.Applied); err != nil {
+			return err
+		}
+		return s.recalculateOrderTaxInTx(ctx, tx, data)
+	}); err != nil {
 		return nil, err
 	}
 
@@ -668,42 +785,61 @@ func (s *Service) CreateOrderService(ctx context.Context, req *CreateOrderServic
 	return data, nil
 }
 
-func (s *Service) calculatePromotionDiscount(ctx context.Context, memberID uuid.UUID, code string, orderAmount decimal.Decimal) (*promotionDiscountResult, error) {
+// calculatePromotionDiscount validates the code and computes its discount.
+// The code may be the promotion's master code or one of its single-use
+// child codes.
+// Promotions scoped to products or categories, buy X get Y and bundles need
+// the order lines and only discount the eligible ones; the minimum spend
+// still applies to the whole order.
+func (s *Service) calculatePromotionDiscount(ctx context.Context, memberID uuid.UUID, code string, orderAmount decimal.Decimal, lines []promotionscope.Line) (*promotionscope.Candidate, error) {
 	normalizedCode := strings.ToUpper(strings.TrimSpace(code))
 	if normalizedCode == "" {
 		return nil, nil
 	}
 
+	resolved, err := promotionscope.ResolveCode(ctx, s.bunDB.DB(), normalizedCode)
+	if err != nil {
+		return nil, err
+	}
+
 	promotion := new(promotionEntity)
 	if err := s.bunDB.DB().NewSelect().
 		Model(promotion).
-		Where("code = ?", normalizedCode).
+		Where("id = ?", resolved.PromotionID).
 		Limit(1).
 		Scan(ctx); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, errors.New("promotion code is invalid")
+			return nil, promotionscope.ErrCodeNotFound
 		}
 		return nil, err
 	}
 
 	now := time.Now().UTC()
 	if !promotion.IsActive {
-		return nil, errors.New("promotion is inactive")
+		return nil, errPromotionInactive
 	}
 	if promotion.StartsAt != nil && now.Before(*promotion.StartsAt) {
-		return nil, errors.New("promotion is not active yet")
+		return nil, errPromotionNotStarted
 	}
 	if promotion.EndsAt != nil && now.After(*promotion.EndsAt) {
-		return nil, errors.New("promotion has expired")
+		return nil, errPromotionExpired
+	}
+
+	inSegment, err := promotionscope.MemberInSegment(ctx, s.bunDB.DB(), promotion.ID, memberID)
+	if err != nil {
+		return nil, err
+	}
+	if !inSegment {
+		return nil, promotionscope.ErrMemberNotInSegment
 	}
 
 	minimumOrderAmount := decimal.NewFromFloat(promotion.MinOrderAmount)
 	if orderAmount.LessThan(minimumOrderAmount) {
-		return nil, errors.New("order amount is below promotion minimum")
+		return nil, errPromotionMinimumNotMet
 	}
 
 	if promotion.UsageLimit != nil && promotion.UsedCount >= *promotion.UsageLimit {
-		return nil, errors.New("promotion usage limit reached")
+		return nil, errPromotionUsageLimit
 	}
 
 	if promotion.UsagePerMember != nil {
@@ -716,13 +852,72 @@ func (s *Service) calculatePromotionDiscount(ctx context.Context, memberID uuid.
 			return nil, err
 		}
 		if memberUsageCount >= *promotion.UsagePerMember {
-			return nil, errors.New("promotion usage per member limit reached")
+			return nil, errPromotionMemberUsageLimit
+		}
+	}
+
+	scope, err := promotionscope.LoadScope(ctx, s.bunDB.DB(), promotion.ID)
+	if err != nil {
+		return nil, err
+	}
+	discountBase := orderAmount
+	var eligible []bool
+	if len(lines) > 0 {
+		eligible, err = promotionscope.EligibleLines(ctx, s.bunDB.DB(), scope, lines)
+		if err != nil {
+			return nil, err
+		}
+		discountBase = promotionscope.EligibleAmount(lines, eligible)
+		if !discountBase.IsPositive() {
+			return nil, errPromotionNoEligibleItems
 		}
+	} else if scope.IsScoped() || promotionscope.IsLineRule(promotion.DiscountType) {
+		return nil, errPromotionRequiresItems
+	}
+
+	result := &promotionscope.Candidate{
+		PromotionID: promotion.ID,
+		CodeID:      resolved.CodeID,
+		Code:        normalizedCode,
+		Policy: promotionscope.Policy{
+			IsExclusive:         promotion.IsExclusive,
+			StackWithTier:       promotion.StackWithTier,
+			StackWithPromotions: promotion.StackWithPromotions,
+			Priority:            promotion.Priority,
+		},
+	}
+
+	if promotionscope.IsLineRule(promotion.DiscountType) {
+		bundleItems, err := promotionscope.LoadBundleItems(ctx, s.bunDB.DB(), []uuid.UUID{promotion.ID})
+		if err != nil {
+			return nil, err
+		}
+		rule := &promotionscope.Rule{
+			Type:               promotion.DiscountType,
+			BuyQuantity:        promotion.BuyQuantity,
+			GetQuantity:        promotion.GetQuantity,
+			GetDiscountPercent: decimal.NewFromFloat(promotion.GetDiscountPercent),
+			GetProductID:       promotion.GetProductID,
+			BundlePrice:        decimal.NewFromFloat(promotion.BundlePrice),
+			BundleItems:        bundleItems[promotion.ID],
+		}
+		if promotion.MaxDiscount != nil {
+			maxDiscount := decimal.NewFromFloat(*promotion.MaxDiscount)
+			rule.MaxDiscount = &maxDiscount
+		}
+		evaluated, err := promotionscope.EvaluateRule(rule, lines, eligible)
+		if err != nil {
+			return nil, err
+		}
+		result.DiscountAmount = evaluated.DiscountAmount.Round(2)
+		result.LineDiscounts = evaluated.LineDiscounts
+		result.Rewards = evaluated.Rewards
+		return result, nil
 	}
 
 	var discountAmount decimal.Decimal
 	if promotion.DiscountType == "percent" {
-		discountAmount = orderAmount.Mul(decimal.NewFromFloat(promotion.DiscountValue)).Div(decimal.NewFromInt(100))
+		discountAmount = discountBase.Mul(decimal.NewFromFloat(promotion.DiscountValue)).Div(decimal.NewFromInt(100))
 	} else {
 		discountAmount = decimal.NewFromFloat(promotion.DiscountValue)
 	}
@@ -734,20 +929,18 @@ func (s *Service) calculatePromotionDiscount(ctx context.Context, memberID uuid.
 		}
 	}
 
-	if discountAmount.GreaterThan(orderAmount) {
-		discountAmount = orderAmount
+	if discountAmount.GreaterThan(discountBase) {
+		discountAmount = discountBase
 	}
 	if discountAmount.IsNegative() {
 		discountAmount = decimal.Zero
 	}
 
-	return &promotionDiscountResult{
-		PromotionID:     promotion.ID,
-		DiscountAmount:  discountAmount.Round(2),
-		OriginalCode:    code,
-		NormalizedCode:  normalizedCode,
-		ValidatedAmount: orderAmount,
-	}, nil
+	result.DiscountAmount = discountAmount.Round(2)
+	if len(lines) > 0 {
+		result.LineDiscounts = promotionscope.AllocateDiscount(lines, eligible, result.DiscountAmount)
+	}
+	return result, nil
 }
 
 func (s *Service) calculateOrderAmountsByMemberTier(ctx context.Context, memberID uuid.UUID, totalAmount decimal.Decimal) (decimal.Decimal, decimal.Decimal, error) {
@@ -881,6 +1074,12 @@ func (s *Service) UpdateOrderService(ctx context.Context, orderID uuid.UUID, req
 		return err
 	}
 
+	paymentMethod, err := s.getPaymentMethod(ctx, s.bunDB.DB(), data.PaymentID)
+	if err != nil {
+		return err
+	}
+	isCOD := paymentMethod == ent.PaymentMethodCOD
+
 	if isRequestingRefund {
 		if !isAdmin {
 			if data.Status != ent.StatusTypePaid && data.Status != ent.StatusTypeShipping && data.Status != ent.StatusTypeCompleted {
@@ -914,7 +1113,7 @@ func (s *Service) UpdateOrderService(ctx context.Context, orderID uuid.UUID, req
 	if nextStatus == ent.StatusTypeCancelled && paymentReviewState.Submitted && data.Status != ent.StatusTypeRefundRequested {
 		return errors.New("cannot cancel order after payment submission")
 	}
-	if nextStatus == ent.StatusTypeRefundRequested && !paymentReviewState.Submitted {
+	if nextStatus == ent.StatusTypeRefundRequested && !paymentReviewState.Submitted && !isCOD {
 		return errors.New("refund request requires payment submission")
 	}
 	if nextStatus == ent.StatusTypeRefundRequested && paymentReviewState.Rejected {
@@ -932,9 +1131,18 @@ func (s *Service) UpdateOrderService(ctx context.Context, orderID uuid.UUID, req
 		}
 	}
 
-	if err := validateOrderStatusTransition(data.Status, nextStatus); err != nil {
+	if err := validateOrderStatusTransition(data.Status, nextStatus, paymentMethod); err != nil {
 		return err
 	}
+	if isCOD && data.Status != nextStatus && nextStatus == ent.StatusTypeCompleted {
+		paymentStatus, paymentErr := s.getOrderPaymentStatus(ctx, data.PaymentID)
+		if paymentErr != nil {
+			return paymentErr
+		}
+		if paymentStatus != ent.PaymentTypeSuccess {
+			return errors.New("cash on delivery payment not collected")
+		}
+	}
 
 	previousStatus := data.Status
 	statusChanged := previousStatus != nextStatus
@@ -970,6 +1178,10 @@ func (s *Service) UpdateOrderService(ctx context.Context, orderID uuid.UUID, req
 			return err
 		}
 
+		if err := s.recalculateOrderTaxInTx(ctx, tx, data); err != nil {
+			return err
+		}
+
 		if statusChanged {
 			if nextStatus == ent.StatusTypeRefundRequested {
 				if err := s.upsertOrderCancellationInTx(ctx, tx, data.ID, requesterID, isAdmin, refundReason); err != nil {
@@ -1120,8 +1332,10 @@ func (s *Service) ReorderService(ctx context.Context, orderID uuid.UUID, request
 		return nil, errors.New("reorder is allowed only for completed orders")
 	}
 
+	// Promotional reward lines are not reordered; they only come with the
+	// promotion that granted them.
 	orderItems := make([]*ent.OrderItemEntity, 0)
-	if err := s.bunDB.DB().NewSelect().Model(&orderItems).Where("order_id = ?", order.ID).Scan(ctx); err != nil {
+	if err := s.bunDB.DB().NewSelect().Model(&orderItems).Where("order_id = ?", order.ID).Where("promotion_id IS NULL").Scan(ctx); err != nil {
 		return nil, err
 	}
 	if len(orderItems) == 0 {
@@ -1284,12 +1498,15 @@ func parseOrderStatus(status string) (ent.StatusTypeEnum, error) {
 	}
 }
 
-func validateOrderStatusTransition(currentStatus ent.StatusTypeEnum, nextStatus ent.StatusTypeEnum) error {
+func validateOrderStatusTransition(currentStatus ent.StatusTypeEnum, nextStatus ent.StatusTypeEnum, paymentMethod ent.PaymentMethodEnum) error {
 	if currentStatus == nextStatus {
 		return nil
 	}
 
 	allowedStatuses := allowedNextStatuses(currentStatus)
+	if paymentMethod == ent.PaymentMethodCOD {
+		allowedStatuses = allowedNextCODStatuses(currentStatus)
+	}
 	for _, allowedStatus := range allowedStatuses {
 		if allowedStatus == nextStatus {
 			return nil
@@ -1316,6 +1533,21 @@ func allowedNextStatuses(status ent.StatusTypeEnum) []ent.StatusTypeEnum {
 	}
 }
 
+// allowedNextCODStatuses lets COD orders ship before payment. Paid is reached
+// only by recording collected cash, never through a plain status update.
+func allowedNextCODStatuses(status ent.StatusTypeEnum) []ent.StatusTypeEnum {
+	switch status {
+	case ent.StatusTypePending:
+		return []ent.StatusTypeEnum{ent.StatusTypeShipping, ent.StatusTypeCancelled}
+	case ent.StatusTypePaid:
+		return []ent.StatusTypeEnum{ent.StatusTypeShipping, ent.StatusTypeRefundRequested}
+	case ent.StatusTypeShipping:
+		return []ent.StatusTypeEnum{ent.StatusTypeCompleted}
+	default:
+		return allowedNextStatuses(status)
+	}
+}
+
 func parseOrderStatusTransitionDetail(detail string) (string, string) {
 	const prefix = "Order status changed from "
 	if !strings.HasPrefix(detail, prefix) {
@@ -1360,6 +1592,19 @@ func (s *Service) applyOrderStatusSideEffects(ctx context.Context, tx bun.Tx, or
 		if _, err := tx.NewUpdate().Model(payment).Where("id = ?", payment.ID).Exec(ctx); err != nil {
 			return err
 		}
+
+		if err := s.queueRefundPayoutInTx(ctx, tx, order, requesterID); err != nil {
+			return err
+		}
+	}
+
+	if previousStatus != ent.StatusTypeCancelled && order.Status == ent.StatusTypeCancelled {
+		if err := flashsale.ReleaseOrderInTx(ctx, tx, order.ID); err != nil {
+			return err
+		}
+		if err := promotionscope.ReleaseOrderCodeInTx(ctx, tx, order.ID); err != nil {
+			return err
+		}
 	}
 
 	if previousStatus != ent.StatusTypeShipping && order.Status == ent.StatusTypeShipping {
@@ -1547,42 +1792,16 @@ func (s *Service) createMemberPaymentFromOrder(ctx context.Context, tx bun.Tx, o
 	return nil
 }
 
+// decreaseStockFromOrderItems picks the order's items from the stock
+// locations chosen for it and records where each came from.
 func (s *Service) decreaseStockFromOrderItems(ctx context.Context, tx bun.Tx, orderID uuid.UUID) error {
-	items, err := s.listOrderItemsByOrderID(ctx, tx, orderID)
-	if err != nil {
-		return err
-	}
-
-	for _, item := range items {
-		stock := new(ent.ProductStockEntity)
-		if err := tx.NewSelect().
-			Model(stock).
-			Where("product_id = ?", item.ProductID).
-			Where("deleted_at IS NULL").
-			Limit(1).
-			For("UPDATE").
-			Scan(ctx); err != nil {
-			return err
-		}
-
-		if stock.Remaining < item.Quantity {
-			return fmt.Errorf("insufficient stock for product %s", item.ProductID.String())
-		}
-
-		stock.Remaining -= item.Quantity
-		stock.UpdatedAt = time.Now()
-
-		if _, err := tx.NewUpdate().Model(stock).Where("id = ?", stock.ID).Exec(ctx); err != nil {
-			return err
-		}
-	}
-
-	return nil
+	_, err := inventory.FulfilOrderInTx(ctx, tx, orderID)
+	return err
 }
 
 func (s *Service) listOrderItemsByOrderID(ctx context.Context, db bun.IDB, orderID uuid.UUID) ([]*ent.OrderItemEntity, error) {
 	items := make([]*ent.OrderItemEntity, 0)
-	if err := db.NewSelect().Model(&items).Where("order_id = ?", orderID).Scan(ctx); err != nil {
+	if err := db.NewSelect().Model(&items).Where("order_id = ?", orderID).OrderExpr("created_at ASC, id ASC").Scan(ctx); err != nil {
 		return nil, err
 	}
 	return items, nil
@@ -1601,6 +1820,14 @@ func (s *Service) ConfirmOrderPaymentService(ctx context.Context, orderID uuid.U
 		return nil, errors.New("order is not pending")
 	}
 
+	paymentMethod, err := s.getPaymentMethod(ctx, s.bunDB.DB(), order.PaymentID)
+	if err != nil {
+		return nil, err
+	}
+	if paymentMethod == ent.PaymentMethodCOD {
+		return nil, errors.New("cash on delivery order does not require payment confirmation")
+	}
+
 	paymentReviewState, err := s.getOrderPaymentReviewState(ctx, order.ID)
 	if err != nil {
 		return nil, err
@@ -1627,6 +1854,20 @@ func (s *Service) ConfirmOrderPaymentService(ctx context.Context, orderID uuid.U
 	}
 
 	slipFilePath := ""
+	slipBytes := decodeSlipForHashing(trimmedSlipBase64)
+	slipUnverified := false
+	if slipBytes == nil && trimmedSlipBase64 == "" && trimmedSlipPath != "" {
+		loaded, err := s.loadSlipForHashing(ctx, trimmedSlipPath)
+		switch {
+		case errors.Is(err, errSlipCannotBeVerified):
+			// External URLs are still accepted, but held for an admin to
+			// check by hand before the payment can be approved.
+			slipUnverified = true
+		case err != nil:
+			return nil, err
+		}
+		slipBytes = loaded
+	}
 	slipFileName := strings.TrimSpace(req.SlipFileName)
 	slipFileType := strings.TrimSpace(req.SlipFileType)
 	slipFileSize := req.SlipFileSize
@@ -1760,6 +2001,14 @@ func (s *Service) ConfirmOrderPaymentService(ctx context.Context, orderID uuid.U
 				return err
 			}
 
+			if slipUnverified {
+				if err := s.recordUnverifiedPaymentSlipInTx(ctx, tx, order, storageID, uploadedBy); err != nil {
+					return err
+				}
+			} else if err := s.recordPaymentSlipHashInTx(ctx, tx, order, storageID, slipBytes, uploadedBy); err != nil {
+				return err
+			}
+
 			paymentFilesTableExists, err := relationExistsInTx(ctx, tx, "public.payment_files")
 			if err != nil {
 				return err
@@ -1922,7 +2171,7 @@ func orderStorageFileSourceFromPath(path string) string {
 	return "STORAGE"
 }
 
-func (s *Service) ApproveOrderPaymentService(ctx context.Context, orderID uuid.UUID, approverID uuid.UUID) (*OrderPaymentServiceResponse, error) {
+func (s *Service) ApproveOrderPaymentService(ctx context.Context, orderID uuid.UUID, approverID uuid.UUID, acknowledgeDuplicateSlip bool) (*OrderPaymentServiceResponse, error) {
 	span, _ := utils.LogSpanFromContext(ctx)
 	span.AddEvent(`orders.svc.payment.approve.start`)
 
@@ -1947,6 +2196,10 @@ func (s *Service) ApproveOrderPaymentService(ctx context.Context, orderID uuid.U
 		return nil, errors.New("payment confirmation not submitted")
 	}
 
+	if err := s.ensurePaymentSlipApprovable(ctx, order.ID, acknowledgeDuplicateSlip); err != nil {
+		return nil, err
+	}
+
 	isAppealApproval, err := s.isOrderPaymentAppealPendingReview(ctx, order.ID)
 	if err != nil {
 		return nil, err
@@ -1992,6 +2245,13 @@ func (s *Service) ApproveOrderPaymentService(ctx context.Context, orderID uuid.U
 			if err := s.applyOrderStatusSideEffects(ctx, tx, order, previousStatus, approverID); err != nil {
 				return err
 			}
+		} else {
+			if err := flashsale.ReleaseOrderInTx(ctx, tx, order.ID); err != nil {
+				return err
+			}
+			if err := promotionscope.ReleaseOrderCodeInTx(ctx, tx, order.ID); err != nil {
+				return err
+			}
 		}
 
 		if _, err := tx.NewUpdate().Model(order).Where("id = ?", order.ID).Exec(ctx); err != nil {
@@ -2500,6 +2760,35 @@ func (s *Service) getOrderPaymentAppealReason(ctx context.Context, orderID uuid.
 	return parsePaymentAppealReason(latestLog.ActionDetail), nil
 }
 
+// parsePromotionExpiryReminder reads "code=...; ends_at=...; promotion=...".
+// The name comes last because it may contain the separator.
+func parsePromotionExpiryReminder(detail string) (string, string, time.Time) {
+	parts := strings.SplitN(detail, "; ", 3)
+	values := make(map[string]string, len(parts))
+	for _, part := range parts {
+		key, value, ok := strings.Cut(part, "=")
+		if ok {
+			values[key] = strings.TrimSpace(value)
+		}
+	}
+	endsAt, _ := time.Parse(time.RFC3339, values["ends_at"])
+	return values["promotion"], values["code"], endsAt
+}
+
+// parseWishlistAlert reads the "key=value; ..." detail written by the
+// wishlist alert job.
+func parseWishlistAlert(detail string) map[string]string {
+	parts := strings.Split(detail, "; ")
+	values := make(map[string]string, len(parts))
+	for _, part := range parts {
+		key, value, ok := strings.Cut(part, "=")
+		if ok {
+			values[key] = strings.TrimSpace(value)
+		}
+	}
+	return values
+}
+
 func parseShippingTrackingNumber(detail string) string {
 	const prefix = "Shipping tracking number: "
 	if strings.HasPrefix(detail, prefix) {
@@ -2537,6 +2826,18 @@ func mapNotificationTitleMessage(actionType string, actionDetail string, orderNo
 			reason = "แอดมินปฏิเสธคำขอคืนเงิน"
 		}
 		return "ไม่อนุมัติการคืนเงิน", orderRef + " ถูกปฏิเสธการคืนเงิน: " + reason
+	case "order_cod_collected":
+		return "ชำระเงินปลายทางแล้ว", orderRef + " ได้รับชำระเงินปลายทางเรียบร้อยแล้ว"
+	case "order_cod_refused":
+		reason := parseCODRefusedReason(actionDetail)
+		if reason == "" {
+			return "ปฏิเสธรับพัสดุ", orderRef + " ถูกยกเลิกเนื่องจากปฏิเสธรับพัสดุเก็บเงินปลายทาง"
+		}
+		return "ปฏิเสธรับพัสดุ", orderRef + " ถูกยกเลิกเนื่องจากปฏิเสธรับพัสดุเก็บเงินปลายทาง: " + reason
+	case "order_refund_paid":
+		return "โอนเงินคืนแล้ว", orderRef + " โอนเงินคืนเข้าบัญชีธนาคารของคุณเรียบร้อยแล้ว"
+	case "order_refund_payout_failed":
+		return "โอนเงินคืนไม่สำเร็จ", orderRef + " โอนเงินคืนไม่สำเร็จ กรุณาตรวจสอบบัญชีธนาคารที่ตั้งเป็นค่าเริ่มต้น"
 	case "order_shipping_tracking_updated":
 		trackingNo := parseShippingTrackingNumber(actionDetail)
 		if trackingNo == "" {
@@ -2564,6 +2865,19 @@ func mapNotificationTitleMessage(actionType string, actionDetail string, orderNo
 			return "สถานะคำสั่งซื้ออัปเดต", orderRef + " เปลี่ยนสถานะจาก " + fromStatus + " เป็น " + toStatus
 		}
 		return "สถานะคำสั่งซื้ออัปเดต", orderRef + " มีการอัปเดตสถานะ"
+	case "promotion_expiry_reminder":
+		name, code, endsAt := parsePromotionExpiryReminder(actionDetail)
+		message := "โปรโมชั่น " + name + " (" + code + ") ที่คุณเก็บไว้ใกล้หมดอายุแล้ว"
+		if !endsAt.IsZero() {
+			message += " ใช้ได้ถึง " + thaidate.GetThaiDateFromTime(endsAt.In(time.Local))
+		}
+		return "โปรโมชั่นใกล้หมดอายุ", message
+	case members.WishlistAlertBackInStock:
+		values := parseWishlistAlert(actionDetail)
+		return "สินค้ากลับมามีสต็อกแล้ว", "สินค้า " + values["product"] + " ในรายการโปรดของคุณกลับมามีสต็อกแล้ว"
+	case members.WishlistAlertPriceDrop:
+		values := parseWishlistAlert(actionDetail)
+		return "สินค้าลดราคา", "สินค้า " + values["product"] + " ในรายการโปรดของคุณลดราคาจาก " + values["saved_price"] + " เหลือ " + values["price"] + " บาท"
 	default:
 		return "อัปเดตคำสั่งซื้อ", orderRef + " มีการอัปเดตใหม่"
 	}
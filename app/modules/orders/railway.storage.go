@@ -114,6 +114,28 @@ func (c *railwayStorageClient) UploadPaymentSlip(ctx context.Context, orderID uu
 	}, nil
 }
 
+// DownloadPaymentSlip reads back a slip stored in the private bucket so it
+// can be hashed. Paths outside the private bucket are not read.
+func (c *railwayStorageClient) DownloadPaymentSlip(ctx context.Context, storedPath string) ([]byte, error) {
+	if !c.enabledForPrivate() {
+		return nil, errors.New("railway storage is not configured")
+	}
+
+	parts := strings.SplitN(strings.Trim(strings.TrimSpace(storedPath), "/"), "/", 2)
+	if len(parts) != 2 || parts[0] != c.privateBucket || strings.TrimSpace(parts[1]) == "" {
+		return nil, errSlipCannotBeVerified
+	}
+
+	data, err := c.s3.GetObject(ctx, parts[0], parts[1], maxSlipFileSizeBytes)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, errors.New("slip image is empty")
+	}
+	return data, nil
+}
+
 func decodeBase64Image(input string) ([]byte, string, error) {
 	raw := strings.TrimSpace(input)
 	if raw == "" {
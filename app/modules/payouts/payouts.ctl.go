@@ -0,0 +1,365 @@
+package payouts
+
+import (
+	"fmt"
+	"net/http"
+	"phakram/app/modules/auth"
+	"phakram/app/utils"
+	"phakram/app/utils/base"
+	"phakram/config/i18n"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type PayoutURIRequest struct {
+	ID string `uri:"id"`
+}
+
+type ListRefundPayoutsControllerRequest struct {
+	base.RequestPaginate
+	Status  string `form:"status"`
+	BatchID string `form:"batch_id"`
+}
+
+type ListPayoutBatchesControllerRequest struct {
+	base.RequestPaginate
+	Status string `form:"status"`
+}
+
+type CreatePayoutBatchControllerRequest struct {
+	FileFormat string      `json:"file_format"`
+	PayoutIDs  []uuid.UUID `json:"payout_ids"`
+}
+
+type ConfirmPayoutBatchControllerRequest struct {
+	TransferReference string                          `json:"transfer_reference"`
+	TransferredAt     *time.Time                      `json:"transferred_at"`
+	FailedPayouts     []FailedPayoutControllerRequest `json:"failed_payouts"`
+}
+
+type FailedPayoutControllerRequest struct {
+	PayoutID      uuid.UUID `json:"payout_id"`
+	FailureReason string    `json:"failure_reason"`
+}
+
+type ConfirmRefundPayoutControllerRequest struct {
+	Success           bool       `json:"success"`
+	TransferReference string     `json:"transfer_reference"`
+	TransferredAt     *time.Time `json:"transferred_at"`
+	FailureReason     string     `json:"failure_reason"`
+}
+
+func (c *Controller) ListRefundPayoutsController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`payouts.ctl.list.start`)
+
+	if _, ok := requireAdmin(ctx); !ok {
+		return
+	}
+
+	var req ListRefundPayoutsControllerRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	svcReq := &ListRefundPayoutsServiceRequest{
+		RequestPaginate: req.RequestPaginate,
+		Status:          req.Status,
+	}
+	if req.BatchID != "" {
+		batchID, err := uuid.Parse(req.BatchID)
+		if err != nil {
+			base.BadRequest(ctx, i18n.BadRequest, nil)
+			return
+		}
+		svcReq.BatchID = &batchID
+	}
+
+	data, page, err := c.svc.ListRefundPayoutsService(ctx.Request.Context(), svcReq)
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`payouts.ctl.list.success`)
+	base.Paginate(ctx, data, page)
+}
+
+func (c *Controller) InfoOrderRefundPayoutController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`payouts.ctl.order_info.start`)
+
+	orderID, ok := parsePayoutURI(ctx)
+	if !ok {
+		return
+	}
+
+	requesterID, hasRequester := auth.GetMemberID(ctx)
+	isAdmin := auth.GetIsAdmin(ctx)
+	if !isAdmin && !hasRequester {
+		base.Forbidden(ctx, i18n.Forbidden, nil)
+		return
+	}
+
+	data, err := c.svc.InfoOrderRefundPayoutService(ctx.Request.Context(), orderID, requesterID, isAdmin)
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`payouts.ctl.order_info.success`)
+	base.Success(ctx, data)
+}
+
+func (c *Controller) ConfirmRefundPayoutController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`payouts.ctl.confirm.start`)
+
+	requesterID, ok := requireAdmin(ctx)
+	if !ok {
+		return
+	}
+
+	payoutID, ok := parsePayoutURI(ctx)
+	if !ok {
+		return
+	}
+
+	var req ConfirmRefundPayoutControllerRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	data, err := c.svc.ConfirmRefundPayoutService(ctx.Request.Context(), payoutID, &ConfirmRefundPayoutServiceRequest{
+		Success:           req.Success,
+		TransferReference: req.TransferReference,
+		TransferredAt:     req.TransferredAt,
+		FailureReason:     req.FailureReason,
+	}, requesterID)
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`payouts.ctl.confirm.success`)
+	base.Success(ctx, data)
+}
+
+func (c *Controller) ListPayoutBatchesController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`payouts.ctl.batches.list.start`)
+
+	if _, ok := requireAdmin(ctx); !ok {
+		return
+	}
+
+	var req ListPayoutBatchesControllerRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	data, page, err := c.svc.ListPayoutBatchesService(ctx.Request.Context(), &ListPayoutBatchesServiceRequest{
+		RequestPaginate: req.RequestPaginate,
+		Status:          req.Status,
+	})
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`payouts.ctl.batches.list.success`)
+	base.Paginate(ctx, data, page)
+}
+
+func (c *Controller) InfoPayoutBatchController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`payouts.ctl.batches.info.start`)
+
+	if _, ok := requireAdmin(ctx); !ok {
+		return
+	}
+
+	batchID, ok := parsePayoutURI(ctx)
+	if !ok {
+		return
+	}
+
+	data, err := c.svc.InfoPayoutBatchService(ctx.Request.Context(), batchID)
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`payouts.ctl.batches.info.success`)
+	base.Success(ctx, data)
+}
+
+func (c *Controller) CreatePayoutBatchController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`payouts.ctl.batches.create.start`)
+
+	requesterID, ok := requireAdmin(ctx)
+	if !ok {
+		return
+	}
+
+	var req CreatePayoutBatchControllerRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	data, err := c.svc.CreatePayoutBatchService(ctx.Request.Context(), &CreatePayoutBatchServiceRequest{
+		FileFormat: req.FileFormat,
+		PayoutIDs:  req.PayoutIDs,
+	}, requesterID)
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`payouts.ctl.batches.create.success`)
+	base.Success(ctx, data)
+}
+
+func (c *Controller) ApprovePayoutBatchController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`payouts.ctl.batches.approve.start`)
+
+	requesterID, ok := requireAdmin(ctx)
+	if !ok {
+		return
+	}
+
+	batchID, ok := parsePayoutURI(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.svc.ApprovePayoutBatchService(ctx.Request.Context(), batchID, requesterID); err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`payouts.ctl.batches.approve.success`)
+	base.Success(ctx, nil)
+}
+
+func (c *Controller) CancelPayoutBatchController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`payouts.ctl.batches.cancel.start`)
+
+	requesterID, ok := requireAdmin(ctx)
+	if !ok {
+		return
+	}
+
+	batchID, ok := parsePayoutURI(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.svc.CancelPayoutBatchService(ctx.Request.Context(), batchID, requesterID); err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`payouts.ctl.batches.cancel.success`)
+	base.Success(ctx, nil)
+}
+
+func (c *Controller) ExportPayoutBatchController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`payouts.ctl.batches.export.start`)
+
+	requesterID, ok := requireAdmin(ctx)
+	if !ok {
+		return
+	}
+
+	batchID, ok := parsePayoutURI(ctx)
+	if !ok {
+		return
+	}
+
+	file, err := c.svc.ExportPayoutBatchService(ctx.Request.Context(), batchID, requesterID)
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`payouts.ctl.batches.export.success`)
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, file.FileName))
+	ctx.Data(http.StatusOK, "text/csv; charset=utf-8", file.Content)
+}
+
+func (c *Controller) ConfirmPayoutBatchController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`payouts.ctl.batches.confirm.start`)
+
+	requesterID, ok := requireAdmin(ctx)
+	if !ok {
+		return
+	}
+
+	batchID, ok := parsePayoutURI(ctx)
+	if !ok {
+		return
+	}
+
+	var req ConfirmPayoutBatchControllerRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	failed := make([]FailedPayoutServiceRequest, 0, len(req.FailedPayouts))
+	for _, item := range req.FailedPayouts {
+		failed = append(failed, FailedPayoutServiceRequest{
+			PayoutID:      item.PayoutID,
+			FailureReason: item.FailureReason,
+		})
+	}
+
+	data, err := c.svc.ConfirmPayoutBatchService(ctx.Request.Context(), batchID, &ConfirmPayoutBatchServiceRequest{
+		TransferReference: req.TransferReference,
+		TransferredAt:     req.TransferredAt,
+		FailedPayouts:     failed,
+	}, requesterID)
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`payouts.ctl.batches.confirm.success`)
+	base.Success(ctx, data)
+}
+
+func requireAdmin(ctx *gin.Context) (uuid.UUID, bool) {
+	requesterID, hasRequester := auth.GetMemberID(ctx)
+	if !auth.GetIsAdmin(ctx) || !hasRequester {
+		base.Forbidden(ctx, i18n.Forbidden, nil)
+		return uuid.Nil, false
+	}
+	return requesterID, true
+}
+
+func parsePayoutURI(ctx *gin.Context) (uuid.UUID, bool) {
+	var uri PayoutURIRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return uuid.Nil, false
+	}
+
+	id, err := uuid.Parse(uri.ID)
+	if err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return uuid.Nil, false
+	}
+	return id, true
+}
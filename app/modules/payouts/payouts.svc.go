@@ -0,0 +1,746 @@
+package payouts
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"phakram/app/modules/entities/ent"
+	"phakram/app/utils"
+	"phakram/app/utils/base"
+	"phakram/app/utils/sequence"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/uptrace/bun"
+)
+
+type ListRefundPayoutsServiceRequest struct {
+	base.RequestPaginate
+	Status  string
+	BatchID *uuid.UUID
+}
+
+type ListPayoutBatchesServiceRequest struct {
+	base.RequestPaginate
+	Status string
+}
+
+type CreatePayoutBatchServiceRequest struct {
+	FileFormat string
+	PayoutIDs  []uuid.UUID
+}
+
+type ConfirmPayoutBatchServiceRequest struct {
+	TransferReference string
+	TransferredAt     *time.Time
+	FailedPayouts     []FailedPayoutServiceRequest
+}
+
+type FailedPayoutServiceRequest struct {
+	PayoutID      uuid.UUID
+	FailureReason string
+}
+
+type ConfirmRefundPayoutServiceRequest struct {
+	Success           bool
+	TransferReference string
+	TransferredAt     *time.Time
+	FailureReason     string
+}
+
+type PayoutBatchDetail struct {
+	*ent.PayoutBatchEntity
+	Items []*ent.RefundPayoutEntity `json:"items"`
+}
+
+type PayoutBatchFile struct {
+	FileName string
+	Content  []byte
+}
+
+func (s *Service) ListRefundPayoutsService(ctx context.Context, req *ListRefundPayoutsServiceRequest) ([]*ent.RefundPayoutEntity, *base.ResponsePaginate, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`payouts.svc.list.start`)
+
+	data := make([]*ent.RefundPayoutEntity, 0)
+	_, page, err := base.NewInstant(s.bunDB.DB()).GetList(
+		ctx,
+		&data,
+		&req.RequestPaginate,
+		[]string{"account_name", "account_no", "bank_code", "status"},
+		[]string{"created_at", "updated_at", "amount", "status"},
+		func(selQ *bun.SelectQuery) *bun.SelectQuery {
+			if status := strings.TrimSpace(req.Status); status != "" {
+				selQ.Where("status = ?", status)
+			}
+			if req.BatchID != nil {
+				selQ.Where("batch_id = ?", *req.BatchID)
+			}
+			return selQ
+		},
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	span.AddEvent(`payouts.svc.list.success`)
+	return data, page, nil
+}
+
+// InfoOrderRefundPayoutService lets a member follow the transfer of their own
+// refund; admins may look up any order.
+func (s *Service) InfoOrderRefundPayoutService(ctx context.Context, orderID uuid.UUID, requesterID uuid.UUID, isAdmin bool) (*ent.RefundPayoutEntity, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`payouts.svc.order_info.start`)
+
+	payout := new(ent.RefundPayoutEntity)
+	query := s.bunDB.DB().NewSelect().
+		Model(payout).
+		Where("order_id = ?", orderID)
+	if !isAdmin {
+		query.Where("member_id = ?", requesterID)
+	}
+	if err := query.Limit(1).Scan(ctx); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("refund payout not found")
+		}
+		return nil, err
+	}
+
+	span.AddEvent(`payouts.svc.order_info.success`)
+	return payout, nil
+}
+
+func (s *Service) ListPayoutBatchesService(ctx context.Context, req *ListPayoutBatchesServiceRequest) ([]*ent.PayoutBatchEntity, *base.ResponsePaginate, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`payouts.svc.batches.list.start`)
+
+	data := make([]*ent.PayoutBatchEntity, 0)
+	_, page, err := base.NewInstant(s.bunDB.DB()).GetList(
+		ctx,
+		&data,
+		&req.RequestPaginate,
+		[]string{"batch_no", "status", "file_format"},
+		[]string{"created_at", "updated_at", "total_amount", "status"},
+		func(selQ *bun.SelectQuery) *bun.SelectQuery {
+			if status := strings.TrimSpace(req.Status); status != "" {
+				selQ.Where("status = ?", status)
+			}
+			return selQ
+		},
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	span.AddEvent(`payouts.svc.batches.list.success`)
+	return data, page, nil
+}
+
+func (s *Service) InfoPayoutBatchService(ctx context.Context, batchID uuid.UUID) (*PayoutBatchDetail, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`payouts.svc.batches.info.start`)
+
+	db := s.bunDB.DB()
+	batch, err := getPayoutBatch(ctx, db, batchID, false)
+	if err != nil {
+		return nil, err
+	}
+	items, err := listBatchPayouts(ctx, db, batchID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	span.AddEvent(`payouts.svc.batches.info.success`)
+	return &PayoutBatchDetail{PayoutBatchEntity: batch, Items: items}, nil
+}
+
+// CreatePayoutBatchService collects pending (or previously failed) refunds
+// into a draft batch. Payouts still missing an account are refreshed from the
+// member's current default bank first and skipped if none is registered.
+func (s *Service) CreatePayoutBatchService(ctx context.Context, req *CreatePayoutBatchServiceRequest, createdBy uuid.UUID) (*PayoutBatchDetail, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`payouts.svc.batches.create.start`)
+
+	fileFormat, err := parsePayoutFileFormat(req.FileFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	var detail *PayoutBatchDetail
+	err = s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if err := refreshMissingPayoutAccountsInTx(ctx, tx, req.PayoutIDs); err != nil {
+			return err
+		}
+
+		payouts := make([]*ent.RefundPayoutEntity, 0)
+		query := tx.NewSelect().
+			Model(&payouts).
+			Where("status IN (?)", bun.In([]ent.RefundPayoutStatusEnum{ent.RefundPayoutStatusPending, ent.RefundPayoutStatusFailed})).
+			Where("account_no <> ''").
+			Where("bank_code <> ''").
+			OrderExpr("created_at ASC, id ASC").
+			For("UPDATE")
+		if len(req.PayoutIDs) > 0 {
+			query.Where("id IN (?)", bun.In(req.PayoutIDs))
+		}
+		if err := query.Scan(ctx); err != nil {
+			return err
+		}
+		if len(payouts) == 0 {
+			return errors.New("no payouts ready for batch")
+		}
+
+		now := time.Now()
+		total := decimal.Zero
+		ids := make([]uuid.UUID, 0, len(payouts))
+		for _, payout := range payouts {
+			total = total.Add(payout.Amount)
+			ids = append(ids, payout.ID)
+		}
+
+		batchNo, err := nextPayoutBatchNoInTx(ctx, tx, now)
+		if err != nil {
+			return err
+		}
+
+		batch := &ent.PayoutBatchEntity{
+			ID:          uuid.New(),
+			BatchNo:     batchNo,
+			FileFormat:  fileFormat,
+			Status:      ent.PayoutBatchStatusDraft,
+			ItemCount:   len(payouts),
+			TotalAmount: total,
+			CreatedBy:   &createdBy,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+		if _, err := tx.NewInsert().Model(batch).Exec(ctx); err != nil {
+			return err
+		}
+
+		if _, err := tx.NewUpdate().
+			Model((*ent.RefundPayoutEntity)(nil)).
+			Set("status = ?", ent.RefundPayoutStatusBatched).
+			Set("batch_id = ?", batch.ID).
+			Set("failure_reason = ''").
+			Set("updated_at = ?", now).
+			Where("id IN (?)", bun.In(ids)).
+			Exec(ctx); err != nil {
+			return err
+		}
+
+		if err := insertPayoutAuditLog(ctx, tx, ent.AuditActionCreated, "payout_batch_created", batch.ID, createdBy,
+			fmt.Sprintf("Payout batch %s created: %d items, %s", batch.BatchNo, batch.ItemCount, total.StringFixed(2)), now); err != nil {
+			return err
+		}
+
+		items, err := listBatchPayouts(ctx, tx, batch.ID, false)
+		if err != nil {
+			return err
+		}
+		detail = &PayoutBatchDetail{PayoutBatchEntity: batch, Items: items}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	span.AddEvent(`payouts.svc.batches.create.success`)
+	return detail, nil
+}
+
+func (s *Service) ApprovePayoutBatchService(ctx context.Context, batchID uuid.UUID, approvedBy uuid.UUID) error {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`payouts.svc.batches.approve.start`)
+
+	err := s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		batch, err := getPayoutBatch(ctx, tx, batchID, true)
+		if err != nil {
+			return err
+		}
+		if batch.Status != ent.PayoutBatchStatusDraft {
+			return errors.New("payout batch is not draft")
+		}
+
+		now := time.Now()
+		if _, err := tx.NewUpdate().
+			Model((*ent.PayoutBatchEntity)(nil)).
+			Set("status = ?", ent.PayoutBatchStatusApproved).
+			Set("approved_by = ?", approvedBy).
+			Set("approved_at = ?", now).
+			Set("updated_at = ?", now).
+			Where("id = ?", batch.ID).
+			Exec(ctx); err != nil {
+			return err
+		}
+
+		return insertPayoutAuditLog(ctx, tx, ent.AuditActionUpdated, "payout_batch_approved", batch.ID, approvedBy,
+			fmt.Sprintf("Payout batch %s approved", batch.BatchNo), now)
+	})
+	if err != nil {
+		return err
+	}
+
+	span.AddEvent(`payouts.svc.batches.approve.success`)
+	return nil
+}
+
+// CancelPayoutBatchService releases the batch's items back to pending so they
+// can be picked up by a new batch. Exported files may already be with the
+// bank, so only batches that were never exported can be cancelled.
+func (s *Service) CancelPayoutBatchService(ctx context.Context, batchID uuid.UUID, cancelledBy uuid.UUID) error {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`payouts.svc.batches.cancel.start`)
+
+	err := s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		batch, err := getPayoutBatch(ctx, tx, batchID, true)
+		if err != nil {
+			return err
+		}
+		if batch.Status != ent.PayoutBatchStatusDraft && batch.Status != ent.PayoutBatchStatusApproved {
+			return errors.New("payout batch cannot be cancelled")
+		}
+
+		now := time.Now()
+		if _, err := tx.NewUpdate().
+			Model((*ent.RefundPayoutEntity)(nil)).
+			Set("status = ?", ent.RefundPayoutStatusPending).
+			Set("batch_id = NULL").
+			Set("updated_at = ?", now).
+			Where("batch_id = ?", batch.ID).
+			Where("status = ?", ent.RefundPayoutStatusBatched).
+			Exec(ctx); err != nil {
+			return err
+		}
+
+		if _, err := tx.NewUpdate().
+			Model((*ent.PayoutBatchEntity)(nil)).
+			Set("status = ?", ent.PayoutBatchStatusCancelled).
+			Set("updated_at = ?", now).
+			Where("id = ?", batch.ID).
+			Exec(ctx); err != nil {
+			return err
+		}
+
+		return insertPayoutAuditLog(ctx, tx, ent.AuditActionUpdated, "payout_batch_cancelled", batch.ID, cancelledBy,
+			fmt.Sprintf("Payout batch %s cancelled", batch.BatchNo), now)
+	})
+	if err != nil {
+		return err
+	}
+
+	span.AddEvent(`payouts.svc.batches.cancel.success`)
+	return nil
+}
+
+// ExportPayoutBatchService renders the bank upload file. Re-exporting an
+// already exported batch returns the same rows so a lost file can be fetched
+// again.
+func (s *Service) ExportPayoutBatchService(ctx context.Context, batchID uuid.UUID, exportedBy uuid.UUID) (*PayoutBatchFile, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`payouts.svc.batches.export.start`)
+
+	var file *PayoutBatchFile
+	err := s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		batch, err := getPayoutBatch(ctx, tx, batchID, true)
+		if err != nil {
+			return err
+		}
+		if batch.Status != ent.PayoutBatchStatusApproved && batch.Status != ent.PayoutBatchStatusExported {
+			return errors.New("payout batch is not approved")
+		}
+
+		items, err := listBatchPayouts(ctx, tx, batch.ID, true)
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return errors.New("no payouts ready for batch")
+		}
+
+		orderNos, err := getOrderNos(ctx, tx, items)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		fileName, content, err := buildPayoutFile(batch, items, orderNos, now)
+		if err != nil {
+			return err
+		}
+
+		if batch.Status == ent.PayoutBatchStatusApproved {
+			if _, err := tx.NewUpdate().
+				Model((*ent.PayoutBatchEntity)(nil)).
+				Set("status = ?", ent.PayoutBatchStatusExported).
+				Set("exported_at = ?", now).
+				Set("updated_at = ?", now).
+				Where("id = ?", batch.ID).
+				Exec(ctx); err != nil {
+				return err
+			}
+		}
+
+		if err := insertPayoutAuditLog(ctx, tx, ent.AuditActionUpdated, "payout_batch_exported", batch.ID, exportedBy,
+			fmt.Sprintf("Payout batch %s exported as %s", batch.BatchNo, fileName), now); err != nil {
+			return err
+		}
+
+		file = &PayoutBatchFile{FileName: fileName, Content: content}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	span.AddEvent(`payouts.svc.batches.export.success`)
+	return file, nil
+}
+
+// ConfirmPayoutBatchService records the bank's result for a whole batch.
+// Every batched item is marked paid except the ones listed as failed.
+func (s *Service) ConfirmPayoutBatchService(ctx context.Context, batchID uuid.UUID, req *ConfirmPayoutBatchServiceRequest, confirmedBy uuid.UUID) (*PayoutBatchDetail, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`payouts.svc.batches.confirm.start`)
+
+	failed := make(map[uuid.UUID]string, len(req.FailedPayouts))
+	for _, item := range req.FailedPayouts {
+		reason := strings.TrimSpace(item.FailureReason)
+		if reason == "" {
+			return nil, errors.New("payout failure reason is required")
+		}
+		failed[item.PayoutID] = reason
+	}
+
+	var detail *PayoutBatchDetail
+	err := s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		batch, err := getPayoutBatch(ctx, tx, batchID, true)
+		if err != nil {
+			return err
+		}
+		if batch.Status != ent.PayoutBatchStatusExported {
+			return errors.New("payout batch is not exported")
+		}
+
+		items, err := listBatchPayouts(ctx, tx, batch.ID, true)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			if item.Status != ent.RefundPayoutStatusBatched {
+				continue
+			}
+			reason, isFailed := failed[item.ID]
+			if err := confirmRefundPayoutInTx(ctx, tx, item, &ConfirmRefundPayoutServiceRequest{
+				Success:           !isFailed,
+				TransferReference: req.TransferReference,
+				TransferredAt:     req.TransferredAt,
+				FailureReason:     reason,
+			}, confirmedBy); err != nil {
+				return err
+			}
+		}
+
+		if err := completePayoutBatchIfSettledInTx(ctx, tx, batch, req.TransferReference, confirmedBy); err != nil {
+			return err
+		}
+
+		batch, err = getPayoutBatch(ctx, tx, batchID, false)
+		if err != nil {
+			return err
+		}
+		items, err = listBatchPayouts(ctx, tx, batch.ID, false)
+		if err != nil {
+			return err
+		}
+		detail = &PayoutBatchDetail{PayoutBatchEntity: batch, Items: items}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	span.AddEvent(`payouts.svc.batches.confirm.success`)
+	return detail, nil
+}
+
+// ConfirmRefundPayoutService records the result of a single transfer, either
+// one line of an exported batch or a refund paid manually outside a batch.
+func (s *Service) ConfirmRefundPayoutService(ctx context.Context, payoutID uuid.UUID, req *ConfirmRefundPayoutServiceRequest, confirmedBy uuid.UUID) (*ent.RefundPayoutEntity, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`payouts.svc.confirm.start`)
+
+	if !req.Success && strings.TrimSpace(req.FailureReason) == "" {
+		return nil, errors.New("payout failure reason is required")
+	}
+
+	payout := new(ent.RefundPayoutEntity)
+	err := s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if err := tx.NewSelect().
+			Model(payout).
+			Where("id = ?", payoutID).
+			For("UPDATE").
+			Scan(ctx); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return errors.New("refund payout not found")
+			}
+			return err
+		}
+		if payout.Status == ent.RefundPayoutStatusPaid {
+			return errors.New("refund payout is already paid")
+		}
+
+		var batch *ent.PayoutBatchEntity
+		if payout.BatchID != nil && payout.Status == ent.RefundPayoutStatusBatched {
+			found, err := getPayoutBatch(ctx, tx, *payout.BatchID, true)
+			if err != nil {
+				return err
+			}
+			if found.Status != ent.PayoutBatchStatusExported {
+				return errors.New("payout batch is not exported")
+			}
+			batch = found
+		}
+
+		if err := confirmRefundPayoutInTx(ctx, tx, payout, req, confirmedBy); err != nil {
+			return err
+		}
+
+		if batch != nil {
+			if err := completePayoutBatchIfSettledInTx(ctx, tx, batch, "", confirmedBy); err != nil {
+				return err
+			}
+		}
+
+		return tx.NewSelect().Model(payout).WherePK().Scan(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	span.AddEvent(`payouts.svc.confirm.success`)
+	return payout, nil
+}
+
+func confirmRefundPayoutInTx(ctx context.Context, tx bun.Tx, payout *ent.RefundPayoutEntity, req *ConfirmRefundPayoutServiceRequest, confirmedBy uuid.UUID) error {
+	now := time.Now()
+	transferredAt := now
+	if req.TransferredAt != nil && !req.TransferredAt.IsZero() {
+		transferredAt = *req.TransferredAt
+	}
+
+	update := tx.NewUpdate().
+		Model((*ent.RefundPayoutEntity)(nil)).
+		Set("confirmed_by = ?", confirmedBy).
+		Set("updated_at = ?", now).
+		Where("id = ?", payout.ID)
+
+	var (
+		actionType string
+		detail     string
+	)
+	if req.Success {
+		update.
+			Set("status = ?", ent.RefundPayoutStatusPaid).
+			Set("transfer_reference = ?", strings.TrimSpace(req.TransferReference)).
+			Set("transferred_at = ?", transferredAt).
+			Set("failure_reason = ''")
+		actionType = "order_refund_paid"
+		detail = fmt.Sprintf("Refund transferred: %s", payout.Amount.StringFixed(2))
+		if ref := strings.TrimSpace(req.TransferReference); ref != "" {
+			detail += " (ref " + ref + ")"
+		}
+	} else {
+		reason := strings.TrimSpace(req.FailureReason)
+		update.
+			Set("status = ?", ent.RefundPayoutStatusFailed).
+			Set("failure_reason = ?", reason)
+		actionType = "order_refund_payout_failed"
+		detail = fmt.Sprintf("Refund transfer failed: %s", reason)
+	}
+
+	if _, err := update.Exec(ctx); err != nil {
+		return err
+	}
+
+	return insertPayoutAuditLog(ctx, tx, ent.AuditActionUpdated, actionType, payout.OrderID, confirmedBy, detail, now)
+}
+
+// completePayoutBatchIfSettledInTx closes the batch once none of its items
+// are still waiting on the bank.
+func completePayoutBatchIfSettledInTx(ctx context.Context, tx bun.Tx, batch *ent.PayoutBatchEntity, transferReference string, confirmedBy uuid.UUID) error {
+	remaining, err := tx.NewSelect().
+		Model((*ent.RefundPayoutEntity)(nil)).
+		Where("batch_id = ?", batch.ID).
+		Where("status = ?", ent.RefundPayoutStatusBatched).
+		Count(ctx)
+	if err != nil {
+		return err
+	}
+	if remaining > 0 {
+		return nil
+	}
+
+	now := time.Now()
+	update := tx.NewUpdate().
+		Model((*ent.PayoutBatchEntity)(nil)).
+		Set("status = ?", ent.PayoutBatchStatusCompleted).
+		Set("completed_at = ?", now).
+		Set("updated_at = ?", now).
+		Where("id = ?", batch.ID)
+	if ref := strings.TrimSpace(transferReference); ref != "" {
+		update.Set("transfer_reference = ?", ref)
+	}
+	if _, err := update.Exec(ctx); err != nil {
+		return err
+	}
+
+	return insertPayoutAuditLog(ctx, tx, ent.AuditActionUpdated, "payout_batch_completed", batch.ID, confirmedBy,
+		fmt.Sprintf("Payout batch %s completed", batch.BatchNo), now)
+}
+
+type payoutAccountRow struct {
+	PayoutID     uuid.UUID `bun:"payout_id"`
+	MemberBankID uuid.UUID `bun:"member_bank_id"`
+	BankCode     string    `bun:"bank_code"`
+	BankName     string    `bun:"bank_name"`
+	AccountNo    string    `bun:"account_no"`
+	AccountName  string    `bun:"account_name"`
+}
+
+// refreshMissingPayoutAccountsInTx fills in the bank snapshot for payouts
+// queued before the member set a default account, and re-reads it for failed
+// payouts in case the member has since corrected their details. Only the
+// default account is used; a member's other accounts are never guessed at.
+func refreshMissingPayoutAccountsInTx(ctx context.Context, tx bun.Tx, payoutIDs []uuid.UUID) error {
+	rows := make([]*payoutAccountRow, 0)
+	query := tx.NewSelect().
+		TableExpr("refund_payouts AS rp").
+		ColumnExpr("rp.id AS payout_id").
+		ColumnExpr("mb.id AS member_bank_id").
+		ColumnExpr("COALESCE(b.code, '') AS bank_code").
+		ColumnExpr("COALESCE(b.name_th, '') AS bank_name").
+		ColumnExpr("mb.bank_no AS account_no").
+		ColumnExpr("COALESCE(NULLIF(TRIM(CONCAT(mb.firstname_th, ' ', mb.lastname_th)), ''), TRIM(CONCAT(mb.firstname_en, ' ', mb.lastname_en))) AS account_name").
+		Join(`JOIN LATERAL (
+			SELECT * FROM member_banks
+			WHERE member_banks.member_id = rp.member_id AND member_banks.is_default
+			ORDER BY member_banks.updated_at DESC
+			LIMIT 1
+		) AS mb ON TRUE`).
+		Join("LEFT JOIN banks AS b ON b.id = mb.bank_id").
+		Where("((rp.status = ? AND (rp.account_no = '' OR rp.bank_code = '')) OR rp.status = ?)", ent.RefundPayoutStatusPending, ent.RefundPayoutStatusFailed)
+	if len(payoutIDs) > 0 {
+		query.Where("rp.id IN (?)", bun.In(payoutIDs))
+	}
+	if err := query.Scan(ctx, &rows); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, row := range rows {
+		if _, err := tx.NewUpdate().
+			Model((*ent.RefundPayoutEntity)(nil)).
+			Set("member_bank_id = ?", row.MemberBankID).
+			Set("bank_code = ?", row.BankCode).
+			Set("bank_name = ?", row.BankName).
+			Set("account_no = ?", row.AccountNo).
+			Set("account_name = ?", row.AccountName).
+			Set("updated_at = ?", now).
+			Where("id = ?", row.PayoutID).
+			Exec(ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func getPayoutBatch(ctx context.Context, db bun.IDB, batchID uuid.UUID, forUpdate bool) (*ent.PayoutBatchEntity, error) {
+	batch := new(ent.PayoutBatchEntity)
+	query := db.NewSelect().
+		Model(batch).
+		Where("id = ?", batchID)
+	if forUpdate {
+		query.For("UPDATE")
+	}
+	if err := query.Scan(ctx); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("payout batch not found")
+		}
+		return nil, err
+	}
+	return batch, nil
+}
+
+func listBatchPayouts(ctx context.Context, db bun.IDB, batchID uuid.UUID, batchedOnly bool) ([]*ent.RefundPayoutEntity, error) {
+	items := make([]*ent.RefundPayoutEntity, 0)
+	query := db.NewSelect().
+		Model(&items).
+		Where("batch_id = ?", batchID).
+		OrderExpr("created_at ASC, id ASC")
+	if batchedOnly {
+		query.Where("status = ?", ent.RefundPayoutStatusBatched)
+	}
+	if err := query.Scan(ctx); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func getOrderNos(ctx context.Context, db bun.IDB, payouts []*ent.RefundPayoutEntity) (map[string]string, error) {
+	orderIDs := make([]uuid.UUID, 0, len(payouts))
+	for _, payout := range payouts {
+		orderIDs = append(orderIDs, payout.OrderID)
+	}
+
+	orders := make([]*ent.OrderEntity, 0)
+	if err := db.NewSelect().
+		Model(&orders).
+		Column("id", "order_no").
+		Where("id IN (?)", bun.In(orderIDs)).
+		Scan(ctx); err != nil {
+		return nil, err
+	}
+
+	orderNos := make(map[string]string, len(orders))
+	for _, order := range orders {
+		orderNos[order.ID.String()] = order.OrderNo
+	}
+	return orderNos, nil
+}
+
+func insertPayoutAuditLog(ctx context.Context, tx bun.Tx, action ent.AuditActionEnum, actionType string, actionID uuid.UUID, actionBy uuid.UUID, detail string, now time.Time) error {
+	auditLog := &ent.AuditLogEntity{
+		ID:           uuid.New(),
+		Action:       action,
+		ActionType:   actionType,
+		ActionID:     actionID,
+		ActionBy:     &actionBy,
+		Status:       ent.StatusAuditSuccesses,
+		ActionDetail: detail,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	_, err := tx.NewInsert().Model(auditLog).Exec(ctx)
+	return err
+}
+
+// payoutBatchSequenceType is the document_sequences counter batch numbers
+// are drawn from. Numbers run per day, matching the date in the batch no.
+const payoutBatchSequenceType = "payout_batch"
+
+func nextPayoutBatchNoInTx(ctx context.Context, tx bun.Tx, now time.Time) (string, error) {
+	period := now.Format("20060102")
+	lastNo, err := sequence.NextInTx(ctx, tx, payoutBatchSequenceType, period, now)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("PB-%s-%04d", period, lastNo), nil
+}
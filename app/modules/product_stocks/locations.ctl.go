@@ -0,0 +1,383 @@
+package productstocks
+
+import (
+	"phakram/app/utils"
+	"phakram/app/utils/base"
+	"phakram/config/i18n"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type CreateStockLocationControllerRequest struct {
+	Code       string     `json:"code"`
+	NameTh     string     `json:"name_th"`
+	NameEn     string     `json:"name_en"`
+	ProvinceID *uuid.UUID `json:"province_id"`
+	Priority   int        `json:"priority"`
+	IsDefault  bool       `json:"is_default"`
+	IsActive   *bool      `json:"is_active"`
+}
+
+type UpdateStockLocationControllerRequest struct {
+	NameTh *string `json:"name_th"`
+	NameEn *string `json:"name_en"`
+	// ProvinceID sets the province; an empty string clears it.
+	ProvinceID *string `json:"province_id"`
+	Priority   *int    `json:"priority"`
+	IsDefault  *bool   `json:"is_default"`
+	IsActive   *bool   `json:"is_active"`
+}
+
+type AdjustProductStockControllerRequest struct {
+	LocationID uuid.UUID `json:"location_id"`
+	Kind       string    `json:"kind"`
+	Quantity   int       `json:"quantity"`
+	Note       *string   `json:"note"`
+}
+
+type TransferStockControllerRequest struct {
+	ProductID      uuid.UUID `json:"product_id"`
+	FromLocationID uuid.UUID `json:"from_location_id"`
+	ToLocationID   uuid.UUID `json:"to_location_id"`
+	Quantity       int       `json:"quantity"`
+	Note           *string   `json:"note"`
+}
+
+type ListStockMovementsControllerRequest struct {
+	base.RequestPaginate
+	ProductID  string `form:"product_id"`
+	LocationID string `form:"location_id"`
+	OrderID    string `form:"order_id"`
+	Kind       string `form:"kind"`
+}
+
+// parseURIID reads the :id route parameter.
+func parseURIID(ctx *gin.Context) (uuid.UUID, bool) {
+	var uri ProductStockRequestUri
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return uuid.Nil, false
+	}
+	id, err := uuid.Parse(uri.ID)
+	if err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// parseOptionalUUID parses a query filter, leaving it unset when blank.
+func parseOptionalUUID(value string) (*uuid.UUID, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(strings.TrimSpace(value))
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+func (c *Controller) ListStockLocationsController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`product_stocks.ctl.locations.list.start`)
+
+	if _, ok := requireAdmin(ctx); !ok {
+		return
+	}
+
+	data, err := c.svc.ListStockLocationsService(ctx.Request.Context())
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`product_stocks.ctl.locations.list.success`)
+	base.Success(ctx, data)
+}
+
+func (c *Controller) CreateStockLocationController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`product_stocks.ctl.locations.create.start`)
+
+	actionBy, ok := requireAdmin(ctx)
+	if !ok {
+		return
+	}
+
+	var req CreateStockLocationControllerRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	data, err := c.svc.CreateStockLocationService(ctx.Request.Context(), &CreateStockLocationServiceRequest{
+		Code:       req.Code,
+		NameTh:     req.NameTh,
+		NameEn:     req.NameEn,
+		ProvinceID: req.ProvinceID,
+		Priority:   req.Priority,
+		IsDefault:  req.IsDefault,
+		IsActive:   isActive,
+		ActionBy:   actionBy,
+	})
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`product_stocks.ctl.locations.create.success`)
+	base.Success(ctx, data)
+}
+
+func (c *Controller) UpdateStockLocationController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`product_stocks.ctl.locations.update.start`)
+
+	actionBy, ok := requireAdmin(ctx)
+	if !ok {
+		return
+	}
+	locationID, ok := parseURIID(ctx)
+	if !ok {
+		return
+	}
+
+	var req UpdateStockLocationControllerRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	svcReq := &UpdateStockLocationServiceRequest{
+		NameTh:    req.NameTh,
+		NameEn:    req.NameEn,
+		Priority:  req.Priority,
+		IsDefault: req.IsDefault,
+		IsActive:  req.IsActive,
+		ActionBy:  actionBy,
+	}
+	if req.ProvinceID != nil {
+		provinceID, err := parseOptionalUUID(*req.ProvinceID)
+		if err != nil {
+			base.BadRequest(ctx, i18n.BadRequest, nil)
+			return
+		}
+		svcReq.ProvinceID = provinceID
+		svcReq.ClearProvince = provinceID == nil
+	}
+
+	data, err := c.svc.UpdateStockLocationService(ctx.Request.Context(), locationID, svcReq)
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`product_stocks.ctl.locations.update.success`)
+	base.Success(ctx, data)
+}
+
+func (c *Controller) DeleteStockLocationController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`product_stocks.ctl.locations.delete.start`)
+
+	actionBy, ok := requireAdmin(ctx)
+	if !ok {
+		return
+	}
+	locationID, ok := parseURIID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.svc.DeleteStockLocationService(ctx.Request.Context(), locationID, actionBy); err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`product_stocks.ctl.locations.delete.success`)
+	base.Success(ctx, nil)
+}
+
+func (c *Controller) ProductStockLocationsController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`product_stocks.ctl.locations.product.start`)
+
+	if _, ok := requireAdmin(ctx); !ok {
+		return
+	}
+	productID, ok := parseURIID(ctx)
+	if !ok {
+		return
+	}
+
+	data, err := c.svc.ProductStockLocationsService(ctx.Request.Context(), productID)
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`product_stocks.ctl.locations.product.success`)
+	base.Success(ctx, data)
+}
+
+func (c *Controller) AdjustProductStockController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`product_stocks.ctl.adjust.start`)
+
+	actionBy, ok := requireAdmin(ctx)
+	if !ok {
+		return
+	}
+	productID, ok := parseURIID(ctx)
+	if !ok {
+		return
+	}
+
+	var req AdjustProductStockControllerRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	data, err := c.svc.AdjustProductStockService(ctx.Request.Context(), productID, &AdjustProductStockServiceRequest{
+		LocationID: req.LocationID,
+		Kind:       req.Kind,
+		Quantity:   req.Quantity,
+		Note:       req.Note,
+		ActionBy:   actionBy,
+	})
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`product_stocks.ctl.adjust.success`)
+	base.Success(ctx, data)
+}
+
+func (c *Controller) TransferStockController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`product_stocks.ctl.transfer.start`)
+
+	actionBy, ok := requireAdmin(ctx)
+	if !ok {
+		return
+	}
+
+	var req TransferStockControllerRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	data, err := c.svc.TransferStockService(ctx.Request.Context(), &TransferStockServiceRequest{
+		ProductID:      req.ProductID,
+		FromLocationID: req.FromLocationID,
+		ToLocationID:   req.ToLocationID,
+		Quantity:       req.Quantity,
+		Note:           req.Note,
+		ActionBy:       actionBy,
+	})
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`product_stocks.ctl.transfer.success`)
+	base.Success(ctx, data)
+}
+
+func (c *Controller) ListStockMovementsController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`product_stocks.ctl.movements.list.start`)
+
+	if _, ok := requireAdmin(ctx); !ok {
+		return
+	}
+
+	var req ListStockMovementsControllerRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+	productID, err := parseOptionalUUID(req.ProductID)
+	if err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+	locationID, err := parseOptionalUUID(req.LocationID)
+	if err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+	orderID, err := parseOptionalUUID(req.OrderID)
+	if err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	data, page, err := c.svc.ListStockMovementsService(ctx.Request.Context(), &ListStockMovementsServiceRequest{
+		RequestPaginate: req.RequestPaginate,
+		ProductID:       productID,
+		LocationID:      locationID,
+		OrderID:         orderID,
+		Kind:            req.Kind,
+	})
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`product_stocks.ctl.movements.list.success`)
+	base.Paginate(ctx, data, page)
+}
+
+func (c *Controller) FulfilmentPlanController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`product_stocks.ctl.fulfilment_plan.start`)
+
+	if _, ok := requireAdmin(ctx); !ok {
+		return
+	}
+	orderID, ok := parseURIID(ctx)
+	if !ok {
+		return
+	}
+
+	data, err := c.svc.FulfilmentPlanService(ctx.Request.Context(), orderID)
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`product_stocks.ctl.fulfilment_plan.success`)
+	base.Success(ctx, data)
+}
+
+// ProductAvailabilityController is public and only reports the total across
+// locations.
+func (c *Controller) ProductAvailabilityController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`product_stocks.ctl.availability.start`)
+
+	productID, ok := parseURIID(ctx)
+	if !ok {
+		return
+	}
+
+	data, err := c.svc.ProductAvailabilityService(ctx.Request.Context(), productID)
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`product_stocks.ctl.availability.success`)
+	base.Success(ctx, data)
+}
@@ -0,0 +1,583 @@
+package productstocks
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"phakram/app/modules/entities/ent"
+	"phakram/app/utils"
+	"phakram/app/utils/base"
+	"phakram/app/utils/inventory"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+var locationCodePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+type CreateStockLocationServiceRequest struct {
+	Code       string
+	NameTh     string
+	NameEn     string
+	ProvinceID *uuid.UUID
+	Priority   int
+	IsDefault  bool
+	IsActive   bool
+	ActionBy   *uuid.UUID
+}
+
+type UpdateStockLocationServiceRequest struct {
+	NameTh     *string
+	NameEn     *string
+	ProvinceID *uuid.UUID
+	// ClearProvince removes the province; it wins over ProvinceID.
+	ClearProvince bool
+	Priority      *int
+	IsDefault     *bool
+	IsActive      *bool
+	ActionBy      *uuid.UUID
+}
+
+type AdjustProductStockServiceRequest struct {
+	LocationID uuid.UUID
+	// Kind is receive for incoming goods or adjust for a count correction.
+	Kind string
+	// Quantity is positive to add stock; only adjust may take it away.
+	Quantity int
+	Note     *string
+	ActionBy *uuid.UUID
+}
+
+type TransferStockServiceRequest struct {
+	ProductID      uuid.UUID
+	FromLocationID uuid.UUID
+	ToLocationID   uuid.UUID
+	Quantity       int
+	Note           *string
+	ActionBy       *uuid.UUID
+}
+
+type ListStockMovementsServiceRequest struct {
+	base.RequestPaginate
+	ProductID  *uuid.UUID
+	LocationID *uuid.UUID
+	OrderID    *uuid.UUID
+	Kind       string
+}
+
+type locationQuantity struct {
+	LocationID uuid.UUID `bun:"location_id"`
+	Quantity   int       `bun:"quantity"`
+}
+
+type StockLocationItem struct {
+	*ent.StockLocationEntity
+	TotalQuantity int `json:"total_quantity"`
+}
+
+type ProductLocationStock struct {
+	LocationID uuid.UUID `bun:"location_id" json:"location_id"`
+	Code       string    `bun:"code" json:"code"`
+	NameTh     string    `bun:"name_th" json:"name_th"`
+	NameEn     string    `bun:"name_en" json:"name_en"`
+	IsActive   bool      `bun:"is_active" json:"is_active"`
+	Quantity   int       `bun:"quantity" json:"quantity"`
+}
+
+type ProductStockLocations struct {
+	ProductID uuid.UUID               `json:"product_id"`
+	Remaining int                     `json:"remaining"`
+	Locations []*ProductLocationStock `json:"locations"`
+}
+
+// ProductAvailability is the stock customers see: the total across
+// locations, without saying where it is held.
+type ProductAvailability struct {
+	ProductID uuid.UUID `json:"product_id"`
+	Remaining int       `json:"remaining"`
+	InStock   bool      `json:"in_stock"`
+}
+
+func (s *Service) ListStockLocationsService(ctx context.Context) ([]*StockLocationItem, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`product_stocks.svc.locations.list.start`)
+
+	locations := make([]*ent.StockLocationEntity, 0)
+	if err := s.bunDB.DB().NewSelect().
+		Model(&locations).
+		OrderExpr("priority ASC, code ASC").
+		Scan(ctx); err != nil {
+		return nil, err
+	}
+
+	totals := make([]*locationQuantity, 0)
+	if err := s.bunDB.DB().NewSelect().
+		TableExpr("location_stocks").
+		ColumnExpr("location_id, COALESCE(SUM(quantity), 0) AS quantity").
+		GroupExpr("location_id").
+		Scan(ctx, &totals); err != nil {
+		return nil, err
+	}
+	byLocation := make(map[uuid.UUID]int, len(totals))
+	for _, total := range totals {
+		byLocation[total.LocationID] = total.Quantity
+	}
+
+	items := make([]*StockLocationItem, 0, len(locations))
+	for _, location := range locations {
+		items = append(items, &StockLocationItem{StockLocationEntity: location, TotalQuantity: byLocation[location.ID]})
+	}
+
+	span.AddEvent(`product_stocks.svc.locations.list.success`)
+	return items, nil
+}
+
+// CreateStockLocationService adds a location. Making it the default takes
+// the flag from the current default.
+func (s *Service) CreateStockLocationService(ctx context.Context, req *CreateStockLocationServiceRequest) (*ent.StockLocationEntity, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`product_stocks.svc.locations.create.start`)
+
+	code := strings.ToLower(strings.TrimSpace(req.Code))
+	if !locationCodePattern.MatchString(code) {
+		return nil, errors.New("invalid stock location code")
+	}
+	nameTh, nameEn := strings.TrimSpace(req.NameTh), strings.TrimSpace(req.NameEn)
+	if nameTh == "" || nameEn == "" {
+		return nil, errors.New("stock location name is required")
+	}
+	if req.Priority < 0 {
+		return nil, errors.New("invalid stock location priority")
+	}
+	if req.IsDefault && !req.IsActive {
+		return nil, errors.New("default stock location must be active")
+	}
+
+	now := time.Now()
+	data := &ent.StockLocationEntity{
+		ID:         uuid.New(),
+		Code:       code,
+		NameTh:     nameTh,
+		NameEn:     nameEn,
+		ProvinceID: req.ProvinceID,
+		Priority:   req.Priority,
+		IsDefault:  req.IsDefault,
+		IsActive:   req.IsActive,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	err := s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if err := ensureProvinceExists(ctx, tx, data.ProvinceID); err != nil {
+			return err
+		}
+		exists, err := tx.NewSelect().Model((*ent.StockLocationEntity)(nil)).Where("code = ?", code).Exists(ctx)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return errors.New("stock location code already exists")
+		}
+		if data.IsDefault {
+			if err := clearDefaultLocation(ctx, tx, now); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.NewInsert().Model(data).Exec(ctx); err != nil {
+			return err
+		}
+		return insertStockLocationAuditLog(ctx, tx, ent.AuditActionCreated, "create_stock_location", data, req.ActionBy, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	span.AddEvent(`product_stocks.svc.locations.create.success`)
+	return data, nil
+}
+
+// UpdateStockLocationService changes a location. There is always exactly one
+// default location, so the default can only move to another location and
+// cannot be deactivated.
+func (s *Service) UpdateStockLocationService(ctx context.Context, locationID uuid.UUID, req *UpdateStockLocationServiceRequest) (*ent.StockLocationEntity, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`product_stocks.svc.locations.update.start`)
+
+	now := time.Now()
+	data := new(ent.StockLocationEntity)
+	err := s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if err := tx.NewSelect().Model(data).Where("id = ?", locationID).For("UPDATE").Scan(ctx); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return inventory.ErrLocationNotFound
+			}
+			return err
+		}
+
+		if req.NameTh != nil {
+			if data.NameTh = strings.TrimSpace(*req.NameTh); data.NameTh == "" {
+				return errors.New("stock location name is required")
+			}
+		}
+		if req.NameEn != nil {
+			if data.NameEn = strings.TrimSpace(*req.NameEn); data.NameEn == "" {
+				return errors.New("stock location name is required")
+			}
+		}
+		if req.ClearProvince {
+			data.ProvinceID = nil
+		} else if req.ProvinceID != nil {
+			if err := ensureProvinceExists(ctx, tx, req.ProvinceID); err != nil {
+				return err
+			}
+			data.ProvinceID = req.ProvinceID
+		}
+		if req.Priority != nil {
+			if *req.Priority < 0 {
+				return errors.New("invalid stock location priority")
+			}
+			data.Priority = *req.Priority
+		}
+		if req.IsActive != nil {
+			data.IsActive = *req.IsActive
+		}
+		if req.IsDefault != nil {
+			if !*req.IsDefault && data.IsDefault {
+				return errors.New("default stock location is required")
+			}
+			if *req.IsDefault && !data.IsDefault {
+				if err := clearDefaultLocation(ctx, tx, now); err != nil {
+					return err
+				}
+				data.IsDefault = true
+			}
+		}
+		if data.IsDefault && !data.IsActive {
+			return errors.New("default stock location must be active")
+		}
+
+		data.UpdatedAt = now
+		if _, err := tx.NewUpdate().Model(data).WherePK().Exec(ctx); err != nil {
+			return err
+		}
+		return insertStockLocationAuditLog(ctx, tx, ent.AuditActionUpdated, "update_stock_location", data, req.ActionBy, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	span.AddEvent(`product_stocks.svc.locations.update.success`)
+	return data, nil
+}
+
+// DeleteStockLocationService removes an empty location other than the
+// default; stock has to be transferred out first.
+func (s *Service) DeleteStockLocationService(ctx context.Context, locationID uuid.UUID, actionBy *uuid.UUID) error {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`product_stocks.svc.locations.delete.start`)
+
+	now := time.Now()
+	err := s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		data := new(ent.StockLocationEntity)
+		if err := tx.NewSelect().Model(data).Where("id = ?", locationID).For("UPDATE").Scan(ctx); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return inventory.ErrLocationNotFound
+			}
+			return err
+		}
+		if data.IsDefault {
+			return errors.New("default stock location is required")
+		}
+
+		held, err := tx.NewSelect().
+			Model((*ent.LocationStockEntity)(nil)).
+			Where("location_id = ?", locationID).
+			Where("quantity > 0").
+			Exists(ctx)
+		if err != nil {
+			return err
+		}
+		if held {
+			return errors.New("stock location has stock")
+		}
+
+		if _, err := tx.NewDelete().Model(data).WherePK().Exec(ctx); err != nil {
+			return err
+		}
+		return insertStockLocationAuditLog(ctx, tx, ent.AuditActionDeleted, "delete_stock_location", data, actionBy, now)
+	})
+	if err != nil {
+		return err
+	}
+
+	span.AddEvent(`product_stocks.svc.locations.delete.success`)
+	return nil
+}
+
+// ProductStockLocationsService lists a product's quantity at every location,
+// including empty ones.
+func (s *Service) ProductStockLocationsService(ctx context.Context, productID uuid.UUID) (*ProductStockLocations, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`product_stocks.svc.locations.product.start`)
+
+	data, err := productStockLocations(ctx, s.bunDB.DB(), productID)
+	if err != nil {
+		return nil, err
+	}
+
+	span.AddEvent(`product_stocks.svc.locations.product.success`)
+	return data, nil
+}
+
+// AdjustProductStockService books goods received at a location, or corrects
+// its count after a stock take.
+func (s *Service) AdjustProductStockService(ctx context.Context, productID uuid.UUID, req *AdjustProductStockServiceRequest) (*ProductStockLocations, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`product_stocks.svc.adjust.start`)
+
+	kind := ent.StockMovementKindEnum(strings.TrimSpace(req.Kind))
+	if kind != ent.StockMovementReceive && kind != ent.StockMovementAdjust {
+		return nil, inventory.ErrInvalidMovement
+	}
+	if req.Quantity == 0 || (kind == ent.StockMovementReceive && req.Quantity < 0) {
+		return nil, inventory.ErrInvalidMovement
+	}
+
+	movement := &inventory.Movement{
+		ProductID: productID,
+		Quantity:  req.Quantity,
+		Kind:      kind,
+		Note:      trimOptional(req.Note),
+		CreatedBy: req.ActionBy,
+	}
+	if req.Quantity > 0 {
+		movement.ToLocationID = &req.LocationID
+	} else {
+		movement.FromLocationID = &req.LocationID
+		movement.Quantity = -req.Quantity
+	}
+
+	var data *ProductStockLocations
+	err := s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if err := ensureProductExists(ctx, tx, productID); err != nil {
+			return err
+		}
+		exists, err := tx.NewSelect().Model((*ent.StockLocationEntity)(nil)).Where("id = ?", req.LocationID).Exists(ctx)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return inventory.ErrLocationNotFound
+		}
+		if err := inventory.MoveInTx(ctx, tx, movement); err != nil {
+			return err
+		}
+		data, err = productStockLocations(ctx, tx, productID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	span.AddEvent(`product_stocks.svc.adjust.success`)
+	return data, nil
+}
+
+// TransferStockService moves stock of a product between two locations.
+func (s *Service) TransferStockService(ctx context.Context, req *TransferStockServiceRequest) (*ProductStockLocations, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`product_stocks.svc.transfer.start`)
+
+	if req.Quantity <= 0 || req.FromLocationID == req.ToLocationID {
+		return nil, inventory.ErrInvalidMovement
+	}
+
+	var data *ProductStockLocations
+	err := s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if err := ensureProductExists(ctx, tx, req.ProductID); err != nil {
+			return err
+		}
+		if err := inventory.TransferInTx(ctx, tx, req.ProductID, req.FromLocationID, req.ToLocationID, req.Quantity, trimOptional(req.Note), req.ActionBy); err != nil {
+			return err
+		}
+		var err error
+		data, err = productStockLocations(ctx, tx, req.ProductID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	span.AddEvent(`product_stocks.svc.transfer.success`)
+	return data, nil
+}
+
+// ListStockMovementsService pages through the movement ledger, newest first
+// unless another order is asked for.
+func (s *Service) ListStockMovementsService(ctx context.Context, req *ListStockMovementsServiceRequest) ([]*ent.StockMovementEntity, *base.ResponsePaginate, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`product_stocks.svc.movements.list.start`)
+
+	data := make([]*ent.StockMovementEntity, 0)
+	_, page, err := base.NewInstant(s.bunDB.DB()).GetList(
+		ctx,
+		&data,
+		&req.RequestPaginate,
+		nil,
+		[]string{"created_at", "quantity", "kind"},
+		func(selQ *bun.SelectQuery) *bun.SelectQuery {
+			if req.ProductID != nil {
+				selQ.Where("product_id = ?", *req.ProductID)
+			}
+			if req.LocationID != nil {
+				selQ.WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+					return q.Where("from_location_id = ?", *req.LocationID).WhereOr("to_location_id = ?", *req.LocationID)
+				})
+			}
+			if req.OrderID != nil {
+				selQ.Where("order_id = ?", *req.OrderID)
+			}
+			if kind := strings.TrimSpace(req.Kind); kind != "" {
+				selQ.Where("kind = ?", kind)
+			}
+			if req.SortBy == "" {
+				selQ.OrderExpr("created_at DESC, id DESC")
+			}
+			return selQ
+		},
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	span.AddEvent(`product_stocks.svc.movements.list.success`)
+	return data, page, nil
+}
+
+// FulfilmentPlanService shows where an order would be picked from if it
+// shipped now.
+func (s *Service) FulfilmentPlanService(ctx context.Context, orderID uuid.UUID) (*inventory.Plan, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`product_stocks.svc.fulfilment_plan.start`)
+
+	exists, err := s.bunDB.DB().NewSelect().Model((*ent.OrderEntity)(nil)).Where("id = ?", orderID).Exists(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.New("order not found")
+	}
+
+	plan, err := inventory.PlanOrder(ctx, s.bunDB.DB(), orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	span.AddEvent(`product_stocks.svc.fulfilment_plan.success`)
+	return plan, nil
+}
+
+func (s *Service) ProductAvailabilityService(ctx context.Context, productID uuid.UUID) (*ProductAvailability, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`product_stocks.svc.availability.start`)
+
+	if err := ensureProductExists(ctx, s.bunDB.DB(), productID); err != nil {
+		return nil, err
+	}
+	var remaining int
+	if err := s.bunDB.DB().NewSelect().
+		TableExpr("product_stocks").
+		ColumnExpr("COALESCE(SUM(remaining), 0)").
+		Where("product_id = ?", productID).
+		Where("deleted_at IS NULL").
+		Scan(ctx, &remaining); err != nil {
+		return nil, err
+	}
+
+	span.AddEvent(`product_stocks.svc.availability.success`)
+	return &ProductAvailability{ProductID: productID, Remaining: remaining, InStock: remaining > 0}, nil
+}
+
+func productStockLocations(ctx context.Context, db bun.IDB, productID uuid.UUID) (*ProductStockLocations, error) {
+	locations := make([]*ProductLocationStock, 0)
+	if err := db.NewSelect().
+		TableExpr("stock_locations AS l").
+		ColumnExpr("l.id AS location_id, l.code, l.name_th, l.name_en, l.is_active").
+		ColumnExpr("COALESCE(ls.quantity, 0) AS quantity").
+		Join("LEFT JOIN location_stocks AS ls ON ls.location_id = l.id AND ls.product_id = ?", productID).
+		Where("l.deleted_at IS NULL").
+		OrderExpr("l.priority ASC, l.code ASC").
+		Scan(ctx, &locations); err != nil {
+		return nil, err
+	}
+
+	data := &ProductStockLocations{ProductID: productID, Locations: locations}
+	for _, location := range locations {
+		data.Remaining += location.Quantity
+	}
+	return data, nil
+}
+
+func ensureProductExists(ctx context.Context, db bun.IDB, productID uuid.UUID) error {
+	exists, err := db.NewSelect().Model((*ent.ProductEntity)(nil)).Where("id = ?", productID).Exists(ctx)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.New("product not found")
+	}
+	return nil
+}
+
+func ensureProvinceExists(ctx context.Context, db bun.IDB, provinceID *uuid.UUID) error {
+	if provinceID == nil {
+		return nil
+	}
+	exists, err := db.NewSelect().Model((*ent.ProvinceEntity)(nil)).Where("id = ?", *provinceID).Exists(ctx)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.New("province not found")
+	}
+	return nil
+}
+
+func clearDefaultLocation(ctx context.Context, tx bun.Tx, now time.Time) error {
+	_, err := tx.NewUpdate().
+		Model((*ent.StockLocationEntity)(nil)).
+		Set("is_default = false").
+		Set("updated_at = ?", now).
+		Where("is_default").
+		Exec(ctx)
+	return err
+}
+
+func insertStockLocationAuditLog(ctx context.Context, tx bun.Tx, action ent.AuditActionEnum, actionType string, location *ent.StockLocationEntity, actionBy *uuid.UUID, now time.Time) error {
+	auditLog := &ent.AuditLogEntity{
+		ID:           uuid.New(),
+		Action:       action,
+		ActionType:   actionType,
+		ActionID:     location.ID,
+		ActionBy:     actionBy,
+		Status:       ent.StatusAuditSuccesses,
+		ActionDetail: fmt.Sprintf("code=%s; priority=%d; default=%t; active=%t", location.Code, location.Priority, location.IsDefault, location.IsActive),
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	_, err := tx.NewInsert().Model(auditLog).Exec(ctx)
+	return err
+}
+
+func trimOptional(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*value)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
@@ -0,0 +1,100 @@
+package productstocks
+
+import (
+	"phakram/app/modules/auth"
+	"phakram/app/utils"
+	"phakram/app/utils/base"
+	"phakram/config/i18n"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type UpdateStockThresholdsControllerRequest struct {
+	ReorderPoint *int `json:"reorder_point"`
+	TargetStock  *int `json:"target_stock"`
+}
+
+type LowStockReportControllerRequest struct {
+	WindowDays int  `form:"window_days"`
+	All        bool `form:"all"`
+}
+
+// requireAdmin returns the requesting admin, or answers 403.
+func requireAdmin(ctx *gin.Context) (*uuid.UUID, bool) {
+	memberID, ok := auth.GetMemberID(ctx)
+	if !ok || !auth.GetIsAdmin(ctx) {
+		base.Forbidden(ctx, i18n.Forbidden, nil)
+		return nil, false
+	}
+	return &memberID, true
+}
+
+// UpdateStockThresholdsController replaces the reorder point and target
+// stock of a product; leaving one out clears it.
+func (c *Controller) UpdateStockThresholdsController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`product_stocks.ctl.thresholds.update.start`)
+
+	actionBy, ok := requireAdmin(ctx)
+	if !ok {
+		return
+	}
+
+	var uri ProductStockRequestUri
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+	productID, err := uuid.Parse(uri.ID)
+	if err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	var req UpdateStockThresholdsControllerRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	data, err := c.svc.UpdateStockThresholdsService(ctx.Request.Context(), productID, &UpdateStockThresholdsServiceRequest{
+		ReorderPoint: req.ReorderPoint,
+		TargetStock:  req.TargetStock,
+		ActionBy:     actionBy,
+	})
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`product_stocks.ctl.thresholds.update.success`)
+	base.Success(ctx, data)
+}
+
+func (c *Controller) LowStockReportController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`product_stocks.ctl.low_stock.report.start`)
+
+	if _, ok := requireAdmin(ctx); !ok {
+		return
+	}
+
+	var req LowStockReportControllerRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	data, err := c.svc.LowStockReportService(ctx.Request.Context(), &LowStockReportServiceRequest{
+		WindowDays: req.WindowDays,
+		All:        req.All,
+	})
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`product_stocks.ctl.low_stock.report.success`)
+	base.Success(ctx, data)
+}
@@ -0,0 +1,403 @@
+package productstocks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"phakram/app/modules/entities/ent"
+	"phakram/app/utils"
+	"phakram/app/utils/notify"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/uptrace/bun"
+)
+
+const (
+	defaultSalesWindowDays = 30
+	maxSalesWindowDays     = 365
+)
+
+type UpdateStockThresholdsServiceRequest struct {
+	ReorderPoint *int
+	TargetStock  *int
+	ActionBy     *uuid.UUID
+}
+
+type LowStockReportServiceRequest struct {
+	WindowDays int
+	// All lists every product with a reorder point, not only those at or
+	// below it.
+	All bool
+}
+
+type LowStockItem struct {
+	ProductID         uuid.UUID        `json:"product_id"`
+	ProductNo         string           `json:"product_no"`
+	NameTh            string           `json:"name_th"`
+	NameEn            string           `json:"name_en"`
+	Remaining         int              `json:"remaining"`
+	ReorderPoint      int              `json:"reorder_point"`
+	TargetStock       *int             `json:"target_stock"`
+	IsLow             bool             `json:"is_low"`
+	UnitsSold         int              `json:"units_sold"`
+	DailyVelocity     decimal.Decimal  `json:"daily_velocity"`
+	DaysOfCover       *decimal.Decimal `json:"days_of_cover"`
+	SuggestedReorder  *int             `json:"suggested_reorder"`
+	LowStockAlertedAt *string          `json:"low_stock_alerted_at"`
+}
+
+type LowStockReport struct {
+	WindowDays int             `json:"window_days"`
+	Items      []*LowStockItem `json:"items"`
+}
+
+type LowStockAlertResult struct {
+	Rearmed int `json:"rearmed"`
+	Alerted int `json:"alerted"`
+}
+
+type lowStockRow struct {
+	ProductID         uuid.UUID  `bun:"product_id"`
+	ProductNo         string     `bun:"product_no"`
+	NameTh            string     `bun:"name_th"`
+	NameEn            string     `bun:"name_en"`
+	Remaining         int        `bun:"remaining"`
+	ReorderPoint      int        `bun:"reorder_point"`
+	TargetStock       *int       `bun:"target_stock"`
+	UnitsSold         int        `bun:"units_sold"`
+	LowStockAlertedAt *time.Time `bun:"low_stock_alerted_at"`
+}
+
+// UpdateStockThresholdsService sets the reorder point and target level of a
+// product's stock. A nil value clears it. Raising the stock back above the
+// reorder point re-arms the alert.
+func (s *Service) UpdateStockThresholdsService(ctx context.Context, productID uuid.UUID, req *UpdateStockThresholdsServiceRequest) (*ent.ProductStockEntity, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`product_stocks.svc.thresholds.update.start`)
+
+	if req.ReorderPoint != nil && *req.ReorderPoint < 0 {
+		return nil, errors.New("invalid reorder point")
+	}
+	if req.TargetStock != nil && *req.TargetStock <= 0 {
+		return nil, errors.New("invalid target stock")
+	}
+	if req.TargetStock != nil && req.ReorderPoint != nil && *req.TargetStock <= *req.ReorderPoint {
+		return nil, errors.New("target stock must be above reorder point")
+	}
+
+	now := time.Now()
+	data := new(ent.ProductStockEntity)
+	err := s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if err := tx.NewSelect().
+			Model(data).
+			Where("product_id = ?", productID).
+			Where("deleted_at IS NULL").
+			For("UPDATE").
+			Scan(ctx); err != nil {
+			return err
+		}
+
+		data.ReorderPoint = req.ReorderPoint
+		data.TargetStock = req.TargetStock
+		if data.ReorderPoint == nil || data.Remaining > *data.ReorderPoint {
+			data.LowStockAlertedAt = nil
+		}
+		data.UpdatedAt = now
+		if _, err := tx.NewUpdate().
+			Model(data).
+			Column("reorder_point", "target_stock", "low_stock_alerted_at", "updated_at").
+			WherePK().
+			Exec(ctx); err != nil {
+			return err
+		}
+
+		auditLog := &ent.AuditLogEntity{
+			ID:           uuid.New(),
+			Action:       ent.AuditActionUpdated,
+			ActionType:   "update_stock_thresholds",
+			ActionID:     productID,
+			ActionBy:     req.ActionBy,
+			Status:       ent.StatusAuditSuccesses,
+			ActionDetail: fmt.Sprintf("reorder_point=%s; target_stock=%s", formatOptionalInt(data.ReorderPoint), formatOptionalInt(data.TargetStock)),
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		}
+		_, err := tx.NewInsert().Model(auditLog).Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	span.AddEvent(`product_stocks.svc.thresholds.update.success`)
+	return data, nil
+}
+
+// LowStockReportService lists stocks at or below their reorder point, lowest
+// headroom first, with sales velocity over the window and how many days the
+// remaining stock should last at that pace.
+func (s *Service) LowStockReportService(ctx context.Context, req *LowStockReportServiceRequest) (*LowStockReport, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`product_stocks.svc.low_stock.report.start`)
+
+	windowDays := s.salesWindowDays(req.WindowDays)
+	rows, err := queryLowStockRows(ctx, s.bunDB.DB(), windowDays, time.Now().UTC(), !req.All, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &LowStockReport{WindowDays: windowDays, Items: make([]*LowStockItem, 0, len(rows))}
+	for _, row := range rows {
+		report.Items = append(report.Items, toLowStockItem(row, windowDays))
+	}
+
+	span.AddEvent(`product_stocks.svc.low_stock.report.success`)
+	return report, nil
+}
+
+// RunLowStockAlerts notifies admins once about every stock that has dropped
+// to its reorder point since the last run, as one digest by email and LINE.
+// A stock is alerted again only after it has been restocked above the
+// reorder point. Nothing is claimed when no channel is set up, and a claim
+// is released when every channel failed, so the next run tries again.
+func (s *Service) RunLowStockAlerts(ctx context.Context, now time.Time) (*LowStockAlertResult, error) {
+	span, log := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`product_stocks.svc.low_stock.run.start`)
+
+	// The claim is matched by timestamp when released, so keep it at the
+	// precision the column stores.
+	now = now.UTC().Truncate(time.Microsecond)
+	result := &LowStockAlertResult{}
+
+	res, err := s.bunDB.DB().NewUpdate().
+		Model((*ent.ProductStockEntity)(nil)).
+		Set("low_stock_alerted_at = NULL").
+		Where("deleted_at IS NULL").
+		Where("low_stock_alerted_at IS NOT NULL").
+		Where("reorder_point IS NULL OR remaining > reorder_point").
+		Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if affected, err := res.RowsAffected(); err == nil {
+		result.Rearmed = int(affected)
+	}
+
+	emails := notify.SplitList(s.conf.AlertEmails)
+	mailEnabled := s.conf.Mail.Enabled() && len(emails) > 0
+	lineEnabled := s.conf.Line.Enabled()
+	if !mailEnabled && !lineEnabled {
+		span.AddEvent(`product_stocks.svc.low_stock.run.no_channel`)
+		return result, nil
+	}
+
+	// Claim the stocks in a short transaction so nothing holds the stock rows
+	// while mail and LINE are sent; checkout and stock edits lock them too.
+	stockIDs := make([]uuid.UUID, 0)
+	err = s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		claimable := tx.NewSelect().
+			Model((*ent.ProductStockEntity)(nil)).
+			Column("id").
+			Where("deleted_at IS NULL").
+			Where("reorder_point IS NOT NULL").
+			Where("remaining <= reorder_point").
+			Where("low_stock_alerted_at IS NULL").
+			For("UPDATE SKIP LOCKED")
+		_, err := tx.NewUpdate().
+			Model((*ent.ProductStockEntity)(nil)).
+			Set("low_stock_alerted_at = ?", now).
+			Where("id IN (?)", claimable).
+			Returning("id").
+			Exec(ctx, &stockIDs)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(stockIDs) == 0 {
+		span.AddEvent(`product_stocks.svc.low_stock.run.success`)
+		return result, nil
+	}
+
+	windowDays := s.salesWindowDays(0)
+	rows, err := queryLowStockRows(ctx, s.bunDB.DB(), windowDays, now, true, stockIDs)
+	if err != nil {
+		s.releaseLowStockClaim(ctx, stockIDs, now)
+		return nil, err
+	}
+	if len(rows) == 0 {
+		span.AddEvent(`product_stocks.svc.low_stock.run.success`)
+		return result, nil
+	}
+	items := make([]*LowStockItem, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, toLowStockItem(row, windowDays))
+	}
+
+	subject, body := lowStockMessage(items, windowDays)
+	sent := false
+	if mailEnabled {
+		if err := notify.SendMail(s.conf.Mail, emails, subject, body); err != nil {
+			log.Errf("low stock alert email failed: %s", err)
+		} else {
+			sent = true
+		}
+	}
+	if lineEnabled {
+		if err := notify.PushLine(ctx, s.conf.Line, subject+"\n\n"+body); err != nil {
+			log.Errf("low stock alert line push failed: %s", err)
+		} else {
+			sent = true
+		}
+	}
+	if !sent {
+		s.releaseLowStockClaim(ctx, stockIDs, now)
+		return nil, errors.New("low stock alert could not be sent")
+	}
+
+	auditLogs := make([]*ent.AuditLogEntity, 0, len(items))
+	for _, item := range items {
+		auditLogs = append(auditLogs, &ent.AuditLogEntity{
+			ID:           uuid.New(),
+			Action:       ent.AuditActionCreated,
+			ActionType:   "low_stock_alert",
+			ActionID:     item.ProductID,
+			Status:       ent.StatusAuditSuccesses,
+			ActionDetail: fmt.Sprintf("remaining=%d; reorder_point=%d", item.Remaining, item.ReorderPoint),
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		})
+	}
+	if _, err := s.bunDB.DB().NewInsert().Model(&auditLogs).Exec(ctx); err != nil {
+		return nil, err
+	}
+	result.Alerted = len(items)
+
+	span.AddEvent(`product_stocks.svc.low_stock.run.success`)
+	return result, nil
+}
+
+// releaseLowStockClaim clears a claim taken by RunLowStockAlerts when the
+// alert was not sent, so the next run tries again. Stocks re-armed or
+// re-claimed since are left alone.
+func (s *Service) releaseLowStockClaim(ctx context.Context, stockIDs []uuid.UUID, claimedAt time.Time) {
+	_, log := utils.LogSpanFromContext(ctx)
+	if _, err := s.bunDB.DB().NewUpdate().
+		Model((*ent.ProductStockEntity)(nil)).
+		Set("low_stock_alerted_at = NULL").
+		Where("id IN (?)", bun.In(stockIDs)).
+		Where("low_stock_alerted_at = ?", claimedAt).
+		Exec(ctx); err != nil {
+		log.Errf("low stock alert claim release failed: %s", err)
+	}
+}
+
+func (s *Service) salesWindowDays(requested int) int {
+	days := requested
+	if days <= 0 && s.conf != nil {
+		days = s.conf.SalesWindowDays
+	}
+	if days <= 0 {
+		days = defaultSalesWindowDays
+	}
+	if days > maxSalesWindowDays {
+		days = maxSalesWindowDays
+	}
+	return days
+}
+
+// queryLowStockRows loads stocks with a reorder point and the units sold in
+// paid orders over the window. stockIDs, when given, limits the rows.
+func queryLowStockRows(ctx context.Context, db bun.IDB, windowDays int, now time.Time, onlyLow bool, stockIDs []uuid.UUID) ([]*lowStockRow, error) {
+	since := now.AddDate(0, 0, -windowDays)
+	sold := db.NewSelect().
+		TableExpr("order_items AS oi").
+		Join("JOIN orders AS o ON o.id = oi.order_id").
+		ColumnExpr("oi.product_id").
+		ColumnExpr("SUM(oi.quantity) AS quantity").
+		Where("o.status IN ('paid', 'shipping', 'completed')").
+		Where("o.created_at >= ?", since).
+		Group("oi.product_id")
+
+	rows := make([]*lowStockRow, 0)
+	q := db.NewSelect().
+		TableExpr("product_stocks AS ps").
+		Join("JOIN products AS p ON p.id = ps.product_id AND p.deleted_at IS NULL").
+		Join("LEFT JOIN (?) AS sold ON sold.product_id = ps.product_id", sold).
+		ColumnExpr("ps.product_id, p.product_no, p.name_th, p.name_en").
+		ColumnExpr("ps.remaining, ps.reorder_point, ps.target_stock, ps.low_stock_alerted_at").
+		ColumnExpr("COALESCE(sold.quantity, 0) AS units_sold").
+		Where("ps.deleted_at IS NULL").
+		Where("ps.reorder_point IS NOT NULL").
+		OrderExpr("ps.remaining - ps.reorder_point ASC, p.product_no ASC")
+	if onlyLow {
+		q = q.Where("ps.remaining <= ps.reorder_point")
+	}
+	if len(stockIDs) > 0 {
+		q = q.Where("ps.id IN (?)", bun.In(stockIDs))
+	}
+	if err := q.Scan(ctx, &rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func toLowStockItem(row *lowStockRow, windowDays int) *LowStockItem {
+	item := &LowStockItem{
+		ProductID:     row.ProductID,
+		ProductNo:     row.ProductNo,
+		NameTh:        row.NameTh,
+		NameEn:        row.NameEn,
+		Remaining:     row.Remaining,
+		ReorderPoint:  row.ReorderPoint,
+		TargetStock:   row.TargetStock,
+		IsLow:         row.Remaining <= row.ReorderPoint,
+		UnitsSold:     row.UnitsSold,
+		DailyVelocity: decimal.NewFromInt(int64(row.UnitsSold)).Div(decimal.NewFromInt(int64(windowDays))).Round(2),
+	}
+	if row.UnitsSold > 0 {
+		cover := decimal.NewFromInt(int64(row.Remaining)).
+			Mul(decimal.NewFromInt(int64(windowDays))).
+			Div(decimal.NewFromInt(int64(row.UnitsSold))).
+			Round(1)
+		item.DaysOfCover = &cover
+	}
+	if row.TargetStock != nil {
+		suggested := max(*row.TargetStock-row.Remaining, 0)
+		item.SuggestedReorder = &suggested
+	}
+	if row.LowStockAlertedAt != nil {
+		alertedAt := row.LowStockAlertedAt.Format("2006-01-02T15:04:05Z07:00")
+		item.LowStockAlertedAt = &alertedAt
+	}
+	return item
+}
+
+func lowStockMessage(items []*LowStockItem, windowDays int) (string, string) {
+	subject := fmt.Sprintf("[Phakram] แจ้งเตือนสินค้าใกล้หมด %d รายการ", len(items))
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "สินค้าต่อไปนี้มีคงเหลือถึงจุดสั่งซื้อแล้ว (ยอดขายเฉลี่ย %d วันล่าสุด)\n\n", windowDays)
+	for _, item := range items {
+		fmt.Fprintf(&body, "- [%s] %s: คงเหลือ %d (จุดสั่งซื้อ %d)", item.ProductNo, item.NameTh, item.Remaining, item.ReorderPoint)
+		fmt.Fprintf(&body, ", ขายเฉลี่ย %s ชิ้น/วัน", item.DailyVelocity.String())
+		if item.DaysOfCover != nil {
+			fmt.Fprintf(&body, ", พอขายอีกประมาณ %s วัน", item.DaysOfCover.String())
+		}
+		if item.SuggestedReorder != nil && *item.SuggestedReorder > 0 {
+			fmt.Fprintf(&body, ", ควรสั่งเพิ่ม %d ชิ้น", *item.SuggestedReorder)
+		}
+		body.WriteString("\n")
+	}
+	return subject, body.String()
+}
+
+func formatOptionalInt(value *int) string {
+	if value == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%d", *value)
+}
@@ -1,12 +1,24 @@
 package productstocks
 
 import (
+	"phakram/app/utils/notify"
 	"phakram/internal/database"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/trace"
 )
 
+type Config struct {
+	// AlertEmails is a comma separated list of admins who get low-stock
+	// alerts by email.
+	AlertEmails string
+	Mail        notify.MailConfig
+	Line        notify.LineConfig
+	// SalesWindowDays is the look-back used for sales velocity in low-stock
+	// alerts and the report.
+	SalesWindowDays int
+}
+
 type Module struct {
 	Svc *Service
 	Ctl *Controller
@@ -16,6 +28,7 @@ type (
 	Service struct {
 		tracer trace.Tracer
 		bunDB  *database.DatabaseService
+		conf   *Config
 	}
 	Controller struct {
 		tracer trace.Tracer
@@ -23,8 +36,8 @@ type (
 	}
 )
 
-func New(bunDB *database.DatabaseService) *Module {
+func New(bunDB *database.DatabaseService, conf *Config) *Module {
 	tracer := otel.Tracer("product_stocks_module")
-	svc := &Service{tracer: tracer, bunDB: bunDB}
+	svc := &Service{tracer: tracer, bunDB: bunDB, conf: conf}
 	return &Module{Svc: svc, Ctl: &Controller{tracer: tracer, svc: svc}}
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"phakram/app/modules/entities/ent"
 	"phakram/app/utils/base"
+	"phakram/app/utils/inventory"
 	"time"
 
 	"github.com/google/uuid"
@@ -44,35 +45,55 @@ func (s *Service) GetByProductID(ctx context.Context, productID uuid.UUID) (*ent
 	return data, nil
 }
 
+// CreateByProductID sets the stock of a product, booking the quantity at
+// the default location.
 func (s *Service) CreateByProductID(ctx context.Context, productID uuid.UUID, payload *ent.ProductStockEntity) error {
 	product := new(ent.ProductEntity)
 	if err := s.bunDB.DB().NewSelect().Model(product).Where("id = ?", productID).Where("deleted_at IS NULL").Limit(1).Scan(ctx); err != nil {
 		return err
 	}
-	payload.UnitPrice = product.Price
-	payload.ID = uuid.New()
-	payload.ProductID = productID
-	_, err := s.bunDB.DB().NewInsert().Model(payload).Exec(ctx)
-	return err
+	return s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		return inventory.SetStockInTx(ctx, tx, productID, payload.StockAmount, payload.Remaining, nil)
+	})
 }
 
+// UpdateByProductID sets the stock of a product. The difference is booked
+// as an adjustment against its locations.
 func (s *Service) UpdateByProductID(ctx context.Context, productID uuid.UUID, payload *ent.ProductStockEntity) error {
-	current, err := s.GetByProductID(ctx, productID)
-	if err != nil {
+	if _, err := s.GetByProductID(ctx, productID); err != nil {
 		return err
 	}
 	product := new(ent.ProductEntity)
 	if err := s.bunDB.DB().NewSelect().Model(product).Where("id = ?", productID).Where("deleted_at IS NULL").Limit(1).Scan(ctx); err != nil {
 		return err
 	}
-	current.UnitPrice = product.Price
-	current.StockAmount = payload.StockAmount
-	current.Remaining = payload.Remaining
-	_, err = s.bunDB.DB().NewUpdate().Model(current).Where("id = ?", current.ID).Exec(ctx)
-	return err
+	return s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if err := inventory.SetStockInTx(ctx, tx, productID, payload.StockAmount, payload.Remaining, nil); err != nil {
+			return err
+		}
+		_, err := tx.NewUpdate().
+			Model((*ent.ProductStockEntity)(nil)).
+			Set("unit_price = ?", product.Price).
+			Where("product_id = ?", productID).
+			Where("deleted_at IS NULL").
+			Exec(ctx)
+		return err
+	})
 }
 
+// DeleteByProductID empties the product's locations and removes its stock.
 func (s *Service) DeleteByProductID(ctx context.Context, productID uuid.UUID) error {
-	_, err := s.bunDB.DB().NewUpdate().Model((*ent.ProductStockEntity)(nil)).Set("deleted_at = ?", time.Now()).Where("product_id = ?", productID).Where("deleted_at IS NULL").Exec(ctx)
-	return err
+	return s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		exists, err := tx.NewSelect().Model((*ent.ProductStockEntity)(nil)).Where("product_id = ?", productID).Exists(ctx)
+		if err != nil {
+			return err
+		}
+		if exists {
+			if err := inventory.SetStockInTx(ctx, tx, productID, 0, 0, nil); err != nil {
+				return err
+			}
+		}
+		_, err = tx.NewUpdate().Model((*ent.ProductStockEntity)(nil)).Set("deleted_at = ?", time.Now()).Where("product_id = ?", productID).Where("deleted_at IS NULL").Exec(ctx)
+		return err
+	})
 }
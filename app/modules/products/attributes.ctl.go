@@ -0,0 +1,75 @@
+package products
+
+import (
+	"encoding/json"
+	"phakram/app/modules/auth"
+	"phakram/app/utils"
+	"phakram/app/utils/base"
+	"phakram/config/i18n"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ReplaceProductAttributesControllerRequest struct {
+	Attributes map[string]json.RawMessage `json:"attributes"`
+}
+
+func (c *Controller) ProductAttributesController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`products.ctl.attributes.info.start`)
+
+	var uri InfoProductControllerRequestUri
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+	productID, err := uuid.Parse(uri.ID)
+	if err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	data, err := c.svc.ProductAttributesService(ctx.Request.Context(), productID)
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`products.ctl.attributes.info.success`)
+	base.Success(ctx, data)
+}
+
+// ReplaceProductAttributesController sets all attribute values of a product,
+// keyed by attribute code. Codes left out are cleared.
+func (c *Controller) ReplaceProductAttributesController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`products.ctl.attributes.replace.start`)
+
+	productID, ok := c.parseProductPriceRequest(ctx)
+	if !ok {
+		return
+	}
+
+	var req ReplaceProductAttributesControllerRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	var actionBy *uuid.UUID
+	if memberID, ok := auth.GetMemberID(ctx); ok {
+		actionBy = &memberID
+	}
+	data, err := c.svc.ReplaceProductAttributesService(ctx.Request.Context(), productID, &ReplaceProductAttributesServiceRequest{
+		Values:   req.Attributes,
+		ActionBy: actionBy,
+	})
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`products.ctl.attributes.replace.success`)
+	base.Success(ctx, data)
+}
@@ -0,0 +1,131 @@
+package products
+
+import (
+	"context"
+	"encoding/json"
+	"phakram/app/modules/entities/ent"
+	"phakram/app/utils"
+	"phakram/app/utils/attributes"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+type ReplaceProductAttributesServiceRequest struct {
+	Values   map[string]json.RawMessage
+	ActionBy *uuid.UUID
+}
+
+func (s *Service) ProductAttributesService(ctx context.Context, productID uuid.UUID) ([]*attributes.Value, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`products.svc.attributes.info.start`)
+
+	product := new(ent.ProductEntity)
+	if err := s.bunDB.DB().NewSelect().
+		Model(product).
+		Column("id", "category_id").
+		Where("id = ?", productID).
+		Scan(ctx); err != nil {
+		return nil, err
+	}
+
+	data, err := attributes.LoadValues(ctx, s.bunDB.DB(), product.ID, product.CategoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	span.AddEvent(`products.svc.attributes.info.success`)
+	return data, nil
+}
+
+// ReplaceProductAttributesService validates the values against the schema
+// of the product's category and replaces every stored value with them.
+func (s *Service) ReplaceProductAttributesService(ctx context.Context, productID uuid.UUID, req *ReplaceProductAttributesServiceRequest) ([]*attributes.Value, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`products.svc.attributes.replace.start`)
+
+	now := time.Now()
+	product := new(ent.ProductEntity)
+	err := s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if err := tx.NewSelect().
+			Model(product).
+			Where("id = ?", productID).
+			For("UPDATE").
+			Scan(ctx); err != nil {
+			return err
+		}
+
+		schema, err := attributes.LoadSchema(ctx, tx, product.CategoryID)
+		if err != nil {
+			return err
+		}
+		rows, err := attributes.Validate(schema, product.ID, req.Values)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.NewDelete().
+			Model((*ent.ProductAttributeValueEntity)(nil)).
+			Where("product_id = ?", product.ID).
+			Exec(ctx); err != nil {
+			return err
+		}
+		if len(rows) > 0 {
+			for _, row := range rows {
+				row.CreatedAt = now
+				row.UpdatedAt = now
+			}
+			if _, err := tx.NewInsert().Model(&rows).Exec(ctx); err != nil {
+				return err
+			}
+		}
+
+		auditLog := &ent.AuditLogEntity{
+			ID:           uuid.New(),
+			Action:       ent.AuditActionUpdated,
+			ActionType:   "update_product_attributes",
+			ActionID:     product.ID,
+			ActionBy:     req.ActionBy,
+			Status:       ent.StatusAuditSuccesses,
+			ActionDetail: "Updated attributes of product with ID " + product.ID.String(),
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		}
+		_, err = tx.NewInsert().Model(auditLog).Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := attributes.LoadValues(ctx, s.bunDB.DB(), product.ID, product.CategoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	span.AddEvent(`products.svc.attributes.replace.success`)
+	return data, nil
+}
+
+// pruneProductAttributesInTx drops the values a product holds for attributes
+// that are not part of its category schema, after it moved category.
+func pruneProductAttributesInTx(ctx context.Context, tx bun.Tx, productID uuid.UUID, categoryID uuid.UUID) error {
+	schema, err := attributes.LoadSchema(ctx, tx, categoryID)
+	if err != nil {
+		return err
+	}
+
+	q := tx.NewDelete().
+		Model((*ent.ProductAttributeValueEntity)(nil)).
+		Where("product_id = ?", productID)
+	if len(schema) > 0 {
+		ids := make([]uuid.UUID, 0, len(schema))
+		for _, def := range schema {
+			ids = append(ids, def.ID)
+		}
+		q = q.Where("attribute_id NOT IN (?)", bun.In(ids))
+	}
+	_, err = q.Exec(ctx)
+	return err
+}
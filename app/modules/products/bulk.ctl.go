@@ -0,0 +1,124 @@
+package products
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"phakram/app/modules/auth"
+	"phakram/app/utils"
+	"phakram/app/utils/base"
+	"phakram/config/i18n"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxProductImportFileSize = 10 << 20
+
+type ExportProductsControllerRequest struct {
+	Format string `form:"format"`
+}
+
+func requireProductBulkAdmin(ctx *gin.Context) bool {
+	_, hasRequester := auth.GetMemberID(ctx)
+	if !auth.GetIsAdmin(ctx) || !hasRequester {
+		base.Forbidden(ctx, i18n.Forbidden, nil)
+		return false
+	}
+	return true
+}
+
+// ImportProductsController takes a CSV or XLSX upload in the "file" field.
+// It is a dry run unless dry_run=false is passed.
+func (c *Controller) ImportProductsController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`products.ctl.import.start`)
+
+	if !requireProductBulkAdmin(ctx) {
+		return
+	}
+
+	dryRun := true
+	if value := ctx.PostForm("dry_run"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			base.BadRequest(ctx, i18n.BadRequest, nil)
+			return
+		}
+		dryRun = parsed
+	}
+
+	header, err := ctx.FormFile("file")
+	if err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+	if header.Size > maxProductImportFileSize {
+		base.HandleError(ctx, errors.New("import file is too large"))
+		return
+	}
+	format, err := ProductBulkFormatFromFileName(header.Filename)
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+	defer file.Close()
+	content, err := io.ReadAll(io.LimitReader(file, maxProductImportFileSize))
+	if err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	data, err := c.svc.ImportProductsService(ctx.Request.Context(), &ImportProductsServiceRequest{
+		Format:  format,
+		Content: content,
+		DryRun:  dryRun,
+	})
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`products.ctl.import.success`)
+	base.Success(ctx, data)
+}
+
+func (c *Controller) ExportProductsController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`products.ctl.export.start`)
+
+	if !requireProductBulkAdmin(ctx) {
+		return
+	}
+
+	var req ExportProductsControllerRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+	if req.Format == "" {
+		req.Format = ProductBulkFormatCSV
+	}
+
+	content, fileName, err := c.svc.ExportProductsService(ctx.Request.Context(), req.Format)
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	contentType := "text/csv; charset=utf-8"
+	if req.Format == ProductBulkFormatXLSX {
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+
+	span.AddEvent(`products.ctl.export.success`)
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+	ctx.Data(http.StatusOK, contentType, content)
+}
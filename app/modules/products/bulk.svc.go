@@ -0,0 +1,781 @@
+package products
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"phakram/app/modules/entities/ent"
+	"phakram/app/utils"
+	"phakram/app/utils/inventory"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/uptrace/bun"
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	ProductBulkFormatCSV  = "csv"
+	ProductBulkFormatXLSX = "xlsx"
+
+	productBulkSheetName = "products"
+	maxProductImportRows = 5000
+)
+
+// productBulkColumns is the file layout shared by import and export, so an
+// export can be edited and imported back.
+var productBulkColumns = []string{
+	"product_no",
+	"name_th",
+	"name_en",
+	"category_name_th",
+	"category_name_en",
+	"price",
+	"is_active",
+	"tax_class",
+	"price_includes_vat",
+	"description",
+	"material",
+	"dimensions",
+	"weight",
+	"care_instructions",
+	"stock_amount",
+	"remaining",
+}
+
+type ImportProductsServiceRequest struct {
+	Format  string
+	Content []byte
+	DryRun  bool
+}
+
+type ProductImportRowResult struct {
+	Row       int      `json:"row"`
+	ProductNo string   `json:"product_no"`
+	Action    string   `json:"action"`
+	Errors    []string `json:"errors,omitempty"`
+}
+
+// ProductImportReport describes what an import did, or would do on a dry
+// run. Nothing is written unless every row is valid.
+type ProductImportReport struct {
+	DryRun            bool                      `json:"dry_run"`
+	Applied           bool                      `json:"applied"`
+	TotalRows         int                       `json:"total_rows"`
+	Created           int                       `json:"created"`
+	Updated           int                       `json:"updated"`
+	Failed            int                       `json:"failed"`
+	CategoriesCreated int                       `json:"categories_created"`
+	Rows              []*ProductImportRowResult `json:"rows"`
+}
+
+// productImportRow is one parsed line. Nil fields were left blank: they keep
+// the current value on update and take the defaults on create.
+type productImportRow struct {
+	result *ProductImportRowResult
+
+	nameTh           *string
+	nameEn           *string
+	categoryNameTh   string
+	categoryNameEn   string
+	price            *decimal.Decimal
+	isActive         *bool
+	taxClass         *ent.TaxClassEnum
+	priceIncludesVAT *bool
+	description      *string
+	material         *string
+	dimensions       *string
+	weight           *decimal.Decimal
+	careInstructions *string
+	stockAmount      *int
+	remaining        *int
+
+	product    *ent.ProductEntity
+	stock      *ent.ProductStockEntity
+	categoryID uuid.UUID
+}
+
+func (r *productImportRow) fail(format string, args ...any) {
+	r.result.Errors = append(r.result.Errors, fmt.Sprintf(format, args...))
+}
+
+// ProductBulkFormatFromFileName picks the file format from its extension.
+func ProductBulkFormatFromFileName(fileName string) (string, error) {
+	lower := strings.ToLower(strings.TrimSpace(fileName))
+	switch {
+	case strings.HasSuffix(lower, ".csv"):
+		return ProductBulkFormatCSV, nil
+	case strings.HasSuffix(lower, ".xlsx"):
+		return ProductBulkFormatXLSX, nil
+	default:
+		return "", errors.New("unsupported import file type")
+	}
+}
+
+func readProductBulkRows(format string, content []byte) ([][]string, error) {
+	switch format {
+	case ProductBulkFormatCSV:
+		reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))))
+		reader.FieldsPerRecord = -1
+		rows, err := reader.ReadAll()
+		if err != nil {
+			return nil, errors.New("invalid import file")
+		}
+		return rows, nil
+	case ProductBulkFormatXLSX:
+		file, err := excelize.OpenReader(bytes.NewReader(content))
+		if err != nil {
+			return nil, errors.New("invalid import file")
+		}
+		defer file.Close()
+		sheets := file.GetSheetList()
+		if len(sheets) == 0 {
+			return nil, errors.New("invalid import file")
+		}
+		rows, err := file.GetRows(sheets[0])
+		if err != nil {
+			return nil, errors.New("invalid import file")
+		}
+		return rows, nil
+	default:
+		return nil, errors.New("unsupported import file type")
+	}
+}
+
+func parseProductImportBool(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "true", "1", "yes", "y":
+		return true, nil
+	case "false", "0", "no", "n":
+		return false, nil
+	default:
+		return false, fmt.Errorf("invalid boolean %q", value)
+	}
+}
+
+func parseProductImportRows(records [][]string) ([]*productImportRow, error) {
+	if len(records) == 0 {
+		return nil, errors.New("import file has no header row")
+	}
+
+	header := make(map[string]int, len(records[0]))
+	for index, name := range records[0] {
+		header[strings.ToLower(strings.TrimSpace(name))] = index
+	}
+	if _, ok := header["product_no"]; !ok {
+		return nil, errors.New("import file must have a product_no column")
+	}
+	if len(records)-1 > maxProductImportRows {
+		return nil, errors.New("import file has too many rows")
+	}
+
+	rows := make([]*productImportRow, 0, len(records)-1)
+	for index, record := range records[1:] {
+		cell := func(column string) string {
+			position, ok := header[column]
+			if !ok || position >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[position])
+		}
+
+		empty := true
+		for _, value := range record {
+			if strings.TrimSpace(value) != "" {
+				empty = false
+				break
+			}
+		}
+		if empty {
+			continue
+		}
+
+		row := &productImportRow{
+			result: &ProductImportRowResult{Row: index + 2, ProductNo: cell("product_no")},
+		}
+		text := func(column string) *string {
+			if value := cell(column); value != "" {
+				return &value
+			}
+			return nil
+		}
+		row.nameTh = text("name_th")
+		row.nameEn = text("name_en")
+		row.categoryNameTh = cell("category_name_th")
+		row.categoryNameEn = cell("category_name_en")
+		row.description = text("description")
+		row.material = text("material")
+		row.dimensions = text("dimensions")
+		row.careInstructions = text("care_instructions")
+
+		if value := cell("price"); value != "" {
+			price, err := decimal.NewFromString(value)
+			if err != nil || price.IsNegative() {
+				row.fail("invalid price %q", value)
+			} else {
+				price = price.Round(2)
+				row.price = &price
+			}
+		}
+		if value := cell("weight"); value != "" {
+			weight, err := decimal.NewFromString(value)
+			if err != nil || weight.IsNegative() {
+				row.fail("invalid weight %q", value)
+			} else {
+				row.weight = &weight
+			}
+		}
+		for _, field := range []struct {
+			column string
+			target **bool
+		}{{"is_active", &row.isActive}, {"price_includes_vat", &row.priceIncludesVAT}} {
+			if value := cell(field.column); value != "" {
+				parsed, err := parseProductImportBool(value)
+				if err != nil {
+					row.fail("%s: %s", field.column, err)
+					continue
+				}
+				*field.target = &parsed
+			}
+		}
+		if value := cell("tax_class"); value != "" {
+			taxClass, err := parseTaxClass(value)
+			if err != nil {
+				row.fail("invalid tax class %q", value)
+			} else {
+				row.taxClass = &taxClass
+			}
+		}
+		for _, field := range []struct {
+			column string
+			target **int
+		}{{"stock_amount", &row.stockAmount}, {"remaining", &row.remaining}} {
+			if value := cell(field.column); value != "" {
+				parsed, err := strconv.Atoi(value)
+				if err != nil || parsed < 0 {
+					row.fail("invalid %s %q", field.column, value)
+					continue
+				}
+				*field.target = &parsed
+			}
+		}
+
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func productCategoryKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// planProductImport resolves every row against the database: the product it
+// updates, the category it belongs to, and whatever makes it invalid.
+func (s *Service) planProductImport(ctx context.Context, rows []*productImportRow) (map[string]*ent.CategoryEntity, error) {
+	productNos := make([]string, 0, len(rows))
+	seen := make(map[string]int, len(rows))
+	for _, row := range rows {
+		productNo := row.result.ProductNo
+		if productNo == "" {
+			continue
+		}
+		if first, ok := seen[productNo]; ok {
+			row.fail("product_no %s is repeated from row %d", productNo, first)
+			continue
+		}
+		seen[productNo] = row.result.Row
+		productNos = append(productNos, productNo)
+	}
+
+	existing := make(map[string]*ent.ProductEntity, len(productNos))
+	if len(productNos) > 0 {
+		products := make([]*ent.ProductEntity, 0, len(productNos))
+		if err := s.bunDB.DB().NewSelect().
+			Model(&products).
+			Where("product_no IN (?)", bun.In(productNos)).
+			Scan(ctx); err != nil {
+			return nil, err
+		}
+		for _, product := range products {
+			existing[product.ProductNo] = product
+		}
+	}
+
+	stocks := make(map[uuid.UUID]*ent.ProductStockEntity, len(existing))
+	if len(existing) > 0 {
+		productIDs := make([]uuid.UUID, 0, len(existing))
+		for _, product := range existing {
+			productIDs = append(productIDs, product.ID)
+		}
+		stockRows := make([]*ent.ProductStockEntity, 0, len(productIDs))
+		if err := s.bunDB.DB().NewSelect().
+			Model(&stockRows).
+			Where("product_id IN (?)", bun.In(productIDs)).
+			Order("created_at ASC").
+			Scan(ctx); err != nil {
+			return nil, err
+		}
+		for _, stock := range stockRows {
+			if _, ok := stocks[stock.ProductID]; !ok {
+				stocks[stock.ProductID] = stock
+			}
+		}
+	}
+
+	categories := make([]*ent.CategoryEntity, 0)
+	if err := s.bunDB.DB().NewSelect().Model(&categories).Scan(ctx); err != nil {
+		return nil, err
+	}
+	categoryByName := make(map[string]*ent.CategoryEntity, len(categories)*2)
+	for _, category := range categories {
+		if key := productCategoryKey(category.NameEn); key != "" {
+			categoryByName[key] = category
+		}
+	}
+	for _, category := range categories {
+		if key := productCategoryKey(category.NameTh); key != "" {
+			categoryByName[key] = category
+		}
+	}
+	newCategories := make(map[string]*ent.CategoryEntity)
+
+	for _, row := range rows {
+		row.product = existing[row.result.ProductNo]
+		if row.product != nil {
+			row.result.Action = "update"
+			row.categoryID = row.product.CategoryID
+		} else {
+			row.result.Action = "create"
+			if row.nameTh == nil {
+				row.fail("name_th is required for a new product")
+			}
+			if row.price == nil {
+				row.fail("price is required for a new product")
+			}
+		}
+
+		if row.categoryNameTh != "" || row.categoryNameEn != "" {
+			category := categoryByName[productCategoryKey(row.categoryNameTh)]
+			if category == nil {
+				category = categoryByName[productCategoryKey(row.categoryNameEn)]
+			}
+			if category == nil {
+				key := productCategoryKey(row.categoryNameTh)
+				if key == "" {
+					key = productCategoryKey(row.categoryNameEn)
+				}
+				category = newCategories[key]
+				if category == nil {
+					nameTh := row.categoryNameTh
+					if nameTh == "" {
+						nameTh = row.categoryNameEn
+					}
+					category = &ent.CategoryEntity{
+						ID:       uuid.New(),
+						NameTh:   nameTh,
+						NameEn:   row.categoryNameEn,
+						IsActive: true,
+					}
+					newCategories[key] = category
+				}
+			}
+			row.categoryID = category.ID
+		} else if row.product == nil {
+			row.fail("category_name_th or category_name_en is required for a new product")
+		}
+
+		if row.product != nil {
+			row.stock = stocks[row.product.ID]
+		}
+		if row.stockAmount != nil || row.remaining != nil {
+			stockAmount, remaining := 0, 0
+			if row.stock != nil {
+				stockAmount, remaining = row.stock.StockAmount, row.stock.Remaining
+			}
+			if row.stockAmount != nil {
+				stockAmount = *row.stockAmount
+				if row.stock == nil {
+					remaining = stockAmount
+				}
+			}
+			if row.remaining != nil {
+				remaining = *row.remaining
+			}
+			if remaining > stockAmount {
+				row.fail("remaining cannot exceed stock_amount")
+			}
+		}
+
+		if len(row.result.Errors) > 0 {
+			row.result.Action = "error"
+		}
+	}
+	return newCategories, nil
+}
+
+// ImportProductsService upserts products, their details, stock and category
+// by product_no. Rows without a product_no create new products; categories
+// are matched by name and created when missing. Every row is validated
+// first and the import only writes when all of them pass and DryRun is off.
+func (s *Service) ImportProductsService(ctx context.Context, req *ImportProductsServiceRequest) (*ProductImportReport, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`products.svc.import.start`)
+
+	records, err := readProductBulkRows(req.Format, req.Content)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := parseProductImportRows(records)
+	if err != nil {
+		return nil, err
+	}
+	newCategories, err := s.planProductImport(ctx, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ProductImportReport{
+		DryRun:            req.DryRun,
+		TotalRows:         len(rows),
+		CategoriesCreated: len(newCategories),
+		Rows:              make([]*ProductImportRowResult, 0, len(rows)),
+	}
+	for _, row := range rows {
+		report.Rows = append(report.Rows, row.result)
+		switch row.result.Action {
+		case "create":
+			report.Created++
+		case "update":
+			report.Updated++
+		default:
+			report.Failed++
+		}
+	}
+	if req.DryRun || report.Failed > 0 || len(rows) == 0 {
+		span.AddEvent(`products.svc.import.checked`)
+		return report, nil
+	}
+
+	for _, row := range rows {
+		if row.product == nil && row.result.ProductNo == "" {
+			productNo, err := s.generateUniqueProductNo(ctx)
+			if err != nil {
+				return nil, err
+			}
+			row.result.ProductNo = productNo
+		}
+	}
+
+	now := time.Now()
+	err = s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		for _, category := range newCategories {
+			category.CreatedAt = now
+			category.UpdatedAt = now
+			if _, err := tx.NewInsert().Model(category).Exec(ctx); err != nil {
+				return err
+			}
+		}
+		for _, row := range rows {
+			if err := applyProductImportRowInTx(ctx, tx, row, now); err != nil {
+				return fmt.Errorf("row %d: %w", row.result.Row, err)
+			}
+		}
+
+		auditLog := &ent.AuditLogEntity{
+			ID:           uuid.New(),
+			Action:       ent.AuditActionCreated,
+			ActionType:   "import_products",
+			ActionID:     uuid.New(),
+			Status:       ent.StatusAuditSuccesses,
+			ActionDetail: fmt.Sprintf("Imported products: %d created, %d updated, %d categories created", report.Created, report.Updated, report.CategoriesCreated),
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		}
+		_, err := tx.NewInsert().Model(auditLog).Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report.Applied = true
+	span.AddEvent(`products.svc.import.success`)
+	return report, nil
+}
+
+func applyProductImportRowInTx(ctx context.Context, tx bun.Tx, row *productImportRow, now time.Time) error {
+	product := row.product
+	var previousPrice *decimal.Decimal
+	if product != nil {
+		price := product.Price
+		previousPrice = &price
+	}
+	if product == nil {
+		product = &ent.ProductEntity{
+			ID:               uuid.New(),
+			ProductNo:        row.result.ProductNo,
+			TaxClass:         ent.TaxClassVAT,
+			PriceIncludesVAT: true,
+			CreatedAt:        now,
+		}
+	}
+	previousCategoryID := product.CategoryID
+	product.CategoryID = row.categoryID
+	if row.nameTh != nil {
+		product.NameTh = *row.nameTh
+	}
+	if row.nameEn != nil {
+		product.NameEn = *row.nameEn
+	}
+	if row.price != nil {
+		product.Price = *row.price
+	}
+	// is_active only moves the lifecycle when it changes, so re-importing an
+	// export leaves scheduled and archived products where they are.
+	if row.product == nil || (row.isActive != nil && *row.isActive != product.IsActive) {
+		if err := applyProductPublishing(product, &ProductPublishing{IsActive: row.isActive}, now.UTC()); err != nil {
+			return err
+		}
+	}
+	if row.taxClass != nil {
+		product.TaxClass = *row.taxClass
+	}
+	if row.priceIncludesVAT != nil {
+		product.PriceIncludesVAT = *row.priceIncludesVAT
+	}
+	product.UpdatedAt = now
+
+	if row.product == nil {
+		if _, err := tx.NewInsert().Model(product).Exec(ctx); err != nil {
+			return err
+		}
+	} else {
+		if _, err := tx.NewUpdate().
+			Model(product).
+			Column("category_id", "name_th", "name_en", "price", "is_active", "status", "publish_at", "tax_class", "price_includes_vat", "updated_at").
+			WherePK().
+			Exec(ctx); err != nil {
+			return err
+		}
+	}
+	if err := recordProductPriceChangeInTx(ctx, tx, product.ID, previousPrice, product.Price, PriceChangeImport, nil, nil, now); err != nil {
+		return err
+	}
+	if row.product != nil && product.CategoryID != previousCategoryID {
+		if err := pruneProductAttributesInTx(ctx, tx, product.ID, product.CategoryID); err != nil {
+			return err
+		}
+	}
+
+	if row.description != nil || row.material != nil || row.dimensions != nil || row.weight != nil || row.careInstructions != nil {
+		detail := new(ent.ProductDetailEntity)
+		err := tx.NewSelect().Model(detail).Where("product_id = ?", product.ID).Limit(1).Scan(ctx)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		isNew := err != nil
+		if isNew {
+			detail = &ent.ProductDetailEntity{ID: uuid.New(), ProductID: product.ID}
+		}
+		if row.description != nil {
+			detail.Description = *row.description
+		}
+		if row.material != nil {
+			detail.Material = *row.material
+		}
+		if row.dimensions != nil {
+			detail.Dimensions = *row.dimensions
+		}
+		if row.weight != nil {
+			detail.Weight = *row.weight
+		}
+		if row.careInstructions != nil {
+			detail.CareInstructions = *row.careInstructions
+		}
+		if isNew {
+			_, err = tx.NewInsert().Model(detail).Exec(ctx)
+		} else {
+			_, err = tx.NewUpdate().Model(detail).WherePK().Exec(ctx)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if row.stockAmount != nil || row.remaining != nil {
+		stockAmount, remaining := 0, 0
+		if row.stock != nil {
+			stockAmount, remaining = row.stock.StockAmount, row.stock.Remaining
+		}
+		if row.stockAmount != nil {
+			stockAmount = *row.stockAmount
+			if row.stock == nil && row.remaining == nil {
+				remaining = *row.stockAmount
+			}
+		}
+		if row.remaining != nil {
+			remaining = *row.remaining
+		}
+		if err := inventory.SetStockInTx(ctx, tx, product.ID, stockAmount, remaining, nil); err != nil {
+			return err
+		}
+		if _, err := tx.NewUpdate().
+			Model((*ent.ProductStockEntity)(nil)).
+			Set("unit_price = ?", product.Price).
+			Where("product_id = ?", product.ID).
+			Where("deleted_at IS NULL").
+			Exec(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type productExportRow struct {
+	ProductNo        string           `bun:"product_no"`
+	NameTh           string           `bun:"name_th"`
+	NameEn           string           `bun:"name_en"`
+	CategoryNameTh   string           `bun:"category_name_th"`
+	CategoryNameEn   string           `bun:"category_name_en"`
+	Price            decimal.Decimal  `bun:"price"`
+	IsActive         bool             `bun:"is_active"`
+	TaxClass         string           `bun:"tax_class"`
+	PriceIncludesVAT bool             `bun:"price_includes_vat"`
+	Description      string           `bun:"description"`
+	Material         string           `bun:"material"`
+	Dimensions       string           `bun:"dimensions"`
+	Weight           *decimal.Decimal `bun:"weight"`
+	CareInstructions string           `bun:"care_instructions"`
+	StockAmount      *int             `bun:"stock_amount"`
+	Remaining        *int             `bun:"remaining"`
+}
+
+func (r *productExportRow) cells() []string {
+	weight := ""
+	if r.Weight != nil {
+		weight = r.Weight.String()
+	}
+	stockAmount, remaining := "", ""
+	if r.StockAmount != nil {
+		stockAmount = strconv.Itoa(*r.StockAmount)
+	}
+	if r.Remaining != nil {
+		remaining = strconv.Itoa(*r.Remaining)
+	}
+	return []string{
+		r.ProductNo,
+		r.NameTh,
+		r.NameEn,
+		r.CategoryNameTh,
+		r.CategoryNameEn,
+		r.Price.StringFixed(2),
+		strconv.FormatBool(r.IsActive),
+		r.TaxClass,
+		strconv.FormatBool(r.PriceIncludesVAT),
+		r.Description,
+		r.Material,
+		r.Dimensions,
+		weight,
+		r.CareInstructions,
+		stockAmount,
+		remaining,
+	}
+}
+
+// ExportProductsService writes every product in the import layout and
+// returns the file with its name.
+func (s *Service) ExportProductsService(ctx context.Context, format string) ([]byte, string, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`products.svc.export.start`)
+
+	rows := make([]*productExportRow, 0)
+	if err := s.bunDB.DB().NewSelect().
+		TableExpr("products AS p").
+		Join("LEFT JOIN categories AS c ON c.id = p.category_id").
+		Join("LEFT JOIN LATERAL (SELECT * FROM product_details AS pd WHERE pd.product_id = p.id LIMIT 1) AS pd ON true").
+		Join("LEFT JOIN LATERAL (SELECT * FROM product_stocks AS ps WHERE ps.product_id = p.id AND ps.deleted_at IS NULL LIMIT 1) AS ps ON true").
+		ColumnExpr("p.product_no, p.name_th, p.name_en, p.price, p.is_active, p.tax_class, p.price_includes_vat").
+		ColumnExpr("COALESCE(c.name_th, '') AS category_name_th").
+		ColumnExpr("COALESCE(c.name_en, '') AS category_name_en").
+		ColumnExpr("COALESCE(pd.description, '') AS description").
+		ColumnExpr("COALESCE(pd.material, '') AS material").
+		ColumnExpr("COALESCE(pd.dimensions, '') AS dimensions").
+		ColumnExpr("pd.weight AS weight").
+		ColumnExpr("COALESCE(pd.care_instructions, '') AS care_instructions").
+		ColumnExpr("ps.stock_amount AS stock_amount").
+		ColumnExpr("ps.remaining AS remaining").
+		Where("p.deleted_at IS NULL").
+		OrderExpr("p.product_no ASC").
+		Scan(ctx, &rows); err != nil {
+		return nil, "", err
+	}
+
+	fileName := fmt.Sprintf("products-%s.%s", time.Now().Format("20060102-150405"), format)
+	var buf bytes.Buffer
+	switch format {
+	case ProductBulkFormatCSV:
+		if err := writeProductExportCSV(&buf, rows); err != nil {
+			return nil, "", err
+		}
+	case ProductBulkFormatXLSX:
+		if err := writeProductExportXLSX(&buf, rows); err != nil {
+			return nil, "", err
+		}
+	default:
+		return nil, "", errors.New("unsupported export format")
+	}
+
+	span.AddEvent(`products.svc.export.success`)
+	return buf.Bytes(), fileName, nil
+}
+
+func writeProductExportCSV(w io.Writer, rows []*productExportRow) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(productBulkColumns); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := writer.Write(row.cells()); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func writeProductExportXLSX(w io.Writer, rows []*productExportRow) error {
+	file := excelize.NewFile()
+	defer file.Close()
+	if err := file.SetSheetName(file.GetSheetName(0), productBulkSheetName); err != nil {
+		return err
+	}
+
+	writeRow := func(index int, values []string) error {
+		cell, err := excelize.CoordinatesToCellName(1, index)
+		if err != nil {
+			return err
+		}
+		row := make([]any, len(values))
+		for i, value := range values {
+			row[i] = value
+		}
+		return file.SetSheetRow(productBulkSheetName, cell, &row)
+	}
+	if err := writeRow(1, productBulkColumns); err != nil {
+		return err
+	}
+	for index, row := range rows {
+		if err := writeRow(index+2, row.cells()); err != nil {
+			return err
+		}
+	}
+	_, err := file.WriteTo(w)
+	return err
+}
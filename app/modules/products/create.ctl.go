@@ -10,11 +10,16 @@ import (
 )
 
 type CreateProductController struct {
-	CategoryID string `json:"category_id"`
-	NameTh     string `json:"name_th"`
-	NameEn     string `json:"name_en"`
-	Price      string `json:"price"`
-	IsActive   *bool  `json:"is_active"`
+	CategoryID       string  `json:"category_id"`
+	NameTh           string  `json:"name_th"`
+	NameEn           string  `json:"name_en"`
+	Price            string  `json:"price"`
+	IsActive         *bool   `json:"is_active"`
+	TaxClass         string  `json:"tax_class"`
+	PriceIncludesVAT *bool   `json:"price_includes_vat"`
+	Status           *string `json:"status"`
+	PublishAt        *string `json:"publish_at"`
+	UnpublishAt      *string `json:"unpublish_at"`
 }
 
 func (c *Controller) CreateProductController(ctx *gin.Context) {
@@ -35,11 +40,16 @@ func (c *Controller) CreateProductController(ctx *gin.Context) {
 	}
 
 	if err := c.svc.CreateProductService(ctx.Request.Context(), &CreateProductService{
-		CategoryID: req.CategoryID,
-		NameTh:     req.NameTh,
-		NameEn:     req.NameEn,
-		Price:      priceDec,
-		IsActive:   req.IsActive,
+		CategoryID:       req.CategoryID,
+		NameTh:           req.NameTh,
+		NameEn:           req.NameEn,
+		Price:            priceDec,
+		IsActive:         req.IsActive,
+		TaxClass:         req.TaxClass,
+		PriceIncludesVAT: req.PriceIncludesVAT,
+		Status:           req.Status,
+		PublishAt:        req.PublishAt,
+		UnpublishAt:      req.UnpublishAt,
 	}); err != nil {
 		base.HandleError(ctx, err)
 		return
@@ -2,9 +2,11 @@ package products
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"phakram/app/modules/entities/ent"
 	"phakram/app/utils"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,11 +15,16 @@ import (
 )
 
 type CreateProductService struct {
-	CategoryID string          `json:"category_id"`
-	NameTh     string          `json:"name_th"`
-	NameEn     string          `json:"name_en"`
-	Price      decimal.Decimal `json:"price"`
-	IsActive   *bool           `json:"is_active"`
+	CategoryID       string          `json:"category_id"`
+	NameTh           string          `json:"name_th"`
+	NameEn           string          `json:"name_en"`
+	Price            decimal.Decimal `json:"price"`
+	IsActive         *bool           `json:"is_active"`
+	TaxClass         string          `json:"tax_class"`
+	PriceIncludesVAT *bool           `json:"price_includes_vat"`
+	Status           *string         `json:"status"`
+	PublishAt        *string         `json:"publish_at"`
+	UnpublishAt      *string         `json:"unpublish_at"`
 }
 
 func (s *Service) CreateProductService(ctx context.Context, req *CreateProductService) error {
@@ -25,34 +32,51 @@ func (s *Service) CreateProductService(ctx context.Context, req *CreateProductSe
 	span.AddEvent(`products.svc.create.start`)
 
 	id := uuid.New()
-	isActive := true
-	if req.IsActive != nil {
-		isActive = *req.IsActive
-	}
 
 	categoryID, err := uuid.Parse(req.CategoryID)
 	if err != nil {
 		return err
 	}
 
+	taxClass, err := parseTaxClass(req.TaxClass)
+	if err != nil {
+		return err
+	}
+	priceIncludesVAT := true
+	if req.PriceIncludesVAT != nil {
+		priceIncludesVAT = *req.PriceIncludesVAT
+	}
+
 	productNo, err := s.generateUniqueProductNo(ctx)
 	if err != nil {
 		return err
 	}
 
 	product := &ent.ProductEntity{
-		ID:         id,
-		CategoryID: categoryID,
-		NameTh:     req.NameTh,
-		NameEn:     req.NameEn,
-		ProductNo:  productNo,
-		Price:      req.Price,
-		IsActive:   isActive,
+		ID:               id,
+		CategoryID:       categoryID,
+		NameTh:           req.NameTh,
+		NameEn:           req.NameEn,
+		ProductNo:        productNo,
+		Price:            req.Price,
+		TaxClass:         taxClass,
+		PriceIncludesVAT: priceIncludesVAT,
+	}
+	if err := applyProductPublishing(product, &ProductPublishing{
+		Status:      req.Status,
+		PublishAt:   req.PublishAt,
+		UnpublishAt: req.UnpublishAt,
+		IsActive:    req.IsActive,
+	}, time.Now().UTC()); err != nil {
+		return err
 	}
 	err = s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
 		if _, err := tx.NewInsert().Model(product).Exec(ctx); err != nil {
 			return err
 		}
+		if err := recordProductPriceChangeInTx(ctx, tx, id, nil, product.Price, PriceChangeCreate, nil, nil, time.Now()); err != nil {
+			return err
+		}
 		auditLog := &ent.AuditLogEntity{
 			ID:           uuid.New(),
 			Action:       ent.AuditActionCreated,
@@ -87,6 +111,19 @@ func (s *Service) CreateProductService(ctx context.Context, req *CreateProductSe
 	return nil
 }
 
+func parseTaxClass(value string) (ent.TaxClassEnum, error) {
+	switch ent.TaxClassEnum(strings.ToLower(strings.TrimSpace(value))) {
+	case "", ent.TaxClassVAT:
+		return ent.TaxClassVAT, nil
+	case ent.TaxClassZeroRated:
+		return ent.TaxClassZeroRated, nil
+	case ent.TaxClassExempt:
+		return ent.TaxClassExempt, nil
+	default:
+		return "", errors.New("invalid tax class")
+	}
+}
+
 func (s *Service) generateUniqueProductNo(ctx context.Context) (string, error) {
 	for range 20 {
 		code, err := utils.GenerateProductNo()
@@ -13,16 +13,35 @@ type productImageControllerURI struct {
 	ID string `uri:"id"`
 }
 
-type deleteProductImageControllerURI struct {
+type productImageItemControllerURI struct {
 	ID      string `uri:"id"`
 	ImageID string `uri:"image_id"`
 }
 
+type listProductImagesControllerRequest struct {
+	VariantKey string `form:"variant_key"`
+}
+
 type uploadProductImageControllerRequest struct {
 	FileName   string `json:"file_name"`
 	FileType   string `json:"file_type"`
 	FileSize   int64  `json:"file_size"`
 	FileBase64 string `json:"file_base64"`
+	AltTextTh  string `json:"alt_text_th"`
+	AltTextEn  string `json:"alt_text_en"`
+	VariantKey string `json:"variant_key"`
+	IsPrimary  bool   `json:"is_primary"`
+}
+
+type updateProductImageControllerRequest struct {
+	AltTextTh  *string `json:"alt_text_th"`
+	AltTextEn  *string `json:"alt_text_en"`
+	VariantKey *string `json:"variant_key"`
+	IsPrimary  bool    `json:"is_primary"`
+}
+
+type reorderProductImagesControllerRequest struct {
+	ImageIDs []uuid.UUID `json:"image_ids" binding:"required"`
 }
 
 func (c *Controller) ListProductImagesController(ctx *gin.Context) {
@@ -41,7 +60,13 @@ func (c *Controller) ListProductImagesController(ctx *gin.Context) {
 		return
 	}
 
-	data, err := c.svc.ListProductImagesService(ctx.Request.Context(), productID)
+	var req listProductImagesControllerRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	data, err := c.svc.ListProductImagesService(ctx.Request.Context(), productID, req.VariantKey)
 	if err != nil {
 		base.HandleError(ctx, err)
 		return
@@ -78,6 +103,10 @@ func (c *Controller) UploadProductImageController(ctx *gin.Context) {
 		FileType:   req.FileType,
 		FileSize:   req.FileSize,
 		FileBase64: req.FileBase64,
+		AltTextTh:  req.AltTextTh,
+		AltTextEn:  req.AltTextEn,
+		VariantKey: req.VariantKey,
+		IsPrimary:  req.IsPrimary,
 	}
 
 	if err := c.svc.normalizeProductImageInput(serviceReq); err != nil {
@@ -99,7 +128,7 @@ func (c *Controller) DeleteProductImageController(ctx *gin.Context) {
 	span, _ := utils.LogSpanFromGin(ctx)
 	span.AddEvent(`products.ctl.images.delete.start`)
 
-	var uri deleteProductImageControllerURI
+	var uri productImageItemControllerURI
 	if err := ctx.ShouldBindUri(&uri); err != nil {
 		base.BadRequest(ctx, i18n.BadRequest, nil)
 		return
@@ -125,3 +154,78 @@ func (c *Controller) DeleteProductImageController(ctx *gin.Context) {
 	span.AddEvent(`products.ctl.images.delete.success`)
 	base.Success(ctx, nil)
 }
+
+func (c *Controller) UpdateProductImageController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`products.ctl.images.update.start`)
+
+	var uri productImageItemControllerURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	productID, err := uuid.Parse(uri.ID)
+	if err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	imageID, err := uuid.Parse(uri.ImageID)
+	if err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	var req updateProductImageControllerRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	item, err := c.svc.UpdateProductImageService(ctx.Request.Context(), productID, imageID, &UpdateProductImageServiceRequest{
+		AltTextTh:  req.AltTextTh,
+		AltTextEn:  req.AltTextEn,
+		VariantKey: req.VariantKey,
+		IsPrimary:  req.IsPrimary,
+	})
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`products.ctl.images.update.success`)
+	base.Success(ctx, item)
+}
+
+func (c *Controller) ReorderProductImagesController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`products.ctl.images.reorder.start`)
+
+	var uri productImageControllerURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	productID, err := uuid.Parse(uri.ID)
+	if err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	var req reorderProductImagesControllerRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	data, err := c.svc.ReorderProductImagesService(ctx.Request.Context(), productID, req.ImageIDs)
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`products.ctl.images.reorder.success`)
+	base.Success(ctx, data)
+}
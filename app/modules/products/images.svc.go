@@ -3,12 +3,12 @@ package products
 import (
 	"context"
 	"database/sql"
-	"encoding/base64"
 	"errors"
 	"fmt"
 	"log/slog"
 	"phakram/app/modules/entities/ent"
 	"phakram/app/utils"
+	"phakram/app/utils/imageproc"
 	"strings"
 	"time"
 
@@ -16,16 +16,35 @@ import (
 	"github.com/uptrace/bun"
 )
 
+const maxProductImageVariantKeyLength = 64
+
 type ProductImageItem struct {
-	ID         uuid.UUID `json:"id"`
-	FileID     uuid.UUID `json:"file_id"`
-	FileName   string    `json:"file_name"`
-	FilePath   string    `json:"file_path"`
-	FileSource string    `json:"file_source"`
-	FileType   string    `json:"file_type"`
-	FileSize   int64     `json:"file_size"`
-	CreatedAt  string    `json:"created_at"`
-	UpdatedAt  string    `json:"updated_at"`
+	ID         uuid.UUID           `json:"id"`
+	FileID     uuid.UUID           `json:"file_id"`
+	FileName   string              `json:"file_name"`
+	FilePath   string              `json:"file_path"`
+	FileSource string              `json:"file_source"`
+	FileType   string              `json:"file_type"`
+	FileSize   int64               `json:"file_size"`
+	SortOrder  int                 `json:"sort_order"`
+	IsPrimary  bool                `json:"is_primary"`
+	AltTextTh  string              `json:"alt_text_th"`
+	AltTextEn  string              `json:"alt_text_en"`
+	VariantKey *string             `json:"variant_key"`
+	Image      *imageproc.ImageSet `json:"image"`
+	CreatedAt  string              `json:"created_at"`
+	UpdatedAt  string              `json:"updated_at"`
+}
+
+// ProductImage is a product image as the catalogue shows it.
+type ProductImage struct {
+	ID         uuid.UUID           `json:"id"`
+	SortOrder  int                 `json:"sort_order"`
+	IsPrimary  bool                `json:"is_primary"`
+	AltTextTh  string              `json:"alt_text_th"`
+	AltTextEn  string              `json:"alt_text_en"`
+	VariantKey *string             `json:"variant_key"`
+	Image      *imageproc.ImageSet `json:"image"`
 }
 
 type UploadProductImageServiceRequest struct {
@@ -33,19 +52,39 @@ type UploadProductImageServiceRequest struct {
 	FileType   string
 	FileSize   int64
 	FileBase64 string
+	AltTextTh  string
+	AltTextEn  string
+	VariantKey string
+	IsPrimary  bool
+}
+
+// UpdateProductImageServiceRequest changes the metadata of an image; nil
+// fields are left as they are and an empty VariantKey detaches the image
+// from its variant.
+type UpdateProductImageServiceRequest struct {
+	AltTextTh  *string
+	AltTextEn  *string
+	VariantKey *string
+	IsPrimary  bool
 }
 
 type productImageRow struct {
-	ProductFileID uuid.UUID `bun:"product_file_id"`
-	StorageID     uuid.UUID `bun:"storage_id"`
-	FileName      string    `bun:"file_name"`
-	FilePath      string    `bun:"file_path"`
-	FileSource    string    `bun:"file_source"`
-	FileType      string    `bun:"file_type"`
-	FileSize      int64     `bun:"file_size"`
-	CreatedAt     time.Time `bun:"created_at"`
-	UpdatedAt     time.Time `bun:"updated_at"`
-	ProductID     uuid.UUID `bun:"product_id"`
+	ProductFileID uuid.UUID            `bun:"product_file_id"`
+	StorageID     uuid.UUID            `bun:"storage_id"`
+	FileName      string               `bun:"file_name"`
+	FilePath      string               `bun:"file_path"`
+	FileSource    string               `bun:"file_source"`
+	FileType      string               `bun:"file_type"`
+	FileSize      int64                `bun:"file_size"`
+	Renditions    imageproc.Renditions `bun:"renditions,type:jsonb"`
+	SortOrder     int                  `bun:"sort_order"`
+	IsPrimary     bool                 `bun:"is_primary"`
+	AltTextTh     string               `bun:"alt_text_th"`
+	AltTextEn     string               `bun:"alt_text_en"`
+	VariantKey    *string              `bun:"variant_key"`
+	CreatedAt     time.Time            `bun:"created_at"`
+	UpdatedAt     time.Time            `bun:"updated_at"`
+	ProductID     uuid.UUID            `bun:"product_id"`
 }
 
 func productImageFileSourceFromPath(path string) string {
@@ -59,6 +98,14 @@ func productImageFileSourceFromPath(path string) string {
 	return "STORAGE"
 }
 
+func (s *Service) productImageSet(filePath string, renditions imageproc.Renditions) *imageproc.ImageSet {
+	resolve := strings.TrimSpace
+	if s.railwayStorage != nil {
+		resolve = s.railwayStorage.ResolveObjectURL
+	}
+	return imageproc.NewImageSet(filePath, renditions, resolve)
+}
+
 func isProductFilesRelationMissing(err error) bool {
 	if err == nil {
 		return false
@@ -70,107 +117,181 @@ func isProductFilesRelationMissing(err error) bool {
 	return strings.Contains(message, `relation "product_files" does not exist`) || strings.Contains(message, "sqlstate 42p01")
 }
 
-func (s *Service) ListProductImagesService(ctx context.Context, productID uuid.UUID) ([]*ProductImageItem, error) {
-	if _, err := s.db.GetProductByID(ctx, productID); err != nil {
-		return nil, err
+// loadProductImageRows reads the active images of products in display
+// order. A variantKey limits them to that variant plus the shared images.
+func (s *Service) loadProductImageRows(ctx context.Context, productIDs []uuid.UUID, variantKey string) ([]*productImageRow, error) {
+	rows := make([]*productImageRow, 0)
+	if len(productIDs) == 0 {
+		return rows, nil
 	}
 
-	rows := make([]*productImageRow, 0)
-	err := s.bunDB.DB().NewSelect().
+	query := s.bunDB.DB().NewSelect().
 		TableExpr("product_files AS pf").
 		Join("JOIN storages AS st ON st.id = pf.file_id").
 		ColumnExpr("pf.id AS product_file_id").
 		ColumnExpr("pf.product_id AS product_id").
+		ColumnExpr("pf.sort_order AS sort_order").
+		ColumnExpr("pf.is_primary AS is_primary").
+		ColumnExpr("pf.alt_text_th AS alt_text_th").
+		ColumnExpr("pf.alt_text_en AS alt_text_en").
+		ColumnExpr("pf.variant_key AS variant_key").
 		ColumnExpr("st.id AS storage_id").
 		ColumnExpr("st.file_name AS file_name").
 		ColumnExpr("st.file_path AS file_path").
 		ColumnExpr("st.file_source AS file_source").
 		ColumnExpr("st.file_type AS file_type").
 		ColumnExpr("st.file_size AS file_size").
+		ColumnExpr("st.renditions AS renditions").
 		ColumnExpr("st.created_at AS created_at").
 		ColumnExpr("st.updated_at AS updated_at").
-		Where("pf.product_id = ?", productID).
+		Where("pf.product_id IN (?)", bun.In(productIDs)).
 		Where("pf.deleted_at IS NULL").
 		Where("st.deleted_at IS NULL").
-		Where("st.is_active = true").
-		OrderExpr("pf.created_at ASC").
+		Where("st.is_active = true")
+	if variantKey != "" {
+		query = query.Where("(pf.variant_key IS NULL OR pf.variant_key = ?)", variantKey)
+	}
+	err := query.
+		OrderExpr("pf.product_id ASC, pf.sort_order ASC, pf.created_at ASC").
 		Scan(ctx, &rows)
 	if err != nil {
 		if isProductFilesRelationMissing(err) {
-			return []*ProductImageItem{}, nil
+			return []*productImageRow{}, nil
 		}
 		return nil, err
 	}
+	return rows, nil
+}
+
+func (s *Service) toProductImageItem(row *productImageRow) *ProductImageItem {
+	imageSet := s.productImageSet(row.FilePath, row.Renditions)
+	resolvedSource := strings.TrimSpace(row.FileSource)
+	if resolvedSource == "" {
+		resolvedSource = productImageFileSourceFromPath(row.FilePath)
+	}
+	return &ProductImageItem{
+		ID:         row.StorageID,
+		FileID:     row.StorageID,
+		FileName:   row.FileName,
+		FilePath:   imageSet.Src,
+		FileSource: resolvedSource,
+		FileType:   row.FileType,
+		FileSize:   row.FileSize,
+		SortOrder:  row.SortOrder,
+		IsPrimary:  row.IsPrimary,
+		AltTextTh:  row.AltTextTh,
+		AltTextEn:  row.AltTextEn,
+		VariantKey: row.VariantKey,
+		Image:      imageSet,
+		CreatedAt:  row.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:  row.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
+
+func (s *Service) toProductImage(row *productImageRow) *ProductImage {
+	return &ProductImage{
+		ID:         row.StorageID,
+		SortOrder:  row.SortOrder,
+		IsPrimary:  row.IsPrimary,
+		AltTextTh:  row.AltTextTh,
+		AltTextEn:  row.AltTextEn,
+		VariantKey: row.VariantKey,
+		Image:      s.productImageSet(row.FilePath, row.Renditions),
+	}
+}
+
+func normalizeProductImageVariantKey(value string) (*string, error) {
+	trimmed := strings.TrimSpace(value)
+	if trimmed == "" {
+		return nil, nil
+	}
+	if len(trimmed) > maxProductImageVariantKeyLength {
+		return nil, errors.New("image variant key is too long")
+	}
+	return &trimmed, nil
+}
+
+func (s *Service) ListProductImagesService(ctx context.Context, productID uuid.UUID, variantKey string) ([]*ProductImageItem, error) {
+	if _, err := s.db.GetProductByID(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.loadProductImageRows(ctx, []uuid.UUID{productID}, strings.TrimSpace(variantKey))
+	if err != nil {
+		return nil, err
+	}
 
 	items := make([]*ProductImageItem, 0, len(rows))
 	for _, row := range rows {
 		if row == nil {
 			continue
 		}
-		resolvedPath := strings.TrimSpace(row.FilePath)
-		if s.railwayStorage != nil {
-			resolvedPath = s.railwayStorage.ResolveObjectURL(row.FilePath)
-		}
-		resolvedSource := strings.TrimSpace(row.FileSource)
-		if resolvedSource == "" {
-			resolvedSource = productImageFileSourceFromPath(row.FilePath)
-		}
-		items = append(items, &ProductImageItem{
-			ID:         row.StorageID,
-			FileID:     row.StorageID,
-			FileName:   row.FileName,
-			FilePath:   resolvedPath,
-			FileSource: resolvedSource,
-			FileType:   row.FileType,
-			FileSize:   row.FileSize,
-			CreatedAt:  row.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-			UpdatedAt:  row.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		})
+		items = append(items, s.toProductImageItem(row))
 	}
 
 	return items, nil
 }
 
+// loadProductImages returns the catalogue images of a product, primary
+// first and then in sort order.
+func (s *Service) loadProductImages(ctx context.Context, productID uuid.UUID) ([]*ProductImage, error) {
+	rows, err := s.loadProductImageRows(ctx, []uuid.UUID{productID}, "")
+	if err != nil {
+		return nil, err
+	}
+
+	images := make([]*ProductImage, 0, len(rows))
+	for _, row := range rows {
+		if row == nil {
+			continue
+		}
+		image := s.toProductImage(row)
+		if image.Image.Src == "" {
+			continue
+		}
+		if row.IsPrimary {
+			images = append([]*ProductImage{image}, images...)
+			continue
+		}
+		images = append(images, image)
+	}
+	return images, nil
+}
+
 func (s *Service) UploadProductImageService(ctx context.Context, productID uuid.UUID, req *UploadProductImageServiceRequest) (*ProductImageItem, error) {
 	if _, err := s.db.GetProductByID(ctx, productID); err != nil {
 		return nil, err
 	}
+	variantKey, err := normalizeProductImageVariantKey(req.VariantKey)
+	if err != nil {
+		return nil, err
+	}
 
 	resolvedFileName := strings.TrimSpace(req.FileName)
 	resolvedFilePath := ""
-	resolvedFileType := strings.TrimSpace(req.FileType)
-	resolvedFileSize := req.FileSize
+	resolvedFileType := ""
+	resolvedFileSize := int64(0)
+	var renditions imageproc.Renditions
 
 	buildInlineImage := func() error {
-		decodedImage, detectedMIME, err := decodeBase64Image(strings.TrimSpace(req.FileBase64))
+		decodedImage, _, err := decodeBase64Image(strings.TrimSpace(req.FileBase64))
 		if err != nil {
 			return err
 		}
-		if len(decodedImage) == 0 {
-			return errors.New("image is empty")
-		}
 		if len(decodedImage) > maxProductImageFileSizeBytes {
 			return errors.New("image exceeds 5 MB")
 		}
-		if !isAllowedProductImageMIME(detectedMIME) {
-			return fmt.Errorf("unsupported image type: %s", detectedMIME)
-		}
-
-		resolvedFilePath = strings.TrimSpace(req.FileBase64)
-		if !strings.HasPrefix(strings.ToLower(resolvedFilePath), "data:") {
-			resolvedFilePath = fmt.Sprintf("data:%s;base64,%s", detectedMIME, base64.StdEncoding.EncodeToString(decodedImage))
+		original, err := imageproc.ProcessOriginal(decodedImage)
+		if err != nil {
+			return err
 		}
 
+		resolvedFilePath = original.DataURL()
 		if resolvedFileName == "" {
-			resolvedFileName = fmt.Sprintf("product-%s%s", productID.String(), extensionByMIME(detectedMIME))
-		}
-		if resolvedFileType == "" {
-			resolvedFileType = detectedMIME
+			resolvedFileName = fmt.Sprintf("product-%s%s", productID.String(), original.Ext)
 		}
-		if resolvedFileSize <= 0 {
-			resolvedFileSize = int64(len(decodedImage))
-		}
-
+		resolvedFileType = original.MIMEType
+		resolvedFileSize = int64(len(original.Data))
 		return nil
 	}
 
@@ -185,12 +306,9 @@ func (s *Service) UploadProductImageService(ctx context.Context, productID uuid.
 			if resolvedFileName == "" {
 				resolvedFileName = uploaded.FileName
 			}
-			if resolvedFileType == "" {
-				resolvedFileType = uploaded.MIMEType
-			}
-			if resolvedFileSize <= 0 {
-				resolvedFileSize = uploaded.Size
-			}
+			resolvedFileType = uploaded.MIMEType
+			resolvedFileSize = uploaded.Size
+			renditions = uploaded.Renditions
 		}
 	} else {
 		if err := buildInlineImage(); err != nil {
@@ -213,18 +331,50 @@ func (s *Service) UploadProductImageService(ctx context.Context, productID uuid.
 		IsActive:      true,
 		RelatedEntity: ent.RelatedEntityProductFile,
 		UploadedBy:    nil,
+		Renditions:    renditions,
 		CreatedAt:     now,
 		UpdatedAt:     now,
 	}
 	productFile := &ent.ProductFileEntity{
-		ID:        productFileID,
-		ProductID: productID,
-		FileID:    storageID,
-		CreatedAt: now,
-		UpdatedAt: now,
+		ID:         productFileID,
+		ProductID:  productID,
+		FileID:     storageID,
+		IsPrimary:  req.IsPrimary,
+		AltTextTh:  strings.TrimSpace(req.AltTextTh),
+		AltTextEn:  strings.TrimSpace(req.AltTextEn),
+		VariantKey: variantKey,
+		CreatedAt:  now,
+		UpdatedAt:  now,
 	}
 
 	txErr := s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if err := lockProductImagesInTx(ctx, tx, productID); err != nil {
+			return err
+		}
+
+		var position struct {
+			NextSortOrder int  `bun:"next_sort_order"`
+			HasPrimary    bool `bun:"has_primary"`
+		}
+		if err := tx.NewSelect().
+			TableExpr("product_files").
+			ColumnExpr("COALESCE(MAX(sort_order) + 1, 0) AS next_sort_order").
+			ColumnExpr("COALESCE(BOOL_OR(is_primary), false) AS has_primary").
+			Where("product_id = ?", productID).
+			Where("deleted_at IS NULL").
+			Scan(ctx, &position); err != nil {
+			return err
+		}
+		productFile.SortOrder = position.NextSortOrder
+		if !position.HasPrimary {
+			productFile.IsPrimary = true
+		}
+		if productFile.IsPrimary && position.HasPrimary {
+			if err := clearProductPrimaryImageInTx(ctx, tx, productID, now); err != nil {
+				return err
+			}
+		}
+
 		if _, err := tx.NewInsert().Model(storage).Exec(ctx); err != nil {
 			return err
 		}
@@ -240,48 +390,32 @@ func (s *Service) UploadProductImageService(ctx context.Context, productID uuid.
 		return nil, txErr
 	}
 
-	resolvedPath := strings.TrimSpace(resolvedFilePath)
-	if s.railwayStorage != nil {
-		resolvedPath = s.railwayStorage.ResolveObjectURL(resolvedPath)
-	}
-
-	return &ProductImageItem{
-		ID:         storageID,
-		FileID:     storageID,
-		FileName:   resolvedFileName,
-		FilePath:   resolvedPath,
-		FileSource: productImageFileSourceFromPath(resolvedFilePath),
-		FileType:   resolvedFileType,
-		FileSize:   resolvedFileSize,
-		CreatedAt:  now.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt:  now.Format("2006-01-02T15:04:05Z07:00"),
-	}, nil
+	return s.toProductImageItem(&productImageRow{
+		ProductFileID: productFileID,
+		StorageID:     storageID,
+		FileName:      resolvedFileName,
+		FilePath:      resolvedFilePath,
+		FileSource:    productImageFileSourceFromPath(resolvedFilePath),
+		FileType:      resolvedFileType,
+		FileSize:      resolvedFileSize,
+		Renditions:    renditions,
+		SortOrder:     productFile.SortOrder,
+		IsPrimary:     productFile.IsPrimary,
+		AltTextTh:     productFile.AltTextTh,
+		AltTextEn:     productFile.AltTextEn,
+		VariantKey:    productFile.VariantKey,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+		ProductID:     productID,
+	}), nil
 }
 
-func (s *Service) loadProductPrimaryImageMap(ctx context.Context, productIDs []uuid.UUID) (map[uuid.UUID]string, error) {
-	imageMap := make(map[uuid.UUID]string)
-	if len(productIDs) == 0 {
-		return imageMap, nil
-	}
-
-	rows := make([]*productImageRow, 0)
-	err := s.bunDB.DB().NewSelect().
-		TableExpr("product_files AS pf").
-		Join("JOIN storages AS st ON st.id = pf.file_id").
-		ColumnExpr("pf.id AS product_file_id").
-		ColumnExpr("pf.product_id AS product_id").
-		ColumnExpr("st.file_path AS file_path").
-		ColumnExpr("pf.created_at AS created_at").
-		Where("pf.product_id IN (?)", bun.In(productIDs)).
-		Where("pf.deleted_at IS NULL").
-		Where("st.deleted_at IS NULL").
-		Where("st.is_active = true").
-		OrderExpr("pf.product_id ASC, pf.created_at ASC").
-		Scan(ctx, &rows)
+// loadProductPrimaryImageMap returns the primary image of each product,
+// falling back to the first image for products without one.
+func (s *Service) loadProductPrimaryImageMap(ctx context.Context, productIDs []uuid.UUID) (map[uuid.UUID]*ProductImage, error) {
+	imageMap := make(map[uuid.UUID]*ProductImage)
+	rows, err := s.loadProductImageRows(ctx, productIDs, "")
 	if err != nil {
-		if isProductFilesRelationMissing(err) {
-			return imageMap, nil
-		}
 		return nil, err
 	}
 
@@ -289,41 +423,18 @@ func (s *Service) loadProductPrimaryImageMap(ctx context.Context, productIDs []u
 		if row == nil {
 			continue
 		}
-		if _, exists := imageMap[row.ProductID]; exists {
+		if current, exists := imageMap[row.ProductID]; exists && (current.IsPrimary || !row.IsPrimary) {
 			continue
 		}
-		resolved := strings.TrimSpace(row.FilePath)
-		if s.railwayStorage != nil {
-			resolved = s.railwayStorage.ResolveObjectURL(row.FilePath)
-		}
-		if resolved != "" {
-			imageMap[row.ProductID] = resolved
+		image := s.toProductImage(row)
+		if image.Image.Src != "" {
+			imageMap[row.ProductID] = image
 		}
 	}
 
 	return imageMap, nil
 }
 
-func (s *Service) loadProductImageURLs(ctx context.Context, productID uuid.UUID) ([]string, error) {
-	rows, err := s.ListProductImagesService(ctx, productID)
-	if err != nil {
-		return nil, err
-	}
-
-	urls := make([]string, 0, len(rows))
-	for _, row := range rows {
-		if row == nil {
-			continue
-		}
-		path := strings.TrimSpace(row.FilePath)
-		if path == "" {
-			continue
-		}
-		urls = append(urls, path)
-	}
-	return urls, nil
-}
-
 func (s *Service) DeleteProductImageService(ctx context.Context, productID uuid.UUID, imageID uuid.UUID) error {
 	span, log := utils.LogSpanFromContext(ctx)
 	span.AddEvent(`products.svc.images.delete.start`)
@@ -340,6 +451,8 @@ func (s *Service) DeleteProductImageService(ctx context.Context, productID uuid.
 		ColumnExpr("pf.product_id AS product_id").
 		ColumnExpr("st.id AS storage_id").
 		ColumnExpr("st.file_path AS file_path").
+		ColumnExpr("st.renditions AS renditions").
+		ColumnExpr("pf.is_primary AS is_primary").
 		Where("pf.product_id = ?", productID).
 		Where("pf.file_id = ?", imageID).
 		Where("pf.deleted_at IS NULL").
@@ -358,14 +471,23 @@ func (s *Service) DeleteProductImageService(ctx context.Context, productID uuid.
 
 	now := time.Now()
 	err = s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if err := lockProductImagesInTx(ctx, tx, productID); err != nil {
+			return err
+		}
 		if _, err := tx.NewUpdate().
 			Model(&ent.ProductFileEntity{}).
+			Set("is_primary = false").
 			Set("deleted_at = ?", now).
 			Set("updated_at = ?", now).
 			Where("id = ?", row.ProductFileID).
 			Exec(ctx); err != nil {
 			return err
 		}
+		if row.IsPrimary {
+			if err := promoteProductPrimaryImageInTx(ctx, tx, productID, now); err != nil {
+				return err
+			}
+		}
 
 		if _, err := tx.NewUpdate().
 			Model(&ent.StorageEntity{}).
@@ -387,8 +509,10 @@ func (s *Service) DeleteProductImageService(ctx context.Context, productID uuid.
 	}
 
 	if s.railwayStorage != nil {
-		if removeErr := s.railwayStorage.DeleteProductImageObject(ctx, strings.TrimSpace(row.FilePath)); removeErr != nil {
-			log.With(slog.Any("product_id", productID), slog.Any("image_id", imageID)).Errf("products.svc.images.delete.railway: %s", removeErr)
+		for _, path := range append([]string{row.FilePath}, row.Renditions.Paths()...) {
+			if removeErr := s.railwayStorage.DeleteProductImageObject(ctx, strings.TrimSpace(path)); removeErr != nil {
+				log.With(slog.Any("product_id", productID), slog.Any("image_id", imageID)).Errf("products.svc.images.delete.railway: %s", removeErr)
+			}
 		}
 	}
 
@@ -396,6 +520,193 @@ func (s *Service) DeleteProductImageService(ctx context.Context, productID uuid.
 	return nil
 }
 
+// lockProductImagesInTx serialises changes to the images of a product so
+// sort orders and the primary flag stay consistent.
+func lockProductImagesInTx(ctx context.Context, tx bun.Tx, productID uuid.UUID) error {
+	var lockedID uuid.UUID
+	return tx.NewSelect().
+		Model((*ent.ProductEntity)(nil)).
+		Column("id").
+		Where("id = ?", productID).
+		For("UPDATE").
+		Scan(ctx, &lockedID)
+}
+
+func clearProductPrimaryImageInTx(ctx context.Context, tx bun.Tx, productID uuid.UUID, now time.Time) error {
+	_, err := tx.NewUpdate().
+		Model((*ent.ProductFileEntity)(nil)).
+		Set("is_primary = false").
+		Set("updated_at = ?", now).
+		Where("product_id = ?", productID).
+		Where("is_primary = true").
+		Where("deleted_at IS NULL").
+		Exec(ctx)
+	return err
+}
+
+// promoteProductPrimaryImageInTx makes the first remaining image primary
+// after the primary one was removed.
+func promoteProductPrimaryImageInTx(ctx context.Context, tx bun.Tx, productID uuid.UUID, now time.Time) error {
+	_, err := tx.NewUpdate().
+		Model((*ent.ProductFileEntity)(nil)).
+		Set("is_primary = true").
+		Set("updated_at = ?", now).
+		Where("id = (?)", tx.NewSelect().
+			TableExpr("product_files").
+			Column("id").
+			Where("product_id = ?", productID).
+			Where("deleted_at IS NULL").
+			OrderExpr("sort_order ASC, created_at ASC").
+			Limit(1)).
+		Exec(ctx)
+	return err
+}
+
+func (s *Service) findProductImageFile(ctx context.Context, db bun.IDB, productID uuid.UUID, imageID uuid.UUID) (*ent.ProductFileEntity, error) {
+	file := new(ent.ProductFileEntity)
+	if err := db.NewSelect().
+		Model(file).
+		Where("product_id = ?", productID).
+		Where("file_id = ?", imageID).
+		Limit(1).
+		Scan(ctx); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("product image not found")
+		}
+		return nil, err
+	}
+	return file, nil
+}
+
+// UpdateProductImageService edits the alt text and variant of an image, and
+// makes it the primary image when asked. The primary image cannot be unset
+// directly; another image has to be made primary instead.
+func (s *Service) UpdateProductImageService(ctx context.Context, productID uuid.UUID, imageID uuid.UUID, req *UpdateProductImageServiceRequest) (*ProductImageItem, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`products.svc.images.update.start`)
+
+	if _, err := s.db.GetProductByID(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	var variantKey *string
+	if req.VariantKey != nil {
+		normalized, err := normalizeProductImageVariantKey(*req.VariantKey)
+		if err != nil {
+			return nil, err
+		}
+		variantKey = normalized
+	}
+
+	now := time.Now()
+	err := s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if err := lockProductImagesInTx(ctx, tx, productID); err != nil {
+			return err
+		}
+		file, err := s.findProductImageFile(ctx, tx, productID, imageID)
+		if err != nil {
+			return err
+		}
+
+		if req.AltTextTh != nil {
+			file.AltTextTh = strings.TrimSpace(*req.AltTextTh)
+		}
+		if req.AltTextEn != nil {
+			file.AltTextEn = strings.TrimSpace(*req.AltTextEn)
+		}
+		if req.VariantKey != nil {
+			file.VariantKey = variantKey
+		}
+		if req.IsPrimary && !file.IsPrimary {
+			if err := clearProductPrimaryImageInTx(ctx, tx, productID, now); err != nil {
+				return err
+			}
+			file.IsPrimary = true
+		}
+		file.UpdatedAt = now
+
+		_, err = tx.NewUpdate().
+			Model(file).
+			Column("alt_text_th", "alt_text_en", "variant_key", "is_primary", "updated_at").
+			WherePK().
+			Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.ListProductImagesService(ctx, productID, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if item.ID == imageID {
+			span.AddEvent(`products.svc.images.update.success`)
+			return item, nil
+		}
+	}
+	return nil, errors.New("product image not found")
+}
+
+// ReorderProductImagesService sets the display order of a product's images.
+// imageIDs must list every active image of the product exactly once.
+func (s *Service) ReorderProductImagesService(ctx context.Context, productID uuid.UUID, imageIDs []uuid.UUID) ([]*ProductImageItem, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`products.svc.images.reorder.start`)
+
+	if _, err := s.db.GetProductByID(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	positions := make(map[uuid.UUID]int, len(imageIDs))
+	for index, imageID := range imageIDs {
+		if _, exists := positions[imageID]; exists {
+			return nil, errors.New("image order must list every product image once")
+		}
+		positions[imageID] = index
+	}
+
+	now := time.Now()
+	err := s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if err := lockProductImagesInTx(ctx, tx, productID); err != nil {
+			return err
+		}
+
+		files := make([]*ent.ProductFileEntity, 0)
+		if err := tx.NewSelect().
+			Model(&files).
+			Where("product_id = ?", productID).
+			Scan(ctx); err != nil {
+			return err
+		}
+		if len(files) != len(positions) {
+			return errors.New("image order must list every product image once")
+		}
+		for _, file := range files {
+			position, ok := positions[file.FileID]
+			if !ok {
+				return errors.New("image order must list every product image once")
+			}
+			if _, err := tx.NewUpdate().
+				Model((*ent.ProductFileEntity)(nil)).
+				Set("sort_order = ?", position).
+				Set("updated_at = ?", now).
+				Where("id = ?", file.ID).
+				Exec(ctx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	span.AddEvent(`products.svc.images.reorder.success`)
+	return s.ListProductImagesService(ctx, productID, "")
+}
+
 func (s *Service) normalizeProductImageInput(req *UploadProductImageServiceRequest) error {
 	if req == nil {
 		return errors.New("image payload is required")
@@ -2,8 +2,11 @@ package products
 
 import (
 	"log/slog"
+	"phakram/app/modules/auth"
 	"phakram/app/utils"
 	"phakram/app/utils/base"
+	"phakram/app/utils/flashsale"
+	"phakram/app/utils/pricelist"
 	"phakram/config/i18n"
 
 	"github.com/gin-gonic/gin"
@@ -15,18 +18,31 @@ type InfoProductControllerRequestUri struct {
 	ID string `uri:"id"`
 }
 
+type InfoProductControllerRequestQuery struct {
+	Preview bool `form:"preview"`
+}
+
 type InfoProductControllerResponses struct {
-	ID         uuid.UUID       `json:"id"`
-	CategoryID uuid.UUID       `json:"category_id"`
-	NameTh     string          `json:"name_th"`
-	NameEn     string          `json:"name_en"`
-	ProductNo  string          `json:"product_no"`
-	Price      decimal.Decimal `json:"price"`
-	ImageURL   string          `json:"image_url,omitempty"`
-	ImageURLs  []string        `json:"image_urls,omitempty"`
-	IsActive   bool            `json:"is_active"`
-	CreatedAt  string          `json:"created_at"`
-	UpdatedAt  string          `json:"updated_at"`
+	ID               uuid.UUID        `json:"id"`
+	CategoryID       uuid.UUID        `json:"category_id"`
+	NameTh           string           `json:"name_th"`
+	NameEn           string           `json:"name_en"`
+	ProductNo        string           `json:"product_no"`
+	Price            decimal.Decimal  `json:"price"`
+	ImageURL         string           `json:"image_url,omitempty"`
+	ImageURLs        []string         `json:"image_urls,omitempty"`
+	PrimaryImage     *ProductImage    `json:"primary_image,omitempty"`
+	Images           []*ProductImage  `json:"images,omitempty"`
+	IsActive         bool             `json:"is_active"`
+	TaxClass         string           `json:"tax_class"`
+	PriceIncludesVAT bool             `json:"price_includes_vat"`
+	Status           string           `json:"status"`
+	PublishAt        *string          `json:"publish_at"`
+	UnpublishAt      *string          `json:"unpublish_at"`
+	FlashSale        *flashsale.Offer `json:"flash_sale,omitempty"`
+	Pricing          *pricelist.Quote `json:"pricing"`
+	CreatedAt        string           `json:"created_at"`
+	UpdatedAt        string           `json:"updated_at"`
 }
 
 func (c *Controller) InfoController(ctx *gin.Context) {
@@ -47,7 +63,18 @@ func (c *Controller) InfoController(ctx *gin.Context) {
 		return
 	}
 
-	data, err := c.svc.InfoService(ctx, id)
+	var query InfoProductControllerRequestQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+	if query.Preview && !auth.GetIsAdmin(ctx) {
+		base.Forbidden(ctx, i18n.Forbidden, nil)
+		return
+	}
+
+	memberID, _ := auth.GetMemberID(ctx)
+	data, err := c.svc.InfoService(ctx, id, memberID, query.Preview)
 	if err != nil {
 		base.HandleError(ctx, err)
 		return
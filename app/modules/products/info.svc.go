@@ -2,28 +2,44 @@ package products
 
 import (
 	"context"
+	"database/sql"
 	"log/slog"
+	"phakram/app/modules/entities/ent"
 	"phakram/app/utils"
+	"phakram/app/utils/flashsale"
+	"phakram/app/utils/pricelist"
 
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 )
 
 type InfoProductServiceResponses struct {
-	ID         uuid.UUID       `json:"id"`
-	CategoryID uuid.UUID       `json:"category_id"`
-	NameTh     string          `json:"name_th"`
-	NameEn     string          `json:"name_en"`
-	ProductNo  string          `json:"product_no"`
-	Price      decimal.Decimal `json:"price"`
-	ImageURL   string          `json:"image_url,omitempty"`
-	ImageURLs  []string        `json:"image_urls,omitempty"`
-	IsActive   bool            `json:"is_active"`
-	CreatedAt  string          `json:"created_at"`
-	UpdatedAt  string          `json:"updated_at"`
+	ID               uuid.UUID        `json:"id"`
+	CategoryID       uuid.UUID        `json:"category_id"`
+	NameTh           string           `json:"name_th"`
+	NameEn           string           `json:"name_en"`
+	ProductNo        string           `json:"product_no"`
+	Price            decimal.Decimal  `json:"price"`
+	ImageURL         string           `json:"image_url,omitempty"`
+	ImageURLs        []string         `json:"image_urls,omitempty"`
+	PrimaryImage     *ProductImage    `json:"primary_image,omitempty"`
+	Images           []*ProductImage  `json:"images,omitempty"`
+	IsActive         bool             `json:"is_active"`
+	TaxClass         string           `json:"tax_class"`
+	PriceIncludesVAT bool             `json:"price_includes_vat"`
+	Status           string           `json:"status"`
+	PublishAt        *string          `json:"publish_at"`
+	UnpublishAt      *string          `json:"unpublish_at"`
+	FlashSale        *flashsale.Offer `json:"flash_sale,omitempty"`
+	Pricing          *pricelist.Quote `json:"pricing"`
+	CreatedAt        string           `json:"created_at"`
+	UpdatedAt        string           `json:"updated_at"`
 }
 
-func (s *Service) InfoService(ctx context.Context, id uuid.UUID) (*InfoProductServiceResponses, error) {
+// InfoService returns a product with the pricing of the calling member, or
+// the public pricing when memberID is uuid.Nil. Products that are not
+// published are only returned in an admin preview.
+func (s *Service) InfoService(ctx context.Context, id uuid.UUID, memberID uuid.UUID, preview bool) (*InfoProductServiceResponses, error) {
 	span, log := utils.LogSpanFromContext(ctx)
 	span.AddEvent(`products.svc.info.start`)
 
@@ -32,30 +48,65 @@ func (s *Service) InfoService(ctx context.Context, id uuid.UUID) (*InfoProductSe
 		log.With(slog.Any(`id`, id)).Errf(`internal: %s`, err)
 		return nil, err
 	}
+	if !preview && data.Status != ent.ProductStatusPublished {
+		return nil, sql.ErrNoRows
+	}
+
+	images, err := s.loadProductImages(ctx, id)
+	if err != nil {
+		log.With(slog.Any(`id`, id)).Errf(`internal: %s`, err)
+		return nil, err
+	}
+
+	offers, err := flashsale.LoadActive(ctx, s.bunDB.DB(), []uuid.UUID{id})
+	if err != nil {
+		log.With(slog.Any(`id`, id)).Errf(`internal: %s`, err)
+		return nil, err
+	}
 
-	imageURLs, err := s.loadProductImageURLs(ctx, id)
+	tier, err := pricelist.MemberTier(ctx, s.bunDB.DB(), memberID)
+	if err != nil {
+		log.With(slog.Any(`id`, id)).Errf(`internal: %s`, err)
+		return nil, err
+	}
+	priceLists, err := pricelist.Load(ctx, s.bunDB.DB(), tier.ID, []uuid.UUID{id})
 	if err != nil {
 		log.With(slog.Any(`id`, id)).Errf(`internal: %s`, err)
 		return nil, err
 	}
 
+	imageURLs := make([]string, 0, len(images))
+	for _, image := range images {
+		imageURLs = append(imageURLs, image.Image.Src)
+	}
 	primaryImageURL := ""
-	if len(imageURLs) > 0 {
+	var primaryImage *ProductImage
+	if len(images) > 0 {
 		primaryImageURL = imageURLs[0]
+		primaryImage = images[0]
 	}
 
 	resp := &InfoProductServiceResponses{
-		ID:         data.ID,
-		CategoryID: data.CategoryID,
-		NameTh:     data.NameTh,
-		NameEn:     data.NameEn,
-		ProductNo:  data.ProductNo,
-		Price:      data.Price,
-		ImageURL:   primaryImageURL,
-		ImageURLs:  imageURLs,
-		IsActive:   data.IsActive,
-		CreatedAt:  data.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt:  data.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		ID:               data.ID,
+		CategoryID:       data.CategoryID,
+		NameTh:           data.NameTh,
+		NameEn:           data.NameEn,
+		ProductNo:        data.ProductNo,
+		Price:            data.Price,
+		ImageURL:         primaryImageURL,
+		ImageURLs:        imageURLs,
+		PrimaryImage:     primaryImage,
+		Images:           images,
+		IsActive:         data.IsActive,
+		TaxClass:         string(data.TaxClass),
+		PriceIncludesVAT: data.PriceIncludesVAT,
+		Status:           string(data.Status),
+		PublishAt:        formatProductTime(data.PublishAt),
+		UnpublishAt:      formatProductTime(data.UnpublishAt),
+		FlashSale:        offers[id],
+		Pricing:          priceLists[id].Quote(data.Price, tier),
+		CreatedAt:        data.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:        data.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
 	}
 	span.AddEvent(`products.svc.info.success`)
 	return resp, nil
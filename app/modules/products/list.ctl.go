@@ -2,9 +2,14 @@ package products
 
 import (
 	"log/slog"
+	"phakram/app/modules/auth"
 	"phakram/app/utils"
+	"phakram/app/utils/attributes"
 	"phakram/app/utils/base"
+	"phakram/app/utils/flashsale"
+	"phakram/app/utils/pricelist"
 	"phakram/config/i18n"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -13,19 +18,29 @@ import (
 
 type ListProductControllerRequest struct {
 	base.RequestPaginate
+	Preview bool   `form:"preview"`
+	Status  string `form:"status"`
 }
 
 type ListProductControllerResponses struct {
-	ID         uuid.UUID       `json:"id"`
-	CategoryID uuid.UUID       `json:"category_id"`
-	NameTh     string          `json:"name_th"`
-	NameEn     string          `json:"name_en"`
-	ProductNo  string          `json:"product_no"`
-	Price      decimal.Decimal `json:"price"`
-	ImageURL   string          `json:"image_url,omitempty"`
-	IsActive   bool            `json:"is_active"`
-	CreatedAt  string          `json:"created_at"`
-	UpdatedAt  string          `json:"updated_at"`
+	ID               uuid.UUID        `json:"id"`
+	CategoryID       uuid.UUID        `json:"category_id"`
+	NameTh           string           `json:"name_th"`
+	NameEn           string           `json:"name_en"`
+	ProductNo        string           `json:"product_no"`
+	Price            decimal.Decimal  `json:"price"`
+	ImageURL         string           `json:"image_url,omitempty"`
+	PrimaryImage     *ProductImage    `json:"primary_image,omitempty"`
+	IsActive         bool             `json:"is_active"`
+	TaxClass         string           `json:"tax_class"`
+	PriceIncludesVAT bool             `json:"price_includes_vat"`
+	Status           string           `json:"status"`
+	PublishAt        *string          `json:"publish_at"`
+	UnpublishAt      *string          `json:"unpublish_at"`
+	FlashSale        *flashsale.Offer `json:"flash_sale,omitempty"`
+	Pricing          *pricelist.Quote `json:"pricing"`
+	CreatedAt        string           `json:"created_at"`
+	UpdatedAt        string           `json:"updated_at"`
 }
 
 func (c *Controller) ProductsList(ctx *gin.Context) {
@@ -39,8 +54,32 @@ func (c *Controller) ProductsList(ctx *gin.Context) {
 	}
 	span.AddEvent(`products.ctl.list.request`)
 
+	if req.Preview && !auth.GetIsAdmin(ctx) {
+		base.Forbidden(ctx, i18n.Forbidden, nil)
+		return
+	}
+	if req.Status != "" {
+		if _, err := parseProductStatus(req.Status); err != nil {
+			base.BadRequest(ctx, i18n.BadRequest, nil)
+			return
+		}
+	}
+
+	// Attribute filters come as attr[<code>]=value, e.g. attr[color]=red,blue
+	// or attr[width]=10..20.
+	filters, err := attributes.ParseFilters(ctx.QueryMap("attr"))
+	if err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	memberID, _ := auth.GetMemberID(ctx)
 	data, page, err := c.svc.ListService(ctx, &ListProductServiceRequest{
 		RequestPaginate: req.RequestPaginate,
+		MemberID:        memberID,
+		Preview:         req.Preview,
+		Status:          strings.ToLower(strings.TrimSpace(req.Status)),
+		Attributes:      filters,
 	})
 	if err != nil {
 		base.HandleError(ctx, err)
@@ -4,42 +4,79 @@ import (
 	"context"
 	"log/slog"
 	entitiesdto "phakram/app/modules/entities/dto"
+	"phakram/app/modules/entities/ent"
 	"phakram/app/utils"
+	"phakram/app/utils/attributes"
 	"phakram/app/utils/base"
+	"phakram/app/utils/flashsale"
+	"phakram/app/utils/pricelist"
 
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 )
 
+// ListProductServiceRequest lists published products. Preview is for
+// admins and includes products in every state, optionally narrowed by
+// Status.
 type ListProductServiceRequest struct {
 	base.RequestPaginate
+	MemberID   uuid.UUID
+	Preview    bool
+	Status     string
+	Attributes []*attributes.Filter
 }
 
 type ListProductServiceResponses struct {
-	ID         uuid.UUID       `json:"id"`
-	CategoryID uuid.UUID       `json:"category_id"`
-	NameTh     string          `json:"name_th"`
-	NameEn     string          `json:"name_en"`
-	ProductNo  string          `json:"product_no"`
-	Price      decimal.Decimal `json:"price"`
-	ImageURL   string          `json:"image_url,omitempty"`
-	IsActive   bool            `json:"is_active"`
-	CreatedAt  string          `json:"created_at"`
-	UpdatedAt  string          `json:"updated_at"`
+	ID               uuid.UUID        `json:"id"`
+	CategoryID       uuid.UUID        `json:"category_id"`
+	NameTh           string           `json:"name_th"`
+	NameEn           string           `json:"name_en"`
+	ProductNo        string           `json:"product_no"`
+	Price            decimal.Decimal  `json:"price"`
+	ImageURL         string           `json:"image_url,omitempty"`
+	PrimaryImage     *ProductImage    `json:"primary_image,omitempty"`
+	IsActive         bool             `json:"is_active"`
+	TaxClass         string           `json:"tax_class"`
+	PriceIncludesVAT bool             `json:"price_includes_vat"`
+	Status           string           `json:"status"`
+	PublishAt        *string          `json:"publish_at"`
+	UnpublishAt      *string          `json:"unpublish_at"`
+	FlashSale        *flashsale.Offer `json:"flash_sale,omitempty"`
+	Pricing          *pricelist.Quote `json:"pricing"`
+	CreatedAt        string           `json:"created_at"`
+	UpdatedAt        string           `json:"updated_at"`
 }
 
 func (s *Service) ListService(ctx context.Context, req *ListProductServiceRequest) ([]*ListProductServiceResponses, *base.ResponsePaginate, error) {
 	span, log := utils.LogSpanFromContext(ctx)
 	span.AddEvent(`products.svc.list.start`)
 
+	status := string(ent.ProductStatusPublished)
+	if req.Preview {
+		status = req.Status
+	}
 	data, page, err := s.db.ListProducts(ctx, &entitiesdto.ListProductsRequest{
 		RequestPaginate: req.RequestPaginate,
+		Status:          status,
+		Attributes:      req.Attributes,
 	})
 	if err != nil {
 		log.With(slog.Any(`body`, req)).Errf(`internal: %s`, err)
 		return nil, nil, err
 	}
 
+	response, err := s.toProductListItems(ctx, data, req.MemberID)
+	if err != nil {
+		log.With(slog.Any(`body`, req)).Errf(`internal: %s`, err)
+		return nil, nil, err
+	}
+	span.AddEvent(`products.svc.list.copy`)
+	return response, page, nil
+}
+
+// toProductListItems turns products into list items with their primary
+// image, flash sale and the member's tier pricing.
+func (s *Service) toProductListItems(ctx context.Context, data []*ent.ProductEntity, memberID uuid.UUID) ([]*ListProductServiceResponses, error) {
 	productIDs := make([]uuid.UUID, 0, len(data))
 	for _, item := range data {
 		if item == nil {
@@ -50,26 +87,51 @@ func (s *Service) ListService(ctx context.Context, req *ListProductServiceReques
 
 	imageMap, err := s.loadProductPrimaryImageMap(ctx, productIDs)
 	if err != nil {
-		log.With(slog.Any(`body`, req)).Errf(`internal: %s`, err)
-		return nil, nil, err
+		return nil, err
+	}
+
+	offers, err := flashsale.LoadActive(ctx, s.bunDB.DB(), productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	tier, err := pricelist.MemberTier(ctx, s.bunDB.DB(), memberID)
+	if err != nil {
+		return nil, err
+	}
+	priceLists, err := pricelist.Load(ctx, s.bunDB.DB(), tier.ID, productIDs)
+	if err != nil {
+		return nil, err
 	}
 
 	var response []*ListProductServiceResponses
 	for _, item := range data {
+		if item == nil {
+			continue
+		}
 		temp := &ListProductServiceResponses{
-			ID:         item.ID,
-			CategoryID: item.CategoryID,
-			NameTh:     item.NameTh,
-			NameEn:     item.NameEn,
-			ProductNo:  item.ProductNo,
-			Price:      item.Price,
-			ImageURL:   imageMap[item.ID],
-			IsActive:   item.IsActive,
-			CreatedAt:  item.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-			UpdatedAt:  item.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+			ID:               item.ID,
+			CategoryID:       item.CategoryID,
+			NameTh:           item.NameTh,
+			NameEn:           item.NameEn,
+			ProductNo:        item.ProductNo,
+			Price:            item.Price,
+			PrimaryImage:     imageMap[item.ID],
+			IsActive:         item.IsActive,
+			TaxClass:         string(item.TaxClass),
+			PriceIncludesVAT: item.PriceIncludesVAT,
+			Status:           string(item.Status),
+			PublishAt:        formatProductTime(item.PublishAt),
+			UnpublishAt:      formatProductTime(item.UnpublishAt),
+			FlashSale:        offers[item.ID],
+			Pricing:          priceLists[item.ID].Quote(item.Price, tier),
+			CreatedAt:        item.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+			UpdatedAt:        item.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		}
+		if temp.PrimaryImage != nil {
+			temp.ImageURL = temp.PrimaryImage.Image.Src
 		}
 		response = append(response, temp)
 	}
-	span.AddEvent(`products.svc.list.copy`)
-	return response, page, nil
+	return response, nil
 }
@@ -0,0 +1,141 @@
+package products
+
+import (
+	"phakram/app/modules/auth"
+	"phakram/app/utils"
+	"phakram/app/utils/base"
+	"phakram/config/i18n"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type productPriceScheduleControllerURI struct {
+	ID         string `uri:"id"`
+	ScheduleID string `uri:"schedule_id"`
+}
+
+type ProductPriceTimelineControllerRequest struct {
+	From *string `form:"from"`
+	To   *string `form:"to"`
+}
+
+type CreateProductPriceScheduleControllerRequest struct {
+	Price       string `json:"price"`
+	EffectiveAt string `json:"effective_at"`
+}
+
+// PublicPriceTimelineController returns the last 30 days of a published
+// product's price with the lowest price in that window.
+func (c *Controller) PublicPriceTimelineController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`products.ctl.price_timeline.public.start`)
+
+	var uri productPriceControllerURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+	productID, err := uuid.Parse(uri.ID)
+	if err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	data, err := c.svc.ProductPriceTimelineService(ctx.Request.Context(), productID, &ProductPriceTimelineServiceRequest{})
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`products.ctl.price_timeline.public.success`)
+	base.Success(ctx, data)
+}
+
+func (c *Controller) PriceTimelineController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`products.ctl.price_timeline.start`)
+
+	productID, ok := c.parseProductPriceRequest(ctx)
+	if !ok {
+		return
+	}
+
+	var req ProductPriceTimelineControllerRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	data, err := c.svc.ProductPriceTimelineService(ctx.Request.Context(), productID, &ProductPriceTimelineServiceRequest{
+		From:  req.From,
+		To:    req.To,
+		Admin: true,
+	})
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`products.ctl.price_timeline.success`)
+	base.Success(ctx, data)
+}
+
+func (c *Controller) CreatePriceScheduleController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`products.ctl.price_schedule.create.start`)
+
+	productID, ok := c.parseProductPriceRequest(ctx)
+	if !ok {
+		return
+	}
+
+	var req CreateProductPriceScheduleControllerRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	memberID, _ := auth.GetMemberID(ctx)
+	data, err := c.svc.CreateProductPriceScheduleService(ctx.Request.Context(), productID, &CreateProductPriceScheduleServiceRequest{
+		Price:       req.Price,
+		EffectiveAt: req.EffectiveAt,
+		CreatedBy:   memberID,
+	})
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`products.ctl.price_schedule.create.success`)
+	base.Success(ctx, data)
+}
+
+func (c *Controller) CancelPriceScheduleController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`products.ctl.price_schedule.cancel.start`)
+
+	productID, ok := c.parseProductPriceRequest(ctx)
+	if !ok {
+		return
+	}
+
+	var uri productPriceScheduleControllerURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+	scheduleID, err := uuid.Parse(uri.ScheduleID)
+	if err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	if err := c.svc.CancelProductPriceScheduleService(ctx.Request.Context(), productID, scheduleID); err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`products.ctl.price_schedule.cancel.success`)
+	base.Success(ctx, nil)
+}
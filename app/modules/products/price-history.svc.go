@@ -0,0 +1,445 @@
+package products
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"phakram/app/modules/entities/ent"
+	"phakram/app/utils"
+	"phakram/app/utils/flashsale"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/uptrace/bun"
+)
+
+// Where a base price change came from, saved on product_price_history.
+const (
+	PriceChangeInitial  = "initial"
+	PriceChangeCreate   = "create"
+	PriceChangeUpdate   = "update"
+	PriceChangeImport   = "import"
+	PriceChangeSchedule = "schedule"
+)
+
+const (
+	PriceSchedulePending   = "pending"
+	PriceScheduleApplied   = "applied"
+	PriceScheduleCancelled = "cancelled"
+)
+
+// lowestPriceWindow is the look-back for the "lowest price in the last 30
+// days" disclosure shown next to a discount.
+const lowestPriceWindow = 30 * 24 * time.Hour
+
+type productPriceHistoryRecord struct {
+	bun.BaseModel `bun:"table:product_price_history"`
+
+	ID            uuid.UUID        `bun:"id,pk,type:uuid"`
+	ProductID     uuid.UUID        `bun:"product_id,type:uuid"`
+	Price         decimal.Decimal  `bun:"price"`
+	PreviousPrice *decimal.Decimal `bun:"previous_price"`
+	Source        string           `bun:"source"`
+	ScheduleID    *uuid.UUID       `bun:"schedule_id,type:uuid"`
+	ChangedBy     *uuid.UUID       `bun:"changed_by,type:uuid"`
+	EffectiveAt   time.Time        `bun:"effective_at"`
+	CreatedAt     time.Time        `bun:"created_at"`
+}
+
+type productPriceScheduleRecord struct {
+	bun.BaseModel `bun:"table:product_price_schedules"`
+
+	ID          uuid.UUID       `bun:"id,pk,type:uuid"`
+	ProductID   uuid.UUID       `bun:"product_id,type:uuid"`
+	Price       decimal.Decimal `bun:"price"`
+	EffectiveAt time.Time       `bun:"effective_at"`
+	Status      string          `bun:"status"`
+	AppliedAt   *time.Time      `bun:"applied_at"`
+	CreatedBy   *uuid.UUID      `bun:"created_by,type:uuid"`
+	CreatedAt   time.Time       `bun:"created_at"`
+	UpdatedAt   time.Time       `bun:"updated_at"`
+}
+
+type ProductPriceHistoryItem struct {
+	ID            uuid.UUID        `json:"id"`
+	Price         decimal.Decimal  `json:"price"`
+	PreviousPrice *decimal.Decimal `json:"previous_price"`
+	Source        string           `json:"source"`
+	ScheduleID    *uuid.UUID       `json:"schedule_id,omitempty"`
+	ChangedBy     *uuid.UUID       `json:"changed_by,omitempty"`
+	EffectiveAt   string           `json:"effective_at"`
+}
+
+type ProductPriceScheduleItem struct {
+	ID          uuid.UUID       `json:"id"`
+	Price       decimal.Decimal `json:"price"`
+	EffectiveAt string          `json:"effective_at"`
+	Status      string          `json:"status"`
+	AppliedAt   *string         `json:"applied_at"`
+	CreatedBy   *uuid.UUID      `json:"created_by,omitempty"`
+	CreatedAt   string          `json:"created_at"`
+}
+
+// ProductPriceTimeline is a product's base price over a period: the price
+// in effect when the period starts and every change inside it. LowestPrice
+// is the lowest of those and of any flash sale price offered in the period.
+// Scheduled is only filled for admins.
+type ProductPriceTimeline struct {
+	ProductID    uuid.UUID                   `json:"product_id"`
+	CurrentPrice decimal.Decimal             `json:"current_price"`
+	From         string                      `json:"from"`
+	To           string                      `json:"to"`
+	LowestPrice  decimal.Decimal             `json:"lowest_price"`
+	History      []*ProductPriceHistoryItem  `json:"history"`
+	Scheduled    []*ProductPriceScheduleItem `json:"scheduled,omitempty"`
+}
+
+type ProductPriceTimelineServiceRequest struct {
+	From  *string
+	To    *string
+	Admin bool
+}
+
+type CreateProductPriceScheduleServiceRequest struct {
+	Price       string
+	EffectiveAt string
+	CreatedBy   uuid.UUID
+}
+
+type ProductPriceScheduleResult struct {
+	Applied   int `json:"applied"`
+	Cancelled int `json:"cancelled"`
+}
+
+func toProductPriceHistoryItem(record *productPriceHistoryRecord) *ProductPriceHistoryItem {
+	return &ProductPriceHistoryItem{
+		ID:            record.ID,
+		Price:         record.Price,
+		PreviousPrice: record.PreviousPrice,
+		Source:        record.Source,
+		ScheduleID:    record.ScheduleID,
+		ChangedBy:     record.ChangedBy,
+		EffectiveAt:   record.EffectiveAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
+
+func toProductPriceScheduleItem(record *productPriceScheduleRecord) *ProductPriceScheduleItem {
+	return &ProductPriceScheduleItem{
+		ID:          record.ID,
+		Price:       record.Price,
+		EffectiveAt: record.EffectiveAt.Format("2006-01-02T15:04:05Z07:00"),
+		Status:      record.Status,
+		AppliedAt:   formatProductTime(record.AppliedAt),
+		CreatedBy:   record.CreatedBy,
+		CreatedAt:   record.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
+
+// recordProductPriceChangeInTx writes a history row when a product's base
+// price is set or changes. previous is nil for a new product; an unchanged
+// price writes nothing.
+func recordProductPriceChangeInTx(ctx context.Context, tx bun.Tx, productID uuid.UUID, previous *decimal.Decimal, price decimal.Decimal, source string, scheduleID *uuid.UUID, changedBy *uuid.UUID, at time.Time) error {
+	if previous != nil && previous.Equal(price) {
+		return nil
+	}
+	record := &productPriceHistoryRecord{
+		ID:            uuid.New(),
+		ProductID:     productID,
+		Price:         price,
+		PreviousPrice: previous,
+		Source:        source,
+		ScheduleID:    scheduleID,
+		ChangedBy:     changedBy,
+		EffectiveAt:   at.UTC(),
+		CreatedAt:     at,
+	}
+	_, err := tx.NewInsert().Model(record).Exec(ctx)
+	return err
+}
+
+// ProductPriceTimelineService returns the base price timeline of a product.
+// The period defaults to the last 30 days, which makes LowestPrice the
+// figure for the lowest-price-in-30-days disclosure. Shoppers only see
+// published products and no scheduled prices.
+func (s *Service) ProductPriceTimelineService(ctx context.Context, productID uuid.UUID, req *ProductPriceTimelineServiceRequest) (*ProductPriceTimeline, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`products.svc.price_timeline.start`)
+
+	product, err := s.db.GetProductByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if !req.Admin && product.Status != ent.ProductStatusPublished {
+		return nil, sql.ErrNoRows
+	}
+
+	now := time.Now().UTC()
+	to := now
+	from := now.Add(-lowestPriceWindow)
+	if req.Admin {
+		if req.To != nil {
+			parsed, err := parseProductTime(*req.To)
+			if err != nil {
+				return nil, err
+			}
+			if parsed != nil {
+				to = *parsed
+			}
+		}
+		if req.From != nil {
+			parsed, err := parseProductTime(*req.From)
+			if err != nil {
+				return nil, err
+			}
+			if parsed != nil {
+				from = *parsed
+			}
+		} else {
+			from = to.Add(-lowestPriceWindow)
+		}
+	}
+	if !from.Before(to) {
+		return nil, errors.New("price timeline start must be before its end")
+	}
+
+	records := make([]*productPriceHistoryRecord, 0)
+	if err := s.bunDB.DB().NewSelect().
+		Model(&records).
+		Where("product_id = ?", productID).
+		Where("effective_at > ?", from).
+		Where("effective_at <= ?", to).
+		Order("effective_at ASC").
+		Scan(ctx); err != nil {
+		return nil, err
+	}
+
+	// The price that was already in effect when the period started counts
+	// towards the lowest price too.
+	opening := new(productPriceHistoryRecord)
+	err = s.bunDB.DB().NewSelect().
+		Model(opening).
+		Where("product_id = ?", productID).
+		Where("effective_at <= ?", from).
+		Order("effective_at DESC").
+		Limit(1).
+		Scan(ctx)
+	switch {
+	case err == nil:
+		records = append([]*productPriceHistoryRecord{opening}, records...)
+	case !errors.Is(err, sql.ErrNoRows):
+		return nil, err
+	}
+
+	timeline := &ProductPriceTimeline{
+		ProductID:    productID,
+		CurrentPrice: product.Price,
+		From:         from.Format("2006-01-02T15:04:05Z07:00"),
+		To:           to.Format("2006-01-02T15:04:05Z07:00"),
+		LowestPrice:  product.Price,
+		History:      make([]*ProductPriceHistoryItem, 0, len(records)),
+	}
+	for index, record := range records {
+		if index == 0 || record.Price.LessThan(timeline.LowestPrice) {
+			timeline.LowestPrice = record.Price
+		}
+		timeline.History = append(timeline.History, toProductPriceHistoryItem(record))
+	}
+
+	flashSalePrice, err := flashsale.LowestPrice(ctx, s.bunDB.DB(), productID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if flashSalePrice != nil && flashSalePrice.LessThan(timeline.LowestPrice) {
+		timeline.LowestPrice = *flashSalePrice
+	}
+
+	if req.Admin {
+		schedules := make([]*productPriceScheduleRecord, 0)
+		if err := s.bunDB.DB().NewSelect().
+			Model(&schedules).
+			Where("product_id = ?", productID).
+			Where("status = ?", PriceSchedulePending).
+			Order("effective_at ASC").
+			Scan(ctx); err != nil {
+			return nil, err
+		}
+		timeline.Scheduled = make([]*ProductPriceScheduleItem, 0, len(schedules))
+		for _, schedule := range schedules {
+			timeline.Scheduled = append(timeline.Scheduled, toProductPriceScheduleItem(schedule))
+		}
+	}
+
+	span.AddEvent(`products.svc.price_timeline.success`)
+	return timeline, nil
+}
+
+// CreateProductPriceScheduleService queues a base price change that the
+// scheduler applies once EffectiveAt has passed.
+func (s *Service) CreateProductPriceScheduleService(ctx context.Context, productID uuid.UUID, req *CreateProductPriceScheduleServiceRequest) (*ProductPriceScheduleItem, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`products.svc.price_schedule.create.start`)
+
+	if _, err := s.db.GetProductByID(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	price, err := decimal.NewFromString(req.Price)
+	if err != nil || price.IsNegative() {
+		return nil, errors.New("invalid price")
+	}
+	effectiveAt, err := parseProductTime(req.EffectiveAt)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	if effectiveAt == nil || !effectiveAt.After(now) {
+		return nil, errors.New("price schedule must be in the future")
+	}
+
+	record := &productPriceScheduleRecord{
+		ID:          uuid.New(),
+		ProductID:   productID,
+		Price:       price.Round(2),
+		EffectiveAt: *effectiveAt,
+		Status:      PriceSchedulePending,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if req.CreatedBy != uuid.Nil {
+		record.CreatedBy = &req.CreatedBy
+	}
+
+	exists, err := s.bunDB.DB().NewSelect().
+		Model((*productPriceScheduleRecord)(nil)).
+		Where("product_id = ?", productID).
+		Where("effective_at = ?", record.EffectiveAt).
+		Where("status = ?", PriceSchedulePending).
+		Exists(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, errors.New("duplicate price schedule")
+	}
+
+	if _, err := s.bunDB.DB().NewInsert().Model(record).Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	span.AddEvent(`products.svc.price_schedule.create.success`)
+	return toProductPriceScheduleItem(record), nil
+}
+
+// CancelProductPriceScheduleService drops a price change that has not been
+// applied yet.
+func (s *Service) CancelProductPriceScheduleService(ctx context.Context, productID uuid.UUID, scheduleID uuid.UUID) error {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`products.svc.price_schedule.cancel.start`)
+
+	res, err := s.bunDB.DB().NewUpdate().
+		Model((*productPriceScheduleRecord)(nil)).
+		Set("status = ?", PriceScheduleCancelled).
+		Set("updated_at = ?", time.Now().UTC()).
+		Where("id = ?", scheduleID).
+		Where("product_id = ?", productID).
+		Where("status = ?", PriceSchedulePending).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return errors.New("price schedule not found")
+	}
+
+	span.AddEvent(`products.svc.price_schedule.cancel.success`)
+	return nil
+}
+
+// RunPriceSchedule applies every pending price change whose time has come,
+// oldest first, and records each one in the price history. Schedules of
+// deleted products are cancelled. It is safe to run repeatedly.
+func (s *Service) RunPriceSchedule(ctx context.Context, now time.Time) (*ProductPriceScheduleResult, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`products.svc.price_schedule.run.start`)
+
+	now = now.UTC()
+	result := &ProductPriceScheduleResult{}
+	err := s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		schedules := make([]*productPriceScheduleRecord, 0)
+		if err := tx.NewSelect().
+			Model(&schedules).
+			Where("status = ?", PriceSchedulePending).
+			Where("effective_at <= ?", now).
+			Order("effective_at ASC").
+			For("UPDATE SKIP LOCKED").
+			Scan(ctx); err != nil {
+			return err
+		}
+
+		for _, schedule := range schedules {
+			product := new(ent.ProductEntity)
+			err := tx.NewSelect().
+				Model(product).
+				Where("id = ?", schedule.ProductID).
+				For("UPDATE").
+				Scan(ctx)
+			if errors.Is(err, sql.ErrNoRows) {
+				schedule.Status = PriceScheduleCancelled
+				schedule.UpdatedAt = now
+				if _, err := tx.NewUpdate().Model(schedule).Column("status", "updated_at").WherePK().Exec(ctx); err != nil {
+					return err
+				}
+				result.Cancelled++
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			previous := product.Price
+			if _, err := tx.NewUpdate().
+				Model((*ent.ProductEntity)(nil)).
+				Set("price = ?", schedule.Price).
+				Set("updated_at = ?", now).
+				Where("id = ?", product.ID).
+				Exec(ctx); err != nil {
+				return err
+			}
+			if err := recordProductPriceChangeInTx(ctx, tx, product.ID, &previous, schedule.Price, PriceChangeSchedule, &schedule.ID, schedule.CreatedBy, schedule.EffectiveAt); err != nil {
+				return err
+			}
+
+			schedule.Status = PriceScheduleApplied
+			schedule.AppliedAt = &now
+			schedule.UpdatedAt = now
+			if _, err := tx.NewUpdate().Model(schedule).Column("status", "applied_at", "updated_at").WherePK().Exec(ctx); err != nil {
+				return err
+			}
+
+			auditLog := &ent.AuditLogEntity{
+				ID:           uuid.New(),
+				Action:       ent.AuditActionUpdated,
+				ActionType:   "scheduled_product_price",
+				ActionID:     product.ID,
+				ActionBy:     schedule.CreatedBy,
+				Status:       ent.StatusAuditSuccesses,
+				ActionDetail: fmt.Sprintf("Price of product %s changed from %s to %s by schedule %s", product.ID, previous.String(), schedule.Price.String(), schedule.ID),
+				CreatedAt:    now,
+				UpdatedAt:    now,
+			}
+			if _, err := tx.NewInsert().Model(auditLog).Exec(ctx); err != nil {
+				return err
+			}
+			result.Applied++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	span.AddEvent(`products.svc.price_schedule.run.success`)
+	return result, nil
+}
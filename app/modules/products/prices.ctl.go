@@ -0,0 +1,107 @@
+package products
+
+import (
+	"phakram/app/modules/auth"
+	"phakram/app/utils"
+	"phakram/app/utils/base"
+	"phakram/config/i18n"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type productPriceControllerURI struct {
+	ID string `uri:"id"`
+}
+
+type ProductPriceControllerRequest struct {
+	TierID      string `json:"tier_id"`
+	MinQuantity int    `json:"min_quantity"`
+	Price       string `json:"price"`
+}
+
+type ReplaceProductPricesControllerRequest struct {
+	Prices []ProductPriceControllerRequest `json:"prices"`
+}
+
+func (c *Controller) parseProductPriceRequest(ctx *gin.Context) (uuid.UUID, bool) {
+	_, hasRequester := auth.GetMemberID(ctx)
+	if !auth.GetIsAdmin(ctx) || !hasRequester {
+		base.Forbidden(ctx, i18n.Forbidden, nil)
+		return uuid.Nil, false
+	}
+
+	var uri productPriceControllerURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return uuid.Nil, false
+	}
+	productID, err := uuid.Parse(uri.ID)
+	if err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return uuid.Nil, false
+	}
+	return productID, true
+}
+
+func (c *Controller) ListProductPricesController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`products.ctl.prices.list.start`)
+
+	productID, ok := c.parseProductPriceRequest(ctx)
+	if !ok {
+		return
+	}
+
+	data, err := c.svc.ListProductPricesService(ctx.Request.Context(), productID)
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`products.ctl.prices.list.success`)
+	base.Success(ctx, data)
+}
+
+func (c *Controller) ReplaceProductPricesController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`products.ctl.prices.replace.start`)
+
+	productID, ok := c.parseProductPriceRequest(ctx)
+	if !ok {
+		return
+	}
+
+	var req ReplaceProductPricesControllerRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	prices := make([]ProductPriceServiceRequest, 0, len(req.Prices))
+	for _, item := range req.Prices {
+		price := ProductPriceServiceRequest{
+			MinQuantity: item.MinQuantity,
+			Price:       item.Price,
+		}
+		if tierID := strings.TrimSpace(item.TierID); tierID != "" {
+			parsed, err := uuid.Parse(tierID)
+			if err != nil {
+				base.BadRequest(ctx, i18n.BadRequest, nil)
+				return
+			}
+			price.TierID = &parsed
+		}
+		prices = append(prices, price)
+	}
+
+	data, err := c.svc.ReplaceProductPricesService(ctx.Request.Context(), productID, prices)
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`products.ctl.prices.replace.success`)
+	base.Success(ctx, data)
+}
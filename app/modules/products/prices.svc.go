@@ -0,0 +1,165 @@
+package products
+
+import (
+	"context"
+	"errors"
+	"phakram/app/modules/entities/ent"
+	"phakram/app/utils"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/uptrace/bun"
+)
+
+type productPriceRecord struct {
+	bun.BaseModel `bun:"table:product_prices"`
+
+	ID          uuid.UUID       `bun:"id,pk,type:uuid"`
+	ProductID   uuid.UUID       `bun:"product_id,type:uuid"`
+	TierID      *uuid.UUID      `bun:"tier_id,type:uuid"`
+	MinQuantity int             `bun:"min_quantity"`
+	Price       decimal.Decimal `bun:"price"`
+	CreatedAt   time.Time       `bun:"created_at"`
+	UpdatedAt   time.Time       `bun:"updated_at"`
+}
+
+type ProductPriceServiceRequest struct {
+	TierID      *uuid.UUID
+	MinQuantity int
+	Price       string
+}
+
+type ProductPriceItem struct {
+	ID          uuid.UUID       `json:"id"`
+	TierID      *uuid.UUID      `json:"tier_id"`
+	MinQuantity int             `json:"min_quantity"`
+	Price       decimal.Decimal `json:"price"`
+	CreatedAt   string          `json:"created_at"`
+	UpdatedAt   string          `json:"updated_at"`
+}
+
+type priceListKey struct {
+	tierID      uuid.UUID
+	minQuantity int
+}
+
+func toProductPriceItem(record *productPriceRecord) *ProductPriceItem {
+	return &ProductPriceItem{
+		ID:          record.ID,
+		TierID:      record.TierID,
+		MinQuantity: record.MinQuantity,
+		Price:       record.Price,
+		CreatedAt:   record.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:   record.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
+
+func (s *Service) ListProductPricesService(ctx context.Context, productID uuid.UUID) ([]*ProductPriceItem, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`products.svc.prices.list.start`)
+
+	if _, err := s.db.GetProductByID(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	records := make([]*productPriceRecord, 0)
+	if err := s.bunDB.DB().NewSelect().
+		Model(&records).
+		Where("product_id = ?", productID).
+		OrderExpr("tier_id NULLS FIRST, min_quantity ASC").
+		Scan(ctx); err != nil {
+		return nil, err
+	}
+
+	items := make([]*ProductPriceItem, 0, len(records))
+	for _, record := range records {
+		items = append(items, toProductPriceItem(record))
+	}
+
+	span.AddEvent(`products.svc.prices.list.success`)
+	return items, nil
+}
+
+// ReplaceProductPricesService swaps the whole price list of a product.
+// Rows without a tier apply to everyone; tier rows override them, and the
+// tier discount rate, for members of that tier. An empty list returns the
+// product to its base price.
+func (s *Service) ReplaceProductPricesService(ctx context.Context, productID uuid.UUID, req []ProductPriceServiceRequest) ([]*ProductPriceItem, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`products.svc.prices.replace.start`)
+
+	if _, err := s.db.GetProductByID(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	records := make([]*productPriceRecord, 0, len(req))
+	seen := make(map[priceListKey]struct{}, len(req))
+	tierIDs := make(map[uuid.UUID]struct{})
+	for _, item := range req {
+		if item.MinQuantity < 1 {
+			return nil, errors.New("price list quantity must be at least 1")
+		}
+		price, err := decimal.NewFromString(item.Price)
+		if err != nil || price.IsNegative() {
+			return nil, errors.New("invalid price list price")
+		}
+
+		key := priceListKey{minQuantity: item.MinQuantity}
+		if item.TierID != nil {
+			key.tierID = *item.TierID
+			tierIDs[*item.TierID] = struct{}{}
+		}
+		if _, ok := seen[key]; ok {
+			return nil, errors.New("duplicate price list quantity")
+		}
+		seen[key] = struct{}{}
+
+		records = append(records, &productPriceRecord{
+			ID:          uuid.New(),
+			ProductID:   productID,
+			TierID:      item.TierID,
+			MinQuantity: item.MinQuantity,
+			Price:       price.Round(2),
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		})
+	}
+
+	if len(tierIDs) > 0 {
+		ids := make([]uuid.UUID, 0, len(tierIDs))
+		for id := range tierIDs {
+			ids = append(ids, id)
+		}
+		count, err := s.bunDB.DB().NewSelect().
+			Model((*ent.TierEntity)(nil)).
+			Where("id IN (?)", bun.In(ids)).
+			Count(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if count != len(ids) {
+			return nil, errors.New("tier not found")
+		}
+	}
+
+	if err := s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewDelete().
+			Model((*productPriceRecord)(nil)).
+			Where("product_id = ?", productID).
+			Exec(ctx); err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			return nil
+		}
+		_, err := tx.NewInsert().Model(&records).Exec(ctx)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	span.AddEvent(`products.svc.prices.replace.success`)
+	return s.ListProductPricesService(ctx, productID)
+}
@@ -0,0 +1,199 @@
+package products
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"phakram/app/modules/entities/ent"
+	"phakram/app/utils"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// ProductPublishing is the lifecycle part of a create or update request.
+// A nil field keeps the current value; an empty PublishAt or UnpublishAt
+// clears it. IsActive is the older switch and is only used when Status is
+// not given.
+type ProductPublishing struct {
+	Status      *string `json:"status"`
+	PublishAt   *string `json:"publish_at"`
+	UnpublishAt *string `json:"unpublish_at"`
+	IsActive    *bool   `json:"is_active"`
+}
+
+type ProductPublishScheduleResult struct {
+	Published   int `json:"published"`
+	Unpublished int `json:"unpublished"`
+}
+
+func parseProductStatus(value string) (ent.ProductStatusEnum, error) {
+	switch status := ent.ProductStatusEnum(strings.ToLower(strings.TrimSpace(value))); status {
+	case ent.ProductStatusDraft, ent.ProductStatusScheduled, ent.ProductStatusPublished, ent.ProductStatusArchived:
+		return status, nil
+	default:
+		return "", errors.New("invalid product status")
+	}
+}
+
+func parseProductTime(value string) (*time.Time, error) {
+	trimmed := strings.TrimSpace(value)
+	if trimmed == "" {
+		return nil, nil
+	}
+	if parsed, err := time.Parse(time.RFC3339, trimmed); err == nil {
+		utc := parsed.UTC()
+		return &utc, nil
+	}
+	if parsed, err := time.Parse("2006-01-02T15:04", trimmed); err == nil {
+		utc := parsed.UTC()
+		return &utc, nil
+	}
+	return nil, fmt.Errorf("invalid datetime format")
+}
+
+func formatProductTime(value *time.Time) *string {
+	if value == nil {
+		return nil
+	}
+	v := value.Format("2006-01-02T15:04:05Z07:00")
+	return &v
+}
+
+// applyProductPublishing moves product to the requested lifecycle state and
+// keeps IsActive in step with it. Publishing together with a future
+// publish_at schedules the product instead; publishing a scheduled product
+// without one publishes it now.
+func applyProductPublishing(product *ent.ProductEntity, req *ProductPublishing, now time.Time) error {
+	if req.PublishAt != nil {
+		publishAt, err := parseProductTime(*req.PublishAt)
+		if err != nil {
+			return err
+		}
+		product.PublishAt = publishAt
+	}
+	if req.UnpublishAt != nil {
+		unpublishAt, err := parseProductTime(*req.UnpublishAt)
+		if err != nil {
+			return err
+		}
+		product.UnpublishAt = unpublishAt
+	}
+
+	status := product.Status
+	switch {
+	case req.Status != nil && strings.TrimSpace(*req.Status) != "":
+		parsed, err := parseProductStatus(*req.Status)
+		if err != nil {
+			return err
+		}
+		status = parsed
+	case req.IsActive != nil && *req.IsActive:
+		status = ent.ProductStatusPublished
+	case req.IsActive != nil && status == ent.ProductStatusPublished:
+		status = ent.ProductStatusArchived
+	case req.IsActive != nil && status != ent.ProductStatusArchived:
+		status = ent.ProductStatusDraft
+	}
+	if status == "" {
+		status = ent.ProductStatusPublished
+	}
+
+	switch status {
+	case ent.ProductStatusPublished:
+		switch {
+		case product.PublishAt == nil:
+			product.PublishAt = &now
+		case product.PublishAt.After(now) && req.PublishAt != nil:
+			status = ent.ProductStatusScheduled
+		case product.PublishAt.After(now):
+			product.PublishAt = &now
+		}
+	case ent.ProductStatusScheduled:
+		if product.PublishAt == nil || !product.PublishAt.After(now) {
+			return errors.New("scheduled product needs a future publish_at")
+		}
+	}
+
+	if product.UnpublishAt != nil && (status == ent.ProductStatusPublished || status == ent.ProductStatusScheduled) {
+		from := now
+		if product.PublishAt != nil && product.PublishAt.After(from) {
+			from = *product.PublishAt
+		}
+		if !product.UnpublishAt.After(from) {
+			return errors.New("unpublish_at must be after publish_at")
+		}
+	}
+
+	product.Status = status
+	product.IsActive = status == ent.ProductStatusPublished
+	return nil
+}
+
+// RunPublishSchedule publishes scheduled products whose publish_at has come
+// and archives published products past their unpublish_at. It is safe to run
+// repeatedly; each step only picks up products still in the earlier state.
+func (s *Service) RunPublishSchedule(ctx context.Context, now time.Time) (*ProductPublishScheduleResult, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`products.svc.publish_schedule.start`)
+
+	now = now.UTC()
+	result := &ProductPublishScheduleResult{}
+
+	published, err := s.moveScheduledProducts(ctx, now,
+		ent.ProductStatusScheduled, ent.ProductStatusPublished, "publish_at", "publish_product")
+	if err != nil {
+		return nil, err
+	}
+	result.Published = published
+
+	unpublished, err := s.moveScheduledProducts(ctx, now,
+		ent.ProductStatusPublished, ent.ProductStatusArchived, "unpublish_at", "unpublish_product")
+	if err != nil {
+		return nil, err
+	}
+	result.Unpublished = unpublished
+
+	span.AddEvent(`products.svc.publish_schedule.success`)
+	return result, nil
+}
+
+func (s *Service) moveScheduledProducts(ctx context.Context, now time.Time, from ent.ProductStatusEnum, to ent.ProductStatusEnum, column string, actionType string) (int, error) {
+	ids := make([]uuid.UUID, 0)
+	err := s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewUpdate().
+			Model((*ent.ProductEntity)(nil)).
+			Set("status = ?", to).
+			Set("is_active = ?", to == ent.ProductStatusPublished).
+			Set("updated_at = ?", now).
+			Where("status = ?", from).
+			Where("? IS NOT NULL", bun.Ident(column)).
+			Where("? <= ?", bun.Ident(column), now).
+			Returning("id").
+			Exec(ctx, &ids); err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		auditLogs := make([]*ent.AuditLogEntity, 0, len(ids))
+		for _, id := range ids {
+			auditLogs = append(auditLogs, &ent.AuditLogEntity{
+				ID:           uuid.New(),
+				Action:       ent.AuditActionUpdated,
+				ActionType:   actionType,
+				ActionID:     id,
+				Status:       ent.StatusAuditSuccesses,
+				ActionDetail: fmt.Sprintf("Product %s moved from %s to %s by schedule", id, from, to),
+				CreatedAt:    now,
+				UpdatedAt:    now,
+			})
+		}
+		_, err := tx.NewInsert().Model(&auditLogs).Exec(ctx)
+		return err
+	})
+	return len(ids), err
+}
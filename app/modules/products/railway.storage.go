@@ -8,6 +8,7 @@ import (
 	"mime"
 	"net/http"
 	"os"
+	"phakram/app/utils/imageproc"
 	"phakram/app/utils/s3compat"
 	"strings"
 	"time"
@@ -25,10 +26,11 @@ type railwayStorageClient struct {
 }
 
 type uploadedProductImage struct {
-	Path     string
-	FileName string
-	MIMEType string
-	Size     int64
+	Path       string
+	FileName   string
+	MIMEType   string
+	Size       int64
+	Renditions imageproc.Renditions
 }
 
 func newRailwayStorageClient(conf RailwayConfig) *railwayStorageClient {
@@ -137,36 +139,35 @@ func (c *railwayStorageClient) UploadProductImage(ctx context.Context, productID
 		return nil, errors.New("railway public storage is not configured")
 	}
 
-	data, mimeType, err := decodeBase64Image(encoded)
+	data, _, err := decodeBase64Image(encoded)
 	if err != nil {
 		return nil, err
 	}
-	if len(data) == 0 {
-		return nil, errors.New("image is empty")
-	}
 	if len(data) > maxProductImageFileSizeBytes {
 		return nil, errors.New("image exceeds 5 MB")
 	}
-	if !isAllowedProductImageMIME(mimeType) {
-		return nil, fmt.Errorf("unsupported image type: %s", mimeType)
+	processed, err := imageproc.Process(data)
+	if err != nil {
+		return nil, err
 	}
 
-	ext := extensionByMIME(mimeType)
 	safeName := strings.TrimSpace(fileName)
 	if safeName == "" {
-		safeName = fmt.Sprintf("product-%s%s", productID.String(), ext)
+		safeName = fmt.Sprintf("product-%s%s", productID.String(), processed.Original.Ext)
 	}
 
-	objectPath := fmt.Sprintf("products/%s/%s-%d%s", productID.String(), uuid.NewString(), time.Now().UnixMilli(), ext)
-	if err := c.s3.PutObject(ctx, c.publicBucket, objectPath, mimeType, data); err != nil {
+	objectBase := fmt.Sprintf("products/%s/%s-%d", productID.String(), uuid.NewString(), time.Now().UnixMilli())
+	uploaded, err := processed.Upload(ctx, c.s3, c.publicBucket, objectBase)
+	if err != nil {
 		return nil, err
 	}
 
 	return &uploadedProductImage{
-		Path:     fmt.Sprintf("%s/%s", c.publicBucket, objectPath),
-		FileName: safeName,
-		MIMEType: mimeType,
-		Size:     int64(len(data)),
+		Path:       uploaded.Path,
+		FileName:   safeName,
+		MIMEType:   uploaded.MIMEType,
+		Size:       uploaded.Size,
+		Renditions: uploaded.Renditions,
 	}, nil
 }
 
@@ -228,32 +229,6 @@ func decodeBase64Image(input string) ([]byte, string, error) {
 	return decoded, strings.ToLower(strings.TrimSpace(mimeType)), nil
 }
 
-func isAllowedProductImageMIME(mimeType string) bool {
-	switch strings.ToLower(strings.TrimSpace(mimeType)) {
-	case "image/jpeg", "image/png", "image/webp", "image/heic", "image/heif", "image/heic-sequence", "image/heif-sequence":
-		return true
-	default:
-		return false
-	}
-}
-
-func extensionByMIME(mimeType string) string {
-	switch strings.ToLower(strings.TrimSpace(mimeType)) {
-	case "image/jpeg":
-		return ".jpg"
-	case "image/png":
-		return ".png"
-	case "image/webp":
-		return ".webp"
-	case "image/heic", "image/heic-sequence":
-		return ".heic"
-	case "image/heif", "image/heif-sequence":
-		return ".heif"
-	default:
-		return ".bin"
-	}
-}
-
 func splitBucketAndObjectPath(storedPath string) (string, string, bool) {
 	trimmed := strings.Trim(strings.TrimSpace(storedPath), "/")
 	parts := strings.SplitN(trimmed, "/", 2)
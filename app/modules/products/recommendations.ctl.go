@@ -0,0 +1,97 @@
+package products
+
+import (
+	"phakram/app/modules/auth"
+	"phakram/app/utils"
+	"phakram/app/utils/base"
+	"phakram/config/i18n"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type RelatedProductsControllerRequest struct {
+	Limit int `form:"limit"`
+}
+
+type CartRecommendationsControllerRequest struct {
+	ProductIDs string `form:"product_ids"`
+	Limit      int    `form:"limit"`
+}
+
+func (c *Controller) RelatedProductsController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`products.ctl.related.start`)
+
+	var uri InfoProductControllerRequestUri
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+	productID, err := uuid.Parse(uri.ID)
+	if err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	var req RelatedProductsControllerRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	memberID, _ := auth.GetMemberID(ctx)
+	data, err := c.svc.RelatedProductsService(ctx.Request.Context(), productID, &ProductRecommendationsServiceRequest{
+		Limit:    req.Limit,
+		MemberID: memberID,
+	})
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`products.ctl.related.success`)
+	base.Success(ctx, data)
+}
+
+// CartRecommendationsController recommends products for a comma separated
+// product_ids list, falling back to the signed-in member's active cart.
+func (c *Controller) CartRecommendationsController(ctx *gin.Context) {
+	span, _ := utils.LogSpanFromGin(ctx)
+	span.AddEvent(`products.ctl.cart_recommendations.start`)
+
+	var req CartRecommendationsControllerRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	productIDs := make([]uuid.UUID, 0)
+	for _, value := range strings.Split(req.ProductIDs, ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		productID, err := uuid.Parse(value)
+		if err != nil {
+			base.BadRequest(ctx, i18n.BadRequest, nil)
+			return
+		}
+		productIDs = append(productIDs, productID)
+	}
+
+	memberID, _ := auth.GetMemberID(ctx)
+	data, err := c.svc.CartRecommendationsService(ctx.Request.Context(), &CartRecommendationsServiceRequest{
+		ProductIDs: productIDs,
+		Limit:      req.Limit,
+		MemberID:   memberID,
+	})
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	span.AddEvent(`products.ctl.cart_recommendations.success`)
+	base.Success(ctx, data)
+}
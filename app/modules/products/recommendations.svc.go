@@ -0,0 +1,355 @@
+package products
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"phakram/app/modules/entities/ent"
+	"phakram/app/utils"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// Why a product was recommended, saved on product_recommendations.
+const (
+	RecommendationCoPurchase = "co_purchase"
+	RecommendationCategory   = "category"
+)
+
+const (
+	// recommendationRefreshInterval is how old the computed table may get
+	// before the scheduler rebuilds it.
+	recommendationRefreshInterval = 6 * time.Hour
+	// recommendationsPerProduct caps the stored neighbours of each kind.
+	recommendationsPerProduct = 20
+	// coPurchaseMinSupport is how many orders must contain both products
+	// before the pair counts as bought together.
+	coPurchaseMinSupport = 2
+
+	defaultRecommendationLimit = 8
+	maxRecommendationLimit     = 24
+	maxRecommendationSources   = 50
+)
+
+type productRecommendationRecord struct {
+	bun.BaseModel `bun:"table:product_recommendations,alias:r"`
+
+	ProductID        uuid.UUID `bun:"product_id,pk,type:uuid"`
+	RelatedProductID uuid.UUID `bun:"related_product_id,pk,type:uuid"`
+	Kind             string    `bun:"kind,pk"`
+	Score            float64   `bun:"score"`
+	Support          int       `bun:"support"`
+	ComputedAt       time.Time `bun:"computed_at"`
+}
+
+type ProductRecommendationResult struct {
+	Skipped    bool `json:"skipped"`
+	CoPurchase int  `json:"co_purchase"`
+	Category   int  `json:"category"`
+}
+
+type ProductRecommendationItem struct {
+	*ListProductServiceResponses
+	Reason string `json:"reason"`
+}
+
+type ProductRecommendationsServiceRequest struct {
+	Limit    int
+	MemberID uuid.UUID
+}
+
+type CartRecommendationsServiceRequest struct {
+	ProductIDs []uuid.UUID
+	Limit      int
+	MemberID   uuid.UUID
+}
+
+// RunRecommendations rebuilds the product_recommendations table when it is
+// older than recommendationRefreshInterval. Co-purchase pairs come from
+// orders that were paid, scored by cosine similarity of the products' order
+// sets; category neighbours are published products in the same category
+// ranked by closeness in price.
+func (s *Service) RunRecommendations(ctx context.Context, now time.Time) (*ProductRecommendationResult, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`products.svc.recommendations.run.start`)
+
+	now = now.UTC()
+	result := &ProductRecommendationResult{}
+	err := s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		// EXCLUSIVE still lets readers through but keeps a second scheduler
+		// from rebuilding at the same time.
+		if _, err := tx.ExecContext(ctx, "LOCK TABLE product_recommendations IN EXCLUSIVE MODE"); err != nil {
+			return err
+		}
+
+		var computedAt sql.NullTime
+		if err := tx.NewSelect().
+			Model((*productRecommendationRecord)(nil)).
+			ColumnExpr("MAX(r.computed_at)").
+			Scan(ctx, &computedAt); err != nil {
+			return err
+		}
+		if computedAt.Valid && now.Sub(computedAt.Time) < recommendationRefreshInterval {
+			result.Skipped = true
+			return nil
+		}
+
+		if _, err := tx.NewDelete().
+			Model((*productRecommendationRecord)(nil)).
+			Where("TRUE").
+			Exec(ctx); err != nil {
+			return err
+		}
+
+		res, err := tx.NewRaw(`
+			WITH order_products AS (
+				SELECT DISTINCT oi.order_id, oi.product_id
+				FROM order_items AS oi
+				JOIN orders AS o ON o.id = oi.order_id
+				WHERE o.status IN ('paid', 'shipping', 'completed')
+			), product_orders AS (
+				SELECT product_id, COUNT(*) AS orders
+				FROM order_products
+				GROUP BY product_id
+			), pairs AS (
+				SELECT a.product_id, b.product_id AS related_product_id, COUNT(*) AS support
+				FROM order_products AS a
+				JOIN order_products AS b ON b.order_id = a.order_id AND b.product_id <> a.product_id
+				GROUP BY a.product_id, b.product_id
+				HAVING COUNT(*) >= ?
+			), ranked AS (
+				SELECT pairs.product_id, pairs.related_product_id, pairs.support,
+					pairs.support / sqrt(pa.orders::double precision * pb.orders) AS score
+				FROM pairs
+				JOIN product_orders AS pa ON pa.product_id = pairs.product_id
+				JOIN product_orders AS pb ON pb.product_id = pairs.related_product_id
+			)
+			INSERT INTO product_recommendations (product_id, related_product_id, kind, score, support, computed_at)
+			SELECT product_id, related_product_id, ?, score, support, ?
+			FROM (
+				SELECT ranked.*, ROW_NUMBER() OVER (
+					PARTITION BY product_id ORDER BY score DESC, support DESC, related_product_id
+				) AS rank
+				FROM ranked
+				JOIN products AS p ON p.id = ranked.product_id AND p.deleted_at IS NULL
+				JOIN products AS rp ON rp.id = ranked.related_product_id AND rp.deleted_at IS NULL
+			) AS top
+			WHERE rank <= ?
+		`, coPurchaseMinSupport, RecommendationCoPurchase, now, recommendationsPerProduct).Exec(ctx)
+		if err != nil {
+			return err
+		}
+		if affected, err := res.RowsAffected(); err == nil {
+			result.CoPurchase = int(affected)
+		}
+
+		res, err = tx.NewRaw(`
+			INSERT INTO product_recommendations (product_id, related_product_id, kind, score, support, computed_at)
+			SELECT product_id, related_product_id, ?, score, 0, ?
+			FROM (
+				SELECT a.id AS product_id, b.id AS related_product_id,
+					1 / (1 + abs(b.price - a.price) / GREATEST(a.price, 1))::double precision AS score,
+					ROW_NUMBER() OVER (
+						PARTITION BY a.id ORDER BY abs(b.price - a.price), b.id
+					) AS rank
+				FROM products AS a
+				JOIN products AS b ON b.category_id = a.category_id AND b.id <> a.id
+				WHERE a.deleted_at IS NULL
+					AND b.deleted_at IS NULL
+					AND b.status = ?
+			) AS top
+			WHERE rank <= ?
+		`, RecommendationCategory, now, ent.ProductStatusPublished, recommendationsPerProduct).Exec(ctx)
+		if err != nil {
+			return err
+		}
+		if affected, err := res.RowsAffected(); err == nil {
+			result.Category = int(affected)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	span.AddEvent(`products.svc.recommendations.run.success`)
+	return result, nil
+}
+
+// RelatedProductsService returns the products most often bought with a
+// published product, topped up with similar products from its category.
+func (s *Service) RelatedProductsService(ctx context.Context, productID uuid.UUID, req *ProductRecommendationsServiceRequest) ([]*ProductRecommendationItem, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`products.svc.related.start`)
+
+	exists, err := s.bunDB.DB().NewSelect().
+		Model((*ent.ProductEntity)(nil)).
+		Where("id = ?", productID).
+		Where("status = ?", ent.ProductStatusPublished).
+		Exists(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, sql.ErrNoRows
+	}
+
+	data, err := s.recommendProducts(ctx, []uuid.UUID{productID}, req.Limit, req.MemberID)
+	if err != nil {
+		return nil, err
+	}
+
+	span.AddEvent(`products.svc.related.success`)
+	return data, nil
+}
+
+// CartRecommendationsService returns "you may also like" products for the
+// given products, or for the member's active cart when none are given.
+// Products already in the input are never recommended.
+func (s *Service) CartRecommendationsService(ctx context.Context, req *CartRecommendationsServiceRequest) ([]*ProductRecommendationItem, error) {
+	span, _ := utils.LogSpanFromContext(ctx)
+	span.AddEvent(`products.svc.cart_recommendations.start`)
+
+	productIDs := req.ProductIDs
+	if len(productIDs) == 0 && req.MemberID != uuid.Nil {
+		if err := s.bunDB.DB().NewSelect().
+			TableExpr("cart_items AS ci").
+			Join("JOIN carts AS c ON c.id = ci.cart_id").
+			ColumnExpr("DISTINCT ci.product_id").
+			Where("c.member_id = ?", req.MemberID).
+			Where("c.is_active = TRUE").
+			Scan(ctx, &productIDs); err != nil {
+			return nil, err
+		}
+	}
+	if len(productIDs) > maxRecommendationSources {
+		return nil, errors.New("too many products for recommendations")
+	}
+
+	data, err := s.recommendProducts(ctx, productIDs, req.Limit, req.MemberID)
+	if err != nil {
+		return nil, err
+	}
+
+	span.AddEvent(`products.svc.cart_recommendations.success`)
+	return data, nil
+}
+
+type productRecommendationCandidate struct {
+	id         uuid.UUID
+	reason     string
+	coPurchase float64
+	category   float64
+}
+
+// recommendProducts merges the stored neighbours of every source product.
+// Co-purchase evidence always ranks ahead of category similarity; only
+// published products with stock on hand are returned.
+func (s *Service) recommendProducts(ctx context.Context, sourceIDs []uuid.UUID, limit int, memberID uuid.UUID) ([]*ProductRecommendationItem, error) {
+	response := make([]*ProductRecommendationItem, 0)
+	if len(sourceIDs) == 0 {
+		return response, nil
+	}
+	if limit <= 0 {
+		limit = defaultRecommendationLimit
+	}
+	if limit > maxRecommendationLimit {
+		limit = maxRecommendationLimit
+	}
+
+	rows := make([]*productRecommendationRecord, 0)
+	if err := s.bunDB.DB().NewSelect().
+		Model(&rows).
+		Where("r.product_id IN (?)", bun.In(sourceIDs)).
+		Where("r.related_product_id NOT IN (?)", bun.In(sourceIDs)).
+		Where(`EXISTS (
+			SELECT 1 FROM products AS p
+			WHERE p.id = r.related_product_id
+				AND p.deleted_at IS NULL
+				AND p.is_active = TRUE
+				AND p.status = ?
+		)`, ent.ProductStatusPublished).
+		Where(`EXISTS (
+			SELECT 1 FROM product_stocks AS ps
+			WHERE ps.product_id = r.related_product_id
+				AND ps.deleted_at IS NULL
+				AND ps.remaining > 0
+		)`).
+		Scan(ctx); err != nil {
+		return nil, err
+	}
+
+	candidates := make([]*productRecommendationCandidate, 0)
+	byID := make(map[uuid.UUID]*productRecommendationCandidate)
+	for _, row := range rows {
+		candidate, ok := byID[row.RelatedProductID]
+		if !ok {
+			candidate = &productRecommendationCandidate{id: row.RelatedProductID, reason: RecommendationCategory}
+			byID[row.RelatedProductID] = candidate
+			candidates = append(candidates, candidate)
+		}
+		switch row.Kind {
+		case RecommendationCoPurchase:
+			candidate.coPurchase += row.Score
+			candidate.reason = RecommendationCoPurchase
+		case RecommendationCategory:
+			candidate.category += row.Score
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.coPurchase != b.coPurchase {
+			return a.coPurchase > b.coPurchase
+		}
+		if a.category != b.category {
+			return a.category > b.category
+		}
+		return a.id.String() < b.id.String()
+	})
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	if len(candidates) == 0 {
+		return response, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(candidates))
+	for _, candidate := range candidates {
+		ids = append(ids, candidate.id)
+	}
+	products := make([]*ent.ProductEntity, 0, len(ids))
+	if err := s.bunDB.DB().NewSelect().
+		Model(&products).
+		Where("id IN (?)", bun.In(ids)).
+		Scan(ctx); err != nil {
+		return nil, err
+	}
+	productByID := make(map[uuid.UUID]*ent.ProductEntity, len(products))
+	for _, product := range products {
+		productByID[product.ID] = product
+	}
+
+	ordered := make([]*ent.ProductEntity, 0, len(products))
+	reasons := make([]string, 0, len(products))
+	for _, candidate := range candidates {
+		if product, ok := productByID[candidate.id]; ok {
+			ordered = append(ordered, product)
+			reasons = append(reasons, candidate.reason)
+		}
+	}
+
+	items, err := s.toProductListItems(ctx, ordered, memberID)
+	if err != nil {
+		return nil, err
+	}
+	for i, item := range items {
+		response = append(response, &ProductRecommendationItem{
+			ListProductServiceResponses: item,
+			Reason:                      reasons[i],
+		})
+	}
+	return response, nil
+}
@@ -2,6 +2,7 @@ package products
 
 import (
 	"log/slog"
+	"phakram/app/modules/auth"
 	"phakram/app/utils"
 	"phakram/app/utils/base"
 	"phakram/config/i18n"
@@ -16,12 +17,17 @@ type UpdateProductControllerRequestUri struct {
 }
 
 type UpdateProductController struct {
-	CategoryID *string `json:"category_id"`
-	NameTh     string  `json:"name_th"`
-	NameEn     string  `json:"name_en"`
-	ProductNo  string  `json:"product_no"`
-	Price      *string `json:"price"`
-	IsActive   *bool   `json:"is_active"`
+	CategoryID       *string `json:"category_id"`
+	NameTh           string  `json:"name_th"`
+	NameEn           string  `json:"name_en"`
+	ProductNo        string  `json:"product_no"`
+	Price            *string `json:"price"`
+	IsActive         *bool   `json:"is_active"`
+	TaxClass         *string `json:"tax_class"`
+	PriceIncludesVAT *bool   `json:"price_includes_vat"`
+	Status           *string `json:"status"`
+	PublishAt        *string `json:"publish_at"`
+	UnpublishAt      *string `json:"unpublish_at"`
 }
 
 func (c *Controller) UpdateController(ctx *gin.Context) {
@@ -60,14 +66,20 @@ func (c *Controller) UpdateController(ctx *gin.Context) {
 		priceDec = &tempPrice
 	}
 
+	updatedBy, _ := auth.GetMemberID(ctx)
 	if err := c.svc.UpdateService(ctx, id, &UpdateProductService{
-		CategoryID: req.CategoryID,
-		NameTh:     req.NameTh,
-		NameEn:     req.NameEn,
-		ProductNo:  req.ProductNo,
-		Price:      priceDec,
-		IsActive:   req.IsActive,
-	}); err != nil {
+		CategoryID:       req.CategoryID,
+		NameTh:           req.NameTh,
+		NameEn:           req.NameEn,
+		ProductNo:        req.ProductNo,
+		Price:            priceDec,
+		IsActive:         req.IsActive,
+		TaxClass:         req.TaxClass,
+		PriceIncludesVAT: req.PriceIncludesVAT,
+		Status:           req.Status,
+		PublishAt:        req.PublishAt,
+		UnpublishAt:      req.UnpublishAt,
+	}, updatedBy); err != nil {
 		base.HandleError(ctx, err)
 		return
 	}
@@ -14,24 +14,38 @@ import (
 )
 
 type UpdateProductService struct {
-	CategoryID *string          `json:"category_id"`
-	NameTh     string           `json:"name_th"`
-	NameEn     string           `json:"name_en"`
-	ProductNo  string           `json:"product_no"`
-	Price      *decimal.Decimal `json:"price"`
-	IsActive   *bool            `json:"is_active"`
+	CategoryID       *string          `json:"category_id"`
+	NameTh           string           `json:"name_th"`
+	NameEn           string           `json:"name_en"`
+	ProductNo        string           `json:"product_no"`
+	Price            *decimal.Decimal `json:"price"`
+	IsActive         *bool            `json:"is_active"`
+	TaxClass         *string          `json:"tax_class"`
+	PriceIncludesVAT *bool            `json:"price_includes_vat"`
+	Status           *string          `json:"status"`
+	PublishAt        *string          `json:"publish_at"`
+	UnpublishAt      *string          `json:"unpublish_at"`
 }
 
-func (s *Service) UpdateService(ctx context.Context, id uuid.UUID, req *UpdateProductService) error {
+func (s *Service) UpdateService(ctx context.Context, id uuid.UUID, req *UpdateProductService, updatedBy uuid.UUID) error {
 	span, log := utils.LogSpanFromContext(ctx)
 	span.AddEvent(`products.svc.update.start`)
 
+	var actionBy *uuid.UUID
+	if updatedBy != uuid.Nil {
+		actionBy = &updatedBy
+	}
+
 	err := s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
 		data := new(ent.ProductEntity)
-		if err := tx.NewSelect().Model(data).Where("id = ?", id).Scan(ctx); err != nil {
+		// Locked like RunPriceSchedule does, so a manual edit and a
+		// scheduled price change never record from the same old price.
+		if err := tx.NewSelect().Model(data).Where("id = ?", id).For("UPDATE").Scan(ctx); err != nil {
 			log.With(slog.Any(`id`, id)).Errf(`internal: %s`, err)
 			return err
 		}
+		previousPrice := data.Price
+		previousCategoryID := data.CategoryID
 
 		if req.CategoryID != nil && *req.CategoryID != "" {
 			categoryID, err := uuid.Parse(*req.CategoryID)
@@ -52,21 +66,46 @@ func (s *Service) UpdateService(ctx context.Context, id uuid.UUID, req *UpdatePr
 		if req.Price != nil {
 			data.Price = *req.Price
 		}
-		if req.IsActive != nil {
-			data.IsActive = *req.IsActive
+		if req.IsActive != nil || req.Status != nil || req.PublishAt != nil || req.UnpublishAt != nil {
+			if err := applyProductPublishing(data, &ProductPublishing{
+				Status:      req.Status,
+				PublishAt:   req.PublishAt,
+				UnpublishAt: req.UnpublishAt,
+				IsActive:    req.IsActive,
+			}, time.Now().UTC()); err != nil {
+				return err
+			}
+		}
+		if req.TaxClass != nil {
+			taxClass, err := parseTaxClass(*req.TaxClass)
+			if err != nil {
+				return err
+			}
+			data.TaxClass = taxClass
+		}
+		if req.PriceIncludesVAT != nil {
+			data.PriceIncludesVAT = *req.PriceIncludesVAT
 		}
 
 		if _, err := tx.NewUpdate().Model(data).Where("id = ?", data.ID).Exec(ctx); err != nil {
 			log.With(slog.Any(`id`, id)).Errf(`internal: %s`, err)
 			return err
 		}
+		if err := recordProductPriceChangeInTx(ctx, tx, data.ID, &previousPrice, data.Price, PriceChangeUpdate, nil, actionBy, time.Now()); err != nil {
+			return err
+		}
+		if data.CategoryID != previousCategoryID {
+			if err := pruneProductAttributesInTx(ctx, tx, data.ID, data.CategoryID); err != nil {
+				return err
+			}
+		}
 
 		auditLog := &ent.AuditLogEntity{
 			ID:           uuid.New(),
 			Action:       ent.AuditActionUpdated,
 			ActionType:   "update_product",
 			ActionID:     id,
-			ActionBy:     nil,
+			ActionBy:     actionBy,
 			Status:       ent.StatusAuditSuccesses,
 			ActionDetail: "Updated product with ID " + id.String(),
 			CreatedAt:    time.Now(),
@@ -82,7 +121,7 @@ func (s *Service) UpdateService(ctx context.Context, id uuid.UUID, req *UpdatePr
 			Action:       ent.AuditActionUpdated,
 			ActionType:   "update_product",
 			ActionID:     id,
-			ActionBy:     nil,
+			ActionBy:     actionBy,
 			Status:       ent.StatusAuditFailed,
 			ActionDetail: fmt.Sprintf("Update product failed: %v", err),
 			CreatedAt:    time.Now(),
@@ -0,0 +1,76 @@
+package promotions
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"phakram/app/utils/base"
+	"phakram/config/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PromotionAnalyticsControllerRequest struct {
+	StartDate int64 `form:"start_date"`
+	EndDate   int64 `form:"end_date"`
+}
+
+func bindPromotionAnalyticsRequest(ctx *gin.Context) (*PromotionAnalyticsServiceRequest, bool) {
+	promotionID := strings.TrimSpace(ctx.Param("id"))
+	if promotionID == "" {
+		base.BadRequest(ctx, "ไม่พบรหัสโปรโมชั่น", nil)
+		return nil, false
+	}
+
+	var req PromotionAnalyticsControllerRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return nil, false
+	}
+
+	return &PromotionAnalyticsServiceRequest{
+		PromotionID: promotionID,
+		StartDate:   req.StartDate,
+		EndDate:     req.EndDate,
+	}, true
+}
+
+func (c *Controller) AnalyticsController(ctx *gin.Context) {
+	if !requirePromotionAdmin(ctx) {
+		return
+	}
+
+	req, ok := bindPromotionAnalyticsRequest(ctx)
+	if !ok {
+		return
+	}
+
+	data, err := c.svc.Analytics(ctx.Request.Context(), req)
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	base.Success(ctx, data)
+}
+
+func (c *Controller) ExportAnalyticsController(ctx *gin.Context) {
+	if !requirePromotionAdmin(ctx) {
+		return
+	}
+
+	req, ok := bindPromotionAnalyticsRequest(ctx)
+	if !ok {
+		return
+	}
+
+	content, fileName, err := c.svc.ExportAnalytics(ctx.Request.Context(), req)
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+	ctx.Data(http.StatusOK, "text/csv; charset=utf-8", content)
+}
@@ -0,0 +1,332 @@
+package promotions
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"phakram/app/modules/entities/ent"
+	"phakram/app/utils"
+	promotionscope "phakram/app/utils/promotion"
+	"phakram/app/utils/sales"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+const defaultAnalyticsDays = 30
+
+type PromotionAnalyticsServiceRequest struct {
+	PromotionID string
+	StartDate   int64
+	EndDate     int64
+}
+
+type PromotionAnalyticsDay struct {
+	Date           string          `json:"date"`
+	Orders         int             `json:"orders"`
+	Revenue        decimal.Decimal `json:"revenue"`
+	DiscountAmount decimal.Decimal `json:"discount_amount"`
+	NewMembers     int             `json:"new_members"`
+	Cancelled      int             `json:"cancelled"`
+	Refunded       int             `json:"refunded"`
+}
+
+type PromotionAnalytics struct {
+	PromotionID            string                   `json:"promotion_id"`
+	Code                   string                   `json:"code"`
+	Name                   string                   `json:"name"`
+	StartDate              string                   `json:"start_date"`
+	EndDate                string                   `json:"end_date"`
+	Collected              int                      `json:"collected"`
+	Redemptions            int                      `json:"redemptions"`
+	Orders                 int                      `json:"orders"`
+	Revenue                decimal.Decimal          `json:"revenue"`
+	DiscountAmount         decimal.Decimal          `json:"discount_amount"`
+	AverageOrderValue      decimal.Decimal          `json:"average_order_value"`
+	AverageOrderValueOther decimal.Decimal          `json:"average_order_value_without_code"`
+	NewMembers             int                      `json:"new_members"`
+	ReturningMembers       int                      `json:"returning_members"`
+	CancelledOrders        int                      `json:"cancelled_orders"`
+	RefundedOrders         int                      `json:"refunded_orders"`
+	CancellationRate       decimal.Decimal          `json:"cancellation_rate"`
+	RefundRate             decimal.Decimal          `json:"refund_rate"`
+	ReturnOnDiscount       decimal.Decimal          `json:"return_on_discount"`
+	Days                   []*PromotionAnalyticsDay `json:"days"`
+}
+
+type promotedOrderRow struct {
+	OrderID        uuid.UUID          `bun:"order_id"`
+	MemberID       uuid.UUID          `bun:"member_id"`
+	Status         ent.StatusTypeEnum `bun:"status"`
+	NetAmount      decimal.Decimal    `bun:"net_amount"`
+	DiscountAmount decimal.Decimal    `bun:"discount_amount"`
+	CreatedAt      time.Time          `bun:"created_at"`
+	FirstOrder     bool               `bun:"first_order"`
+	Refunded       bool               `bun:"refunded"`
+	Paid           bool               `bun:"paid"`
+}
+
+type otherOrdersRow struct {
+	Orders  int             `bun:"orders"`
+	Revenue decimal.Decimal `bun:"revenue"`
+}
+
+// analyticsPeriod turns the requested unix range into whole days. Without a
+// range it covers the last defaultAnalyticsDays days.
+func analyticsPeriod(startDate int64, endDate int64, loc *time.Location) (time.Time, time.Time, error) {
+	now := time.Now().In(loc)
+	end := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc).AddDate(0, 0, 1)
+	if endDate > 0 {
+		t := time.Unix(endDate, 0).In(loc)
+		end = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc).AddDate(0, 0, 1)
+	}
+	start := end.AddDate(0, 0, -defaultAnalyticsDays)
+	if startDate > 0 {
+		t := time.Unix(startDate, 0).In(loc)
+		start = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	}
+	if !start.Before(end) {
+		return time.Time{}, time.Time{}, errors.New("invalid report period")
+	}
+	return start, end, nil
+}
+
+func analyticsRate(part int, total int) decimal.Decimal {
+	if total == 0 {
+		return decimal.Zero
+	}
+	return decimal.NewFromInt(int64(part)).Div(decimal.NewFromInt(int64(total))).Round(4)
+}
+
+// Analytics reports how a promotion performed over a period. Orders are
+// attributed to the promotion when a usage row links them; revenue and
+// average order value only count paid orders not waiting on a refund, while
+// cancellation and refund rates are taken over every promoted order. A
+// member is new when the promoted order is their first order that was not
+// cancelled.
+func (s *Service) Analytics(ctx context.Context, req *PromotionAnalyticsServiceRequest) (*PromotionAnalytics, error) {
+	promotionID, err := uuid.Parse(strings.TrimSpace(req.PromotionID))
+	if err != nil {
+		return nil, err
+	}
+	promotion := new(promotionRecord)
+	if err := s.bunDB.DB().NewSelect().
+		Model(promotion).
+		Where("id = ?", promotionID).
+		Limit(1).
+		Scan(ctx); err != nil {
+		return nil, err
+	}
+
+	loc := utils.DatabaseTimeLocation()
+	start, end, err := analyticsPeriod(req.StartDate, req.EndDate, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]*promotedOrderRow, 0)
+	if err := s.bunDB.DB().NewSelect().
+		TableExpr("orders AS o").
+		Join("JOIN (SELECT order_id, SUM(discount_amount) AS discount_amount FROM promotion_usages WHERE promotion_id = ? AND order_id IS NOT NULL GROUP BY order_id) AS pu ON pu.order_id = o.id", promotionID).
+		ColumnExpr("o.id AS order_id").
+		ColumnExpr("o.member_id").
+		ColumnExpr("o.status").
+		ColumnExpr("o.net_amount").
+		ColumnExpr("pu.discount_amount").
+		ColumnExpr("o.created_at").
+		ColumnExpr("NOT EXISTS (SELECT 1 FROM orders AS prev WHERE prev.member_id = o.member_id AND prev.created_at < o.created_at AND prev.status <> ?) AS first_order", ent.StatusTypeCancelled).
+		ColumnExpr("(o.status = ? OR EXISTS (SELECT 1 FROM refund_payouts AS rp WHERE rp.order_id = o.id)) AS refunded", ent.StatusTypeRefundRequested).
+		ColumnExpr("? AS paid", sales.Paid("o")).
+		Where("o.created_at >= ?", start).
+		Where("o.created_at < ?", end).
+		OrderExpr("o.created_at ASC").
+		Scan(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	other := new(otherOrdersRow)
+	if err := s.bunDB.DB().NewSelect().
+		TableExpr("orders AS o").
+		ColumnExpr("COUNT(*) AS orders").
+		ColumnExpr("COALESCE(SUM(o.net_amount), 0) AS revenue").
+		Where("?", sales.Paid("o")).
+		Where("o.status <> ?", ent.StatusTypeRefundRequested).
+		Where("o.created_at >= ?", start).
+		Where("o.created_at < ?", end).
+		Where("NOT EXISTS (SELECT 1 FROM promotion_usages AS pu WHERE pu.order_id = o.id AND pu.promotion_id = ?)", promotionID).
+		Scan(ctx, other); err != nil {
+		return nil, err
+	}
+
+	var discountAmount decimal.Decimal
+	redemptions, err := s.bunDB.DB().NewSelect().
+		Model((*promotionUsageRecord)(nil)).
+		Where("promotion_id = ?", promotionID).
+		Where("used_at >= ?", start).
+		Where("used_at < ?", end).
+		Count(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.bunDB.DB().NewSelect().
+		Model((*promotionUsageRecord)(nil)).
+		ColumnExpr("COALESCE(SUM(discount_amount), 0)").
+		Where("promotion_id = ?", promotionID).
+		Where("used_at >= ?", start).
+		Where("used_at < ?", end).
+		Scan(ctx, &discountAmount); err != nil {
+		return nil, err
+	}
+
+	collected, err := s.bunDB.DB().NewSelect().
+		TableExpr("promotion_events").
+		Where("promotion_id = ?", promotionID).
+		Where("event_type = ?", promotionscope.EventCollected).
+		Where("created_at >= ?", start).
+		Where("created_at < ?", end).
+		Count(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &PromotionAnalytics{
+		PromotionID:    promotion.ID.String(),
+		Code:           promotion.Code,
+		Name:           promotion.Name,
+		StartDate:      start.Format("2006-01-02"),
+		EndDate:        end.AddDate(0, 0, -1).Format("2006-01-02"),
+		Collected:      collected,
+		Redemptions:    redemptions,
+		Revenue:        decimal.Zero,
+		DiscountAmount: discountAmount.Round(2),
+		Days:           make([]*PromotionAnalyticsDay, 0),
+	}
+
+	days := make(map[string]*PromotionAnalyticsDay)
+	for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+		item := &PromotionAnalyticsDay{
+			Date:           day.Format("2006-01-02"),
+			Revenue:        decimal.Zero,
+			DiscountAmount: decimal.Zero,
+		}
+		days[item.Date] = item
+		data.Days = append(data.Days, item)
+	}
+
+	paidOrders := 0
+	for _, row := range rows {
+		day := days[row.CreatedAt.In(loc).Format("2006-01-02")]
+		if day == nil {
+			continue
+		}
+		data.Orders++
+		day.Orders++
+		day.DiscountAmount = day.DiscountAmount.Add(row.DiscountAmount)
+
+		if row.FirstOrder {
+			data.NewMembers++
+			day.NewMembers++
+		} else {
+			data.ReturningMembers++
+		}
+
+		switch {
+		case row.Refunded:
+			data.RefundedOrders++
+			day.Refunded++
+		case row.Status == ent.StatusTypeCancelled:
+			data.CancelledOrders++
+			day.Cancelled++
+		case row.Paid:
+			paidOrders++
+			data.Revenue = data.Revenue.Add(row.NetAmount)
+			day.Revenue = day.Revenue.Add(row.NetAmount)
+		}
+	}
+
+	data.Revenue = data.Revenue.Round(2)
+	if paidOrders > 0 {
+		data.AverageOrderValue = data.Revenue.Div(decimal.NewFromInt(int64(paidOrders))).Round(2)
+	}
+	if other.Orders > 0 {
+		data.AverageOrderValueOther = other.Revenue.Div(decimal.NewFromInt(int64(other.Orders))).Round(2)
+	}
+	if data.DiscountAmount.IsPositive() {
+		data.ReturnOnDiscount = data.Revenue.Div(data.DiscountAmount).Round(2)
+	}
+	data.CancellationRate = analyticsRate(data.CancelledOrders, data.Orders)
+	data.RefundRate = analyticsRate(data.RefundedOrders, data.Orders)
+	for _, day := range data.Days {
+		day.Revenue = day.Revenue.Round(2)
+		day.DiscountAmount = day.DiscountAmount.Round(2)
+	}
+
+	return data, nil
+}
+
+// ExportAnalytics renders the analytics as CSV: the daily series followed by
+// a total row, with the period-wide figures underneath.
+func (s *Service) ExportAnalytics(ctx context.Context, req *PromotionAnalyticsServiceRequest) ([]byte, string, error) {
+	data, err := s.Analytics(ctx, req)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	rows := [][]string{{"date", "orders", "revenue", "discount_amount", "new_members", "cancelled", "refunded"}}
+	for _, day := range data.Days {
+		rows = append(rows, []string{
+			day.Date,
+			strconv.Itoa(day.Orders),
+			day.Revenue.StringFixed(2),
+			day.DiscountAmount.StringFixed(2),
+			strconv.Itoa(day.NewMembers),
+			strconv.Itoa(day.Cancelled),
+			strconv.Itoa(day.Refunded),
+		})
+	}
+	rows = append(rows,
+		[]string{
+			"total",
+			strconv.Itoa(data.Orders),
+			data.Revenue.StringFixed(2),
+			data.DiscountAmount.StringFixed(2),
+			strconv.Itoa(data.NewMembers),
+			strconv.Itoa(data.CancelledOrders),
+			strconv.Itoa(data.RefundedOrders),
+		},
+		[]string{},
+		[]string{"metric", "value"},
+		[]string{"promotion_code", data.Code},
+		[]string{"promotion_name", data.Name},
+		[]string{"period", data.StartDate + " - " + data.EndDate},
+		[]string{"collected", strconv.Itoa(data.Collected)},
+		[]string{"redemptions", strconv.Itoa(data.Redemptions)},
+		[]string{"average_order_value", data.AverageOrderValue.StringFixed(2)},
+		[]string{"average_order_value_without_code", data.AverageOrderValueOther.StringFixed(2)},
+		[]string{"returning_members", strconv.Itoa(data.ReturningMembers)},
+		[]string{"cancellation_rate", data.CancellationRate.String()},
+		[]string{"refund_rate", data.RefundRate.String()},
+		[]string{"return_on_discount", data.ReturnOnDiscount.StringFixed(2)},
+	)
+
+	for _, row := range rows {
+		if err := writer.Write(row); err != nil {
+			return nil, "", err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, "", err
+	}
+
+	fileName := fmt.Sprintf("promotion-analytics-%s-%s-%s.csv", data.Code, data.StartDate, data.EndDate)
+	return buf.Bytes(), fileName, nil
+}
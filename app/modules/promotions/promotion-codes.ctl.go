@@ -0,0 +1,153 @@
+package promotions
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"phakram/app/modules/auth"
+	"phakram/app/utils/base"
+	"phakram/config/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CreatePromotionCodeBatchControllerRequest struct {
+	Prefix     string `json:"prefix"`
+	Alphabet   string `json:"alphabet"`
+	CodeLength int    `json:"code_length"`
+	Quantity   int    `json:"quantity"`
+}
+
+type ListPromotionCodesControllerRequest struct {
+	base.RequestPaginate
+	Status string `form:"status"`
+}
+
+// Generated codes work like vouchers, so only admins may create or read them.
+func requirePromotionAdmin(ctx *gin.Context) bool {
+	_, hasRequester := auth.GetMemberID(ctx)
+	if !auth.GetIsAdmin(ctx) || !hasRequester {
+		base.Forbidden(ctx, i18n.Forbidden, nil)
+		return false
+	}
+	return true
+}
+
+func parseCodeBatchParams(ctx *gin.Context) (string, string, bool) {
+	promotionID := strings.TrimSpace(ctx.Param("id"))
+	if promotionID == "" {
+		base.BadRequest(ctx, "ไม่พบรหัสโปรโมชั่น", nil)
+		return "", "", false
+	}
+	batchID := strings.TrimSpace(ctx.Param("batch_id"))
+	if batchID == "" {
+		base.BadRequest(ctx, "ไม่พบรหัสชุดโค้ด", nil)
+		return "", "", false
+	}
+	return promotionID, batchID, true
+}
+
+func (c *Controller) CreateCodeBatchController(ctx *gin.Context) {
+	if !requirePromotionAdmin(ctx) {
+		return
+	}
+	memberID, _ := auth.GetMemberID(ctx)
+
+	promotionID := strings.TrimSpace(ctx.Param("id"))
+	if promotionID == "" {
+		base.BadRequest(ctx, "ไม่พบรหัสโปรโมชั่น", nil)
+		return
+	}
+
+	var req CreatePromotionCodeBatchControllerRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	data, err := c.svc.CreateCodeBatch(ctx.Request.Context(), &CreatePromotionCodeBatchServiceRequest{
+		PromotionID: promotionID,
+		Prefix:      req.Prefix,
+		Alphabet:    req.Alphabet,
+		CodeLength:  req.CodeLength,
+		Quantity:    req.Quantity,
+		CreatedBy:   memberID,
+	})
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	base.Success(ctx, data)
+}
+
+func (c *Controller) ListCodeBatchesController(ctx *gin.Context) {
+	if !requirePromotionAdmin(ctx) {
+		return
+	}
+
+	promotionID := strings.TrimSpace(ctx.Param("id"))
+	if promotionID == "" {
+		base.BadRequest(ctx, "ไม่พบรหัสโปรโมชั่น", nil)
+		return
+	}
+
+	data, err := c.svc.ListCodeBatches(ctx.Request.Context(), promotionID)
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	base.Success(ctx, data)
+}
+
+func (c *Controller) ListCodesController(ctx *gin.Context) {
+	if !requirePromotionAdmin(ctx) {
+		return
+	}
+
+	promotionID, batchID, ok := parseCodeBatchParams(ctx)
+	if !ok {
+		return
+	}
+
+	var req ListPromotionCodesControllerRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	data, page, err := c.svc.ListCodes(ctx.Request.Context(), &ListPromotionCodesServiceRequest{
+		RequestPaginate: req.RequestPaginate,
+		PromotionID:     promotionID,
+		BatchID:         batchID,
+		Status:          req.Status,
+	})
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	base.Paginate(ctx, data, page)
+}
+
+func (c *Controller) ExportCodeBatchController(ctx *gin.Context) {
+	if !requirePromotionAdmin(ctx) {
+		return
+	}
+
+	promotionID, batchID, ok := parseCodeBatchParams(ctx)
+	if !ok {
+		return
+	}
+
+	content, fileName, err := c.svc.ExportCodeBatch(ctx.Request.Context(), promotionID, batchID)
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+	ctx.Data(http.StatusOK, "text/csv; charset=utf-8", content)
+}
@@ -0,0 +1,491 @@
+package promotions
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"phakram/app/utils/base"
+	promotionscope "phakram/app/utils/promotion"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+const (
+	defaultCodeAlphabet  = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	defaultCodeLength    = 10
+	minCodeLength        = 6
+	maxCodeLength        = 32
+	maxCodePrefixLength  = 20
+	maxCodeBatchQuantity = 50000
+	codeInsertChunkSize  = 1000
+	// maxCodeChunkCollisions bounds how many generated codes one chunk may
+	// throw away as duplicates before the code space counts as exhausted.
+	maxCodeChunkCollisions = codeInsertChunkSize
+)
+
+type promotionCodeBatchRecord struct {
+	bun.BaseModel `bun:"table:promotion_code_batches"`
+
+	ID          uuid.UUID  `bun:"id,pk,type:uuid"`
+	PromotionID uuid.UUID  `bun:"promotion_id,type:uuid,notnull"`
+	Prefix      string     `bun:"prefix,notnull"`
+	Alphabet    string     `bun:"alphabet,notnull"`
+	CodeLength  int        `bun:"code_length,notnull"`
+	Quantity    int        `bun:"quantity,notnull"`
+	CreatedBy   *uuid.UUID `bun:"created_by,type:uuid"`
+	CreatedAt   time.Time  `bun:"created_at,notnull"`
+}
+
+type promotionCodeRecord struct {
+	bun.BaseModel `bun:"table:promotion_codes"`
+
+	ID          uuid.UUID  `bun:"id,pk,type:uuid"`
+	PromotionID uuid.UUID  `bun:"promotion_id,type:uuid,notnull"`
+	BatchID     uuid.UUID  `bun:"batch_id,type:uuid,notnull"`
+	Code        string     `bun:"code,notnull"`
+	Status      string     `bun:"status,notnull"`
+	RedeemedBy  *uuid.UUID `bun:"redeemed_by,type:uuid"`
+	OrderID     *uuid.UUID `bun:"order_id,type:uuid"`
+	RedeemedAt  *time.Time `bun:"redeemed_at"`
+	CreatedAt   time.Time  `bun:"created_at,notnull"`
+	UpdatedAt   time.Time  `bun:"updated_at,notnull"`
+}
+
+type PromotionCodeBatchItem struct {
+	ID            string `json:"id"`
+	PromotionID   string `json:"promotion_id"`
+	Prefix        string `json:"prefix"`
+	Alphabet      string `json:"alphabet"`
+	CodeLength    int    `json:"code_length"`
+	Quantity      int    `json:"quantity"`
+	RedeemedCount int    `json:"redeemed_count"`
+	CreatedBy     string `json:"created_by,omitempty"`
+	CreatedAt     string `json:"created_at"`
+}
+
+type PromotionCodeItem struct {
+	ID         string  `json:"id"`
+	Code       string  `json:"code"`
+	Status     string  `json:"status"`
+	RedeemedBy *string `json:"redeemed_by"`
+	OrderID    *string `json:"order_id"`
+	RedeemedAt *string `json:"redeemed_at"`
+	CreatedAt  string  `json:"created_at"`
+}
+
+type CreatePromotionCodeBatchServiceRequest struct {
+	PromotionID string
+	Prefix      string
+	Alphabet    string
+	CodeLength  int
+	Quantity    int
+	CreatedBy   uuid.UUID
+}
+
+type ListPromotionCodesServiceRequest struct {
+	base.RequestPaginate
+	PromotionID string
+	BatchID     string
+	Status      string
+}
+
+type promotionCodeBatchRow struct {
+	promotionCodeBatchRecord `bun:",extend"`
+	RedeemedCount            int `bun:"redeemed_count"`
+}
+
+func normalizeCodePrefix(value string) (string, error) {
+	prefix := strings.ToUpper(strings.TrimSpace(value))
+	if len(prefix) > maxCodePrefixLength {
+		return "", fmt.Errorf("invalid promotion code prefix")
+	}
+	for _, r := range prefix {
+		if !(r >= 'A' && r <= 'Z') && !(r >= '0' && r <= '9') && r != '-' {
+			return "", fmt.Errorf("invalid promotion code prefix")
+		}
+	}
+	return prefix, nil
+}
+
+// normalizeCodeAlphabet upper-cases the alphabet and drops duplicate
+// characters so every symbol is equally likely.
+func normalizeCodeAlphabet(value string) (string, error) {
+	alphabet := strings.ToUpper(strings.TrimSpace(value))
+	if alphabet == "" {
+		return defaultCodeAlphabet, nil
+	}
+
+	seen := make(map[rune]struct{}, len(alphabet))
+	var b strings.Builder
+	for _, r := range alphabet {
+		if !(r >= 'A' && r <= 'Z') && !(r >= '0' && r <= '9') {
+			return "", fmt.Errorf("invalid promotion code alphabet")
+		}
+		if _, ok := seen[r]; ok {
+			continue
+		}
+		seen[r] = struct{}{}
+		b.WriteRune(r)
+	}
+	if b.Len() < 2 {
+		return "", fmt.Errorf("invalid promotion code alphabet")
+	}
+	return b.String(), nil
+}
+
+func generatePromotionCode(prefix string, alphabet string, length int) (string, error) {
+	max := big.NewInt(int64(len(alphabet)))
+	buf := make([]byte, length)
+	for i := range buf {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		buf[i] = alphabet[n.Int64()]
+	}
+	return prefix + string(buf), nil
+}
+
+func toPromotionCodeBatchItem(row *promotionCodeBatchRow) *PromotionCodeBatchItem {
+	item := &PromotionCodeBatchItem{
+		ID:            row.ID.String(),
+		PromotionID:   row.PromotionID.String(),
+		Prefix:        row.Prefix,
+		Alphabet:      row.Alphabet,
+		CodeLength:    row.CodeLength,
+		Quantity:      row.Quantity,
+		RedeemedCount: row.RedeemedCount,
+		CreatedAt:     row.CreatedAt.Format(time.RFC3339),
+	}
+	if row.CreatedBy != nil {
+		item.CreatedBy = row.CreatedBy.String()
+	}
+	return item
+}
+
+func toPromotionCodeItem(record *promotionCodeRecord) *PromotionCodeItem {
+	return &PromotionCodeItem{
+		ID:         record.ID.String(),
+		Code:       record.Code,
+		Status:     record.Status,
+		RedeemedBy: formatOptionalUUID(record.RedeemedBy),
+		OrderID:    formatOptionalUUID(record.OrderID),
+		RedeemedAt: formatOptionalTime(record.RedeemedAt),
+		CreatedAt:  record.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// CreateCodeBatch generates quantity unique single-use codes for a promotion.
+// Codes that collide with an existing child code or promotion master code are
+// dropped and regenerated until the batch is full.
+func (s *Service) CreateCodeBatch(ctx context.Context, req *CreatePromotionCodeBatchServiceRequest) (*PromotionCodeBatchItem, error) {
+	promotionID, err := uuid.Parse(strings.TrimSpace(req.PromotionID))
+	if err != nil {
+		return nil, err
+	}
+	if req.Quantity <= 0 || req.Quantity > maxCodeBatchQuantity {
+		return nil, fmt.Errorf("invalid promotion code quantity")
+	}
+	codeLength := req.CodeLength
+	if codeLength == 0 {
+		codeLength = defaultCodeLength
+	}
+	if codeLength < minCodeLength || codeLength > maxCodeLength {
+		return nil, fmt.Errorf("invalid promotion code length")
+	}
+	prefix, err := normalizeCodePrefix(req.Prefix)
+	if err != nil {
+		return nil, err
+	}
+	alphabet, err := normalizeCodeAlphabet(req.Alphabet)
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := s.bunDB.DB().NewSelect().
+		Model((*promotionRecord)(nil)).
+		Where("id = ?", promotionID).
+		Exists(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, sql.ErrNoRows
+	}
+
+	now := time.Now().UTC()
+	batch := &promotionCodeBatchRecord{
+		ID:          uuid.New(),
+		PromotionID: promotionID,
+		Prefix:      prefix,
+		Alphabet:    alphabet,
+		CodeLength:  codeLength,
+		Quantity:    req.Quantity,
+		CreatedAt:   now,
+	}
+	if req.CreatedBy != uuid.Nil {
+		createdBy := req.CreatedBy
+		batch.CreatedBy = &createdBy
+	}
+
+	if err := s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewInsert().Model(batch).Exec(ctx); err != nil {
+			return err
+		}
+
+		return s.insertPromotionCodesInTx(ctx, tx, batch, req.Quantity, now)
+	}); err != nil {
+		return nil, err
+	}
+
+	return toPromotionCodeBatchItem(&promotionCodeBatchRow{promotionCodeBatchRecord: *batch}), nil
+}
+
+// insertPromotionCodesInTx inserts quantity fresh codes in chunks. Codes that
+// collide with existing ones are regenerated within their chunk; a chunk
+// that keeps colliding means the code space is nearly used up.
+func (s *Service) insertPromotionCodesInTx(ctx context.Context, tx bun.Tx, batch *promotionCodeBatchRecord, quantity int, now time.Time) error {
+	for inserted := 0; inserted < quantity; {
+		size := min(quantity-inserted, codeInsertChunkSize)
+		chunkInserted, err := s.insertPromotionCodeChunkInTx(ctx, tx, batch, size, now)
+		if err != nil {
+			return err
+		}
+		inserted += chunkInserted
+	}
+	return nil
+}
+
+func (s *Service) insertPromotionCodeChunkInTx(ctx context.Context, tx bun.Tx, batch *promotionCodeBatchRecord, size int, now time.Time) (int, error) {
+	inserted := 0
+	collisions := 0
+	tried := make(map[string]struct{}, size)
+	for inserted < size {
+		pending := size - inserted
+		codes := make([]string, 0, pending)
+		for len(codes) < pending {
+			if collisions > maxCodeChunkCollisions {
+				return 0, fmt.Errorf("promotion code space is exhausted")
+			}
+			code, err := generatePromotionCode(batch.Prefix, batch.Alphabet, batch.CodeLength)
+			if err != nil {
+				return 0, err
+			}
+			if _, ok := tried[code]; ok {
+				collisions++
+				continue
+			}
+			tried[code] = struct{}{}
+			codes = append(codes, code)
+		}
+
+		taken := make([]string, 0)
+		if err := tx.NewSelect().
+			Model((*promotionRecord)(nil)).
+			Column("code").
+			Where("code IN (?)", bun.In(codes)).
+			Scan(ctx, &taken); err != nil {
+			return 0, err
+		}
+		takenSet := make(map[string]struct{}, len(taken))
+		for _, code := range taken {
+			takenSet[code] = struct{}{}
+		}
+
+		records := make([]*promotionCodeRecord, 0, len(codes))
+		for _, code := range codes {
+			if _, ok := takenSet[code]; ok {
+				continue
+			}
+			records = append(records, &promotionCodeRecord{
+				ID:          uuid.New(),
+				PromotionID: batch.PromotionID,
+				BatchID:     batch.ID,
+				Code:        code,
+				Status:      promotionscope.CodeStatusAvailable,
+				CreatedAt:   now,
+				UpdatedAt:   now,
+			})
+		}
+
+		affected := 0
+		if len(records) > 0 {
+			res, err := tx.NewInsert().
+				Model(&records).
+				On("CONFLICT (code) DO NOTHING").
+				Exec(ctx)
+			if err != nil {
+				return 0, err
+			}
+			rows, err := res.RowsAffected()
+			if err != nil {
+				return 0, err
+			}
+			affected = int(rows)
+		}
+		inserted += affected
+		collisions += len(codes) - affected
+	}
+	return inserted, nil
+}
+
+func (s *Service) ListCodeBatches(ctx context.Context, promotionID string) ([]*PromotionCodeBatchItem, error) {
+	id, err := uuid.Parse(strings.TrimSpace(promotionID))
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]*promotionCodeBatchRow, 0)
+	if err := s.bunDB.DB().NewSelect().
+		TableExpr("promotion_code_batches AS pcb").
+		ColumnExpr("pcb.*").
+		ColumnExpr("(SELECT COUNT(*) FROM promotion_codes AS pc WHERE pc.batch_id = pcb.id AND pc.status = ?) AS redeemed_count", promotionscope.CodeStatusRedeemed).
+		Where("pcb.promotion_id = ?", id).
+		OrderExpr("pcb.created_at DESC").
+		Scan(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	items := make([]*PromotionCodeBatchItem, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, toPromotionCodeBatchItem(row))
+	}
+	return items, nil
+}
+
+func (s *Service) getCodeBatch(ctx context.Context, promotionID string, batchID string) (*promotionCodeBatchRecord, error) {
+	parsedPromotionID, err := uuid.Parse(strings.TrimSpace(promotionID))
+	if err != nil {
+		return nil, err
+	}
+	parsedBatchID, err := uuid.Parse(strings.TrimSpace(batchID))
+	if err != nil {
+		return nil, err
+	}
+
+	batch := new(promotionCodeBatchRecord)
+	if err := s.bunDB.DB().NewSelect().
+		Model(batch).
+		Where("id = ?", parsedBatchID).
+		Where("promotion_id = ?", parsedPromotionID).
+		Limit(1).
+		Scan(ctx); err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+func (s *Service) ListCodes(ctx context.Context, req *ListPromotionCodesServiceRequest) ([]*PromotionCodeItem, *base.ResponsePaginate, error) {
+	batch, err := s.getCodeBatch(ctx, req.PromotionID, req.BatchID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	status := strings.ToLower(strings.TrimSpace(req.Status))
+	switch status {
+	case "", promotionscope.CodeStatusAvailable, promotionscope.CodeStatusRedeemed, promotionscope.CodeStatusDisabled:
+	default:
+		return nil, nil, fmt.Errorf("invalid promotion code status")
+	}
+
+	records := make([]*promotionCodeRecord, 0)
+	query := s.bunDB.DB().NewSelect().
+		Model(&records).
+		Where("batch_id = ?", batch.ID)
+	if status != "" {
+		query.Where("status = ?", status)
+	}
+	if search := strings.ToUpper(strings.TrimSpace(req.Search)); search != "" {
+		query.Where("code LIKE ?", "%"+search+"%")
+	}
+
+	total, err := query.Count(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req.SetOffsetLimit(query)
+	if err := query.OrderExpr("code ASC").Scan(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	items := make([]*PromotionCodeItem, 0, len(records))
+	for _, record := range records {
+		items = append(items, toPromotionCodeItem(record))
+	}
+
+	return items, &base.ResponsePaginate{
+		Page:  req.GetPage(),
+		Size:  req.GetSize(),
+		Total: int64(total),
+	}, nil
+}
+
+// ExportCodeBatch renders every code of the batch as CSV for handing over to
+// influencers or loading into a CRM tool.
+func (s *Service) ExportCodeBatch(ctx context.Context, promotionID string, batchID string) ([]byte, string, error) {
+	batch, err := s.getCodeBatch(ctx, promotionID, batchID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	records := make([]*promotionCodeRecord, 0, batch.Quantity)
+	if err := s.bunDB.DB().NewSelect().
+		Model(&records).
+		Where("batch_id = ?", batch.ID).
+		OrderExpr("code ASC").
+		Scan(ctx); err != nil {
+		return nil, "", err
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.Write([]string{"code", "status", "redeemed_at", "order_id"}); err != nil {
+		return nil, "", err
+	}
+	for _, record := range records {
+		redeemedAt := ""
+		if record.RedeemedAt != nil {
+			redeemedAt = record.RedeemedAt.Format(time.RFC3339)
+		}
+		orderID := ""
+		if record.OrderID != nil {
+			orderID = record.OrderID.String()
+		}
+		if err := writer.Write([]string{record.Code, record.Status, redeemedAt, orderID}); err != nil {
+			return nil, "", err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, "", err
+	}
+
+	fileName := fmt.Sprintf("promotion-codes-%s.csv", batch.ID.String())
+	return buf.Bytes(), fileName, nil
+}
+
+// ensureCodeNotGenerated keeps master codes from shadowing a generated child
+// code, since lookups try master codes first.
+func (s *Service) ensureCodeNotGenerated(ctx context.Context, code string) error {
+	exists, err := s.bunDB.DB().NewSelect().
+		Model((*promotionCodeRecord)(nil)).
+		Where("code = ?", strings.ToUpper(strings.TrimSpace(code))).
+		Exists(ctx)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return errors.New("promotion code already exists")
+	}
+	return nil
+}
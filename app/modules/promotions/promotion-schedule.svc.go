@@ -0,0 +1,184 @@
+package promotions
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"phakram/app/modules/entities/ent"
+	promotionscope "phakram/app/utils/promotion"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+const expiryReminderBatchSize = 500
+
+type PromotionScheduleResult struct {
+	Activated int `json:"activated"`
+	Expired   int `json:"expired"`
+	Reminded  int `json:"reminded"`
+}
+
+type expiryReminderRow struct {
+	CollectionID uuid.UUID `bun:"collection_id"`
+	MemberID     uuid.UUID `bun:"member_id"`
+	PromotionID  uuid.UUID `bun:"promotion_id"`
+	Code         string    `bun:"code"`
+	Name         string    `bun:"name"`
+	EndsAt       time.Time `bun:"ends_at"`
+}
+
+// RunSchedule moves promotions across their start and end dates and reminds
+// members about collected promotions that are about to expire. It is safe to
+// run repeatedly; every step only picks up rows it has not handled yet.
+func (s *Service) RunSchedule(ctx context.Context, now time.Time) (*PromotionScheduleResult, error) {
+	now = now.UTC()
+	result := &PromotionScheduleResult{}
+
+	activated, err := s.activateScheduledPromotions(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	result.Activated = activated
+
+	expired, err := s.expireEndedPromotions(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	result.Expired = expired
+
+	if s.conf != nil && s.conf.ExpiryReminderDays > 0 {
+		reminded, err := s.sendExpiryReminders(ctx, now, s.conf.ExpiryReminderDays)
+		if err != nil {
+			return nil, err
+		}
+		result.Reminded = reminded
+	}
+
+	return result, nil
+}
+
+func (s *Service) activateScheduledPromotions(ctx context.Context, now time.Time) (int, error) {
+	ids := make([]uuid.UUID, 0)
+	err := s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewUpdate().
+			Model((*promotionRecord)(nil)).
+			Set("is_active = true").
+			Set("lifecycle_status = ?", promotionscope.LifecycleActive).
+			Set("updated_at = ?", now).
+			Where("lifecycle_status = ?", promotionscope.LifecycleScheduled).
+			Where("(starts_at IS NULL OR starts_at <= ?)", now).
+			Where("(ends_at IS NULL OR ends_at > ?)", now).
+			Returning("id").
+			Exec(ctx, &ids); err != nil {
+			return err
+		}
+		return promotionscope.RecordEvents(ctx, tx, lifecycleEvents(ids, promotionscope.EventActivated)...)
+	})
+	return len(ids), err
+}
+
+func (s *Service) expireEndedPromotions(ctx context.Context, now time.Time) (int, error) {
+	ids := make([]uuid.UUID, 0)
+	err := s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewUpdate().
+			Model((*promotionRecord)(nil)).
+			Set("is_active = false").
+			Set("lifecycle_status = ?", promotionscope.LifecycleExpired).
+			Set("updated_at = ?", now).
+			Where("lifecycle_status <> ?", promotionscope.LifecycleExpired).
+			Where("ends_at IS NOT NULL").
+			Where("ends_at <= ?", now).
+			Returning("id").
+			Exec(ctx, &ids); err != nil {
+			return err
+		}
+		return promotionscope.RecordEvents(ctx, tx, lifecycleEvents(ids, promotionscope.EventExpired)...)
+	})
+	return len(ids), err
+}
+
+func lifecycleEvents(ids []uuid.UUID, eventType string) []promotionscope.Event {
+	events := make([]promotionscope.Event, 0, len(ids))
+	for _, id := range ids {
+		events = append(events, promotionscope.Event{PromotionID: id, Type: eventType})
+	}
+	return events
+}
+
+// sendExpiryReminders notifies each member once per collected promotion that
+// ends within days and that they have not used yet. The reminder is an audit
+// log entry, which is what the member notification feed reads.
+func (s *Service) sendExpiryReminders(ctx context.Context, now time.Time, days int) (int, error) {
+	reminded := 0
+	for {
+		rows := make([]*expiryReminderRow, 0)
+		if err := s.bunDB.DB().NewSelect().
+			TableExpr("member_promotion_collections AS mpc").
+			Join("JOIN promotions AS p ON p.id = mpc.promotion_id").
+			ColumnExpr("mpc.id AS collection_id").
+			ColumnExpr("mpc.member_id").
+			ColumnExpr("p.id AS promotion_id").
+			ColumnExpr("p.code").
+			ColumnExpr("p.name").
+			ColumnExpr("p.ends_at").
+			Where("mpc.expiry_reminded_at IS NULL").
+			Where("p.is_active = true").
+			Where("p.ends_at > ?", now).
+			Where("p.ends_at <= ?", now.AddDate(0, 0, days)).
+			Where("NOT EXISTS (SELECT 1 FROM promotion_usages AS pu WHERE pu.promotion_id = p.id AND pu.member_id = mpc.member_id)").
+			OrderExpr("p.ends_at ASC, mpc.id ASC").
+			Limit(expiryReminderBatchSize).
+			Scan(ctx, &rows); err != nil {
+			return reminded, err
+		}
+		if len(rows) == 0 {
+			return reminded, nil
+		}
+
+		if err := s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			collectionIDs := make([]uuid.UUID, 0, len(rows))
+			logs := make([]*ent.AuditLogEntity, 0, len(rows))
+			events := make([]promotionscope.Event, 0, len(rows))
+			for _, row := range rows {
+				collectionIDs = append(collectionIDs, row.CollectionID)
+				logs = append(logs, &ent.AuditLogEntity{
+					ID:           uuid.New(),
+					Action:       ent.AuditActionCreated,
+					ActionType:   "promotion_expiry_reminder",
+					ActionID:     row.CollectionID,
+					Status:       ent.StatusAuditSuccesses,
+					ActionDetail: fmt.Sprintf("code=%s; ends_at=%s; promotion=%s", row.Code, row.EndsAt.Format(time.RFC3339), row.Name),
+					CreatedAt:    now,
+					UpdatedAt:    now,
+				})
+				memberID := row.MemberID
+				events = append(events, promotionscope.Event{
+					PromotionID: row.PromotionID,
+					Type:        promotionscope.EventExpiryReminded,
+					MemberID:    &memberID,
+				})
+			}
+
+			if _, err := tx.NewInsert().Model(&logs).Exec(ctx); err != nil {
+				return err
+			}
+			if _, err := tx.NewUpdate().
+				Model((*memberPromotionCollectionRecord)(nil)).
+				Set("expiry_reminded_at = ?", now).
+				Where("id IN (?)", bun.In(collectionIDs)).
+				Exec(ctx); err != nil {
+				return err
+			}
+			return promotionscope.RecordEvents(ctx, tx, events...)
+		}); err != nil {
+			return reminded, err
+		}
+
+		reminded += len(rows)
+		if len(rows) < expiryReminderBatchSize {
+			return reminded, nil
+		}
+	}
+}
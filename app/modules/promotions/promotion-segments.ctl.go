@@ -0,0 +1,78 @@
+package promotions
+
+import (
+	"strings"
+
+	"phakram/app/utils/base"
+	"phakram/config/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReplacePromotionSegmentMembersControllerRequest struct {
+	FileBase64 string   `json:"file_base64"`
+	Members    []string `json:"members"`
+}
+
+type ListPromotionSegmentMembersControllerRequest struct {
+	base.RequestPaginate
+}
+
+func (c *Controller) ReplaceSegmentMembersController(ctx *gin.Context) {
+	if !requirePromotionAdmin(ctx) {
+		return
+	}
+
+	promotionID := strings.TrimSpace(ctx.Param("id"))
+	if promotionID == "" {
+		base.BadRequest(ctx, "ไม่พบรหัสโปรโมชั่น", nil)
+		return
+	}
+
+	var req ReplacePromotionSegmentMembersControllerRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	data, err := c.svc.ReplaceSegmentMembers(ctx.Request.Context(), &ReplacePromotionSegmentMembersServiceRequest{
+		PromotionID: promotionID,
+		FileBase64:  req.FileBase64,
+		Members:     req.Members,
+	})
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	base.Success(ctx, data)
+}
+
+func (c *Controller) ListSegmentMembersController(ctx *gin.Context) {
+	if !requirePromotionAdmin(ctx) {
+		return
+	}
+
+	promotionID := strings.TrimSpace(ctx.Param("id"))
+	if promotionID == "" {
+		base.BadRequest(ctx, "ไม่พบรหัสโปรโมชั่น", nil)
+		return
+	}
+
+	var req ListPromotionSegmentMembersControllerRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	data, page, err := c.svc.ListSegmentMembers(ctx.Request.Context(), &ListPromotionSegmentMembersServiceRequest{
+		RequestPaginate: req.RequestPaginate,
+		PromotionID:     promotionID,
+	})
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	base.Paginate(ctx, data, page)
+}
@@ -0,0 +1,277 @@
+package promotions
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"phakram/app/utils/base"
+	promotionscope "phakram/app/utils/promotion"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+const maxSegmentMembers = 100000
+
+type ReplacePromotionSegmentMembersServiceRequest struct {
+	PromotionID string
+	FileBase64  string
+	Members     []string
+}
+
+type PromotionSegmentMembersResult struct {
+	Matched   int      `json:"matched"`
+	Unmatched []string `json:"unmatched"`
+}
+
+type ListPromotionSegmentMembersServiceRequest struct {
+	base.RequestPaginate
+	PromotionID string
+}
+
+type PromotionSegmentMemberItem struct {
+	MemberID  string `json:"member_id"`
+	MemberNo  string `json:"member_no"`
+	Name      string `json:"name"`
+	CreatedAt string `json:"created_at"`
+}
+
+type segmentMemberRow struct {
+	ID       uuid.UUID `bun:"id"`
+	MemberNo string    `bun:"member_no"`
+}
+
+// decodeSegmentMemberFile reads the first column of an uploaded CSV file,
+// skipping a member_no or member_id header row.
+func decodeSegmentMemberFile(input string) ([]string, error) {
+	raw := strings.TrimSpace(input)
+	if raw == "" {
+		return nil, nil
+	}
+	if strings.HasPrefix(raw, "data:") {
+		parts := strings.SplitN(raw, ",", 2)
+		if len(parts) != 2 {
+			return nil, errors.New("invalid data url format")
+		}
+		raw = parts[1]
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil {
+		decoded, err = base64.RawStdEncoding.DecodeString(raw)
+		if err != nil {
+			return nil, errors.New("invalid member list file")
+		}
+	}
+	decoded = bytes.TrimPrefix(decoded, []byte("\xef\xbb\xbf"))
+
+	reader := csv.NewReader(bytes.NewReader(decoded))
+	reader.FieldsPerRecord = -1
+	values := make([]string, 0)
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, errors.New("invalid member list file")
+		}
+		if len(record) == 0 {
+			continue
+		}
+		value := strings.TrimSpace(record[0])
+		if value == "" {
+			continue
+		}
+		if len(values) == 0 && isSegmentMemberHeader(value) {
+			continue
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+func isSegmentMemberHeader(value string) bool {
+	switch strings.ToLower(value) {
+	case "member_no", "member_id", "id", "member":
+		return true
+	}
+	return false
+}
+
+// resolveSegmentMembers matches identifiers by member ID or member number.
+func (s *Service) resolveSegmentMembers(ctx context.Context, identifiers []string) ([]uuid.UUID, []string, error) {
+	ids := make([]uuid.UUID, 0)
+	memberNos := make([]string, 0)
+	for _, identifier := range identifiers {
+		if id, err := uuid.Parse(identifier); err == nil {
+			ids = append(ids, id)
+			continue
+		}
+		memberNos = append(memberNos, identifier)
+	}
+
+	rows := make([]*segmentMemberRow, 0)
+	if len(ids) > 0 || len(memberNos) > 0 {
+		query := s.bunDB.DB().NewSelect().
+			TableExpr("members").
+			Column("id", "member_no").
+			Where("deleted_at IS NULL")
+		switch {
+		case len(ids) > 0 && len(memberNos) > 0:
+			query.Where("(id IN (?) OR member_no IN (?))", bun.In(ids), bun.In(memberNos))
+		case len(ids) > 0:
+			query.Where("id IN (?)", bun.In(ids))
+		default:
+			query.Where("member_no IN (?)", bun.In(memberNos))
+		}
+		if err := query.Scan(ctx, &rows); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	known := make(map[string]uuid.UUID, len(rows)*2)
+	for _, row := range rows {
+		known[row.ID.String()] = row.ID
+		if row.MemberNo != "" {
+			known[row.MemberNo] = row.ID
+		}
+	}
+
+	matched := make([]uuid.UUID, 0, len(identifiers))
+	unmatched := make([]string, 0)
+	for _, identifier := range identifiers {
+		key := identifier
+		if id, err := uuid.Parse(identifier); err == nil {
+			key = id.String()
+		}
+		if id, ok := known[key]; ok {
+			matched = append(matched, id)
+			continue
+		}
+		unmatched = append(unmatched, identifier)
+	}
+	return matched, unmatched, nil
+}
+
+// ReplaceSegmentMembers replaces the uploaded member list of a promotion.
+// An empty upload clears the list so the other segment rules apply alone.
+func (s *Service) ReplaceSegmentMembers(ctx context.Context, req *ReplacePromotionSegmentMembersServiceRequest) (*PromotionSegmentMembersResult, error) {
+	promotionID, err := uuid.Parse(strings.TrimSpace(req.PromotionID))
+	if err != nil {
+		return nil, err
+	}
+	exists, err := s.bunDB.DB().NewSelect().
+		Model((*promotionRecord)(nil)).
+		Where("id = ?", promotionID).
+		Exists(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, sql.ErrNoRows
+	}
+
+	identifiers, err := decodeSegmentMemberFile(req.FileBase64)
+	if err != nil {
+		return nil, err
+	}
+	for _, member := range req.Members {
+		if value := strings.TrimSpace(member); value != "" {
+			identifiers = append(identifiers, value)
+		}
+	}
+	if len(identifiers) > maxSegmentMembers {
+		return nil, fmt.Errorf("member list is too large")
+	}
+
+	matched, unmatched, err := s.resolveSegmentMembers(ctx, identifiers)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if err := promotionscope.ReplaceSegmentInTx(ctx, tx, promotionID, promotionscope.SegmentMember, matched); err != nil {
+			return err
+		}
+		_, err := tx.NewUpdate().
+			Model((*promotionRecord)(nil)).
+			Set("updated_at = ?", time.Now().UTC()).
+			Where("id = ?", promotionID).
+			Exec(ctx)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	counts, err := promotionscope.CountSegmentMembers(ctx, s.bunDB.DB(), []uuid.UUID{promotionID})
+	if err != nil {
+		return nil, err
+	}
+	return &PromotionSegmentMembersResult{
+		Matched:   counts[promotionID],
+		Unmatched: unmatched,
+	}, nil
+}
+
+func (s *Service) ListSegmentMembers(ctx context.Context, req *ListPromotionSegmentMembersServiceRequest) ([]*PromotionSegmentMemberItem, *base.ResponsePaginate, error) {
+	promotionID, err := uuid.Parse(strings.TrimSpace(req.PromotionID))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	query := s.bunDB.DB().NewSelect().
+		TableExpr("promotion_segments AS ps").
+		Join("JOIN members AS m ON m.id = ps.target_id").
+		Where("ps.promotion_id = ?", promotionID).
+		Where("ps.segment_type = ?", promotionscope.SegmentMember)
+	search := strings.TrimSpace(req.Search)
+	if search != "" {
+		query.Where("(m.member_no ILIKE ? OR m.firstname_th ILIKE ? OR m.lastname_th ILIKE ?)", "%"+search+"%", "%"+search+"%", "%"+search+"%")
+	}
+
+	total, err := query.Count(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	type row struct {
+		MemberID    uuid.UUID `bun:"member_id"`
+		MemberNo    string    `bun:"member_no"`
+		FirstnameTh string    `bun:"firstname_th"`
+		LastnameTh  string    `bun:"lastname_th"`
+		CreatedAt   time.Time `bun:"created_at"`
+	}
+	rows := make([]*row, 0)
+	query.
+		ColumnExpr("m.id AS member_id").
+		ColumnExpr("m.member_no").
+		ColumnExpr("m.firstname_th").
+		ColumnExpr("m.lastname_th").
+		ColumnExpr("ps.created_at").
+		OrderExpr("m.member_no ASC")
+	req.SetOffsetLimit(query)
+	if err := query.Scan(ctx, &rows); err != nil {
+		return nil, nil, err
+	}
+
+	items := make([]*PromotionSegmentMemberItem, 0, len(rows))
+	for _, item := range rows {
+		items = append(items, &PromotionSegmentMemberItem{
+			MemberID:  item.MemberID.String(),
+			MemberNo:  item.MemberNo,
+			Name:      strings.TrimSpace(item.FirstnameTh + " " + item.LastnameTh),
+			CreatedAt: item.CreatedAt.Format(time.RFC3339),
+		})
+	}
+
+	return items, &base.ResponsePaginate{Page: req.GetPage(), Size: req.GetSize(), Total: int64(total)}, nil
+}
@@ -0,0 +1,53 @@
+package promotions
+
+import (
+	"phakram/app/modules/auth"
+	"phakram/app/utils/base"
+	"phakram/config/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UpdatePromotionSettingControllerRequest struct {
+	MaxDiscountAmount  string `json:"max_discount_amount"`
+	MaxDiscountPercent string `json:"max_discount_percent"`
+}
+
+func (c *Controller) GetSettingController(ctx *gin.Context) {
+	if !requirePromotionAdmin(ctx) {
+		return
+	}
+
+	data, err := c.svc.GetSetting(ctx.Request.Context())
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	base.Success(ctx, data)
+}
+
+func (c *Controller) UpdateSettingController(ctx *gin.Context) {
+	if !requirePromotionAdmin(ctx) {
+		return
+	}
+	memberID, _ := auth.GetMemberID(ctx)
+
+	var req UpdatePromotionSettingControllerRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		base.BadRequest(ctx, i18n.BadRequest, nil)
+		return
+	}
+
+	data, err := c.svc.UpdateSetting(ctx.Request.Context(), &UpdatePromotionSettingServiceRequest{
+		MaxDiscountAmount:  req.MaxDiscountAmount,
+		MaxDiscountPercent: req.MaxDiscountPercent,
+		UpdatedBy:          memberID,
+	})
+	if err != nil {
+		base.HandleError(ctx, err)
+		return
+	}
+
+	base.Success(ctx, data)
+}
@@ -0,0 +1,146 @@
+package promotions
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/uptrace/bun"
+)
+
+type promotionSettingRecord struct {
+	bun.BaseModel `bun:"table:promotion_settings"`
+
+	ID                 uuid.UUID        `bun:"id,pk,type:uuid"`
+	MaxDiscountAmount  *decimal.Decimal `bun:"max_discount_amount"`
+	MaxDiscountPercent *decimal.Decimal `bun:"max_discount_percent"`
+	UpdatedBy          *uuid.UUID       `bun:"updated_by,type:uuid"`
+	CreatedAt          time.Time        `bun:"created_at,notnull"`
+	UpdatedAt          time.Time        `bun:"updated_at,notnull"`
+}
+
+type PromotionSettingItem struct {
+	MaxDiscountAmount  *string `json:"max_discount_amount"`
+	MaxDiscountPercent *string `json:"max_discount_percent"`
+	UpdatedAt          *string `json:"updated_at"`
+}
+
+// UpdatePromotionSettingServiceRequest sets the order-level discount cap.
+// Empty values remove the corresponding limit.
+type UpdatePromotionSettingServiceRequest struct {
+	MaxDiscountAmount  string
+	MaxDiscountPercent string
+	UpdatedBy          uuid.UUID
+}
+
+func parseOptionalLimit(value string) (*decimal.Decimal, error) {
+	trimmed := strings.TrimSpace(value)
+	if trimmed == "" {
+		return nil, nil
+	}
+	parsed, err := decimal.NewFromString(trimmed)
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
+
+func formatOptionalDecimal(value *decimal.Decimal) *string {
+	if value == nil {
+		return nil
+	}
+	formatted := value.StringFixed(2)
+	return &formatted
+}
+
+func toPromotionSettingItem(record *promotionSettingRecord) *PromotionSettingItem {
+	item := &PromotionSettingItem{
+		MaxDiscountAmount:  formatOptionalDecimal(record.MaxDiscountAmount),
+		MaxDiscountPercent: formatOptionalDecimal(record.MaxDiscountPercent),
+	}
+	if !record.UpdatedAt.IsZero() {
+		updatedAt := record.UpdatedAt.Format(time.RFC3339)
+		item.UpdatedAt = &updatedAt
+	}
+	return item
+}
+
+func (s *Service) getSetting(ctx context.Context, db bun.IDB) (*promotionSettingRecord, error) {
+	record := new(promotionSettingRecord)
+	if err := db.NewSelect().
+		Model(record).
+		OrderExpr("updated_at DESC").
+		Limit(1).
+		Scan(ctx); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &promotionSettingRecord{}, nil
+		}
+		return nil, err
+	}
+	return record, nil
+}
+
+func (s *Service) GetSetting(ctx context.Context) (*PromotionSettingItem, error) {
+	record, err := s.getSetting(ctx, s.bunDB.DB())
+	if err != nil {
+		return nil, err
+	}
+	return toPromotionSettingItem(record), nil
+}
+
+func (s *Service) UpdateSetting(ctx context.Context, req *UpdatePromotionSettingServiceRequest) (*PromotionSettingItem, error) {
+	maxAmount, err := parseOptionalLimit(req.MaxDiscountAmount)
+	if err != nil {
+		return nil, err
+	}
+	if maxAmount != nil && maxAmount.IsNegative() {
+		return nil, fmt.Errorf("invalid maximum order discount")
+	}
+	maxPercent, err := parseOptionalLimit(req.MaxDiscountPercent)
+	if err != nil {
+		return nil, err
+	}
+	if maxPercent != nil && (maxPercent.IsNegative() || maxPercent.GreaterThan(decimal.NewFromInt(100))) {
+		return nil, fmt.Errorf("invalid maximum order discount")
+	}
+
+	record := new(promotionSettingRecord)
+	if err := s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		current, err := s.getSetting(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		updatedBy := req.UpdatedBy
+		*record = promotionSettingRecord{
+			ID:                 current.ID,
+			MaxDiscountAmount:  maxAmount,
+			MaxDiscountPercent: maxPercent,
+			UpdatedBy:          &updatedBy,
+			CreatedAt:          current.CreatedAt,
+			UpdatedAt:          now,
+		}
+		if current.ID == uuid.Nil {
+			record.ID = uuid.New()
+			record.CreatedAt = now
+			_, err = tx.NewInsert().Model(record).Exec(ctx)
+			return err
+		}
+		_, err = tx.NewUpdate().
+			Model(record).
+			Column("max_discount_amount", "max_discount_percent", "updated_by", "updated_at").
+			WherePK().
+			Exec(ctx)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return toPromotionSettingItem(record), nil
+}
@@ -7,9 +7,11 @@ import (
 
 	"phakram/app/modules/auth"
 	"phakram/app/utils/base"
+	promotionscope "phakram/app/utils/promotion"
 	"phakram/config/i18n"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 type ListPromotionsControllerRequest struct {
@@ -18,38 +20,83 @@ type ListPromotionsControllerRequest struct {
 }
 
 type CreatePromotionControllerRequest struct {
-	Code           string   `json:"code"`
-	Name           string   `json:"name"`
-	Description    string   `json:"description"`
-	DiscountType   string   `json:"discount_type"`
-	DiscountValue  float64  `json:"discount_value"`
-	MaxDiscount    *float64 `json:"max_discount"`
-	MinOrderAmount float64  `json:"min_order_amount"`
-	UsageLimit     *int     `json:"usage_limit"`
-	UsagePerMember *int     `json:"usage_per_member"`
-	StartsAt       *string  `json:"starts_at"`
-	EndsAt         *string  `json:"ends_at"`
-	IsActive       *bool    `json:"is_active"`
+	Code                        string                                 `json:"code"`
+	Name                        string                                 `json:"name"`
+	Description                 string                                 `json:"description"`
+	DiscountType                string                                 `json:"discount_type"`
+	DiscountValue               float64                                `json:"discount_value"`
+	MaxDiscount                 *float64                               `json:"max_discount"`
+	MinOrderAmount              float64                                `json:"min_order_amount"`
+	UsageLimit                  *int                                   `json:"usage_limit"`
+	UsagePerMember              *int                                   `json:"usage_per_member"`
+	StartsAt                    *string                                `json:"starts_at"`
+	EndsAt                      *string                                `json:"ends_at"`
+	IsActive                    *bool                                  `json:"is_active"`
+	ProductIDs                  []string                               `json:"product_ids"`
+	CategoryIDs                 []string                               `json:"category_ids"`
+	ExcludedProductIDs          []string                               `json:"excluded_product_ids"`
+	BuyQuantity                 int                                    `json:"buy_quantity"`
+	GetQuantity                 int                                    `json:"get_quantity"`
+	GetDiscountPercent          *float64                               `json:"get_discount_percent"`
+	GetProductID                *string                                `json:"get_product_id"`
+	BundlePrice                 float64                                `json:"bundle_price"`
+	BundleItems                 []PromotionBundleItemControllerRequest `json:"bundle_items"`
+	IsExclusive                 bool                                   `json:"is_exclusive"`
+	StackWithTier               *bool                                  `json:"stack_with_tier"`
+	StackWithPromotions         bool                                   `json:"stack_with_promotions"`
+	Priority                    int                                    `json:"priority"`
+	SegmentTierIDs              []string                               `json:"segment_tier_ids"`
+	SegmentRegisteredWithinDays *int                                   `json:"segment_registered_within_days"`
+	SegmentFirstPurchase        bool                                   `json:"segment_first_purchase"`
+	SegmentInactiveDays         *int                                   `json:"segment_inactive_days"`
 }
 
 type UpdatePromotionControllerRequest struct {
-	Code           string   `json:"code"`
-	Name           string   `json:"name"`
-	Description    string   `json:"description"`
-	DiscountType   string   `json:"discount_type"`
-	DiscountValue  float64  `json:"discount_value"`
-	MaxDiscount    *float64 `json:"max_discount"`
-	MinOrderAmount float64  `json:"min_order_amount"`
-	UsageLimit     *int     `json:"usage_limit"`
-	UsagePerMember *int     `json:"usage_per_member"`
-	StartsAt       *string  `json:"starts_at"`
-	EndsAt         *string  `json:"ends_at"`
-	IsActive       *bool    `json:"is_active"`
+	Code                        string                                 `json:"code"`
+	Name                        string                                 `json:"name"`
+	Description                 string                                 `json:"description"`
+	DiscountType                string                                 `json:"discount_type"`
+	DiscountValue               float64                                `json:"discount_value"`
+	MaxDiscount                 *float64                               `json:"max_discount"`
+	MinOrderAmount              float64                                `json:"min_order_amount"`
+	UsageLimit                  *int                                   `json:"usage_limit"`
+	UsagePerMember              *int                                   `json:"usage_per_member"`
+	StartsAt                    *string                                `json:"starts_at"`
+	EndsAt                      *string                                `json:"ends_at"`
+	IsActive                    *bool                                  `json:"is_active"`
+	ProductIDs                  []string                               `json:"product_ids"`
+	CategoryIDs                 []string                               `json:"category_ids"`
+	ExcludedProductIDs          []string                               `json:"excluded_product_ids"`
+	BuyQuantity                 int                                    `json:"buy_quantity"`
+	GetQuantity                 int                                    `json:"get_quantity"`
+	GetDiscountPercent          *float64                               `json:"get_discount_percent"`
+	GetProductID                *string                                `json:"get_product_id"`
+	BundlePrice                 float64                                `json:"bundle_price"`
+	BundleItems                 []PromotionBundleItemControllerRequest `json:"bundle_items"`
+	IsExclusive                 bool                                   `json:"is_exclusive"`
+	StackWithTier               *bool                                  `json:"stack_with_tier"`
+	StackWithPromotions         bool                                   `json:"stack_with_promotions"`
+	Priority                    int                                    `json:"priority"`
+	SegmentTierIDs              []string                               `json:"segment_tier_ids"`
+	SegmentRegisteredWithinDays *int                                   `json:"segment_registered_within_days"`
+	SegmentFirstPurchase        bool                                   `json:"segment_first_purchase"`
+	SegmentInactiveDays         *int                                   `json:"segment_inactive_days"`
+}
+
+type PromotionBundleItemControllerRequest struct {
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
 }
 
 type ValidatePromotionControllerRequest struct {
-	Code        string  `json:"code"`
-	OrderAmount float64 `json:"order_amount"`
+	Code        string                                   `json:"code"`
+	OrderAmount float64                                  `json:"order_amount"`
+	Items       []ValidatePromotionLineControllerRequest `json:"items"`
+}
+
+type ValidatePromotionLineControllerRequest struct {
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
 }
 
 type UsePromotionControllerRequest struct {
@@ -57,6 +104,45 @@ type UsePromotionControllerRequest struct {
 	DiscountAmount any     `json:"discount_amount"`
 }
 
+func toPromotionRuleServiceRequest(buyQuantity int, getQuantity int, getDiscountPercent *float64, getProductID *string, bundlePrice float64, bundleItems []PromotionBundleItemControllerRequest) PromotionRuleServiceRequest {
+	items := make([]PromotionBundleItemServiceRequest, 0, len(bundleItems))
+	for _, item := range bundleItems {
+		items = append(items, PromotionBundleItemServiceRequest{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+		})
+	}
+
+	return PromotionRuleServiceRequest{
+		BuyQuantity:        buyQuantity,
+		GetQuantity:        getQuantity,
+		GetDiscountPercent: getDiscountPercent,
+		GetProductID:       getProductID,
+		BundlePrice:        bundlePrice,
+		BundleItems:        items,
+	}
+}
+
+// toPromotionPolicy defaults to stacking with the tier discount, which is how
+// promotions behaved before stacking rules existed. An exclusive promotion
+// never stacks with anything.
+func toPromotionPolicy(isExclusive bool, stackWithTier *bool, stackWithPromotions bool, priority int) promotionscope.Policy {
+	policy := promotionscope.Policy{
+		IsExclusive:         isExclusive,
+		StackWithTier:       true,
+		StackWithPromotions: stackWithPromotions,
+		Priority:            priority,
+	}
+	if stackWithTier != nil {
+		policy.StackWithTier = *stackWithTier
+	}
+	if isExclusive {
+		policy.StackWithTier = false
+		policy.StackWithPromotions = false
+	}
+	return policy
+}
+
 func parseDiscountAmount(raw any) (float64, error) {
 	switch value := raw.(type) {
 	case float64:
@@ -139,18 +225,29 @@ func (c *Controller) CreateController(ctx *gin.Context) {
 	}
 
 	if err := c.svc.Create(ctx.Request.Context(), &CreatePromotionServiceRequest{
-		Code:           req.Code,
-		Name:           req.Name,
-		Description:    req.Description,
-		DiscountType:   req.DiscountType,
-		DiscountValue:  req.DiscountValue,
-		MaxDiscount:    req.MaxDiscount,
-		MinOrderAmount: req.MinOrderAmount,
-		UsageLimit:     req.UsageLimit,
-		UsagePerMember: req.UsagePerMember,
-		StartsAt:       req.StartsAt,
-		EndsAt:         req.EndsAt,
-		IsActive:       isActive,
+		Code:               req.Code,
+		Name:               req.Name,
+		Description:        req.Description,
+		DiscountType:       req.DiscountType,
+		DiscountValue:      req.DiscountValue,
+		MaxDiscount:        req.MaxDiscount,
+		MinOrderAmount:     req.MinOrderAmount,
+		UsageLimit:         req.UsageLimit,
+		UsagePerMember:     req.UsagePerMember,
+		StartsAt:           req.StartsAt,
+		EndsAt:             req.EndsAt,
+		IsActive:           isActive,
+		ProductIDs:         req.ProductIDs,
+		CategoryIDs:        req.CategoryIDs,
+		ExcludedProductIDs: req.ExcludedProductIDs,
+		Rule:               toPromotionRuleServiceRequest(req.BuyQuantity, req.GetQuantity, req.GetDiscountPercent, req.GetProductID, req.BundlePrice, req.BundleItems),
+		Policy:             toPromotionPolicy(req.IsExclusive, req.StackWithTier, req.StackWithPromotions, req.Priority),
+		Segment: PromotionSegmentServiceRequest{
+			TierIDs:              req.SegmentTierIDs,
+			RegisteredWithinDays: req.SegmentRegisteredWithinDays,
+			FirstPurchase:        req.SegmentFirstPurchase,
+			InactiveDays:         req.SegmentInactiveDays,
+		},
 	}); err != nil {
 		base.HandleError(ctx, err)
 		return
@@ -182,19 +279,30 @@ func (c *Controller) UpdateController(ctx *gin.Context) {
 	}
 
 	if err := c.svc.Update(ctx.Request.Context(), &UpdatePromotionServiceRequest{
-		ID:             id,
-		Code:           req.Code,
-		Name:           req.Name,
-		Description:    req.Description,
-		DiscountType:   req.DiscountType,
-		DiscountValue:  req.DiscountValue,
-		MaxDiscount:    req.MaxDiscount,
-		MinOrderAmount: req.MinOrderAmount,
-		UsageLimit:     req.UsageLimit,
-		UsagePerMember: req.UsagePerMember,
-		StartsAt:       req.StartsAt,
-		EndsAt:         req.EndsAt,
-		IsActive:       isActive,
+		ID:                 id,
+		Code:               req.Code,
+		Name:               req.Name,
+		Description:        req.Description,
+		DiscountType:       req.DiscountType,
+		DiscountValue:      req.DiscountValue,
+		MaxDiscount:        req.MaxDiscount,
+		MinOrderAmount:     req.MinOrderAmount,
+		UsageLimit:         req.UsageLimit,
+		UsagePerMember:     req.UsagePerMember,
+		StartsAt:           req.StartsAt,
+		EndsAt:             req.EndsAt,
+		IsActive:           isActive,
+		ProductIDs:         req.ProductIDs,
+		CategoryIDs:        req.CategoryIDs,
+		ExcludedProductIDs: req.ExcludedProductIDs,
+		Rule:               toPromotionRuleServiceRequest(req.BuyQuantity, req.GetQuantity, req.GetDiscountPercent, req.GetProductID, req.BundlePrice, req.BundleItems),
+		Policy:             toPromotionPolicy(req.IsExclusive, req.StackWithTier, req.StackWithPromotions, req.Priority),
+		Segment: PromotionSegmentServiceRequest{
+			TierIDs:              req.SegmentTierIDs,
+			RegisteredWithinDays: req.SegmentRegisteredWithinDays,
+			FirstPurchase:        req.SegmentFirstPurchase,
+			InactiveDays:         req.SegmentInactiveDays,
+		},
 	}); err != nil {
 		base.HandleError(ctx, err)
 		return
@@ -231,9 +339,23 @@ func (c *Controller) ValidateController(ctx *gin.Context) {
 		return
 	}
 
+	items := make([]ValidatePromotionLineServiceRequest, 0, len(req.Items))
+	for _, item := range req.Items {
+		productID, err := uuid.Parse(item.ProductID)
+		if err != nil {
+			base.BadRequest(ctx, i18n.BadRequest, nil)
+			return
+		}
+		items = append(items, ValidatePromotionLineServiceRequest{
+			ProductID: productID,
+			Quantity:  item.Quantity,
+		})
+	}
+
 	data, err := c.svc.Validate(ctx.Request.Context(), memberID, &ValidatePromotionServiceRequest{
 		Code:        req.Code,
 		OrderAmount: req.OrderAmount,
+		Items:       items,
 	})
 	if err != nil {
 		base.HandleError(ctx, err)
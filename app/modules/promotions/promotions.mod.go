@@ -7,6 +7,12 @@ import (
 	"go.opentelemetry.io/otel/trace"
 )
 
+type Config struct {
+	// ExpiryReminderDays is how many days before a collected promotion ends
+	// its members are reminded. Zero turns reminders off.
+	ExpiryReminderDays int
+}
+
 type Module struct {
 	Svc *Service
 	Ctl *Controller
@@ -16,6 +22,7 @@ type (
 	Service struct {
 		tracer trace.Tracer
 		bunDB  *database.DatabaseService
+		conf   *Config
 	}
 
 	Controller struct {
@@ -27,13 +34,15 @@ type (
 type Options struct {
 	tracer trace.Tracer
 	bunDB  *database.DatabaseService
+	conf   *Config
 }
 
-func New(bunDB *database.DatabaseService) *Module {
+func New(bunDB *database.DatabaseService, conf *Config) *Module {
 	tracer := otel.Tracer("promotions_module")
 	svc := newService(&Options{
 		tracer: tracer,
 		bunDB:  bunDB,
+		conf:   conf,
 	})
 
 	return &Module{
@@ -46,6 +55,7 @@ func newService(opt *Options) *Service {
 	return &Service{
 		tracer: opt.tracer,
 		bunDB:  opt.bunDB,
+		conf:   opt.conf,
 	}
 }
 
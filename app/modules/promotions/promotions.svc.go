@@ -3,47 +3,66 @@ package promotions
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
 	"phakram/app/utils/base"
+	"phakram/app/utils/flashsale"
+	"phakram/app/utils/pricelist"
+	promotionscope "phakram/app/utils/promotion"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"github.com/uptrace/bun"
 )
 
 type promotionRecord struct {
 	bun.BaseModel `bun:"table:promotions"`
 
-	ID             uuid.UUID  `bun:"id,pk,type:uuid"`
-	Code           string     `bun:"code,notnull"`
-	Name           string     `bun:"name,notnull"`
-	Description    string     `bun:"description"`
-	DiscountType   string     `bun:"discount_type,notnull"`
-	DiscountValue  float64    `bun:"discount_value,notnull"`
-	MaxDiscount    *float64   `bun:"max_discount"`
-	MinOrderAmount float64    `bun:"min_order_amount,notnull"`
-	UsageLimit     *int       `bun:"usage_limit"`
-	UsagePerMember *int       `bun:"usage_per_member"`
-	UsedCount      int        `bun:"used_count,notnull"`
-	StartsAt       *time.Time `bun:"starts_at"`
-	EndsAt         *time.Time `bun:"ends_at"`
-	IsActive       bool       `bun:"is_active,notnull"`
-	CreatedAt      time.Time  `bun:"created_at,notnull"`
-	UpdatedAt      time.Time  `bun:"updated_at,notnull"`
+	ID                          uuid.UUID  `bun:"id,pk,type:uuid"`
+	Code                        string     `bun:"code,notnull"`
+	Name                        string     `bun:"name,notnull"`
+	Description                 string     `bun:"description"`
+	DiscountType                string     `bun:"discount_type,notnull"`
+	DiscountValue               float64    `bun:"discount_value,notnull"`
+	MaxDiscount                 *float64   `bun:"max_discount"`
+	MinOrderAmount              float64    `bun:"min_order_amount,notnull"`
+	UsageLimit                  *int       `bun:"usage_limit"`
+	UsagePerMember              *int       `bun:"usage_per_member"`
+	UsedCount                   int        `bun:"used_count,notnull"`
+	StartsAt                    *time.Time `bun:"starts_at"`
+	EndsAt                      *time.Time `bun:"ends_at"`
+	IsActive                    bool       `bun:"is_active,notnull"`
+	LifecycleStatus             string     `bun:"lifecycle_status,notnull"`
+	BuyQuantity                 int        `bun:"buy_quantity,notnull"`
+	GetQuantity                 int        `bun:"get_quantity,notnull"`
+	GetDiscountPercent          float64    `bun:"get_discount_percent,notnull"`
+	GetProductID                *uuid.UUID `bun:"get_product_id,type:uuid"`
+	BundlePrice                 float64    `bun:"bundle_price,notnull"`
+	IsExclusive                 bool       `bun:"is_exclusive,notnull"`
+	StackWithTier               bool       `bun:"stack_with_tier,notnull"`
+	StackWithPromotions         bool       `bun:"stack_with_promotions,notnull"`
+	Priority                    int        `bun:"priority,notnull"`
+	SegmentRegisteredWithinDays *int       `bun:"segment_registered_within_days"`
+	SegmentFirstPurchase        bool       `bun:"segment_first_purchase,notnull"`
+	SegmentInactiveDays         *int       `bun:"segment_inactive_days"`
+	CreatedAt                   time.Time  `bun:"created_at,notnull"`
+	UpdatedAt                   time.Time  `bun:"updated_at,notnull"`
 }
 
 type promotionUsageRecord struct {
 	bun.BaseModel `bun:"table:promotion_usages"`
 
-	ID             uuid.UUID  `bun:"id,pk,type:uuid"`
-	PromotionID    uuid.UUID  `bun:"promotion_id,type:uuid,notnull"`
-	MemberID       uuid.UUID  `bun:"member_id,type:uuid,notnull"`
-	OrderID        *uuid.UUID `bun:"order_id,type:uuid"`
-	DiscountAmount float64    `bun:"discount_amount,notnull"`
-	UsedAt         time.Time  `bun:"used_at,notnull"`
-	CreatedAt      time.Time  `bun:"created_at,notnull"`
+	ID              uuid.UUID  `bun:"id,pk,type:uuid"`
+	PromotionID     uuid.UUID  `bun:"promotion_id,type:uuid,notnull"`
+	MemberID        uuid.UUID  `bun:"member_id,type:uuid,notnull"`
+	OrderID         *uuid.UUID `bun:"order_id,type:uuid"`
+	PromotionCodeID *uuid.UUID `bun:"promotion_code_id,type:uuid"`
+	DiscountAmount  float64    `bun:"discount_amount,notnull"`
+	UsedAt          time.Time  `bun:"used_at,notnull"`
+	CreatedAt       time.Time  `bun:"created_at,notnull"`
 }
 
 type memberPromotionCollectionRecord struct {
@@ -57,53 +76,117 @@ type memberPromotionCollectionRecord struct {
 }
 
 type PromotionItem struct {
-	ID             string   `json:"id"`
-	Code           string   `json:"code"`
-	Name           string   `json:"name"`
-	Description    string   `json:"description"`
-	DiscountType   string   `json:"discount_type"`
-	DiscountValue  float64  `json:"discount_value"`
-	MaxDiscount    *float64 `json:"max_discount"`
-	MinOrderAmount float64  `json:"min_order_amount"`
-	UsageLimit     *int     `json:"usage_limit"`
-	UsagePerMember *int     `json:"usage_per_member"`
-	UsedCount      int      `json:"used_count"`
-	StartsAt       *string  `json:"starts_at"`
-	EndsAt         *string  `json:"ends_at"`
-	IsActive       bool     `json:"is_active"`
-	CreatedAt      string   `json:"created_at"`
-	UpdatedAt      string   `json:"updated_at"`
+	ID                          string                      `json:"id"`
+	Code                        string                      `json:"code"`
+	Name                        string                      `json:"name"`
+	Description                 string                      `json:"description"`
+	DiscountType                string                      `json:"discount_type"`
+	DiscountValue               float64                     `json:"discount_value"`
+	MaxDiscount                 *float64                    `json:"max_discount"`
+	MinOrderAmount              float64                     `json:"min_order_amount"`
+	UsageLimit                  *int                        `json:"usage_limit"`
+	UsagePerMember              *int                        `json:"usage_per_member"`
+	UsedCount                   int                         `json:"used_count"`
+	StartsAt                    *string                     `json:"starts_at"`
+	EndsAt                      *string                     `json:"ends_at"`
+	IsActive                    bool                        `json:"is_active"`
+	LifecycleStatus             string                      `json:"lifecycle_status"`
+	ProductIDs                  []string                    `json:"product_ids"`
+	CategoryIDs                 []string                    `json:"category_ids"`
+	ExcludedProductIDs          []string                    `json:"excluded_product_ids"`
+	BuyQuantity                 int                         `json:"buy_quantity"`
+	GetQuantity                 int                         `json:"get_quantity"`
+	GetDiscountPercent          float64                     `json:"get_discount_percent"`
+	GetProductID                *string                     `json:"get_product_id"`
+	BundlePrice                 float64                     `json:"bundle_price"`
+	BundleItems                 []promotionscope.BundleItem `json:"bundle_items"`
+	IsExclusive                 bool                        `json:"is_exclusive"`
+	StackWithTier               bool                        `json:"stack_with_tier"`
+	StackWithPromotions         bool                        `json:"stack_with_promotions"`
+	Priority                    int                         `json:"priority"`
+	SegmentTierIDs              []string                    `json:"segment_tier_ids"`
+	SegmentRegisteredWithinDays *int                        `json:"segment_registered_within_days"`
+	SegmentFirstPurchase        bool                        `json:"segment_first_purchase"`
+	SegmentInactiveDays         *int                        `json:"segment_inactive_days"`
+	SegmentMemberCount          int                         `json:"segment_member_count"`
+	CreatedAt                   string                      `json:"created_at"`
+	UpdatedAt                   string                      `json:"updated_at"`
+}
+
+// PromotionRuleServiceRequest carries the buy X get Y and bundle settings.
+type PromotionRuleServiceRequest struct {
+	BuyQuantity        int
+	GetQuantity        int
+	GetDiscountPercent *float64
+	GetProductID       *string
+	BundlePrice        float64
+	BundleItems        []PromotionBundleItemServiceRequest
+}
+
+// PromotionSegmentServiceRequest limits which members may use a promotion.
+// Uploaded member lists are managed separately.
+type PromotionSegmentServiceRequest struct {
+	TierIDs              []string
+	RegisteredWithinDays *int
+	FirstPurchase        bool
+	InactiveDays         *int
+}
+
+type PromotionBundleItemServiceRequest struct {
+	ProductID string
+	Quantity  int
+}
+
+type parsedPromotionRule struct {
+	BuyQuantity        int
+	GetQuantity        int
+	GetDiscountPercent float64
+	GetProductID       *uuid.UUID
+	BundlePrice        float64
+	BundleItems        []promotionscope.BundleItem
 }
 
 type CreatePromotionServiceRequest struct {
-	Code           string
-	Name           string
-	Description    string
-	DiscountType   string
-	DiscountValue  float64
-	MaxDiscount    *float64
-	MinOrderAmount float64
-	UsageLimit     *int
-	UsagePerMember *int
-	StartsAt       *string
-	EndsAt         *string
-	IsActive       bool
+	Code               string
+	Name               string
+	Description        string
+	DiscountType       string
+	DiscountValue      float64
+	MaxDiscount        *float64
+	MinOrderAmount     float64
+	UsageLimit         *int
+	UsagePerMember     *int
+	StartsAt           *string
+	EndsAt             *string
+	IsActive           bool
+	ProductIDs         []string
+	CategoryIDs        []string
+	ExcludedProductIDs []string
+	Rule               PromotionRuleServiceRequest
+	Policy             promotionscope.Policy
+	Segment            PromotionSegmentServiceRequest
 }
 
 type UpdatePromotionServiceRequest struct {
-	ID             string
-	Code           string
-	Name           string
-	Description    string
-	DiscountType   string
-	DiscountValue  float64
-	MaxDiscount    *float64
-	MinOrderAmount float64
-	UsageLimit     *int
-	UsagePerMember *int
-	StartsAt       *string
-	EndsAt         *string
-	IsActive       bool
+	ID                 string
+	Code               string
+	Name               string
+	Description        string
+	DiscountType       string
+	DiscountValue      float64
+	MaxDiscount        *float64
+	MinOrderAmount     float64
+	UsageLimit         *int
+	UsagePerMember     *int
+	StartsAt           *string
+	EndsAt             *string
+	IsActive           bool
+	ProductIDs         []string
+	CategoryIDs        []string
+	ExcludedProductIDs []string
+	Rule               PromotionRuleServiceRequest
+	Policy             promotionscope.Policy
+	Segment            PromotionSegmentServiceRequest
 }
 
 type ListPromotionsServiceRequest struct {
@@ -114,14 +197,31 @@ type ListPromotionsServiceRequest struct {
 type ValidatePromotionServiceRequest struct {
 	Code        string
 	OrderAmount float64
+	Items       []ValidatePromotionLineServiceRequest
+}
+
+type ValidatePromotionLineServiceRequest struct {
+	ProductID uuid.UUID
+	Quantity  int
 }
 
 type ValidatePromotionServiceResponse struct {
-	Promotion      *PromotionItem `json:"promotion"`
-	IsValid        bool           `json:"is_valid"`
-	Reason         string         `json:"reason"`
-	DiscountAmount float64        `json:"discount_amount"`
-	FinalAmount    float64        `json:"final_amount"`
+	Promotion      *PromotionItem                 `json:"promotion"`
+	IsValid        bool                           `json:"is_valid"`
+	Reason         string                         `json:"reason"`
+	EligibleAmount float64                        `json:"eligible_amount"`
+	DiscountAmount float64                        `json:"discount_amount"`
+	FinalAmount    float64                        `json:"final_amount"`
+	Items          []*ValidatePromotionLineResult `json:"items"`
+	Rewards        []promotionscope.Reward        `json:"rewards"`
+}
+
+type ValidatePromotionLineResult struct {
+	ProductID      string  `json:"product_id"`
+	Quantity       int     `json:"quantity"`
+	Amount         float64 `json:"amount"`
+	IsEligible     bool    `json:"is_eligible"`
+	DiscountAmount float64 `json:"discount_amount"`
 }
 
 type UsePromotionServiceRequest struct {
@@ -170,12 +270,89 @@ type ListPromotionUsagesServiceRequest struct {
 
 func normalizeDiscountType(value string) string {
 	v := strings.TrimSpace(strings.ToLower(value))
-	if v == "percent" || v == "amount" {
+	switch v {
+	case promotionscope.TypePercent, promotionscope.TypeAmount, promotionscope.TypeBuyXGetY, promotionscope.TypeBundle:
 		return v
 	}
 	return ""
 }
 
+// parsePromotionRule validates the settings each discount type needs and
+// clears the ones it does not use.
+func parsePromotionRule(discountType string, discountValue float64, req *PromotionRuleServiceRequest) (*parsedPromotionRule, error) {
+	rule := &parsedPromotionRule{GetDiscountPercent: 100}
+
+	switch discountType {
+	case promotionscope.TypeBuyXGetY:
+		if req.BuyQuantity <= 0 || req.GetQuantity <= 0 {
+			return nil, fmt.Errorf("buy and get quantities must be greater than 0")
+		}
+		if req.GetDiscountPercent != nil {
+			if *req.GetDiscountPercent <= 0 || *req.GetDiscountPercent > 100 {
+				return nil, fmt.Errorf("get discount percent must be between 0 and 100")
+			}
+			rule.GetDiscountPercent = *req.GetDiscountPercent
+		}
+		if req.GetProductID != nil && strings.TrimSpace(*req.GetProductID) != "" {
+			productID, err := uuid.Parse(strings.TrimSpace(*req.GetProductID))
+			if err != nil {
+				return nil, fmt.Errorf("invalid promotion target")
+			}
+			rule.GetProductID = &productID
+		}
+		rule.BuyQuantity = req.BuyQuantity
+		rule.GetQuantity = req.GetQuantity
+	case promotionscope.TypeBundle:
+		totalQuantity := 0
+		seen := make(map[uuid.UUID]struct{}, len(req.BundleItems))
+		for _, item := range req.BundleItems {
+			productID, err := uuid.Parse(strings.TrimSpace(item.ProductID))
+			if err != nil {
+				return nil, fmt.Errorf("invalid promotion target")
+			}
+			if item.Quantity <= 0 {
+				return nil, fmt.Errorf("quantity must be greater than zero")
+			}
+			if _, ok := seen[productID]; ok {
+				return nil, fmt.Errorf("invalid promotion target")
+			}
+			seen[productID] = struct{}{}
+			totalQuantity += item.Quantity
+			rule.BundleItems = append(rule.BundleItems, promotionscope.BundleItem{ProductID: productID, Quantity: item.Quantity})
+		}
+		if totalQuantity < 2 {
+			return nil, fmt.Errorf("bundle requires at least two items")
+		}
+		if req.BundlePrice <= 0 {
+			return nil, fmt.Errorf("bundle price must be greater than 0")
+		}
+		rule.BundlePrice = req.BundlePrice
+	default:
+		if discountValue <= 0 {
+			return nil, fmt.Errorf("discount value must be greater than 0")
+		}
+	}
+
+	return rule, nil
+}
+
+func toPromotionRule(record *promotionRecord, bundleItems []promotionscope.BundleItem) *promotionscope.Rule {
+	rule := &promotionscope.Rule{
+		Type:               record.DiscountType,
+		BuyQuantity:        record.BuyQuantity,
+		GetQuantity:        record.GetQuantity,
+		GetDiscountPercent: decimal.NewFromFloat(record.GetDiscountPercent),
+		GetProductID:       record.GetProductID,
+		BundlePrice:        decimal.NewFromFloat(record.BundlePrice),
+		BundleItems:        bundleItems,
+	}
+	if record.MaxDiscount != nil {
+		maxDiscount := decimal.NewFromFloat(*record.MaxDiscount)
+		rule.MaxDiscount = &maxDiscount
+	}
+	return rule
+}
+
 func parseOptionalTime(value *string) (*time.Time, error) {
 	if value == nil {
 		return nil, nil
@@ -208,25 +385,141 @@ func formatOptionalTime(value *time.Time) *string {
 
 func toPromotionItem(record *promotionRecord) *PromotionItem {
 	return &PromotionItem{
-		ID:             record.ID.String(),
-		Code:           record.Code,
-		Name:           record.Name,
-		Description:    record.Description,
-		DiscountType:   record.DiscountType,
-		DiscountValue:  record.DiscountValue,
-		MaxDiscount:    record.MaxDiscount,
-		MinOrderAmount: record.MinOrderAmount,
-		UsageLimit:     record.UsageLimit,
-		UsagePerMember: record.UsagePerMember,
-		UsedCount:      record.UsedCount,
-		StartsAt:       formatOptionalTime(record.StartsAt),
-		EndsAt:         formatOptionalTime(record.EndsAt),
-		IsActive:       record.IsActive,
-		CreatedAt:      record.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt:      record.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		ID:                          record.ID.String(),
+		Code:                        record.Code,
+		Name:                        record.Name,
+		Description:                 record.Description,
+		DiscountType:                record.DiscountType,
+		DiscountValue:               record.DiscountValue,
+		MaxDiscount:                 record.MaxDiscount,
+		MinOrderAmount:              record.MinOrderAmount,
+		UsageLimit:                  record.UsageLimit,
+		UsagePerMember:              record.UsagePerMember,
+		UsedCount:                   record.UsedCount,
+		StartsAt:                    formatOptionalTime(record.StartsAt),
+		EndsAt:                      formatOptionalTime(record.EndsAt),
+		IsActive:                    record.IsActive,
+		LifecycleStatus:             record.LifecycleStatus,
+		BuyQuantity:                 record.BuyQuantity,
+		GetQuantity:                 record.GetQuantity,
+		GetDiscountPercent:          record.GetDiscountPercent,
+		GetProductID:                formatOptionalUUID(record.GetProductID),
+		BundlePrice:                 record.BundlePrice,
+		IsExclusive:                 record.IsExclusive,
+		StackWithTier:               record.StackWithTier,
+		StackWithPromotions:         record.StackWithPromotions,
+		Priority:                    record.Priority,
+		SegmentRegisteredWithinDays: record.SegmentRegisteredWithinDays,
+		SegmentFirstPurchase:        record.SegmentFirstPurchase,
+		SegmentInactiveDays:         record.SegmentInactiveDays,
+		CreatedAt:                   record.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:                   record.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
 	}
 }
 
+func formatOptionalUUID(value *uuid.UUID) *string {
+	if value == nil {
+		return nil
+	}
+	v := value.String()
+	return &v
+}
+
+func parsePromotionTargetIDs(values []string) ([]uuid.UUID, error) {
+	ids := make([]uuid.UUID, 0, len(values))
+	for _, value := range values {
+		id, err := uuid.Parse(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid promotion target")
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func parsePromotionScope(productIDs []string, categoryIDs []string, excludedProductIDs []string) (*promotionscope.Scope, error) {
+	scope := &promotionscope.Scope{}
+	var err error
+	if scope.ProductIDs, err = parsePromotionTargetIDs(productIDs); err != nil {
+		return nil, err
+	}
+	if scope.CategoryIDs, err = parsePromotionTargetIDs(categoryIDs); err != nil {
+		return nil, err
+	}
+	if scope.ExcludedProductIDs, err = parsePromotionTargetIDs(excludedProductIDs); err != nil {
+		return nil, err
+	}
+	return scope, nil
+}
+
+func parsePromotionSegment(req *PromotionSegmentServiceRequest) ([]uuid.UUID, error) {
+	for _, days := range []*int{req.RegisteredWithinDays, req.InactiveDays} {
+		if days != nil && *days <= 0 {
+			return nil, fmt.Errorf("segment days must be greater than 0")
+		}
+	}
+	return parsePromotionTargetIDs(req.TierIDs)
+}
+
+func uuidStrings(ids []uuid.UUID) []string {
+	values := make([]string, 0, len(ids))
+	for _, id := range ids {
+		values = append(values, id.String())
+	}
+	return values
+}
+
+// attachPromotionScopes fills the targets, bundle contents and segment lists
+// of each item.
+func (s *Service) attachPromotionScopes(ctx context.Context, items ...*PromotionItem) error {
+	promotionIDs := make([]uuid.UUID, 0, len(items))
+	for _, item := range items {
+		id, err := uuid.Parse(item.ID)
+		if err != nil {
+			return err
+		}
+		promotionIDs = append(promotionIDs, id)
+	}
+
+	scopes, err := promotionscope.LoadScopes(ctx, s.bunDB.DB(), promotionIDs)
+	if err != nil {
+		return err
+	}
+	bundleItems, err := promotionscope.LoadBundleItems(ctx, s.bunDB.DB(), promotionIDs)
+	if err != nil {
+		return err
+	}
+	segmentTiers, err := promotionscope.LoadSegmentTiers(ctx, s.bunDB.DB(), promotionIDs)
+	if err != nil {
+		return err
+	}
+	segmentMembers, err := promotionscope.CountSegmentMembers(ctx, s.bunDB.DB(), promotionIDs)
+	if err != nil {
+		return err
+	}
+	for i, item := range items {
+		item.SegmentTierIDs = uuidStrings(segmentTiers[promotionIDs[i]])
+		item.SegmentMemberCount = segmentMembers[promotionIDs[i]]
+		scope := scopes[promotionIDs[i]]
+		item.ProductIDs = uuidStrings(scope.ProductIDs)
+		item.CategoryIDs = uuidStrings(scope.CategoryIDs)
+		item.ExcludedProductIDs = uuidStrings(scope.ExcludedProductIDs)
+		item.BundleItems = bundleItems[promotionIDs[i]]
+		if item.BundleItems == nil {
+			item.BundleItems = []promotionscope.BundleItem{}
+		}
+	}
+	return nil
+}
+
+func (s *Service) attachMemberPromotionScopes(ctx context.Context, items []*MemberPromotionItem) error {
+	promotions := make([]*PromotionItem, 0, len(items))
+	for _, item := range items {
+		promotions = append(promotions, &item.PromotionItem)
+	}
+	return s.attachPromotionScopes(ctx, promotions...)
+}
+
 func (s *Service) List(ctx context.Context, req *ListPromotionsServiceRequest) ([]*PromotionItem, *base.ResponsePaginate, error) {
 	query := s.bunDB.DB().NewSelect().Model((*promotionRecord)(nil))
 
@@ -265,6 +558,9 @@ func (s *Service) List(ctx context.Context, req *ListPromotionsServiceRequest) (
 	for _, item := range items {
 		response = append(response, toPromotionItem(item))
 	}
+	if err := s.attachPromotionScopes(ctx, response...); err != nil {
+		return nil, nil, err
+	}
 
 	return response, &base.ResponsePaginate{Page: req.GetPage(), Size: req.GetSize(), Total: int64(total)}, nil
 }
@@ -284,16 +580,22 @@ func (s *Service) Info(ctx context.Context, id string) (*PromotionItem, error) {
 		return nil, err
 	}
 
-	return toPromotionItem(record), nil
+	item := toPromotionItem(record)
+	if err := s.attachPromotionScopes(ctx, item); err != nil {
+		return nil, err
+	}
+
+	return item, nil
 }
 
 func (s *Service) Create(ctx context.Context, req *CreatePromotionServiceRequest) error {
 	discountType := normalizeDiscountType(req.DiscountType)
 	if discountType == "" {
-		return fmt.Errorf("discount type must be percent or amount")
+		return fmt.Errorf("invalid discount type")
 	}
-	if req.DiscountValue <= 0 {
-		return fmt.Errorf("discount value must be greater than 0")
+	rule, err := parsePromotionRule(discountType, req.DiscountValue, &req.Rule)
+	if err != nil {
+		return err
 	}
 
 	startsAt, err := parseOptionalTime(req.StartsAt)
@@ -308,31 +610,66 @@ func (s *Service) Create(ctx context.Context, req *CreatePromotionServiceRequest
 		return fmt.Errorf("end date must be after start date")
 	}
 
-	now := time.Now().UTC()
-	record := &promotionRecord{
-		ID:             uuid.New(),
-		Code:           strings.ToUpper(strings.TrimSpace(req.Code)),
-		Name:           strings.TrimSpace(req.Name),
-		Description:    strings.TrimSpace(req.Description),
-		DiscountType:   discountType,
-		DiscountValue:  req.DiscountValue,
-		MaxDiscount:    req.MaxDiscount,
-		MinOrderAmount: req.MinOrderAmount,
-		UsageLimit:     req.UsageLimit,
-		UsagePerMember: req.UsagePerMember,
-		UsedCount:      0,
-		StartsAt:       startsAt,
-		EndsAt:         endsAt,
-		IsActive:       req.IsActive,
-		CreatedAt:      now,
-		UpdatedAt:      now,
-	}
-
-	if _, err := s.bunDB.DB().NewInsert().Model(record).Exec(ctx); err != nil {
+	scope, err := parsePromotionScope(req.ProductIDs, req.CategoryIDs, req.ExcludedProductIDs)
+	if err != nil {
+		return err
+	}
+	if err := s.ensureCodeNotGenerated(ctx, req.Code); err != nil {
+		return err
+	}
+	segmentTierIDs, err := parsePromotionSegment(&req.Segment)
+	if err != nil {
 		return err
 	}
 
-	return nil
+	now := time.Now().UTC()
+	record := &promotionRecord{
+		ID:                          uuid.New(),
+		Code:                        strings.ToUpper(strings.TrimSpace(req.Code)),
+		Name:                        strings.TrimSpace(req.Name),
+		Description:                 strings.TrimSpace(req.Description),
+		DiscountType:                discountType,
+		DiscountValue:               req.DiscountValue,
+		MaxDiscount:                 req.MaxDiscount,
+		MinOrderAmount:              req.MinOrderAmount,
+		UsageLimit:                  req.UsageLimit,
+		UsagePerMember:              req.UsagePerMember,
+		UsedCount:                   0,
+		StartsAt:                    startsAt,
+		EndsAt:                      endsAt,
+		IsActive:                    req.IsActive,
+		LifecycleStatus:             promotionscope.LifecycleStatus(startsAt, endsAt, now),
+		BuyQuantity:                 rule.BuyQuantity,
+		GetQuantity:                 rule.GetQuantity,
+		GetDiscountPercent:          rule.GetDiscountPercent,
+		GetProductID:                rule.GetProductID,
+		BundlePrice:                 rule.BundlePrice,
+		IsExclusive:                 req.Policy.IsExclusive,
+		StackWithTier:               req.Policy.StackWithTier,
+		StackWithPromotions:         req.Policy.StackWithPromotions,
+		Priority:                    req.Policy.Priority,
+		SegmentRegisteredWithinDays: req.Segment.RegisteredWithinDays,
+		SegmentFirstPurchase:        req.Segment.FirstPurchase,
+		SegmentInactiveDays:         req.Segment.InactiveDays,
+		CreatedAt:                   now,
+		UpdatedAt:                   now,
+	}
+
+	return s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewInsert().Model(record).Exec(ctx); err != nil {
+			return err
+		}
+		if err := promotionscope.ReplaceScopeInTx(ctx, tx, record.ID, scope); err != nil {
+			return err
+		}
+		if err := promotionscope.ReplaceBundleItemsInTx(ctx, tx, record.ID, rule.BundleItems); err != nil {
+			return err
+		}
+		if err := promotionscope.ReplaceSegmentInTx(ctx, tx, record.ID, promotionscope.SegmentTier, segmentTierIDs); err != nil {
+			return err
+		}
+		return promotionscope.RecordEvents(ctx, tx, promotionscope.Event{PromotionID: record.ID, Type: promotionscope.EventCreated})
+	})
 }
 
 func (s *Service) Update(ctx context.Context, req *UpdatePromotionServiceRequest) error {
@@ -343,10 +680,11 @@ func (s *Service) Update(ctx context.Context, req *UpdatePromotionServiceRequest
 
 	discountType := normalizeDiscountType(req.DiscountType)
 	if discountType == "" {
-		return fmt.Errorf("discount type must be percent or amount")
+		return fmt.Errorf("invalid discount type")
 	}
-	if req.DiscountValue <= 0 {
-		return fmt.Errorf("discount value must be greater than 0")
+	rule, err := parsePromotionRule(discountType, req.DiscountValue, &req.Rule)
+	if err != nil {
+		return err
 	}
 
 	startsAt, err := parseOptionalTime(req.StartsAt)
@@ -361,28 +699,59 @@ func (s *Service) Update(ctx context.Context, req *UpdatePromotionServiceRequest
 		return fmt.Errorf("end date must be after start date")
 	}
 
-	_, err = s.bunDB.DB().NewUpdate().
-		Model((*promotionRecord)(nil)).
-		Set("code = ?", strings.ToUpper(strings.TrimSpace(req.Code))).
-		Set("name = ?", strings.TrimSpace(req.Name)).
-		Set("description = ?", strings.TrimSpace(req.Description)).
-		Set("discount_type = ?", discountType).
-		Set("discount_value = ?", req.DiscountValue).
-		Set("max_discount = ?", req.MaxDiscount).
-		Set("min_order_amount = ?", req.MinOrderAmount).
-		Set("usage_limit = ?", req.UsageLimit).
-		Set("usage_per_member = ?", req.UsagePerMember).
-		Set("starts_at = ?", startsAt).
-		Set("ends_at = ?", endsAt).
-		Set("is_active = ?", req.IsActive).
-		Set("updated_at = ?", time.Now().UTC()).
-		Where("id = ?", promotionID).
-		Exec(ctx)
+	scope, err := parsePromotionScope(req.ProductIDs, req.CategoryIDs, req.ExcludedProductIDs)
+	if err != nil {
+		return err
+	}
+	if err := s.ensureCodeNotGenerated(ctx, req.Code); err != nil {
+		return err
+	}
+	segmentTierIDs, err := parsePromotionSegment(&req.Segment)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewUpdate().
+			Model((*promotionRecord)(nil)).
+			Set("code = ?", strings.ToUpper(strings.TrimSpace(req.Code))).
+			Set("name = ?", strings.TrimSpace(req.Name)).
+			Set("description = ?", strings.TrimSpace(req.Description)).
+			Set("discount_type = ?", discountType).
+			Set("discount_value = ?", req.DiscountValue).
+			Set("max_discount = ?", req.MaxDiscount).
+			Set("min_order_amount = ?", req.MinOrderAmount).
+			Set("usage_limit = ?", req.UsageLimit).
+			Set("usage_per_member = ?", req.UsagePerMember).
+			Set("starts_at = ?", startsAt).
+			Set("ends_at = ?", endsAt).
+			Set("is_active = ?", req.IsActive).
+			Set("lifecycle_status = ?", promotionscope.LifecycleStatus(startsAt, endsAt, time.Now().UTC())).
+			Set("buy_quantity = ?", rule.BuyQuantity).
+			Set("get_quantity = ?", rule.GetQuantity).
+			Set("get_discount_percent = ?", rule.GetDiscountPercent).
+			Set("get_product_id = ?", rule.GetProductID).
+			Set("bundle_price = ?", rule.BundlePrice).
+			Set("is_exclusive = ?", req.Policy.IsExclusive).
+			Set("stack_with_tier = ?", req.Policy.StackWithTier).
+			Set("stack_with_promotions = ?", req.Policy.StackWithPromotions).
+			Set("priority = ?", req.Policy.Priority).
+			Set("segment_registered_within_days = ?", req.Segment.RegisteredWithinDays).
+			Set("segment_first_purchase = ?", req.Segment.FirstPurchase).
+			Set("segment_inactive_days = ?", req.Segment.InactiveDays).
+			Set("updated_at = ?", time.Now().UTC()).
+			Where("id = ?", promotionID).
+			Exec(ctx); err != nil {
+			return err
+		}
+		if err := promotionscope.ReplaceScopeInTx(ctx, tx, promotionID, scope); err != nil {
+			return err
+		}
+		if err := promotionscope.ReplaceBundleItemsInTx(ctx, tx, promotionID, rule.BundleItems); err != nil {
+			return err
+		}
+		return promotionscope.ReplaceSegmentInTx(ctx, tx, promotionID, promotionscope.SegmentTier, segmentTierIDs)
+	})
 }
 
 func (s *Service) Delete(ctx context.Context, id string) error {
@@ -401,6 +770,11 @@ func (s *Service) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
+var promotionRuleReasons = map[error]string{
+	promotionscope.ErrBuyQuantityNotMet: "จำนวนสินค้าที่ร่วมรายการยังไม่ครบตามเงื่อนไขโปรโมชั่น",
+	promotionscope.ErrBundleIncomplete:  "สินค้าในตะกร้ายังไม่ครบชุดตามโปรโมชั่น",
+}
+
 func (s *Service) Validate(ctx context.Context, memberID uuid.UUID, req *ValidatePromotionServiceRequest) (*ValidatePromotionServiceResponse, error) {
 	response := &ValidatePromotionServiceResponse{
 		IsValid:        false,
@@ -414,22 +788,50 @@ func (s *Service) Validate(ctx context.Context, memberID uuid.UUID, req *Validat
 		response.Reason = "กรุณาระบุโค้ดโปรโมชั่น"
 		return response, nil
 	}
+
+	lines, err := s.priceValidateLines(ctx, memberID, req.Items)
+	if err != nil {
+		return nil, err
+	}
+	if req.OrderAmount <= 0 && len(lines) > 0 {
+		total := decimal.Zero
+		for _, line := range lines {
+			total = total.Add(line.Amount)
+		}
+		req.OrderAmount = total.InexactFloat64()
+		response.FinalAmount = req.OrderAmount
+	}
 	if req.OrderAmount <= 0 {
 		response.Reason = "ยอดคำสั่งซื้อไม่ถูกต้อง"
 		return response, nil
 	}
 
+	resolved, err := promotionscope.ResolveCode(ctx, s.bunDB.DB(), code)
+	if err != nil {
+		if errors.Is(err, promotionscope.ErrCodeRedeemed) {
+			response.Reason = "โค้ดนี้ถูกใช้งานแล้ว"
+			return response, nil
+		}
+		if errors.Is(err, promotionscope.ErrCodeNotFound) {
+			return response, nil
+		}
+		return nil, err
+	}
+
 	now := time.Now().UTC()
 	record := &promotionRecord{}
 	if err := s.bunDB.DB().NewSelect().
 		Model(record).
-		Where("code = ?", code).
+		Where("id = ?", resolved.PromotionID).
 		Limit(1).
 		Scan(ctx); err != nil {
 		return response, nil
 	}
 
 	response.Promotion = toPromotionItem(record)
+	if err := s.attachPromotionScopes(ctx, response.Promotion); err != nil {
+		return nil, err
+	}
 
 	if !record.IsActive {
 		response.Reason = "โปรโมชั่นปิดใช้งานอยู่"
@@ -443,6 +845,14 @@ func (s *Service) Validate(ctx context.Context, memberID uuid.UUID, req *Validat
 		response.Reason = "โปรโมชั่นหมดอายุแล้ว"
 		return response, nil
 	}
+	inSegment, err := promotionscope.MemberInSegment(ctx, s.bunDB.DB(), record.ID, memberID)
+	if err != nil {
+		return nil, err
+	}
+	if !inSegment {
+		response.Reason = "โปรโมชั่นนี้ไม่ได้จัดให้สำหรับบัญชีของคุณ"
+		return response, nil
+	}
 	if req.OrderAmount < record.MinOrderAmount {
 		response.Reason = "ยอดสั่งซื้อไม่ถึงขั้นต่ำของโปรโมชั่น"
 		return response, nil
@@ -468,21 +878,78 @@ func (s *Service) Validate(ctx context.Context, memberID uuid.UUID, req *Validat
 		}
 	}
 
+	scope, err := promotionscope.LoadScope(ctx, s.bunDB.DB(), record.ID)
+	if err != nil {
+		return nil, err
+	}
+	discountBase := req.OrderAmount
+	var eligible []bool
+	if len(lines) > 0 {
+		eligible, err = promotionscope.EligibleLines(ctx, s.bunDB.DB(), scope, lines)
+		if err != nil {
+			return nil, err
+		}
+		discountBase = promotionscope.EligibleAmount(lines, eligible).InexactFloat64()
+		if discountBase <= 0 {
+			response.Reason = "ไม่มีสินค้าในตะกร้าที่ร่วมรายการโปรโมชั่นนี้"
+			return response, nil
+		}
+	} else if scope.IsScoped() || promotionscope.IsLineRule(record.DiscountType) {
+		response.Reason = "โปรโมชั่นนี้ใช้ได้กับสินค้าบางรายการ กรุณาระบุสินค้าในตะกร้า"
+		return response, nil
+	}
+	response.EligibleAmount = discountBase
+
 	discount := 0.0
-	if record.DiscountType == "percent" {
-		discount = req.OrderAmount * (record.DiscountValue / 100)
+	var allocations []decimal.Decimal
+	if promotionscope.IsLineRule(record.DiscountType) {
+		bundleItems, err := promotionscope.LoadBundleItems(ctx, s.bunDB.DB(), []uuid.UUID{record.ID})
+		if err != nil {
+			return nil, err
+		}
+		result, err := promotionscope.EvaluateRule(toPromotionRule(record, bundleItems[record.ID]), lines, eligible)
+		if err != nil {
+			if reason, ok := promotionRuleReasons[err]; ok {
+				response.Reason = reason
+				return response, nil
+			}
+			return nil, err
+		}
+		discount = result.DiscountAmount.InexactFloat64()
+		allocations = result.LineDiscounts
+		response.Rewards = result.Rewards
 	} else {
-		discount = record.DiscountValue
-	}
+		if record.DiscountType == "percent" {
+			discount = discountBase * (record.DiscountValue / 100)
+		} else {
+			discount = record.DiscountValue
+		}
 
-	if record.MaxDiscount != nil && *record.MaxDiscount > 0 && discount > *record.MaxDiscount {
-		discount = *record.MaxDiscount
-	}
-	if discount > req.OrderAmount {
-		discount = req.OrderAmount
+		if record.MaxDiscount != nil && *record.MaxDiscount > 0 && discount > *record.MaxDiscount {
+			discount = *record.MaxDiscount
+		}
+		if discount > discountBase {
+			discount = discountBase
+		}
+		if discount < 0 {
+			discount = 0
+		}
+		if len(lines) > 0 {
+			allocations = promotionscope.AllocateDiscount(lines, eligible, decimal.NewFromFloat(discount).Round(2))
+		}
 	}
-	if discount < 0 {
-		discount = 0
+
+	if len(lines) > 0 {
+		response.Items = make([]*ValidatePromotionLineResult, 0, len(lines))
+		for i, line := range lines {
+			response.Items = append(response.Items, &ValidatePromotionLineResult{
+				ProductID:      line.ProductID.String(),
+				Quantity:       line.Quantity,
+				Amount:         line.Amount.InexactFloat64(),
+				IsEligible:     eligible[i],
+				DiscountAmount: allocations[i].InexactFloat64(),
+			})
+		}
 	}
 
 	response.IsValid = true
@@ -496,6 +963,69 @@ func (s *Service) Validate(ctx context.Context, memberID uuid.UUID, req *Validat
 	return response, nil
 }
 
+// priceValidateLines prices cart lines at the member's current product
+// price, the same way orders are priced when they are created.
+func (s *Service) priceValidateLines(ctx context.Context, memberID uuid.UUID, items []ValidatePromotionLineServiceRequest) ([]promotionscope.Line, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	productIDs := make([]uuid.UUID, 0, len(items))
+	for _, item := range items {
+		productIDs = append(productIDs, item.ProductID)
+	}
+
+	type productPriceRow struct {
+		ID    uuid.UUID       `bun:"id"`
+		Price decimal.Decimal `bun:"price"`
+	}
+	rows := make([]*productPriceRow, 0)
+	if err := s.bunDB.DB().NewSelect().
+		TableExpr("products").
+		Column("id", "price").
+		Where("id IN (?)", bun.In(productIDs)).
+		Scan(ctx, &rows); err != nil {
+		return nil, err
+	}
+	offers, err := flashsale.LoadActive(ctx, s.bunDB.DB(), productIDs)
+	if err != nil {
+		return nil, err
+	}
+	tier, err := pricelist.MemberTier(ctx, s.bunDB.DB(), memberID)
+	if err != nil {
+		return nil, err
+	}
+	priceLists, err := pricelist.Load(ctx, s.bunDB.DB(), tier.ID, productIDs)
+	if err != nil {
+		return nil, err
+	}
+	basePrices := make(map[uuid.UUID]decimal.Decimal, len(rows))
+	for _, row := range rows {
+		basePrices[row.ID] = row.Price
+	}
+
+	lines := make([]promotionscope.Line, 0, len(items))
+	for _, item := range items {
+		basePrice, ok := basePrices[item.ProductID]
+		if !ok {
+			return nil, fmt.Errorf("product not found")
+		}
+		if item.Quantity <= 0 {
+			return nil, fmt.Errorf("quantity must be greater than zero")
+		}
+		price := priceLists[item.ProductID].Resolve(basePrice, item.Quantity).UnitPrice
+		if offer, ok := offers[item.ProductID]; ok {
+			price = offer.SalePrice
+		}
+		lines = append(lines, promotionscope.Line{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			Amount:    price.Mul(decimal.NewFromInt(int64(item.Quantity))).Round(2),
+		})
+	}
+	return lines, nil
+}
+
 func (s *Service) Use(ctx context.Context, req *UsePromotionServiceRequest) error {
 	promotionID, err := uuid.Parse(strings.TrimSpace(req.PromotionID))
 	if err != nil {
@@ -516,6 +1046,23 @@ func (s *Service) Use(ctx context.Context, req *UsePromotionServiceRequest) erro
 			usage.OrderID = &orderID
 		}
 	}
+	if usage.OrderID != nil {
+		// Orders placed with a single-use code have already redeemed it.
+		var codeID uuid.UUID
+		err := s.bunDB.DB().NewSelect().
+			Model((*promotionCodeRecord)(nil)).
+			Column("id").
+			Where("promotion_id = ?", promotionID).
+			Where("order_id = ?", *usage.OrderID).
+			Limit(1).
+			Scan(ctx, &codeID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		if err == nil {
+			usage.PromotionCodeID = &codeID
+		}
+	}
 
 	err = s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
 		if _, err := tx.NewInsert().Model(usage).Exec(ctx); err != nil {
@@ -531,7 +1078,12 @@ func (s *Service) Use(ctx context.Context, req *UsePromotionServiceRequest) erro
 			return err
 		}
 
-		return nil
+		return promotionscope.RecordEvents(ctx, tx, promotionscope.Event{
+			PromotionID: promotionID,
+			Type:        promotionscope.EventRedeemed,
+			MemberID:    &usage.MemberID,
+			OrderID:     usage.OrderID,
+		})
 	})
 	if err != nil {
 		return err
@@ -547,6 +1099,7 @@ func (s *Service) ListAvailableForMember(ctx context.Context, memberID uuid.UUID
 		Where("is_active = ?", true).
 		Where("(starts_at IS NULL OR starts_at <= ?)", now).
 		Where("(ends_at IS NULL OR ends_at >= ?)", now)
+	query = promotionscope.WhereMemberInSegment(query, "?TableAlias", memberID)
 
 	search := strings.TrimSpace(req.Search)
 	if search != "" {
@@ -565,6 +1118,7 @@ func (s *Service) ListAvailableForMember(ctx context.Context, memberID uuid.UUID
 		Where("(starts_at IS NULL OR starts_at <= ?)", now).
 		Where("(ends_at IS NULL OR ends_at >= ?)", now).
 		OrderExpr("created_at DESC")
+	listQuery = promotionscope.WhereMemberInSegment(listQuery, "?TableAlias", memberID)
 	if search != "" {
 		listQuery = listQuery.Where("(code ILIKE ? OR name ILIKE ?)", "%"+search+"%", "%"+search+"%")
 	}
@@ -606,6 +1160,9 @@ func (s *Service) ListAvailableForMember(ctx context.Context, memberID uuid.UUID
 		}
 		response = append(response, memberItem)
 	}
+	if err := s.attachMemberPromotionScopes(ctx, response); err != nil {
+		return nil, nil, err
+	}
 
 	return response, &base.ResponsePaginate{Page: req.GetPage(), Size: req.GetSize(), Total: int64(total)}, nil
 }
@@ -626,6 +1183,18 @@ func (s *Service) ListMy(ctx context.Context, memberID uuid.UUID, req *ListMembe
 		StartsAt        *time.Time `bun:"starts_at"`
 		EndsAt          *time.Time `bun:"ends_at"`
 		IsActive        bool       `bun:"is_active"`
+		BuyQuantity     int        `bun:"buy_quantity"`
+		GetQuantity     int        `bun:"get_quantity"`
+		GetDiscountPct  float64    `bun:"get_discount_percent"`
+		GetProductID    *uuid.UUID `bun:"get_product_id"`
+		BundlePrice     float64    `bun:"bundle_price"`
+		IsExclusive     bool       `bun:"is_exclusive"`
+		StackWithTier   bool       `bun:"stack_with_tier"`
+		StackWithPromos bool       `bun:"stack_with_promotions"`
+		Priority        int        `bun:"priority"`
+		SegmentRecent   *int       `bun:"segment_registered_within_days"`
+		SegmentFirst    bool       `bun:"segment_first_purchase"`
+		SegmentInactive *int       `bun:"segment_inactive_days"`
 		PromotionCreate time.Time  `bun:"promotion_created_at"`
 		PromotionUpdate time.Time  `bun:"promotion_updated_at"`
 		CollectedAt     time.Time  `bun:"collected_at"`
@@ -665,6 +1234,18 @@ func (s *Service) ListMy(ctx context.Context, memberID uuid.UUID, req *ListMembe
 		ColumnExpr("p.starts_at").
 		ColumnExpr("p.ends_at").
 		ColumnExpr("p.is_active").
+		ColumnExpr("p.buy_quantity").
+		ColumnExpr("p.get_quantity").
+		ColumnExpr("p.get_discount_percent").
+		ColumnExpr("p.get_product_id").
+		ColumnExpr("p.bundle_price").
+		ColumnExpr("p.is_exclusive").
+		ColumnExpr("p.stack_with_tier").
+		ColumnExpr("p.stack_with_promotions").
+		ColumnExpr("p.priority").
+		ColumnExpr("p.segment_registered_within_days").
+		ColumnExpr("p.segment_first_purchase").
+		ColumnExpr("p.segment_inactive_days").
 		ColumnExpr("p.created_at AS promotion_created_at").
 		ColumnExpr("p.updated_at AS promotion_updated_at").
 		ColumnExpr("mpc.collected_at").
@@ -683,22 +1264,35 @@ func (s *Service) ListMy(ctx context.Context, memberID uuid.UUID, req *ListMembe
 	for _, item := range rows {
 		promotion := &MemberPromotionItem{
 			PromotionItem: PromotionItem{
-				ID:             item.PromotionID.String(),
-				Code:           item.Code,
-				Name:           item.Name,
-				Description:    item.Description,
-				DiscountType:   item.DiscountType,
-				DiscountValue:  item.DiscountValue,
-				MaxDiscount:    item.MaxDiscount,
-				MinOrderAmount: item.MinOrderAmount,
-				UsageLimit:     item.UsageLimit,
-				UsagePerMember: item.UsagePerMember,
-				UsedCount:      item.UsedCount,
-				StartsAt:       formatOptionalTime(item.StartsAt),
-				EndsAt:         formatOptionalTime(item.EndsAt),
-				IsActive:       item.IsActive,
-				CreatedAt:      item.PromotionCreate.Format("2006-01-02T15:04:05Z07:00"),
-				UpdatedAt:      item.PromotionUpdate.Format("2006-01-02T15:04:05Z07:00"),
+				ID:                          item.PromotionID.String(),
+				Code:                        item.Code,
+				Name:                        item.Name,
+				Description:                 item.Description,
+				DiscountType:                item.DiscountType,
+				DiscountValue:               item.DiscountValue,
+				MaxDiscount:                 item.MaxDiscount,
+				MinOrderAmount:              item.MinOrderAmount,
+				UsageLimit:                  item.UsageLimit,
+				UsagePerMember:              item.UsagePerMember,
+				UsedCount:                   item.UsedCount,
+				StartsAt:                    formatOptionalTime(item.StartsAt),
+				EndsAt:                      formatOptionalTime(item.EndsAt),
+				IsActive:                    item.IsActive,
+				LifecycleStatus:             promotionscope.LifecycleStatus(item.StartsAt, item.EndsAt, time.Now().UTC()),
+				BuyQuantity:                 item.BuyQuantity,
+				GetQuantity:                 item.GetQuantity,
+				GetDiscountPercent:          item.GetDiscountPct,
+				GetProductID:                formatOptionalUUID(item.GetProductID),
+				BundlePrice:                 item.BundlePrice,
+				IsExclusive:                 item.IsExclusive,
+				StackWithTier:               item.StackWithTier,
+				StackWithPromotions:         item.StackWithPromos,
+				Priority:                    item.Priority,
+				SegmentRegisteredWithinDays: item.SegmentRecent,
+				SegmentFirstPurchase:        item.SegmentFirst,
+				SegmentInactiveDays:         item.SegmentInactive,
+				CreatedAt:                   item.PromotionCreate.Format("2006-01-02T15:04:05Z07:00"),
+				UpdatedAt:                   item.PromotionUpdate.Format("2006-01-02T15:04:05Z07:00"),
 			},
 			CollectedAt: func() *string {
 				formatted := item.CollectedAt.Format("2006-01-02T15:04:05Z07:00")
@@ -708,6 +1302,9 @@ func (s *Service) ListMy(ctx context.Context, memberID uuid.UUID, req *ListMembe
 		}
 		response = append(response, promotion)
 	}
+	if err := s.attachMemberPromotionScopes(ctx, response); err != nil {
+		return nil, nil, err
+	}
 
 	return response, &base.ResponsePaginate{Page: req.GetPage(), Size: req.GetSize(), Total: int64(total)}, nil
 }
@@ -737,6 +1334,13 @@ func (s *Service) Collect(ctx context.Context, memberID uuid.UUID, promotionID s
 	if promotion.EndsAt != nil && now.After(*promotion.EndsAt) {
 		return fmt.Errorf("promotion has expired")
 	}
+	inSegment, err := promotionscope.MemberInSegment(ctx, s.bunDB.DB(), promotion.ID, memberID)
+	if err != nil {
+		return err
+	}
+	if !inSegment {
+		return promotionscope.ErrMemberNotInSegment
+	}
 
 	record := &memberPromotionCollectionRecord{
 		ID:          uuid.New(),
@@ -746,14 +1350,23 @@ func (s *Service) Collect(ctx context.Context, memberID uuid.UUID, promotionID s
 		CreatedAt:   now,
 	}
 
-	if _, err := s.bunDB.DB().NewInsert().
-		Model(record).
-		On("CONFLICT (member_id, promotion_id) DO NOTHING").
-		Exec(ctx); err != nil {
-		return err
-	}
-
-	return nil
+	return s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		result, err := tx.NewInsert().
+			Model(record).
+			On("CONFLICT (member_id, promotion_id) DO NOTHING").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+			return err
+		}
+		return promotionscope.RecordEvents(ctx, tx, promotionscope.Event{
+			PromotionID: parsedPromotionID,
+			Type:        promotionscope.EventCollected,
+			MemberID:    &memberID,
+		})
+	})
 }
 
 func (s *Service) ReportSummary(ctx context.Context) (*PromotionReportSummary, error) {
@@ -8,7 +8,7 @@ import (
 	"mime"
 	"net/http"
 	"os"
-	"path/filepath"
+	"phakram/app/utils/imageproc"
 	"phakram/app/utils/s3compat"
 	"strings"
 	"time"
@@ -27,10 +27,11 @@ type railwayStorageClient struct {
 }
 
 type uploadedReviewImage struct {
-	Path     string
-	FileName string
-	MIMEType string
-	Size     int64
+	Path       string
+	FileName   string
+	MIMEType   string
+	Size       int64
+	Renditions imageproc.Renditions
 }
 
 func newRailwayStorageClient(conf RailwayConfig) *railwayStorageClient {
@@ -133,7 +134,7 @@ func (c *railwayStorageClient) UploadReviewImage(ctx context.Context, productID
 		return nil, errors.New("railway public storage is not configured")
 	}
 
-	data, mimeType, err := decodeReviewBase64Image(encoded)
+	data, _, err := decodeReviewBase64Image(encoded)
 	if err != nil {
 		return nil, err
 	}
@@ -143,26 +144,28 @@ func (c *railwayStorageClient) UploadReviewImage(ctx context.Context, productID
 	if len(data) > maxReviewImageFileSizeBytes {
 		return nil, errors.New("review image exceeds 5 MB")
 	}
-	if !isAllowedReviewImageMIME(mimeType) {
-		return nil, fmt.Errorf("unsupported review image type: %s", mimeType)
+	processed, err := imageproc.Process(data)
+	if err != nil {
+		return nil, err
 	}
 
-	ext := extensionByReviewMIME(mimeType)
 	safeName := strings.TrimSpace(fileName)
 	if safeName == "" {
-		safeName = fmt.Sprintf("review-%s%s", reviewID.String(), ext)
+		safeName = fmt.Sprintf("review-%s%s", reviewID.String(), processed.Original.Ext)
 	}
 
-	objectPath := fmt.Sprintf("reviews/%s/%s/%s-%d%s", productID.String(), reviewID.String(), uuid.NewString(), time.Now().UnixMilli(), ext)
-	if err := c.s3.PutObject(ctx, c.reviewBucket, objectPath, mimeType, data); err != nil {
+	objectBase := fmt.Sprintf("reviews/%s/%s/%s-%d", productID.String(), reviewID.String(), uuid.NewString(), time.Now().UnixMilli())
+	uploaded, err := processed.Upload(ctx, c.s3, c.reviewBucket, objectBase)
+	if err != nil {
 		return nil, err
 	}
 
 	return &uploadedReviewImage{
-		Path:     fmt.Sprintf("%s/%s", c.reviewBucket, objectPath),
-		FileName: safeName,
-		MIMEType: mimeType,
-		Size:     int64(len(data)),
+		Path:       uploaded.Path,
+		FileName:   safeName,
+		MIMEType:   uploaded.MIMEType,
+		Size:       uploaded.Size,
+		Renditions: uploaded.Renditions,
 	}, nil
 }
 
@@ -203,33 +206,3 @@ func decodeReviewBase64Image(input string) ([]byte, string, error) {
 
 	return decoded, strings.ToLower(strings.TrimSpace(mimeType)), nil
 }
-
-func isAllowedReviewImageMIME(mimeType string) bool {
-	switch strings.ToLower(strings.TrimSpace(mimeType)) {
-	case "image/jpeg", "image/png", "image/webp", "image/heic", "image/heif", "image/heic-sequence", "image/heif-sequence":
-		return true
-	default:
-		return false
-	}
-}
-
-func extensionByReviewMIME(mimeType string) string {
-	switch strings.ToLower(strings.TrimSpace(mimeType)) {
-	case "image/jpeg":
-		return ".jpg"
-	case "image/png":
-		return ".png"
-	case "image/webp":
-		return ".webp"
-	case "image/heic", "image/heic-sequence":
-		return ".heic"
-	case "image/heif", "image/heif-sequence":
-		return ".heif"
-	default:
-		ext := filepath.Ext(mimeType)
-		if ext != "" {
-			return ext
-		}
-		return ".bin"
-	}
-}
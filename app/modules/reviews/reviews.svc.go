@@ -7,7 +7,9 @@ import (
 	"strings"
 	"time"
 
+	"phakram/app/modules/entities/ent"
 	"phakram/app/utils/base"
+	"phakram/app/utils/imageproc"
 
 	"github.com/google/uuid"
 	"github.com/uptrace/bun"
@@ -59,20 +61,21 @@ type ListAdminReviewsServiceRequest struct {
 }
 
 type ProductReviewItem struct {
-	ID                 string   `json:"id"`
-	MemberID           string   `json:"member_id"`
-	MemberName         string   `json:"member_name"`
-	ProductID          string   `json:"product_id"`
-	OrderID            string   `json:"order_id"`
-	OrderItemID        string   `json:"order_item_id"`
-	OrderNo            string   `json:"order_no"`
-	Rating             int      `json:"rating"`
-	Comment            string   `json:"comment"`
-	ImageURLs          []string `json:"image_urls"`
-	IsVisible          bool     `json:"is_visible"`
-	IsVerifiedPurchase bool     `json:"is_verified_purchase"`
-	CreatedAt          string   `json:"created_at"`
-	UpdatedAt          string   `json:"updated_at"`
+	ID                 string                `json:"id"`
+	MemberID           string                `json:"member_id"`
+	MemberName         string                `json:"member_name"`
+	ProductID          string                `json:"product_id"`
+	OrderID            string                `json:"order_id"`
+	OrderItemID        string                `json:"order_item_id"`
+	OrderNo            string                `json:"order_no"`
+	Rating             int                   `json:"rating"`
+	Comment            string                `json:"comment"`
+	ImageURLs          []string              `json:"image_urls"`
+	Images             []*imageproc.ImageSet `json:"images"`
+	IsVisible          bool                  `json:"is_visible"`
+	IsVerifiedPurchase bool                  `json:"is_verified_purchase"`
+	CreatedAt          string                `json:"created_at"`
+	UpdatedAt          string                `json:"updated_at"`
 }
 
 type ProductReviewSummary struct {
@@ -152,8 +155,8 @@ func normalizeReviewSort(sortBy string, orderBy string) string {
 	}
 }
 
-func (s *Service) loadReviewImageMap(ctx context.Context, reviewIDs []uuid.UUID) (map[uuid.UUID][]string, error) {
-	imageMap := make(map[uuid.UUID][]string)
+func (s *Service) loadReviewImageMap(ctx context.Context, reviewIDs []uuid.UUID) (map[uuid.UUID][]*imageproc.ImageSet, error) {
+	imageMap := make(map[uuid.UUID][]*imageproc.ImageSet)
 	if len(reviewIDs) == 0 {
 		return imageMap, nil
 	}
@@ -167,19 +170,44 @@ func (s *Service) loadReviewImageMap(ctx context.Context, reviewIDs []uuid.UUID)
 		return nil, err
 	}
 
+	paths := make([]string, 0, len(rows))
 	for _, item := range rows {
-		resolved := strings.TrimSpace(item.ImageURL)
-		if s.railwayStorage != nil {
-			resolved = s.railwayStorage.ResolveObjectURL(resolved)
-		}
-		imageMap[item.ReviewID] = append(imageMap[item.ReviewID], resolved)
+		paths = append(paths, strings.TrimSpace(item.ImageURL))
+	}
+	renditions, err := imageproc.LoadRenditions(ctx, s.bunDB.DB(), paths)
+	if err != nil {
+		return nil, err
+	}
+
+	resolve := strings.TrimSpace
+	if s.railwayStorage != nil {
+		resolve = s.railwayStorage.ResolveObjectURL
+	}
+	for _, item := range rows {
+		path := strings.TrimSpace(item.ImageURL)
+		imageMap[item.ReviewID] = append(imageMap[item.ReviewID], imageproc.NewImageSet(path, renditions[path], resolve))
 	}
 
 	return imageMap, nil
 }
 
-func (s *Service) toStoredReviewImageURLs(ctx context.Context, productID uuid.UUID, reviewID uuid.UUID, imageURLs []string) ([]string, error) {
+func reviewImageURLs(images []*imageproc.ImageSet) []string {
+	if images == nil {
+		return nil
+	}
+	urls := make([]string, 0, len(images))
+	for _, image := range images {
+		urls = append(urls, image.Src)
+	}
+	return urls
+}
+
+// toStoredReviewImageURLs uploads the data URLs of a review and returns the
+// paths to save, plus a storages row for every new upload.
+func (s *Service) toStoredReviewImageURLs(ctx context.Context, memberID uuid.UUID, productID uuid.UUID, reviewID uuid.UUID, imageURLs []string) ([]string, []*ent.StorageEntity, error) {
+	now := time.Now()
 	stored := make([]string, 0, len(imageURLs))
+	storages := make([]*ent.StorageEntity, 0)
 	for _, imageURL := range imageURLs {
 		trimmed := strings.TrimSpace(imageURL)
 		if trimmed == "" {
@@ -189,15 +217,31 @@ func (s *Service) toStoredReviewImageURLs(ctx context.Context, productID uuid.UU
 		lowerValue := strings.ToLower(trimmed)
 		if strings.HasPrefix(lowerValue, "data:image/") {
 			if s.railwayStorage == nil || !s.railwayStorage.enabledForPublic() {
-				return nil, errors.New("railway public storage is not configured")
+				return nil, nil, errors.New("railway public storage is not configured")
 			}
 
 			uploaded, err := s.railwayStorage.UploadReviewImage(ctx, productID, reviewID, "", trimmed)
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 
 			stored = append(stored, uploaded.Path)
+			uploadedBy := memberID
+			storages = append(storages, &ent.StorageEntity{
+				ID:            uuid.New(),
+				RefID:         reviewID,
+				FileName:      uploaded.FileName,
+				FilePath:      uploaded.Path,
+				FileSource:    "STORAGE",
+				FileSize:      uploaded.Size,
+				FileType:      uploaded.MIMEType,
+				IsActive:      true,
+				RelatedEntity: ent.RelatedEntityReviewFile,
+				UploadedBy:    &uploadedBy,
+				Renditions:    uploaded.Renditions,
+				CreatedAt:     now,
+				UpdatedAt:     now,
+			})
 			continue
 		}
 
@@ -209,7 +253,7 @@ func (s *Service) toStoredReviewImageURLs(ctx context.Context, productID uuid.UU
 		stored = append(stored, trimmed)
 	}
 
-	return stored, nil
+	return stored, storages, nil
 }
 
 func (s *Service) applyPublicReviewFilters(query *bun.SelectQuery, req *ListProductReviewsServiceRequest) {
@@ -320,7 +364,8 @@ func (s *Service) ListPublicByProduct(ctx context.Context, productID uuid.UUID,
 			OrderNo:            item.OrderNo,
 			Rating:             item.Rating,
 			Comment:            item.Comment,
-			ImageURLs:          imageMap[item.ID],
+			ImageURLs:          reviewImageURLs(imageMap[item.ID]),
+			Images:             imageMap[item.ID],
 			IsVisible:          item.IsVisible,
 			IsVerifiedPurchase: true,
 			CreatedAt:          item.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
@@ -376,10 +421,18 @@ func (s *Service) ListEligibleByProduct(ctx context.Context, memberID uuid.UUID,
 	return items, nil
 }
 
-func (s *Service) upsertReviewImagesInTx(ctx context.Context, tx bun.Tx, reviewID uuid.UUID, imageURLs []string) error {
+func (s *Service) upsertReviewImagesInTx(ctx context.Context, tx bun.Tx, reviewID uuid.UUID, imageURLs []string, storages []*ent.StorageEntity) error {
 	if _, err := tx.NewDelete().Model((*productReviewImageRecord)(nil)).Where("review_id = ?", reviewID).Exec(ctx); err != nil {
 		return err
 	}
+	if err := deactivateReviewStoragesInTx(ctx, tx, reviewID, imageURLs); err != nil {
+		return err
+	}
+	if len(storages) > 0 {
+		if _, err := tx.NewInsert().Model(&storages).Exec(ctx); err != nil {
+			return err
+		}
+	}
 
 	if len(imageURLs) == 0 {
 		return nil
@@ -405,6 +458,25 @@ func (s *Service) upsertReviewImagesInTx(ctx context.Context, tx bun.Tx, reviewI
 	return nil
 }
 
+// deactivateReviewStoragesInTx retires the storages rows of a review whose
+// image is no longer in keepPaths.
+func deactivateReviewStoragesInTx(ctx context.Context, tx bun.Tx, reviewID uuid.UUID, keepPaths []string) error {
+	now := time.Now()
+	query := tx.NewUpdate().
+		Model((*ent.StorageEntity)(nil)).
+		Set("is_active = false").
+		Set("deleted_at = ?", now).
+		Set("updated_at = ?", now).
+		Where("ref_id = ?", reviewID).
+		Where("related_entity = ?", ent.RelatedEntityReviewFile).
+		Where("deleted_at IS NULL")
+	if len(keepPaths) > 0 {
+		query = query.Where("file_path NOT IN (?)", bun.In(keepPaths))
+	}
+	_, err := query.Exec(ctx)
+	return err
+}
+
 func (s *Service) Create(ctx context.Context, req *CreateReviewServiceRequest) error {
 	if req.Rating < 1 || req.Rating > 5 {
 		return errors.New("rating must be between 1 and 5")
@@ -456,7 +528,7 @@ func (s *Service) Create(ctx context.Context, req *CreateReviewServiceRequest) e
 
 	now := time.Now().UTC()
 	reviewID := uuid.New()
-	storedImageURLs, err := s.toStoredReviewImageURLs(ctx, orderItem.ProductID, reviewID, imageURLs)
+	storedImageURLs, storages, err := s.toStoredReviewImageURLs(ctx, req.MemberID, orderItem.ProductID, reviewID, imageURLs)
 	if err != nil {
 		return err
 	}
@@ -478,7 +550,7 @@ func (s *Service) Create(ctx context.Context, req *CreateReviewServiceRequest) e
 		if _, err := tx.NewInsert().Model(record).Exec(ctx); err != nil {
 			return err
 		}
-		return s.upsertReviewImagesInTx(ctx, tx, record.ID, storedImageURLs)
+		return s.upsertReviewImagesInTx(ctx, tx, record.ID, storedImageURLs, storages)
 	})
 }
 
@@ -514,7 +586,7 @@ func (s *Service) Update(ctx context.Context, req *UpdateReviewServiceRequest) e
 		return errors.New("review edit window expired")
 	}
 
-	storedImageURLs, err := s.toStoredReviewImageURLs(ctx, review.ProductID, review.ID, imageURLs)
+	storedImageURLs, storages, err := s.toStoredReviewImageURLs(ctx, req.MemberID, review.ProductID, review.ID, imageURLs)
 	if err != nil {
 		return err
 	}
@@ -532,7 +604,7 @@ func (s *Service) Update(ctx context.Context, req *UpdateReviewServiceRequest) e
 			return err
 		}
 
-		return s.upsertReviewImagesInTx(ctx, tx, req.ReviewID, storedImageURLs)
+		return s.upsertReviewImagesInTx(ctx, tx, req.ReviewID, storedImageURLs, storages)
 	})
 }
 
@@ -561,6 +633,9 @@ func (s *Service) Delete(ctx context.Context, reviewID uuid.UUID, memberID uuid.
 			Exec(ctx); err != nil {
 			return err
 		}
+		if err := deactivateReviewStoragesInTx(ctx, tx, reviewID, nil); err != nil {
+			return err
+		}
 
 		res, err := tx.NewDelete().
 			Model((*productReviewRecord)(nil)).
@@ -698,7 +773,8 @@ func (s *Service) ListAdmin(ctx context.Context, req *ListAdminReviewsServiceReq
 			OrderNo:            item.OrderNo,
 			Rating:             item.Rating,
 			Comment:            item.Comment,
-			ImageURLs:          imageMap[item.ID],
+			ImageURLs:          reviewImageURLs(imageMap[item.ID]),
+			Images:             imageMap[item.ID],
 			IsVisible:          item.IsVisible,
 			IsVerifiedPurchase: true,
 			CreatedAt:          item.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
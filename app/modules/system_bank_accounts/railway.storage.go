@@ -8,7 +8,8 @@ import (
 	"mime"
 	"net/http"
 	"os"
-	"path/filepath"
+	"path"
+	"phakram/app/utils/imageproc"
 	"phakram/app/utils/s3compat"
 	"strings"
 	"time"
@@ -25,9 +26,11 @@ type railwayStorageClient struct {
 }
 
 type uploadedSystemBankQR struct {
-	Path     string
-	MIMEType string
-	Size     int64
+	Path       string
+	FileName   string
+	MIMEType   string
+	Size       int64
+	Renditions imageproc.Renditions
 }
 
 func newRailwayStorageClient(conf RailwayConfig) *railwayStorageClient {
@@ -109,7 +112,7 @@ func (c *railwayStorageClient) UploadSystemBankQRCode(ctx context.Context, syste
 		return nil, errors.New("railway public storage is not configured")
 	}
 
-	data, mimeType, err := decodeSystemBankBase64Image(encoded)
+	data, _, err := decodeSystemBankBase64Image(encoded)
 	if err != nil {
 		return nil, err
 	}
@@ -119,20 +122,23 @@ func (c *railwayStorageClient) UploadSystemBankQRCode(ctx context.Context, syste
 	if len(data) > maxSystemBankQRCodeFileSizeBytes {
 		return nil, errors.New("qr image exceeds 5 MB")
 	}
-	if !isAllowedSystemBankImageMIME(mimeType) {
-		return nil, fmt.Errorf("unsupported qr image type: %s", mimeType)
+	processed, err := imageproc.Process(data)
+	if err != nil {
+		return nil, err
 	}
 
-	ext := extensionBySystemBankMIME(mimeType)
-	objectPath := fmt.Sprintf("system-bank-accounts/%s/qr-%d%s", systemBankAccountID.String(), time.Now().UnixMilli(), ext)
-	if err := c.s3.PutObject(ctx, c.publicBucket, objectPath, mimeType, data); err != nil {
+	objectBase := fmt.Sprintf("system-bank-accounts/%s/qr-%d", systemBankAccountID.String(), time.Now().UnixMilli())
+	uploaded, err := processed.Upload(ctx, c.s3, c.publicBucket, objectBase)
+	if err != nil {
 		return nil, err
 	}
 
 	return &uploadedSystemBankQR{
-		Path:     fmt.Sprintf("%s/%s", c.publicBucket, objectPath),
-		MIMEType: mimeType,
-		Size:     int64(len(data)),
+		Path:       uploaded.Path,
+		FileName:   path.Base(uploaded.Path),
+		MIMEType:   uploaded.MIMEType,
+		Size:       uploaded.Size,
+		Renditions: uploaded.Renditions,
 	}, nil
 }
 
@@ -174,36 +180,6 @@ func decodeSystemBankBase64Image(input string) ([]byte, string, error) {
 	return decoded, strings.ToLower(strings.TrimSpace(mimeType)), nil
 }
 
-func isAllowedSystemBankImageMIME(mimeType string) bool {
-	switch strings.ToLower(strings.TrimSpace(mimeType)) {
-	case "image/jpeg", "image/png", "image/webp", "image/heic", "image/heif", "image/heic-sequence", "image/heif-sequence":
-		return true
-	default:
-		return false
-	}
-}
-
-func extensionBySystemBankMIME(mimeType string) string {
-	switch strings.ToLower(strings.TrimSpace(mimeType)) {
-	case "image/jpeg":
-		return ".jpg"
-	case "image/png":
-		return ".png"
-	case "image/webp":
-		return ".webp"
-	case "image/heic", "image/heic-sequence":
-		return ".heic"
-	case "image/heif", "image/heif-sequence":
-		return ".heif"
-	default:
-		ext := filepath.Ext(mimeType)
-		if ext != "" {
-			return ext
-		}
-		return ".bin"
-	}
-}
-
 func splitBucketAndObjectPath(storedPath string) (string, string, bool) {
 	trimmed := strings.Trim(strings.TrimSpace(storedPath), "/")
 	parts := strings.SplitN(trimmed, "/", 2)
@@ -6,6 +6,7 @@ import (
 	"phakram/app/modules/entities/ent"
 	"phakram/app/utils"
 	"phakram/app/utils/base"
+	"phakram/app/utils/imageproc"
 	"strings"
 	"time"
 
@@ -20,20 +21,21 @@ type ListSystemBankAccountServiceRequest struct {
 }
 
 type SystemBankAccountServiceResponse struct {
-	ID                uuid.UUID `json:"id"`
-	BankID            uuid.UUID `json:"bank_id"`
-	BankNameTh        string    `json:"bank_name_th"`
-	BankNameEn        string    `json:"bank_name_en"`
-	AccountName       string    `json:"account_name"`
-	AccountNo         string    `json:"account_no"`
-	Branch            string    `json:"branch"`
-	QRCodeImageURL    string    `json:"qr_image_url"`
-	QRCodeImageSource string    `json:"qr_image_source"`
-	IsActive          bool      `json:"is_active"`
-	IsDefaultReceive  bool      `json:"is_default_receive"`
-	IsDefaultRefund   bool      `json:"is_default_refund"`
-	CreatedAt         time.Time `json:"created_at"`
-	UpdatedAt         time.Time `json:"updated_at"`
+	ID                uuid.UUID           `json:"id"`
+	BankID            uuid.UUID           `json:"bank_id"`
+	BankNameTh        string              `json:"bank_name_th"`
+	BankNameEn        string              `json:"bank_name_en"`
+	AccountName       string              `json:"account_name"`
+	AccountNo         string              `json:"account_no"`
+	Branch            string              `json:"branch"`
+	QRCodeImageURL    string              `json:"qr_image_url"`
+	QRCodeImageSource string              `json:"qr_image_source"`
+	QRCodeImage       *imageproc.ImageSet `json:"qr_image,omitempty"`
+	IsActive          bool                `json:"is_active"`
+	IsDefaultReceive  bool                `json:"is_default_receive"`
+	IsDefaultRefund   bool                `json:"is_default_refund"`
+	CreatedAt         time.Time           `json:"created_at"`
+	UpdatedAt         time.Time           `json:"updated_at"`
 }
 
 type UpsertSystemBankAccountServiceRequest struct {
@@ -119,32 +121,9 @@ func (s *Service) ListService(ctx context.Context, req *ListSystemBankAccountSer
 		return nil, nil, err
 	}
 
-	data := make([]*SystemBankAccountServiceResponse, 0, len(rows))
-	for _, row := range rows {
-		resolvedQR := strings.TrimSpace(row.QRCodeImageURL)
-		if s.railwayStorage != nil {
-			resolvedQR = s.railwayStorage.ResolveObjectURL(resolvedQR)
-		}
-		resolvedSource := strings.TrimSpace(row.QRCodeImageSource)
-		if resolvedSource == "" {
-			resolvedSource = systemBankQRCodeSourceFromPath(row.QRCodeImageURL)
-		}
-		data = append(data, &SystemBankAccountServiceResponse{
-			ID:                row.ID,
-			BankID:            row.BankID,
-			BankNameTh:        row.BankNameTh,
-			BankNameEn:        row.BankNameEn,
-			AccountName:       row.AccountName,
-			AccountNo:         row.AccountNo,
-			Branch:            row.Branch,
-			QRCodeImageURL:    resolvedQR,
-			QRCodeImageSource: resolvedSource,
-			IsActive:          row.IsActive,
-			IsDefaultReceive:  row.IsDefaultReceive,
-			IsDefaultRefund:   row.IsDefaultRefund,
-			CreatedAt:         row.CreatedAt,
-			UpdatedAt:         row.UpdatedAt,
-		})
+	data, err := s.toSystemBankAccountResponses(ctx, rows)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	page := &base.ResponsePaginate{Page: req.GetPage(), Size: req.GetSize(), Total: int64(total)}
@@ -186,34 +165,131 @@ func (s *Service) InfoService(ctx context.Context, id string) (*SystemBankAccoun
 		return nil, err
 	}
 
-	resolvedQR := strings.TrimSpace(row.QRCodeImageURL)
+	data, err := s.toSystemBankAccountResponses(ctx, []*systemBankAccountRow{row})
+	if err != nil {
+		return nil, err
+	}
+
+	span.AddEvent(`system_bank_accounts.svc.info.success`)
+	return data[0], nil
+}
+
+func (s *Service) toSystemBankAccountResponses(ctx context.Context, rows []*systemBankAccountRow) ([]*SystemBankAccountServiceResponse, error) {
+	paths := make([]string, 0, len(rows))
+	for _, row := range rows {
+		if path := strings.TrimSpace(row.QRCodeImageURL); path != "" {
+			paths = append(paths, path)
+		}
+	}
+	renditions, err := imageproc.LoadRenditions(ctx, s.bunDB.DB(), paths)
+	if err != nil {
+		return nil, err
+	}
+
+	resolve := strings.TrimSpace
 	if s.railwayStorage != nil {
-		resolvedQR = s.railwayStorage.ResolveObjectURL(resolvedQR)
+		resolve = s.railwayStorage.ResolveObjectURL
 	}
-	resolvedSource := strings.TrimSpace(row.QRCodeImageSource)
-	if resolvedSource == "" {
-		resolvedSource = systemBankQRCodeSourceFromPath(row.QRCodeImageURL)
+
+	data := make([]*SystemBankAccountServiceResponse, 0, len(rows))
+	for _, row := range rows {
+		qrPath := strings.TrimSpace(row.QRCodeImageURL)
+		var qrImage *imageproc.ImageSet
+		if qrPath != "" {
+			qrImage = imageproc.NewImageSet(qrPath, renditions[qrPath], resolve)
+		}
+		resolvedSource := strings.TrimSpace(row.QRCodeImageSource)
+		if resolvedSource == "" {
+			resolvedSource = systemBankQRCodeSourceFromPath(row.QRCodeImageURL)
+		}
+		data = append(data, &SystemBankAccountServiceResponse{
+			ID:                row.ID,
+			BankID:            row.BankID,
+			BankNameTh:        row.BankNameTh,
+			BankNameEn:        row.BankNameEn,
+			AccountName:       row.AccountName,
+			AccountNo:         row.AccountNo,
+			Branch:            row.Branch,
+			QRCodeImageURL:    resolve(qrPath),
+			QRCodeImageSource: resolvedSource,
+			QRCodeImage:       qrImage,
+			IsActive:          row.IsActive,
+			IsDefaultReceive:  row.IsDefaultReceive,
+			IsDefaultRefund:   row.IsDefaultRefund,
+			CreatedAt:         row.CreatedAt,
+			UpdatedAt:         row.UpdatedAt,
+		})
 	}
+	return data, nil
+}
 
-	span.AddEvent(`system_bank_accounts.svc.info.success`)
-	return &SystemBankAccountServiceResponse{
-		ID:                row.ID,
-		BankID:            row.BankID,
-		BankNameTh:        row.BankNameTh,
-		BankNameEn:        row.BankNameEn,
-		AccountName:       row.AccountName,
-		AccountNo:         row.AccountNo,
-		Branch:            row.Branch,
-		QRCodeImageURL:    resolvedQR,
-		QRCodeImageSource: resolvedSource,
-		IsActive:          row.IsActive,
-		IsDefaultReceive:  row.IsDefaultReceive,
-		IsDefaultRefund:   row.IsDefaultRefund,
-		CreatedAt:         row.CreatedAt,
-		UpdatedAt:         row.UpdatedAt,
+// storeQRCodeImage cleans a QR code sent as a data URL. With object storage
+// it is uploaded with its renditions and a storages row is returned to save;
+// without it the cleaned image stays inline. Other values are kept as is.
+func (s *Service) storeQRCodeImage(ctx context.Context, id uuid.UUID, qrCodeImageURL string) (string, *ent.StorageEntity, error) {
+	if !strings.HasPrefix(strings.ToLower(qrCodeImageURL), "data:") {
+		return qrCodeImageURL, nil, nil
+	}
+
+	if s.railwayStorage == nil || !s.railwayStorage.enabledPublic() {
+		data, _, err := decodeSystemBankBase64Image(qrCodeImageURL)
+		if err != nil {
+			return "", nil, err
+		}
+		if len(data) > maxSystemBankQRCodeFileSizeBytes {
+			return "", nil, errors.New("qr image exceeds 5 MB")
+		}
+		original, err := imageproc.ProcessOriginal(data)
+		if err != nil {
+			return "", nil, err
+		}
+		return original.DataURL(), nil, nil
+	}
+
+	uploaded, err := s.railwayStorage.UploadSystemBankQRCode(ctx, id, qrCodeImageURL)
+	if err != nil {
+		return "", nil, err
+	}
+	now := time.Now()
+	return uploaded.Path, &ent.StorageEntity{
+		ID:            uuid.New(),
+		RefID:         id,
+		FileName:      uploaded.FileName,
+		FilePath:      uploaded.Path,
+		FileSource:    "STORAGE",
+		FileSize:      uploaded.Size,
+		FileType:      uploaded.MIMEType,
+		IsActive:      true,
+		RelatedEntity: ent.RelatedEntityBankFile,
+		Renditions:    uploaded.Renditions,
+		CreatedAt:     now,
+		UpdatedAt:     now,
 	}, nil
 }
 
+// replaceQRCodeStorageInTx retires the storages rows of QR codes the account
+// no longer uses and saves the new one, if any.
+func replaceQRCodeStorageInTx(ctx context.Context, tx bun.Tx, id uuid.UUID, qrCodeImageURL string, storage *ent.StorageEntity) error {
+	now := time.Now()
+	if _, err := tx.NewUpdate().
+		Model((*ent.StorageEntity)(nil)).
+		Set("is_active = false").
+		Set("deleted_at = ?", now).
+		Set("updated_at = ?", now).
+		Where("ref_id = ?", id).
+		Where("related_entity = ?", ent.RelatedEntityBankFile).
+		Where("file_path <> ?", qrCodeImageURL).
+		Where("deleted_at IS NULL").
+		Exec(ctx); err != nil {
+		return err
+	}
+	if storage == nil {
+		return nil
+	}
+	_, err := tx.NewInsert().Model(storage).Exec(ctx)
+	return err
+}
+
 func (s *Service) CreateService(ctx context.Context, req *UpsertSystemBankAccountServiceRequest) error {
 	span, _ := utils.LogSpanFromContext(ctx)
 	span.AddEvent(`system_bank_accounts.svc.create.start`)
@@ -223,13 +299,9 @@ func (s *Service) CreateService(ctx context.Context, req *UpsertSystemBankAccoun
 	}
 
 	id := uuid.New()
-	qrCodeImageURL := strings.TrimSpace(req.QRCodeImageURL)
-	if strings.HasPrefix(strings.ToLower(qrCodeImageURL), "data:") && s.railwayStorage != nil && s.railwayStorage.enabledPublic() {
-		uploadedQR, uploadErr := s.railwayStorage.UploadSystemBankQRCode(ctx, id, qrCodeImageURL)
-		if uploadErr != nil {
-			return uploadErr
-		}
-		qrCodeImageURL = uploadedQR.Path
+	qrCodeImageURL, qrStorage, err := s.storeQRCodeImage(ctx, id, strings.TrimSpace(req.QRCodeImageURL))
+	if err != nil {
+		return err
 	}
 	now := time.Now()
 	item := &ent.SystemBankAccountEntity{
@@ -247,7 +319,7 @@ func (s *Service) CreateService(ctx context.Context, req *UpsertSystemBankAccoun
 		UpdatedAt:         now,
 	}
 
-	err := s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+	err = s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
 		if req.IsDefaultReceive {
 			if _, err := tx.NewUpdate().Model((*ent.SystemBankAccountEntity)(nil)).Set("is_default_receive = false").Where("1 = 1").Exec(ctx); err != nil {
 				return err
@@ -262,6 +334,9 @@ func (s *Service) CreateService(ctx context.Context, req *UpsertSystemBankAccoun
 		if _, err := tx.NewInsert().Model(item).Exec(ctx); err != nil {
 			return err
 		}
+		if err := replaceQRCodeStorageInTx(ctx, tx, id, qrCodeImageURL, qrStorage); err != nil {
+			return err
+		}
 
 		auditLog := &ent.AuditLogEntity{
 			ID:           uuid.New(),
@@ -302,13 +377,9 @@ func (s *Service) UpdateService(ctx context.Context, id string, req *UpsertSyste
 		return err
 	}
 
-	qrCodeImageURL := strings.TrimSpace(req.QRCodeImageURL)
-	if strings.HasPrefix(strings.ToLower(qrCodeImageURL), "data:") && s.railwayStorage != nil && s.railwayStorage.enabledPublic() {
-		uploadedQR, uploadErr := s.railwayStorage.UploadSystemBankQRCode(ctx, itemID, qrCodeImageURL)
-		if uploadErr != nil {
-			return uploadErr
-		}
-		qrCodeImageURL = uploadedQR.Path
+	qrCodeImageURL, qrStorage, err := s.storeQRCodeImage(ctx, itemID, strings.TrimSpace(req.QRCodeImageURL))
+	if err != nil {
+		return err
 	}
 
 	now := time.Now()
@@ -338,6 +409,9 @@ func (s *Service) UpdateService(ctx context.Context, id string, req *UpsertSyste
 		if _, err := tx.NewUpdate().Model(item).WherePK().Exec(ctx); err != nil {
 			return err
 		}
+		if err := replaceQRCodeStorageInTx(ctx, tx, itemID, qrCodeImageURL, qrStorage); err != nil {
+			return err
+		}
 
 		auditLog := &ent.AuditLogEntity{
 			ID:           uuid.New(),
@@ -378,7 +452,13 @@ func (s *Service) DeleteService(ctx context.Context, id string) error {
 		return errors.New("default account cannot be deleted")
 	}
 
-	if _, err := s.bunDB.DB().NewDelete().Model((*ent.SystemBankAccountEntity)(nil)).Where("id = ?", itemID).Exec(ctx); err != nil {
+	err = s.bunDB.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewDelete().Model((*ent.SystemBankAccountEntity)(nil)).Where("id = ?", itemID).Exec(ctx); err != nil {
+			return err
+		}
+		return replaceQRCodeStorageInTx(ctx, tx, itemID, "", nil)
+	})
+	if err != nil {
 		return normalizeDBError(err)
 	}
 
@@ -0,0 +1,416 @@
+package attributes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"phakram/app/modules/entities/ent"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/uptrace/bun"
+)
+
+const maxTextLength = 500
+
+var codePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// Value is a product's value for one attribute of its category schema,
+// ready to show: Value is a string, decimal or bool by data type, and enum
+// values carry their option labels.
+type Value struct {
+	AttributeID   uuid.UUID                 `json:"attribute_id"`
+	Code          string                    `json:"code"`
+	LabelTh       string                    `json:"label_th"`
+	LabelEn       string                    `json:"label_en"`
+	DataType      ent.AttributeDataTypeEnum `json:"data_type"`
+	Unit          *string                   `json:"unit"`
+	Value         any                       `json:"value"`
+	OptionLabelTh *string                   `json:"option_label_th,omitempty"`
+	OptionLabelEn *string                   `json:"option_label_en,omitempty"`
+}
+
+// Filter narrows a product listing to products with a matching attribute
+// value. Values match text, enum, boolean or exact number values; Min and
+// Max bound a number attribute.
+type Filter struct {
+	Code   string
+	Values []string
+	Min    *decimal.Decimal
+	Max    *decimal.Decimal
+}
+
+// NormalizeDefinition trims and checks an attribute definition before it is
+// saved. Options are only kept for enum attributes and a unit only for
+// number attributes.
+func NormalizeDefinition(def *ent.CategoryAttributeEntity) error {
+	def.Code = strings.ToLower(strings.TrimSpace(def.Code))
+	if !codePattern.MatchString(def.Code) {
+		return errors.New("invalid attribute code")
+	}
+	def.LabelTh = strings.TrimSpace(def.LabelTh)
+	def.LabelEn = strings.TrimSpace(def.LabelEn)
+	if def.LabelTh == "" || def.LabelEn == "" {
+		return errors.New("attribute label is required")
+	}
+
+	switch def.DataType {
+	case ent.AttributeText, ent.AttributeNumber, ent.AttributeEnum, ent.AttributeBoolean:
+	default:
+		return errors.New("invalid attribute data type")
+	}
+
+	if def.Unit != nil {
+		unit := strings.TrimSpace(*def.Unit)
+		def.Unit = nil
+		if unit != "" {
+			if def.DataType != ent.AttributeNumber {
+				return errors.New("attribute unit is only for number attributes")
+			}
+			def.Unit = &unit
+		}
+	}
+
+	if def.DataType != ent.AttributeEnum {
+		def.Options = []ent.CategoryAttributeOption{}
+		return nil
+	}
+	if len(def.Options) == 0 {
+		return errors.New("enum attribute needs options")
+	}
+	seen := make(map[string]struct{}, len(def.Options))
+	for i := range def.Options {
+		option := &def.Options[i]
+		option.Value = strings.TrimSpace(option.Value)
+		option.LabelTh = strings.TrimSpace(option.LabelTh)
+		option.LabelEn = strings.TrimSpace(option.LabelEn)
+		if option.Value == "" {
+			return errors.New("enum attribute needs options")
+		}
+		if option.LabelTh == "" {
+			option.LabelTh = option.Value
+		}
+		if option.LabelEn == "" {
+			option.LabelEn = option.Value
+		}
+		if _, ok := seen[option.Value]; ok {
+			return errors.New("duplicate attribute option")
+		}
+		seen[option.Value] = struct{}{}
+	}
+	return nil
+}
+
+// LoadSchema returns the attributes a product in the category must follow:
+// those of the category and all its ancestors, root first. When a category
+// redefines an inherited code, the nearest definition wins.
+func LoadSchema(ctx context.Context, db bun.IDB, categoryID uuid.UUID) ([]*ent.CategoryAttributeEntity, error) {
+	path := make([]uuid.UUID, 0)
+	if err := db.NewRaw(`
+		WITH RECURSIVE category_path AS (
+			SELECT c.id, c.parent_id, 0 AS depth
+			FROM categories AS c
+			WHERE c.id = ?
+			UNION ALL
+			SELECT c.id, c.parent_id, cp.depth + 1
+			FROM category_path AS cp
+			JOIN categories AS c ON c.id = cp.parent_id
+			WHERE cp.depth < 16
+		)
+		SELECT id FROM category_path ORDER BY depth DESC
+	`, categoryID).Scan(ctx, &path); err != nil {
+		return nil, err
+	}
+	schema := make([]*ent.CategoryAttributeEntity, 0)
+	if len(path) == 0 {
+		return schema, nil
+	}
+
+	defs := make([]*ent.CategoryAttributeEntity, 0)
+	if err := db.NewSelect().
+		Model(&defs).
+		Where("category_id IN (?)", bun.In(path)).
+		Order("sort_order ASC", "code ASC").
+		Scan(ctx); err != nil {
+		return nil, err
+	}
+
+	depth := make(map[uuid.UUID]int, len(path))
+	for i, id := range path {
+		depth[id] = i
+	}
+	sort.SliceStable(defs, func(i, j int) bool {
+		return depth[defs[i].CategoryID] < depth[defs[j].CategoryID]
+	})
+
+	byCode := make(map[string]int, len(defs))
+	for _, def := range defs {
+		if i, ok := byCode[def.Code]; ok {
+			schema[i] = def
+			continue
+		}
+		byCode[def.Code] = len(schema)
+		schema = append(schema, def)
+	}
+	return schema, nil
+}
+
+// Validate checks the submitted values, keyed by attribute code, against a
+// category schema and returns the rows to store for the product. A JSON null
+// counts as not given.
+func Validate(schema []*ent.CategoryAttributeEntity, productID uuid.UUID, values map[string]json.RawMessage) ([]*ent.ProductAttributeValueEntity, error) {
+	byCode := make(map[string]*ent.CategoryAttributeEntity, len(schema))
+	for _, def := range schema {
+		byCode[def.Code] = def
+	}
+	for code := range values {
+		if _, ok := byCode[code]; !ok {
+			return nil, errors.New("unknown product attribute")
+		}
+	}
+
+	rows := make([]*ent.ProductAttributeValueEntity, 0, len(values))
+	for _, def := range schema {
+		raw, ok := values[def.Code]
+		if !ok || string(raw) == "null" {
+			if def.IsRequired {
+				return nil, errors.New("missing required product attribute")
+			}
+			continue
+		}
+		row := &ent.ProductAttributeValueEntity{ProductID: productID, AttributeID: def.ID}
+		if err := parseValue(def, raw, row); err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func parseValue(def *ent.CategoryAttributeEntity, raw json.RawMessage, row *ent.ProductAttributeValueEntity) error {
+	invalid := errors.New("invalid product attribute value")
+	switch def.DataType {
+	case ent.AttributeText, ent.AttributeEnum:
+		var text string
+		if err := json.Unmarshal(raw, &text); err != nil {
+			return invalid
+		}
+		text = strings.TrimSpace(text)
+		if text == "" {
+			if def.IsRequired {
+				return errors.New("missing required product attribute")
+			}
+			return invalid
+		}
+		if utf8.RuneCountInString(text) > maxTextLength {
+			return invalid
+		}
+		if def.DataType == ent.AttributeEnum && findOption(def, text) == nil {
+			return invalid
+		}
+		row.ValueText = &text
+	case ent.AttributeNumber:
+		var number decimal.Decimal
+		if err := json.Unmarshal(raw, &number); err != nil {
+			return invalid
+		}
+		row.ValueNumber = &number
+	case ent.AttributeBoolean:
+		var flag bool
+		if err := json.Unmarshal(raw, &flag); err != nil {
+			return invalid
+		}
+		row.ValueBoolean = &flag
+	default:
+		return invalid
+	}
+	return nil
+}
+
+func findOption(def *ent.CategoryAttributeEntity, value string) *ent.CategoryAttributeOption {
+	for i := range def.Options {
+		if def.Options[i].Value == value {
+			return &def.Options[i]
+		}
+	}
+	return nil
+}
+
+// LoadValues returns a product's attribute values in schema order. Values of
+// attributes outside the category schema are left out.
+func LoadValues(ctx context.Context, db bun.IDB, productID uuid.UUID, categoryID uuid.UUID) ([]*Value, error) {
+	schema, err := LoadSchema(ctx, db, categoryID)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]*Value, 0, len(schema))
+	if len(schema) == 0 {
+		return values, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(schema))
+	for _, def := range schema {
+		ids = append(ids, def.ID)
+	}
+	rows := make([]*ent.ProductAttributeValueEntity, 0)
+	if err := db.NewSelect().
+		Model(&rows).
+		Where("product_id = ?", productID).
+		Where("attribute_id IN (?)", bun.In(ids)).
+		Scan(ctx); err != nil {
+		return nil, err
+	}
+	byAttribute := make(map[uuid.UUID]*ent.ProductAttributeValueEntity, len(rows))
+	for _, row := range rows {
+		byAttribute[row.AttributeID] = row
+	}
+
+	for _, def := range schema {
+		row, ok := byAttribute[def.ID]
+		if !ok {
+			continue
+		}
+		value := &Value{
+			AttributeID: def.ID,
+			Code:        def.Code,
+			LabelTh:     def.LabelTh,
+			LabelEn:     def.LabelEn,
+			DataType:    def.DataType,
+			Unit:        def.Unit,
+		}
+		switch {
+		case row.ValueText != nil:
+			value.Value = *row.ValueText
+			if option := findOption(def, *row.ValueText); option != nil {
+				value.OptionLabelTh = &option.LabelTh
+				value.OptionLabelEn = &option.LabelEn
+			}
+		case row.ValueNumber != nil:
+			value.Value = *row.ValueNumber
+		case row.ValueBoolean != nil:
+			value.Value = *row.ValueBoolean
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// ParseFilters reads listing filters keyed by attribute code. A value is a
+// comma separated list to match, or a "min..max" number range where either
+// end may be left open.
+func ParseFilters(query map[string]string) ([]*Filter, error) {
+	codes := make([]string, 0, len(query))
+	for code := range query {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	filters := make([]*Filter, 0, len(codes))
+	for _, code := range codes {
+		raw := strings.TrimSpace(query[code])
+		code = strings.ToLower(strings.TrimSpace(code))
+		if !codePattern.MatchString(code) || raw == "" {
+			return nil, errors.New("invalid attribute filter")
+		}
+		filter := &Filter{Code: code}
+
+		if from, to, ok := strings.Cut(raw, ".."); ok {
+			bounds := []*decimal.Decimal{nil, nil}
+			for i, part := range []string{from, to} {
+				part = strings.TrimSpace(part)
+				if part == "" {
+					continue
+				}
+				bound, err := decimal.NewFromString(part)
+				if err != nil {
+					return nil, errors.New("invalid attribute filter")
+				}
+				bounds[i] = &bound
+			}
+			if bounds[0] == nil && bounds[1] == nil {
+				return nil, errors.New("invalid attribute filter")
+			}
+			filter.Min, filter.Max = bounds[0], bounds[1]
+			filters = append(filters, filter)
+			continue
+		}
+
+		for _, value := range strings.Split(raw, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				filter.Values = append(filter.Values, value)
+			}
+		}
+		if len(filter.Values) == 0 {
+			return nil, errors.New("invalid attribute filter")
+		}
+		filters = append(filters, filter)
+	}
+	return filters, nil
+}
+
+// ApplyFilters restricts a product select query to products matching every
+// filter. Only filterable attributes are considered.
+func ApplyFilters(q *bun.SelectQuery, filters []*Filter) *bun.SelectQuery {
+	for _, filter := range filters {
+		sub := q.NewSelect().
+			TableExpr("product_attribute_values AS pav").
+			Join("JOIN category_attributes AS ca ON ca.id = pav.attribute_id").
+			Column("pav.product_id").
+			Where("ca.code = ?", filter.Code).
+			Where("ca.is_filterable = TRUE")
+
+		if len(filter.Values) > 0 {
+			values := filter.Values
+			sub = sub.WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+				q = q.Where("pav.value_text IN (?)", bun.In(values))
+				if flags, ok := parseBools(values); ok {
+					q = q.WhereOr("pav.value_boolean IN (?)", bun.In(flags))
+				}
+				if numbers, ok := parseNumbers(values); ok {
+					q = q.WhereOr("pav.value_number IN (?)", bun.In(numbers))
+				}
+				return q
+			})
+		}
+		if filter.Min != nil {
+			sub = sub.Where("pav.value_number >= ?", *filter.Min)
+		}
+		if filter.Max != nil {
+			sub = sub.Where("pav.value_number <= ?", *filter.Max)
+		}
+		q = q.Where("?TableAlias.id IN (?)", sub)
+	}
+	return q
+}
+
+func parseBools(values []string) ([]bool, bool) {
+	flags := make([]bool, 0, len(values))
+	for _, value := range values {
+		switch strings.ToLower(value) {
+		case "true":
+			flags = append(flags, true)
+		case "false":
+			flags = append(flags, false)
+		default:
+			return nil, false
+		}
+	}
+	return flags, true
+}
+
+func parseNumbers(values []string) ([]decimal.Decimal, bool) {
+	numbers := make([]decimal.Decimal, 0, len(values))
+	for _, value := range values {
+		number, err := decimal.NewFromString(value)
+		if err != nil {
+			return nil, false
+		}
+		numbers = append(numbers, number)
+	}
+	return numbers, true
+}
@@ -90,6 +90,387 @@ var errorMappings = map[string]ResponseFunction{
 	"payment appeal is allowed only after rejection": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
 		return ValidateFailed(ctx, "อุทธรณ์ได้เฉพาะรายการที่ถูกปฏิเสธการชำระเงินแล้ว", nil, params...)
 	},
+	"invalid payment method": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "วิธีการชำระเงินไม่ถูกต้อง", nil, params...)
+	},
+	"member is not eligible for cash on delivery": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ไม่สามารถเลือกชำระเงินปลายทางสำหรับคำสั่งซื้อนี้ได้", nil, params...)
+	},
+	"cash on delivery order cannot use existing payment": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "คำสั่งซื้อเก็บเงินปลายทางไม่สามารถอ้างอิงรายการชำระเงินเดิมได้", nil, params...)
+	},
+	"cash on delivery order does not require payment confirmation": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "คำสั่งซื้อเก็บเงินปลายทางไม่ต้องแนบหลักฐานการชำระเงิน", nil, params...)
+	},
+	"cash on delivery payment not collected": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ยังไม่ได้บันทึกการรับเงินปลายทาง", nil, params...)
+	},
+	"cash on delivery already collected": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "บันทึกการรับเงินปลายทางแล้ว", nil, params...)
+	},
+	"cash on delivery can be collected only before completion": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "บันทึกรับเงินปลายทางได้เฉพาะคำสั่งซื้อที่รอจัดส่งหรือกำลังจัดส่ง", nil, params...)
+	},
+	"collected amount is less than order net amount": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ยอดเงินที่เก็บได้น้อยกว่ายอดสุทธิของคำสั่งซื้อ", nil, params...)
+	},
+	"order is not cash on delivery": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "คำสั่งซื้อนี้ไม่ได้ชำระเงินปลายทาง", nil, params...)
+	},
+	"cod refusal reason is required": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "กรุณาระบุเหตุผลที่ลูกค้าปฏิเสธรับพัสดุ", nil, params...)
+	},
+	"cod refusal is allowed only while shipping": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "บันทึกการปฏิเสธรับพัสดุได้เฉพาะคำสั่งซื้อที่กำลังจัดส่ง", nil, params...)
+	},
+	"invalid cod fee type": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ประเภทค่าธรรมเนียมเก็บเงินปลายทางไม่ถูกต้อง", nil, params...)
+	},
+	"invalid cod setting amount": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "จำนวนเงินในการตั้งค่าเก็บเงินปลายทางไม่ถูกต้อง", nil, params...)
+	},
+	"payment slip matches another order": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "สลิปนี้ซ้ำกับสลิปของคำสั่งซื้ออื่น กรุณาตรวจสอบก่อนอนุมัติ", nil, params...)
+	},
+	"invalid document type": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ประเภทเอกสารไม่ถูกต้อง", nil, params...)
+	},
+	"document is available only for paid orders": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ออกเอกสารได้เฉพาะคำสั่งซื้อที่ชำระเงินแล้ว", nil, params...)
+	},
+	"buyer name is required": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "กรุณาระบุชื่อผู้ซื้อ", nil, params...)
+	},
+	"invalid buyer tax id": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "เลขประจำตัวผู้เสียภาษีของผู้ซื้อไม่ถูกต้อง", nil, params...)
+	},
+	"buyer address is required": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "กรุณาระบุที่อยู่ผู้ซื้อ", nil, params...)
+	},
+	"invalid buyer branch": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "รหัสสาขาของผู้ซื้อไม่ถูกต้อง", nil, params...)
+	},
+	"document not found": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ไม่พบเอกสาร", nil, params...)
+	},
+	"document font is not configured": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ยังไม่ได้ตั้งค่าฟอนต์สำหรับออกเอกสาร", nil, params...)
+	},
+	"invalid tax class": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ประเภทภาษีของสินค้าไม่ถูกต้อง", nil, params...)
+	},
+	"invalid report month": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "รูปแบบเดือนของรายงานไม่ถูกต้อง (YYYY-MM)", nil, params...)
+	},
+	"invalid payout file format": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "รูปแบบไฟล์โอนเงินไม่ถูกต้อง", nil, params...)
+	},
+	"no payouts ready for batch": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ไม่มีรายการคืนเงินที่พร้อมโอน", nil, params...)
+	},
+	"payout batch not found": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ไม่พบชุดรายการโอนเงิน", nil, params...)
+	},
+	"payout batch is not draft": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ชุดรายการโอนเงินไม่อยู่ในสถานะรออนุมัติ", nil, params...)
+	},
+	"payout batch is not approved": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ชุดรายการโอนเงินยังไม่ได้รับการอนุมัติ", nil, params...)
+	},
+	"payout batch is not exported": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ชุดรายการโอนเงินยังไม่ได้ส่งออกไฟล์", nil, params...)
+	},
+	"payout batch cannot be cancelled": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ไม่สามารถยกเลิกชุดรายการโอนเงินนี้ได้", nil, params...)
+	},
+	"refund payout not found": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ไม่พบรายการโอนเงินคืน", nil, params...)
+	},
+	"refund payout is already paid": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "รายการนี้โอนเงินคืนแล้ว", nil, params...)
+	},
+	"payout failure reason is required": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "กรุณาระบุสาเหตุที่โอนเงินไม่สำเร็จ", nil, params...)
+	},
+	"invalid promotion target": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "สินค้าหรือหมวดหมู่ของโปรโมชั่นไม่ถูกต้อง", nil, params...)
+	},
+	"promotion requires order items": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "โปรโมชั่นนี้ใช้ได้กับสินค้าบางรายการ กรุณาระบุสินค้าในคำสั่งซื้อ", nil, params...)
+	},
+	"no items eligible for promotion": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ไม่มีสินค้าในคำสั่งซื้อที่ร่วมรายการโปรโมชั่นนี้", nil, params...)
+	},
+	"cart does not have enough items for promotion": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "จำนวนสินค้าที่ร่วมรายการยังไม่ครบตามเงื่อนไขโปรโมชั่น", nil, params...)
+	},
+	"cart does not contain the full bundle": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "สินค้าในคำสั่งซื้อยังไม่ครบชุดตามโปรโมชั่น", nil, params...)
+	},
+	"invalid discount type": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ประเภทส่วนลดไม่ถูกต้อง", nil, params...)
+	},
+	"buy and get quantities must be greater than 0": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "กรุณาระบุจำนวนที่ต้องซื้อและจำนวนที่ได้รับให้มากกว่า 0", nil, params...)
+	},
+	"get discount percent must be between 0 and 100": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "เปอร์เซ็นต์ส่วนลดของสินค้าที่ได้รับต้องอยู่ระหว่าง 0 ถึง 100", nil, params...)
+	},
+	"bundle requires at least two items": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ชุดสินค้าต้องมีสินค้าอย่างน้อย 2 ชิ้น", nil, params...)
+	},
+	"bundle price must be greater than 0": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ราคาชุดสินค้าต้องมากกว่า 0", nil, params...)
+	},
+	"promotion code has already been used": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "โค้ดโปรโมชั่นนี้ถูกใช้งานแล้ว", nil, params...)
+	},
+	"promotion code already exists": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "โค้ดโปรโมชั่นนี้มีอยู่แล้ว", nil, params...)
+	},
+	"invalid promotion code quantity": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "จำนวนโค้ดต้องอยู่ระหว่าง 1 ถึง 50,000", nil, params...)
+	},
+	"invalid promotion code length": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ความยาวโค้ดต้องอยู่ระหว่าง 6 ถึง 32 ตัวอักษร", nil, params...)
+	},
+	"invalid promotion code prefix": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "คำนำหน้าโค้ดต้องเป็น A-Z, 0-9 หรือ - และยาวไม่เกิน 20 ตัวอักษร", nil, params...)
+	},
+	"invalid promotion code alphabet": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ชุดตัวอักษรของโค้ดไม่ถูกต้อง", nil, params...)
+	},
+	"invalid promotion code status": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "สถานะโค้ดไม่ถูกต้อง", nil, params...)
+	},
+	"promotion code space is exhausted": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ไม่สามารถสร้างโค้ดที่ไม่ซ้ำได้ กรุณาเพิ่มความยาวโค้ด", nil, params...)
+	},
+	"invalid maximum order discount": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "เพดานส่วนลดต่อคำสั่งซื้อไม่ถูกต้อง", nil, params...)
+	},
+	"promotion is not available for this member": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "โปรโมชั่นนี้ไม่ได้จัดให้สำหรับบัญชีของคุณ", nil, params...)
+	},
+	"segment days must be greater than 0": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "จำนวนวันของกลุ่มเป้าหมายต้องมากกว่า 0", nil, params...)
+	},
+	"invalid member list file": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ไฟล์รายชื่อสมาชิกไม่ถูกต้อง", nil, params...)
+	},
+	"member list is too large": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "รายชื่อสมาชิกมีจำนวนมากเกินไป", nil, params...)
+	},
+	"flash sale has ended": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "แฟลชเซลนี้สิ้นสุดแล้ว กรุณาตรวจสอบราคาสินค้าอีกครั้ง", nil, params...)
+	},
+	"flash sale is sold out": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "สินค้าราคาแฟลชเซลหมดแล้ว", nil, params...)
+	},
+	"flash sale purchase limit reached": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "คุณซื้อสินค้าราคาแฟลชเซลครบตามจำนวนที่กำหนดแล้ว", nil, params...)
+	},
+	"flash sale item not found": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ไม่พบสินค้าในแฟลชเซล", nil, params...)
+	},
+	"flash sale requires at least one product": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "กรุณาเลือกสินค้าที่ร่วมแฟลชเซลอย่างน้อย 1 รายการ", nil, params...)
+	},
+	"duplicate flash sale product": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "มีสินค้าซ้ำกันในแฟลชเซล", nil, params...)
+	},
+	"invalid flash sale price": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ราคาแฟลชเซลไม่ถูกต้อง", nil, params...)
+	},
+	"flash sale quantity must be greater than 0": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "จำนวนสินค้าแฟลชเซลต้องมากกว่า 0", nil, params...)
+	},
+	"product is already in another flash sale": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "สินค้านี้อยู่ในแฟลชเซลอื่นที่ช่วงเวลาทับซ้อนกัน", nil, params...)
+	},
+	"flash sale quantity is below sold quantity": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "จำนวนสินค้าแฟลชเซลต้องไม่น้อยกว่าจำนวนที่ขายไปแล้ว", nil, params...)
+	},
+	"flash sale item already has purchases": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ไม่สามารถนำสินค้าที่มีผู้ซื้อแล้วออกจากแฟลชเซลได้", nil, params...)
+	},
+	"flash sale already has purchases": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "แฟลชเซลนี้มีผู้ซื้อแล้ว กรุณาปิดการใช้งานแทนการลบ", nil, params...)
+	},
+	"invalid flash sale status": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "สถานะแฟลชเซลไม่ถูกต้อง", nil, params...)
+	},
+	"invalid report period": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ช่วงวันที่ของรายงานไม่ถูกต้อง", nil, params...)
+	},
+	"price list quantity must be at least 1": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "จำนวนขั้นต่ำของราคาต้องไม่น้อยกว่า 1", nil, params...)
+	},
+	"invalid price list price": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ราคาในรายการราคาไม่ถูกต้อง", nil, params...)
+	},
+	"duplicate price list quantity": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "มีจำนวนขั้นต่ำซ้ำกันในรายการราคาเดียวกัน", nil, params...)
+	},
+	"tier not found": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ไม่พบระดับสมาชิก", nil, params...)
+	},
+	"product not found": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ไม่พบสินค้า", nil, params...)
+	},
+	"unsupported image type": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ไฟล์รูปภาพไม่รองรับ กรุณาใช้ไฟล์ JPEG, PNG หรือ WebP", nil, params...)
+	},
+	"invalid image": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ไฟล์รูปภาพไม่ถูกต้องหรือเสียหาย", nil, params...)
+	},
+	"image dimensions are too large": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "รูปภาพมีขนาดความละเอียดใหญ่เกินไป", nil, params...)
+	},
+	"image is empty": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ไฟล์รูปภาพว่างเปล่า", nil, params...)
+	},
+	"image variant key is too long": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "รหัสตัวเลือกสินค้าของรูปภาพยาวเกินไป", nil, params...)
+	},
+	"image order must list every product image once": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ลำดับรูปภาพต้องระบุรูปภาพของสินค้าทุกรูปเพียงครั้งเดียว", nil, params...)
+	},
+	"product image not found": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ไม่พบรูปภาพสินค้า", nil, params...)
+	},
+	"unsupported import file type": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "รองรับเฉพาะไฟล์ CSV หรือ XLSX", nil, params...)
+	},
+	"invalid import file": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ไฟล์นำเข้าไม่ถูกต้อง", nil, params...)
+	},
+	"import file is too large": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ไฟล์นำเข้ามีขนาดใหญ่เกินไป", nil, params...)
+	},
+	"import file has no header row": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ไฟล์นำเข้าไม่มีแถวหัวตาราง", nil, params...)
+	},
+	"import file must have a product_no column": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ไฟล์นำเข้าต้องมีคอลัมน์ product_no", nil, params...)
+	},
+	"import file has too many rows": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ไฟล์นำเข้ามีจำนวนแถวมากเกินไป", nil, params...)
+	},
+	"unsupported export format": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "รองรับการส่งออกเฉพาะ CSV หรือ XLSX", nil, params...)
+	},
+	"invalid product status": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "สถานะสินค้าไม่ถูกต้อง", nil, params...)
+	},
+	"scheduled product needs a future publish_at": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "สินค้าที่ตั้งเวลาเผยแพร่ต้องมีเวลาเผยแพร่ในอนาคต", nil, params...)
+	},
+	"unpublish_at must be after publish_at": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "เวลาหยุดเผยแพร่ต้องอยู่หลังเวลาเผยแพร่", nil, params...)
+	},
+	"invalid datetime format": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "รูปแบบวันเวลาไม่ถูกต้อง", nil, params...)
+	},
+	"invalid price": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ราคาไม่ถูกต้อง", nil, params...)
+	},
+	"price schedule must be in the future": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "เวลาเปลี่ยนราคาต้องอยู่ในอนาคต", nil, params...)
+	},
+	"duplicate price schedule": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "มีการตั้งเวลาเปลี่ยนราคาในเวลานี้แล้ว", nil, params...)
+	},
+	"price schedule not found": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ไม่พบรายการตั้งเวลาเปลี่ยนราคา", nil, params...)
+	},
+	"price timeline start must be before its end": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "วันเริ่มต้นต้องอยู่ก่อนวันสิ้นสุด", nil, params...)
+	},
+	"too many products for recommendations": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "จำนวนสินค้าสำหรับการแนะนำมากเกินไป", nil, params...)
+	},
+	"invalid attribute code": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "รหัสคุณสมบัติไม่ถูกต้อง", nil, params...)
+	},
+	"attribute label is required": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "กรุณาระบุชื่อคุณสมบัติ", nil, params...)
+	},
+	"invalid attribute data type": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ประเภทข้อมูลคุณสมบัติไม่ถูกต้อง", nil, params...)
+	},
+	"attribute unit is only for number attributes": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "กำหนดหน่วยได้เฉพาะคุณสมบัติประเภทตัวเลข", nil, params...)
+	},
+	"enum attribute needs options": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "คุณสมบัติแบบตัวเลือกต้องมีตัวเลือก", nil, params...)
+	},
+	"duplicate attribute option": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ตัวเลือกคุณสมบัติซ้ำกัน", nil, params...)
+	},
+	"attribute code already exists": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "รหัสคุณสมบัตินี้มีอยู่แล้ว", nil, params...)
+	},
+	"attribute option is in use": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ตัวเลือกคุณสมบัตินี้ถูกใช้งานโดยสินค้าอยู่", nil, params...)
+	},
+	"unknown product attribute": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ไม่พบคุณสมบัติสินค้าในหมวดหมู่นี้", nil, params...)
+	},
+	"missing required product attribute": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "กรุณาระบุคุณสมบัติสินค้าที่จำเป็น", nil, params...)
+	},
+	"invalid product attribute value": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ค่าคุณสมบัติสินค้าไม่ถูกต้อง", nil, params...)
+	},
+	"invalid attribute filter": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ตัวกรองคุณสมบัติไม่ถูกต้อง", nil, params...)
+	},
+	"invalid reorder point": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "จุดสั่งซื้อไม่ถูกต้อง", nil, params...)
+	},
+	"invalid target stock": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ระดับสต็อกเป้าหมายไม่ถูกต้อง", nil, params...)
+	},
+	"target stock must be above reorder point": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ระดับสต็อกเป้าหมายต้องมากกว่าจุดสั่งซื้อ", nil, params...)
+	},
+	"insufficient stock at location": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "สต็อกในคลังที่เลือกไม่เพียงพอ", nil, params...)
+	},
+	"invalid stock movement": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ข้อมูลการเคลื่อนไหวสต็อกไม่ถูกต้อง", nil, params...)
+	},
+	"stock location not found": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ไม่พบคลังสินค้า", nil, params...)
+	},
+	"invalid stock location code": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "รหัสคลังสินค้าไม่ถูกต้อง", nil, params...)
+	},
+	"stock location name is required": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "กรุณาระบุชื่อคลังสินค้า", nil, params...)
+	},
+	"invalid stock location priority": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ลำดับความสำคัญของคลังสินค้าไม่ถูกต้อง", nil, params...)
+	},
+	"default stock location must be active": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "คลังสินค้าหลักต้องเปิดใช้งาน", nil, params...)
+	},
+	"default stock location is required": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ต้องมีคลังสินค้าหลักหนึ่งแห่ง", nil, params...)
+	},
+	"stock location code already exists": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "รหัสคลังสินค้าซ้ำ", nil, params...)
+	},
+	"stock location has stock": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "คลังสินค้านี้ยังมีสต็อกอยู่ กรุณาโอนสต็อกออกก่อน", nil, params...)
+	},
+	"province not found": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "ไม่พบจังหวัด", nil, params...)
+	},
+	"payment slip has not been verified": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
+		return ValidateFailed(ctx, "สลิปนี้ยังไม่ได้ตรวจสอบความซ้ำซ้อน กรุณาตรวจสอบสลิปก่อนอนุมัติ", nil, params...)
+	},
 	"payment is in use": func(ctx *gin.Context, _ string, _ any, params ...map[string]string) error {
 		return ValidateFailed(ctx, "ไม่สามารถลบได้ เนื่องจาก payment ถูกอ้างอิงอยู่", nil, params...)
 	},
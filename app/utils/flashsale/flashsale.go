@@ -0,0 +1,265 @@
+package flashsale
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/uptrace/bun"
+)
+
+var (
+	ErrSaleEnded    = errors.New("flash sale has ended")
+	ErrSoldOut      = errors.New("flash sale is sold out")
+	ErrMemberLimit  = errors.New("flash sale purchase limit reached")
+	ErrItemNotFound = errors.New("flash sale item not found")
+)
+
+// Offer is the running sale of one product as shown to shoppers.
+type Offer struct {
+	FlashSaleID     uuid.UUID       `json:"flash_sale_id"`
+	FlashSaleItemID uuid.UUID       `json:"flash_sale_item_id"`
+	Name            string          `json:"name"`
+	SalePrice       decimal.Decimal `json:"sale_price"`
+	QuantityCap     int             `json:"quantity_cap"`
+	Remaining       int             `json:"remaining"`
+	PerMemberLimit  *int            `json:"per_member_limit"`
+	EndsAt          time.Time       `json:"ends_at"`
+}
+
+// Reservation asks for quantity units of a flash sale item for one order.
+type Reservation struct {
+	ItemID   uuid.UUID
+	Quantity int
+}
+
+type offerRow struct {
+	FlashSaleID     uuid.UUID       `bun:"flash_sale_id"`
+	FlashSaleItemID uuid.UUID       `bun:"flash_sale_item_id"`
+	ProductID       uuid.UUID       `bun:"product_id"`
+	Name            string          `bun:"name"`
+	SalePrice       decimal.Decimal `bun:"sale_price"`
+	QuantityCap     int             `bun:"quantity_cap"`
+	SoldQuantity    int             `bun:"sold_quantity"`
+	PerMemberLimit  *int            `bun:"per_member_limit"`
+	EndsAt          time.Time       `bun:"ends_at"`
+}
+
+type itemLockRow struct {
+	ID             uuid.UUID `bun:"id"`
+	QuantityCap    int       `bun:"quantity_cap"`
+	SoldQuantity   int       `bun:"sold_quantity"`
+	PerMemberLimit *int      `bun:"per_member_limit"`
+	IsRunning      bool      `bun:"is_running"`
+}
+
+type purchaseInsert struct {
+	bun.BaseModel `bun:"table:flash_sale_purchases"`
+
+	ID              uuid.UUID `bun:"id,pk,type:uuid"`
+	FlashSaleItemID uuid.UUID `bun:"flash_sale_item_id,type:uuid"`
+	MemberID        uuid.UUID `bun:"member_id,type:uuid"`
+	OrderID         uuid.UUID `bun:"order_id,type:uuid"`
+	Quantity        int       `bun:"quantity"`
+	CreatedAt       time.Time `bun:"created_at"`
+}
+
+// LoadActive returns the running sale of each product that still has units
+// left. A product is in at most one running sale, but the cheapest wins if
+// schedules ever overlap.
+func LoadActive(ctx context.Context, db bun.IDB, productIDs []uuid.UUID) (map[uuid.UUID]*Offer, error) {
+	offers := make(map[uuid.UUID]*Offer, len(productIDs))
+	if len(productIDs) == 0 {
+		return offers, nil
+	}
+
+	now := time.Now().UTC()
+	rows := make([]*offerRow, 0)
+	if err := db.NewSelect().
+		TableExpr("flash_sale_items AS fsi").
+		Join("JOIN flash_sales AS fs ON fs.id = fsi.flash_sale_id").
+		ColumnExpr("fs.id AS flash_sale_id").
+		ColumnExpr("fsi.id AS flash_sale_item_id").
+		ColumnExpr("fsi.product_id").
+		ColumnExpr("fs.name").
+		ColumnExpr("fsi.sale_price").
+		ColumnExpr("fsi.quantity_cap").
+		ColumnExpr("fsi.sold_quantity").
+		ColumnExpr("fsi.per_member_limit").
+		ColumnExpr("fs.ends_at").
+		Where("fsi.product_id IN (?)", bun.In(productIDs)).
+		Where("fs.is_active = true").
+		Where("fs.starts_at <= ?", now).
+		Where("fs.ends_at > ?", now).
+		Where("fsi.sold_quantity < fsi.quantity_cap").
+		OrderExpr("fsi.sale_price DESC").
+		Scan(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		offers[row.ProductID] = &Offer{
+			FlashSaleID:     row.FlashSaleID,
+			FlashSaleItemID: row.FlashSaleItemID,
+			Name:            row.Name,
+			SalePrice:       row.SalePrice,
+			QuantityCap:     row.QuantityCap,
+			Remaining:       row.QuantityCap - row.SoldQuantity,
+			PerMemberLimit:  row.PerMemberLimit,
+			EndsAt:          row.EndsAt,
+		}
+	}
+	return offers, nil
+}
+
+// LowestPrice returns the lowest sale price the product was offered at by a
+// sale running at any point in (from, to], or nil when there was none. Sales
+// that sold out still count; sales that have not started yet do not.
+func LowestPrice(ctx context.Context, db bun.IDB, productID uuid.UUID, from time.Time, to time.Time) (*decimal.Decimal, error) {
+	var lowest decimal.NullDecimal
+	if err := db.NewSelect().
+		TableExpr("flash_sale_items AS fsi").
+		Join("JOIN flash_sales AS fs ON fs.id = fsi.flash_sale_id").
+		ColumnExpr("MIN(fsi.sale_price)").
+		Where("fsi.product_id = ?", productID).
+		Where("fs.is_active = true").
+		Where("fs.starts_at < ?", to).
+		Where("fs.starts_at <= ?", time.Now().UTC()).
+		Where("fs.ends_at > ?", from).
+		Scan(ctx, &lowest); err != nil {
+		return nil, err
+	}
+	if !lowest.Valid {
+		return nil, nil
+	}
+	return &lowest.Decimal, nil
+}
+
+// ReserveInTx takes the requested units out of each sale item for an order.
+// Item rows are locked in a stable order so concurrent checkouts queue up
+// instead of overselling the cap or a member's limit.
+func ReserveInTx(ctx context.Context, tx bun.Tx, memberID uuid.UUID, orderID uuid.UUID, reservations []Reservation) error {
+	quantities := make(map[uuid.UUID]int, len(reservations))
+	itemIDs := make([]uuid.UUID, 0, len(reservations))
+	for _, reservation := range reservations {
+		if reservation.Quantity <= 0 {
+			continue
+		}
+		if _, ok := quantities[reservation.ItemID]; !ok {
+			itemIDs = append(itemIDs, reservation.ItemID)
+		}
+		quantities[reservation.ItemID] += reservation.Quantity
+	}
+	if len(itemIDs) == 0 {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	items := make([]*itemLockRow, 0, len(itemIDs))
+	if err := tx.NewSelect().
+		TableExpr("flash_sale_items AS fsi").
+		Join("JOIN flash_sales AS fs ON fs.id = fsi.flash_sale_id").
+		ColumnExpr("fsi.id").
+		ColumnExpr("fsi.quantity_cap").
+		ColumnExpr("fsi.sold_quantity").
+		ColumnExpr("fsi.per_member_limit").
+		ColumnExpr("(fs.is_active AND fs.starts_at <= ? AND fs.ends_at > ?) AS is_running", now, now).
+		Where("fsi.id IN (?)", bun.In(itemIDs)).
+		OrderExpr("fsi.id ASC").
+		For("UPDATE OF fsi").
+		Scan(ctx, &items); err != nil {
+		return err
+	}
+	if len(items) != len(itemIDs) {
+		return ErrItemNotFound
+	}
+
+	for _, item := range items {
+		quantity := quantities[item.ID]
+		if !item.IsRunning {
+			return ErrSaleEnded
+		}
+		if item.SoldQuantity+quantity > item.QuantityCap {
+			return ErrSoldOut
+		}
+		if item.PerMemberLimit != nil {
+			bought, err := purchasedQuantity(ctx, tx, item.ID, memberID)
+			if err != nil {
+				return err
+			}
+			if bought+quantity > *item.PerMemberLimit {
+				return ErrMemberLimit
+			}
+		}
+
+		if _, err := tx.NewUpdate().
+			TableExpr("flash_sale_items").
+			Set("sold_quantity = sold_quantity + ?", quantity).
+			Set("updated_at = ?", now).
+			Where("id = ?", item.ID).
+			Exec(ctx); err != nil {
+			return err
+		}
+		if _, err := tx.NewInsert().Model(&purchaseInsert{
+			ID:              uuid.New(),
+			FlashSaleItemID: item.ID,
+			MemberID:        memberID,
+			OrderID:         orderID,
+			Quantity:        quantity,
+			CreatedAt:       now,
+		}).Exec(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func purchasedQuantity(ctx context.Context, tx bun.Tx, itemID uuid.UUID, memberID uuid.UUID) (int, error) {
+	var total sql.NullInt64
+	if err := tx.NewSelect().
+		TableExpr("flash_sale_purchases").
+		ColumnExpr("SUM(quantity)").
+		Where("flash_sale_item_id = ?", itemID).
+		Where("member_id = ?", memberID).
+		Where("released_at IS NULL").
+		Scan(ctx, &total); err != nil {
+		return 0, err
+	}
+	return int(total.Int64), nil
+}
+
+// ReleaseOrderInTx gives the units reserved by a cancelled order back to
+// their sales. Calling it again for the same order does nothing.
+func ReleaseOrderInTx(ctx context.Context, tx bun.Tx, orderID uuid.UUID) error {
+	type releasedRow struct {
+		FlashSaleItemID uuid.UUID `bun:"flash_sale_item_id"`
+		Quantity        int       `bun:"quantity"`
+	}
+
+	now := time.Now().UTC()
+	released := make([]*releasedRow, 0)
+	if _, err := tx.NewUpdate().
+		TableExpr("flash_sale_purchases").
+		Set("released_at = ?", now).
+		Where("order_id = ?", orderID).
+		Where("released_at IS NULL").
+		Returning("flash_sale_item_id, quantity").
+		Exec(ctx, &released); err != nil {
+		return err
+	}
+
+	for _, row := range released {
+		if _, err := tx.NewUpdate().
+			TableExpr("flash_sale_items").
+			Set("sold_quantity = GREATEST(sold_quantity - ?, 0)", row.Quantity).
+			Set("updated_at = ?", now).
+			Where("id = ?", row.FlashSaleItemID).
+			Exec(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
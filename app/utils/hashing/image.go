@@ -0,0 +1,111 @@
+package hashing
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"math/bits"
+	"strconv"
+
+	_ "golang.org/x/image/webp"
+)
+
+// Image hash functions
+
+// ImageSHA256 returns the hex encoded sha256 of the raw file bytes, used to
+// catch byte-for-byte identical uploads.
+func ImageSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// ImageDifferenceHash returns a 64-bit difference hash (dHash) as 16 hex chars.
+// Re-encoded, resized or recompressed copies of the same picture produce
+// hashes within a small Hamming distance of each other.
+func ImageDifferenceHash(data []byte) (string, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+
+	const width, height = 9, 8
+	bounds := img.Bounds()
+	if bounds.Dx() < 1 || bounds.Dy() < 1 {
+		return "", fmt.Errorf("image is empty")
+	}
+
+	var gray [height][width]float64
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*bounds.Dy()/height
+		y1 := bounds.Min.Y + (y+1)*bounds.Dy()/height
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*bounds.Dx()/width
+			x1 := bounds.Min.X + (x+1)*bounds.Dx()/width
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+			gray[y][x] = averageLuminance(img, x0, y0, x1, y1)
+		}
+	}
+
+	var hash uint64
+	for y := 0; y < height; y++ {
+		for x := 0; x < width-1; x++ {
+			hash <<= 1
+			if gray[y][x] > gray[y][x+1] {
+				hash |= 1
+			}
+		}
+	}
+
+	return fmt.Sprintf("%016x", hash), nil
+}
+
+// ImageHashDistance returns the Hamming distance between two hex encoded
+// difference hashes.
+func ImageHashDistance(a string, b string) (int, error) {
+	left, err := strconv.ParseUint(a, 16, 64)
+	if err != nil {
+		return 0, err
+	}
+	right, err := strconv.ParseUint(b, 16, 64)
+	if err != nil {
+		return 0, err
+	}
+	return bits.OnesCount64(left ^ right), nil
+}
+
+func averageLuminance(img image.Image, x0 int, y0 int, x1 int, y1 int) float64 {
+	// Sample at most 16x16 pixels per cell to keep large photos cheap.
+	stepX := (x1 - x0 + 15) / 16
+	stepY := (y1 - y0 + 15) / 16
+	if stepX < 1 {
+		stepX = 1
+	}
+	if stepY < 1 {
+		stepY = 1
+	}
+
+	var total float64
+	var count int
+	for y := y0; y < y1; y += stepY {
+		for x := x0; x < x1; x += stepX {
+			r, g, b, _ := img.At(x, y).RGBA()
+			total += 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+			count++
+		}
+	}
+	if count == 0 {
+		return 0
+	}
+
+	return total / float64(count)
+}
@@ -0,0 +1,225 @@
+// Package imageproc turns uploaded images into clean, web-ready files. The
+// content is decoded to prove it is a real image, turned upright according
+// to its EXIF orientation and re-encoded, which drops EXIF, GPS and any other
+// metadata. Each upload also gets thumbnail, medium and large renditions in
+// WebP and JPEG.
+package imageproc
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+
+	"github.com/HugoSmits86/nativewebp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// MaxPixels caps the decoded size of an upload so a small, highly compressed
+// file cannot expand into gigabytes of memory.
+const MaxPixels = 25_000_000
+
+const (
+	originalJPEGQuality  = 90
+	renditionJPEGQuality = 82
+)
+
+// Size is one rendition size; images are scaled to fit inside a
+// MaxSide x MaxSide box and never upscaled.
+type Size struct {
+	Name    string
+	MaxSide int
+}
+
+var Sizes = []Size{
+	{Name: "thumbnail", MaxSide: 200},
+	{Name: "medium", MaxSide: 600},
+	{Name: "large", MaxSide: 1200},
+}
+
+// File is one encoded image ready to be stored.
+type File struct {
+	MIMEType string
+	Ext      string
+	Width    int
+	Height   int
+	Data     []byte
+}
+
+// Variant is one rendition size in both formats.
+type Variant struct {
+	Size string
+	WebP File
+	JPEG File
+}
+
+// Processed is the result of Process: the cleaned original and its
+// renditions in the order of Sizes.
+type Processed struct {
+	Original File
+	Variants []Variant
+}
+
+// Process validates and cleans an uploaded image. JPEG, PNG and WebP are
+// accepted, judged by their content rather than the declared MIME type.
+// Originals are re-encoded as JPEG, or as PNG when the upload is a PNG or
+// has transparency.
+func Process(data []byte) (*Processed, error) {
+	img, lossless, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	original, err := encodeOriginal(img, lossless)
+	if err != nil {
+		return nil, err
+	}
+
+	processed := &Processed{Original: original, Variants: make([]Variant, 0, len(Sizes))}
+	for _, size := range Sizes {
+		scaled := resize(img, size.MaxSide)
+		webpFile, err := encodeWebP(scaled)
+		if err != nil {
+			return nil, err
+		}
+		jpegFile, err := encodeJPEG(flatten(scaled), renditionJPEGQuality)
+		if err != nil {
+			return nil, err
+		}
+		processed.Variants = append(processed.Variants, Variant{Size: size.Name, WebP: webpFile, JPEG: jpegFile})
+	}
+	return processed, nil
+}
+
+// ProcessOriginal validates and cleans an upload like Process but skips the
+// renditions, for callers that keep the image inline.
+func ProcessOriginal(data []byte) (*File, error) {
+	img, lossless, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	original, err := encodeOriginal(img, lossless)
+	if err != nil {
+		return nil, err
+	}
+	return &original, nil
+}
+
+// decode reads an upload into an upright image and reports whether the
+// original should be kept lossless.
+func decode(data []byte) (image.Image, bool, error) {
+	if len(data) == 0 {
+		return nil, false, errors.New("image is empty")
+	}
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, false, errors.New("unsupported image type")
+	}
+	switch format {
+	case "jpeg", "png", "webp":
+	default:
+		return nil, false, errors.New("unsupported image type")
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return nil, false, errors.New("invalid image")
+	}
+	if config.Width*config.Height > MaxPixels {
+		return nil, false, errors.New("image dimensions are too large")
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, false, errors.New("invalid image")
+	}
+	if format == "jpeg" {
+		img = orient(img, jpegOrientation(data))
+	}
+	return img, format == "png" || !isOpaque(img), nil
+}
+
+// DataURL renders the file as a base64 data URL.
+func (f *File) DataURL() string {
+	return fmt.Sprintf("data:%s;base64,%s", f.MIMEType, base64.StdEncoding.EncodeToString(f.Data))
+}
+
+func isOpaque(img image.Image) bool {
+	if opaque, ok := img.(interface{ Opaque() bool }); ok {
+		return opaque.Opaque()
+	}
+	return false
+}
+
+func encodeOriginal(img image.Image, lossless bool) (File, error) {
+	if !lossless {
+		return encodeJPEG(flatten(img), originalJPEGQuality)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return File{}, err
+	}
+	bounds := img.Bounds()
+	return File{MIMEType: "image/png", Ext: ".png", Width: bounds.Dx(), Height: bounds.Dy(), Data: buf.Bytes()}, nil
+}
+
+func encodeJPEG(img image.Image, quality int) (File, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return File{}, err
+	}
+	bounds := img.Bounds()
+	return File{MIMEType: "image/jpeg", Ext: ".jpg", Width: bounds.Dx(), Height: bounds.Dy(), Data: buf.Bytes()}, nil
+}
+
+func encodeWebP(img image.Image) (File, error) {
+	var buf bytes.Buffer
+	if err := nativewebp.Encode(&buf, img, nil); err != nil {
+		return File{}, err
+	}
+	bounds := img.Bounds()
+	return File{MIMEType: "image/webp", Ext: ".webp", Width: bounds.Dx(), Height: bounds.Dy(), Data: buf.Bytes()}, nil
+}
+
+// resize scales img to fit inside a maxSide square, keeping the aspect
+// ratio. Smaller images are copied at their own size.
+func resize(img image.Image, maxSide int) *image.NRGBA {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > maxSide || height > maxSide {
+		if width >= height {
+			height = max(1, height*maxSide/width)
+			width = maxSide
+		} else {
+			width = max(1, width*maxSide/height)
+			height = maxSide
+		}
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	if width == bounds.Dx() && height == bounds.Dy() {
+		draw.Draw(dst, dst.Bounds(), img, bounds.Min, draw.Src)
+		return dst
+	}
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+	return dst
+}
+
+// flatten paints img over white so transparent areas do not turn black in
+// formats without alpha.
+func flatten(img image.Image) image.Image {
+	if isOpaque(img) {
+		return img
+	}
+	bounds := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, bounds.Min, draw.Over)
+	return dst
+}
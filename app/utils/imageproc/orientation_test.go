@@ -0,0 +1,166 @@
+package imageproc
+
+import (
+	"encoding/binary"
+	"image"
+	"image/color"
+	"testing"
+)
+
+type tiffEntry struct {
+	tag   uint16
+	value uint16
+}
+
+// buildTIFF lays out a TIFF header followed by a single IFD at offset 8.
+func buildTIFF(order binary.ByteOrder, entries ...tiffEntry) []byte {
+	tiff := make([]byte, 8+2+len(entries)*12+4)
+	if order == binary.LittleEndian {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	order.PutUint16(tiff[2:4], 42)
+	order.PutUint32(tiff[4:8], 8)
+
+	order.PutUint16(tiff[8:10], uint16(len(entries)))
+	for i, entry := range entries {
+		field := tiff[10+i*12 : 22+i*12]
+		order.PutUint16(field[0:2], entry.tag)
+		order.PutUint16(field[2:4], 3) // SHORT
+		order.PutUint32(field[4:8], 1)
+		order.PutUint16(field[8:10], entry.value)
+	}
+	return tiff
+}
+
+func segment(marker byte, payload []byte) []byte {
+	out := []byte{0xFF, marker, 0, 0}
+	binary.BigEndian.PutUint16(out[2:4], uint16(len(payload)+2))
+	return append(out, payload...)
+}
+
+func exifSegment(tiff []byte) []byte {
+	return segment(0xE1, append([]byte("Exif\x00\x00"), tiff...))
+}
+
+func buildJPEG(segments ...[]byte) []byte {
+	data := []byte{0xFF, 0xD8}
+	for _, s := range segments {
+		data = append(data, s...)
+	}
+	return append(data, 0xFF, 0xD9)
+}
+
+func Test_tiffOrientation(t *testing.T) {
+	truncated := buildTIFF(binary.LittleEndian, tiffEntry{exifOrientationTag, 6})
+	badOffset := buildTIFF(binary.BigEndian, tiffEntry{exifOrientationTag, 6})
+	binary.BigEndian.PutUint32(badOffset[4:8], 4096)
+
+	tests := []struct {
+		name string
+		tiff []byte
+		want int
+	}{
+		{"little endian", buildTIFF(binary.LittleEndian, tiffEntry{exifOrientationTag, 6}), 6},
+		{"big endian", buildTIFF(binary.BigEndian, tiffEntry{exifOrientationTag, 3}), 3},
+		{"after other tags", buildTIFF(binary.LittleEndian, tiffEntry{0x010F, 1}, tiffEntry{0x0110, 2}, tiffEntry{exifOrientationTag, 8}), 8},
+		{"no orientation tag", buildTIFF(binary.LittleEndian, tiffEntry{0x010F, 6}), 1},
+		{"no entries", buildTIFF(binary.BigEndian), 1},
+		{"orientation zero", buildTIFF(binary.LittleEndian, tiffEntry{exifOrientationTag, 0}), 1},
+		{"orientation out of range", buildTIFF(binary.LittleEndian, tiffEntry{exifOrientationTag, 9}), 1},
+		{"unknown byte order", append([]byte("XX"), truncated[2:]...), 1},
+		{"too short", []byte("II*\x00"), 1},
+		{"ifd outside the data", badOffset, 1},
+		{"entry cut off", truncated[:8+2+6], 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tiffOrientation(tt.tiff); got != tt.want {
+				t.Errorf("tiffOrientation() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_jpegOrientation(t *testing.T) {
+	rotated := exifSegment(buildTIFF(binary.BigEndian, tiffEntry{exifOrientationTag, 6}))
+	jfif := segment(0xE0, []byte("JFIF\x00\x01\x02\x00\x00\x01\x00\x01\x00\x00"))
+	xmp := segment(0xE1, []byte("http://ns.adobe.com/xap/1.0/\x00<x/>"))
+	scan := segment(0xDA, []byte{0x01, 0x01, 0x00, 0x00, 0x3F, 0x00})
+
+	overlong := buildJPEG(jfif)
+	binary.BigEndian.PutUint16(overlong[4:6], 0x7FFF)
+
+	tests := []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{"exif right after soi", buildJPEG(rotated), 6},
+		{"exif after jfif", buildJPEG(jfif, rotated), 6},
+		{"xmp app1 is skipped", buildJPEG(jfif, xmp, rotated), 6},
+		{"no exif", buildJPEG(jfif), 1},
+		{"exif after start of scan is ignored", buildJPEG(jfif, scan, rotated), 1},
+		{"not a jpeg", []byte("\x89PNG\r\n\x1a\n"), 1},
+		{"empty", nil, 1},
+		{"segment longer than the data", overlong, 1},
+		{"garbage between segments", append(buildJPEG(jfif)[:len(buildJPEG(jfif))-2], 0x00, 0x00, 0xFF, 0xE1), 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := jpegOrientation(tt.data); got != tt.want {
+				t.Errorf("jpegOrientation() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_orient(t *testing.T) {
+	// The source is 3x2:
+	//   a b c
+	//   d e f
+	src := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	labels := [][]uint8{{'a', 'b', 'c'}, {'d', 'e', 'f'}}
+	for y, row := range labels {
+		for x, label := range row {
+			src.SetNRGBA(x, y, color.NRGBA{R: label, A: 255})
+		}
+	}
+
+	tests := []struct {
+		name        string
+		orientation int
+		want        []string
+	}{
+		{"upright", 1, []string{"abc", "def"}},
+		{"mirrored", 2, []string{"cba", "fed"}},
+		{"rotated 180", 3, []string{"fed", "cba"}},
+		{"flipped", 4, []string{"def", "abc"}},
+		{"transposed", 5, []string{"ad", "be", "cf"}},
+		{"rotated 90 clockwise", 6, []string{"da", "eb", "fc"}},
+		{"transversed", 7, []string{"fc", "eb", "da"}},
+		{"rotated 90 counter-clockwise", 8, []string{"cf", "be", "ad"}},
+		{"unknown orientation", 9, []string{"abc", "def"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := orient(src, tt.orientation)
+			bounds := got.Bounds()
+			if bounds.Dy() != len(tt.want) || bounds.Dx() != len(tt.want[0]) {
+				t.Fatalf("orient() size = %dx%d, want %dx%d", bounds.Dx(), bounds.Dy(), len(tt.want[0]), len(tt.want))
+			}
+			for y, row := range tt.want {
+				for x := range row {
+					r, _, _, _ := got.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+					if label := byte(r >> 8); label != row[x] {
+						t.Errorf("orient() pixel (%d,%d) = %c, want %c", x, y, label, row[x])
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package imageproc
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"phakram/app/modules/entities/ent"
+
+	"github.com/uptrace/bun"
+)
+
+// Rendition and Renditions live on the storages entity; the aliases keep
+// callers of this package from importing ent for them.
+type (
+	Rendition  = ent.Rendition
+	Renditions = ent.Renditions
+)
+
+// ObjectStore is the part of the object storage client uploads need.
+type ObjectStore interface {
+	PutObject(ctx context.Context, bucket string, objectPath string, contentType string, payload []byte) error
+}
+
+// Uploaded is a processed image after it was written to the bucket.
+type Uploaded struct {
+	Path       string
+	MIMEType   string
+	Ext        string
+	Size       int64
+	Renditions Renditions
+}
+
+// Upload writes the original to objectBase plus its extension and every
+// rendition next to it as objectBase-<size>.webp and .jpg.
+func (p *Processed) Upload(ctx context.Context, store ObjectStore, bucket string, objectBase string) (*Uploaded, error) {
+	originalPath := objectBase + p.Original.Ext
+	if err := store.PutObject(ctx, bucket, originalPath, p.Original.MIMEType, p.Original.Data); err != nil {
+		return nil, err
+	}
+
+	renditions := make(Renditions, len(p.Variants))
+	for _, variant := range p.Variants {
+		webpPath := fmt.Sprintf("%s-%s%s", objectBase, variant.Size, variant.WebP.Ext)
+		if err := store.PutObject(ctx, bucket, webpPath, variant.WebP.MIMEType, variant.WebP.Data); err != nil {
+			return nil, err
+		}
+		jpegPath := fmt.Sprintf("%s-%s%s", objectBase, variant.Size, variant.JPEG.Ext)
+		if err := store.PutObject(ctx, bucket, jpegPath, variant.JPEG.MIMEType, variant.JPEG.Data); err != nil {
+			return nil, err
+		}
+		renditions[variant.Size] = Rendition{
+			Width:  variant.JPEG.Width,
+			Height: variant.JPEG.Height,
+			WebP:   bucket + "/" + webpPath,
+			JPEG:   bucket + "/" + jpegPath,
+		}
+	}
+
+	return &Uploaded{
+		Path:       bucket + "/" + originalPath,
+		MIMEType:   p.Original.MIMEType,
+		Ext:        p.Original.Ext,
+		Size:       int64(len(p.Original.Data)),
+		Renditions: renditions,
+	}, nil
+}
+
+// Source is one resolved rendition in an ImageSet.
+type Source struct {
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	WebP   string `json:"webp"`
+	JPEG   string `json:"jpeg"`
+}
+
+// ImageSet is the responsive form of a stored image. Src is the cleaned
+// original; SrcSet and WebPSrcSet are ready for <img srcset> and
+// <source type="image/webp" srcset>. Images stored before renditions
+// existed only have Src.
+type ImageSet struct {
+	Src        string            `json:"src"`
+	SrcSet     string            `json:"srcset,omitempty"`
+	WebPSrcSet string            `json:"webp_srcset,omitempty"`
+	Sizes      map[string]Source `json:"sizes,omitempty"`
+}
+
+// NewImageSet resolves the stored paths of an image with resolve, which
+// turns a "bucket/object" path into a URL.
+func NewImageSet(original string, renditions Renditions, resolve func(string) string) *ImageSet {
+	if resolve == nil {
+		resolve = strings.TrimSpace
+	}
+	set := &ImageSet{Src: resolve(original)}
+	if len(renditions) == 0 {
+		return set
+	}
+
+	set.Sizes = make(map[string]Source, len(renditions))
+	srcSet := make([]string, 0, len(renditions))
+	webpSrcSet := make([]string, 0, len(renditions))
+	for _, size := range Sizes {
+		rendition, ok := renditions[size.Name]
+		if !ok {
+			continue
+		}
+		source := Source{
+			Width:  rendition.Width,
+			Height: rendition.Height,
+			WebP:   resolve(rendition.WebP),
+			JPEG:   resolve(rendition.JPEG),
+		}
+		set.Sizes[size.Name] = source
+		if source.JPEG != "" {
+			srcSet = append(srcSet, fmt.Sprintf("%s %dw", source.JPEG, source.Width))
+		}
+		if source.WebP != "" {
+			webpSrcSet = append(webpSrcSet, fmt.Sprintf("%s %dw", source.WebP, source.Width))
+		}
+	}
+	set.SrcSet = strings.Join(srcSet, ", ")
+	set.WebPSrcSet = strings.Join(webpSrcSet, ", ")
+	return set
+}
+
+// LoadRenditions reads the renditions saved on storages for stored image
+// paths. Paths without a storages row, such as external URLs, get no entry.
+func LoadRenditions(ctx context.Context, db bun.IDB, paths []string) (map[string]Renditions, error) {
+	result := make(map[string]Renditions, len(paths))
+	if len(paths) == 0 {
+		return result, nil
+	}
+
+	rows := make([]struct {
+		FilePath   string     `bun:"file_path"`
+		Renditions Renditions `bun:"renditions,type:jsonb"`
+	}, 0)
+	if err := db.NewSelect().
+		TableExpr("storages").
+		Column("file_path", "renditions").
+		Where("md5(file_path) IN (?)", bun.In(filePathHashes(paths))).
+		Where("renditions IS NOT NULL").
+		Where("deleted_at IS NULL").
+		Scan(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		result[row.FilePath] = row.Renditions
+	}
+	return result, nil
+}
+
+// filePathHashes matches the storages_file_path_md5_idx expression index.
+// file_path can hold whole data: URLs, too large for a plain index.
+func filePathHashes(paths []string) []string {
+	hashes := make([]string, 0, len(paths))
+	for _, path := range paths {
+		sum := md5.Sum([]byte(path))
+		hashes = append(hashes, hex.EncodeToString(sum[:]))
+	}
+	return hashes
+}
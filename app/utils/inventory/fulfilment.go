@@ -0,0 +1,317 @@
+package inventory
+
+import (
+	"context"
+	"fmt"
+
+	"phakram/app/modules/entities/ent"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// Allocation is the quantity of a product an order takes from one location.
+type Allocation struct {
+	LocationID   uuid.UUID `json:"location_id"`
+	LocationCode string    `json:"location_code"`
+	ProductID    uuid.UUID `json:"product_id"`
+	Quantity     int       `json:"quantity"`
+}
+
+// Shortage is the quantity of a product no location can supply.
+type Shortage struct {
+	ProductID uuid.UUID `json:"product_id"`
+	Quantity  int       `json:"quantity"`
+}
+
+// Plan says where an order's items are picked from. An order ships from a
+// single location when one can supply every item, preferring locations in
+// the customer's province and then lower priority; otherwise each item is
+// split across locations in the same order.
+type Plan struct {
+	OrderID     uuid.UUID     `json:"order_id"`
+	ProvinceID  *uuid.UUID    `json:"province_id"`
+	Fulfillable bool          `json:"fulfillable"`
+	Split       bool          `json:"split"`
+	Allocations []*Allocation `json:"allocations"`
+	Shortages   []*Shortage   `json:"shortages"`
+}
+
+type orderLine struct {
+	ProductID uuid.UUID `bun:"product_id"`
+	Quantity  int       `bun:"quantity"`
+}
+
+// pickedStock is how much of a product an order took from a location.
+type pickedStock struct {
+	ProductID  uuid.UUID `bun:"product_id"`
+	LocationID uuid.UUID `bun:"location_id"`
+	Quantity   int       `bun:"quantity"`
+}
+
+type planLocation struct {
+	ID           uuid.UUID `bun:"id"`
+	Code         string    `bun:"code"`
+	SameProvince bool      `bun:"same_province"`
+}
+
+// PlanOrder works out where an order would be picked from now. It writes
+// nothing.
+func PlanOrder(ctx context.Context, db bun.IDB, orderID uuid.UUID) (*Plan, error) {
+	lines, err := loadOrderLines(ctx, db, orderID)
+	if err != nil {
+		return nil, err
+	}
+	return planOrder(ctx, db, orderID, lines)
+}
+
+// FulfilOrderInTx takes an order's items out of the locations picked by
+// PlanOrder, recording a fulfil movement per location and product.
+func FulfilOrderInTx(ctx context.Context, tx bun.Tx, orderID uuid.UUID) (*Plan, error) {
+	lines, err := loadOrderLines(ctx, tx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := lockStocks(ctx, tx, lineProductIDs(lines)); err != nil {
+		return nil, err
+	}
+
+	plan, err := planOrder(ctx, tx, orderID, lines)
+	if err != nil {
+		return nil, err
+	}
+	if !plan.Fulfillable {
+		return nil, fmt.Errorf("insufficient stock for product %s", plan.Shortages[0].ProductID.String())
+	}
+
+	for _, allocation := range plan.Allocations {
+		if err := applyMovement(ctx, tx, &Movement{
+			ProductID:      allocation.ProductID,
+			FromLocationID: &allocation.LocationID,
+			Quantity:       allocation.Quantity,
+			Kind:           ent.StockMovementFulfil,
+			OrderID:        &orderID,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	for _, line := range lines {
+		if err := syncStock(ctx, tx, line.ProductID, 0, nil); err != nil {
+			return nil, err
+		}
+	}
+	return plan, nil
+}
+
+// ReturnOrderInTx puts an order's items back where they were picked from.
+// Items of orders shipped before locations existed go to the default
+// location, and products without stock are skipped.
+func ReturnOrderInTx(ctx context.Context, tx bun.Tx, orderID uuid.UUID, createdBy *uuid.UUID) error {
+	lines, err := loadOrderLines(ctx, tx, orderID)
+	if err != nil {
+		return err
+	}
+	stocked, err := lockStocks(ctx, tx, lineProductIDs(lines))
+	if err != nil {
+		return err
+	}
+
+	picked := make([]*pickedStock, 0)
+	if err := tx.NewSelect().
+		TableExpr("stock_movements").
+		ColumnExpr("product_id, from_location_id AS location_id").
+		ColumnExpr("SUM(quantity) AS quantity").
+		Where("order_id = ?", orderID).
+		Where("kind = ?", ent.StockMovementFulfil).
+		Where("from_location_id IS NOT NULL").
+		GroupExpr("product_id, from_location_id").
+		OrderExpr("product_id ASC, from_location_id ASC").
+		Scan(ctx, &picked); err != nil {
+		return err
+	}
+
+	var defaultID *uuid.UUID
+	for _, line := range lines {
+		if !stocked[line.ProductID] {
+			continue
+		}
+
+		toReturn := line.Quantity
+		for _, row := range picked {
+			if row.ProductID != line.ProductID || toReturn == 0 {
+				continue
+			}
+			quantity := min(row.Quantity, toReturn)
+			if err := applyMovement(ctx, tx, &Movement{
+				ProductID:    line.ProductID,
+				ToLocationID: &row.LocationID,
+				Quantity:     quantity,
+				Kind:         ent.StockMovementReturn,
+				OrderID:      &orderID,
+				CreatedBy:    createdBy,
+			}); err != nil {
+				return err
+			}
+			toReturn -= quantity
+		}
+		if toReturn > 0 {
+			if defaultID == nil {
+				id, err := DefaultLocationID(ctx, tx)
+				if err != nil {
+					return err
+				}
+				defaultID = &id
+			}
+			if err := applyMovement(ctx, tx, &Movement{
+				ProductID:    line.ProductID,
+				ToLocationID: defaultID,
+				Quantity:     toReturn,
+				Kind:         ent.StockMovementReturn,
+				OrderID:      &orderID,
+				CreatedBy:    createdBy,
+			}); err != nil {
+				return err
+			}
+		}
+
+		if err := syncStock(ctx, tx, line.ProductID, 0, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func loadOrderLines(ctx context.Context, db bun.IDB, orderID uuid.UUID) ([]*orderLine, error) {
+	lines := make([]*orderLine, 0)
+	if err := db.NewSelect().
+		TableExpr("order_items").
+		ColumnExpr("product_id").
+		ColumnExpr("SUM(quantity) AS quantity").
+		Where("order_id = ?", orderID).
+		GroupExpr("product_id").
+		OrderExpr("product_id ASC").
+		Scan(ctx, &lines); err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+func lineProductIDs(lines []*orderLine) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(lines))
+	for _, line := range lines {
+		ids = append(ids, line.ProductID)
+	}
+	return ids
+}
+
+func planOrder(ctx context.Context, db bun.IDB, orderID uuid.UUID, lines []*orderLine) (*Plan, error) {
+	var province uuid.NullUUID
+	if err := db.NewSelect().
+		TableExpr("orders AS o").
+		ColumnExpr("ma.province_id").
+		Join("LEFT JOIN member_addresses AS ma ON ma.id = o.address_id").
+		Where("o.id = ?", orderID).
+		Limit(1).
+		Scan(ctx, &province); err != nil {
+		return nil, err
+	}
+	var provinceID *uuid.UUID
+	if province.Valid {
+		provinceID = &province.UUID
+	}
+
+	locations := make([]*planLocation, 0)
+	if err := db.NewSelect().
+		TableExpr("stock_locations AS l").
+		ColumnExpr("l.id, l.code").
+		ColumnExpr("COALESCE(l.province_id = ?, false) AS same_province", provinceID).
+		Where("l.is_active").
+		Where("l.deleted_at IS NULL").
+		OrderExpr("same_province DESC, l.priority ASC, l.code ASC").
+		Scan(ctx, &locations); err != nil {
+		return nil, err
+	}
+
+	available := make(map[uuid.UUID]map[uuid.UUID]int, len(locations))
+	if len(lines) > 0 && len(locations) > 0 {
+		held := make([]*ent.LocationStockEntity, 0)
+		if err := db.NewSelect().
+			Model(&held).
+			Where("product_id IN (?)", bun.In(lineProductIDs(lines))).
+			Where("quantity > 0").
+			Scan(ctx); err != nil {
+			return nil, err
+		}
+		for _, stock := range held {
+			if available[stock.LocationID] == nil {
+				available[stock.LocationID] = make(map[uuid.UUID]int)
+			}
+			available[stock.LocationID][stock.ProductID] = stock.Quantity
+		}
+	}
+
+	plan := allocate(lines, locations, available)
+	plan.OrderID = orderID
+	plan.ProvinceID = provinceID
+	return plan, nil
+}
+
+// allocate picks the first location that can supply every line, or splits
+// each line across locations in order when none can.
+func allocate(lines []*orderLine, locations []*planLocation, available map[uuid.UUID]map[uuid.UUID]int) *Plan {
+	plan := &Plan{
+		Fulfillable: true,
+		Allocations: make([]*Allocation, 0, len(lines)),
+		Shortages:   make([]*Shortage, 0),
+	}
+
+	for _, location := range locations {
+		covers := true
+		for _, line := range lines {
+			if available[location.ID][line.ProductID] < line.Quantity {
+				covers = false
+				break
+			}
+		}
+		if !covers {
+			continue
+		}
+		for _, line := range lines {
+			plan.Allocations = append(plan.Allocations, &Allocation{
+				LocationID:   location.ID,
+				LocationCode: location.Code,
+				ProductID:    line.ProductID,
+				Quantity:     line.Quantity,
+			})
+		}
+		return plan
+	}
+
+	used := make(map[uuid.UUID]bool)
+	for _, line := range lines {
+		needed := line.Quantity
+		for _, location := range locations {
+			if needed == 0 {
+				break
+			}
+			quantity := min(available[location.ID][line.ProductID], needed)
+			if quantity <= 0 {
+				continue
+			}
+			plan.Allocations = append(plan.Allocations, &Allocation{
+				LocationID:   location.ID,
+				LocationCode: location.Code,
+				ProductID:    line.ProductID,
+				Quantity:     quantity,
+			})
+			used[location.ID] = true
+			needed -= quantity
+		}
+		if needed > 0 {
+			plan.Fulfillable = false
+			plan.Shortages = append(plan.Shortages, &Shortage{ProductID: line.ProductID, Quantity: needed})
+		}
+	}
+	plan.Split = len(used) > 1
+	return plan
+}
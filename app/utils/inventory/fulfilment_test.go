@@ -0,0 +1,162 @@
+package inventory
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+var (
+	productA = uuid.MustParse("00000000-0000-0000-0000-00000000000a")
+	productB = uuid.MustParse("00000000-0000-0000-0000-00000000000b")
+
+	nearby   = &planLocation{ID: uuid.MustParse("00000000-0000-0000-0000-000000000001"), Code: "BKK", SameProvince: true}
+	central  = &planLocation{ID: uuid.MustParse("00000000-0000-0000-0000-000000000002"), Code: "MAIN"}
+	overflow = &planLocation{ID: uuid.MustParse("00000000-0000-0000-0000-000000000003"), Code: "OVERFLOW"}
+)
+
+type stockAt map[*planLocation]map[uuid.UUID]int
+
+func (s stockAt) available() map[uuid.UUID]map[uuid.UUID]int {
+	available := make(map[uuid.UUID]map[uuid.UUID]int, len(s))
+	for location, quantities := range s {
+		available[location.ID] = quantities
+	}
+	return available
+}
+
+func formatAllocations(allocations []*Allocation) []string {
+	out := make([]string, 0, len(allocations))
+	for _, allocation := range allocations {
+		out = append(out, fmt.Sprintf("%s:%s:%d", allocation.LocationCode, allocation.ProductID.String()[35:], allocation.Quantity))
+	}
+	return out
+}
+
+func formatShortages(shortages []*Shortage) []string {
+	out := make([]string, 0, len(shortages))
+	for _, shortage := range shortages {
+		out = append(out, fmt.Sprintf("%s:%d", shortage.ProductID.String()[35:], shortage.Quantity))
+	}
+	return out
+}
+
+func equalStrings(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func Test_allocate(t *testing.T) {
+	tests := []struct {
+		name            string
+		lines           []*orderLine
+		locations       []*planLocation
+		stock           stockAt
+		wantFulfillable bool
+		wantSplit       bool
+		wantAllocations []string
+		wantShortages   []string
+	}{
+		{
+			name:            "first location that covers everything",
+			lines:           []*orderLine{{ProductID: productA, Quantity: 2}, {ProductID: productB, Quantity: 1}},
+			locations:       []*planLocation{nearby, central},
+			stock:           stockAt{nearby: {productA: 5, productB: 5}, central: {productA: 5, productB: 5}},
+			wantFulfillable: true,
+			wantAllocations: []string{"BKK:a:2", "BKK:b:1"},
+			wantShortages:   []string{},
+		},
+		{
+			name:            "a later location that covers everything beats splitting",
+			lines:           []*orderLine{{ProductID: productA, Quantity: 2}, {ProductID: productB, Quantity: 1}},
+			locations:       []*planLocation{nearby, central},
+			stock:           stockAt{nearby: {productA: 5}, central: {productA: 2, productB: 1}},
+			wantFulfillable: true,
+			wantAllocations: []string{"MAIN:a:2", "MAIN:b:1"},
+			wantShortages:   []string{},
+		},
+		{
+			name:            "split across locations in order",
+			lines:           []*orderLine{{ProductID: productA, Quantity: 4}, {ProductID: productB, Quantity: 1}},
+			locations:       []*planLocation{nearby, central, overflow},
+			stock:           stockAt{nearby: {productA: 2}, central: {productA: 3, productB: 1}, overflow: {productA: 10}},
+			wantFulfillable: true,
+			wantSplit:       true,
+			wantAllocations: []string{"BKK:a:2", "MAIN:a:2", "MAIN:b:1"},
+			wantShortages:   []string{},
+		},
+		{
+			name:            "locations without the product are skipped when splitting",
+			lines:           []*orderLine{{ProductID: productA, Quantity: 3}, {ProductID: productB, Quantity: 2}},
+			locations:       []*planLocation{nearby, central, overflow},
+			stock:           stockAt{nearby: {productB: 2}, overflow: {productA: 3}},
+			wantFulfillable: true,
+			wantSplit:       true,
+			wantAllocations: []string{"OVERFLOW:a:3", "BKK:b:2"},
+			wantShortages:   []string{},
+		},
+		{
+			name:            "shortage takes what there is",
+			lines:           []*orderLine{{ProductID: productA, Quantity: 3}},
+			locations:       []*planLocation{nearby, central},
+			stock:           stockAt{central: {productA: 1}},
+			wantFulfillable: false,
+			wantAllocations: []string{"MAIN:a:1"},
+			wantShortages:   []string{"a:2"},
+		},
+		{
+			name:            "shortage of one line only",
+			lines:           []*orderLine{{ProductID: productA, Quantity: 1}, {ProductID: productB, Quantity: 4}},
+			locations:       []*planLocation{nearby, central},
+			stock:           stockAt{nearby: {productA: 1, productB: 1}, central: {productB: 2}},
+			wantFulfillable: false,
+			wantSplit:       true,
+			wantAllocations: []string{"BKK:a:1", "BKK:b:1", "MAIN:b:2"},
+			wantShortages:   []string{"b:1"},
+		},
+		{
+			name:            "no locations",
+			lines:           []*orderLine{{ProductID: productA, Quantity: 1}},
+			locations:       []*planLocation{},
+			stock:           stockAt{},
+			wantFulfillable: false,
+			wantAllocations: []string{},
+			wantShortages:   []string{"a:1"},
+		},
+		{
+			name:            "no lines",
+			lines:           []*orderLine{},
+			locations:       []*planLocation{nearby},
+			stock:           stockAt{},
+			wantFulfillable: true,
+			wantAllocations: []string{},
+			wantShortages:   []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := allocate(tt.lines, tt.locations, tt.stock.available())
+			if got.Fulfillable != tt.wantFulfillable {
+				t.Errorf("allocate() fulfillable = %v, want %v", got.Fulfillable, tt.wantFulfillable)
+			}
+			if got.Split != tt.wantSplit {
+				t.Errorf("allocate() split = %v, want %v", got.Split, tt.wantSplit)
+			}
+			if allocations := formatAllocations(got.Allocations); !equalStrings(allocations, tt.wantAllocations) {
+				t.Errorf("allocate() allocations = %v, want %v", allocations, tt.wantAllocations)
+			}
+			if shortages := formatShortages(got.Shortages); !equalStrings(shortages, tt.wantShortages) {
+				t.Errorf("allocate() shortages = %v, want %v", shortages, tt.wantShortages)
+			}
+		})
+	}
+}
@@ -0,0 +1,352 @@
+// Package inventory keeps per-location stock, the stock movement ledger and
+// the product_stocks aggregate in step. Every write locks the aggregate rows
+// first, in product order, so concurrent writers queue up instead of
+// deadlocking, and product_stocks.remaining always equals the sum of the
+// location quantities.
+package inventory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"phakram/app/modules/entities/ent"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+var (
+	ErrInsufficientStock = errors.New("insufficient stock at location")
+	ErrInvalidMovement   = errors.New("invalid stock movement")
+	ErrLocationNotFound  = errors.New("stock location not found")
+)
+
+// Movement is one change to stock. Stock leaves FromLocationID and arrives
+// at ToLocationID; receipts have no source and removals no destination.
+type Movement struct {
+	ProductID      uuid.UUID
+	FromLocationID *uuid.UUID
+	ToLocationID   *uuid.UUID
+	Quantity       int
+	Kind           ent.StockMovementKindEnum
+	OrderID        *uuid.UUID
+	Note           *string
+	CreatedBy      *uuid.UUID
+}
+
+// DefaultLocationID returns the default location, or the most preferred
+// active one when no default is set.
+func DefaultLocationID(ctx context.Context, db bun.IDB) (uuid.UUID, error) {
+	location := new(ent.StockLocationEntity)
+	err := db.NewSelect().Model(location).Column("id").Where("is_default").Limit(1).Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = db.NewSelect().
+			Model(location).
+			Column("id").
+			Where("is_active").
+			OrderExpr("priority ASC, code ASC").
+			Limit(1).
+			Scan(ctx)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return uuid.Nil, ErrLocationNotFound
+	}
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return location.ID, nil
+}
+
+// MoveInTx applies a single movement, records it and updates the product's
+// aggregate stock. Receipts and adjustments also change stock_amount.
+func MoveInTx(ctx context.Context, tx bun.Tx, movement *Movement) error {
+	if _, err := lockStocks(ctx, tx, []uuid.UUID{movement.ProductID}); err != nil {
+		return err
+	}
+	if err := applyMovement(ctx, tx, movement); err != nil {
+		return err
+	}
+
+	amountDelta := 0
+	switch movement.Kind {
+	case ent.StockMovementInitial, ent.StockMovementReceive:
+		amountDelta = movement.Quantity
+	case ent.StockMovementAdjust:
+		amountDelta = movement.Quantity
+		if movement.ToLocationID == nil {
+			amountDelta = -movement.Quantity
+		}
+	}
+	return syncStock(ctx, tx, movement.ProductID, amountDelta, nil)
+}
+
+// TransferInTx moves stock of a product between two locations. The total on
+// hand is unchanged.
+func TransferInTx(ctx context.Context, tx bun.Tx, productID uuid.UUID, fromLocationID uuid.UUID, toLocationID uuid.UUID, quantity int, note *string, createdBy *uuid.UUID) error {
+	if fromLocationID == toLocationID {
+		return ErrInvalidMovement
+	}
+	count, err := tx.NewSelect().
+		Model((*ent.StockLocationEntity)(nil)).
+		Where("id IN (?)", bun.In([]uuid.UUID{fromLocationID, toLocationID})).
+		Count(ctx)
+	if err != nil {
+		return err
+	}
+	if count != 2 {
+		return ErrLocationNotFound
+	}
+
+	return MoveInTx(ctx, tx, &Movement{
+		ProductID:      productID,
+		FromLocationID: &fromLocationID,
+		ToLocationID:   &toLocationID,
+		Quantity:       quantity,
+		Kind:           ent.StockMovementTransfer,
+		Note:           note,
+		CreatedBy:      createdBy,
+	})
+}
+
+// SetStockInTx sets a product's aggregate stock the way the single-record
+// stock endpoints and the bulk import always have. The difference in
+// remaining is booked as an adjustment: additions go to the default
+// location, removals come out of the default location first and then the
+// least preferred ones.
+func SetStockInTx(ctx context.Context, tx bun.Tx, productID uuid.UUID, stockAmount int, remaining int, createdBy *uuid.UUID) error {
+	if stockAmount < 0 || remaining < 0 || remaining > stockAmount {
+		return ErrInvalidMovement
+	}
+	if _, err := lockStocks(ctx, tx, []uuid.UUID{productID}); err != nil {
+		return err
+	}
+
+	onHand, err := onHandQuantity(ctx, tx, productID)
+	if err != nil {
+		return err
+	}
+
+	note := "stock set to a new total"
+	if delta := remaining - onHand; delta > 0 {
+		defaultID, err := DefaultLocationID(ctx, tx)
+		if err != nil {
+			return err
+		}
+		if err := applyMovement(ctx, tx, &Movement{
+			ProductID:    productID,
+			ToLocationID: &defaultID,
+			Quantity:     delta,
+			Kind:         ent.StockMovementAdjust,
+			Note:         &note,
+			CreatedBy:    createdBy,
+		}); err != nil {
+			return err
+		}
+	} else if delta < 0 {
+		held := make([]*ent.LocationStockEntity, 0)
+		if err := tx.NewSelect().
+			Model(&held).
+			Join("JOIN stock_locations AS l ON l.id = location_stock_entity.location_id").
+			Where("location_stock_entity.product_id = ?", productID).
+			Where("location_stock_entity.quantity > 0").
+			OrderExpr("l.is_default DESC, l.priority DESC, l.code DESC").
+			Scan(ctx); err != nil {
+			return err
+		}
+		toRemove := -delta
+		for _, stock := range held {
+			if toRemove == 0 {
+				break
+			}
+			quantity := min(stock.Quantity, toRemove)
+			if err := applyMovement(ctx, tx, &Movement{
+				ProductID:      productID,
+				FromLocationID: &stock.LocationID,
+				Quantity:       quantity,
+				Kind:           ent.StockMovementAdjust,
+				Note:           &note,
+				CreatedBy:      createdBy,
+			}); err != nil {
+				return err
+			}
+			toRemove -= quantity
+		}
+		if toRemove > 0 {
+			return ErrInsufficientStock
+		}
+	}
+
+	return syncStock(ctx, tx, productID, 0, &stockAmount)
+}
+
+// QuantitiesByLocation returns the quantity of a product at every location
+// that holds or has held it.
+func QuantitiesByLocation(ctx context.Context, db bun.IDB, productID uuid.UUID) ([]*ent.LocationStockEntity, error) {
+	data := make([]*ent.LocationStockEntity, 0)
+	if err := db.NewSelect().
+		Model(&data).
+		Join("JOIN stock_locations AS l ON l.id = location_stock_entity.location_id AND l.deleted_at IS NULL").
+		Where("location_stock_entity.product_id = ?", productID).
+		OrderExpr("l.priority ASC, l.code ASC").
+		Scan(ctx); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// lockStocks locks the aggregate stock rows of the products and returns the
+// products that have one.
+func lockStocks(ctx context.Context, tx bun.Tx, productIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	locked := make(map[uuid.UUID]bool, len(productIDs))
+	if len(productIDs) == 0 {
+		return locked, nil
+	}
+
+	ids := make([]uuid.UUID, 0)
+	if err := tx.NewSelect().
+		TableExpr("product_stocks").
+		ColumnExpr("product_id").
+		Where("product_id IN (?)", bun.In(productIDs)).
+		Where("deleted_at IS NULL").
+		OrderExpr("product_id ASC, id ASC").
+		For("UPDATE").
+		Scan(ctx, &ids); err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		locked[id] = true
+	}
+	return locked, nil
+}
+
+// applyMovement moves the quantity between locations and records the
+// movement. The aggregate is left to the caller.
+func applyMovement(ctx context.Context, tx bun.Tx, movement *Movement) error {
+	if movement.Quantity <= 0 || (movement.FromLocationID == nil && movement.ToLocationID == nil) {
+		return ErrInvalidMovement
+	}
+	if movement.FromLocationID != nil && movement.ToLocationID != nil && *movement.FromLocationID == *movement.ToLocationID {
+		return ErrInvalidMovement
+	}
+
+	now := time.Now()
+	if movement.FromLocationID != nil {
+		res, err := tx.NewUpdate().
+			Model((*ent.LocationStockEntity)(nil)).
+			Set("quantity = quantity - ?", movement.Quantity).
+			Set("updated_at = ?", now).
+			Where("location_id = ?", *movement.FromLocationID).
+			Where("product_id = ?", movement.ProductID).
+			Where("quantity >= ?", movement.Quantity).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		if affected, err := res.RowsAffected(); err != nil {
+			return err
+		} else if affected == 0 {
+			return ErrInsufficientStock
+		}
+	}
+	if movement.ToLocationID != nil {
+		if _, err := tx.NewInsert().
+			Model(&ent.LocationStockEntity{
+				LocationID: *movement.ToLocationID,
+				ProductID:  movement.ProductID,
+				Quantity:   movement.Quantity,
+				CreatedAt:  now,
+				UpdatedAt:  now,
+			}).
+			On("CONFLICT (location_id, product_id) DO UPDATE").
+			Set("quantity = location_stock_entity.quantity + EXCLUDED.quantity").
+			Set("updated_at = EXCLUDED.updated_at").
+			Exec(ctx); err != nil {
+			return err
+		}
+	}
+
+	_, err := tx.NewInsert().Model(&ent.StockMovementEntity{
+		ID:             uuid.New(),
+		ProductID:      movement.ProductID,
+		FromLocationID: movement.FromLocationID,
+		ToLocationID:   movement.ToLocationID,
+		Quantity:       movement.Quantity,
+		Kind:           movement.Kind,
+		OrderID:        movement.OrderID,
+		Note:           movement.Note,
+		CreatedBy:      movement.CreatedBy,
+		CreatedAt:      now,
+	}).Exec(ctx)
+	return err
+}
+
+func onHandQuantity(ctx context.Context, db bun.IDB, productID uuid.UUID) (int, error) {
+	var onHand int
+	if err := db.NewSelect().
+		TableExpr("location_stocks").
+		ColumnExpr("COALESCE(SUM(quantity), 0)").
+		Where("product_id = ?", productID).
+		Scan(ctx, &onHand); err != nil {
+		return 0, err
+	}
+	return onHand, nil
+}
+
+// syncStock sets remaining to the quantity held across locations and moves
+// stock_amount by amountDelta, or to stockAmount when given. stock_amount
+// never drops below remaining. A missing aggregate row is created.
+func syncStock(ctx context.Context, tx bun.Tx, productID uuid.UUID, amountDelta int, stockAmount *int) error {
+	onHand, err := onHandQuantity(ctx, tx, productID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	stock := new(ent.ProductStockEntity)
+	err = tx.NewSelect().
+		Model(stock).
+		Where("product_id = ?", productID).
+		OrderExpr("created_at ASC").
+		Limit(1).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		product := new(ent.ProductEntity)
+		if err := tx.NewSelect().Model(product).Where("id = ?", productID).Limit(1).Scan(ctx); err != nil {
+			return err
+		}
+		stock = &ent.ProductStockEntity{
+			ID:          uuid.New(),
+			ProductID:   productID,
+			UnitPrice:   product.Price,
+			StockAmount: max(amountDelta, onHand),
+			Remaining:   onHand,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+		if stockAmount != nil {
+			stock.StockAmount = max(*stockAmount, onHand)
+		}
+		_, err = tx.NewInsert().Model(stock).Exec(ctx)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	if stockAmount != nil {
+		stock.StockAmount = *stockAmount
+	} else {
+		stock.StockAmount += amountDelta
+	}
+	stock.StockAmount = max(stock.StockAmount, onHand)
+	stock.Remaining = onHand
+	stock.UpdatedAt = now
+	_, err = tx.NewUpdate().
+		Model(stock).
+		Column("stock_amount", "remaining", "updated_at").
+		WherePK().
+		Exec(ctx)
+	return err
+}
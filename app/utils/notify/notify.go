@@ -0,0 +1,180 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// linePushURL is the LINE Messaging API endpoint for push messages.
+const linePushURL = "https://api.line.me/v2/bot/message/push"
+
+// lineTextLimit is the longest text a LINE message may carry.
+const lineTextLimit = 5000
+
+type MailConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// LineConfig sends pushes through a LINE Official Account. To is a comma
+// separated list of user, group or room IDs.
+type LineConfig struct {
+	ChannelAccessToken string
+	To                 string
+}
+
+// mailTimeout bounds a whole SMTP exchange, from dialing to QUIT.
+const mailTimeout = 30 * time.Second
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+func (c MailConfig) Enabled() bool {
+	return strings.TrimSpace(c.Host) != "" && c.Port > 0 && strings.TrimSpace(c.From) != ""
+}
+
+func (c LineConfig) Enabled() bool {
+	return strings.TrimSpace(c.ChannelAccessToken) != "" && len(SplitList(c.To)) > 0
+}
+
+// SplitList splits a comma separated config value, dropping blanks.
+func SplitList(value string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// SendMail sends a plain text UTF-8 email. Unlike smtp.SendMail it gives
+// up after mailTimeout, so a hung server cannot stall the caller.
+func SendMail(conf MailConfig, to []string, subject string, body string) error {
+	if !conf.Enabled() {
+		return errors.New("smtp is not configured")
+	}
+	if len(to) == 0 {
+		return errors.New("mail has no recipients")
+	}
+
+	host := strings.TrimSpace(conf.Host)
+	from := strings.TrimSpace(conf.From)
+	addr := net.JoinHostPort(host, strconv.Itoa(conf.Port))
+	auth := smtp.Auth(nil)
+	if conf.Username != "" && conf.Password != "" {
+		auth = smtp.PlainAuth("", strings.TrimSpace(conf.Username), strings.TrimSpace(conf.Password), host)
+	}
+
+	subject = strings.NewReplacer("\r", " ", "\n", " ").Replace(subject)
+	message := strings.Join([]string{
+		fmt.Sprintf("From: %s", from),
+		fmt.Sprintf("To: %s", strings.Join(to, ", ")),
+		fmt.Sprintf("Subject: %s", subject),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		body,
+	}, "\r\n")
+
+	conn, err := net.DialTimeout("tcp", addr, mailTimeout)
+	if err != nil {
+		return err
+	}
+	if err := conn.SetDeadline(time.Now().Add(mailTimeout)); err != nil {
+		_ = conn.Close()
+		return err
+	}
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if auth != nil {
+		if ok, _ := client.Extension("AUTH"); ok {
+			if err := client.Auth(auth); err != nil {
+				return err
+			}
+		}
+	}
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err := client.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write([]byte(message)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// PushLine sends a text message to every configured LINE recipient. Text
+// over the LINE limit is cut short.
+func PushLine(ctx context.Context, conf LineConfig, text string) error {
+	if !conf.Enabled() {
+		return errors.New("line is not configured")
+	}
+	if runes := []rune(text); len(runes) > lineTextLimit {
+		text = string(runes[:lineTextLimit-1]) + "…"
+	}
+
+	for _, to := range SplitList(conf.To) {
+		payload, err := json.Marshal(map[string]any{
+			"to": to,
+			"messages": []map[string]string{
+				{"type": "text", "text": text},
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, linePushURL, bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(conf.ChannelAccessToken))
+
+		res, err := httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		_ = res.Body.Close()
+		if res.StatusCode >= http.StatusMultipleChoices {
+			return fmt.Errorf("line push failed: %s: %s", res.Status, strings.TrimSpace(string(body)))
+		}
+	}
+	return nil
+}
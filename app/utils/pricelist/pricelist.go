@@ -0,0 +1,216 @@
+package pricelist
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/uptrace/bun"
+)
+
+// Break is one step of a product's price list: the unit price from
+// MinQuantity units upwards.
+type Break struct {
+	ID          uuid.UUID       `json:"-"`
+	MinQuantity int             `json:"min_quantity"`
+	Price       decimal.Decimal `json:"price"`
+	TierPriced  bool            `json:"tier_priced"`
+}
+
+// Price is the unit price a member pays for a quantity of a product.
+// TierPriced prices come from the member's tier list and replace the tier
+// discount rate; every other price still gets the rate at checkout.
+type Price struct {
+	BasePrice   decimal.Decimal `json:"base_price"`
+	UnitPrice   decimal.Decimal `json:"unit_price"`
+	TierPriced  bool            `json:"tier_priced"`
+	PriceID     *uuid.UUID      `json:"-"`
+	MinQuantity int             `json:"min_quantity"`
+}
+
+// List holds the price rows of one product that apply to one member: the
+// general rows and those of the member's tier, each sorted by quantity.
+type List struct {
+	general []Break
+	tier    []Break
+}
+
+type priceRow struct {
+	ID          uuid.UUID       `bun:"id"`
+	ProductID   uuid.UUID       `bun:"product_id"`
+	TierID      *uuid.UUID      `bun:"tier_id"`
+	MinQuantity int             `bun:"min_quantity"`
+	Price       decimal.Decimal `bun:"price"`
+}
+
+// Tier is the active tier of a member; the zero value means no tier.
+type Tier struct {
+	ID           uuid.UUID       `bun:"id"`
+	DiscountRate decimal.Decimal `bun:"discount_rate"`
+}
+
+// MemberTier returns the active tier of a member. Members without an active
+// tier, and anonymous callers, get the zero Tier.
+func MemberTier(ctx context.Context, db bun.IDB, memberID uuid.UUID) (Tier, error) {
+	tier := Tier{}
+	if memberID == uuid.Nil {
+		return tier, nil
+	}
+
+	err := db.NewSelect().
+		TableExpr("members AS m").
+		Join("JOIN tiers AS t ON t.id = m.tier_id").
+		ColumnExpr("t.id").
+		ColumnExpr("t.discount_rate").
+		Where("m.id = ?", memberID).
+		Where("t.is_active = true").
+		Limit(1).
+		Scan(ctx, &tier)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Tier{}, nil
+	}
+	return tier, err
+}
+
+// Load reads the price lists of the products for a tier. Products without
+// rows get no entry; Resolve on a nil list falls back to the base price.
+func Load(ctx context.Context, db bun.IDB, tierID uuid.UUID, productIDs []uuid.UUID) (map[uuid.UUID]*List, error) {
+	lists := make(map[uuid.UUID]*List, len(productIDs))
+	if len(productIDs) == 0 {
+		return lists, nil
+	}
+
+	rows := make([]*priceRow, 0)
+	query := db.NewSelect().
+		TableExpr("product_prices").
+		Column("id", "product_id", "tier_id", "min_quantity", "price").
+		Where("product_id IN (?)", bun.In(productIDs)).
+		OrderExpr("min_quantity ASC")
+	if tierID == uuid.Nil {
+		query = query.Where("tier_id IS NULL")
+	} else {
+		query = query.Where("(tier_id IS NULL OR tier_id = ?)", tierID)
+	}
+	if err := query.Scan(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		list, ok := lists[row.ProductID]
+		if !ok {
+			list = &List{}
+			lists[row.ProductID] = list
+		}
+		step := Break{ID: row.ID, MinQuantity: row.MinQuantity, Price: row.Price, TierPriced: row.TierID != nil}
+		if step.TierPriced {
+			list.tier = append(list.tier, step)
+		} else {
+			list.general = append(list.general, step)
+		}
+	}
+	return lists, nil
+}
+
+func match(steps []Break, quantity int) *Break {
+	var found *Break
+	for i := range steps {
+		if steps[i].MinQuantity > quantity {
+			break
+		}
+		found = &steps[i]
+	}
+	return found
+}
+
+// Resolve picks the unit price for quantity units. The member's tier rows
+// win over general rows, and the largest quantity break that is reached
+// wins within each; without a matching row the base price applies.
+func (l *List) Resolve(basePrice decimal.Decimal, quantity int) Price {
+	price := Price{BasePrice: basePrice, UnitPrice: basePrice, MinQuantity: 1}
+	if l == nil {
+		return price
+	}
+
+	step := match(l.tier, quantity)
+	if step == nil {
+		step = match(l.general, quantity)
+	}
+	if step == nil {
+		return price
+	}
+
+	id := step.ID
+	price.UnitPrice = step.Price
+	price.TierPriced = step.TierPriced
+	price.PriceID = &id
+	price.MinQuantity = step.MinQuantity
+	return price
+}
+
+// Quote is the catalogue view of a product's price for one member: the
+// single-unit price, the price after the tier discount rate where it still
+// applies, and the quantity breaks.
+type Quote struct {
+	BasePrice        decimal.Decimal `json:"base_price"`
+	UnitPrice        decimal.Decimal `json:"unit_price"`
+	TierPriced       bool            `json:"tier_priced"`
+	TierDiscountRate decimal.Decimal `json:"tier_discount_rate"`
+	EffectivePrice   decimal.Decimal `json:"effective_price"`
+	Breaks           []Break         `json:"breaks"`
+}
+
+func (l *List) Quote(basePrice decimal.Decimal, tier Tier) *Quote {
+	price := l.Resolve(basePrice, 1)
+	quote := &Quote{
+		BasePrice:        basePrice,
+		UnitPrice:        price.UnitPrice,
+		TierPriced:       price.TierPriced,
+		TierDiscountRate: decimal.Zero,
+		EffectivePrice:   price.UnitPrice,
+		Breaks:           l.Breaks(basePrice),
+	}
+	if !price.TierPriced && tier.DiscountRate.IsPositive() {
+		hundred := decimal.NewFromInt(100)
+		rate := decimal.Min(tier.DiscountRate, hundred)
+		quote.TierDiscountRate = rate
+		quote.EffectivePrice = price.UnitPrice.Mul(hundred.Sub(rate)).Div(hundred).Round(2)
+	}
+	return quote
+}
+
+// Breaks lists the unit price at every quantity where it changes, as the
+// member would see it in the catalogue.
+func (l *List) Breaks(basePrice decimal.Decimal) []Break {
+	if l == nil {
+		return []Break{}
+	}
+
+	quantities := make(map[int]struct{})
+	for _, step := range l.general {
+		quantities[step.MinQuantity] = struct{}{}
+	}
+	for _, step := range l.tier {
+		quantities[step.MinQuantity] = struct{}{}
+	}
+	ordered := make([]int, 0, len(quantities)+1)
+	ordered = append(ordered, 1)
+	for quantity := range quantities {
+		if quantity > 1 {
+			ordered = append(ordered, quantity)
+		}
+	}
+	sort.Ints(ordered)
+
+	result := make([]Break, 0, len(ordered))
+	for _, quantity := range ordered {
+		price := l.Resolve(basePrice, quantity)
+		if n := len(result); n > 0 && result[n-1].Price.Equal(price.UnitPrice) && result[n-1].TierPriced == price.TierPriced {
+			continue
+		}
+		result = append(result, Break{MinQuantity: quantity, Price: price.UnitPrice, TierPriced: price.TierPriced})
+	}
+	return result
+}
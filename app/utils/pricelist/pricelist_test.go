@@ -0,0 +1,188 @@
+package pricelist
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	generalTen   = uuid.MustParse("00000000-0000-0000-0000-000000000010")
+	generalFifty = uuid.MustParse("00000000-0000-0000-0000-000000000050")
+	tierTwenty   = uuid.MustParse("00000000-0000-0000-0000-000000000020")
+	tierOne      = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+)
+
+func dec(value string) decimal.Decimal {
+	return decimal.RequireFromString(value)
+}
+
+// sampleList has general breaks at 10 and 50 units and a tier break at 20.
+func sampleList() *List {
+	return &List{
+		general: []Break{
+			{ID: generalTen, MinQuantity: 10, Price: dec("90")},
+			{ID: generalFifty, MinQuantity: 50, Price: dec("80")},
+		},
+		tier: []Break{
+			{ID: tierTwenty, MinQuantity: 20, Price: dec("85"), TierPriced: true},
+		},
+	}
+}
+
+func TestList_Resolve(t *testing.T) {
+	type args struct {
+		list     *List
+		quantity int
+	}
+	tests := []struct {
+		name            string
+		args            args
+		wantUnitPrice   string
+		wantTierPriced  bool
+		wantPriceID     *uuid.UUID
+		wantMinQuantity int
+	}{
+		{"nil list uses the base price", args{nil, 5}, "100", false, nil, 1},
+		{"below the first break", args{sampleList(), 9}, "100", false, nil, 1},
+		{"general break reached", args{sampleList(), 10}, "90", false, &generalTen, 10},
+		{"between breaks", args{sampleList(), 19}, "90", false, &generalTen, 10},
+		{"tier break wins over general", args{sampleList(), 20}, "85", true, &tierTwenty, 20},
+		{"tier break wins over a cheaper general break", args{sampleList(), 60}, "85", true, &tierTwenty, 20},
+		{"general list only", args{&List{general: sampleList().general}, 60}, "80", false, &generalFifty, 50},
+		{"empty list", args{&List{}, 60}, "100", false, nil, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.args.list.Resolve(dec("100"), tt.args.quantity)
+			if !got.BasePrice.Equal(dec("100")) {
+				t.Errorf("Resolve() base price = %v, want 100", got.BasePrice)
+			}
+			if !got.UnitPrice.Equal(dec(tt.wantUnitPrice)) {
+				t.Errorf("Resolve() unit price = %v, want %v", got.UnitPrice, tt.wantUnitPrice)
+			}
+			if got.TierPriced != tt.wantTierPriced {
+				t.Errorf("Resolve() tier priced = %v, want %v", got.TierPriced, tt.wantTierPriced)
+			}
+			switch {
+			case tt.wantPriceID == nil && got.PriceID != nil:
+				t.Errorf("Resolve() price id = %v, want none", *got.PriceID)
+			case tt.wantPriceID != nil && (got.PriceID == nil || *got.PriceID != *tt.wantPriceID):
+				t.Errorf("Resolve() price id = %v, want %v", got.PriceID, *tt.wantPriceID)
+			}
+			if got.MinQuantity != tt.wantMinQuantity {
+				t.Errorf("Resolve() min quantity = %v, want %v", got.MinQuantity, tt.wantMinQuantity)
+			}
+		})
+	}
+}
+
+func TestList_Breaks(t *testing.T) {
+	tests := []struct {
+		name string
+		list *List
+		want []Break
+	}{
+		{"nil list", nil, []Break{}},
+		{"empty list starts at the base price", &List{}, []Break{{MinQuantity: 1, Price: dec("100")}}},
+		{
+			name: "steps where the price changes",
+			list: sampleList(),
+			want: []Break{
+				{MinQuantity: 1, Price: dec("100")},
+				{MinQuantity: 10, Price: dec("90")},
+				{MinQuantity: 20, Price: dec("85"), TierPriced: true},
+			},
+		},
+		{
+			name: "a break at the base price is left out",
+			list: &List{general: []Break{
+				{MinQuantity: 5, Price: dec("100")},
+				{MinQuantity: 10, Price: dec("95")},
+			}},
+			want: []Break{
+				{MinQuantity: 1, Price: dec("100")},
+				{MinQuantity: 10, Price: dec("95")},
+			},
+		},
+		{
+			name: "a tier price equal to the general one is still shown",
+			list: &List{
+				general: []Break{{MinQuantity: 1, Price: dec("90")}},
+				tier:    []Break{{MinQuantity: 5, Price: dec("90"), TierPriced: true}},
+			},
+			want: []Break{
+				{MinQuantity: 1, Price: dec("90")},
+				{MinQuantity: 5, Price: dec("90"), TierPriced: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.list.Breaks(dec("100"))
+			if len(got) != len(tt.want) {
+				t.Fatalf("Breaks() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i].MinQuantity != tt.want[i].MinQuantity || !got[i].Price.Equal(tt.want[i].Price) || got[i].TierPriced != tt.want[i].TierPriced {
+					t.Errorf("Breaks()[%d] = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestList_Quote(t *testing.T) {
+	type args struct {
+		list      *List
+		basePrice string
+		tierRate  string
+	}
+	tests := []struct {
+		name          string
+		args          args
+		wantUnit      string
+		wantRate      string
+		wantEffective string
+		wantTier      bool
+	}{
+		{"no tier", args{nil, "100", "0"}, "100", "0", "100", false},
+		{"tier rate on the base price", args{nil, "100", "10"}, "100", "10", "90", false},
+		{"tier rate on a general price", args{&List{general: []Break{{MinQuantity: 1, Price: dec("80")}}}, "100", "10"}, "80", "10", "72", false},
+		{"tier rate is capped at 100", args{nil, "100", "150"}, "100", "100", "0", false},
+		{"effective price is rounded", args{nil, "99.99", "7.5"}, "99.99", "7.5", "92.49", false},
+		{
+			name:          "tier price replaces the rate",
+			args:          args{&List{tier: []Break{{ID: tierOne, MinQuantity: 1, Price: dec("85"), TierPriced: true}}}, "100", "10"},
+			wantUnit:      "85",
+			wantRate:      "0",
+			wantEffective: "85",
+			wantTier:      true,
+		},
+		{"quantity breaks do not change the single-unit quote", args{sampleList(), "100", "5"}, "100", "5", "95", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.args.list.Quote(dec(tt.args.basePrice), Tier{ID: uuid.New(), DiscountRate: dec(tt.args.tierRate)})
+			if !got.BasePrice.Equal(dec(tt.args.basePrice)) {
+				t.Errorf("Quote() base price = %v, want %v", got.BasePrice, tt.args.basePrice)
+			}
+			if !got.UnitPrice.Equal(dec(tt.wantUnit)) {
+				t.Errorf("Quote() unit price = %v, want %v", got.UnitPrice, tt.wantUnit)
+			}
+			if !got.TierDiscountRate.Equal(dec(tt.wantRate)) {
+				t.Errorf("Quote() tier discount rate = %v, want %v", got.TierDiscountRate, tt.wantRate)
+			}
+			if !got.EffectivePrice.Equal(dec(tt.wantEffective)) {
+				t.Errorf("Quote() effective price = %v, want %v", got.EffectivePrice, tt.wantEffective)
+			}
+			if got.TierPriced != tt.wantTier {
+				t.Errorf("Quote() tier priced = %v, want %v", got.TierPriced, tt.wantTier)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package promotion
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+const (
+	CodeStatusAvailable = "available"
+	CodeStatusRedeemed  = "redeemed"
+	CodeStatusDisabled  = "disabled"
+)
+
+var (
+	ErrCodeNotFound = errors.New("promotion code is invalid")
+	ErrCodeRedeemed = errors.New("promotion code has already been used")
+)
+
+// ResolvedCode is the promotion a customer-entered code points to. CodeID is
+// set when the code is a single-use child code rather than the master code.
+type ResolvedCode struct {
+	PromotionID uuid.UUID
+	CodeID      *uuid.UUID
+}
+
+type childCodeRow struct {
+	ID          uuid.UUID `bun:"id"`
+	PromotionID uuid.UUID `bun:"promotion_id"`
+	Status      string    `bun:"status"`
+}
+
+// ResolveCode looks the code up as a promotion master code first and then as
+// a generated child code. Child codes that are redeemed or disabled are
+// rejected.
+func ResolveCode(ctx context.Context, db bun.IDB, code string) (*ResolvedCode, error) {
+	normalized := strings.ToUpper(strings.TrimSpace(code))
+	if normalized == "" {
+		return nil, ErrCodeNotFound
+	}
+
+	var promotionID uuid.UUID
+	err := db.NewSelect().
+		TableExpr("promotions").
+		Column("id").
+		Where("code = ?", normalized).
+		Limit(1).
+		Scan(ctx, &promotionID)
+	if err == nil {
+		return &ResolvedCode{PromotionID: promotionID}, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	row := new(childCodeRow)
+	if err := db.NewSelect().
+		TableExpr("promotion_codes").
+		Column("id", "promotion_id", "status").
+		Where("code = ?", normalized).
+		Limit(1).
+		Scan(ctx, row); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCodeNotFound
+		}
+		return nil, err
+	}
+	if row.Status != CodeStatusAvailable {
+		return nil, ErrCodeRedeemed
+	}
+
+	return &ResolvedCode{PromotionID: row.PromotionID, CodeID: &row.ID}, nil
+}
+
+// RedeemCodeInTx marks a child code as used by the order. The status check in
+// the update keeps two orders from redeeming the same code.
+func RedeemCodeInTx(ctx context.Context, tx bun.Tx, codeID uuid.UUID, memberID uuid.UUID, orderID uuid.UUID) error {
+	now := time.Now()
+	res, err := tx.NewUpdate().
+		TableExpr("promotion_codes").
+		Set("status = ?", CodeStatusRedeemed).
+		Set("redeemed_by = ?", memberID).
+		Set("order_id = ?", orderID).
+		Set("redeemed_at = ?", now).
+		Set("updated_at = ?", now).
+		Where("id = ?", codeID).
+		Where("status = ?", CodeStatusAvailable).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrCodeRedeemed
+	}
+	return nil
+}
+
+// ReleaseOrderCodeInTx makes the child code redeemed by a cancelled order
+// available again. Calling it again for the same order does nothing.
+func ReleaseOrderCodeInTx(ctx context.Context, tx bun.Tx, orderID uuid.UUID) error {
+	_, err := tx.NewUpdate().
+		TableExpr("promotion_codes").
+		Set("status = ?", CodeStatusAvailable).
+		Set("redeemed_by = NULL").
+		Set("order_id = NULL").
+		Set("redeemed_at = NULL").
+		Set("updated_at = ?", time.Now()).
+		Where("order_id = ?", orderID).
+		Where("status = ?", CodeStatusRedeemed).
+		Exec(ctx)
+	return err
+}
@@ -0,0 +1,79 @@
+package promotion
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+const (
+	LifecycleScheduled = "scheduled"
+	LifecycleActive    = "active"
+	LifecycleExpired   = "expired"
+)
+
+// Lifecycle events recorded in promotion_events for analytics.
+const (
+	EventCreated        = "created"
+	EventActivated      = "activated"
+	EventExpired        = "expired"
+	EventCollected      = "collected"
+	EventRedeemed       = "redeemed"
+	EventExpiryReminded = "expiry_reminded"
+)
+
+type Event struct {
+	PromotionID uuid.UUID
+	Type        string
+	MemberID    *uuid.UUID
+	OrderID     *uuid.UUID
+	Detail      string
+}
+
+type eventInsert struct {
+	bun.BaseModel `bun:"table:promotion_events"`
+
+	ID          uuid.UUID  `bun:"id,pk,type:uuid"`
+	PromotionID uuid.UUID  `bun:"promotion_id,type:uuid"`
+	EventType   string     `bun:"event_type"`
+	MemberID    *uuid.UUID `bun:"member_id,type:uuid"`
+	OrderID     *uuid.UUID `bun:"order_id,type:uuid"`
+	Detail      string     `bun:"detail"`
+	CreatedAt   time.Time  `bun:"created_at"`
+}
+
+// LifecycleStatus tells where a promotion's schedule stands at now.
+// Promotions without dates are always active.
+func LifecycleStatus(startsAt *time.Time, endsAt *time.Time, now time.Time) string {
+	if endsAt != nil && !now.Before(*endsAt) {
+		return LifecycleExpired
+	}
+	if startsAt != nil && now.Before(*startsAt) {
+		return LifecycleScheduled
+	}
+	return LifecycleActive
+}
+
+func RecordEvents(ctx context.Context, db bun.IDB, events ...Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	rows := make([]*eventInsert, 0, len(events))
+	for _, event := range events {
+		rows = append(rows, &eventInsert{
+			ID:          uuid.New(),
+			PromotionID: event.PromotionID,
+			EventType:   event.Type,
+			MemberID:    event.MemberID,
+			OrderID:     event.OrderID,
+			Detail:      event.Detail,
+			CreatedAt:   now,
+		})
+	}
+	_, err := db.NewInsert().Model(&rows).Exec(ctx)
+	return err
+}
@@ -0,0 +1,313 @@
+package promotion
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/uptrace/bun"
+)
+
+const (
+	TypePercent  = "percent"
+	TypeAmount   = "amount"
+	TypeBuyXGetY = "buy_x_get_y"
+	TypeBundle   = "bundle"
+)
+
+var (
+	ErrBuyQuantityNotMet = errors.New("cart does not have enough items for promotion")
+	ErrBundleIncomplete  = errors.New("cart does not contain the full bundle")
+)
+
+var hundred = decimal.NewFromInt(100)
+
+type BundleItem struct {
+	ProductID uuid.UUID `json:"product_id"`
+	Quantity  int       `json:"quantity"`
+}
+
+// Rule describes the mechanics of a line-based promotion.
+//
+// Buy X get Y rewards units from the eligible lines themselves (the cheapest
+// ones) unless GetProductID names a separate reward product, which is then
+// granted as extra units. A bundle sells one set of BundleItems for
+// BundlePrice.
+type Rule struct {
+	Type               string
+	BuyQuantity        int
+	GetQuantity        int
+	GetDiscountPercent decimal.Decimal
+	GetProductID       *uuid.UUID
+	BundlePrice        decimal.Decimal
+	BundleItems        []BundleItem
+	MaxDiscount        *decimal.Decimal
+}
+
+// Reward is an extra product unit granted by a buy X get Y promotion.
+type Reward struct {
+	ProductID       uuid.UUID       `json:"product_id"`
+	Quantity        int             `json:"quantity"`
+	DiscountPercent decimal.Decimal `json:"discount_percent"`
+}
+
+type Result struct {
+	DiscountAmount decimal.Decimal
+	LineDiscounts  []decimal.Decimal
+	Rewards        []Reward
+}
+
+type bundleItemRow struct {
+	PromotionID uuid.UUID `bun:"promotion_id"`
+	ProductID   uuid.UUID `bun:"product_id"`
+	Quantity    int       `bun:"quantity"`
+}
+
+// IsLineRule reports whether the promotion type can only be evaluated
+// against cart lines.
+func IsLineRule(promotionType string) bool {
+	return promotionType == TypeBuyXGetY || promotionType == TypeBundle
+}
+
+func LoadBundleItems(ctx context.Context, db bun.IDB, promotionIDs []uuid.UUID) (map[uuid.UUID][]BundleItem, error) {
+	items := make(map[uuid.UUID][]BundleItem, len(promotionIDs))
+	if len(promotionIDs) == 0 {
+		return items, nil
+	}
+
+	rows := make([]*bundleItemRow, 0)
+	if err := db.NewSelect().
+		TableExpr("promotion_bundle_items").
+		Column("promotion_id", "product_id", "quantity").
+		Where("promotion_id IN (?)", bun.In(promotionIDs)).
+		OrderExpr("created_at ASC").
+		Scan(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		items[row.PromotionID] = append(items[row.PromotionID], BundleItem{ProductID: row.ProductID, Quantity: row.Quantity})
+	}
+	return items, nil
+}
+
+func ReplaceBundleItemsInTx(ctx context.Context, tx bun.Tx, promotionID uuid.UUID, items []BundleItem) error {
+	if _, err := tx.NewDelete().
+		TableExpr("promotion_bundle_items").
+		Where("promotion_id = ?", promotionID).
+		Exec(ctx); err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		return nil
+	}
+
+	type bundleItemInsert struct {
+		bun.BaseModel `bun:"table:promotion_bundle_items"`
+
+		ID          uuid.UUID `bun:"id,pk,type:uuid"`
+		PromotionID uuid.UUID `bun:"promotion_id,type:uuid"`
+		ProductID   uuid.UUID `bun:"product_id,type:uuid"`
+		Quantity    int       `bun:"quantity"`
+	}
+
+	rows := make([]*bundleItemInsert, 0, len(items))
+	for _, item := range items {
+		rows = append(rows, &bundleItemInsert{
+			ID:          uuid.New(),
+			PromotionID: promotionID,
+			ProductID:   item.ProductID,
+			Quantity:    item.Quantity,
+		})
+	}
+
+	_, err := tx.NewInsert().Model(&rows).Exec(ctx)
+	return err
+}
+
+// EvaluateRule applies a buy X get Y or bundle rule to the eligible lines.
+// It returns ErrBuyQuantityNotMet or ErrBundleIncomplete when the cart does
+// not qualify.
+func EvaluateRule(rule *Rule, lines []Line, eligible []bool) (*Result, error) {
+	var (
+		result *Result
+		err    error
+	)
+	switch rule.Type {
+	case TypeBuyXGetY:
+		result, err = evaluateBuyXGetY(rule, lines, eligible)
+	case TypeBundle:
+		result, err = evaluateBundle(rule, lines, eligible)
+	default:
+		return nil, errors.New("unsupported promotion type")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if rule.MaxDiscount != nil && rule.MaxDiscount.IsPositive() && result.DiscountAmount.GreaterThan(*rule.MaxDiscount) {
+		result.LineDiscounts = ScaleDiscounts(result.LineDiscounts, *rule.MaxDiscount)
+		result.DiscountAmount = *rule.MaxDiscount
+	}
+
+	return result, nil
+}
+
+// ScaleDiscounts reduces per-line discounts to a new total while keeping
+// their proportions.
+func ScaleDiscounts(discounts []decimal.Decimal, total decimal.Decimal) []decimal.Decimal {
+	weights := make([]Line, len(discounts))
+	weighted := make([]bool, len(discounts))
+	for i, discount := range discounts {
+		weights[i] = Line{Amount: discount}
+		weighted[i] = discount.IsPositive()
+	}
+	return AllocateDiscount(weights, weighted, total)
+}
+
+type lineUnit struct {
+	line  int
+	price decimal.Decimal
+}
+
+func evaluateBuyXGetY(rule *Rule, lines []Line, eligible []bool) (*Result, error) {
+	result := &Result{
+		DiscountAmount: decimal.Zero,
+		LineDiscounts:  zeroAmounts(len(lines)),
+	}
+	if rule.BuyQuantity <= 0 || rule.GetQuantity <= 0 {
+		return result, nil
+	}
+
+	units := make([]lineUnit, 0)
+	for i, line := range lines {
+		if !eligible[i] || line.Quantity <= 0 {
+			continue
+		}
+		unitPrice := line.Amount.Div(decimal.NewFromInt(int64(line.Quantity)))
+		for q := 0; q < line.Quantity; q++ {
+			units = append(units, lineUnit{line: i, price: unitPrice})
+		}
+	}
+
+	percent := rule.GetDiscountPercent
+	if !percent.IsPositive() || percent.GreaterThan(hundred) {
+		percent = hundred
+	}
+
+	if rule.GetProductID != nil {
+		sets := len(units) / rule.BuyQuantity
+		if sets == 0 {
+			return nil, ErrBuyQuantityNotMet
+		}
+		result.Rewards = []Reward{{
+			ProductID:       *rule.GetProductID,
+			Quantity:        sets * rule.GetQuantity,
+			DiscountPercent: percent,
+		}}
+		return result, nil
+	}
+
+	groupSize := rule.BuyQuantity + rule.GetQuantity
+	sets := len(units) / groupSize
+	if sets == 0 {
+		return nil, ErrBuyQuantityNotMet
+	}
+
+	// The cheapest units are the ones given away.
+	sort.SliceStable(units, func(a, b int) bool { return units[a].price.LessThan(units[b].price) })
+	for _, unit := range units[:sets*rule.GetQuantity] {
+		discount := unit.price.Mul(percent).Div(hundred)
+		result.LineDiscounts[unit.line] = result.LineDiscounts[unit.line].Add(discount)
+	}
+	for i := range result.LineDiscounts {
+		result.LineDiscounts[i] = result.LineDiscounts[i].Round(2)
+		result.DiscountAmount = result.DiscountAmount.Add(result.LineDiscounts[i])
+	}
+
+	return result, nil
+}
+
+func evaluateBundle(rule *Rule, lines []Line, eligible []bool) (*Result, error) {
+	result := &Result{
+		DiscountAmount: decimal.Zero,
+		LineDiscounts:  zeroAmounts(len(lines)),
+	}
+	if len(rule.BundleItems) == 0 {
+		return nil, ErrBundleIncomplete
+	}
+
+	quantities := make(map[uuid.UUID]int)
+	amounts := make(map[uuid.UUID]decimal.Decimal)
+	for i, line := range lines {
+		if !eligible[i] {
+			continue
+		}
+		quantities[line.ProductID] += line.Quantity
+		amounts[line.ProductID] = amounts[line.ProductID].Add(line.Amount)
+	}
+
+	sets := -1
+	for _, item := range rule.BundleItems {
+		if item.Quantity <= 0 {
+			continue
+		}
+		available := quantities[item.ProductID] / item.Quantity
+		if sets < 0 || available < sets {
+			sets = available
+		}
+	}
+	if sets <= 0 {
+		return nil, ErrBundleIncomplete
+	}
+
+	// Price one set at the cart's average unit prices, then spread each
+	// set's saving over the component lines by their share of the set.
+	setPrice := decimal.Zero
+	componentAmounts := make(map[uuid.UUID]decimal.Decimal, len(rule.BundleItems))
+	for _, item := range rule.BundleItems {
+		unitPrice := amounts[item.ProductID].Div(decimal.NewFromInt(int64(quantities[item.ProductID])))
+		componentAmount := unitPrice.Mul(decimal.NewFromInt(int64(item.Quantity * sets)))
+		componentAmounts[item.ProductID] = componentAmount
+		setPrice = setPrice.Add(unitPrice.Mul(decimal.NewFromInt(int64(item.Quantity))))
+	}
+	saving := setPrice.Sub(rule.BundlePrice)
+	if !saving.IsPositive() {
+		return result, nil
+	}
+	total := saving.Mul(decimal.NewFromInt(int64(sets))).Round(2)
+
+	weights := make([]Line, len(lines))
+	weighted := make([]bool, len(lines))
+	remaining := make(map[uuid.UUID]decimal.Decimal, len(componentAmounts))
+	for id, amount := range componentAmounts {
+		remaining[id] = amount
+	}
+	for i, line := range lines {
+		left, ok := remaining[line.ProductID]
+		if !eligible[i] || !ok || !left.IsPositive() {
+			continue
+		}
+		share := line.Amount
+		if share.GreaterThan(left) {
+			share = left
+		}
+		remaining[line.ProductID] = left.Sub(share)
+		weights[i] = Line{Amount: share}
+		weighted[i] = true
+	}
+
+	result.LineDiscounts = AllocateDiscount(weights, weighted, total)
+	result.DiscountAmount = total
+	return result, nil
+}
+
+func zeroAmounts(n int) []decimal.Decimal {
+	amounts := make([]decimal.Decimal, n)
+	for i := range amounts {
+		amounts[i] = decimal.Zero
+	}
+	return amounts
+}
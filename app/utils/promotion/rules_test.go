@@ -0,0 +1,413 @@
+package promotion
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	productA = uuid.MustParse("00000000-0000-0000-0000-00000000000a")
+	productB = uuid.MustParse("00000000-0000-0000-0000-00000000000b")
+	productC = uuid.MustParse("00000000-0000-0000-0000-00000000000c")
+	productD = uuid.MustParse("00000000-0000-0000-0000-00000000000d")
+	productR = uuid.MustParse("00000000-0000-0000-0000-0000000000ff")
+)
+
+func dec(value string) decimal.Decimal {
+	return decimal.RequireFromString(value)
+}
+
+func decPtr(value string) *decimal.Decimal {
+	d := dec(value)
+	return &d
+}
+
+func decs(values ...string) []decimal.Decimal {
+	out := make([]decimal.Decimal, 0, len(values))
+	for _, value := range values {
+		out = append(out, dec(value))
+	}
+	return out
+}
+
+func allEligible(n int) []bool {
+	eligible := make([]bool, n)
+	for i := range eligible {
+		eligible[i] = true
+	}
+	return eligible
+}
+
+func equalDecimals(a []decimal.Decimal, b []decimal.Decimal) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestEvaluateRule(t *testing.T) {
+	type args struct {
+		rule     *Rule
+		lines    []Line
+		eligible []bool
+	}
+	tests := []struct {
+		name         string
+		args         args
+		wantErr      error
+		wantDiscount decimal.Decimal
+		wantLines    []decimal.Decimal
+		wantRewards  []Reward
+		wantAnyErr   bool
+	}{
+		{
+			name: "buy 2 get 1 gives the cheapest unit away",
+			args: args{
+				rule: &Rule{Type: TypeBuyXGetY, BuyQuantity: 2, GetQuantity: 1},
+				lines: []Line{
+					{ProductID: productA, Quantity: 2, Amount: dec("200")},
+					{ProductID: productB, Quantity: 1, Amount: dec("50")},
+				},
+				eligible: allEligible(2),
+			},
+			wantDiscount: dec("50"),
+			wantLines:    decs("0", "50"),
+		},
+		{
+			name: "buy 2 get 1 at half price",
+			args: args{
+				rule: &Rule{Type: TypeBuyXGetY, BuyQuantity: 2, GetQuantity: 1, GetDiscountPercent: dec("50")},
+				lines: []Line{
+					{ProductID: productA, Quantity: 2, Amount: dec("200")},
+					{ProductID: productB, Quantity: 1, Amount: dec("50")},
+				},
+				eligible: allEligible(2),
+			},
+			wantDiscount: dec("25"),
+			wantLines:    decs("0", "25"),
+		},
+		{
+			name: "percent over 100 is treated as free",
+			args: args{
+				rule: &Rule{Type: TypeBuyXGetY, BuyQuantity: 1, GetQuantity: 1, GetDiscountPercent: dec("150")},
+				lines: []Line{
+					{ProductID: productA, Quantity: 2, Amount: dec("80")},
+				},
+				eligible: allEligible(1),
+			},
+			wantDiscount: dec("40"),
+			wantLines:    decs("40"),
+		},
+		{
+			name: "unit prices are rounded per line",
+			args: args{
+				rule: &Rule{Type: TypeBuyXGetY, BuyQuantity: 2, GetQuantity: 1},
+				lines: []Line{
+					{ProductID: productA, Quantity: 3, Amount: dec("100")},
+				},
+				eligible: allEligible(1),
+			},
+			wantDiscount: dec("33.33"),
+			wantLines:    decs("33.33"),
+		},
+		{
+			name: "buy quantity not met",
+			args: args{
+				rule: &Rule{Type: TypeBuyXGetY, BuyQuantity: 2, GetQuantity: 1},
+				lines: []Line{
+					{ProductID: productA, Quantity: 2, Amount: dec("200")},
+				},
+				eligible: allEligible(1),
+			},
+			wantErr: ErrBuyQuantityNotMet,
+		},
+		{
+			name: "ineligible lines do not count towards buy quantity",
+			args: args{
+				rule: &Rule{Type: TypeBuyXGetY, BuyQuantity: 2, GetQuantity: 1},
+				lines: []Line{
+					{ProductID: productA, Quantity: 2, Amount: dec("200")},
+					{ProductID: productB, Quantity: 1, Amount: dec("50")},
+				},
+				eligible: []bool{true, false},
+			},
+			wantErr: ErrBuyQuantityNotMet,
+		},
+		{
+			name: "reward product is granted per completed set",
+			args: args{
+				rule: &Rule{Type: TypeBuyXGetY, BuyQuantity: 2, GetQuantity: 1, GetProductID: &productR},
+				lines: []Line{
+					{ProductID: productA, Quantity: 5, Amount: dec("500")},
+				},
+				eligible: allEligible(1),
+			},
+			wantDiscount: dec("0"),
+			wantLines:    decs("0"),
+			wantRewards:  []Reward{{ProductID: productR, Quantity: 2, DiscountPercent: dec("100")}},
+		},
+		{
+			name: "reward product needs one full buy set",
+			args: args{
+				rule: &Rule{Type: TypeBuyXGetY, BuyQuantity: 3, GetQuantity: 1, GetProductID: &productR},
+				lines: []Line{
+					{ProductID: productA, Quantity: 2, Amount: dec("200")},
+				},
+				eligible: allEligible(1),
+			},
+			wantErr: ErrBuyQuantityNotMet,
+		},
+		{
+			name: "misconfigured buy x get y gives nothing",
+			args: args{
+				rule: &Rule{Type: TypeBuyXGetY, BuyQuantity: 0, GetQuantity: 1},
+				lines: []Line{
+					{ProductID: productA, Quantity: 5, Amount: dec("500")},
+				},
+				eligible: allEligible(1),
+			},
+			wantDiscount: dec("0"),
+			wantLines:    decs("0"),
+		},
+		{
+			name: "max discount scales line discounts",
+			args: args{
+				rule: &Rule{Type: TypeBuyXGetY, BuyQuantity: 1, GetQuantity: 1, MaxDiscount: decPtr("40")},
+				lines: []Line{
+					{ProductID: productA, Quantity: 1, Amount: dec("100")},
+					{ProductID: productB, Quantity: 1, Amount: dec("60")},
+					{ProductID: productC, Quantity: 1, Amount: dec("40")},
+					{ProductID: productD, Quantity: 1, Amount: dec("40")},
+				},
+				eligible: allEligible(4),
+			},
+			wantDiscount: dec("40"),
+			wantLines:    decs("0", "0", "20", "20"),
+		},
+		{
+			name: "max discount above the discount changes nothing",
+			args: args{
+				rule: &Rule{Type: TypeBuyXGetY, BuyQuantity: 2, GetQuantity: 1, MaxDiscount: decPtr("500")},
+				lines: []Line{
+					{ProductID: productA, Quantity: 2, Amount: dec("200")},
+					{ProductID: productB, Quantity: 1, Amount: dec("50")},
+				},
+				eligible: allEligible(2),
+			},
+			wantDiscount: dec("50"),
+			wantLines:    decs("0", "50"),
+		},
+		{
+			name: "bundle saving is spread by share of the set",
+			args: args{
+				rule: &Rule{
+					Type:        TypeBundle,
+					BundlePrice: dec("150"),
+					BundleItems: []BundleItem{{ProductID: productA, Quantity: 1}, {ProductID: productB, Quantity: 1}},
+				},
+				lines: []Line{
+					{ProductID: productA, Quantity: 1, Amount: dec("100")},
+					{ProductID: productB, Quantity: 1, Amount: dec("80")},
+				},
+				eligible: allEligible(2),
+			},
+			wantDiscount: dec("30"),
+			wantLines:    decs("16.67", "13.33"),
+		},
+		{
+			name: "bundle counts complete sets only",
+			args: args{
+				rule: &Rule{
+					Type:        TypeBundle,
+					BundlePrice: dec("150"),
+					BundleItems: []BundleItem{{ProductID: productA, Quantity: 1}, {ProductID: productB, Quantity: 1}},
+				},
+				lines: []Line{
+					{ProductID: productA, Quantity: 2, Amount: dec("200")},
+					{ProductID: productB, Quantity: 3, Amount: dec("240")},
+				},
+				eligible: allEligible(2),
+			},
+			wantDiscount: dec("60"),
+			wantLines:    decs("33.33", "26.67"),
+		},
+		{
+			name: "bundle with a missing component",
+			args: args{
+				rule: &Rule{
+					Type:        TypeBundle,
+					BundlePrice: dec("150"),
+					BundleItems: []BundleItem{{ProductID: productA, Quantity: 1}, {ProductID: productB, Quantity: 1}},
+				},
+				lines: []Line{
+					{ProductID: productA, Quantity: 3, Amount: dec("300")},
+				},
+				eligible: allEligible(1),
+			},
+			wantErr: ErrBundleIncomplete,
+		},
+		{
+			name: "bundle ignores ineligible lines",
+			args: args{
+				rule: &Rule{
+					Type:        TypeBundle,
+					BundlePrice: dec("150"),
+					BundleItems: []BundleItem{{ProductID: productA, Quantity: 1}, {ProductID: productB, Quantity: 1}},
+				},
+				lines: []Line{
+					{ProductID: productA, Quantity: 1, Amount: dec("100")},
+					{ProductID: productB, Quantity: 1, Amount: dec("80")},
+				},
+				eligible: []bool{true, false},
+			},
+			wantErr: ErrBundleIncomplete,
+		},
+		{
+			name: "bundle without items",
+			args: args{
+				rule:     &Rule{Type: TypeBundle, BundlePrice: dec("150")},
+				lines:    []Line{{ProductID: productA, Quantity: 1, Amount: dec("100")}},
+				eligible: allEligible(1),
+			},
+			wantErr: ErrBundleIncomplete,
+		},
+		{
+			name: "bundle priced above the set saves nothing",
+			args: args{
+				rule: &Rule{
+					Type:        TypeBundle,
+					BundlePrice: dec("200"),
+					BundleItems: []BundleItem{{ProductID: productA, Quantity: 1}, {ProductID: productB, Quantity: 1}},
+				},
+				lines: []Line{
+					{ProductID: productA, Quantity: 1, Amount: dec("100")},
+					{ProductID: productB, Quantity: 1, Amount: dec("80")},
+				},
+				eligible: allEligible(2),
+			},
+			wantDiscount: dec("0"),
+			wantLines:    decs("0", "0"),
+		},
+		{
+			name: "bundle discount is capped",
+			args: args{
+				rule: &Rule{
+					Type:        TypeBundle,
+					BundlePrice: dec("100"),
+					BundleItems: []BundleItem{{ProductID: productA, Quantity: 1}, {ProductID: productB, Quantity: 1}},
+					MaxDiscount: decPtr("40"),
+				},
+				lines: []Line{
+					{ProductID: productA, Quantity: 1, Amount: dec("100")},
+					{ProductID: productB, Quantity: 1, Amount: dec("100")},
+				},
+				eligible: allEligible(2),
+			},
+			wantDiscount: dec("40"),
+			wantLines:    decs("20", "20"),
+		},
+		{
+			name: "unsupported type",
+			args: args{
+				rule:     &Rule{Type: TypePercent},
+				lines:    []Line{{ProductID: productA, Quantity: 1, Amount: dec("100")}},
+				eligible: allEligible(1),
+			},
+			wantAnyErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EvaluateRule(tt.args.rule, tt.args.lines, tt.args.eligible)
+			if tt.wantAnyErr {
+				if err == nil {
+					t.Fatalf("EvaluateRule() error = nil, want an error")
+				}
+				return
+			}
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("EvaluateRule() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("EvaluateRule() error = %v", err)
+			}
+			if !got.DiscountAmount.Equal(tt.wantDiscount) {
+				t.Errorf("EvaluateRule() discount = %v, want %v", got.DiscountAmount, tt.wantDiscount)
+			}
+			if !equalDecimals(got.LineDiscounts, tt.wantLines) {
+				t.Errorf("EvaluateRule() line discounts = %v, want %v", got.LineDiscounts, tt.wantLines)
+			}
+			if len(got.Rewards) != len(tt.wantRewards) {
+				t.Fatalf("EvaluateRule() rewards = %v, want %v", got.Rewards, tt.wantRewards)
+			}
+			for i, reward := range got.Rewards {
+				want := tt.wantRewards[i]
+				if reward.ProductID != want.ProductID || reward.Quantity != want.Quantity || !reward.DiscountPercent.Equal(want.DiscountPercent) {
+					t.Errorf("EvaluateRule() reward[%d] = %v, want %v", i, reward, want)
+				}
+			}
+		})
+	}
+}
+
+func TestEvaluateRuleLineDiscountsAddUp(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  *Rule
+		lines []Line
+	}{
+		{
+			name: "buy x get y",
+			rule: &Rule{Type: TypeBuyXGetY, BuyQuantity: 2, GetQuantity: 1, GetDiscountPercent: dec("33")},
+			lines: []Line{
+				{ProductID: productA, Quantity: 3, Amount: dec("99.99")},
+				{ProductID: productB, Quantity: 4, Amount: dec("51.17")},
+				{ProductID: productC, Quantity: 2, Amount: dec("12.35")},
+			},
+		},
+		{
+			name: "bundle",
+			rule: &Rule{
+				Type:        TypeBundle,
+				BundlePrice: dec("99.99"),
+				BundleItems: []BundleItem{{ProductID: productA, Quantity: 2}, {ProductID: productB, Quantity: 1}},
+			},
+			lines: []Line{
+				{ProductID: productA, Quantity: 3, Amount: dec("133.33")},
+				{ProductID: productA, Quantity: 2, Amount: dec("90")},
+				{ProductID: productB, Quantity: 2, Amount: dec("77.77")},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EvaluateRule(tt.rule, tt.lines, allEligible(len(tt.lines)))
+			if err != nil {
+				t.Fatalf("EvaluateRule() error = %v", err)
+			}
+			sum := decimal.Zero
+			for i, discount := range got.LineDiscounts {
+				if discount.IsNegative() || discount.GreaterThan(tt.lines[i].Amount) {
+					t.Errorf("EvaluateRule() line %d discount = %v, outside 0..%v", i, discount, tt.lines[i].Amount)
+				}
+				sum = sum.Add(discount)
+			}
+			if !sum.Equal(got.DiscountAmount) {
+				t.Errorf("EvaluateRule() line discounts sum to %v, want %v", sum, got.DiscountAmount)
+			}
+		})
+	}
+}
@@ -0,0 +1,288 @@
+package promotion
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/uptrace/bun"
+)
+
+const (
+	TargetProduct         = "product"
+	TargetCategory        = "category"
+	TargetExcludedProduct = "excluded_product"
+)
+
+// Line is a priced cart or order line a promotion may apply to.
+type Line struct {
+	ProductID uuid.UUID
+	Quantity  int
+	Amount    decimal.Decimal
+}
+
+// Scope lists what a promotion targets. A promotion without product or
+// category targets applies to every line; excluded products never qualify.
+type Scope struct {
+	ProductIDs         []uuid.UUID
+	CategoryIDs        []uuid.UUID
+	ExcludedProductIDs []uuid.UUID
+}
+
+type targetRow struct {
+	PromotionID uuid.UUID `bun:"promotion_id"`
+	TargetType  string    `bun:"target_type"`
+	TargetID    uuid.UUID `bun:"target_id"`
+}
+
+type productCategoryRow struct {
+	ProductID  uuid.UUID `bun:"product_id"`
+	CategoryID uuid.UUID `bun:"category_id"`
+}
+
+func (s *Scope) IsScoped() bool {
+	return s != nil && (len(s.ProductIDs) > 0 || len(s.CategoryIDs) > 0 || len(s.ExcludedProductIDs) > 0)
+}
+
+func (s *Scope) hasIncludes() bool {
+	return len(s.ProductIDs) > 0 || len(s.CategoryIDs) > 0
+}
+
+func LoadScope(ctx context.Context, db bun.IDB, promotionID uuid.UUID) (*Scope, error) {
+	scopes, err := LoadScopes(ctx, db, []uuid.UUID{promotionID})
+	if err != nil {
+		return nil, err
+	}
+	return scopes[promotionID], nil
+}
+
+func LoadScopes(ctx context.Context, db bun.IDB, promotionIDs []uuid.UUID) (map[uuid.UUID]*Scope, error) {
+	scopes := make(map[uuid.UUID]*Scope, len(promotionIDs))
+	for _, id := range promotionIDs {
+		scopes[id] = &Scope{}
+	}
+	if len(promotionIDs) == 0 {
+		return scopes, nil
+	}
+
+	rows := make([]*targetRow, 0)
+	if err := db.NewSelect().
+		TableExpr("promotion_targets").
+		Column("promotion_id", "target_type", "target_id").
+		Where("promotion_id IN (?)", bun.In(promotionIDs)).
+		OrderExpr("created_at ASC").
+		Scan(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		scope := scopes[row.PromotionID]
+		switch row.TargetType {
+		case TargetProduct:
+			scope.ProductIDs = append(scope.ProductIDs, row.TargetID)
+		case TargetCategory:
+			scope.CategoryIDs = append(scope.CategoryIDs, row.TargetID)
+		case TargetExcludedProduct:
+			scope.ExcludedProductIDs = append(scope.ExcludedProductIDs, row.TargetID)
+		}
+	}
+
+	return scopes, nil
+}
+
+// ReplaceScopeInTx rewrites the promotion's targets.
+func ReplaceScopeInTx(ctx context.Context, tx bun.Tx, promotionID uuid.UUID, scope *Scope) error {
+	if _, err := tx.NewDelete().
+		TableExpr("promotion_targets").
+		Where("promotion_id = ?", promotionID).
+		Exec(ctx); err != nil {
+		return err
+	}
+	if scope == nil {
+		return nil
+	}
+
+	type targetInsert struct {
+		bun.BaseModel `bun:"table:promotion_targets"`
+
+		ID          uuid.UUID `bun:"id,pk,type:uuid"`
+		PromotionID uuid.UUID `bun:"promotion_id,type:uuid"`
+		TargetType  string    `bun:"target_type"`
+		TargetID    uuid.UUID `bun:"target_id,type:uuid"`
+	}
+
+	targets := make([]*targetInsert, 0)
+	seen := make(map[string]struct{})
+	add := func(targetType string, ids []uuid.UUID) {
+		for _, id := range ids {
+			key := targetType + ":" + id.String()
+			if id == uuid.Nil {
+				continue
+			}
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			targets = append(targets, &targetInsert{
+				ID:          uuid.New(),
+				PromotionID: promotionID,
+				TargetType:  targetType,
+				TargetID:    id,
+			})
+		}
+	}
+	add(TargetProduct, scope.ProductIDs)
+	add(TargetCategory, scope.CategoryIDs)
+	add(TargetExcludedProduct, scope.ExcludedProductIDs)
+	if len(targets) == 0 {
+		return nil
+	}
+
+	_, err := tx.NewInsert().Model(&targets).Exec(ctx)
+	return err
+}
+
+// EligibleLines reports, per line, whether the scope covers it. A category
+// target also covers every subcategory beneath it.
+func EligibleLines(ctx context.Context, db bun.IDB, scope *Scope, lines []Line) ([]bool, error) {
+	eligible := make([]bool, len(lines))
+	if !scope.IsScoped() {
+		for i := range eligible {
+			eligible[i] = true
+		}
+		return eligible, nil
+	}
+
+	excluded := toSet(scope.ExcludedProductIDs)
+	included := toSet(scope.ProductIDs)
+	includedCategories := toSet(scope.CategoryIDs)
+
+	var categories map[uuid.UUID][]uuid.UUID
+	if len(includedCategories) > 0 {
+		productIDs := make([]uuid.UUID, 0, len(lines))
+		for _, line := range lines {
+			productIDs = append(productIDs, line.ProductID)
+		}
+		loaded, err := loadProductCategoryPaths(ctx, db, productIDs)
+		if err != nil {
+			return nil, err
+		}
+		categories = loaded
+	}
+
+	for i, line := range lines {
+		if _, ok := excluded[line.ProductID]; ok {
+			continue
+		}
+		if !scope.hasIncludes() {
+			eligible[i] = true
+			continue
+		}
+		if _, ok := included[line.ProductID]; ok {
+			eligible[i] = true
+			continue
+		}
+		for _, categoryID := range categories[line.ProductID] {
+			if _, ok := includedCategories[categoryID]; ok {
+				eligible[i] = true
+				break
+			}
+		}
+	}
+
+	return eligible, nil
+}
+
+// EligibleAmount sums the amounts of the eligible lines.
+func EligibleAmount(lines []Line, eligible []bool) decimal.Decimal {
+	total := decimal.Zero
+	for i, line := range lines {
+		if eligible[i] {
+			total = total.Add(line.Amount)
+		}
+	}
+	return total
+}
+
+// AllocateDiscount prorates a discount across eligible lines by amount. The
+// rounding remainder goes to the last eligible line so the shares add up.
+func AllocateDiscount(lines []Line, eligible []bool, discount decimal.Decimal) []decimal.Decimal {
+	allocations := make([]decimal.Decimal, len(lines))
+	for i := range allocations {
+		allocations[i] = decimal.Zero
+	}
+
+	total := EligibleAmount(lines, eligible)
+	if !total.IsPositive() || !discount.IsPositive() {
+		return allocations
+	}
+	if discount.GreaterThan(total) {
+		discount = total
+	}
+
+	last := -1
+	for i := range lines {
+		if eligible[i] && lines[i].Amount.IsPositive() {
+			last = i
+		}
+	}
+
+	remaining := discount
+	for i, line := range lines {
+		if !eligible[i] || !line.Amount.IsPositive() {
+			continue
+		}
+		if i == last {
+			allocations[i] = remaining
+			break
+		}
+		share := discount.Mul(line.Amount).Div(total).Round(2)
+		if share.GreaterThan(remaining) {
+			share = remaining
+		}
+		allocations[i] = share
+		remaining = remaining.Sub(share)
+	}
+
+	return allocations
+}
+
+// loadProductCategoryPaths returns each product's category followed by all
+// of its ancestors.
+func loadProductCategoryPaths(ctx context.Context, db bun.IDB, productIDs []uuid.UUID) (map[uuid.UUID][]uuid.UUID, error) {
+	paths := make(map[uuid.UUID][]uuid.UUID, len(productIDs))
+	if len(productIDs) == 0 {
+		return paths, nil
+	}
+
+	rows := make([]*productCategoryRow, 0)
+	if err := db.NewRaw(`
+		WITH RECURSIVE category_path AS (
+			SELECT p.id AS product_id, c.id AS category_id, c.parent_id, 0 AS depth
+			FROM products AS p
+			JOIN categories AS c ON c.id = p.category_id
+			WHERE p.id IN (?)
+			UNION ALL
+			SELECT cp.product_id, c.id, c.parent_id, cp.depth + 1
+			FROM category_path AS cp
+			JOIN categories AS c ON c.id = cp.parent_id
+			WHERE cp.depth < 16
+		)
+		SELECT product_id, category_id FROM category_path ORDER BY product_id, depth
+	`, bun.In(productIDs)).Scan(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		paths[row.ProductID] = append(paths[row.ProductID], row.CategoryID)
+	}
+	return paths, nil
+}
+
+func toSet(ids []uuid.UUID) map[uuid.UUID]struct{} {
+	set := make(map[uuid.UUID]struct{}, len(ids))
+	for _, id := range ids {
+		set[id] = struct{}{}
+	}
+	return set
+}
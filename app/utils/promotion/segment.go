@@ -0,0 +1,156 @@
+package promotion
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+const (
+	SegmentTier   = "tier"
+	SegmentMember = "member"
+)
+
+const segmentInsertChunkSize = 1000
+
+var ErrMemberNotInSegment = errors.New("promotion is not available for this member")
+
+type segmentRow struct {
+	PromotionID uuid.UUID `bun:"promotion_id"`
+	SegmentType string    `bun:"segment_type"`
+	TargetID    uuid.UUID `bun:"target_id"`
+}
+
+type segmentCountRow struct {
+	PromotionID uuid.UUID `bun:"promotion_id"`
+	Total       int       `bun:"total"`
+}
+
+// WhereMemberInSegment keeps the promotions whose segment includes the
+// member. alias is the promotions table alias in q; pass "?TableAlias" for
+// model queries. Every configured condition has to hold:
+//   - tier and member lists match when the member is listed;
+//   - segment_registered_within_days matches members who signed up recently;
+//   - segment_first_purchase matches members without a non-cancelled order;
+//   - segment_inactive_days matches members whose last non-cancelled order is
+//     older than that, so members who never ordered are not included.
+func WhereMemberInSegment(q *bun.SelectQuery, alias string, memberID uuid.UUID) *bun.SelectQuery {
+	listed := func(segmentType string, target string) string {
+		return fmt.Sprintf(`(NOT EXISTS (SELECT 1 FROM promotion_segments AS ps WHERE ps.promotion_id = %[1]s.id AND ps.segment_type = '%[2]s')
+			OR EXISTS (SELECT 1 FROM promotion_segments AS ps WHERE ps.promotion_id = %[1]s.id AND ps.segment_type = '%[2]s' AND ps.target_id = %[3]s))`, alias, segmentType, target)
+	}
+
+	return q.
+		Where(listed(SegmentTier, "(SELECT m.tier_id FROM members AS m WHERE m.id = ?)"), memberID).
+		Where(listed(SegmentMember, "?"), memberID).
+		Where(fmt.Sprintf(`(%[1]s.segment_registered_within_days IS NULL
+			OR EXISTS (SELECT 1 FROM members AS m WHERE m.id = ? AND COALESCE(m.registration, m.created_at) >= LOCALTIMESTAMP - make_interval(days => %[1]s.segment_registered_within_days)))`, alias), memberID).
+		Where(fmt.Sprintf(`(%[1]s.segment_first_purchase = false
+			OR NOT EXISTS (SELECT 1 FROM orders AS o WHERE o.member_id = ? AND o.status <> 'cancelled'))`, alias), memberID).
+		Where(fmt.Sprintf(`(%[1]s.segment_inactive_days IS NULL
+			OR (EXISTS (SELECT 1 FROM orders AS o WHERE o.member_id = ? AND o.status <> 'cancelled')
+				AND NOT EXISTS (SELECT 1 FROM orders AS o WHERE o.member_id = ? AND o.status <> 'cancelled' AND o.created_at > LOCALTIMESTAMP - make_interval(days => %[1]s.segment_inactive_days))))`, alias), memberID, memberID)
+}
+
+// MemberInSegment reports whether the member belongs to the promotion's
+// segment. Promotions without segment conditions include every member.
+func MemberInSegment(ctx context.Context, db bun.IDB, promotionID uuid.UUID, memberID uuid.UUID) (bool, error) {
+	q := db.NewSelect().
+		TableExpr("promotions AS p").
+		Where("p.id = ?", promotionID)
+	return WhereMemberInSegment(q, "p", memberID).Exists(ctx)
+}
+
+func LoadSegmentTiers(ctx context.Context, db bun.IDB, promotionIDs []uuid.UUID) (map[uuid.UUID][]uuid.UUID, error) {
+	tiers := make(map[uuid.UUID][]uuid.UUID, len(promotionIDs))
+	if len(promotionIDs) == 0 {
+		return tiers, nil
+	}
+
+	rows := make([]*segmentRow, 0)
+	if err := db.NewSelect().
+		TableExpr("promotion_segments").
+		Column("promotion_id", "segment_type", "target_id").
+		Where("promotion_id IN (?)", bun.In(promotionIDs)).
+		Where("segment_type = ?", SegmentTier).
+		OrderExpr("created_at ASC").
+		Scan(ctx, &rows); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		tiers[row.PromotionID] = append(tiers[row.PromotionID], row.TargetID)
+	}
+	return tiers, nil
+}
+
+func CountSegmentMembers(ctx context.Context, db bun.IDB, promotionIDs []uuid.UUID) (map[uuid.UUID]int, error) {
+	counts := make(map[uuid.UUID]int, len(promotionIDs))
+	if len(promotionIDs) == 0 {
+		return counts, nil
+	}
+
+	rows := make([]*segmentCountRow, 0)
+	if err := db.NewSelect().
+		TableExpr("promotion_segments").
+		ColumnExpr("promotion_id").
+		ColumnExpr("COUNT(*) AS total").
+		Where("promotion_id IN (?)", bun.In(promotionIDs)).
+		Where("segment_type = ?", SegmentMember).
+		GroupExpr("promotion_id").
+		Scan(ctx, &rows); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		counts[row.PromotionID] = row.Total
+	}
+	return counts, nil
+}
+
+// ReplaceSegmentInTx rewrites one segment list of a promotion.
+func ReplaceSegmentInTx(ctx context.Context, tx bun.Tx, promotionID uuid.UUID, segmentType string, ids []uuid.UUID) error {
+	if _, err := tx.NewDelete().
+		TableExpr("promotion_segments").
+		Where("promotion_id = ?", promotionID).
+		Where("segment_type = ?", segmentType).
+		Exec(ctx); err != nil {
+		return err
+	}
+
+	type segmentInsert struct {
+		bun.BaseModel `bun:"table:promotion_segments"`
+
+		ID          uuid.UUID `bun:"id,pk,type:uuid"`
+		PromotionID uuid.UUID `bun:"promotion_id,type:uuid"`
+		SegmentType string    `bun:"segment_type"`
+		TargetID    uuid.UUID `bun:"target_id,type:uuid"`
+	}
+
+	seen := make(map[uuid.UUID]struct{}, len(ids))
+	rows := make([]*segmentInsert, 0, len(ids))
+	for _, id := range ids {
+		if id == uuid.Nil {
+			continue
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		rows = append(rows, &segmentInsert{
+			ID:          uuid.New(),
+			PromotionID: promotionID,
+			SegmentType: segmentType,
+			TargetID:    id,
+		})
+	}
+
+	for start := 0; start < len(rows); start += segmentInsertChunkSize {
+		chunk := rows[start:min(start+segmentInsertChunkSize, len(rows))]
+		if _, err := tx.NewInsert().Model(&chunk).Exec(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,280 @@
+package promotion
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/uptrace/bun"
+)
+
+const (
+	SourceTier      = "tier"
+	SourcePromotion = "promotion"
+)
+
+// Policy controls which other discounts a promotion may be combined with.
+// Higher priorities are considered first when codes conflict.
+type Policy struct {
+	IsExclusive         bool `json:"is_exclusive"`
+	StackWithTier       bool `json:"stack_with_tier"`
+	StackWithPromotions bool `json:"stack_with_promotions"`
+	Priority            int  `json:"priority"`
+}
+
+// Candidate is a promotion code that passed validation and is waiting to be
+// stacked with the other discounts of the order.
+type Candidate struct {
+	PromotionID    uuid.UUID
+	CodeID         *uuid.UUID
+	Code           string
+	Policy         Policy
+	DiscountAmount decimal.Decimal
+	LineDiscounts  []decimal.Decimal
+	Rewards        []Reward
+}
+
+// Explanation records what happened to one discount source.
+type Explanation struct {
+	Source         string          `json:"source"`
+	PromotionID    *string         `json:"promotion_id,omitempty"`
+	Code           string          `json:"code,omitempty"`
+	Priority       int             `json:"priority"`
+	OriginalAmount decimal.Decimal `json:"original_amount"`
+	Amount         decimal.Decimal `json:"amount"`
+	Applied        bool            `json:"applied"`
+	Reason         string          `json:"reason,omitempty"`
+}
+
+// Settings holds the order-level discount cap. Nil values mean no limit.
+type Settings struct {
+	MaxDiscountAmount  *decimal.Decimal
+	MaxDiscountPercent *decimal.Decimal
+}
+
+type settingsRow struct {
+	MaxDiscountAmount  *decimal.Decimal `bun:"max_discount_amount"`
+	MaxDiscountPercent *decimal.Decimal `bun:"max_discount_percent"`
+}
+
+// Stack is the set of promotions chosen for an order before amounts are
+// capped.
+type Stack struct {
+	Applied      []*Candidate
+	TierAllowed  bool
+	explanations map[*Candidate]*Explanation
+	order        []*Explanation
+}
+
+type Breakdown struct {
+	TierDiscount      decimal.Decimal `json:"tier_discount"`
+	PromotionDiscount decimal.Decimal `json:"promotion_discount"`
+	TotalDiscount     decimal.Decimal `json:"total_discount"`
+	MaxDiscount       *string         `json:"max_discount"`
+	Explanations      []*Explanation  `json:"explanations"`
+}
+
+func LoadSettings(ctx context.Context, db bun.IDB) (*Settings, error) {
+	row := new(settingsRow)
+	if err := db.NewSelect().
+		TableExpr("promotion_settings").
+		Column("max_discount_amount", "max_discount_percent").
+		OrderExpr("updated_at DESC").
+		Limit(1).
+		Scan(ctx, row); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &Settings{}, nil
+		}
+		return nil, err
+	}
+	return &Settings{MaxDiscountAmount: row.MaxDiscountAmount, MaxDiscountPercent: row.MaxDiscountPercent}, nil
+}
+
+// Select picks the promotions that may be combined. Candidates are tried by
+// priority and then by discount; one that conflicts with an already chosen
+// promotion is skipped. The tier discount is allowed only when every chosen
+// promotion stacks with it.
+func Select(candidates []*Candidate) *Stack {
+	sorted := make([]*Candidate, len(candidates))
+	copy(sorted, candidates)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Policy.Priority != sorted[j].Policy.Priority {
+			return sorted[i].Policy.Priority > sorted[j].Policy.Priority
+		}
+		return sorted[i].DiscountAmount.GreaterThan(sorted[j].DiscountAmount)
+	})
+
+	stack := &Stack{
+		TierAllowed:  true,
+		explanations: make(map[*Candidate]*Explanation, len(sorted)),
+	}
+	seen := make(map[uuid.UUID]struct{}, len(sorted))
+	for _, candidate := range sorted {
+		promotionID := candidate.PromotionID.String()
+		explanation := &Explanation{
+			Source:         SourcePromotion,
+			PromotionID:    &promotionID,
+			Code:           candidate.Code,
+			Priority:       candidate.Policy.Priority,
+			OriginalAmount: candidate.DiscountAmount,
+			Amount:         decimal.Zero,
+		}
+		stack.explanations[candidate] = explanation
+		stack.order = append(stack.order, explanation)
+
+		if _, ok := seen[candidate.PromotionID]; ok {
+			explanation.Reason = "ใช้โปรโมชั่นเดียวกันซ้ำไม่ได้"
+			continue
+		}
+		if reason := stack.conflict(candidate); reason != "" {
+			explanation.Reason = reason
+			continue
+		}
+
+		seen[candidate.PromotionID] = struct{}{}
+		explanation.Applied = true
+		explanation.Amount = candidate.DiscountAmount
+		stack.Applied = append(stack.Applied, candidate)
+		if candidate.Policy.IsExclusive || !candidate.Policy.StackWithTier {
+			stack.TierAllowed = false
+		}
+	}
+
+	return stack
+}
+
+func (s *Stack) conflict(candidate *Candidate) string {
+	if len(s.Applied) == 0 {
+		return ""
+	}
+	if candidate.Policy.IsExclusive {
+		return "โปรโมชั่นนี้ใช้ได้เพียงโปรโมชั่นเดียวต่อคำสั่งซื้อ"
+	}
+	if !candidate.Policy.StackWithPromotions {
+		return "โปรโมชั่นนี้ใช้ร่วมกับโปรโมชั่นอื่นไม่ได้"
+	}
+	for _, applied := range s.Applied {
+		if applied.Policy.IsExclusive || !applied.Policy.StackWithPromotions {
+			return "ใช้ร่วมกับโปรโมชั่น " + applied.Code + " ไม่ได้"
+		}
+	}
+	return ""
+}
+
+// Apply settles the amounts of the chosen promotions. Line discounts from
+// several promotions never exceed a line's amount, promotions never exceed
+// what the tier discount leaves, and the order-level cap trims the lowest
+// priority promotions first and the tier discount last.
+func (s *Stack) Apply(orderAmount decimal.Decimal, lines []Line, tierDiscount decimal.Decimal, settings *Settings) *Breakdown {
+	tierExplanation := &Explanation{
+		Source:         SourceTier,
+		OriginalAmount: tierDiscount,
+		Amount:         decimal.Zero,
+	}
+	if tierDiscount.IsPositive() {
+		if s.TierAllowed {
+			tierExplanation.Applied = true
+			tierExplanation.Amount = tierDiscount
+		} else {
+			tierExplanation.Reason = "โปรโมชั่นที่ใช้ไม่สามารถใช้ร่วมกับส่วนลดระดับสมาชิก"
+		}
+	}
+
+	remainingLines := make([]decimal.Decimal, len(lines))
+	for i, line := range lines {
+		remainingLines[i] = line.Amount
+	}
+	remainingOrder := orderAmount.Sub(tierExplanation.Amount)
+	if remainingOrder.IsNegative() {
+		remainingOrder = decimal.Zero
+	}
+
+	for _, candidate := range s.Applied {
+		if len(candidate.LineDiscounts) > 0 {
+			total := decimal.Zero
+			for i, discount := range candidate.LineDiscounts {
+				if i >= len(remainingLines) {
+					break
+				}
+				if discount.GreaterThan(remainingLines[i]) {
+					discount = remainingLines[i]
+					candidate.LineDiscounts[i] = discount
+				}
+				remainingLines[i] = remainingLines[i].Sub(discount)
+				total = total.Add(discount)
+			}
+			candidate.DiscountAmount = total
+		}
+		if candidate.DiscountAmount.GreaterThan(remainingOrder) {
+			s.setCandidateAmount(candidate, remainingOrder)
+		}
+		remainingOrder = remainingOrder.Sub(candidate.DiscountAmount)
+	}
+
+	breakdown := &Breakdown{}
+	if limit := settings.limit(orderAmount); limit != nil {
+		value := limit.StringFixed(2)
+		breakdown.MaxDiscount = &value
+
+		excess := tierExplanation.Amount.Add(s.promotionTotal()).Sub(*limit)
+		for i := len(s.Applied) - 1; i >= 0 && excess.IsPositive(); i-- {
+			candidate := s.Applied[i]
+			cut := decimal.Min(candidate.DiscountAmount, excess)
+			if cut.IsPositive() {
+				s.setCandidateAmount(candidate, candidate.DiscountAmount.Sub(cut))
+				s.explanations[candidate].Reason = "ส่วนลดถูกจำกัดตามเพดานส่วนลดต่อคำสั่งซื้อ"
+				excess = excess.Sub(cut)
+			}
+		}
+		if excess.IsPositive() && tierExplanation.Amount.IsPositive() {
+			tierExplanation.Amount = decimal.Max(tierExplanation.Amount.Sub(excess), decimal.Zero)
+			tierExplanation.Reason = "ส่วนลดถูกจำกัดตามเพดานส่วนลดต่อคำสั่งซื้อ"
+		}
+	}
+
+	for _, candidate := range s.Applied {
+		candidate.DiscountAmount = candidate.DiscountAmount.Round(2)
+		s.explanations[candidate].Amount = candidate.DiscountAmount
+	}
+	breakdown.TierDiscount = tierExplanation.Amount.Round(2)
+	breakdown.PromotionDiscount = s.promotionTotal().Round(2)
+	breakdown.TotalDiscount = breakdown.TierDiscount.Add(breakdown.PromotionDiscount)
+	breakdown.Explanations = append([]*Explanation{tierExplanation}, s.order...)
+	return breakdown
+}
+
+func (s *Stack) setCandidateAmount(candidate *Candidate, amount decimal.Decimal) {
+	if len(candidate.LineDiscounts) > 0 {
+		candidate.LineDiscounts = ScaleDiscounts(candidate.LineDiscounts, amount)
+	}
+	candidate.DiscountAmount = amount
+}
+
+func (s *Stack) promotionTotal() decimal.Decimal {
+	total := decimal.Zero
+	for _, candidate := range s.Applied {
+		total = total.Add(candidate.DiscountAmount)
+	}
+	return total
+}
+
+func (s *Settings) limit(orderAmount decimal.Decimal) *decimal.Decimal {
+	if s == nil {
+		return nil
+	}
+	var limit *decimal.Decimal
+	if s.MaxDiscountAmount != nil {
+		value := *s.MaxDiscountAmount
+		limit = &value
+	}
+	if s.MaxDiscountPercent != nil {
+		value := orderAmount.Mul(*s.MaxDiscountPercent).Div(hundred).Round(2)
+		if limit == nil || value.LessThan(*limit) {
+			limit = &value
+		}
+	}
+	return limit
+}
@@ -0,0 +1,409 @@
+package promotion
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	promotionX = uuid.MustParse("00000000-0000-0000-0000-000000000101")
+	promotionY = uuid.MustParse("00000000-0000-0000-0000-000000000102")
+	promotionZ = uuid.MustParse("00000000-0000-0000-0000-000000000103")
+)
+
+var stackable = Policy{StackWithTier: true, StackWithPromotions: true}
+
+func candidate(promotionID uuid.UUID, code string, policy Policy, amount string, lineDiscounts ...string) *Candidate {
+	return &Candidate{
+		PromotionID:    promotionID,
+		Code:           code,
+		Policy:         policy,
+		DiscountAmount: dec(amount),
+		LineDiscounts:  decs(lineDiscounts...),
+	}
+}
+
+func withPriority(policy Policy, priority int) Policy {
+	policy.Priority = priority
+	return policy
+}
+
+func appliedCodes(stack *Stack) []string {
+	codes := make([]string, 0, len(stack.Applied))
+	for _, applied := range stack.Applied {
+		codes = append(codes, applied.Code)
+	}
+	return codes
+}
+
+func equalStrings(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSelect(t *testing.T) {
+	tests := []struct {
+		name            string
+		candidates      []*Candidate
+		wantApplied     []string
+		wantTierAllowed bool
+		wantReasons     map[string]string
+	}{
+		{
+			name:            "nothing to stack",
+			candidates:      nil,
+			wantApplied:     []string{},
+			wantTierAllowed: true,
+		},
+		{
+			name: "higher priority goes first",
+			candidates: []*Candidate{
+				candidate(promotionX, "LOW", withPriority(stackable, 1), "500"),
+				candidate(promotionY, "HIGH", withPriority(stackable, 5), "10"),
+			},
+			wantApplied:     []string{"HIGH", "LOW"},
+			wantTierAllowed: true,
+		},
+		{
+			name: "larger discount breaks a priority tie",
+			candidates: []*Candidate{
+				candidate(promotionX, "SMALL", stackable, "10"),
+				candidate(promotionY, "LARGE", stackable, "20"),
+			},
+			wantApplied:     []string{"LARGE", "SMALL"},
+			wantTierAllowed: true,
+		},
+		{
+			name: "exclusive promotion after another is skipped",
+			candidates: []*Candidate{
+				candidate(promotionX, "FIRST", withPriority(stackable, 2), "10"),
+				candidate(promotionY, "ONLY", Policy{IsExclusive: true, Priority: 1}, "50"),
+			},
+			wantApplied:     []string{"FIRST"},
+			wantTierAllowed: true,
+			wantReasons:     map[string]string{"ONLY": "โปรโมชั่นนี้ใช้ได้เพียงโปรโมชั่นเดียวต่อคำสั่งซื้อ"},
+		},
+		{
+			name: "exclusive promotion blocks the rest and the tier discount",
+			candidates: []*Candidate{
+				candidate(promotionX, "ONLY", Policy{IsExclusive: true, Priority: 2}, "10"),
+				candidate(promotionY, "OTHER", withPriority(stackable, 1), "50"),
+			},
+			wantApplied:     []string{"ONLY"},
+			wantTierAllowed: false,
+			wantReasons:     map[string]string{"OTHER": "ใช้ร่วมกับโปรโมชั่น ONLY ไม่ได้"},
+		},
+		{
+			name: "promotion that does not stack with promotions is skipped",
+			candidates: []*Candidate{
+				candidate(promotionX, "FIRST", withPriority(stackable, 2), "10"),
+				candidate(promotionY, "ALONE", Policy{StackWithTier: true, Priority: 1}, "50"),
+			},
+			wantApplied:     []string{"FIRST"},
+			wantTierAllowed: true,
+			wantReasons:     map[string]string{"ALONE": "โปรโมชั่นนี้ใช้ร่วมกับโปรโมชั่นอื่นไม่ได้"},
+		},
+		{
+			name: "the same promotion is applied once",
+			candidates: []*Candidate{
+				candidate(promotionX, "CODE-1", stackable, "20"),
+				candidate(promotionX, "CODE-2", stackable, "10"),
+			},
+			wantApplied:     []string{"CODE-1"},
+			wantTierAllowed: true,
+			wantReasons:     map[string]string{"CODE-2": "ใช้โปรโมชั่นเดียวกันซ้ำไม่ได้"},
+		},
+		{
+			name: "promotion that does not stack with tier turns the tier off",
+			candidates: []*Candidate{
+				candidate(promotionX, "A", stackable, "20"),
+				candidate(promotionY, "B", Policy{StackWithPromotions: true}, "10"),
+			},
+			wantApplied:     []string{"A", "B"},
+			wantTierAllowed: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Select(tt.candidates)
+			if codes := appliedCodes(got); !equalStrings(codes, tt.wantApplied) {
+				t.Errorf("Select() applied = %v, want %v", codes, tt.wantApplied)
+			}
+			if got.TierAllowed != tt.wantTierAllowed {
+				t.Errorf("Select() tier allowed = %v, want %v", got.TierAllowed, tt.wantTierAllowed)
+			}
+			if len(got.order) != len(tt.candidates) {
+				t.Errorf("Select() explained %d candidates, want %d", len(got.order), len(tt.candidates))
+			}
+			for _, explanation := range got.order {
+				want := tt.wantReasons[explanation.Code]
+				if explanation.Reason != want {
+					t.Errorf("Select() reason for %s = %q, want %q", explanation.Code, explanation.Reason, want)
+				}
+				if explanation.Applied != (want == "") {
+					t.Errorf("Select() applied for %s = %v, want %v", explanation.Code, explanation.Applied, want == "")
+				}
+			}
+		})
+	}
+}
+
+func TestStackApply(t *testing.T) {
+	type want struct {
+		tier        string
+		promotion   string
+		total       string
+		maxDiscount *string
+		amounts     map[string]string
+		lines       map[string][]decimal.Decimal
+		tierReason  string
+		reasons     map[string]string
+	}
+	maxOf := func(value string) *string { return &value }
+	capReason := "ส่วนลดถูกจำกัดตามเพดานส่วนลดต่อคำสั่งซื้อ"
+
+	tests := []struct {
+		name         string
+		candidates   []*Candidate
+		orderAmount  string
+		lines        []Line
+		tierDiscount string
+		settings     *Settings
+		want         want
+	}{
+		{
+			name: "no cap keeps every discount",
+			candidates: []*Candidate{
+				candidate(promotionX, "A", withPriority(stackable, 2), "100"),
+				candidate(promotionY, "B", withPriority(stackable, 1), "50"),
+			},
+			orderAmount:  "1000",
+			tierDiscount: "30",
+			want: want{
+				tier:      "30",
+				promotion: "150",
+				total:     "180",
+				amounts:   map[string]string{"A": "100", "B": "50"},
+			},
+		},
+		{
+			name: "tier discount is dropped when a promotion does not stack with it",
+			candidates: []*Candidate{
+				candidate(promotionX, "A", Policy{StackWithPromotions: true}, "100"),
+			},
+			orderAmount:  "1000",
+			tierDiscount: "30",
+			want: want{
+				tier:       "0",
+				promotion:  "100",
+				total:      "100",
+				amounts:    map[string]string{"A": "100"},
+				tierReason: "โปรโมชั่นที่ใช้ไม่สามารถใช้ร่วมกับส่วนลดระดับสมาชิก",
+			},
+		},
+		{
+			name: "promotions only get what the tier discount leaves",
+			candidates: []*Candidate{
+				candidate(promotionX, "A", withPriority(stackable, 2), "50"),
+				candidate(promotionY, "B", withPriority(stackable, 1), "50"),
+			},
+			orderAmount:  "100",
+			tierDiscount: "80",
+			want: want{
+				tier:      "80",
+				promotion: "20",
+				total:     "100",
+				amounts:   map[string]string{"A": "20", "B": "0"},
+			},
+		},
+		{
+			name: "line discounts of stacked promotions are clamped to the line",
+			candidates: []*Candidate{
+				candidate(promotionX, "A", withPriority(stackable, 2), "70", "70", "0"),
+				candidate(promotionY, "B", withPriority(stackable, 1), "60", "50", "10"),
+			},
+			orderAmount: "200",
+			lines: []Line{
+				{ProductID: productA, Quantity: 1, Amount: dec("100")},
+				{ProductID: productB, Quantity: 1, Amount: dec("100")},
+			},
+			tierDiscount: "0",
+			want: want{
+				tier:      "0",
+				promotion: "110",
+				total:     "110",
+				amounts:   map[string]string{"A": "70", "B": "40"},
+				lines: map[string][]decimal.Decimal{
+					"A": decs("70", "0"),
+					"B": decs("30", "10"),
+				},
+			},
+		},
+		{
+			name: "amount cap trims the lowest priority promotion first",
+			candidates: []*Candidate{
+				candidate(promotionX, "A", withPriority(stackable, 2), "100"),
+				candidate(promotionY, "B", withPriority(stackable, 1), "100"),
+			},
+			orderAmount:  "1000",
+			tierDiscount: "50",
+			settings:     &Settings{MaxDiscountAmount: decPtr("180")},
+			want: want{
+				tier:        "50",
+				promotion:   "130",
+				total:       "180",
+				maxDiscount: maxOf("180.00"),
+				amounts:     map[string]string{"A": "100", "B": "30"},
+				reasons:     map[string]string{"B": capReason},
+			},
+		},
+		{
+			name: "the lower of the amount and percent caps wins",
+			candidates: []*Candidate{
+				candidate(promotionX, "A", stackable, "100"),
+			},
+			orderAmount:  "1000",
+			tierDiscount: "50",
+			settings:     &Settings{MaxDiscountAmount: decPtr("500"), MaxDiscountPercent: decPtr("10")},
+			want: want{
+				tier:        "50",
+				promotion:   "50",
+				total:       "100",
+				maxDiscount: maxOf("100.00"),
+				amounts:     map[string]string{"A": "50"},
+				reasons:     map[string]string{"A": capReason},
+			},
+		},
+		{
+			name: "the cap reaches the tier discount last",
+			candidates: []*Candidate{
+				candidate(promotionX, "A", stackable, "100"),
+			},
+			orderAmount:  "1000",
+			tierDiscount: "150",
+			settings:     &Settings{MaxDiscountAmount: decPtr("120")},
+			want: want{
+				tier:        "120",
+				promotion:   "0",
+				total:       "120",
+				maxDiscount: maxOf("120.00"),
+				amounts:     map[string]string{"A": "0"},
+				tierReason:  capReason,
+				reasons:     map[string]string{"A": capReason},
+			},
+		},
+		{
+			name: "capped line discounts keep their proportions",
+			candidates: []*Candidate{
+				candidate(promotionX, "A", stackable, "60", "40", "20"),
+			},
+			orderAmount: "200",
+			lines: []Line{
+				{ProductID: productA, Quantity: 1, Amount: dec("100")},
+				{ProductID: productB, Quantity: 1, Amount: dec("100")},
+			},
+			tierDiscount: "0",
+			settings:     &Settings{MaxDiscountAmount: decPtr("30")},
+			want: want{
+				tier:        "0",
+				promotion:   "30",
+				total:       "30",
+				maxDiscount: maxOf("30.00"),
+				amounts:     map[string]string{"A": "30"},
+				lines:       map[string][]decimal.Decimal{"A": decs("20", "10")},
+				reasons:     map[string]string{"A": capReason},
+			},
+		},
+		{
+			name: "a cap above the total changes nothing",
+			candidates: []*Candidate{
+				candidate(promotionZ, "A", stackable, "10"),
+			},
+			orderAmount:  "1000",
+			tierDiscount: "10",
+			settings:     &Settings{MaxDiscountAmount: decPtr("500")},
+			want: want{
+				tier:        "10",
+				promotion:   "10",
+				total:       "20",
+				maxDiscount: maxOf("500.00"),
+				amounts:     map[string]string{"A": "10"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stack := Select(tt.candidates)
+			got := stack.Apply(dec(tt.orderAmount), tt.lines, dec(tt.tierDiscount), tt.settings)
+
+			if !got.TierDiscount.Equal(dec(tt.want.tier)) {
+				t.Errorf("Apply() tier discount = %v, want %v", got.TierDiscount, tt.want.tier)
+			}
+			if !got.PromotionDiscount.Equal(dec(tt.want.promotion)) {
+				t.Errorf("Apply() promotion discount = %v, want %v", got.PromotionDiscount, tt.want.promotion)
+			}
+			if !got.TotalDiscount.Equal(dec(tt.want.total)) {
+				t.Errorf("Apply() total discount = %v, want %v", got.TotalDiscount, tt.want.total)
+			}
+			switch {
+			case tt.want.maxDiscount == nil && got.MaxDiscount != nil:
+				t.Errorf("Apply() max discount = %v, want none", *got.MaxDiscount)
+			case tt.want.maxDiscount != nil && (got.MaxDiscount == nil || *got.MaxDiscount != *tt.want.maxDiscount):
+				t.Errorf("Apply() max discount = %v, want %v", got.MaxDiscount, *tt.want.maxDiscount)
+			}
+
+			for _, applied := range stack.Applied {
+				if want, ok := tt.want.amounts[applied.Code]; ok && !applied.DiscountAmount.Equal(dec(want)) {
+					t.Errorf("Apply() %s amount = %v, want %v", applied.Code, applied.DiscountAmount, want)
+				}
+				if want, ok := tt.want.lines[applied.Code]; ok && !equalDecimals(applied.LineDiscounts, want) {
+					t.Errorf("Apply() %s line discounts = %v, want %v", applied.Code, applied.LineDiscounts, want)
+				}
+			}
+
+			tier := got.Explanations[0]
+			if tier.Source != SourceTier || tier.Reason != tt.want.tierReason {
+				t.Errorf("Apply() tier explanation = %s %q, want %s %q", tier.Source, tier.Reason, SourceTier, tt.want.tierReason)
+			}
+			for _, explanation := range got.Explanations[1:] {
+				if want := tt.want.reasons[explanation.Code]; explanation.Reason != want {
+					t.Errorf("Apply() reason for %s = %q, want %q", explanation.Code, explanation.Reason, want)
+				}
+			}
+		})
+	}
+}
+
+func TestScaleDiscounts(t *testing.T) {
+	tests := []struct {
+		name      string
+		discounts []decimal.Decimal
+		total     decimal.Decimal
+		want      []decimal.Decimal
+	}{
+		{"halved", decs("40", "20"), dec("30"), decs("20", "10")},
+		{"zero lines stay zero", decs("10", "0", "10"), dec("5"), decs("2.5", "0", "2.5")},
+		{"rounding remainder goes to the last line", decs("1", "1", "1"), dec("1"), decs("0.33", "0.33", "0.34")},
+		{"zero total", decs("40", "20"), dec("0"), decs("0", "0")},
+		{"never scales up", decs("40", "20"), dec("100"), decs("40", "20")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ScaleDiscounts(tt.discounts, tt.total); !equalDecimals(got, tt.want) {
+				t.Errorf("ScaleDiscounts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -229,6 +229,77 @@ func (c *Client) DeleteObject(ctx context.Context, bucket string, objectPath str
 	return nil
 }
 
+// GetObject downloads an object. Objects larger than maxBytes are rejected
+// rather than truncated; a non-positive maxBytes means no limit.
+func (c *Client) GetObject(ctx context.Context, bucket string, objectPath string, maxBytes int64) ([]byte, error) {
+	if !c.Enabled() {
+		return nil, fmt.Errorf("object storage is not configured")
+	}
+
+	trimmedBucket := strings.Trim(strings.TrimSpace(bucket), "/")
+	trimmedObject := strings.Trim(strings.TrimSpace(objectPath), "/")
+	if trimmedBucket == "" || trimmedObject == "" {
+		return nil, fmt.Errorf("bucket and object path are required")
+	}
+
+	target, err := c.buildTarget(trimmedBucket, trimmedObject)
+	if err != nil {
+		return nil, err
+	}
+	canonicalURI := target.canonicalURI
+	requestURL := target.requestURL
+	payloadHash := sha256Hex(nil)
+	now := time.Now().UTC()
+	dateStamp := now.Format("20060102")
+	amzDate := now.Format("20060102T150405Z")
+	host := target.host
+
+	headers := map[string]string{
+		"host":                 host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           amzDate,
+	}
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := buildCanonicalRequest(http.MethodGet, canonicalURI, "", headers, signedHeaders, payloadHash)
+	signature := c.signature(dateStamp, amzDate, canonicalRequest)
+	authorization := c.authorizationHeader(dateStamp, signedHeaders, signature)
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Host", host)
+	request.Header.Set("x-amz-content-sha256", payloadHash)
+	request.Header.Set("x-amz-date", amzDate)
+	request.Header.Set("Authorization", authorization)
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+		return nil, fmt.Errorf("object storage download failed: %s", strings.TrimSpace(string(body)))
+	}
+
+	if maxBytes <= 0 {
+		return io.ReadAll(response.Body)
+	}
+	if response.ContentLength > maxBytes {
+		return nil, fmt.Errorf("object exceeds %d bytes", maxBytes)
+	}
+	payload, err := io.ReadAll(io.LimitReader(response.Body, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(payload)) > maxBytes {
+		return nil, fmt.Errorf("object exceeds %d bytes", maxBytes)
+	}
+	return payload, nil
+}
+
 func (c *Client) PresignGetObject(bucket string, objectPath string, expires time.Duration) (string, error) {
 	if !c.Enabled() {
 		return "", fmt.Errorf("object storage is not configured")
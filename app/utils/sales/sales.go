@@ -0,0 +1,37 @@
+// Package sales decides when an order counts as a sale. Order status alone
+// is not enough: cash-on-delivery orders reach shipping before any money is
+// collected, and may still be refused at the door.
+package sales
+
+import (
+	"context"
+
+	"phakram/app/modules/entities/ent"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/schema"
+)
+
+// Paid is a boolean SQL expression that is true when the order aliased
+// orderAlias has a successful payment, either an approved transfer or cash
+// collected on delivery, and has not been cancelled. Use it in Where or as a
+// column: q.ColumnExpr("? AS paid", sales.Paid("o")).
+func Paid(orderAlias string) schema.QueryWithArgs {
+	return bun.SafeQuery(
+		"(?.status <> ? AND EXISTS (SELECT 1 FROM payments AS paid_payment WHERE paid_payment.id = ?.payment_id AND paid_payment.status = ?))",
+		bun.Ident(orderAlias),
+		ent.StatusTypeCancelled,
+		bun.Ident(orderAlias),
+		ent.PaymentTypeSuccess,
+	)
+}
+
+// IsOrderPaid reports whether the order counts as a sale.
+func IsOrderPaid(ctx context.Context, db bun.IDB, orderID uuid.UUID) (bool, error) {
+	return db.NewSelect().
+		TableExpr("orders AS o").
+		Where("o.id = ?", orderID).
+		Where("?", Paid("o")).
+		Exists(ctx)
+}
@@ -0,0 +1,37 @@
+// Package sequence hands out running numbers from document_sequences, one
+// counter per sequence type and period.
+package sequence
+
+import (
+	"context"
+	"time"
+
+	"phakram/app/modules/entities/ent"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// NextInTx claims the next number of sequenceType in period, starting at 1.
+// The row stays locked until tx ends, so concurrent callers are numbered in
+// commit order without gaps.
+func NextInTx(ctx context.Context, tx bun.Tx, sequenceType string, period string, now time.Time) (int, error) {
+	sequence := &ent.DocumentSequenceEntity{
+		ID:           uuid.New(),
+		DocumentType: sequenceType,
+		Period:       period,
+		LastNo:       1,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if _, err := tx.NewInsert().
+		Model(sequence).
+		On("CONFLICT (document_type, period) DO UPDATE").
+		Set("last_no = ds.last_no + 1").
+		Set("updated_at = EXCLUDED.updated_at").
+		Returning("last_no").
+		Exec(ctx); err != nil {
+		return 0, err
+	}
+	return sequence.LastNo, nil
+}
@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"os"
+	"time"
+)
+
+// DatabaseTimeLocation is the zone the database session runs in, used to
+// cut days and months the same way the database does. It falls back to the
+// local zone when none is configured or the configured one is unknown.
+func DatabaseTimeLocation() *time.Location {
+	keys := []string{"DATABASE_SQL__TIME_ZONE", "DATABASE_SQL__TIMEZONE", "DB_TIMEZONE"}
+	for _, key := range keys {
+		if tz := os.Getenv(key); tz != "" {
+			loc, err := time.LoadLocation(tz)
+			if err == nil {
+				return loc
+			}
+			break
+		}
+	}
+	return time.Local
+}
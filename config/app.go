@@ -2,8 +2,11 @@ package config
 
 import (
 	"phakram/app/modules/contact"
+	"phakram/app/modules/documents"
 	"phakram/app/modules/example"
 	exampletwo "phakram/app/modules/example-two"
+	productstocks "phakram/app/modules/product_stocks"
+	"phakram/app/modules/promotions"
 	"phakram/app/modules/sentry"
 	"phakram/app/modules/specs"
 	"phakram/internal/kafka"
@@ -36,6 +39,12 @@ type Config struct {
 	Log     log.Option
 	Contact contact.Config
 
+	Documents documents.Config
+
+	Promotions promotions.Config
+
+	ProductStocks productstocks.Config
+
 	Example example.Config
 
 	ExampleTwo exampletwo.Config
@@ -63,6 +72,15 @@ var App = Config{
 			Port: 587,
 		},
 	},
+	Documents: documents.Config{
+		SellerBranch: "00000",
+	},
+	Promotions: promotions.Config{
+		ExpiryReminderDays: 3,
+	},
+	ProductStocks: productstocks.Config{
+		SalesWindowDays: 30,
+	},
 
 	AppName: "go_app",
 	Port:    8081,
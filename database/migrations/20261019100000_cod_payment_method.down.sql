@@ -0,0 +1,23 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+DROP TABLE IF EXISTS order_cod_refusals;
+
+--bun:split
+
+DROP TABLE IF EXISTS cod_settings;
+
+--bun:split
+
+ALTER TABLE orders
+DROP COLUMN IF EXISTS cod_fee;
+
+--bun:split
+
+DROP INDEX IF EXISTS payments_method_idx;
+
+--bun:split
+
+ALTER TABLE payments
+DROP COLUMN IF EXISTS method;
@@ -0,0 +1,51 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+ALTER TABLE payments
+ADD COLUMN IF NOT EXISTS method varchar NOT NULL DEFAULT 'transfer';
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS payments_method_idx ON payments (method);
+
+--bun:split
+
+ALTER TABLE orders
+ADD COLUMN IF NOT EXISTS cod_fee decimal NOT NULL DEFAULT 0;
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS cod_settings (
+    id uuid PRIMARY KEY,
+    is_enabled boolean NOT NULL DEFAULT false,
+    fee_type varchar NOT NULL DEFAULT 'amount',
+    fee_value decimal NOT NULL DEFAULT 0,
+    max_fee decimal,
+    min_order_amount decimal NOT NULL DEFAULT 0,
+    max_order_amount decimal,
+    max_refused_count int NOT NULL DEFAULT 1,
+    updated_by uuid REFERENCES members (id),
+    created_at timestamp DEFAULT current_timestamp,
+    updated_at timestamp DEFAULT current_timestamp
+);
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS order_cod_refusals (
+    id uuid PRIMARY KEY,
+    order_id uuid NOT NULL UNIQUE REFERENCES orders (id) ON DELETE CASCADE,
+    member_id uuid NOT NULL REFERENCES members (id),
+    reason text,
+    recorded_by uuid REFERENCES members (id),
+    created_at timestamp DEFAULT current_timestamp,
+    updated_at timestamp DEFAULT current_timestamp
+);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS order_cod_refusals_member_id_idx ON order_cod_refusals (member_id);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS order_cod_refusals_recorded_by_idx ON order_cod_refusals (recorded_by);
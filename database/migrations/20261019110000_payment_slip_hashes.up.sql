@@ -0,0 +1,31 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS payment_slip_hashes (
+    id uuid PRIMARY KEY,
+    order_id uuid NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    payment_id uuid NOT NULL REFERENCES payments (id) ON DELETE CASCADE,
+    file_id uuid REFERENCES storages (id) ON DELETE SET NULL,
+    sha256 varchar NOT NULL,
+    perceptual_hash varchar,
+    is_flagged boolean NOT NULL DEFAULT false,
+    created_at timestamp DEFAULT current_timestamp,
+    updated_at timestamp DEFAULT current_timestamp
+);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS payment_slip_hashes_order_id_idx ON payment_slip_hashes (order_id);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS payment_slip_hashes_payment_id_idx ON payment_slip_hashes (payment_id);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS payment_slip_hashes_sha256_idx ON payment_slip_hashes (sha256);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS payment_slip_hashes_is_flagged_idx ON payment_slip_hashes (is_flagged);
@@ -0,0 +1,9 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+DROP TABLE IF EXISTS order_documents;
+
+--bun:split
+
+DROP TABLE IF EXISTS document_sequences;
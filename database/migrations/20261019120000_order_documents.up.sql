@@ -0,0 +1,51 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS document_sequences (
+    id uuid PRIMARY KEY,
+    document_type varchar NOT NULL,
+    period varchar NOT NULL,
+    last_no int NOT NULL DEFAULT 0,
+    created_at timestamp DEFAULT current_timestamp,
+    updated_at timestamp DEFAULT current_timestamp
+);
+
+--bun:split
+
+CREATE UNIQUE INDEX IF NOT EXISTS document_sequences_type_period_uidx ON document_sequences (document_type, period);
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS order_documents (
+    id uuid PRIMARY KEY,
+    order_id uuid NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    document_type varchar NOT NULL,
+    document_no varchar NOT NULL,
+    buyer_name varchar,
+    buyer_tax_id varchar,
+    buyer_branch varchar,
+    buyer_address text,
+    subtotal_amount decimal NOT NULL DEFAULT 0,
+    discount_amount decimal NOT NULL DEFAULT 0,
+    vat_base_amount decimal NOT NULL DEFAULT 0,
+    vat_amount decimal NOT NULL DEFAULT 0,
+    total_amount decimal NOT NULL DEFAULT 0,
+    file_id uuid REFERENCES storages (id) ON DELETE SET NULL,
+    issued_by uuid REFERENCES members (id),
+    issued_at timestamp DEFAULT current_timestamp,
+    created_at timestamp DEFAULT current_timestamp,
+    updated_at timestamp DEFAULT current_timestamp
+);
+
+--bun:split
+
+CREATE UNIQUE INDEX IF NOT EXISTS order_documents_document_no_uidx ON order_documents (document_no);
+
+--bun:split
+
+CREATE UNIQUE INDEX IF NOT EXISTS order_documents_order_type_uidx ON order_documents (order_id, document_type);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS order_documents_issued_at_idx ON order_documents (issued_at);
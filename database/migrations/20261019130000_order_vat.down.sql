@@ -0,0 +1,30 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+DROP INDEX IF EXISTS orders_created_at_idx;
+
+--bun:split
+
+ALTER TABLE orders
+DROP COLUMN IF EXISTS exempt_amount,
+DROP COLUMN IF EXISTS zero_rated_amount,
+DROP COLUMN IF EXISTS vat_added_amount,
+DROP COLUMN IF EXISTS vat_amount,
+DROP COLUMN IF EXISTS vat_base_amount;
+
+--bun:split
+
+ALTER TABLE order_items
+DROP COLUMN IF EXISTS vat_amount,
+DROP COLUMN IF EXISTS vat_base_amount,
+DROP COLUMN IF EXISTS discount_amount,
+DROP COLUMN IF EXISTS price_includes_vat,
+DROP COLUMN IF EXISTS vat_rate,
+DROP COLUMN IF EXISTS tax_class;
+
+--bun:split
+
+ALTER TABLE products
+DROP COLUMN IF EXISTS price_includes_vat,
+DROP COLUMN IF EXISTS tax_class;
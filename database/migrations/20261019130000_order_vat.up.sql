@@ -0,0 +1,30 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+ALTER TABLE products
+ADD COLUMN IF NOT EXISTS tax_class varchar NOT NULL DEFAULT 'vat',
+ADD COLUMN IF NOT EXISTS price_includes_vat boolean NOT NULL DEFAULT true;
+
+--bun:split
+
+ALTER TABLE order_items
+ADD COLUMN IF NOT EXISTS tax_class varchar NOT NULL DEFAULT 'vat',
+ADD COLUMN IF NOT EXISTS vat_rate decimal NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS price_includes_vat boolean NOT NULL DEFAULT true,
+ADD COLUMN IF NOT EXISTS discount_amount decimal NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS vat_base_amount decimal NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS vat_amount decimal NOT NULL DEFAULT 0;
+
+--bun:split
+
+ALTER TABLE orders
+ADD COLUMN IF NOT EXISTS vat_base_amount decimal NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS vat_amount decimal NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS vat_added_amount decimal NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS zero_rated_amount decimal NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS exempt_amount decimal NOT NULL DEFAULT 0;
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS orders_created_at_idx ON orders (created_at);
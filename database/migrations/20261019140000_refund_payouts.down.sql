@@ -0,0 +1,14 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+DROP TABLE IF EXISTS refund_payouts;
+
+--bun:split
+
+DROP TABLE IF EXISTS payout_batches;
+
+--bun:split
+
+ALTER TABLE banks
+DROP COLUMN IF EXISTS code;
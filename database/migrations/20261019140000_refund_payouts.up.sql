@@ -0,0 +1,90 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+ALTER TABLE banks
+ADD COLUMN IF NOT EXISTS code varchar;
+
+--bun:split
+
+UPDATE banks
+SET code = CASE upper(name_abb_en)
+    WHEN 'BBL' THEN '002'
+    WHEN 'KBANK' THEN '004'
+    WHEN 'KTB' THEN '006'
+    WHEN 'TTB' THEN '011'
+    WHEN 'SCB' THEN '014'
+    WHEN 'CIMBT' THEN '022'
+    WHEN 'UOBT' THEN '024'
+    WHEN 'BAY' THEN '025'
+    WHEN 'GSB' THEN '030'
+    WHEN 'GHB' THEN '033'
+    WHEN 'BAAC' THEN '034'
+    WHEN 'TISCO' THEN '067'
+    WHEN 'KKP' THEN '069'
+    WHEN 'ICBCT' THEN '070'
+    WHEN 'LHBANK' THEN '073'
+    ELSE code
+END
+WHERE code IS NULL;
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS payout_batches (
+    id uuid PRIMARY KEY,
+    batch_no varchar NOT NULL,
+    file_format varchar NOT NULL,
+    status varchar NOT NULL DEFAULT 'draft',
+    item_count int NOT NULL DEFAULT 0,
+    total_amount decimal NOT NULL DEFAULT 0,
+    transfer_reference varchar,
+    created_by uuid REFERENCES members (id),
+    approved_by uuid REFERENCES members (id),
+    approved_at timestamp,
+    exported_at timestamp,
+    completed_at timestamp,
+    created_at timestamp DEFAULT current_timestamp,
+    updated_at timestamp DEFAULT current_timestamp
+);
+
+--bun:split
+
+CREATE UNIQUE INDEX IF NOT EXISTS payout_batches_batch_no_uidx ON payout_batches (batch_no);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS payout_batches_status_idx ON payout_batches (status);
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS refund_payouts (
+    id uuid PRIMARY KEY,
+    order_id uuid NOT NULL REFERENCES orders (id),
+    member_id uuid NOT NULL REFERENCES members (id),
+    member_bank_id uuid REFERENCES member_banks (id),
+    bank_code varchar,
+    bank_name varchar,
+    account_no varchar,
+    account_name varchar,
+    amount decimal NOT NULL DEFAULT 0,
+    status varchar NOT NULL DEFAULT 'pending',
+    batch_id uuid REFERENCES payout_batches (id),
+    transfer_reference varchar,
+    transferred_at timestamp,
+    failure_reason varchar,
+    confirmed_by uuid REFERENCES members (id),
+    created_at timestamp DEFAULT current_timestamp,
+    updated_at timestamp DEFAULT current_timestamp
+);
+
+--bun:split
+
+CREATE UNIQUE INDEX IF NOT EXISTS refund_payouts_order_id_uidx ON refund_payouts (order_id);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS refund_payouts_status_idx ON refund_payouts (status);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS refund_payouts_batch_id_idx ON refund_payouts (batch_id);
@@ -0,0 +1,9 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+DROP TABLE IF EXISTS order_item_discounts;
+
+--bun:split
+
+DROP TABLE IF EXISTS promotion_targets;
@@ -0,0 +1,40 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS promotion_targets (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    promotion_id uuid NOT NULL REFERENCES promotions(id) ON DELETE CASCADE,
+    target_type varchar(20) NOT NULL,
+    target_id uuid NOT NULL,
+    created_at timestamp DEFAULT current_timestamp
+);
+
+--bun:split
+
+CREATE UNIQUE INDEX IF NOT EXISTS promotion_targets_promotion_target_uidx
+    ON promotion_targets (promotion_id, target_type, target_id);
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS order_item_discounts (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    order_id uuid NOT NULL,
+    order_item_id uuid NOT NULL,
+    promotion_id uuid NOT NULL,
+    discount_amount decimal NOT NULL DEFAULT 0,
+    created_at timestamp DEFAULT current_timestamp,
+    updated_at timestamp DEFAULT current_timestamp
+);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS order_item_discounts_order_id_idx ON order_item_discounts (order_id);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS order_item_discounts_order_item_id_idx ON order_item_discounts (order_item_id);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS order_item_discounts_promotion_id_idx ON order_item_discounts (promotion_id);
@@ -0,0 +1,19 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+ALTER TABLE order_items
+DROP COLUMN IF EXISTS promotion_id;
+
+--bun:split
+
+DROP TABLE IF EXISTS promotion_bundle_items;
+
+--bun:split
+
+ALTER TABLE promotions
+DROP COLUMN IF EXISTS bundle_price,
+DROP COLUMN IF EXISTS get_product_id,
+DROP COLUMN IF EXISTS get_discount_percent,
+DROP COLUMN IF EXISTS get_quantity,
+DROP COLUMN IF EXISTS buy_quantity;
@@ -0,0 +1,30 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+ALTER TABLE promotions
+ADD COLUMN IF NOT EXISTS buy_quantity int NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS get_quantity int NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS get_discount_percent NUMERIC(5,2) NOT NULL DEFAULT 100,
+ADD COLUMN IF NOT EXISTS get_product_id uuid,
+ADD COLUMN IF NOT EXISTS bundle_price NUMERIC(12,2) NOT NULL DEFAULT 0;
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS promotion_bundle_items (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    promotion_id uuid NOT NULL REFERENCES promotions(id) ON DELETE CASCADE,
+    product_id uuid NOT NULL,
+    quantity int NOT NULL DEFAULT 1,
+    created_at timestamp DEFAULT current_timestamp
+);
+
+--bun:split
+
+CREATE UNIQUE INDEX IF NOT EXISTS promotion_bundle_items_promotion_product_uidx
+    ON promotion_bundle_items (promotion_id, product_id);
+
+--bun:split
+
+ALTER TABLE order_items
+ADD COLUMN IF NOT EXISTS promotion_id uuid;
@@ -0,0 +1,14 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+ALTER TABLE promotion_usages
+DROP COLUMN IF EXISTS promotion_code_id;
+
+--bun:split
+
+DROP TABLE IF EXISTS promotion_codes;
+
+--bun:split
+
+DROP TABLE IF EXISTS promotion_code_batches;
@@ -0,0 +1,46 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS promotion_code_batches (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    promotion_id uuid NOT NULL REFERENCES promotions(id) ON DELETE CASCADE,
+    prefix varchar(20) NOT NULL DEFAULT '',
+    alphabet varchar(64) NOT NULL,
+    code_length int NOT NULL,
+    quantity int NOT NULL,
+    created_by uuid,
+    created_at timestamp DEFAULT current_timestamp
+);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS promotion_code_batches_promotion_id_idx ON promotion_code_batches (promotion_id);
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS promotion_codes (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    promotion_id uuid NOT NULL REFERENCES promotions(id) ON DELETE CASCADE,
+    batch_id uuid NOT NULL REFERENCES promotion_code_batches(id) ON DELETE CASCADE,
+    code varchar(60) NOT NULL,
+    status varchar(20) NOT NULL DEFAULT 'available',
+    redeemed_by uuid,
+    order_id uuid,
+    redeemed_at timestamp,
+    created_at timestamp DEFAULT current_timestamp,
+    updated_at timestamp DEFAULT current_timestamp
+);
+
+--bun:split
+
+CREATE UNIQUE INDEX IF NOT EXISTS promotion_codes_code_uidx ON promotion_codes (code);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS promotion_codes_batch_id_status_idx ON promotion_codes (batch_id, status);
+
+--bun:split
+
+ALTER TABLE promotion_usages
+ADD COLUMN IF NOT EXISTS promotion_code_id uuid;
@@ -0,0 +1,13 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+DROP TABLE IF EXISTS promotion_settings;
+
+--bun:split
+
+ALTER TABLE promotions
+DROP COLUMN IF EXISTS priority,
+DROP COLUMN IF EXISTS stack_with_promotions,
+DROP COLUMN IF EXISTS stack_with_tier,
+DROP COLUMN IF EXISTS is_exclusive;
@@ -0,0 +1,20 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+ALTER TABLE promotions
+ADD COLUMN IF NOT EXISTS is_exclusive boolean NOT NULL DEFAULT false,
+ADD COLUMN IF NOT EXISTS stack_with_tier boolean NOT NULL DEFAULT true,
+ADD COLUMN IF NOT EXISTS stack_with_promotions boolean NOT NULL DEFAULT false,
+ADD COLUMN IF NOT EXISTS priority int NOT NULL DEFAULT 0;
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS promotion_settings (
+    id uuid PRIMARY KEY,
+    max_discount_amount decimal,
+    max_discount_percent decimal,
+    updated_by uuid REFERENCES members (id),
+    created_at timestamp DEFAULT current_timestamp,
+    updated_at timestamp DEFAULT current_timestamp
+);
@@ -0,0 +1,12 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+DROP TABLE IF EXISTS promotion_segments;
+
+--bun:split
+
+ALTER TABLE promotions
+DROP COLUMN IF EXISTS segment_inactive_days,
+DROP COLUMN IF EXISTS segment_first_purchase,
+DROP COLUMN IF EXISTS segment_registered_within_days;
@@ -0,0 +1,23 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+ALTER TABLE promotions
+ADD COLUMN IF NOT EXISTS segment_registered_within_days int,
+ADD COLUMN IF NOT EXISTS segment_first_purchase boolean NOT NULL DEFAULT false,
+ADD COLUMN IF NOT EXISTS segment_inactive_days int;
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS promotion_segments (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    promotion_id uuid NOT NULL REFERENCES promotions(id) ON DELETE CASCADE,
+    segment_type varchar(20) NOT NULL,
+    target_id uuid NOT NULL,
+    created_at timestamp DEFAULT current_timestamp
+);
+
+--bun:split
+
+CREATE UNIQUE INDEX IF NOT EXISTS promotion_segments_promotion_target_uidx
+    ON promotion_segments (promotion_id, segment_type, target_id);
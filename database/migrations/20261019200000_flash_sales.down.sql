@@ -0,0 +1,18 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+ALTER TABLE order_items
+DROP COLUMN IF EXISTS flash_sale_item_id;
+
+--bun:split
+
+DROP TABLE IF EXISTS flash_sale_purchases;
+
+--bun:split
+
+DROP TABLE IF EXISTS flash_sale_items;
+
+--bun:split
+
+DROP TABLE IF EXISTS flash_sales;
@@ -0,0 +1,71 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS flash_sales (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name varchar(255) NOT NULL,
+    starts_at timestamp NOT NULL,
+    ends_at timestamp NOT NULL,
+    is_active boolean NOT NULL DEFAULT true,
+    created_by uuid,
+    created_at timestamp DEFAULT current_timestamp,
+    updated_at timestamp DEFAULT current_timestamp
+);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS flash_sales_period_idx
+    ON flash_sales (starts_at, ends_at);
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS flash_sale_items (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    flash_sale_id uuid NOT NULL REFERENCES flash_sales(id) ON DELETE CASCADE,
+    product_id uuid NOT NULL REFERENCES products(id),
+    sale_price decimal(12,2) NOT NULL,
+    quantity_cap int NOT NULL,
+    sold_quantity int NOT NULL DEFAULT 0,
+    per_member_limit int,
+    created_at timestamp DEFAULT current_timestamp,
+    updated_at timestamp DEFAULT current_timestamp,
+    CONSTRAINT flash_sale_items_sold_quantity_check CHECK (sold_quantity >= 0 AND sold_quantity <= quantity_cap)
+);
+
+--bun:split
+
+CREATE UNIQUE INDEX IF NOT EXISTS flash_sale_items_sale_product_uidx
+    ON flash_sale_items (flash_sale_id, product_id);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS flash_sale_items_product_id_idx
+    ON flash_sale_items (product_id);
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS flash_sale_purchases (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    flash_sale_item_id uuid NOT NULL REFERENCES flash_sale_items(id),
+    member_id uuid NOT NULL,
+    order_id uuid NOT NULL,
+    quantity int NOT NULL,
+    released_at timestamp,
+    created_at timestamp DEFAULT current_timestamp
+);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS flash_sale_purchases_item_member_idx
+    ON flash_sale_purchases (flash_sale_item_id, member_id);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS flash_sale_purchases_order_id_idx
+    ON flash_sale_purchases (order_id);
+
+--bun:split
+
+ALTER TABLE order_items
+ADD COLUMN IF NOT EXISTS flash_sale_item_id uuid;
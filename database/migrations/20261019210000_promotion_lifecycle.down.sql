@@ -0,0 +1,19 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+DROP TABLE IF EXISTS promotion_events;
+
+--bun:split
+
+ALTER TABLE member_promotion_collections
+DROP COLUMN IF EXISTS expiry_reminded_at;
+
+--bun:split
+
+DROP INDEX IF EXISTS promotions_lifecycle_status_idx;
+
+--bun:split
+
+ALTER TABLE promotions
+DROP COLUMN IF EXISTS lifecycle_status;
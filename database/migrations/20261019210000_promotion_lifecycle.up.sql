@@ -0,0 +1,47 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+ALTER TABLE promotions
+ADD COLUMN IF NOT EXISTS lifecycle_status varchar(20) NOT NULL DEFAULT 'active';
+
+--bun:split
+
+UPDATE promotions
+SET lifecycle_status = CASE
+    WHEN ends_at IS NOT NULL AND ends_at <= (now() AT TIME ZONE 'utc') THEN 'expired'
+    WHEN starts_at IS NOT NULL AND starts_at > (now() AT TIME ZONE 'utc') THEN 'scheduled'
+    ELSE 'active'
+END;
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS promotions_lifecycle_status_idx
+    ON promotions (lifecycle_status);
+
+--bun:split
+
+ALTER TABLE member_promotion_collections
+ADD COLUMN IF NOT EXISTS expiry_reminded_at timestamp;
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS promotion_events (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    promotion_id uuid NOT NULL REFERENCES promotions(id) ON DELETE CASCADE,
+    event_type varchar(40) NOT NULL,
+    member_id uuid,
+    order_id uuid,
+    detail text,
+    created_at timestamp DEFAULT current_timestamp
+);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS promotion_events_promotion_type_idx
+    ON promotion_events (promotion_id, event_type, created_at);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS promotion_events_type_created_at_idx
+    ON promotion_events (event_type, created_at);
@@ -0,0 +1,10 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+ALTER TABLE order_items
+DROP COLUMN IF EXISTS product_price_id;
+
+--bun:split
+
+DROP TABLE IF EXISTS product_prices;
@@ -0,0 +1,30 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS product_prices (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    product_id uuid NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    tier_id uuid REFERENCES tiers(id) ON DELETE CASCADE,
+    min_quantity int NOT NULL DEFAULT 1,
+    price decimal(12,2) NOT NULL,
+    created_at timestamp DEFAULT current_timestamp,
+    updated_at timestamp DEFAULT current_timestamp,
+    CONSTRAINT product_prices_min_quantity_check CHECK (min_quantity >= 1),
+    CONSTRAINT product_prices_price_check CHECK (price >= 0)
+);
+
+--bun:split
+
+CREATE UNIQUE INDEX IF NOT EXISTS product_prices_product_tier_quantity_uidx
+    ON product_prices (product_id, COALESCE(tier_id, '00000000-0000-0000-0000-000000000000'::uuid), min_quantity);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS product_prices_tier_id_idx
+    ON product_prices (tier_id);
+
+--bun:split
+
+ALTER TABLE order_items
+ADD COLUMN IF NOT EXISTS product_price_id uuid REFERENCES product_prices(id) ON DELETE SET NULL;
@@ -0,0 +1,14 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+DROP INDEX IF EXISTS storages_file_path_md5_idx;
+
+--bun:split
+
+ALTER TABLE storages
+DROP COLUMN IF EXISTS renditions;
+
+--bun:split
+
+-- Enum values cannot be easily removed without recreating the type.
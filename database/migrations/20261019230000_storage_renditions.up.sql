@@ -0,0 +1,19 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+ALTER TYPE related_entity_enum ADD VALUE IF NOT EXISTS 'REVIEW_FILE';
+
+--bun:split
+
+ALTER TYPE related_entity_enum ADD VALUE IF NOT EXISTS 'SYSTEM_BANK_FILE';
+
+--bun:split
+
+ALTER TABLE storages
+ADD COLUMN IF NOT EXISTS renditions jsonb;
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS storages_file_path_md5_idx
+ON storages (md5(file_path));
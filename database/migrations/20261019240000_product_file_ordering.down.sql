@@ -0,0 +1,18 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+DROP INDEX IF EXISTS product_files_product_sort_idx;
+
+--bun:split
+
+DROP INDEX IF EXISTS product_files_primary_uidx;
+
+--bun:split
+
+ALTER TABLE product_files
+DROP COLUMN IF EXISTS variant_key,
+DROP COLUMN IF EXISTS alt_text_en,
+DROP COLUMN IF EXISTS alt_text_th,
+DROP COLUMN IF EXISTS is_primary,
+DROP COLUMN IF EXISTS sort_order;
@@ -0,0 +1,37 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+ALTER TABLE product_files
+ADD COLUMN IF NOT EXISTS sort_order integer NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS is_primary boolean NOT NULL DEFAULT false,
+ADD COLUMN IF NOT EXISTS alt_text_th varchar NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS alt_text_en varchar NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS variant_key varchar(64);
+
+--bun:split
+
+WITH ordered AS (
+	SELECT
+		id,
+		ROW_NUMBER() OVER (PARTITION BY product_id ORDER BY created_at ASC, id ASC) - 1 AS position
+	FROM product_files
+	WHERE deleted_at IS NULL
+)
+UPDATE product_files AS pf
+SET sort_order = ordered.position,
+	is_primary = ordered.position = 0
+FROM ordered
+WHERE pf.id = ordered.id;
+
+--bun:split
+
+CREATE UNIQUE INDEX IF NOT EXISTS product_files_primary_uidx
+ON product_files (product_id)
+WHERE is_primary = true AND deleted_at IS NULL;
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS product_files_product_sort_idx
+ON product_files (product_id, sort_order)
+WHERE deleted_at IS NULL;
@@ -0,0 +1,20 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+DROP INDEX IF EXISTS products_published_unpublish_at_idx;
+
+--bun:split
+
+DROP INDEX IF EXISTS products_scheduled_publish_at_idx;
+
+--bun:split
+
+DROP INDEX IF EXISTS products_status_idx;
+
+--bun:split
+
+ALTER TABLE products
+DROP COLUMN IF EXISTS unpublish_at,
+DROP COLUMN IF EXISTS publish_at,
+DROP COLUMN IF EXISTS status;
@@ -0,0 +1,32 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+ALTER TABLE products
+ADD COLUMN IF NOT EXISTS status varchar(20) NOT NULL DEFAULT 'published',
+ADD COLUMN IF NOT EXISTS publish_at timestamp,
+ADD COLUMN IF NOT EXISTS unpublish_at timestamp;
+
+--bun:split
+
+UPDATE products
+SET status = CASE WHEN is_active THEN 'published' ELSE 'draft' END,
+    publish_at = CASE WHEN is_active THEN created_at ELSE NULL END;
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS products_status_idx
+    ON products (status)
+    WHERE deleted_at IS NULL;
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS products_scheduled_publish_at_idx
+    ON products (publish_at)
+    WHERE status = 'scheduled' AND deleted_at IS NULL;
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS products_published_unpublish_at_idx
+    ON products (unpublish_at)
+    WHERE status = 'published' AND unpublish_at IS NOT NULL AND deleted_at IS NULL;
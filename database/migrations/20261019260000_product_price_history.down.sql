@@ -0,0 +1,9 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+DROP TABLE IF EXISTS product_price_history;
+
+--bun:split
+
+DROP TABLE IF EXISTS product_price_schedules;
@@ -0,0 +1,56 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS product_price_schedules (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    product_id uuid NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    price decimal(12,2) NOT NULL,
+    effective_at timestamp NOT NULL,
+    status varchar(20) NOT NULL DEFAULT 'pending',
+    applied_at timestamp,
+    created_by uuid REFERENCES members(id) ON DELETE SET NULL,
+    created_at timestamp DEFAULT current_timestamp,
+    updated_at timestamp DEFAULT current_timestamp,
+    CONSTRAINT product_price_schedules_price_check CHECK (price >= 0)
+);
+
+--bun:split
+
+CREATE UNIQUE INDEX IF NOT EXISTS product_price_schedules_pending_uidx
+    ON product_price_schedules (product_id, effective_at)
+    WHERE status = 'pending';
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS product_price_schedules_due_idx
+    ON product_price_schedules (effective_at)
+    WHERE status = 'pending';
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS product_price_history (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    product_id uuid NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    price decimal NOT NULL,
+    previous_price decimal,
+    source varchar(20) NOT NULL,
+    schedule_id uuid REFERENCES product_price_schedules(id) ON DELETE SET NULL,
+    changed_by uuid REFERENCES members(id) ON DELETE SET NULL,
+    effective_at timestamp NOT NULL,
+    created_at timestamp DEFAULT current_timestamp
+);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS product_price_history_product_effective_idx
+    ON product_price_history (product_id, effective_at);
+
+--bun:split
+
+INSERT INTO product_price_history (product_id, price, source, effective_at)
+SELECT p.id, p.price, 'initial', COALESCE(p.created_at, now() AT TIME ZONE 'utc')
+FROM products AS p
+WHERE NOT EXISTS (
+    SELECT 1 FROM product_price_history AS h WHERE h.product_id = p.id
+);
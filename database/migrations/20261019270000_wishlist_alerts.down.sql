@@ -0,0 +1,20 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+DROP INDEX IF EXISTS member_wishlist_alerts_idx;
+
+--bun:split
+
+DROP INDEX IF EXISTS member_wishlist_alert_token_uidx;
+
+--bun:split
+
+ALTER TABLE member_wishlist
+DROP COLUMN IF EXISTS price_drop_alerted_at,
+DROP COLUMN IF EXISTS back_in_stock_alerted_at,
+DROP COLUMN IF EXISTS alert_notified_price,
+DROP COLUMN IF EXISTS alert_in_stock,
+DROP COLUMN IF EXISTS alert_token,
+DROP COLUMN IF EXISTS alert_price_drop,
+DROP COLUMN IF EXISTS alert_back_in_stock;
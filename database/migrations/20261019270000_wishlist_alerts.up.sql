@@ -0,0 +1,23 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+ALTER TABLE member_wishlist
+ADD COLUMN IF NOT EXISTS alert_back_in_stock boolean NOT NULL DEFAULT false,
+ADD COLUMN IF NOT EXISTS alert_price_drop boolean NOT NULL DEFAULT false,
+ADD COLUMN IF NOT EXISTS alert_token uuid NOT NULL DEFAULT uuid_generate_v4(),
+ADD COLUMN IF NOT EXISTS alert_in_stock boolean,
+ADD COLUMN IF NOT EXISTS alert_notified_price decimal,
+ADD COLUMN IF NOT EXISTS back_in_stock_alerted_at timestamp,
+ADD COLUMN IF NOT EXISTS price_drop_alerted_at timestamp;
+
+--bun:split
+
+CREATE UNIQUE INDEX IF NOT EXISTS member_wishlist_alert_token_uidx
+    ON member_wishlist (alert_token);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS member_wishlist_alerts_idx
+    ON member_wishlist (id)
+    WHERE alert_back_in_stock OR alert_price_drop;
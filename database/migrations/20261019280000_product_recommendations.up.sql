@@ -0,0 +1,19 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS product_recommendations (
+    product_id uuid NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    related_product_id uuid NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    kind varchar(20) NOT NULL,
+    score double precision NOT NULL,
+    support integer NOT NULL DEFAULT 0,
+    computed_at timestamp NOT NULL,
+    PRIMARY KEY (product_id, related_product_id, kind),
+    CONSTRAINT product_recommendations_self_check CHECK (product_id <> related_product_id)
+);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS product_recommendations_product_score_idx
+    ON product_recommendations (product_id, kind, score DESC);
@@ -0,0 +1,48 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS category_attributes (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    category_id uuid NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    code varchar(64) NOT NULL,
+    label_th varchar(255) NOT NULL,
+    label_en varchar(255) NOT NULL,
+    data_type varchar(20) NOT NULL,
+    unit varchar(32),
+    options jsonb NOT NULL DEFAULT '[]'::jsonb,
+    is_required boolean NOT NULL DEFAULT false,
+    is_filterable boolean NOT NULL DEFAULT false,
+    sort_order integer NOT NULL DEFAULT 0,
+    created_at timestamp DEFAULT current_timestamp,
+    updated_at timestamp DEFAULT current_timestamp,
+    CONSTRAINT category_attributes_data_type_check CHECK (data_type IN ('text', 'number', 'enum', 'boolean'))
+);
+
+--bun:split
+
+CREATE UNIQUE INDEX IF NOT EXISTS category_attributes_category_code_uidx
+    ON category_attributes (category_id, code);
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS product_attribute_values (
+    product_id uuid NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    attribute_id uuid NOT NULL REFERENCES category_attributes(id) ON DELETE CASCADE,
+    value_text text,
+    value_number decimal,
+    value_boolean boolean,
+    created_at timestamp DEFAULT current_timestamp,
+    updated_at timestamp DEFAULT current_timestamp,
+    PRIMARY KEY (product_id, attribute_id)
+);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS product_attribute_values_text_idx
+    ON product_attribute_values (attribute_id, value_text);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS product_attribute_values_number_idx
+    ON product_attribute_values (attribute_id, value_number);
@@ -0,0 +1,18 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+DROP INDEX IF EXISTS product_stocks_reorder_point_idx;
+
+--bun:split
+
+ALTER TABLE product_stocks
+    DROP CONSTRAINT IF EXISTS product_stocks_target_stock_check,
+    DROP CONSTRAINT IF EXISTS product_stocks_reorder_point_check;
+
+--bun:split
+
+ALTER TABLE product_stocks
+    DROP COLUMN IF EXISTS low_stock_alerted_at,
+    DROP COLUMN IF EXISTS target_stock,
+    DROP COLUMN IF EXISTS reorder_point;
@@ -0,0 +1,22 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+ALTER TABLE product_stocks
+    ADD COLUMN IF NOT EXISTS reorder_point int,
+    ADD COLUMN IF NOT EXISTS target_stock int,
+    ADD COLUMN IF NOT EXISTS low_stock_alerted_at timestamp;
+
+--bun:split
+
+ALTER TABLE product_stocks
+    ADD CONSTRAINT product_stocks_reorder_point_check CHECK (reorder_point IS NULL OR reorder_point >= 0),
+    ADD CONSTRAINT product_stocks_target_stock_check CHECK (
+        target_stock IS NULL OR (target_stock > 0 AND (reorder_point IS NULL OR target_stock > reorder_point))
+    );
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS product_stocks_reorder_point_idx
+    ON product_stocks (product_id)
+    WHERE deleted_at IS NULL AND reorder_point IS NOT NULL;
@@ -0,0 +1,13 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+DROP TABLE IF EXISTS stock_movements;
+
+--bun:split
+
+DROP TABLE IF EXISTS location_stocks;
+
+--bun:split
+
+DROP TABLE IF EXISTS stock_locations;
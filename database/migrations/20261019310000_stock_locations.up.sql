@@ -0,0 +1,94 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS stock_locations (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    code varchar(32) NOT NULL,
+    name_th varchar(255) NOT NULL,
+    name_en varchar(255) NOT NULL,
+    province_id uuid REFERENCES provinces(id) ON DELETE SET NULL,
+    priority integer NOT NULL DEFAULT 0,
+    is_default boolean NOT NULL DEFAULT false,
+    is_active boolean NOT NULL DEFAULT true,
+    created_at timestamp DEFAULT current_timestamp,
+    updated_at timestamp DEFAULT current_timestamp,
+    deleted_at timestamp
+);
+
+--bun:split
+
+CREATE UNIQUE INDEX IF NOT EXISTS stock_locations_code_uidx
+    ON stock_locations (code)
+    WHERE deleted_at IS NULL;
+
+--bun:split
+
+CREATE UNIQUE INDEX IF NOT EXISTS stock_locations_default_uidx
+    ON stock_locations (is_default)
+    WHERE is_default AND deleted_at IS NULL;
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS location_stocks (
+    location_id uuid NOT NULL REFERENCES stock_locations(id) ON DELETE CASCADE,
+    product_id uuid NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    quantity integer NOT NULL DEFAULT 0,
+    created_at timestamp DEFAULT current_timestamp,
+    updated_at timestamp DEFAULT current_timestamp,
+    PRIMARY KEY (location_id, product_id),
+    CONSTRAINT location_stocks_quantity_check CHECK (quantity >= 0)
+);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS location_stocks_product_idx ON location_stocks (product_id);
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    product_id uuid NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    from_location_id uuid REFERENCES stock_locations(id) ON DELETE SET NULL,
+    to_location_id uuid REFERENCES stock_locations(id) ON DELETE SET NULL,
+    quantity integer NOT NULL,
+    kind varchar(20) NOT NULL,
+    order_id uuid REFERENCES orders(id) ON DELETE SET NULL,
+    note text,
+    created_by uuid REFERENCES members(id) ON DELETE SET NULL,
+    created_at timestamp DEFAULT current_timestamp,
+    CONSTRAINT stock_movements_quantity_check CHECK (quantity > 0)
+);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS stock_movements_product_created_idx
+    ON stock_movements (product_id, created_at DESC);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS stock_movements_order_idx
+    ON stock_movements (order_id)
+    WHERE order_id IS NOT NULL;
+
+--bun:split
+
+INSERT INTO stock_locations (code, name_th, name_en, priority, is_default)
+SELECT 'main', 'คลังสินค้าหลัก', 'Main stock', 0, true
+WHERE NOT EXISTS (SELECT 1 FROM stock_locations WHERE deleted_at IS NULL);
+
+--bun:split
+
+INSERT INTO location_stocks (location_id, product_id, quantity)
+SELECT l.id, ps.product_id, GREATEST(ps.remaining, 0)
+FROM product_stocks AS ps
+JOIN stock_locations AS l ON l.is_default AND l.deleted_at IS NULL
+WHERE ps.deleted_at IS NULL AND ps.product_id IS NOT NULL
+ON CONFLICT (location_id, product_id) DO NOTHING;
+
+--bun:split
+
+INSERT INTO stock_movements (product_id, to_location_id, quantity, kind, note, created_at)
+SELECT ls.product_id, ls.location_id, ls.quantity, 'initial', 'opening balance', now() AT TIME ZONE 'utc'
+FROM location_stocks AS ls
+WHERE ls.quantity > 0;
@@ -0,0 +1,7 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+-- storages_file_path_idx is not recreated: it cannot be built once a
+-- data: URL is stored. storages_file_path_md5_idx belongs to the
+-- storage_renditions migration and is dropped by its down migration.
@@ -0,0 +1,12 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+-- file_path can hold whole data: URLs, which do not fit a plain B-tree
+-- entry. Lookups hash the path and use the expression index instead.
+DROP INDEX IF EXISTS storages_file_path_idx;
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS storages_file_path_md5_idx
+ON storages (md5(file_path));
@@ -0,0 +1,16 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+DELETE FROM payment_slip_hashes
+WHERE sha256 IS NULL;
+
+--bun:split
+
+ALTER TABLE payment_slip_hashes
+ALTER COLUMN sha256 SET NOT NULL;
+
+--bun:split
+
+ALTER TABLE payment_slip_hashes
+DROP COLUMN IF EXISTS is_unverified;
@@ -0,0 +1,13 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+-- Slips sent as an external URL cannot be hashed. They are kept with an
+-- empty hash and flagged so an admin checks them by hand before approving.
+ALTER TABLE payment_slip_hashes
+ADD COLUMN IF NOT EXISTS is_unverified boolean NOT NULL DEFAULT false;
+
+--bun:split
+
+ALTER TABLE payment_slip_hashes
+ALTER COLUMN sha256 DROP NOT NULL;
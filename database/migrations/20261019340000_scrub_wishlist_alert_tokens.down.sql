@@ -0,0 +1,5 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+-- Removed tokens are not written back to the audit log.
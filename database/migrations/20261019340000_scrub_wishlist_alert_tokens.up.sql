@@ -0,0 +1,10 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+-- Wishlist alerts used to copy the unsubscribe token into the audit log.
+-- The notification feed now reads it from member_wishlist.
+UPDATE audit_log
+SET action_detail = regexp_replace(action_detail, '; unsubscribe_token=[^;]*', '')
+WHERE action_type IN ('wishlist_back_in_stock', 'wishlist_price_drop')
+  AND action_detail LIKE '%unsubscribe_token=%';
@@ -103,15 +103,22 @@ func apiSystem(r *gin.RouterGroup, mod *modules.Modules) {
 			categories.POST("/", mod.Categories.Ctl.CreateCategoryController)
 			categories.PATCH("/:id", mod.Categories.Ctl.CategoriesUpdate)
 			categories.DELETE("/:id", mod.Categories.Ctl.CategoriesDelete)
+			categories.GET("/:id/attributes", mod.Categories.Ctl.ListCategoryAttributesController)
 		}
 		products := system.Group("/products")
 		{
-			products.GET("/", mod.Products.Ctl.ProductsList)
-			products.GET("/:id", mod.Products.Ctl.ProductsInfo)
+			products.GET("/", mod.Auth.Ctl.OptionalAuthMiddleware(), mod.Products.Ctl.ProductsList)
+			products.GET("/:id", mod.Auth.Ctl.OptionalAuthMiddleware(), mod.Products.Ctl.ProductsInfo)
 			products.GET("/:id/reviews", mod.Reviews.Ctl.ListProductPublicController)
 			products.GET("/:id/images", mod.Products.Ctl.ListProductImagesController)
+			products.GET("/:id/price-history", mod.Products.Ctl.PublicPriceTimelineController)
+			products.GET("/:id/attributes", mod.Products.Ctl.ProductAttributesController)
+			products.GET("/:id/related", mod.Auth.Ctl.OptionalAuthMiddleware(), mod.Products.Ctl.RelatedProductsController)
+			products.GET("/recommendations", mod.Auth.Ctl.OptionalAuthMiddleware(), mod.Products.Ctl.CartRecommendationsController)
 			products.POST("/", mod.Products.Ctl.CreateProductController)
 			products.POST("/:id/images", mod.Products.Ctl.UploadProductImageController)
+			products.PUT("/:id/images/order", mod.Products.Ctl.ReorderProductImagesController)
+			products.PATCH("/:id/images/:image_id", mod.Products.Ctl.UpdateProductImageController)
 			products.DELETE("/:id/images/:image_id", mod.Products.Ctl.DeleteProductImageController)
 			products.PATCH("/:id", mod.Products.Ctl.ProductsUpdate)
 			products.DELETE("/:id", mod.Products.Ctl.ProductsDelete)
@@ -125,6 +132,7 @@ func apiSystem(r *gin.RouterGroup, mod *modules.Modules) {
 			products.POST("/:id/stock", mod.ProductStocks.Ctl.CreateController)
 			products.PATCH("/:id/stock", mod.ProductStocks.Ctl.UpdateController)
 			products.DELETE("/:id/stock", mod.ProductStocks.Ctl.DeleteController)
+			products.GET("/:id/availability", mod.ProductStocks.Ctl.ProductAvailabilityController)
 		}
 
 		productStocks := system.Group("/product_stocks")
@@ -188,6 +196,12 @@ func apiPublic(r *gin.RouterGroup, mod *modules.Modules) {
 		{
 			members.POST("/register", mod.Members.Ctl.CreateRegisterController)
 		}
+
+		wishlistAlerts := public.Group("/wishlist-alerts")
+		{
+			wishlistAlerts.GET("/unsubscribe", mod.Members.Ctl.WishlistAlertSubscriptionController)
+			wishlistAlerts.POST("/unsubscribe", mod.Members.Ctl.UnsubscribeWishlistAlertsController)
+		}
 	}
 }
 
@@ -221,6 +235,8 @@ func apiAuth(r *gin.RouterGroup, mod *modules.Modules) {
 			promotions.GET("/my", mod.Promotions.Ctl.ListMyController)
 			promotions.GET("/report/summary", mod.Promotions.Ctl.ReportSummaryController)
 			promotions.GET("/report/usages", mod.Promotions.Ctl.ListUsagesController)
+			promotions.GET("/settings", mod.Promotions.Ctl.GetSettingController)
+			promotions.PATCH("/settings", mod.Promotions.Ctl.UpdateSettingController)
 			promotions.POST("/:id/collect", mod.Promotions.Ctl.CollectController)
 			promotions.GET("/", mod.Promotions.Ctl.ListController)
 			promotions.GET("/:id", mod.Promotions.Ctl.InfoController)
@@ -229,6 +245,60 @@ func apiAuth(r *gin.RouterGroup, mod *modules.Modules) {
 			promotions.DELETE("/:id", mod.Promotions.Ctl.DeleteController)
 			promotions.POST("/validate", mod.Promotions.Ctl.ValidateController)
 			promotions.POST("/:id/use", mod.Promotions.Ctl.UseController)
+			promotions.GET("/:id/segment-members", mod.Promotions.Ctl.ListSegmentMembersController)
+			promotions.PUT("/:id/segment-members", mod.Promotions.Ctl.ReplaceSegmentMembersController)
+			promotions.GET("/:id/code-batches", mod.Promotions.Ctl.ListCodeBatchesController)
+			promotions.POST("/:id/code-batches", mod.Promotions.Ctl.CreateCodeBatchController)
+			promotions.GET("/:id/code-batches/:batch_id/codes", mod.Promotions.Ctl.ListCodesController)
+			promotions.GET("/:id/code-batches/:batch_id/export", mod.Promotions.Ctl.ExportCodeBatchController)
+			promotions.GET("/:id/report/analytics", mod.Promotions.Ctl.AnalyticsController)
+			promotions.GET("/:id/report/analytics/export", mod.Promotions.Ctl.ExportAnalyticsController)
+		}
+
+		flashSales := auth.Group("/flash-sales")
+		{
+			flashSales.GET("/", mod.FlashSales.Ctl.ListController)
+			flashSales.GET("/:id", mod.FlashSales.Ctl.InfoController)
+			flashSales.POST("/", mod.FlashSales.Ctl.CreateController)
+			flashSales.PATCH("/:id", mod.FlashSales.Ctl.UpdateController)
+			flashSales.DELETE("/:id", mod.FlashSales.Ctl.DeleteController)
+		}
+
+		productPrices := auth.Group("/products")
+		{
+			productPrices.GET("/:id/prices", mod.Products.Ctl.ListProductPricesController)
+			productPrices.PUT("/:id/prices", mod.Products.Ctl.ReplaceProductPricesController)
+			productPrices.GET("/:id/price-history", mod.Products.Ctl.PriceTimelineController)
+			productPrices.POST("/:id/price-schedules", mod.Products.Ctl.CreatePriceScheduleController)
+			productPrices.DELETE("/:id/price-schedules/:schedule_id", mod.Products.Ctl.CancelPriceScheduleController)
+			productPrices.POST("/import", mod.Products.Ctl.ImportProductsController)
+			productPrices.GET("/export", mod.Products.Ctl.ExportProductsController)
+			productPrices.PUT("/:id/attributes", mod.Products.Ctl.ReplaceProductAttributesController)
+			productPrices.PUT("/:id/stock/thresholds", mod.ProductStocks.Ctl.UpdateStockThresholdsController)
+			productPrices.GET("/:id/stock/locations", mod.ProductStocks.Ctl.ProductStockLocationsController)
+			productPrices.POST("/:id/stock/adjustments", mod.ProductStocks.Ctl.AdjustProductStockController)
+		}
+
+		stockReports := auth.Group("/product-stocks")
+		{
+			stockReports.GET("/low-stock", mod.ProductStocks.Ctl.LowStockReportController)
+			stockReports.POST("/transfers", mod.ProductStocks.Ctl.TransferStockController)
+			stockReports.GET("/movements", mod.ProductStocks.Ctl.ListStockMovementsController)
+		}
+
+		stockLocations := auth.Group("/stock-locations")
+		{
+			stockLocations.GET("/", mod.ProductStocks.Ctl.ListStockLocationsController)
+			stockLocations.POST("/", mod.ProductStocks.Ctl.CreateStockLocationController)
+			stockLocations.PATCH("/:id", mod.ProductStocks.Ctl.UpdateStockLocationController)
+			stockLocations.DELETE("/:id", mod.ProductStocks.Ctl.DeleteStockLocationController)
+		}
+
+		categoryAttributes := auth.Group("/categories/:id/attributes")
+		{
+			categoryAttributes.POST("/", mod.Categories.Ctl.CreateCategoryAttributeController)
+			categoryAttributes.PATCH("/:attribute_id", mod.Categories.Ctl.UpdateCategoryAttributeController)
+			categoryAttributes.DELETE("/:attribute_id", mod.Categories.Ctl.DeleteCategoryAttributeController)
 		}
 
 		reviews := auth.Group("/reviews")
@@ -296,6 +366,7 @@ func apiAuth(r *gin.RouterGroup, mod *modules.Modules) {
 			wishlist.POST("/", mod.Members.Ctl.CreateMemberWishlistController)
 			wishlist.PATCH("/:wishlist_id", mod.Members.Ctl.UpdateMemberWishlistController)
 			wishlist.DELETE("/:wishlist_id", mod.Members.Ctl.DeleteMemberWishlistController)
+			wishlist.PUT("/:wishlist_id/alerts", mod.Members.Ctl.UpdateMemberWishlistAlertsController)
 		}
 
 		orders := auth.Group("/orders")
@@ -303,11 +374,22 @@ func apiAuth(r *gin.RouterGroup, mod *modules.Modules) {
 			orders.GET("/", mod.Orders.Ctl.ListOrderController)
 			orders.GET("/:id", mod.Orders.Ctl.InfoOrderController)
 			orders.GET("/:id/timeline", mod.Orders.Ctl.TimelineOrderController)
+			orders.GET("/:id/fulfilment-plan", mod.ProductStocks.Ctl.FulfilmentPlanController)
 			orders.POST("/:id/payment/confirm", mod.Orders.Ctl.ConfirmOrderPaymentController)
 			orders.PATCH("/:id/payment/appeal", mod.Orders.Ctl.AppealOrderPaymentController)
 			orders.PATCH("/:id/payment/approve", mod.Orders.Ctl.ApproveOrderPaymentController)
 			orders.PATCH("/:id/payment/reject", mod.Orders.Ctl.RejectOrderPaymentController)
+			orders.GET("/:id/payment/slip-matches", mod.Orders.Ctl.ListOrderPaymentSlipMatchesController)
+			orders.PATCH("/:id/cod/collect", mod.Orders.Ctl.CollectCODPaymentController)
+			orders.PATCH("/:id/cod/refuse", mod.Orders.Ctl.RefuseCODOrderController)
+			orders.GET("/:id/documents", mod.Documents.Ctl.ListOrderDocumentsController)
+			orders.GET("/:id/documents/:document_id", mod.Documents.Ctl.InfoOrderDocumentController)
+			orders.POST("/:id/documents/receipt", mod.Documents.Ctl.IssueReceiptController)
+			orders.POST("/:id/documents/tax-invoice", mod.Documents.Ctl.IssueTaxInvoiceController)
+			orders.GET("/:id/refund-payout", mod.Payouts.Ctl.InfoOrderRefundPayoutController)
 			orders.POST("/:id/reorder", mod.Orders.Ctl.ReorderController)
+			orders.POST("/discounts/calculate", mod.Orders.Ctl.CalculateOrderDiscountController)
+			orders.POST("/promotions/suggest", mod.Orders.Ctl.SuggestOrderPromotionsController)
 			orders.POST("/", mod.Orders.Ctl.CreateOrderController)
 			orders.PATCH("/:id", mod.Orders.Ctl.UpdateOrderController)
 			orders.DELETE("/:id", mod.Orders.Ctl.DeleteOrderController)
@@ -319,6 +401,31 @@ func apiAuth(r *gin.RouterGroup, mod *modules.Modules) {
 			orders.DELETE("/:id/items/:item_id", mod.Orders.Ctl.DeleteOrderItemController)
 		}
 
+		reports := auth.Group("/reports")
+		{
+			reports.GET("/sales-tax", mod.Orders.Ctl.SalesTaxReportController)
+		}
+
+		payouts := auth.Group("/payouts")
+		{
+			payouts.GET("/", mod.Payouts.Ctl.ListRefundPayoutsController)
+			payouts.PATCH("/:id/confirm", mod.Payouts.Ctl.ConfirmRefundPayoutController)
+			payouts.GET("/batches", mod.Payouts.Ctl.ListPayoutBatchesController)
+			payouts.GET("/batches/:id", mod.Payouts.Ctl.InfoPayoutBatchController)
+			payouts.POST("/batches", mod.Payouts.Ctl.CreatePayoutBatchController)
+			payouts.PATCH("/batches/:id/approve", mod.Payouts.Ctl.ApprovePayoutBatchController)
+			payouts.PATCH("/batches/:id/cancel", mod.Payouts.Ctl.CancelPayoutBatchController)
+			payouts.GET("/batches/:id/export", mod.Payouts.Ctl.ExportPayoutBatchController)
+			payouts.PATCH("/batches/:id/confirm", mod.Payouts.Ctl.ConfirmPayoutBatchController)
+		}
+
+		cod := auth.Group("/cod")
+		{
+			cod.GET("/settings", mod.Orders.Ctl.GetCODSettingController)
+			cod.PATCH("/settings", mod.Orders.Ctl.UpdateCODSettingController)
+			cod.GET("/eligibility", mod.Orders.Ctl.CODEligibilityController)
+		}
+
 		carts := auth.Group("/carts")
 		{
 			carts.GET("/", mod.Carts.Ctl.ListCartController)